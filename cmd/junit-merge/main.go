@@ -0,0 +1,142 @@
+// Command junit-merge merges the JUnit reports the suites of a run wrote to REPORTS_DUMP_DIR, or the report files and
+// directories given as arguments, into a single JUnit report. The test suites are prefixed with the name of their
+// report file, the test suites of the same name are merged with their properties de-duplicated, and the counts of
+// the report are computed from the test cases. It prints the pass or fail summary of every test suite and of the
+// report, optionally written as JSON for the CI dashboards, and exits with status 1 when the report failed, and 2 on
+// usage errors.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/env"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/junit"
+)
+
+const defaultReportsDir = "/tmp/reports"
+
+func main() {
+	output := flag.String("output", "nvidia-ci_merged"+junit.FileSuffix, "merged JUnit report to write")
+	summaryPath := flag.String("summary", "", "JSON summary of the merged report to write, none when empty")
+	prefix := flag.Bool("prefix", true, "prefix the test suites with the name of their report file")
+	flag.Parse()
+
+	inputs := flag.Args()
+	if len(inputs) == 0 {
+		inputs = []string{env.OrDefault("REPORTS_DUMP_DIR", defaultReportsDir)}
+	}
+
+	reportPaths, err := listReports(inputs, *output)
+	if err != nil {
+		exitUsage(err)
+	}
+
+	var files []junit.File
+
+	for _, reportPath := range reportPaths {
+		report, err := junit.Read(reportPath)
+		if err != nil {
+			exitUsage(err)
+		}
+
+		file := junit.File{Report: report}
+		if *prefix {
+			file.Prefix = junit.Prefix(reportPath)
+		}
+
+		files = append(files, file)
+	}
+
+	merged := junit.Merge(files)
+	if err := junit.Write(*output, merged); err != nil {
+		exitUsage(err)
+	}
+
+	summary := junit.Summarize(merged)
+	if err := summary.WriteText(os.Stdout); err != nil {
+		exitUsage(err)
+	}
+
+	if *summaryPath != "" {
+		if err := writeSummary(*summaryPath, summary); err != nil {
+			exitUsage(err)
+		}
+	}
+
+	if !summary.Passed {
+		os.Exit(1)
+	}
+}
+
+// listReports returns the JUnit reports of the inputs, the JUnit reports of the directories being listed in name
+// order. The merged report is left out, so that a run merges its reports again in place, and the reports are listed
+// once.
+func listReports(inputs []string, output string) ([]string, error) {
+	outputPath, err := filepath.Abs(output)
+	if err != nil {
+		return nil, err
+	}
+
+	var reportPaths []string
+
+	listed := map[string]bool{outputPath: true}
+
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return nil, err
+		}
+
+		paths := []string{input}
+
+		if info.IsDir() {
+			if paths, err = filepath.Glob(filepath.Join(input, "*"+junit.FileSuffix)); err != nil {
+				return nil, err
+			}
+
+			sort.Strings(paths)
+		}
+
+		for _, reportPath := range paths {
+			absPath, err := filepath.Abs(reportPath)
+			if err != nil {
+				return nil, err
+			}
+
+			if !listed[absPath] {
+				listed[absPath] = true
+				reportPaths = append(reportPaths, reportPath)
+			}
+		}
+	}
+
+	if len(reportPaths) == 0 {
+		return nil, fmt.Errorf("no JUnit report found in %v", inputs)
+	}
+
+	return reportPaths, nil
+}
+
+func writeSummary(summaryPath string, summary junit.Summary) error {
+	summaryFile, err := os.Create(summaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to create summary %s: %w", summaryPath, err)
+	}
+
+	defer summaryFile.Close()
+
+	if err := summary.WriteJSON(summaryFile); err != nil {
+		return fmt.Errorf("failed to write summary %s: %w", summaryPath, err)
+	}
+
+	return nil
+}
+
+func exitUsage(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
@@ -0,0 +1,151 @@
+// Command nvidia-ci discovers the suites of the tests directory and runs the selected ones with ginkgo, like the
+// test-runner script, with the reports of the suites merged to a JSON and a JUnit report of the artifact directory
+// and a summary of every suite printed at the end. The arguments after the flags are passed to ginkgo. It exits with
+// the ginkgo exit status, and 2 on usage errors. With -check-labels, it walks the specs with a ginkgo dry run, without
+// a cluster, and exits with status 1 when the labels of a spec do not follow the taxonomy.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/env"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/suiterunner"
+)
+
+const defaultArtifactDir = "/tmp/reports"
+
+func main() {
+	features := flag.String("features", env.OrDefault("TEST_FEATURES", suiterunner.AllFeatures),
+		"space or comma separated suite directories to run, all of them with \"all\"")
+	labels := flag.String("labels", os.Getenv("TEST_LABELS"), "ginkgo label filter of the specs to run")
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML or JSON config file of the suites")
+	artifactDir := flag.String("artifact-dir", env.OrDefault("ARTIFACT_DIR", defaultArtifactDir),
+		"directory of the reports and artifacts")
+	timeout := flag.Duration("timeout", 24*time.Hour, "ginkgo timeout of the whole run")
+	procs := flag.Int("procs", 0, "parallel ginkgo processes of each suite, serial below 2")
+	verbose := flag.Bool("verbose", os.Getenv("TEST_VERBOSE") == "true", "verbose ginkgo output")
+	list := flag.Bool("list", false, "list the suites matching the features and exit")
+	checkLabels := flag.Bool("check-labels", false,
+		"walk the specs without running them and check that their labels follow the taxonomy")
+	testsDir := flag.String("tests-dir", "./tests", "directory of the suites")
+	ginkgoPath := flag.String("ginkgo", "ginkgo", "ginkgo binary, looked up in PATH and GOPATH/bin")
+	flag.Parse()
+
+	suites, err := suiterunner.Discover(*testsDir, strings.FieldsFunc(*features, func(r rune) bool {
+		return r == ' ' || r == ','
+	}))
+	if err != nil {
+		exitUsage(err)
+	}
+
+	if *list {
+		for _, suite := range suites {
+			fmt.Printf("%s\t%s\n", suite.Feature, suite.Dir)
+		}
+
+		return
+	}
+
+	ginkgoBinary, err := lookGinkgo(*ginkgoPath)
+	if err != nil {
+		exitUsage(err)
+	}
+
+	absArtifactDir, err := filepath.Abs(*artifactDir)
+	if err != nil {
+		exitUsage(err)
+	}
+
+	if err := os.MkdirAll(absArtifactDir, 0755); err != nil {
+		exitUsage(err)
+	}
+
+	scriptsDir, _ := filepath.Abs("scripts")
+
+	command := suiterunner.Command(ginkgoBinary, suites, suiterunner.Options{
+		Labels:               *labels,
+		ConfigFile:           *configFile,
+		ArtifactDir:          absArtifactDir,
+		MustGatherScriptsDir: env.OrDefault("MUST_GATHER_SCRIPTS_DIR", scriptsDir),
+		Timeout:              *timeout,
+		Procs:                *procs,
+		Verbose:              *verbose,
+		DryRun:               *checkLabels,
+		GinkgoArgs:           flag.Args(),
+	})
+
+	fmt.Println(strings.Join(command.Args, " "))
+
+	runErr := command.Run()
+
+	if *checkLabels && runErr == nil {
+		exitOnLabelViolations(filepath.Join(absArtifactDir, suiterunner.JSONReportFile))
+	}
+
+	summaries, err := suiterunner.ReadReport(filepath.Join(absArtifactDir, suiterunner.JSONReportFile))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to summarize the run: %v\n", err)
+	} else if err := suiterunner.WriteSummary(os.Stdout, summaries); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write the summary: %v\n", err)
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+
+	if runErr != nil {
+		exitUsage(runErr)
+	}
+}
+
+// exitOnLabelViolations prints the specs of the report whose labels do not follow the taxonomy, and exits with status
+// 1 when there are some.
+func exitOnLabelViolations(reportPath string) {
+	violations, err := suiterunner.CheckLabels(reportPath)
+	if err != nil {
+		exitUsage(err)
+	}
+
+	if len(violations) == 0 {
+		fmt.Println("the labels of all the specs follow the taxonomy")
+
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "%d specs do not follow the label taxonomy:\n%s\n", len(violations),
+		strings.Join(violations, "\n"))
+	os.Exit(1)
+}
+
+// lookGinkgo returns the path of the ginkgo binary, in PATH or else in GOPATH/bin where install-ginkgo installs it.
+func lookGinkgo(ginkgoPath string) (string, error) {
+	if path, err := exec.LookPath(ginkgoPath); err == nil {
+		return path, nil
+	}
+
+	goPath := os.Getenv("GOPATH")
+	if goPath == "" {
+		home, _ := os.UserHomeDir()
+		goPath = filepath.Join(home, "go")
+	}
+
+	path, err := exec.LookPath(filepath.Join(goPath, "bin", ginkgoPath))
+	if err != nil {
+		return "", fmt.Errorf("ginkgo binary %s not found, run make install-ginkgo: %w", ginkgoPath, err)
+	}
+
+	return path, nil
+}
+
+func exitUsage(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
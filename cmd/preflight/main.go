@@ -0,0 +1,53 @@
+// Command preflight queries the cluster of KUBECONFIG and prints its readiness report for the test suites: the
+// OpenShift version, the GPU node inventory, the NFD status, the operators installed, the pull secret and entitlement
+// and the storage classes. It exits with status 1 when the report has blockers, and 2 on usage errors, so that CI
+// can run it before dispatching the suites.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/preflight"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+func main() {
+	output := flag.String("output", outputText, "format of the report, text or json")
+	scaleCluster := flag.Bool("scale-cluster", os.Getenv("NVIDIAGPU_GPU_MACHINESET_INSTANCE_TYPE") != "",
+		"the suites add the GPU nodes by scaling the cluster, no GPU node is not a blocker")
+	flag.Parse()
+
+	if *output != outputText && *output != outputJSON {
+		fmt.Fprintf(os.Stderr, "unknown output %q, expected %s or %s\n", *output, outputText, outputJSON)
+		os.Exit(2)
+	}
+
+	report := preflight.Run(inittools.APIClient, preflight.Options{
+		WorkerLabelMap: inittools.GeneralConfig.WorkerLabelMap,
+		ScaleCluster:   *scaleCluster,
+	})
+
+	var err error
+	if *output == outputJSON {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write the readiness report: %v\n", err)
+		os.Exit(2)
+	}
+
+	if blockers := report.Blockers(); len(blockers) > 0 {
+		fmt.Fprintf(os.Stderr, "the cluster is not ready, %d blocker(s)\n", len(blockers))
+		os.Exit(1)
+	}
+}
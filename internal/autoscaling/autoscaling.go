@@ -0,0 +1,48 @@
+package autoscaling
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// GPUType is the cluster autoscaler GPU type of the autoscaled GPU nodes, set as their AcceleratorLabel.
+	GPUType = "nvidia-gpu"
+	// WorkloadContainerName is the container name of the pods built by NewWorkloadPod.
+	WorkloadContainerName = "test"
+)
+
+var isTrue = true
+
+// NewWorkloadPod returns a pod requesting a GPU on the nodes of the nodeSelector, which lists its GPU and completes
+// after the duration, so that the node is scaled down once the pod is deleted.
+func NewWorkloadPod(apiClient *clients.Settings, name, nsname, image string, nodeSelector map[string]string,
+	duration time.Duration) *pod.Builder {
+	podBuilder := pod.NewBuilder(apiClient, name, nsname, image).
+		RedefineDefaultCMD([]string{"/bin/sh", "-c",
+			fmt.Sprintf("nvidia-smi -L && sleep %d", int(duration.Seconds()))}).
+		WithRestartPolicy(corev1.RestartPolicyNever).
+		WithNodeSelector(nodeSelector).
+		WithToleration(corev1.Toleration{
+			Key:      "nvidia.com/gpu",
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		}).
+		WithSecurityContext(&corev1.PodSecurityContext{
+			RunAsNonRoot:   &isTrue,
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		})
+
+	if podBuilder.Definition != nil && len(podBuilder.Definition.Spec.Containers) > 0 {
+		podBuilder.Definition.Spec.Containers[0].Resources.Limits = corev1.ResourceList{
+			"nvidia.com/gpu": resource.MustParse("1"),
+		}
+	}
+
+	return podBuilder
+}
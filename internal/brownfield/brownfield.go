@@ -0,0 +1,137 @@
+package brownfield
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reinstall"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// HostDriverPodName is the name of the debug pod inspecting the driver preinstalled on the host of a node.
+	HostDriverPodName = "brownfield-host-driver"
+	// HostDriverReadyFile is the status file the operator validator writes on the host once it validated a driver
+	// preinstalled on the host, instead of the driver-ready file of the driver containers.
+	HostDriverReadyFile = "/run/nvidia/validations/host-driver-ready"
+	// DisableDriverPatch is the NVIDIAGPU_GPU_CLUSTER_POLICY_PATCH deploying the GPU operator for a host driver.
+	DisableDriverPatch = `[{"op": "add", "path": "/spec/driver/enabled", "value": false}]`
+
+	hostRootVolume = "host-root"
+	versionPrefix  = "version: "
+	modulePrefix   = "module: "
+	readyPrefix    = "ready: "
+)
+
+// hostDriverScript prints the driver version reported by the nvidia-smi of the host root filesystem, whether the
+// kernel module is loaded and whether the validator validated the host driver.
+var hostDriverScript = fmt.Sprintf(`echo "%[1]s$(chroot /host nvidia-smi --query-gpu=driver_version \
+  --format=csv,noheader 2>/dev/null | head -1)"
+if [ -d /host%[2]s ]; then echo "%[3]strue"; else echo "%[3]sfalse"; fi
+if [ -f /host%[4]s ]; then echo "%[5]strue"; else echo "%[5]sfalse"; fi
+`, versionPrefix, reinstall.DriverModulePath, modulePrefix, HostDriverReadyFile, readyPrefix)
+
+// HostDriver is the driver preinstalled on the host of a node.
+type HostDriver struct {
+	// Version is the driver version reported by the nvidia-smi of the host, empty when the host has none.
+	Version string
+	// ModuleLoaded is true when the NVIDIA kernel module is loaded.
+	ModuleLoaded bool
+	// Validated is true when the operator validator wrote HostDriverReadyFile.
+	Validated bool
+}
+
+// ParseHostDriver parses the output of the host driver pod.
+func ParseHostDriver(output string) (*HostDriver, error) {
+	hostDriver := &HostDriver{}
+	found := 0
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if value, ok := strings.CutPrefix(line, versionPrefix); ok {
+			hostDriver.Version = strings.TrimSpace(value)
+			found++
+		} else if value, ok := strings.CutPrefix(line, modulePrefix); ok {
+			hostDriver.ModuleLoaded = value == "true"
+			found++
+		} else if value, ok := strings.CutPrefix(line, readyPrefix); ok {
+			hostDriver.Validated = value == "true"
+			found++
+		}
+	}
+
+	if found != 3 {
+		return nil, fmt.Errorf("incomplete host driver output %q", output)
+	}
+
+	return hostDriver, nil
+}
+
+// GetHostDriver returns the driver preinstalled on the host of the node, inspected from a privileged debug pod
+// created in the namespace, which must allow privileged pods.
+func GetHostDriver(apiClient *clients.Settings, nodeName, nsname, image string,
+	timeout time.Duration) (*HostDriver, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Inspecting the host driver of node '%s'", nodeName)
+
+	hostDriverPod, err := pod.NewBuilder(apiClient, HostDriverPodName, nsname, image).
+		DefineOnNode(nodeName).
+		WithRestartPolicy(corev1.RestartPolicyNever).
+		WithToleration(corev1.Toleration{
+			Key:      string(cudasamples.GPUResource),
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		}).
+		RedefineDefaultCMD([]string{"/bin/sh", "-c", hostDriverScript}).
+		WithPrivilegedFlag().
+		WithHostPathVolume(hostRootVolume, "/", "/host").
+		Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the host driver pod of node %s: %w", nodeName, err)
+	}
+
+	defer func() {
+		if _, err := hostDriverPod.DeleteAndWait(timeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", HostDriverPodName, err)
+		}
+	}()
+
+	if err := hostDriverPod.WaitUntilInStatus(corev1.PodSucceeded, timeout); err != nil {
+		return nil, fmt.Errorf("host driver pod of node %s did not succeed: %w", nodeName, err)
+	}
+
+	output, err := hostDriverPod.GetFullLog(hostDriverPod.Definition.Spec.Containers[0].Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod %s log: %w", HostDriverPodName, err)
+	}
+
+	return ParseHostDriver(output)
+}
+
+// DriverPods returns the names of the driver pods the GPU operator runs, which must be none when the ClusterPolicy
+// disables the driver.
+func DriverPods(apiClient *clients.Settings) ([]string, error) {
+	driverPods, err := apiClient.Pods(nvidiagpu.NvidiaGPUNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: driverupgrade.DriverLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the driver pods: %w", err)
+	}
+
+	var names []string
+	for _, driverPod := range driverPods.Items {
+		names = append(names, driverPod.Name)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,209 @@
+package cc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/kata"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// ModeOn is the CC mode enabling confidential computing on the node GPUs.
+	ModeOn = "on"
+	// ModeOff is the CC mode disabling confidential computing on the node GPUs.
+	ModeOff = "off"
+	// ModeDevtools is the CC mode enabling confidential computing with the GPU debugging and profiling tools allowed.
+	ModeDevtools = "devtools"
+	// ModeStateFailed is the value of kata.CCModeStateLabel when the CC manager could not apply the mode.
+	ModeStateFailed = "failed"
+
+	// CCManagerPodLabel selects the CC manager pods.
+	CCManagerPodLabel = "app=nvidia-cc-manager"
+	// GPUProductLabel is the GPU product name discovered by GFD.
+	GPUProductLabel = "nvidia.com/gpu.product"
+	// WorkloadContainerName is the container name of the pods built by CreateCCWorkloadPod.
+	WorkloadContainerName = "cc-cuda-ctr"
+
+	ccStatusPrefix   = "CC status:"
+	readyStatePrefix = "Confidential Compute GPUs Ready state:"
+)
+
+// ccCapableProducts are the GPU product name fragments of the GPUs supporting confidential computing, used when GFD
+// does not label the node with kata.CCCapableLabel.
+var ccCapableProducts = []string{"H100", "H200", "H800", "GH200"}
+
+var (
+	isFalse = false
+)
+
+// ConfComputeStatus is the confidential computing state reported by nvidia-smi conf-compute in a workload pod.
+type ConfComputeStatus struct {
+	// CCStatus is the CC feature status, "ON", "OFF" or "DEVTOOLS".
+	CCStatus string
+	// ReadyState is the GPU ready state for confidential workloads, "ready" or "not-ready".
+	ReadyState string
+}
+
+// IsCCCapable returns true when the GPUs of the node support confidential computing, either as reported by GFD with
+// kata.CCCapableLabel or from the GPU product name.
+func IsCCCapable(nodeBuilder *nodes.Builder) bool {
+	if nodeBuilder.Object.Labels[kata.CCCapableLabel] == "true" {
+		return true
+	}
+
+	product := nodeBuilder.Object.Labels[GPUProductLabel]
+
+	for _, ccCapableProduct := range ccCapableProducts {
+		if strings.Contains(product, ccCapableProduct) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ListCCCapableNodes returns the nodes matching the label selector whose GPUs support confidential computing.
+func ListCCCapableNodes(apiClient *clients.Settings, labelSelector string) ([]*nodes.Builder, error) {
+	nodeBuilders, err := nodes.List(apiClient, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes with selector %s: %w", labelSelector, err)
+	}
+
+	var ccCapableNodes []*nodes.Builder
+
+	for _, nodeBuilder := range nodeBuilders {
+		if IsCCCapable(nodeBuilder) {
+			ccCapableNodes = append(ccCapableNodes, nodeBuilder)
+
+			continue
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' GPU '%s' does not support confidential computing",
+			nodeBuilder.Object.Name, nodeBuilder.Object.Labels[GPUProductLabel])
+	}
+
+	return ccCapableNodes, nil
+}
+
+// WaitForCCModeState waits until the CC manager reports the mode in kata.CCModeStateLabel on the node.
+// A "failed" state ends the wait early with an error.
+func WaitForCCModeState(apiClient *clients.Settings, nodeName, mode string, pollInterval,
+	timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' pull from cluster error: %v", nodeName, err)
+
+				return false, nil
+			}
+
+			currentState := nodeBuilder.Object.Labels[kata.CCModeStateLabel]
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' CC mode state is '%s'", nodeName, currentState)
+
+			if currentState == ModeStateFailed {
+				return false, fmt.Errorf("CC manager failed to apply mode '%s' on node %s", mode, nodeName)
+			}
+
+			return currentState == mode, nil
+		})
+}
+
+// SetMode requests the confidential computing mode on the node with kata.CCModeLabel and waits for the CC manager to
+// apply it.
+func SetMode(apiClient *clients.Settings, nodeName, mode string, pollInterval, timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Labeling node '%s' with %s=%s", nodeName, kata.CCModeLabel, mode)
+
+	if err := nodes.SetLabel(apiClient, nodeName, kata.CCModeLabel, mode); err != nil {
+		return fmt.Errorf("failed to label node %s with %s=%s: %w", nodeName, kata.CCModeLabel, mode, err)
+	}
+
+	return WaitForCCModeState(apiClient, nodeName, mode, pollInterval, timeout)
+}
+
+// CreateCCWorkloadPod returns a cuda vectorAdd pod running in a confidential kata VM with one passthrough GPU. It
+// queries the confidential computing state of the GPU with nvidia-smi conf-compute before running vectorAdd.
+func CreateCCWorkloadPod(podName, podNamespace, nodeName, runtimeClassName, resourceName,
+	image string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "cc-test-app",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RuntimeClassName: &runtimeClassName,
+			RestartPolicy:    corev1.RestartPolicyNever,
+			NodeSelector: map[string]string{
+				"kubernetes.io/hostname": nodeName,
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            WorkloadContainerName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command: []string{"/bin/sh", "-c",
+						"set -e; nvidia-smi conf-compute -f; nvidia-smi conf-compute -grs; /cuda-samples/vectorAdd"},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &isFalse,
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+					},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceName(resourceName): resource.MustParse("1"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ParseConfComputeStatus returns the confidential computing state printed by the pods built by
+// CreateCCWorkloadPod.
+func ParseConfComputeStatus(output string) (*ConfComputeStatus, error) {
+	status := &ConfComputeStatus{}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if value, found := strings.CutPrefix(line, ccStatusPrefix); found {
+			status.CCStatus = strings.TrimSpace(value)
+		}
+
+		if value, found := strings.CutPrefix(line, readyStatePrefix); found {
+			status.ReadyState = strings.TrimSpace(value)
+		}
+	}
+
+	if status.CCStatus == "" {
+		return nil, fmt.Errorf("no '%s' line found in the pod output", ccStatusPrefix)
+	}
+
+	if status.ReadyState == "" {
+		return nil, fmt.Errorf("no '%s' line found in the pod output", readyStatePrefix)
+	}
+
+	return status, nil
+}
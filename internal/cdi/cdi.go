@@ -0,0 +1,185 @@
+package cdi
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SpecPodName is the name of the debug pod listing the CDI spec files of a node.
+	SpecPodName = "cdi-node-specs"
+	// GPUKind is the CDI kind of the GPU devices in the specs generated by the container toolkit.
+	GPUKind = "nvidia.com/gpu"
+	// DevicePluginDaemonSet is the name of the device plugin DaemonSet of the GPU operator.
+	DevicePluginDaemonSet = "nvidia-device-plugin-daemonset"
+	// DeviceListStrategyEnv is the device plugin environment variable listing how the allocated devices are passed
+	// to the container runtime.
+	DeviceListStrategyEnv = "DEVICE_LIST_STRATEGY"
+	// CDIAnnotationsStrategy is the device list strategy passing the allocated devices as CDI annotations.
+	CDIAnnotationsStrategy = "cdi-annotations"
+
+	hostRootVolume = "host-root"
+	specPrefix     = "spec: "
+)
+
+var (
+	// SpecDirs are the host directories the CDI specs are read from by CRI-O, the container toolkit generating its
+	// specs in the transient one.
+	SpecDirs = []string{"/etc/cdi", "/var/run/cdi"}
+
+	kindRegexp = regexp.MustCompile(`"?kind"?\s*:\s*"?([A-Za-z0-9./_-]+)"?`)
+)
+
+// specScript prints the path of every CDI spec file of the host followed by the CDI kinds it declares.
+var specScript = fmt.Sprintf(`for spec in $(ls -d %s 2>/dev/null); do
+  [ -f "$spec" ] || continue
+  echo "%s${spec#/host} $(grep -oE '%s' "$spec" | tr '\n' ' ')"
+done
+`, "/host"+strings.Join(SpecDirs, "/* /host")+"/*", specPrefix, kindRegexp.String())
+
+// EnableCDI sets cdi.enabled, and cdi.default when defaultMode is true, in the ClusterPolicy. It returns a copy of
+// the previous ClusterPolicy spec so that it can be restored, or nil when the ClusterPolicy already had these settings.
+func EnableCDI(apiClient *clients.Settings, clusterPolicyName string,
+	defaultMode bool) (*nvidiagpuv1.ClusterPolicySpec, error) {
+	clusterPolicyBuilder, err := nvidiagpu.Pull(apiClient, clusterPolicyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull ClusterPolicy %s: %w", clusterPolicyName, err)
+	}
+
+	cdiSpec := clusterPolicyBuilder.Definition.Spec.CDI
+	if cdiSpec.IsEnabled() && cdiSpec.IsDefault() == defaultMode {
+		glog.V(gpuparams.GpuLogLevel).Infof("ClusterPolicy '%s' already has CDI enabled with default %t",
+			clusterPolicyName, defaultMode)
+
+		return nil, nil
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Enabling CDI in ClusterPolicy '%s' with default %t", clusterPolicyName,
+		defaultMode)
+
+	var previousSpec *nvidiagpuv1.ClusterPolicySpec
+
+	_, err = nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousSpec = definition.Spec.DeepCopy()
+		enabled := true
+		definition.Spec.CDI.Enabled = &enabled
+		definition.Spec.CDI.Default = &defaultMode
+	})
+	if err != nil {
+		return previousSpec, fmt.Errorf("failed to enable CDI in ClusterPolicy %s: %w", clusterPolicyName, err)
+	}
+
+	return previousSpec, nil
+}
+
+// ParseNodeSpecs parses the output of the spec pod into the CDI kinds declared by every spec file, by path.
+func ParseNodeSpecs(output string) (map[string][]string, error) {
+	specs := map[string][]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields, found := strings.CutPrefix(line, specPrefix)
+		if !found {
+			return nil, fmt.Errorf("invalid CDI spec line %q", line)
+		}
+
+		path, kinds, _ := strings.Cut(fields, " ")
+		specs[path] = nil
+
+		for _, match := range kindRegexp.FindAllStringSubmatch(kinds, -1) {
+			specs[path] = append(specs[path], match[1])
+		}
+	}
+
+	return specs, nil
+}
+
+// HasKind checks whether one of the CDI spec files declares the kind.
+func HasKind(specs map[string][]string, kind string) bool {
+	for _, kinds := range specs {
+		for _, specKind := range kinds {
+			if specKind == kind {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// GetNodeSpecs returns the CDI kinds declared by every CDI spec file of the node, by path, read on the host from a
+// privileged debug pod created in the namespace, which must allow privileged pods.
+func GetNodeSpecs(apiClient *clients.Settings, nodeName, nsname, image string,
+	timeout time.Duration) (map[string][]string, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Listing the CDI specs of node '%s'", nodeName)
+
+	specPod, err := pod.NewBuilder(apiClient, SpecPodName, nsname, image).
+		DefineOnNode(nodeName).
+		WithRestartPolicy(corev1.RestartPolicyNever).
+		WithToleration(corev1.Toleration{
+			Key:      string(cudasamples.GPUResource),
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		}).
+		RedefineDefaultCMD([]string{"/bin/sh", "-c", specScript}).
+		WithPrivilegedFlag().
+		WithHostPathVolume(hostRootVolume, "/", "/host").
+		Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CDI spec pod of node %s: %w", nodeName, err)
+	}
+
+	defer func() {
+		if _, err := specPod.DeleteAndWait(timeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", SpecPodName, err)
+		}
+	}()
+
+	if err := specPod.WaitUntilInStatus(corev1.PodSucceeded, timeout); err != nil {
+		return nil, fmt.Errorf("CDI spec pod of node %s did not succeed: %w", nodeName, err)
+	}
+
+	output, err := specPod.GetFullLog(specPod.Definition.Spec.Containers[0].Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod %s log: %w", SpecPodName, err)
+	}
+
+	return ParseNodeSpecs(output)
+}
+
+// DevicePluginListStrategy returns the device list strategy the GPU operator sets on the device plugin DaemonSet,
+// empty when it sets none.
+func DevicePluginListStrategy(apiClient *clients.Settings) (string, error) {
+	daemonSet, err := apiClient.DaemonSets(nvidiagpu.NvidiaGPUNamespace).Get(context.TODO(), DevicePluginDaemonSet,
+		metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get DaemonSet %s: %w", DevicePluginDaemonSet, err)
+	}
+
+	for _, container := range daemonSet.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			if env.Name == DeviceListStrategyEnv {
+				return env.Value, nil
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,391 @@
+package cgroups
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NodePodName is the name of the debug pod reading the cgroup and container runtime configuration of a node.
+	NodePodName = "cgroup-node-config"
+	// WorkloadContainerName is the container name of the GPU workload pods run by RunWorkload.
+	WorkloadContainerName = "cgroup-workload-ctr"
+	// ModeV1 is the cgroup mode of the nodes mounting the legacy cgroup v1 hierarchies.
+	ModeV1 = "v1"
+	// ModeV2 is the cgroup mode of the nodes mounting the cgroup v2 unified hierarchy.
+	ModeV2 = "v2"
+	// SystemdDriver is the cgroup driver delegating the container cgroups to systemd, the OpenShift default.
+	SystemdDriver = "systemd"
+	// ToolkitConfigPath is the host path of the nvidia-container-runtime configuration written by the container
+	// toolkit.
+	ToolkitConfigPath = "/usr/local/nvidia/toolkit/.config/nvidia-container-runtime/config.toml"
+	// CDIMode is the nvidia-container-runtime mode injecting the GPU devices through CDI into the OCI spec, the
+	// low-level runtime then allowing them in the device controller.
+	CDIMode = "cdi"
+
+	hostRootVolume = "host-root"
+	// crioDefaultCgroupManager is the cgroup manager of CRI-O when its configuration sets none.
+	crioDefaultCgroupManager = SystemdDriver
+	cgroup2Filesystem        = "cgroup2fs"
+	cgroupfsPrefix           = "cgroupfs: "
+	crioPrefix               = "crio: "
+	kubeletPrefix            = "kubelet: "
+	devicePrefix             = "device: "
+	toolkitMarker            = "--- toolkit config"
+)
+
+var (
+	// nodeScript prints the filesystem of the host cgroup mount, the CRI-O and kubelet cgroup settings, and the
+	// container toolkit configuration.
+	nodeScript = fmt.Sprintf(`echo "%[1]s$(chroot /host stat -fc %%T /sys/fs/cgroup)"
+chroot /host sh -c 'cat /etc/crio/crio.conf /etc/crio/crio.conf.d/* 2>/dev/null' |
+  grep -E '^\s*(default_runtime|cgroup_manager)\s*=' | sed 's/^/%[2]s/'
+echo "%[3]s$(chroot /host grep -oE 'cgroupDriver"?:\s*"?[a-z]+' /etc/kubernetes/kubelet.conf | grep -oE '[a-z]+$')"
+if [ -f /host%[4]s ]; then echo "%[5]s"; cat /host%[4]s; fi
+`, cgroupfsPrefix, crioPrefix, kubeletPrefix, ToolkitConfigPath, toolkitMarker)
+
+	// workloadScript prints the filesystem of the container cgroup mount and the GPU device nodes of the container,
+	// then runs vectorAdd.
+	workloadScript = fmt.Sprintf(`echo "%s$(stat -fc %%T /sys/fs/cgroup)"
+for device in /dev/nvidia[0-9]*; do [ -e "$device" ] && echo "%s$device"; done
+/cuda-samples/%s
+`, cgroupfsPrefix, devicePrefix, cudasamples.VectorAdd)
+
+	isFalse = false
+	isTrue  = true
+)
+
+// ToolkitConfig is the part of the nvidia-container-runtime configuration depending on the node cgroup setup.
+type ToolkitConfig struct {
+	// NoCgroups is the nvidia-container-cli no-cgroups setting, leaving the device controller alone when true.
+	NoCgroups bool
+	// Runtimes are the low-level runtimes nvidia-container-runtime looks up, in order, to run the containers.
+	Runtimes []string
+	// Mode is the nvidia-container-runtime mode, auto, legacy or cdi.
+	Mode string
+}
+
+// NodeConfig is the cgroup setup of a node and the container toolkit configuration installed on it.
+type NodeConfig struct {
+	// Mode is ModeV1 or ModeV2.
+	Mode string
+	// DefaultRuntime is the CRI-O default runtime, e.g. crun or runc, empty when its configuration sets none.
+	DefaultRuntime string
+	// CgroupManager is the CRI-O cgroup manager.
+	CgroupManager string
+	// KubeletCgroupDriver is the kubelet cgroup driver.
+	KubeletCgroupDriver string
+	// Toolkit is nil when the container toolkit configuration is not found on the node.
+	Toolkit *ToolkitConfig
+}
+
+// Workload is what a GPU workload pod run by RunWorkload saw of its cgroup and GPU devices.
+type Workload struct {
+	// Mode is the cgroup mode of the container cgroup namespace.
+	Mode string
+	// Devices are the GPU device nodes of the container.
+	Devices []string
+	// Passed is true when vectorAdd passed.
+	Passed bool
+}
+
+// ModeOf returns the cgroup mode of the filesystem type of a /sys/fs/cgroup mount, as reported by stat -f.
+func ModeOf(filesystem string) string {
+	if filesystem == cgroup2Filesystem {
+		return ModeV2
+	}
+
+	return ModeV1
+}
+
+// ParseToolkitConfig parses the nvidia-container-runtime config.toml, only reading the settings of ToolkitConfig.
+func ParseToolkitConfig(content string) (*ToolkitConfig, error) {
+	toolkitConfig := &ToolkitConfig{}
+	section := ""
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[]")
+
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case section == "nvidia-container-cli" && key == "no-cgroups":
+			noCgroups, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid no-cgroups value %q: %w", value, err)
+			}
+
+			toolkitConfig.NoCgroups = noCgroups
+		case section == "nvidia-container-runtime" && key == "runtimes":
+			toolkitConfig.Runtimes = nil
+
+			for _, runtime := range strings.Split(strings.Trim(value, "[]"), ",") {
+				if runtime = strings.Trim(strings.TrimSpace(runtime), `"'`); runtime != "" {
+					toolkitConfig.Runtimes = append(toolkitConfig.Runtimes, runtime)
+				}
+			}
+		case section == "nvidia-container-runtime" && key == "mode":
+			toolkitConfig.Mode = strings.Trim(value, `"'`)
+		}
+	}
+
+	return toolkitConfig, nil
+}
+
+// ParseNodeConfig parses the output of the node pod, the last CRI-O setting winning as the CRI-O drop-ins do.
+func ParseNodeConfig(output string) (*NodeConfig, error) {
+	nodeConfig := &NodeConfig{CgroupManager: crioDefaultCgroupManager}
+	filesystem := ""
+
+	header, toolkitContent, toolkitFound := strings.Cut(output, toolkitMarker)
+
+	for _, line := range strings.Split(header, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, cgroupfsPrefix):
+			filesystem = strings.TrimSpace(strings.TrimPrefix(line, cgroupfsPrefix))
+		case strings.HasPrefix(line, kubeletPrefix):
+			nodeConfig.KubeletCgroupDriver = strings.TrimSpace(strings.TrimPrefix(line, kubeletPrefix))
+		case strings.HasPrefix(line, crioPrefix):
+			key, value, found := strings.Cut(strings.TrimPrefix(line, crioPrefix), "=")
+			if !found {
+				return nil, fmt.Errorf("invalid CRI-O setting %q", line)
+			}
+
+			value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+			switch strings.TrimSpace(key) {
+			case "default_runtime":
+				nodeConfig.DefaultRuntime = value
+			case "cgroup_manager":
+				nodeConfig.CgroupManager = value
+			}
+		}
+	}
+
+	if filesystem == "" {
+		return nil, fmt.Errorf("no cgroup filesystem found in output %q", output)
+	}
+
+	nodeConfig.Mode = ModeOf(filesystem)
+
+	if toolkitFound {
+		toolkitConfig, err := ParseToolkitConfig(toolkitContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", ToolkitConfigPath, err)
+		}
+
+		nodeConfig.Toolkit = toolkitConfig
+	}
+
+	return nodeConfig, nil
+}
+
+// Mismatches returns why the cgroup setup of the node and the container toolkit configuration do not match, empty
+// when GPU containers get their devices allowed by the device controller.
+func (nodeConfig *NodeConfig) Mismatches() []string {
+	var mismatches []string
+
+	if nodeConfig.CgroupManager != SystemdDriver {
+		mismatches = append(mismatches, fmt.Sprintf("CRI-O cgroup manager is %q, not %q", nodeConfig.CgroupManager,
+			SystemdDriver))
+	}
+
+	if nodeConfig.KubeletCgroupDriver != nodeConfig.CgroupManager {
+		mismatches = append(mismatches, fmt.Sprintf("kubelet cgroup driver %q differs from CRI-O cgroup manager %q",
+			nodeConfig.KubeletCgroupDriver, nodeConfig.CgroupManager))
+	}
+
+	if nodeConfig.Toolkit == nil {
+		return append(mismatches, fmt.Sprintf("container toolkit configuration %s not found", ToolkitConfigPath))
+	}
+
+	if nodeConfig.DefaultRuntime != "" && !slices.Contains(nodeConfig.Toolkit.Runtimes, nodeConfig.DefaultRuntime) {
+		mismatches = append(mismatches, fmt.Sprintf("nvidia-container-runtime runtimes %v do not include the CRI-O "+
+			"default runtime %q", nodeConfig.Toolkit.Runtimes, nodeConfig.DefaultRuntime))
+	}
+
+	// Outside of the CDI mode, nvidia-container-cli allows the GPU devices itself, with an eBPF device program on
+	// cgroup v2, unless no-cgroups is set.
+	if nodeConfig.Toolkit.Mode != CDIMode && nodeConfig.Toolkit.NoCgroups {
+		mismatches = append(mismatches, fmt.Sprintf("nvidia-container-cli no-cgroups is set in %s mode on a cgroup "+
+			"%s node, the GPU devices are not allowed in the device controller", nodeConfig.Toolkit.Mode,
+			nodeConfig.Mode))
+	}
+
+	return mismatches
+}
+
+// ParseWorkload parses the log of a GPU workload pod run by RunWorkload.
+func ParseWorkload(output string) (*Workload, error) {
+	workload := &Workload{}
+	filesystem := ""
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if value, found := strings.CutPrefix(line, cgroupfsPrefix); found {
+			filesystem = value
+		} else if device, found := strings.CutPrefix(line, devicePrefix); found {
+			workload.Devices = append(workload.Devices, device)
+		}
+	}
+
+	if filesystem == "" {
+		return nil, fmt.Errorf("no cgroup filesystem found in output %q", output)
+	}
+
+	workload.Mode = ModeOf(filesystem)
+
+	result, err := cudasamples.ParseVectorAdd(output)
+	if err != nil {
+		return nil, err
+	}
+
+	workload.Passed = result.Passed
+
+	return workload, nil
+}
+
+// GetNodeConfig returns the cgroup setup and the container toolkit configuration of the node, read on the host from
+// a privileged debug pod created in the namespace, which must allow privileged pods.
+func GetNodeConfig(apiClient *clients.Settings, nodeName, nsname, image string,
+	timeout time.Duration) (*NodeConfig, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Reading the cgroup configuration of node '%s'", nodeName)
+
+	nodePod, err := pod.NewBuilder(apiClient, NodePodName, nsname, image).
+		DefineOnNode(nodeName).
+		WithRestartPolicy(corev1.RestartPolicyNever).
+		WithToleration(corev1.Toleration{
+			Key:      string(cudasamples.GPUResource),
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		}).
+		RedefineDefaultCMD([]string{"/bin/sh", "-c", nodeScript}).
+		WithPrivilegedFlag().
+		WithHostPathVolume(hostRootVolume, "/", "/host").
+		Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the cgroup node pod of node %s: %w", nodeName, err)
+	}
+
+	defer func() {
+		if _, err := nodePod.DeleteAndWait(timeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", NodePodName, err)
+		}
+	}()
+
+	if err := nodePod.WaitUntilInStatus(corev1.PodSucceeded, timeout); err != nil {
+		return nil, fmt.Errorf("cgroup node pod of node %s did not succeed: %w", nodeName, err)
+	}
+
+	output, err := nodePod.GetFullLog(nodePod.Definition.Spec.Containers[0].Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod %s log: %w", NodePodName, err)
+	}
+
+	return ParseNodeConfig(output)
+}
+
+// RunWorkload runs an unprivileged pod requesting the GPUs on the node, which reports the cgroup mode and GPU device
+// nodes of its container and runs vectorAdd, and returns what it reported once it completed.
+func RunWorkload(apiClient *clients.Settings, name, nsname, image, nodeName string, gpus int64,
+	timeout time.Duration) (*Workload, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Running GPU workload pod '%s' requesting %d GPU(s) on node '%s'", name, gpus,
+		nodeName)
+
+	workloadPod := newWorkloadPod(name, nsname, image, nodeName, gpus)
+	proxy.Inject(apiClient, &workloadPod.Spec)
+	owner.Label(workloadPod)
+
+	if _, err := apiClient.Pods(nsname).Create(context.TODO(), workloadPod, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create pod %s: %w", name, err)
+	}
+
+	podBuilder, err := pod.Pull(apiClient, name, nsname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull pod %s: %w", name, err)
+	}
+
+	defer func() {
+		if _, err := podBuilder.DeleteAndWait(timeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", name, err)
+		}
+	}()
+
+	if err := podBuilder.WaitUntilInStatus(corev1.PodSucceeded, timeout); err != nil {
+		return nil, fmt.Errorf("GPU workload pod %s did not succeed: %w", name, err)
+	}
+
+	output, err := podBuilder.GetFullLog(WorkloadContainerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod %s log: %w", name, err)
+	}
+
+	return ParseWorkload(output)
+}
+
+func newWorkloadPod(name, nsname, image, nodeName string, gpus int64) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsname},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			NodeSelector:  map[string]string{corev1.LabelHostname: nodeName},
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &isTrue,
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Tolerations: []corev1.Toleration{{
+				Key:      string(cudasamples.GPUResource),
+				Effect:   corev1.TaintEffectNoSchedule,
+				Operator: corev1.TolerationOpExists,
+			}},
+			Containers: []corev1.Container{{
+				Name:            WorkloadContainerName,
+				Image:           image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"/bin/sh", "-c", workloadScript},
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: &isFalse,
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						cudasamples.GPUResource: *resource.NewQuantity(gpus, resource.DecimalSI),
+					},
+				},
+			}},
+		},
+	}
+}
@@ -0,0 +1,191 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ValidatorLabel selects the GPU operator validator pods.
+const ValidatorLabel = "app=nvidia-operator-validator"
+
+// KillPod deletes the pod of the node matching the label selector without grace period, as a crashed pod, and
+// returns the UID of the killed pod.
+func KillPod(apiClient *clients.Settings, namespace, labelSelector, nodeName string) (types.UID, error) {
+	nodePod, err := nodePod(apiClient, namespace, labelSelector, nodeName)
+	if err != nil {
+		return "", err
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Killing pod '%s' of node '%s'", nodePod.Object.Name, nodeName)
+
+	gracePeriod := int64(0)
+	err = apiClient.Pods(namespace).Delete(context.TODO(), nodePod.Object.Name,
+		metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	if err != nil {
+		return "", fmt.Errorf("failed to kill pod %s: %w", nodePod.Object.Name, err)
+	}
+
+	return nodePod.Object.UID, nil
+}
+
+// NodePodReplaced waits until the node runs a single pod matching the label selector other than the pod of
+// previousUID, with all its containers ready, and returns it.
+func NodePodReplaced(apiClient *clients.Settings, namespace, labelSelector, nodeName string, previousUID types.UID,
+	pollInterval, timeout time.Duration) (*pod.Builder, error) {
+	var replacement *pod.Builder
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			nodePod, err := nodePod(apiClient, namespace, labelSelector, nodeName)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Info(err)
+
+				return false, nil
+			}
+
+			if nodePod.Object.UID == previousUID {
+				glog.V(gpuparams.GpuLogLevel).Infof("Pod '%s' of node '%s' is not replaced yet",
+					nodePod.Object.Name, nodeName)
+
+				return false, nil
+			}
+
+			if !podReady(nodePod.Object) {
+				glog.V(gpuparams.GpuLogLevel).Infof("Pod '%s' of node '%s' is in phase '%s' and not ready",
+					nodePod.Object.Name, nodeName, nodePod.Object.Status.Phase)
+
+				return false, nil
+			}
+
+			replacement = nodePod
+
+			return true, nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("the %s pod of node %s was not replaced by a ready pod: %w", labelSelector, nodeName,
+			err)
+	}
+
+	return replacement, nil
+}
+
+// NodeDriverReady waits until the driver pod of the node replacing the pod of previousUID is ready and the driver
+// it loaded answers nvidia-smi.
+func NodeDriverReady(apiClient *clients.Settings, nodeName string, previousUID types.UID, pollInterval,
+	timeout time.Duration) error {
+	start := time.Now()
+
+	driverPod, err := NodePodReplaced(apiClient, nvidiagpu.NvidiaGPUNamespace, driverupgrade.DriverLabel, nodeName,
+		previousUID, pollInterval, timeout)
+	if err != nil {
+		return err
+	}
+
+	return driverListsGPUs(driverPod, pollInterval, timeout-time.Since(start))
+}
+
+// driverListsGPUs waits until nvidia-smi lists the GPUs in the driver container of the driver pod.
+func driverListsGPUs(driverPod *pod.Builder, pollInterval, timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			output, err := driverPod.ExecCommand([]string{"nvidia-smi", "-L"}, driverupgrade.DriverContainerName)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("nvidia-smi failed in driver pod '%s': %v",
+					driverPod.Object.Name, err)
+
+				return false, nil
+			}
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Driver pod '%s' lists GPUs: %s", driverPod.Object.Name,
+				output.String())
+
+			return true, nil
+		})
+}
+
+// ValidatorRerun waits until the validator pod of the node was created after since, i.e. the validations ran again,
+// and is ready.
+func ValidatorRerun(apiClient *clients.Settings, nodeName string, since time.Time, pollInterval,
+	timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			validatorPod, err := nodePod(apiClient, nvidiagpu.NvidiaGPUNamespace, ValidatorLabel, nodeName)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Info(err)
+
+				return false, nil
+			}
+
+			if validatorPod.Object.CreationTimestamp.Time.Before(since) {
+				glog.V(gpuparams.GpuLogLevel).Infof("Validator pod '%s' of node '%s' was created before %s",
+					validatorPod.Object.Name, nodeName, since.Format(time.RFC3339))
+
+				return false, nil
+			}
+
+			if !podReady(validatorPod.Object) {
+				glog.V(gpuparams.GpuLogLevel).Infof("Validator pod '%s' of node '%s' is not ready yet",
+					validatorPod.Object.Name, nodeName)
+
+				return false, nil
+			}
+
+			return true, nil
+		})
+}
+
+// nodePod returns the single pod of the node matching the label selector.
+func nodePod(apiClient *clients.Settings, namespace, labelSelector, nodeName string) (*pod.Builder, error) {
+	nodePods, err := nodePods(apiClient, namespace, labelSelector, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodePods) != 1 {
+		return nil, fmt.Errorf("found %d %s pods on node %s instead of 1", len(nodePods), labelSelector, nodeName)
+	}
+
+	return nodePods[0], nil
+}
+
+// nodePods returns the pods of the node matching the label selector, an empty selector matching all of them.
+func nodePods(apiClient *clients.Settings, namespace, labelSelector, nodeName string) ([]*pod.Builder, error) {
+	nodePods, err := pod.List(apiClient, namespace, metav1.ListOptions{
+		LabelSelector: labelSelector,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the %s pods of node %s in namespace %s: %w", labelSelector, nodeName,
+			namespace, err)
+	}
+
+	return nodePods, nil
+}
+
+// podReady returns true when the pod is running and not being deleted, with all its containers ready.
+func podReady(nodePod *corev1.Pod) bool {
+	if nodePod.DeletionTimestamp != nil || nodePod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+
+	for _, containerStatus := range nodePod.Status.ContainerStatuses {
+		if !containerStatus.Ready {
+			return false
+		}
+	}
+
+	return len(nodePod.Status.ContainerStatuses) > 0
+}
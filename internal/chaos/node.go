@@ -0,0 +1,253 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nvidiagpuv1alpha1 "github.com/NVIDIA/k8s-operator-libs/api/upgrade/v1alpha1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// RebootPodName is the name of the debug pod rebooting a node.
+	RebootPodName = "chaos-node-reboot"
+
+	// drainGracePeriod lets the evicted pods use their own termination grace period.
+	drainGracePeriod = -1
+	// defaultDrainTimeoutSeconds is the drain timeout the GPU operator defaults the drain spec to.
+	defaultDrainTimeoutSeconds = 300
+	hostRootVolume             = "host-root"
+)
+
+// ClusterPolicyDrainSpec returns the drain spec of the driver upgrade policy of the ClusterPolicy, the defaults of
+// the GPU operator when the ClusterPolicy does not set it.
+func ClusterPolicyDrainSpec(clusterPolicy *nvidiagpu.Builder) *nvidiagpuv1alpha1.DrainSpec {
+	upgradePolicy := clusterPolicy.Object.Spec.Driver.UpgradePolicy
+	if upgradePolicy == nil || upgradePolicy.DrainSpec == nil {
+		return &nvidiagpuv1alpha1.DrainSpec{TimeoutSecond: defaultDrainTimeoutSeconds}
+	}
+
+	return upgradePolicy.DrainSpec
+}
+
+// DrainNode cordons and drains the node the way the GPU operator does with the drain spec, the daemonset pods of the
+// operands being kept on the node.
+func DrainNode(apiClient *clients.Settings, nodeName string, drainSpec *nvidiagpuv1alpha1.DrainSpec) error {
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to pull node %s: %w", nodeName, err)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Draining node '%s' with force %t, pod selector '%s', timeout %ds and "+
+		"deleteEmptyDir %t", nodeName, drainSpec.Force, drainSpec.PodSelector, drainSpec.TimeoutSecond,
+		drainSpec.DeleteEmptyDir)
+
+	nodeBuilder.SetDrainHelper(drainSpec.Force, true, drainSpec.DeleteEmptyDir, drainGracePeriod, 0,
+		time.Duration(drainSpec.TimeoutSecond)*time.Second)
+	nodeBuilder.SetDrainPodSelector(drainSpec.PodSelector)
+
+	if err := nodeBuilder.Cordon(); err != nil {
+		return fmt.Errorf("failed to cordon node %s: %w", nodeName, err)
+	}
+
+	if err := nodeBuilder.Drain(); err != nil {
+		return fmt.Errorf("failed to drain node %s: %w", nodeName, err)
+	}
+
+	return nil
+}
+
+// UncordonNode marks the node as schedulable again.
+func UncordonNode(apiClient *clients.Settings, nodeName string) error {
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to pull node %s: %w", nodeName, err)
+	}
+
+	if err := nodeBuilder.Uncordon(); err != nil {
+		return fmt.Errorf("failed to uncordon node %s: %w", nodeName, err)
+	}
+
+	return nil
+}
+
+// NodePodUIDs returns the names of the pods of the node matching the label selector by pod UID.
+func NodePodUIDs(apiClient *clients.Settings, namespace, labelSelector, nodeName string) (map[types.UID]string,
+	error) {
+	nodePods, err := nodePods(apiClient, namespace, labelSelector, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	podUIDs := map[types.UID]string{}
+	for _, nodePod := range nodePods {
+		podUIDs[nodePod.Object.UID] = nodePod.Object.Name
+	}
+
+	return podUIDs, nil
+}
+
+// NodeOperandPodUIDs returns the names of the GPU operator operand pods of the node, the pods of its daemonsets, by
+// pod UID.
+func NodeOperandPodUIDs(apiClient *clients.Settings, nodeName string) (map[types.UID]string, error) {
+	operandPods, err := nodeOperandPods(apiClient, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	podUIDs := map[types.UID]string{}
+	for _, operandPod := range operandPods {
+		podUIDs[operandPod.Object.UID] = operandPod.Object.Name
+	}
+
+	return podUIDs, nil
+}
+
+// BootID returns the boot ID of the node, which changes every time the node reboots.
+func BootID(apiClient *clients.Settings, nodeName string) (string, error) {
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		return "", fmt.Errorf("failed to pull node %s: %w", nodeName, err)
+	}
+
+	return nodeBuilder.Object.Status.NodeInfo.BootID, nil
+}
+
+// RebootNode reboots the node from a privileged debug pod created in the namespace, which must allow privileged pods.
+// The debug pod is never restarted so that it does not reboot the node again.
+func RebootNode(apiClient *clients.Settings, nodeName, namespace, image string) (*pod.Builder, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Rebooting node '%s' from debug pod '%s'", nodeName, RebootPodName)
+
+	rebootPod, err := pod.NewBuilder(apiClient, RebootPodName, namespace, image).
+		DefineOnNode(nodeName).
+		WithHostPid(true).
+		WithRestartPolicy(corev1.RestartPolicyNever).
+		RedefineDefaultCMD([]string{"/bin/sh", "-c", "sleep 5 && chroot /host systemctl reboot"}).
+		WithPrivilegedFlag().
+		WithHostPathVolume(hostRootVolume, "/", "/host").
+		Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the reboot pod of node %s: %w", nodeName, err)
+	}
+
+	return rebootPod, nil
+}
+
+// NodeRebooted waits until the node booted with a boot ID other than previousBootID and is ready again. The API
+// server being unreachable while the node reboots, e.g. on Single-Node OpenShift, does not stop the wait.
+func NodeRebooted(apiClient *clients.Settings, nodeName, previousBootID string, pollInterval,
+	timeout time.Duration) error {
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' pull from cluster error: %v", nodeName, err)
+
+				return false, nil
+			}
+
+			if nodeBuilder.Object.Status.NodeInfo.BootID == previousBootID {
+				glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' did not reboot yet", nodeName)
+
+				return false, nil
+			}
+
+			ready, err := nodeBuilder.IsReady()
+			if err != nil || !ready {
+				glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' rebooted and is not ready yet", nodeName)
+
+				return false, nil
+			}
+
+			return true, nil
+		})
+	if err != nil {
+		return fmt.Errorf("node %s did not reboot and become ready: %w", nodeName, err)
+	}
+
+	return nil
+}
+
+// NodeOperandsRestarted waits until all the GPU operator operand pods of the node run containers started after since,
+// i.e. restarted after the node rebooted, and are ready, and the driver answers nvidia-smi.
+func NodeOperandsRestarted(apiClient *clients.Settings, nodeName string, since time.Time, pollInterval,
+	timeout time.Duration) error {
+	start := time.Now()
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			operandPods, err := nodeOperandPods(apiClient, nodeName)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Info(err)
+
+				return false, nil
+			}
+
+			if len(operandPods) == 0 {
+				glog.V(gpuparams.GpuLogLevel).Infof("No GPU operator operand runs on node '%s' yet", nodeName)
+
+				return false, nil
+			}
+
+			for _, operandPod := range operandPods {
+				if !podReady(operandPod.Object) || !containersStartedAfter(operandPod.Object, since) {
+					glog.V(gpuparams.GpuLogLevel).Infof("Pod '%s' of node '%s' did not restart and get ready yet",
+						operandPod.Object.Name, nodeName)
+
+					return false, nil
+				}
+			}
+
+			return true, nil
+		})
+	if err != nil {
+		return fmt.Errorf("the GPU operator operands of node %s did not restart: %w", nodeName, err)
+	}
+
+	driverPod, err := nodePod(apiClient, nvidiagpu.NvidiaGPUNamespace, driverupgrade.DriverLabel, nodeName)
+	if err != nil {
+		return err
+	}
+
+	return driverListsGPUs(driverPod, pollInterval, timeout-time.Since(start))
+}
+
+// containersStartedAfter returns true when all the running containers of the pod started after since.
+func containersStartedAfter(nodePod *corev1.Pod, since time.Time) bool {
+	for _, containerStatus := range nodePod.Status.ContainerStatuses {
+		if containerStatus.State.Running == nil || containerStatus.State.Running.StartedAt.Time.Before(since) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nodeOperandPods returns the GPU operator pods of the node managed by a daemonset.
+func nodeOperandPods(apiClient *clients.Settings, nodeName string) ([]*pod.Builder, error) {
+	gpuOperatorPods, err := nodePods(apiClient, nvidiagpu.NvidiaGPUNamespace, "", nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var operandPods []*pod.Builder
+
+	for _, gpuOperatorPod := range gpuOperatorPods {
+		if owner := metav1.GetControllerOf(gpuOperatorPod.Object); owner != nil && owner.Kind == "DaemonSet" {
+			operandPods = append(operandPods, gpuOperatorPod)
+		}
+	}
+
+	return operandPods, nil
+}
@@ -0,0 +1,144 @@
+package clusterupgrade
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+const (
+	// OSTreeVersionLabel is the NFD label reporting the RHCOS ostree version of a node.
+	OSTreeVersionLabel = "feature.node.kubernetes.io/system-os_release.OSTREE_VERSION"
+	// DriverPodLabel selects the pods of the GPU operator driver daemonsets.
+	DriverPodLabel = "app=nvidia-driver-daemonset"
+	// WorkloadContainerName is the container name of the deployment built by CreateWorkloadDeployment.
+	WorkloadContainerName = "cluster-upgrade-workload-ctr"
+	// WorkloadPodLabel is the label selector of the pods of the deployment built by CreateWorkloadDeployment.
+	WorkloadPodLabel = "app=cluster-upgrade-test-app"
+)
+
+var (
+	isFalse = false
+	isTrue  = true
+)
+
+// NodeState is the RHCOS version of a GPU node and the driver pod running on it.
+type NodeState struct {
+	KernelVersion string
+	OSTreeVersion string
+	DriverPod     *pod.Builder
+}
+
+// GetNodeState returns the kernel and ostree versions of the node and the GPU driver pod scheduled on it.
+func GetNodeState(apiClient *clients.Settings, nodeName string) (*NodeState, error) {
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull node %s: %w", nodeName, err)
+	}
+
+	state := &NodeState{
+		KernelVersion: nodeBuilder.Object.Status.NodeInfo.KernelVersion,
+		OSTreeVersion: nodeBuilder.Object.Labels[OSTreeVersionLabel],
+	}
+
+	driverPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{
+		LabelSelector: DriverPodLabel,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list driver pods on node %s: %w", nodeName, err)
+	}
+
+	if len(driverPods) == 0 {
+		return nil, fmt.Errorf("no driver pod found on node %s", nodeName)
+	}
+
+	state.DriverPod = driverPods[0]
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' runs kernel '%s', ostree '%s' and driver pod '%s'",
+		nodeName, state.KernelVersion, state.OSTreeVersion, state.DriverPod.Object.Name)
+
+	return state, nil
+}
+
+// DriverPodMatchesOSTree returns true when the driver pod belongs to the driver daemonset built for the ostree
+// version. The GPU operator creates one driver daemonset per RHCOS version, suffixed with its ostree version.
+func DriverPodMatchesOSTree(driverPod *pod.Builder, osTreeVersion string) bool {
+	for _, ownerReference := range driverPod.Object.OwnerReferences {
+		if ownerReference.Kind == "DaemonSet" && strings.HasSuffix(ownerReference.Name, osTreeVersion) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DriverPodReady returns true when the driver pod is running and all of its containers are ready.
+func DriverPodReady(driverPod *pod.Builder) bool {
+	if driverPod.Object.Status.Phase != corev1.PodRunning {
+		return false
+	}
+
+	for _, containerStatus := range driverPod.Object.Status.ContainerStatuses {
+		if !containerStatus.Ready {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CreateWorkloadDeployment creates a deployment running a long-running GPU workload that lists its GPU every 30
+// seconds. A deployment is used so that the workload is rescheduled when the nodes are drained by the upgrade.
+func CreateWorkloadDeployment(apiClient *clients.Settings, name, nsname, image string) (*deployment.Builder,
+	error) {
+	return NewWorkloadDeployment(apiClient, name, nsname, image).Create()
+}
+
+// NewWorkloadDeployment returns the deployment created by CreateWorkloadDeployment, e.g. to select its nodes first.
+func NewWorkloadDeployment(apiClient *clients.Settings, name, nsname, image string) *deployment.Builder {
+	container := &corev1.Container{
+		Name:            WorkloadContainerName,
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/sh", "-c", "while nvidia-smi -L; do sleep 30; done"},
+		SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &isFalse,
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("1"),
+			},
+		},
+	}
+
+	workloadBuilder := deployment.NewBuilder(apiClient, name, nsname,
+		map[string]string{"app": "cluster-upgrade-test-app"}, container).
+		WithToleration(corev1.Toleration{
+			Key:      "nvidia.com/gpu",
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		}).
+		WithSecurityContext(&corev1.PodSecurityContext{
+			RunAsNonRoot:   &isTrue,
+			SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+		})
+	proxy.Inject(apiClient, &workloadBuilder.Definition.Spec.Template.Spec)
+
+	return workloadBuilder
+}
@@ -7,29 +7,120 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	yaml "sigs.k8s.io/yaml/goyaml.v2"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/timeouts"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 const (
 	// PathToDefaultParamsFile path to config file with default parameters.
 	PathToDefaultParamsFile = "./default.yaml"
+	// ManagementCluster is the name of the management cluster client, loaded from MNG_KUBECONFIG.
+	ManagementCluster = "management"
+	// HostedCluster is the name of the hosted cluster client, loaded from HOSTED_KUBECONFIG.
+	HostedCluster = "hosted"
 )
 
+// StringMap is a map of strings whose environment variable is a comma separated list of key:value pairs, the
+// values, e.g. image references, possibly containing colons.
+type StringMap map[string]string
+
+// Decode parses the environment variable of the map, implementing envconfig.Decoder.
+func (stringMap *StringMap) Decode(value string) error {
+	decoded := StringMap{}
+
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		key, pairValue, found := strings.Cut(pair, ":")
+		if !found {
+			return fmt.Errorf("invalid map item %q, expected key:value", pair)
+		}
+
+		decoded[strings.TrimSpace(key)] = strings.TrimSpace(pairValue)
+	}
+
+	*stringMap = decoded
+
+	return nil
+}
+
 // GeneralConfig type keeps general configuration.
 type GeneralConfig struct {
-	ReportsDirAbsPath    string `yaml:"reports_dump_dir" envconfig:"REPORTS_DUMP_DIR"`
-	VerboseLevel         string `yaml:"verbose_level" envconfig:"VERBOSE_LEVEL"`
-	DumpFailedTests      bool   `yaml:"dump_failed_tests" envconfig:"DUMP_FAILED_TESTS"`
-	DryRun               bool   `yaml:"dry_run" envconfig:"DRY_RUN"`
-	KubernetesRolePrefix string `yaml:"kubernetes_role_prefix" envconfig:"KUBERNETES_ROLE_PREFIX"`
-	WorkerLabelEnvVar    string `yaml:"worker_label" envconfig:"WORKER_LABEL"`
-	WorkerLabel          string
-	ControlPlaneLabel    string `yaml:"control_plane_label" envconfig:"CONTROL_PLANE_LABEL"`
-	WorkerLabelMap       map[string]string
-	ControlPlaneLabelMap map[string]string
+	ReportsDirAbsPath        string        `yaml:"reports_dump_dir" envconfig:"REPORTS_DUMP_DIR"`
+	VerboseLevel             string        `yaml:"verbose_level" envconfig:"VERBOSE_LEVEL"`
+	DumpFailedTests          bool          `yaml:"dump_failed_tests" envconfig:"DUMP_FAILED_TESTS"`
+	HTMLReport               bool          `yaml:"html_report" envconfig:"HTML_REPORT"`
+	TimingReport             bool          `yaml:"timing_report" envconfig:"TIMING_REPORT"`
+	TimeBudgetAction         string        `yaml:"time_budget_action" envconfig:"TIME_BUDGET_ACTION"`
+	EventLog                 bool          `yaml:"event_log" envconfig:"EVENT_LOG"`
+	LeakCheck                string        `yaml:"leak_check" envconfig:"LEAK_CHECK"`
+	GPUMemoryCheck           string        `yaml:"gpu_memory_check" envconfig:"GPU_MEMORY_CHECK"`
+	GPUMemoryLeakMiB         int           `yaml:"gpu_memory_leak_mib" envconfig:"GPU_MEMORY_LEAK_MIB"`
+	InterruptCleanup         bool          `yaml:"interrupt_cleanup" envconfig:"INTERRUPT_CLEANUP"`
+	LabelCheck               string        `yaml:"label_check" envconfig:"LABEL_CHECK"`
+	KernelLogWindow          time.Duration `yaml:"kernel_log_window" envconfig:"KERNEL_LOG_WINDOW"`
+	TimeoutProfile           string        `yaml:"timeout_profile" envconfig:"TIMEOUT_PROFILE"`
+	OperandLogStream         bool          `yaml:"operand_log_stream" envconfig:"OPERAND_LOG_STREAM"`
+	MustGatherScriptsDir     string        `yaml:"must_gather_scripts_dir" envconfig:"MUST_GATHER_SCRIPTS_DIR"`
+	MustGatherTimeout        time.Duration `yaml:"must_gather_timeout" envconfig:"MUST_GATHER_TIMEOUT"`
+	MustGatherSizeCapMB      int64         `yaml:"must_gather_size_cap_mb" envconfig:"MUST_GATHER_SIZE_CAP_MB"`
+	MustGatherImages         StringMap     `yaml:"must_gather_images" envconfig:"MUST_GATHER_IMAGES"`
+	MustGatherArgs           StringMap     `yaml:"must_gather_args" envconfig:"MUST_GATHER_ARGS"`
+	NotificationWebhookURL   string        `yaml:"notification_webhook_url" envconfig:"NOTIFICATION_WEBHOOK_URL" secret:"true"`
+	NotificationArtifactsURL string        `yaml:"notification_artifacts_url" envconfig:"NOTIFICATION_ARTIFACTS_URL"`
+	JiraURL                  string        `yaml:"jira_url" envconfig:"JIRA_URL"`
+	JiraProject              string        `yaml:"jira_project" envconfig:"JIRA_PROJECT"`
+	JiraUser                 string        `yaml:"jira_user" envconfig:"JIRA_USER"`
+	JiraToken                string        `envconfig:"JIRA_TOKEN" secret:"true"`
+	JiraIssueType            string        `yaml:"jira_issue_type" envconfig:"JIRA_ISSUE_TYPE"`
+	JiraLabels               string        `yaml:"jira_labels" envconfig:"JIRA_LABELS"`
+	ReportPortalURL          string        `yaml:"reportportal_url" envconfig:"REPORTPORTAL_URL"`
+	ReportPortalProject      string        `yaml:"reportportal_project" envconfig:"REPORTPORTAL_PROJECT"`
+	ReportPortalToken        string        `envconfig:"REPORTPORTAL_TOKEN" secret:"true"`
+	ReportPortalAttributes   string        `yaml:"reportportal_attributes" envconfig:"REPORTPORTAL_ATTRIBUTES"`
+	PolarionProjectID        string        `yaml:"polarion_project_id" envconfig:"POLARION_PROJECT_ID"`
+	PolarionTestRunID        string        `yaml:"polarion_testrun_id" envconfig:"POLARION_TESTRUN_ID"`
+	PolarionTestRunTitle     string        `yaml:"polarion_testrun_title" envconfig:"POLARION_TESTRUN_TITLE"`
+	ArtifactsBucket          string        `yaml:"artifacts_bucket" envconfig:"ARTIFACTS_BUCKET"`
+	ArtifactsEndpoint        string        `yaml:"artifacts_endpoint" envconfig:"ARTIFACTS_ENDPOINT"`
+	ArtifactsRegion          string        `yaml:"artifacts_region" envconfig:"ARTIFACTS_REGION"`
+	ArtifactsPrefix          string        `yaml:"artifacts_prefix" envconfig:"ARTIFACTS_PREFIX"`
+	ArtifactsPublicURL       string        `yaml:"artifacts_public_url" envconfig:"ARTIFACTS_PUBLIC_URL"`
+	ArtifactsAccessKey       string        `envconfig:"ARTIFACTS_ACCESS_KEY" secret:"true"`
+	ArtifactsSecretKey       string        `envconfig:"ARTIFACTS_SECRET_KEY" secret:"true"`
+	ArtifactsSessionToken    string        `envconfig:"ARTIFACTS_SESSION_TOKEN" secret:"true"`
+	FlakeAttempts            int           `yaml:"flake_attempts" envconfig:"FLAKE_ATTEMPTS"`
+	ResultsStore             string        `yaml:"results_store" envconfig:"RESULTS_STORE"`
+	ResultsStoreToken        string        `envconfig:"RESULTS_STORE_TOKEN" secret:"true"`
+	ResultsCluster           string        `yaml:"results_cluster" envconfig:"RESULTS_CLUSTER"`
+	ResultsHistory           int           `yaml:"results_history" envconfig:"RESULTS_HISTORY"`
+	ManagementKubeconfig     string        `yaml:"mng_kubeconfig" envconfig:"MNG_KUBECONFIG"`
+	HostedKubeconfig         string        `yaml:"hosted_kubeconfig" envconfig:"HOSTED_KUBECONFIG"`
+	HostedClusterName        string        `yaml:"hosted_cluster_name" envconfig:"HOSTED_CLUSTER_NAME"`
+	HostedClusterNamespace   string        `yaml:"hosted_cluster_namespace" envconfig:"HOSTED_CLUSTER_NAMESPACE"`
+	ClusterKubeconfigs       StringMap     `yaml:"cluster_kubeconfigs" envconfig:"CLUSTER_KUBECONFIGS"`
+	ClientRetryAttempts      int           `yaml:"client_retry_attempts" envconfig:"CLIENT_RETRY_ATTEMPTS"`
+	ClientRetryInterval      time.Duration `yaml:"client_retry_interval" envconfig:"CLIENT_RETRY_INTERVAL"`
+	ClientRetryMaxInterval   time.Duration `yaml:"client_retry_max_interval" envconfig:"CLIENT_RETRY_MAX_INTERVAL"`
+	DryRun                   bool          `yaml:"dry_run" envconfig:"DRY_RUN"`
+	PrerequisitesOnly        bool          `yaml:"prerequisites_only" envconfig:"PREREQUISITES_ONLY"`
+	Disconnected             bool          `yaml:"disconnected" envconfig:"DISCONNECTED"`
+	MirrorRegistry           string        `yaml:"mirror_registry" envconfig:"MIRROR_REGISTRY"`
+	MirrorRegistryInsecure   bool          `yaml:"mirror_registry_insecure" envconfig:"MIRROR_REGISTRY_INSECURE"`
+	ImageMirrors             StringMap     `yaml:"image_mirrors" envconfig:"IMAGE_MIRRORS"`
+	KubernetesRolePrefix     string        `yaml:"kubernetes_role_prefix" envconfig:"KUBERNETES_ROLE_PREFIX"`
+	WorkerLabelEnvVar        string        `yaml:"worker_label" envconfig:"WORKER_LABEL"`
+	WorkerLabel              string
+	ControlPlaneLabel        string `yaml:"control_plane_label" envconfig:"CONTROL_PLANE_LABEL"`
+	WorkerLabelMap           map[string]string
+	ControlPlaneLabelMap     map[string]string
 }
 
 // NewConfig returns instance of GeneralConfig config type.
@@ -38,6 +129,12 @@ func NewConfig() *GeneralConfig {
 
 	var conf GeneralConfig
 
+	if err := loadConfigFile(); err != nil {
+		log.Print(err.Error())
+
+		return nil
+	}
+
 	_, filename, _, _ := runtime.Caller(0)
 	baseDir := filepath.Dir(filename)
 	confFile := filepath.Join(baseDir, PathToDefaultParamsFile)
@@ -52,7 +149,13 @@ func NewConfig() *GeneralConfig {
 	err = readEnv(&conf)
 
 	if err != nil {
-		log.Print("Error to read environment variables")
+		log.Printf("Error to read environment variables: %v", err)
+
+		return nil
+	}
+
+	if err := validateConfigs(&conf); err != nil {
+		log.Print(err.Error())
 
 		return nil
 	}
@@ -68,12 +171,101 @@ func NewConfig() *GeneralConfig {
 	return &conf
 }
 
+// GetClusterKubeconfigs returns the kubeconfig paths of the named clusters: the management and hosted clusters, and
+// the clusters of CLUSTER_KUBECONFIGS.
+func (cfg *GeneralConfig) GetClusterKubeconfigs() map[string]string {
+	kubeconfigs := map[string]string{
+		ManagementCluster: cfg.ManagementKubeconfig,
+		HostedCluster:     cfg.HostedKubeconfig,
+	}
+
+	for clusterName, kubeconfig := range cfg.ClusterKubeconfigs {
+		kubeconfigs[clusterName] = kubeconfig
+	}
+
+	return kubeconfigs
+}
+
+// GetHostedControlPlaneNamespace returns the namespace of the management cluster running the control plane of the
+// HyperShift hosted cluster, or an empty string when HOSTED_CLUSTER_NAME is not set.
+func (cfg *GeneralConfig) GetHostedControlPlaneNamespace() string {
+	if cfg.HostedClusterName == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s-%s", cfg.HostedClusterNamespace, cfg.HostedClusterName)
+}
+
+// IsImageMirroring returns true when the images of the suites are resolved through the mirrors of a disconnected
+// cluster, i.e. DISCONNECTED, MIRROR_REGISTRY or IMAGE_MIRRORS is set.
+func (cfg *GeneralConfig) IsImageMirroring() bool {
+	return cfg.Disconnected || cfg.MirrorRegistry != "" || len(cfg.ImageMirrors) > 0
+}
+
+// GetClientRetryBackoff returns the exponential backoff of the client operations retried on conflicts, throttling
+// and transient API unavailability.
+func (cfg *GeneralConfig) GetClientRetryBackoff() wait.Backoff {
+	return wait.Backoff{
+		Steps:    cfg.ClientRetryAttempts,
+		Duration: cfg.ClientRetryInterval,
+		Factor:   2.0,
+		Jitter:   0.1,
+		Cap:      cfg.ClientRetryMaxInterval,
+	}
+}
+
+// GetTimeoutProfile returns the preset timeout profile of TIMEOUT_PROFILE, scaling the operator install, driver
+// readiness, workload completion and must-gather timeouts and the poll intervals of the suites.
+func (cfg *GeneralConfig) GetTimeoutProfile() (timeouts.Profile, error) {
+	return timeouts.GetProfile(cfg.TimeoutProfile)
+}
+
 // GetJunitReportPath returns full path to the junit report file.
 func (cfg *GeneralConfig) GetJunitReportPath(file string) string {
 	reportFileName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(filepath.Base(file)))
 	return fmt.Sprintf("%s_junit.xml", filepath.Join(cfg.ReportsDirAbsPath, reportFileName))
 }
 
+// GetHTMLReportPath returns full path to the HTML report file.
+func (cfg *GeneralConfig) GetHTMLReportPath(file string) string {
+	reportFileName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(filepath.Base(file)))
+	return fmt.Sprintf("%s_report.html", filepath.Join(cfg.ReportsDirAbsPath, reportFileName))
+}
+
+// GetPolarionReportPath returns full path to the Polarion results file.
+func (cfg *GeneralConfig) GetPolarionReportPath(file string) string {
+	reportFileName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(filepath.Base(file)))
+	return fmt.Sprintf("%s_polarion.xml", filepath.Join(cfg.ReportsDirAbsPath, reportFileName))
+}
+
+// GetTimingReportPath returns full path to the timing report file.
+func (cfg *GeneralConfig) GetTimingReportPath(file string) string {
+	reportFileName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(filepath.Base(file)))
+	return fmt.Sprintf("%s_timing.txt", filepath.Join(cfg.ReportsDirAbsPath, reportFileName))
+}
+
+// GetFlakeSummaryPath returns full path to the flake summary file.
+func (cfg *GeneralConfig) GetFlakeSummaryPath(file string) string {
+	reportFileName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(filepath.Base(file)))
+	return fmt.Sprintf("%s_flakes.json", filepath.Join(cfg.ReportsDirAbsPath, reportFileName))
+}
+
+// GetOperandLogsPath returns full path to the directory of the operand logs streamed during the suite.
+func (cfg *GeneralConfig) GetOperandLogsPath(file string) string {
+	reportFileName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(filepath.Base(file)))
+	return fmt.Sprintf("%s_operand_logs", filepath.Join(cfg.ReportsDirAbsPath, reportFileName))
+}
+
+// GetEventLogPath returns full path to the event log shared by the suites.
+func (cfg *GeneralConfig) GetEventLogPath() string {
+	return filepath.Join(cfg.ReportsDirAbsPath, "events.jsonl")
+}
+
+// GetArtifactIndexPath returns full path to the index of the artifacts collected by the suites.
+func (cfg *GeneralConfig) GetArtifactIndexPath() string {
+	return filepath.Join(cfg.ReportsDirAbsPath, "index.json")
+}
+
 // GetArtifactPath return full path to a file in the report directory.
 func (cfg *GeneralConfig) GetReportPath(file string) string {
 	fileName := filepath.Base(file)
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "sigs.k8s.io/yaml/goyaml.v2"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidianetworkconfig"
+)
+
+// ConfigFileEnvVar is the environment variable holding the path to the optional YAML or JSON config file.
+const ConfigFileEnvVar = "CONFIG_FILE"
+
+// loadConfigFile sets the environment variables of the config file that are not already set, the config file keys
+// being environment variable names, so that the environment overrides the config file and the config file the
+// defaults of every config struct.
+func loadConfigFile() error {
+	configFile := os.Getenv(ConfigFileEnvVar)
+	if configFile == "" {
+		return nil
+	}
+
+	log.Printf("Loading config file %s", configFile)
+
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", configFile, err)
+	}
+
+	entries := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &entries); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", configFile, err)
+	}
+
+	knownEnvVars := map[string]bool{}
+	for _, envVar := range configEnvVars() {
+		knownEnvVars[envVar] = true
+	}
+
+	var unknownKeys []string
+
+	for key, value := range entries {
+		envVar := strings.ToUpper(key)
+		if !knownEnvVars[envVar] {
+			unknownKeys = append(unknownKeys, key)
+
+			continue
+		}
+
+		if _, set := os.LookupEnv(envVar); set {
+			continue
+		}
+
+		if err := os.Setenv(envVar, envValue(value)); err != nil {
+			return fmt.Errorf("failed to set %s from config file %s: %w", envVar, configFile, err)
+		}
+	}
+
+	if len(unknownKeys) > 0 {
+		sort.Strings(unknownKeys)
+
+		return fmt.Errorf("unknown keys in config file %s: %s", configFile, strings.Join(unknownKeys, ", "))
+	}
+
+	return nil
+}
+
+// envValue formats a config file value the way envconfig parses environment variables: comma separated lists
+// and key:value maps.
+func envValue(value interface{}) string {
+	switch typedValue := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		items := make([]string, 0, len(typedValue))
+		for _, item := range typedValue {
+			items = append(items, envValue(item))
+		}
+
+		return strings.Join(items, ",")
+	case map[interface{}]interface{}:
+		items := make([]string, 0, len(typedValue))
+		for key, item := range typedValue {
+			items = append(items, fmt.Sprintf("%v:%s", key, envValue(item)))
+		}
+
+		sort.Strings(items)
+
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(typedValue)
+	}
+}
+
+// Validate returns an error describing the invalid or inconsistent parameters of the general config.
+func (cfg *GeneralConfig) Validate() error {
+	var problems []string
+
+	if _, err := strconv.Atoi(cfg.VerboseLevel); err != nil {
+		problems = append(problems, fmt.Sprintf("VERBOSE_LEVEL %q is not an integer", cfg.VerboseLevel))
+	}
+
+	if cfg.TimeBudgetAction != "warn" && cfg.TimeBudgetAction != "fail" {
+		problems = append(problems, fmt.Sprintf("TIME_BUDGET_ACTION %q is neither warn nor fail",
+			cfg.TimeBudgetAction))
+	}
+
+	if cfg.LeakCheck != "off" && cfg.LeakCheck != "warn" && cfg.LeakCheck != "strict" {
+		problems = append(problems, fmt.Sprintf("LEAK_CHECK %q is neither off, warn nor strict", cfg.LeakCheck))
+	}
+
+	if cfg.GPUMemoryCheck != "off" && cfg.GPUMemoryCheck != "warn" && cfg.GPUMemoryCheck != "strict" {
+		problems = append(problems, fmt.Sprintf("GPU_MEMORY_CHECK %q is neither off, warn nor strict",
+			cfg.GPUMemoryCheck))
+	}
+
+	if cfg.GPUMemoryLeakMiB < 0 {
+		problems = append(problems, fmt.Sprintf("GPU_MEMORY_LEAK_MIB %d is negative", cfg.GPUMemoryLeakMiB))
+	}
+
+	if cfg.LabelCheck != "off" && cfg.LabelCheck != "warn" && cfg.LabelCheck != "strict" {
+		problems = append(problems, fmt.Sprintf("LABEL_CHECK %q is neither off, warn nor strict", cfg.LabelCheck))
+	}
+
+	if cfg.KernelLogWindow < 0 {
+		problems = append(problems, fmt.Sprintf("KERNEL_LOG_WINDOW %s is negative", cfg.KernelLogWindow))
+	}
+
+	if _, err := cfg.GetTimeoutProfile(); err != nil {
+		problems = append(problems, fmt.Sprintf("TIMEOUT_PROFILE: %v", err))
+	}
+
+	if cfg.FlakeAttempts < 0 {
+		problems = append(problems, fmt.Sprintf("FLAKE_ATTEMPTS %d is negative", cfg.FlakeAttempts))
+	}
+
+	if cfg.ResultsStore != "" && cfg.ResultsHistory < 1 {
+		problems = append(problems, fmt.Sprintf("RESULTS_HISTORY %d is not positive", cfg.ResultsHistory))
+	}
+
+	if cfg.MustGatherSizeCapMB < 0 {
+		problems = append(problems, fmt.Sprintf("MUST_GATHER_SIZE_CAP_MB %d is negative", cfg.MustGatherSizeCapMB))
+	}
+
+	if cfg.MustGatherScriptsDir != "" && cfg.MustGatherTimeout <= 0 {
+		problems = append(problems, "MUST_GATHER_TIMEOUT must be positive to collect must-gathers")
+	}
+
+	if cfg.ClientRetryAttempts < 1 {
+		problems = append(problems, fmt.Sprintf("CLIENT_RETRY_ATTEMPTS %d is not positive", cfg.ClientRetryAttempts))
+	}
+
+	if cfg.ClientRetryInterval <= 0 || cfg.ClientRetryMaxInterval < cfg.ClientRetryInterval {
+		problems = append(problems, "CLIENT_RETRY_INTERVAL must be positive and at most CLIENT_RETRY_MAX_INTERVAL")
+	}
+
+	if cfg.HostedClusterName != "" && (cfg.ManagementKubeconfig == "" || cfg.HostedClusterNamespace == "") {
+		problems = append(problems, "HOSTED_CLUSTER_NAME requires MNG_KUBECONFIG and HOSTED_CLUSTER_NAMESPACE")
+	}
+
+	if strings.Contains(cfg.MirrorRegistry, "://") {
+		problems = append(problems, fmt.Sprintf("MIRROR_REGISTRY %q must be a registry host without scheme",
+			cfg.MirrorRegistry))
+	}
+
+	for source, imageMirror := range cfg.ImageMirrors {
+		if source == "" || imageMirror == "" || strings.Contains(source+imageMirror, "://") {
+			problems = append(problems, fmt.Sprintf("IMAGE_MIRRORS %q:%q must map a repository to a mirror "+
+				"repository without scheme", source, imageMirror))
+		}
+	}
+
+	reportPortal := []string{cfg.ReportPortalURL, cfg.ReportPortalProject, cfg.ReportPortalToken}
+	if set := countSet(reportPortal...); set != 0 && set != len(reportPortal) {
+		problems = append(problems,
+			"REPORTPORTAL_URL, REPORTPORTAL_PROJECT and REPORTPORTAL_TOKEN must be set together")
+	}
+
+	jira := []string{cfg.JiraURL, cfg.JiraProject, cfg.JiraToken}
+	if set := countSet(jira...); set != 0 && set != len(jira) {
+		problems = append(problems, "JIRA_URL, JIRA_PROJECT and JIRA_TOKEN must be set together")
+	}
+
+	if cfg.ArtifactsBucket != "" && countSet(cfg.ArtifactsEndpoint, cfg.ArtifactsRegion, cfg.ArtifactsAccessKey,
+		cfg.ArtifactsSecretKey) != 4 {
+		problems = append(problems, "ARTIFACTS_BUCKET requires ARTIFACTS_ENDPOINT, ARTIFACTS_REGION, "+
+			"ARTIFACTS_ACCESS_KEY and ARTIFACTS_SECRET_KEY")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid general config: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// validateConfigs validates the general, GPU and NFD configs, returning an error listing the invalid parameters of
+// all of them, so that a misconfigured suite fails before its first spec.
+func validateConfigs(cfg *GeneralConfig) error {
+	var problems []string
+
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if _, err := nvidiagpuconfig.LoadNvidiaGPUConfig(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if _, err := nfd.NewNFDConfig(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// EffectiveConfig returns the effective parameters of the general, GPU, network and NFD configs, one KEY=value
+// line per environment variable, secrets being masked.
+func (cfg *GeneralConfig) EffectiveConfig() string {
+	configs := []interface{}{cfg}
+
+	if gpuConfig := nvidiagpuconfig.NewNvidiaGPUConfig(); gpuConfig != nil {
+		configs = append(configs, gpuConfig)
+	}
+
+	if networkConfig := nvidianetworkconfig.NewNvidiaNetworkConfig(); networkConfig != nil {
+		configs = append(configs, networkConfig)
+	}
+
+	if nfdConfig, err := nfd.NewNFDConfig(); err == nil {
+		configs = append(configs, nfdConfig)
+	}
+
+	var lines []string
+
+	for _, config := range configs {
+		forEachEnvField(config, func(envVar string, field reflect.StructField, value reflect.Value) {
+			formatted := fmt.Sprint(value.Interface())
+			if formatted != "" && isSecret(field) {
+				formatted = "<redacted>"
+			}
+
+			lines = append(lines, fmt.Sprintf("%s=%s", envVar, formatted))
+		})
+	}
+
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
+
+// configEnvVars returns the environment variables of the general, GPU, network and NFD configs.
+func configEnvVars() []string {
+	var envVars []string
+
+	for _, config := range []interface{}{&GeneralConfig{}, &nvidiagpuconfig.NvidiaGPUConfig{},
+		&nvidianetworkconfig.NvidiaNetworkConfig{}, &nfd.NFDConfig{}} {
+		forEachEnvField(config, func(envVar string, _ reflect.StructField, _ reflect.Value) {
+			envVars = append(envVars, envVar)
+		})
+	}
+
+	return envVars
+}
+
+// forEachEnvField calls visit with the envconfig environment variable, the type field and the value of the fields of
+// the struct pointed by config.
+func forEachEnvField(config interface{},
+	visit func(envVar string, field reflect.StructField, value reflect.Value)) {
+	structValue := reflect.ValueOf(config).Elem()
+
+	for index := 0; index < structValue.NumField(); index++ {
+		field := structValue.Type().Field(index)
+
+		envVar := field.Tag.Get("envconfig")
+		if envVar == "" {
+			continue
+		}
+
+		visit(envVar, field, structValue.Field(index))
+	}
+}
+
+// isSecret returns true when the field is tagged secret:"true", its value being masked in the effective config.
+func isSecret(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+func countSet(values ...string) int {
+	set := 0
+
+	for _, value := range values {
+		if value != "" {
+			set++
+		}
+	}
+
+	return set
+}
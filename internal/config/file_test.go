@@ -0,0 +1,33 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEffectiveConfigMasksSecrets(t *testing.T) {
+	const apiKey = "nvapi-not-a-real-key"
+
+	t.Setenv("NVIDIAGPU_NIM_NGC_API_KEY", apiKey)
+	t.Setenv("NVIDIAGPU_NIM_IMAGE", "nvcr.io/nim/meta/llama")
+
+	cfg := &GeneralConfig{JiraToken: "jira-token", JiraProject: "NVCI"}
+	effective := cfg.EffectiveConfig()
+	lines := strings.Split(effective, "\n")
+
+	for _, line := range []string{
+		"NVIDIAGPU_NIM_NGC_API_KEY=<redacted>",
+		"JIRA_TOKEN=<redacted>",
+		"JIRA_PROJECT=NVCI",
+		"NVIDIAGPU_NIM_IMAGE=nvcr.io/nim/meta/llama",
+	} {
+		if !slices.Contains(lines, line) {
+			t.Errorf("effective config does not contain %q:\n%s", line, effective)
+		}
+	}
+
+	if strings.Contains(effective, apiKey) {
+		t.Errorf("effective config contains the NIM NGC API key in clear text:\n%s", effective)
+	}
+}
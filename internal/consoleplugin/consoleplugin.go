@@ -0,0 +1,180 @@
+package consoleplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/golang/glog"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// PluginName is the name of the NVIDIA GPU console plugin, its helm release, deployment, service and
+	// ConsolePlugin.
+	PluginName = "console-plugin-nvidia-gpu"
+	// PluginNamespace is the namespace where the console plugin is installed.
+	PluginNamespace = nvidiagpu.NvidiaGPUNamespace
+	// ChartRepository is the helm repository hosting the console plugin chart.
+	ChartRepository = "https://rh-ecosystem-edge.github.io/console-plugin-nvidia-gpu"
+	// ManifestPath is the path of the plugin manifest served by the plugin backend.
+	ManifestPath = "/plugin-manifest.json"
+
+	consoleOperatorName = "cluster"
+)
+
+// Manifest is the subset of the plugin manifest served by the plugin backend checked by the tests.
+type Manifest struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Extensions []struct {
+		Type string `json:"type"`
+	} `json:"extensions"`
+}
+
+// BackendService is the service serving the plugin assets, referenced by the ConsolePlugin.
+type BackendService struct {
+	Name      string
+	Namespace string
+	Port      int64
+	BasePath  string
+}
+
+// Install installs the given chart version of the console plugin with helm, the latest version when chartVersion
+// is empty.
+func Install(chartVersion string, timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Installing console plugin chart version '%s' in namespace '%s'",
+		chartVersion, PluginNamespace)
+
+	args := []string{"upgrade", "--install", PluginName, PluginName,
+		"--repo", ChartRepository,
+		"--namespace", PluginNamespace,
+		"--wait", "--timeout", timeout.String()}
+
+	if chartVersion != "" {
+		args = append(args, "--version", chartVersion)
+	}
+
+	if output, err := exec.Command("helm", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to install the console plugin: %w: %s", err, output)
+	}
+
+	return nil
+}
+
+// Uninstall uninstalls the console plugin helm release.
+func Uninstall(timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Uninstalling console plugin from namespace '%s'", PluginNamespace)
+
+	cmd := exec.Command("helm", "uninstall", PluginName, "--namespace", PluginNamespace,
+		"--wait", "--timeout", timeout.String())
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to uninstall the console plugin: %w: %s", err, output)
+	}
+
+	return nil
+}
+
+// SetEnabledInConsole adds the console plugin to the plugins enabled in the OpenShift console, or removes it. It
+// returns whether the plugin was enabled before.
+func SetEnabledInConsole(apiClient *clients.Settings, enabled bool) (bool, error) {
+	console := &operatorv1.Console{}
+
+	if err := apiClient.Get(context.TODO(), types.NamespacedName{Name: consoleOperatorName}, console); err != nil {
+		return false, fmt.Errorf("failed to get console operator config %s: %w", consoleOperatorName, err)
+	}
+
+	previous := slices.Contains(console.Spec.Plugins, PluginName)
+	if previous == enabled {
+		return previous, nil
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Setting console plugin '%s' enabled to '%t' in the console", PluginName,
+		enabled)
+
+	if enabled {
+		console.Spec.Plugins = append(console.Spec.Plugins, PluginName)
+	} else {
+		console.Spec.Plugins = slices.DeleteFunc(console.Spec.Plugins, func(plugin string) bool {
+			return plugin == PluginName
+		})
+	}
+
+	if err := apiClient.Update(context.TODO(), console); err != nil {
+		return previous, fmt.Errorf("failed to update console operator config %s plugins: %w",
+			consoleOperatorName, err)
+	}
+
+	return previous, nil
+}
+
+// PullConsolePlugin retrieves the ConsolePlugin of the console plugin from the cluster.
+func PullConsolePlugin(apiClient *clients.Settings) (*unstructured.Unstructured, error) {
+	consolePlugin, err := apiClient.Resource(GetConsolePluginGVR()).Get(context.TODO(), PluginName,
+		metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ConsolePlugin %s: %w", PluginName, err)
+	}
+
+	return consolePlugin, nil
+}
+
+// GetBackendService returns the backend service of a console.openshift.io/v1 ConsolePlugin.
+func GetBackendService(consolePlugin *unstructured.Unstructured) (*BackendService, error) {
+	backendType, _, _ := unstructured.NestedString(consolePlugin.Object, "spec", "backend", "type")
+	if backendType != "Service" {
+		return nil, fmt.Errorf("ConsolePlugin %s backend type is '%s' instead of Service", consolePlugin.GetName(),
+			backendType)
+	}
+
+	service, found, err := unstructured.NestedMap(consolePlugin.Object, "spec", "backend", "service")
+	if err != nil || !found {
+		return nil, fmt.Errorf("ConsolePlugin %s has no backend service: %v", consolePlugin.GetName(), err)
+	}
+
+	backendService := &BackendService{}
+	backendService.Name, _, _ = unstructured.NestedString(service, "name")
+	backendService.Namespace, _, _ = unstructured.NestedString(service, "namespace")
+	backendService.Port, _, _ = unstructured.NestedInt64(service, "port")
+	backendService.BasePath, _, _ = unstructured.NestedString(service, "basePath")
+
+	return backendService, nil
+}
+
+// GetManifest fetches the plugin manifest from the backend service through the API server service proxy.
+func GetManifest(apiClient *clients.Settings, backendService *BackendService) (*Manifest, error) {
+	manifestPath := path.Join("/", backendService.BasePath, ManifestPath)
+
+	output, err := apiClient.K8sClient.CoreV1().Services(backendService.Namespace).ProxyGet("https",
+		backendService.Name, fmt.Sprint(backendService.Port), manifestPath, nil).DoRaw(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s from service %s/%s: %w", manifestPath, backendService.Namespace,
+			backendService.Name, err)
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(output, manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode plugin manifest %q: %w", output, err)
+	}
+
+	return manifest, nil
+}
+
+// GetConsolePluginGVR returns the ConsolePlugin GroupVersionResource.
+func GetConsolePluginGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: "console.openshift.io", Version: "v1", Resource: "consoleplugins",
+	}
+}
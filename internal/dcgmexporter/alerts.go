@@ -0,0 +1,97 @@
+package dcgmexporter
+
+import (
+	"fmt"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// AlertRuleName is the name of the PrometheusRule of the recommended GPU alerting rules.
+	AlertRuleName = "nvidia-gpu-alerts"
+	// AlertRuleGroup is the name of the rule group of the recommended GPU alerting rules.
+	AlertRuleGroup = "nvidia-gpu.rules"
+
+	// HighUtilizationAlert fires when a GPU is fully utilized for a while, e.g. under gpu-burn.
+	HighUtilizationAlert = "GPUHighUtilization"
+	// HighTemperatureAlert fires when a GPU runs hot for a while.
+	HighTemperatureAlert = "GPUHighTemperature"
+	// XIDErrorAlert fires when a GPU hits an XID error.
+	XIDErrorAlert = "GPUXIDError"
+	// FramebufferFullAlert fires when the framebuffer memory of a GPU is nearly full for a while.
+	FramebufferFullAlert = "GPUFramebufferNearlyFull"
+	// ExporterDownAlert fires when Prometheus cannot scrape a DCGM exporter.
+	ExporterDownAlert = "DCGMExporterDown"
+
+	// HighUtilizationThreshold is the GPU utilization, in percent, above which HighUtilizationAlert fires.
+	HighUtilizationThreshold = 90
+	// HighTemperatureThreshold is the GPU temperature, in degrees Celsius, above which HighTemperatureAlert fires.
+	HighTemperatureThreshold = 85
+)
+
+// HealthyGPUAlerts are the recommended alerts that must not fire on healthy GPUs, loaded or not.
+var HealthyGPUAlerts = []string{XIDErrorAlert, ExporterDownAlert}
+
+// RecommendedAlertRules returns the PrometheusRule of the recommended GPU alerting rules on the DCGM exporter
+// metrics, created in the GPU operator namespace which is monitored by the platform Prometheus.
+func RecommendedAlertRules() *monitoringv1.PrometheusRule {
+	return &monitoringv1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AlertRuleName,
+			Namespace: nvidiagpu.NvidiaGPUNamespace,
+		},
+		Spec: monitoringv1.PrometheusRuleSpec{
+			Groups: []monitoringv1.RuleGroup{{
+				Name: AlertRuleGroup,
+				Rules: []monitoringv1.Rule{
+					alertRule(HighUtilizationAlert, fmt.Sprintf("avg by (%s, %s) (avg_over_time(%s[2m])) > %d",
+						HostnameLabel, GPULabel, UtilizationMetric, HighUtilizationThreshold), "1m", "warning",
+						"GPU {{ $labels.gpu }} of node {{ $labels.Hostname }} is fully utilized."),
+					alertRule(HighTemperatureAlert, fmt.Sprintf("max by (%s, %s) (DCGM_FI_DEV_GPU_TEMP) > %d",
+						HostnameLabel, GPULabel, HighTemperatureThreshold), "5m", "warning",
+						"GPU {{ $labels.gpu }} of node {{ $labels.Hostname }} runs at {{ $value }} C."),
+					alertRule(XIDErrorAlert, fmt.Sprintf("max by (%s, %s) (DCGM_FI_DEV_XID_ERRORS) != 0",
+						HostnameLabel, GPULabel), "", "critical",
+						"GPU {{ $labels.gpu }} of node {{ $labels.Hostname }} hit XID error {{ $value }}."),
+					alertRule(FramebufferFullAlert, fmt.Sprintf("max by (%s, %s) (DCGM_FI_DEV_FB_USED / "+
+						"(DCGM_FI_DEV_FB_USED + DCGM_FI_DEV_FB_FREE)) > 0.95", HostnameLabel, GPULabel), "10m",
+						"warning", "The framebuffer of GPU {{ $labels.gpu }} of node {{ $labels.Hostname }} is "+
+							"nearly full."),
+					alertRule(ExporterDownAlert, fmt.Sprintf(`up{namespace="%s", service="%s"} == 0`,
+						nvidiagpu.NvidiaGPUNamespace, ServiceName), "5m", "critical",
+						"Prometheus cannot scrape the DCGM exporter {{ $labels.instance }}."),
+				},
+			}},
+		},
+	}
+}
+
+// RecommendedAlertNames returns the names of the recommended GPU alerts.
+func RecommendedAlertNames() []string {
+	var names []string
+	for _, rule := range RecommendedAlertRules().Spec.Groups[0].Rules {
+		names = append(names, rule.Alert)
+	}
+
+	return names
+}
+
+// alertRule returns an alerting rule firing when the expression holds for pending, immediately when empty.
+func alertRule(name, expression, pending, severity, description string) monitoringv1.Rule {
+	rule := monitoringv1.Rule{
+		Alert:       name,
+		Expr:        intstr.FromString(expression),
+		Labels:      map[string]string{"severity": severity},
+		Annotations: map[string]string{"description": description},
+	}
+
+	if pending != "" {
+		duration := monitoringv1.Duration(pending)
+		rule.For = &duration
+	}
+
+	return rule
+}
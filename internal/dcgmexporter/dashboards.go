@@ -0,0 +1,147 @@
+package dcgmexporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DashboardNamespace is the namespace of the ConfigMaps of the OpenShift console dashboards.
+	DashboardNamespace = "openshift-config-managed"
+	// DashboardLabel selects the ConfigMaps of the OpenShift console dashboards.
+	DashboardLabel = "console.openshift.io/dashboard=true"
+	// DashboardRange is the range and the interval substituted for the Grafana range variables of the queries.
+	DashboardRange = "5m"
+
+	// gpuMetricPrefix prefixes the DCGM exporter metrics, the dashboards querying one being the GPU dashboards.
+	gpuMetricPrefix = "DCGM_"
+)
+
+var (
+	// variableMatcherRegexp matches the label matchers on a Grafana template variable, e.g. gpu=~"$gpu".
+	variableMatcherRegexp = regexp.MustCompile(`([a-zA-Z_]\w*)\s*(=~|!~|=|!=)\s*"[^"]*\$[^"]*"`)
+	// rangeVariableRegexp matches the Grafana range and interval variables, e.g. $__rate_interval.
+	rangeVariableRegexp = regexp.MustCompile(`\$\{?__(rate_interval|interval|range)\}?`)
+)
+
+// Dashboard is a Grafana dashboard of the OpenShift console with its Prometheus queries.
+type Dashboard struct {
+	// ConfigMap is the name of the ConfigMap holding the dashboard.
+	ConfigMap string
+	Title     string
+	Queries   []DashboardQuery
+}
+
+// DashboardQuery is the Prometheus query of a dashboard panel.
+type DashboardQuery struct {
+	Panel string
+	Expr  string
+}
+
+type grafanaPanel struct {
+	Title   string `json:"title"`
+	Targets []struct {
+		Expr string `json:"expr"`
+	} `json:"targets"`
+	// Panels are the panels of a collapsed row.
+	Panels []grafanaPanel `json:"panels"`
+}
+
+// GPUDashboards returns the OpenShift console dashboards querying DCGM exporter metrics, sorted by ConfigMap name.
+func GPUDashboards(apiClient *clients.Settings) ([]Dashboard, error) {
+	configMaps, err := apiClient.ConfigMaps(DashboardNamespace).List(context.TODO(),
+		metav1.ListOptions{LabelSelector: DashboardLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the dashboard ConfigMaps in namespace %s: %w", DashboardNamespace, err)
+	}
+
+	var dashboards []Dashboard
+
+	for _, configMap := range configMaps.Items {
+		for key, data := range configMap.Data {
+			dashboard, err := parseDashboard(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse dashboard %s of ConfigMap %s: %w", key, configMap.Name, err)
+			}
+
+			if !dashboard.queriesGPUMetrics() {
+				continue
+			}
+
+			glog.V(gpuparams.GpuLogLevel).Infof("ConfigMap %s holds GPU dashboard '%s' with %d queries",
+				configMap.Name, dashboard.Title, len(dashboard.Queries))
+
+			dashboard.ConfigMap = configMap.Name
+			dashboards = append(dashboards, *dashboard)
+		}
+	}
+
+	sort.Slice(dashboards, func(i, j int) bool {
+		return dashboards[i].ConfigMap < dashboards[j].ConfigMap
+	})
+
+	return dashboards, nil
+}
+
+// ExpandQuery returns the dashboard query runnable against Prometheus: the label matchers on template variables
+// match any value and the range variables are replaced with DashboardRange.
+func ExpandQuery(expr string) string {
+	expr = variableMatcherRegexp.ReplaceAllString(expr, `$1=~".*"`)
+
+	return rangeVariableRegexp.ReplaceAllString(expr, DashboardRange)
+}
+
+func parseDashboard(data string) (*Dashboard, error) {
+	var grafanaDashboard struct {
+		Title  string         `json:"title"`
+		Panels []grafanaPanel `json:"panels"`
+		// Rows are the panel rows of the dashboards of the former Grafana schema.
+		Rows []struct {
+			Panels []grafanaPanel `json:"panels"`
+		} `json:"rows"`
+	}
+
+	if err := json.Unmarshal([]byte(data), &grafanaDashboard); err != nil {
+		return nil, err
+	}
+
+	dashboard := &Dashboard{Title: grafanaDashboard.Title}
+	dashboard.addQueries(grafanaDashboard.Panels)
+
+	for _, row := range grafanaDashboard.Rows {
+		dashboard.addQueries(row.Panels)
+	}
+
+	return dashboard, nil
+}
+
+func (dashboard *Dashboard) addQueries(panels []grafanaPanel) {
+	for _, panel := range panels {
+		for _, target := range panel.Targets {
+			if strings.TrimSpace(target.Expr) != "" {
+				dashboard.Queries = append(dashboard.Queries, DashboardQuery{Panel: panel.Title, Expr: target.Expr})
+			}
+		}
+
+		dashboard.addQueries(panel.Panels)
+	}
+}
+
+func (dashboard *Dashboard) queriesGPUMetrics() bool {
+	for _, query := range dashboard.Queries {
+		if strings.Contains(query.Expr, gpuMetricPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
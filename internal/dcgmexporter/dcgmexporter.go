@@ -0,0 +1,173 @@
+package dcgmexporter
+
+import (
+	"context"
+	"fmt"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// ServiceName is the name of the service and of the ServiceMonitor of the DCGM exporter.
+	ServiceName = "nvidia-dcgm-exporter"
+	// HostnameLabel is the DCGM exporter metric label holding the node name.
+	HostnameLabel = prometheus.HostnameLabel
+	// GPULabel is the DCGM exporter metric label holding the GPU index.
+	GPULabel = prometheus.GPULabel
+	// ModelNameLabel is the DCGM exporter metric label holding the GPU product name.
+	ModelNameLabel = "modelName"
+	// UtilizationMetric is the GPU utilization metric, in percent.
+	UtilizationMetric = "DCGM_FI_DEV_GPU_UTIL"
+)
+
+var isTrue = true
+
+// MetricRange is a DCGM exporter metric and the range its values must be in.
+type MetricRange struct {
+	Name string
+	Min  float64
+	Max  float64
+}
+
+// DefaultMetrics are the metrics exported by the DCGM exporter default counters and their sane ranges.
+var DefaultMetrics = []MetricRange{
+	{Name: "DCGM_FI_DEV_GPU_TEMP", Min: 1, Max: 120},
+	{Name: "DCGM_FI_DEV_POWER_USAGE", Min: 1, Max: 2000},
+	{Name: UtilizationMetric, Min: 0, Max: 100},
+	{Name: "DCGM_FI_DEV_SM_CLOCK", Min: 1, Max: 5000},
+	{Name: "DCGM_FI_DEV_FB_FREE", Min: 0, Max: 1024 * 1024},
+	{Name: "DCGM_FI_DEV_FB_USED", Min: 0, Max: 1024 * 1024},
+}
+
+// EnableServiceMonitor enables the DCGM exporter ServiceMonitor in the ClusterPolicy. It returns a copy of the
+// previous ClusterPolicy spec so that it can be restored, or nil when the ServiceMonitor was already enabled.
+func EnableServiceMonitor(apiClient *clients.Settings,
+	clusterPolicyName string) (*nvidiagpuv1.ClusterPolicySpec, error) {
+	clusterPolicyBuilder, err := nvidiagpu.Pull(apiClient, clusterPolicyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull ClusterPolicy %s: %w", clusterPolicyName, err)
+	}
+
+	spec := &clusterPolicyBuilder.Definition.Spec
+	if spec.DCGMExporter.ServiceMonitor != nil && spec.DCGMExporter.ServiceMonitor.IsEnabled() {
+		glog.V(gpuparams.GpuLogLevel).Infof("DCGM exporter ServiceMonitor already enabled in ClusterPolicy '%s'",
+			clusterPolicyName)
+
+		return nil, nil
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Enabling the DCGM exporter ServiceMonitor in ClusterPolicy '%s'",
+		clusterPolicyName)
+
+	var previousSpec *nvidiagpuv1.ClusterPolicySpec
+
+	_, err = nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousSpec = definition.Spec.DeepCopy()
+
+		if definition.Spec.DCGMExporter.ServiceMonitor == nil {
+			definition.Spec.DCGMExporter.ServiceMonitor = &nvidiagpuv1.DCGMExporterServiceMonitorConfig{}
+		}
+
+		definition.Spec.DCGMExporter.ServiceMonitor.Enabled = &isTrue
+	})
+	if err != nil {
+		return previousSpec, fmt.Errorf("failed to enable the DCGM exporter ServiceMonitor in ClusterPolicy "+
+			"%s: %w", clusterPolicyName, err)
+	}
+
+	return previousSpec, nil
+}
+
+// ValidateServiceMonitor checks that the DCGM exporter ServiceMonitor selects the DCGM exporter service and
+// scrapes one of its ports.
+func ValidateServiceMonitor(apiClient *clients.Settings, serviceMonitor *monitoringv1.ServiceMonitor) error {
+	service, err := apiClient.Services(nvidiagpu.NvidiaGPUNamespace).Get(context.TODO(), ServiceName,
+		metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get service %s: %w", ServiceName, err)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&serviceMonitor.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("ServiceMonitor %s has an invalid selector: %w", serviceMonitor.Name, err)
+	}
+
+	if selector.Empty() || !selector.Matches(labels.Set(service.Labels)) {
+		return fmt.Errorf("ServiceMonitor %s selector '%s' does not select service %s with labels %v",
+			serviceMonitor.Name, selector, ServiceName, service.Labels)
+	}
+
+	namespaces := serviceMonitor.Spec.NamespaceSelector.MatchNames
+	if !serviceMonitor.Spec.NamespaceSelector.Any && len(namespaces) > 0 {
+		found := false
+
+		for _, ns := range namespaces {
+			found = found || ns == nvidiagpu.NvidiaGPUNamespace
+		}
+
+		if !found {
+			return fmt.Errorf("ServiceMonitor %s namespace selector %v does not select namespace %s",
+				serviceMonitor.Name, namespaces, nvidiagpu.NvidiaGPUNamespace)
+		}
+	}
+
+	if len(serviceMonitor.Spec.Endpoints) == 0 {
+		return fmt.Errorf("ServiceMonitor %s has no endpoint", serviceMonitor.Name)
+	}
+
+	for _, endpoint := range serviceMonitor.Spec.Endpoints {
+		for _, port := range service.Spec.Ports {
+			if endpoint.Port == port.Name {
+				glog.V(gpuparams.GpuLogLevel).Infof("ServiceMonitor '%s' scrapes port '%s' (%d) of service '%s'",
+					serviceMonitor.Name, port.Name, port.Port, ServiceName)
+
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("no endpoint of ServiceMonitor %s matches a port of service %s", serviceMonitor.Name,
+		ServiceName)
+}
+
+// DCGMExporterTargets returns the Prometheus active targets scraping the DCGM exporter service.
+func DCGMExporterTargets(client *prometheus.Client) ([]prometheus.Target, error) {
+	targets, err := client.ActiveTargets()
+	if err != nil {
+		return nil, err
+	}
+
+	var dcgmTargets []prometheus.Target
+
+	for _, target := range targets {
+		if target.Labels["namespace"] == nvidiagpu.NvidiaGPUNamespace && target.Labels["service"] == ServiceName {
+			dcgmTargets = append(dcgmTargets, target)
+		}
+	}
+
+	return dcgmTargets, nil
+}
+
+// SamplesByNode groups the samples of a DCGM exporter metric by node name, then by GPU index.
+func SamplesByNode(samples []prometheus.Sample) map[string]map[string]prometheus.Sample {
+	byNode := map[string]map[string]prometheus.Sample{}
+
+	for _, sample := range samples {
+		nodeName := sample.Metric[HostnameLabel]
+		if byNode[nodeName] == nil {
+			byNode[nodeName] = map[string]prometheus.Sample{}
+		}
+
+		byNode[nodeName][sample.Metric[GPULabel]] = sample
+	}
+
+	return byNode
+}
@@ -0,0 +1,92 @@
+package deploy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultRegistryImage is the opm image of the registry pods serving the bundle images.
+	DefaultRegistryImage = "quay.io/operator-framework/opm:latest"
+
+	registryPort          = 50051
+	registryPodNameSuffix = "-registry"
+)
+
+// registryScript adds the bundle image to an empty registry database and serves it, like operator-sdk run bundle.
+const registryScript = "mkdir -p /database && " +
+	"opm registry add -d /database/index.db --mode=semver -b %s && " +
+	"opm registry serve -d /database/index.db -p %d"
+
+// DeployBundleCatalogSource serves the bundle image from a registry pod and creates the CatalogSource name of it, so
+// that the operator of the bundle is installed with a Subscription to the CatalogSource, like from a published
+// catalog, without operator-sdk. It returns the CatalogSource once ready.
+func (d deploy) DeployBundleCatalogSource(logLevel glog.Level, bundleConfig *BundleConfig, name, ns, displayName,
+	publisher string, timeout time.Duration) (*olm.CatalogSourceBuilder, error) {
+	registryImage := bundleConfig.RegistryImage
+	if registryImage == "" {
+		registryImage = DefaultRegistryImage
+	}
+
+	glog.V(logLevel).Infof("Creating registry pod '%s' serving bundle image '%s' with image '%s'",
+		name+registryPodNameSuffix, bundleConfig.BundleImage, registryImage)
+
+	registryPod, err := pod.NewBuilder(d.client, name+registryPodNameSuffix, ns, registryImage).
+		RedefineDefaultCMD([]string{"/bin/sh", "-c",
+			fmt.Sprintf(registryScript, bundleConfig.BundleImage, registryPort)}).
+		WithRestartPolicy(corev1.RestartPolicyNever).
+		WithLabel("olm.catalogSource", name).
+		CreateAndWaitUntilRunning(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run the registry pod of bundle image %s: %v", bundleConfig.BundleImage, err)
+	}
+
+	address := fmt.Sprintf("%s:%d", registryPod.Object.Status.PodIP, registryPort)
+
+	glog.V(logLevel).Infof("Creating catalogsource '%s' with the registry address '%s'", name, address)
+
+	catalogSourceBuilder, err := olm.NewCatalogSourceBuilderWithAddress(d.client, name, ns, address, displayName,
+		publisher).Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create catalogsource %s in namespace %s: %v", name, ns, err)
+	}
+
+	if !catalogSourceBuilder.IsReady(timeout) {
+		return nil, fmt.Errorf("timed out waiting for catalogsource %s in namespace %s to be ready", name, ns)
+	}
+
+	glog.V(logLevel).Infof("Catalogsource '%s' serving bundle image '%s' is ready", name, bundleConfig.BundleImage)
+
+	return catalogSourceBuilder, nil
+}
+
+// DeleteBundleCatalogSource deletes the CatalogSource created by DeployBundleCatalogSource and its registry pod.
+func (d deploy) DeleteBundleCatalogSource(logLevel glog.Level, name, ns string) error {
+	glog.V(logLevel).Infof("Deleting catalogsource '%s' and its registry pod in namespace '%s'", name, ns)
+
+	catalogSourceBuilder := olm.NewCatalogSourceBuilder(d.client, name, ns)
+	if catalogSourceBuilder.Exists() {
+		if err := catalogSourceBuilder.Delete(); err != nil {
+			return fmt.Errorf("failed to delete catalogsource %s in namespace %s: %v", name, ns, err)
+		}
+	}
+
+	registryPod, err := pod.Pull(d.client, name+registryPodNameSuffix, ns)
+	if err != nil {
+		glog.V(logLevel).Infof("Registry pod '%s' not found: %v", name+registryPodNameSuffix, err)
+
+		return nil
+	}
+
+	if _, err := registryPod.Delete(); err != nil {
+		return fmt.Errorf("failed to delete registry pod %s in namespace %s: %v", name+registryPodNameSuffix, ns,
+			err)
+	}
+
+	return nil
+}
@@ -9,16 +9,23 @@ import (
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
 	_ "go.uber.org/mock/mockgen/model"
 )
 
 type BundleConfig struct {
 	BundleImage string
+	// RegistryImage is the opm image of the registry pod serving the bundle image in DeployBundleCatalogSource,
+	// DefaultRegistryImage if empty.
+	RegistryImage string
 }
 
 type Deploy interface {
 	CreateAndLabelNamespaceIfNeeded(logLevel glog.Level, targetNs string, labels map[string]string) (*namespace.Builder, error)
 	DeployBundle(logLevel glog.Level, bundleConfig *BundleConfig, ns string, timeout time.Duration) error
+	DeployBundleCatalogSource(logLevel glog.Level, bundleConfig *BundleConfig, name, ns, displayName,
+		publisher string, timeout time.Duration) (*olm.CatalogSourceBuilder, error)
+	DeleteBundleCatalogSource(logLevel glog.Level, name, ns string) error
 	WaitForReadyStatus(logLevel glog.Level, name, ns string, timeout time.Duration) error
 }
 
@@ -0,0 +1,194 @@
+package disconnected
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/images"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/mirror"
+)
+
+const (
+	// MirrorSetName is the name of the ImageDigestMirrorSet, or ImageContentSourcePolicy, of IMAGE_MIRRORS.
+	MirrorSetName = "nvidia-ci-image-mirrors"
+
+	mirrorSetRolloutDelay         = time.Minute
+	mirrorSetRolloutCheckInterval = 30 * time.Second
+	mirrorSetRolloutTimeout       = 45 * time.Minute
+)
+
+var (
+	// resolver is the image resolver of the cluster, loaded on first use.
+	resolver *mirror.Resolver
+	// resolverErr is the error loading the resolver.
+	resolverErr  error
+	resolverOnce sync.Once
+
+	// digestResolver is the image digest resolver of the cluster, loaded on first use.
+	digestResolver *images.Resolver
+	// digestResolverErr is the error loading the digest resolver.
+	digestResolverErr  error
+	digestResolverOnce sync.Once
+)
+
+// Image returns the image the cluster pulls in place of the image when the image mirroring mode is set, i.e. the
+// image itself on connected clusters. The image is left unchanged when the mirror sets cannot be listed, CheckImages
+// reporting the error.
+func Image(image string) string {
+	if !inittools.GeneralConfig.IsImageMirroring() {
+		return image
+	}
+
+	imageResolver, err := getResolver()
+	if err != nil {
+		glog.Errorf("Failed to resolve image %s through the mirrors: %v", image, err)
+
+		return image
+	}
+
+	resolved := imageResolver.Resolve(image)
+	if resolved != image {
+		glog.V(100).Infof("Image %s is resolved to mirror image %s", image, resolved)
+	}
+
+	return resolved
+}
+
+// CheckImages checks that the images the cluster pulls in place of the images are reachable when the image mirroring
+// mode is set, so that the suites fail fast on the images missing from the mirror registry instead of timing out
+// pulling them. It returns an error listing every unreachable image.
+func CheckImages(images ...string) error {
+	config := inittools.GeneralConfig
+	if !config.IsImageMirroring() {
+		return nil
+	}
+
+	if _, err := getResolver(); err != nil {
+		return err
+	}
+
+	checker, err := mirror.NewChecker(inittools.APIClient, config.MirrorRegistryInsecure)
+	if err != nil {
+		return err
+	}
+
+	var unreachable []error
+
+	for _, image := range images {
+		if image == "" {
+			continue
+		}
+
+		if err := checker.CheckReachable(Image(image)); err != nil {
+			unreachable = append(unreachable, err)
+		}
+	}
+
+	if len(unreachable) > 0 {
+		return fmt.Errorf("%d image(s) are not reachable in disconnected mode: %w", len(unreachable),
+			errors.Join(unreachable...))
+	}
+
+	return nil
+}
+
+// ImageDigests returns the digests of the manifests of the images for the architecture, e.g. the architecture of the
+// GPU nodes, fetched from the images the cluster pulls in place of the images when the image mirroring mode is set, so
+// that the suites record the exact images they ran on every architecture and lab. It returns the digests it resolved
+// and an error listing the images it could not resolve.
+func ImageDigests(architecture string, imageRefs ...string) ([]*images.Digest, error) {
+	digestResolverOnce.Do(func() {
+		var imageResolver *mirror.Resolver
+
+		if inittools.GeneralConfig.IsImageMirroring() {
+			if imageResolver, digestResolverErr = getResolver(); digestResolverErr != nil {
+				return
+			}
+		}
+
+		digestResolver, digestResolverErr = images.NewResolver(inittools.APIClient, imageResolver,
+			inittools.GeneralConfig.MirrorRegistryInsecure)
+	})
+
+	if digestResolverErr != nil {
+		return nil, digestResolverErr
+	}
+
+	return digestResolver.ResolveAll(architecture, imageRefs...)
+}
+
+// ApplyImageMirrors lays down the IMAGE_MIRRORS mappings of the repositories to their mirror repositories in the
+// ImageDigestMirrorSet MirrorSetName, or in an ImageContentSourcePolicy on the clusters older than OpenShift 4.13,
+// and waits for the machine config operator to roll the registries configuration out to the nodes. Nothing is
+// applied when IMAGE_MIRRORS is not set.
+func ApplyImageMirrors() error {
+	imageMirrors := inittools.GeneralConfig.ImageMirrors
+	if len(imageMirrors) == 0 {
+		return nil
+	}
+
+	digestMirrorSetsServed, err := mirror.DigestMirrorSetsServed(inittools.APIClient)
+	if err != nil {
+		return err
+	}
+
+	if digestMirrorSetsServed {
+		digestMirrorSetBuilder := mirror.NewDigestMirrorSetBuilder(inittools.APIClient, MirrorSetName, imageMirrors)
+		if digestMirrorSetBuilder.IsUpToDate() {
+			glog.V(100).Infof("ImageDigestMirrorSet %s is up to date", MirrorSetName)
+
+			return nil
+		}
+
+		glog.V(100).Infof("Applying ImageDigestMirrorSet %s with mirrors %v", MirrorSetName, imageMirrors)
+
+		_, err = digestMirrorSetBuilder.Create()
+	} else {
+		contentSourcePolicyBuilder := mirror.NewContentSourcePolicyBuilder(inittools.APIClient, MirrorSetName,
+			imageMirrors)
+		if contentSourcePolicyBuilder.IsUpToDate() {
+			glog.V(100).Infof("ImageContentSourcePolicy %s is up to date", MirrorSetName)
+
+			return nil
+		}
+
+		glog.V(100).Infof("Applying ImageContentSourcePolicy %s with mirrors %v", MirrorSetName, imageMirrors)
+
+		_, err = contentSourcePolicyBuilder.Create()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to apply the image mirrors %s: %w", MirrorSetName, err)
+	}
+
+	// The machine config operator takes a moment to render the registries configuration and start updating the
+	// pools.
+	time.Sleep(mirrorSetRolloutDelay)
+
+	err = wait.MachineConfigPoolsUpdated(inittools.APIClient, mirrorSetRolloutCheckInterval, mirrorSetRolloutTimeout)
+	if err != nil {
+		return fmt.Errorf("timed out waiting for the image mirrors %s to be rolled out to the nodes: %w",
+			MirrorSetName, err)
+	}
+
+	return nil
+}
+
+// getResolver returns the image resolver of the mirror registry and of the mirror sets of the cluster, once the
+// IMAGE_MIRRORS mirror sets are applied.
+func getResolver() (*mirror.Resolver, error) {
+	resolverOnce.Do(func() {
+		if resolverErr = ApplyImageMirrors(); resolverErr != nil {
+			return
+		}
+
+		resolver, resolverErr = mirror.NewResolver(inittools.APIClient, inittools.GeneralConfig.MirrorRegistry)
+	})
+
+	return resolver, resolverErr
+}
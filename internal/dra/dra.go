@@ -0,0 +1,296 @@
+package dra
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	resourcev1beta1 "k8s.io/api/resource/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+const (
+	// DriverName is the name under which the NVIDIA DRA driver publishes GPU devices.
+	DriverName = "gpu.nvidia.com"
+	// DriverNamespace is the namespace where the NVIDIA DRA driver is installed.
+	DriverNamespace = "nvidia-dra-driver-gpu"
+	// DriverReleaseName is the helm release name of the NVIDIA DRA driver.
+	DriverReleaseName = "nvidia-dra-driver-gpu"
+	// DriverChart is the helm chart of the NVIDIA DRA driver.
+	DriverChart = "nvidia-dra-driver-gpu"
+	// DriverChartRepository is the helm repository hosting DriverChart.
+	DriverChartRepository = "https://helm.ngc.nvidia.com/nvidia"
+	// DriverRoot is the host path where the GPU operator driver container exposes the driver.
+	DriverRoot = "/run/nvidia/driver"
+	// ResourceAPIGroupVersion is the DRA API group version used by the tests.
+	ResourceAPIGroupVersion = "resource.k8s.io/v1beta1"
+	// WorkloadContainerName is the container name of the pods built by CreateDRAPod.
+	WorkloadContainerName = "dra-ctr"
+	// PodClaimName is the name under which pods built by CreateDRAPod reference their ResourceClaim.
+	PodClaimName = "gpu"
+	// DeviceRequestName is the name of the device request in claims built by CreateResourceClaim.
+	DeviceRequestName = "gpu"
+
+	privilegedSCCRole = "system:openshift:scc:privileged"
+)
+
+var (
+	isFalse = false
+	isTrue  = true
+)
+
+// ResourceAPIAvailable checks whether the cluster serves the DRA resource.k8s.io API, i.e. whether the
+// DynamicResourceAllocation feature gate is enabled.
+func ResourceAPIAvailable(apiClient *clients.Settings) (bool, error) {
+	_, err := apiClient.K8sClient.Discovery().ServerResourcesForGroupVersion(ResourceAPIGroupVersion)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to discover %s: %w", ResourceAPIGroupVersion, err)
+	}
+
+	return true, nil
+}
+
+// InstallDriver installs the given chart version of the NVIDIA DRA driver with helm, using the driver deployed
+// by the GPU operator. The driver service accounts are granted the privileged SCC first.
+func InstallDriver(apiClient *clients.Settings, chartVersion string, timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Installing NVIDIA DRA driver chart version '%s' in namespace '%s'",
+		chartVersion, DriverNamespace)
+
+	nsBuilder := namespace.NewBuilder(apiClient, DriverNamespace)
+	if !nsBuilder.Exists() {
+		if _, err := nsBuilder.Create(); err != nil {
+			return fmt.Errorf("failed to create namespace %s: %w", DriverNamespace, err)
+		}
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DriverReleaseName + "-privileged",
+			Namespace: DriverNamespace,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     privilegedSCCRole,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				APIGroup: rbacv1.GroupName,
+				Kind:     rbacv1.GroupKind,
+				Name:     "system:serviceaccounts:" + DriverNamespace,
+			},
+		},
+	}
+
+	if _, err := apiClient.K8sClient.RbacV1().RoleBindings(DriverNamespace).Create(context.TODO(), roleBinding,
+		metav1.CreateOptions{}); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create rolebinding %s: %w", roleBinding.Name, err)
+	}
+
+	cmd := exec.Command("helm", "upgrade", "--install", DriverReleaseName, DriverChart,
+		"--repo", DriverChartRepository, "--version", chartVersion,
+		"--namespace", DriverNamespace,
+		"--set", "nvidiaDriverRoot="+DriverRoot,
+		"--set", "resources.gpus.enabled=true",
+		"--set", "gpuResourcesEnabledOverride=true",
+		"--wait", "--timeout", timeout.String())
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to install the NVIDIA DRA driver: %w: %s", err, output)
+	}
+
+	return nil
+}
+
+// UninstallDriver uninstalls the NVIDIA DRA driver helm release and deletes its namespace.
+func UninstallDriver(apiClient *clients.Settings, timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Uninstalling NVIDIA DRA driver from namespace '%s'", DriverNamespace)
+
+	cmd := exec.Command("helm", "uninstall", DriverReleaseName, "--namespace", DriverNamespace,
+		"--wait", "--timeout", timeout.String())
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to uninstall the NVIDIA DRA driver: %w: %s", err, output)
+	}
+
+	return namespace.NewBuilder(apiClient, DriverNamespace).DeleteAndWait(timeout)
+}
+
+// ListResourceSlices returns the ResourceSlices published by the NVIDIA DRA driver for a node.
+func ListResourceSlices(apiClient *clients.Settings, nodeName string) ([]resourcev1beta1.ResourceSlice, error) {
+	selector := fields.Set{"spec.driver": DriverName, "spec.nodeName": nodeName}.AsSelector().String()
+
+	sliceList, err := apiClient.K8sClient.ResourceV1beta1().ResourceSlices().List(context.TODO(),
+		metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ResourceSlices of node %s: %w", nodeName, err)
+	}
+
+	return sliceList.Items, nil
+}
+
+// CountDevices returns the number of devices published in the given ResourceSlices.
+func CountDevices(slices []resourcev1beta1.ResourceSlice) int {
+	count := 0
+
+	for _, slice := range slices {
+		count += len(slice.Spec.Devices)
+	}
+
+	return count
+}
+
+// CreateDeviceClass creates a DeviceClass selecting the GPUs published by the NVIDIA DRA driver.
+func CreateDeviceClass(apiClient *clients.Settings, name string) (*resourcev1beta1.DeviceClass, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating DeviceClass '%s'", name)
+
+	deviceClass := &resourcev1beta1.DeviceClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: resourcev1beta1.DeviceClassSpec{
+			Selectors: []resourcev1beta1.DeviceSelector{
+				{
+					CEL: &resourcev1beta1.CELDeviceSelector{
+						Expression: fmt.Sprintf("device.driver == '%s' && device.attributes['%s'].type == 'gpu'",
+							DriverName, DriverName),
+					},
+				},
+			},
+		},
+	}
+
+	return apiClient.K8sClient.ResourceV1beta1().DeviceClasses().Create(context.TODO(), deviceClass,
+		metav1.CreateOptions{})
+}
+
+// DeleteDeviceClass deletes a DeviceClass, ignoring a DeviceClass that does not exist.
+func DeleteDeviceClass(apiClient *clients.Settings, name string) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Deleting DeviceClass '%s'", name)
+
+	err := apiClient.K8sClient.ResourceV1beta1().DeviceClasses().Delete(context.TODO(), name,
+		metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete DeviceClass %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// CreateResourceClaim creates a ResourceClaim requesting a single device of the given DeviceClass.
+func CreateResourceClaim(apiClient *clients.Settings, name, nsname,
+	deviceClassName string) (*resourcev1beta1.ResourceClaim, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating ResourceClaim '%s' in namespace '%s' for DeviceClass '%s'",
+		name, nsname, deviceClassName)
+
+	claim := &resourcev1beta1.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: nsname,
+		},
+		Spec: resourcev1beta1.ResourceClaimSpec{
+			Devices: resourcev1beta1.DeviceClaim{
+				Requests: []resourcev1beta1.DeviceRequest{
+					{
+						Name:            DeviceRequestName,
+						DeviceClassName: deviceClassName,
+						AllocationMode:  resourcev1beta1.DeviceAllocationModeExactCount,
+						Count:           1,
+					},
+				},
+			},
+		},
+	}
+
+	return apiClient.K8sClient.ResourceV1beta1().ResourceClaims(nsname).Create(context.TODO(), claim,
+		metav1.CreateOptions{})
+}
+
+// GetResourceClaim returns a ResourceClaim from the cluster.
+func GetResourceClaim(apiClient *clients.Settings, name, nsname string) (*resourcev1beta1.ResourceClaim, error) {
+	return apiClient.K8sClient.ResourceV1beta1().ResourceClaims(nsname).Get(context.TODO(), name,
+		metav1.GetOptions{})
+}
+
+// DeleteResourceClaim deletes a ResourceClaim, ignoring a ResourceClaim that does not exist.
+func DeleteResourceClaim(apiClient *clients.Settings, name, nsname string) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Deleting ResourceClaim '%s' in namespace '%s'", name, nsname)
+
+	err := apiClient.K8sClient.ResourceV1beta1().ResourceClaims(nsname).Delete(context.TODO(), name,
+		metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete ResourceClaim %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// CreateDRAPod returns a pod pinned to the node that lists the GPUs allocated through the named ResourceClaim.
+func CreateDRAPod(podName, podNamespace, nodeName, image, claimName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "dra-test-app",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			NodeSelector: map[string]string{
+				"kubernetes.io/hostname": nodeName,
+			},
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &isTrue,
+				SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			ResourceClaims: []corev1.PodResourceClaim{
+				{
+					Name:              PodClaimName,
+					ResourceClaimName: &claimName,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            WorkloadContainerName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/bin/sh", "-c", "nvidia-smi -L && sleep infinity"},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &isFalse,
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+					},
+					Resources: corev1.ResourceRequirements{
+						Claims: []corev1.ResourceClaim{
+							{
+								Name: PodClaimName,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
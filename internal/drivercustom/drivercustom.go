@@ -0,0 +1,135 @@
+package drivercustom
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+)
+
+const (
+	// KernelModuleConfigMapName is the name of the ConfigMap of the nvidia kernel module parameters.
+	KernelModuleConfigMapName = "nvidia-ci-kernel-module-params"
+	// KernelModuleConfigKey is the ConfigMap key the driver container reads the nvidia module parameters from.
+	KernelModuleConfigKey = "nvidia.conf"
+	// RepoConfigMapName is the name of the ConfigMap of the custom package repository of the driver container.
+	RepoConfigMapName = "nvidia-ci-repo-config"
+	// RepoConfigKey is the ConfigMap key of the custom package repository, mounted as a file of RepoConfigDir.
+	RepoConfigKey = "nvidia-ci.repo"
+	// RepoConfigDir is the directory of the driver container the repoConfig ConfigMap is mounted in, on RHCOS.
+	RepoConfigDir = "/etc/yum.repos.d"
+	// RepoID is the id of the custom package repository. The repository is disabled not to affect the driver build.
+	RepoID = "nvidia-ci-custom"
+
+	// moduleParamsPath lists the parameters of the loaded nvidia module, e.g. "RestrictProfilingToAdminUsers: 1".
+	moduleParamsPath = "/proc/driver/nvidia/params"
+	// moduleParamPrefix prefixes the nvidia module parameters in the modprobe options, not in moduleParamsPath.
+	moduleParamPrefix = "NVreg_"
+)
+
+// Customization is the driver customization of the ClusterPolicy under test.
+type Customization struct {
+	// Env are the environment variables of the driver container.
+	Env map[string]string
+	// ModuleParameters are the nvidia kernel module parameters, without the NVreg_ prefix, and their values.
+	ModuleParameters map[string]string
+}
+
+// KernelModuleConfig returns the nvidia.conf content of the kernel module parameters of the customization.
+func (customization Customization) KernelModuleConfig() string {
+	var options []string
+	for name, value := range customization.ModuleParameters {
+		options = append(options, moduleParamPrefix+name+"="+value)
+	}
+
+	slices.Sort(options)
+
+	return strings.Join(options, "\n") + "\n"
+}
+
+// RepoConfig returns the .repo content of the disabled custom package repository.
+func RepoConfig() string {
+	return fmt.Sprintf("[%s]\nname=nvidia-ci custom repository\nbaseurl=https://example.invalid/%s\nenabled=0\n"+
+		"gpgcheck=0\n", RepoID, RepoID)
+}
+
+// ApplyCustomization sets the env, the kernelModuleConfig and the repoConfig of the ClusterPolicy driver spec to the
+// customization and the ConfigMaps of this package. It returns a copy of the previous driver spec so that it can be
+// restored.
+func ApplyCustomization(apiClient *clients.Settings, clusterPolicyName string,
+	customization Customization) (*nvidiagpuv1.DriverSpec, error) {
+	var previousSpec *nvidiagpuv1.DriverSpec
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousSpec = definition.Spec.Driver.DeepCopy()
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Customizing the driver of ClusterPolicy '%s' with env %v, module "+
+			"parameters %v and repoConfig '%s'", clusterPolicyName, customization.Env, customization.ModuleParameters,
+			RepoConfigMapName)
+
+		for _, name := range slices.Sorted(maps.Keys(customization.Env)) {
+			definition.Spec.Driver.Env = append(definition.Spec.Driver.Env, nvidiagpuv1.EnvVar{Name: name,
+				Value: customization.Env[name]})
+		}
+
+		definition.Spec.Driver.KernelModuleConfig = &nvidiagpuv1.KernelModuleConfigSpec{Name: KernelModuleConfigMapName}
+		definition.Spec.Driver.RepoConfig = &nvidiagpuv1.DriverRepoConfigSpec{ConfigMapName: RepoConfigMapName}
+	})
+	if err != nil {
+		return previousSpec, fmt.Errorf("failed to customize the driver of ClusterPolicy %s: %w", clusterPolicyName,
+			err)
+	}
+
+	return previousSpec, nil
+}
+
+// RestoreDriverSpec replaces the ClusterPolicy driver spec with a spec saved by ApplyCustomization.
+func RestoreDriverSpec(apiClient *clients.Settings, clusterPolicyName string,
+	previousSpec *nvidiagpuv1.DriverSpec) error {
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		definition.Spec.Driver = *previousSpec.DeepCopy()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore the driver spec of ClusterPolicy %s: %w", clusterPolicyName, err)
+	}
+
+	return nil
+}
+
+// DriverEnv returns the value of the environment variable in the driver container of the node.
+func DriverEnv(apiClient *clients.Settings, nodeName, name string) (string, error) {
+	return driverupgrade.ExecDriver(apiClient, nodeName, "printenv", name)
+}
+
+// ModuleParameters returns the parameters of the nvidia module loaded on the node, read from the driver container
+// of the node, which shares the host kernel.
+func ModuleParameters(apiClient *clients.Settings, nodeName string) (map[string]string, error) {
+	output, err := driverupgrade.ExecDriver(apiClient, nodeName, "cat", moduleParamsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		name, value, found := strings.Cut(line, ":")
+		if found {
+			parameters[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+
+	return parameters, nil
+}
+
+// RepoConfigFile returns the content of the custom package repository file in the driver container of the node.
+func RepoConfigFile(apiClient *clients.Settings, nodeName string) (string, error) {
+	return driverupgrade.ExecDriver(apiClient, nodeName, "cat", path.Join(RepoConfigDir, RepoConfigKey))
+}
@@ -0,0 +1,226 @@
+package driverupgrade
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// DriverLabel selects the GPU operator driver daemonsets and their pods.
+	DriverLabel = "app=nvidia-driver-daemonset"
+	// DriverContainerName is the name of the driver container of the driver daemonset pods.
+	DriverContainerName = "nvidia-driver-ctr"
+	// DriverRolloutCheckInterval is the polling interval of DriverVersionReady.
+	DriverRolloutCheckInterval = 30 * time.Second
+	// DriverRolloutTimeout is the time the driver daemonsets are given to roll a new driver version out.
+	DriverRolloutTimeout = 30 * time.Minute
+)
+
+// SetDriverVersion sets driver.version in the ClusterPolicy and returns the previous driver version.
+func SetDriverVersion(apiClient *clients.Settings, clusterPolicyName, version string) (string, error) {
+	var previousVersion string
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousVersion = definition.Spec.Driver.Version
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Setting ClusterPolicy '%s' driver version from '%s' to '%s'",
+			clusterPolicyName, previousVersion, version)
+
+		definition.Spec.Driver.Version = version
+	})
+	if err != nil {
+		return previousVersion, fmt.Errorf("failed to set ClusterPolicy %s driver version to %s: %w",
+			clusterPolicyName, version, err)
+	}
+
+	return previousVersion, nil
+}
+
+// DriverVersionReady waits until the driver daemonsets have converged and the driver pod of every node matching
+// nodeSelector is ready, runs the driver image of the version and has the driver of the version loaded.
+func DriverVersionReady(apiClient *clients.Settings, nodeSelector map[string]string, version string, pollInterval,
+	timeout time.Duration) error {
+	gpuNodes, err := nodes.List(apiClient, metav1.ListOptions{
+		LabelSelector: labels.Set(nodeSelector).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list GPU nodes: %w", err)
+	}
+
+	if len(gpuNodes) == 0 {
+		return fmt.Errorf("no node matches selector %v", nodeSelector)
+	}
+
+	return wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			if !driverDaemonSetsConverged(apiClient) {
+				return false, nil
+			}
+
+			for _, gpuNode := range gpuNodes {
+				if !nodeDriverVersionReady(apiClient, gpuNode.Object.Name, version) {
+					return false, nil
+				}
+			}
+
+			return true, nil
+		})
+}
+
+// DriverDaemonSetsReady waits until the driver daemonsets have rolled their pods out, e.g. after a change of the
+// driver spec of the ClusterPolicy, and the pods are ready.
+func DriverDaemonSetsReady(apiClient *clients.Settings, pollInterval, timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			return driverDaemonSetsConverged(apiClient), nil
+		})
+}
+
+// driverDaemonSetsConverged returns true when every driver daemonset has rolled its pods out and they are ready.
+func driverDaemonSetsConverged(apiClient *clients.Settings) bool {
+	daemonSets, err := apiClient.DaemonSets(nvidiagpu.NvidiaGPUNamespace).List(context.TODO(),
+		metav1.ListOptions{LabelSelector: DriverLabel})
+	if err != nil {
+		glog.V(gpuparams.GpuLogLevel).Infof("Error listing driver daemonsets: %v", err)
+
+		return false
+	}
+
+	if len(daemonSets.Items) == 0 {
+		glog.V(gpuparams.GpuLogLevel).Info("No driver daemonset found")
+
+		return false
+	}
+
+	for _, daemonSet := range daemonSets.Items {
+		status := daemonSet.Status
+		if status.ObservedGeneration < daemonSet.Generation ||
+			status.UpdatedNumberScheduled != status.DesiredNumberScheduled ||
+			status.NumberReady != status.DesiredNumberScheduled {
+			glog.V(gpuparams.GpuLogLevel).Infof("Driver daemonset '%s' has %d updated and %d ready pods out of %d",
+				daemonSet.Name, status.UpdatedNumberScheduled, status.NumberReady, status.DesiredNumberScheduled)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// nodeDriverVersionReady returns true when the driver pod of the node is ready, runs the driver image of the version
+// and reports the driver version through nvidia-smi.
+func nodeDriverVersionReady(apiClient *clients.Settings, nodeName, version string) bool {
+	driverPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{
+		LabelSelector: DriverLabel,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		glog.V(gpuparams.GpuLogLevel).Infof("Error listing driver pods on node '%s': %v", nodeName, err)
+
+		return false
+	}
+
+	if len(driverPods) != 1 {
+		glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' runs %d driver pods, waiting for a single one", nodeName,
+			len(driverPods))
+
+		return false
+	}
+
+	driverPod := driverPods[0]
+
+	if driverPod.Object.Status.Phase != corev1.PodRunning {
+		glog.V(gpuparams.GpuLogLevel).Infof("Driver pod '%s' is in phase '%s'", driverPod.Object.Name,
+			driverPod.Object.Status.Phase)
+
+		return false
+	}
+
+	for _, containerStatus := range driverPod.Object.Status.ContainerStatuses {
+		if !containerStatus.Ready {
+			glog.V(gpuparams.GpuLogLevel).Infof("Driver pod '%s' container '%s' is not ready",
+				driverPod.Object.Name, containerStatus.Name)
+
+			return false
+		}
+	}
+
+	for _, container := range driverPod.Object.Spec.Containers {
+		// The driver image tag is the driver version suffixed with the OS, e.g. 550.127.08-rhcos4.17
+		if container.Name == DriverContainerName && !strings.Contains(container.Image, ":"+version) {
+			glog.V(gpuparams.GpuLogLevel).Infof("Driver pod '%s' image '%s' is not of driver version '%s'",
+				driverPod.Object.Name, container.Image, version)
+
+			return false
+		}
+	}
+
+	output, err := driverPod.ExecCommand([]string{"nvidia-smi", "--query-gpu=driver_version",
+		"--format=csv,noheader"}, DriverContainerName)
+	if err != nil {
+		glog.V(gpuparams.GpuLogLevel).Infof("Error running nvidia-smi in driver pod '%s': %v",
+			driverPod.Object.Name, err)
+
+		return false
+	}
+
+	loadedVersion := strings.TrimSpace(strings.Split(strings.TrimSpace(output.String()), "\n")[0])
+	if !strings.HasPrefix(loadedVersion, version) {
+		glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' has driver '%s' loaded instead of '%s'", nodeName,
+			loadedVersion, version)
+
+		return false
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' driver pod '%s' is ready with driver '%s'", nodeName,
+		driverPod.Object.Name, loadedVersion)
+
+	return true
+}
+
+// NodeDriverPod returns the driver pod running on the node.
+func NodeDriverPod(apiClient *clients.Settings, nodeName string) (*pod.Builder, error) {
+	driverPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{
+		LabelSelector: DriverLabel,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the driver pods of node %s: %w", nodeName, err)
+	}
+
+	if len(driverPods) != 1 {
+		return nil, fmt.Errorf("found %d driver pods on node %s instead of 1", len(driverPods), nodeName)
+	}
+
+	return driverPods[0], nil
+}
+
+// ExecDriver runs the command in the driver container of the node and returns its trimmed output.
+func ExecDriver(apiClient *clients.Settings, nodeName string, command ...string) (string, error) {
+	driverPod, err := NodeDriverPod(apiClient, nodeName)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := driverPod.ExecCommand(command, DriverContainerName)
+	if err != nil {
+		return "", fmt.Errorf("failed to run %v in pod %s: %w", command, driverPod.Object.Name, err)
+	}
+
+	return strings.TrimSpace(output.String()), nil
+}
@@ -0,0 +1,12 @@
+package env
+
+import "os"
+
+// OrDefault returns the value of the environment variable, or the default value when it is unset or empty.
+func OrDefault(envVar, defaultValue string) string {
+	if value := os.Getenv(envVar); value != "" {
+		return value
+	}
+
+	return defaultValue
+}
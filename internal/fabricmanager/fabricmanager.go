@@ -0,0 +1,86 @@
+package fabricmanager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiasmi"
+)
+
+const (
+	// ProcessName is the fabric manager daemon the driver container starts on the NVSwitch systems.
+	ProcessName = "nv-fabricmanager"
+	// NVSwitchDevicesDir lists the NVSwitch devices the driver probed on the node.
+	NVSwitchDevicesDir = "/proc/driver/nvidia-nvswitch/devices"
+	// FabricCompleted is the fabric state nvidia-smi reports for the GPUs the fabric manager registered.
+	FabricCompleted = "Completed"
+	// FabricSuccess is the fabric status nvidia-smi reports for the GPUs the fabric manager registered.
+	FabricSuccess = "Success"
+)
+
+// nvswitchScript prints the number of NVSwitch devices of the node.
+var nvswitchScript = fmt.Sprintf("ls %s 2>/dev/null | wc -l", NVSwitchDevicesDir)
+
+// processScript prints the number of fabric manager processes, the bracket keeping grep from matching itself.
+var processScript = fmt.Sprintf("grep -las '[%s]%s' /proc/[0-9]*/cmdline | wc -l", ProcessName[:1],
+	ProcessName[1:])
+
+// NVSwitchCount returns the number of NVSwitch devices of the node, read in its driver container, zero on the nodes
+// whose GPUs are not connected through NVSwitches.
+func NVSwitchCount(apiClient *clients.Settings, nodeName string) (int, error) {
+	return countInDriverPod(apiClient, nodeName, nvswitchScript)
+}
+
+// ProcessRunning checks whether the fabric manager daemon runs in the driver container of the node.
+func ProcessRunning(apiClient *clients.Settings, nodeName string) (bool, error) {
+	count, err := countInDriverPod(apiClient, nodeName, processScript)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// UnregisteredGPUs returns the UUIDs of the GPUs of the nvidia-smi report whose fabric registration did not
+// complete successfully, the GPUs not reporting a fabric state being left out.
+func UnregisteredGPUs(report *nvidiasmi.Log) []string {
+	var unregistered []string
+
+	for _, gpu := range report.GPUs {
+		if !gpu.Fabric.State.Available() {
+			continue
+		}
+
+		if string(gpu.Fabric.State) != FabricCompleted || string(gpu.Fabric.Status) != FabricSuccess {
+			unregistered = append(unregistered, gpu.UUID)
+		}
+	}
+
+	return unregistered
+}
+
+// countInDriverPod runs the script printing a count in the driver container of the node.
+func countInDriverPod(apiClient *clients.Settings, nodeName, script string) (int, error) {
+	driverPod, err := nvidiasmi.NodeDriverPod(apiClient, nodeName)
+	if err != nil {
+		return 0, err
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Running %q in driver pod %s", script, driverPod.Object.Name)
+
+	output, err := driverPod.ExecCommand([]string{"/bin/sh", "-c", script}, nvidiasmi.DriverContainerName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to run %q in driver pod %s: %w", script, driverPod.Object.Name, err)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(output.String()))
+	if err != nil {
+		return 0, fmt.Errorf("invalid count %q in driver pod %s: %w", output.String(), driverPod.Object.Name, err)
+	}
+
+	return count, nil
+}
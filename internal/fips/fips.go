@@ -0,0 +1,140 @@
+package fips
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// InstallConfigNamespace is the namespace of the ConfigMap holding the install-config of the cluster.
+	InstallConfigNamespace = "kube-system"
+	// InstallConfigMapName is the name of the ConfigMap holding the install-config of the cluster.
+	InstallConfigMapName = "cluster-config-v1"
+	// installConfigKey is the ConfigMap key of the install-config.
+	installConfigKey = "install-config"
+	// fipsEnabledPath is the kernel FIPS mode flag, 1 when the node boots in FIPS mode.
+	fipsEnabledPath = "/proc/sys/crypto/fips_enabled"
+	// sigEnforcePath is the kernel flag refusing to load the unsigned kernel modules, Y when enforced.
+	sigEnforcePath = "/sys/module/module/parameters/sig_enforce"
+	// nvidiaTaintPath is the taint flags of the nvidia kernel module, E standing for an unsigned module.
+	nvidiaTaintPath = "/sys/module/nvidia/taint"
+	// unsignedModuleTaint is the kernel module taint flag of the unsigned modules.
+	unsignedModuleTaint = "E"
+)
+
+// driverLogKeywords are the driver container log keywords of the FIPS and module signing diagnostics.
+var driverLogKeywords = []string{"fips", "sign", "crypto", "digest", "key", "error"}
+
+// ModuleSigning is the kernel module signing state of a GPU node.
+type ModuleSigning struct {
+	// Enforced is true when the kernel refuses to load the unsigned modules.
+	Enforced bool
+	// Unsigned is true when the loaded nvidia module is tainted as unsigned.
+	Unsigned bool
+}
+
+// IsClusterFIPS returns true when the cluster was installed in FIPS mode, as set in its install-config.
+func IsClusterFIPS(apiClient *clients.Settings) (bool, error) {
+	configMap, err := apiClient.ConfigMaps(InstallConfigNamespace).Get(context.TODO(), InstallConfigMapName,
+		metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get ConfigMap %s/%s: %w", InstallConfigNamespace, InstallConfigMapName,
+			err)
+	}
+
+	var installConfig struct {
+		FIPS bool `json:"fips"`
+	}
+
+	if err := yaml.Unmarshal([]byte(configMap.Data[installConfigKey]), &installConfig); err != nil {
+		return false, fmt.Errorf("failed to parse the install-config of ConfigMap %s/%s: %w", InstallConfigNamespace,
+			InstallConfigMapName, err)
+	}
+
+	return installConfig.FIPS, nil
+}
+
+// NodeFIPSEnabled returns true when the kernel of the node runs in FIPS mode, read from the driver container of the
+// node, which shares the host kernel.
+func NodeFIPSEnabled(apiClient *clients.Settings, nodeName string) (bool, error) {
+	output, err := driverupgrade.ExecDriver(apiClient, nodeName, "cat", fipsEnabledPath)
+	if err != nil {
+		return false, err
+	}
+
+	return output == "1", nil
+}
+
+// NodeModuleSigning returns the kernel module signing state of the node, the nvidia module it reports being the one
+// built, or precompiled, and loaded by the driver container.
+func NodeModuleSigning(apiClient *clients.Settings, nodeName string) (*ModuleSigning, error) {
+	sigEnforce, err := driverupgrade.ExecDriver(apiClient, nodeName, "cat", sigEnforcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	taint, err := driverupgrade.ExecDriver(apiClient, nodeName, "cat", nvidiaTaintPath)
+	if err != nil {
+		return nil, fmt.Errorf("the nvidia module is not loaded on node %s: %w", nodeName, err)
+	}
+
+	return &ModuleSigning{
+		Enforced: sigEnforce == "Y",
+		Unsigned: strings.Contains(taint, unsignedModuleTaint),
+	}, nil
+}
+
+// Diagnostics returns the FIPS diagnostics of the node: its kernel FIPS mode, command line and module signing state,
+// and the driver container log lines about FIPS, signing and crypto errors.
+func Diagnostics(apiClient *clients.Settings, nodeName string) string {
+	var diagnostics strings.Builder
+
+	fmt.Fprintf(&diagnostics, "==== Node %s FIPS diagnostics ====\n", nodeName)
+
+	driverPod, err := driverupgrade.NodeDriverPod(apiClient, nodeName)
+	if err != nil {
+		fmt.Fprintf(&diagnostics, "%v\n", err)
+
+		return diagnostics.String()
+	}
+
+	for _, path := range []string{fipsEnabledPath, "/proc/cmdline", sigEnforcePath, nvidiaTaintPath} {
+		output, err := driverPod.ExecCommand([]string{"cat", path}, driverupgrade.DriverContainerName)
+		if err != nil {
+			fmt.Fprintf(&diagnostics, "---- %s ----\nfailed to read: %v\n", path, err)
+
+			continue
+		}
+
+		fmt.Fprintf(&diagnostics, "---- %s ----\n%s\n", path, strings.TrimSpace(output.String()))
+	}
+
+	logs, err := driverPod.GetFullLog(driverupgrade.DriverContainerName)
+	if err != nil {
+		fmt.Fprintf(&diagnostics, "failed to get pod %s logs: %v\n", driverPod.Object.Name, err)
+
+		return diagnostics.String()
+	}
+
+	fmt.Fprintf(&diagnostics, "---- pod %s FIPS logs ----\n", driverPod.Object.Name)
+
+	for _, line := range strings.Split(logs, "\n") {
+		lowerLine := strings.ToLower(line)
+
+		for _, keyword := range driverLogKeywords {
+			if strings.Contains(lowerLine, keyword) {
+				fmt.Fprintln(&diagnostics, line)
+
+				break
+			}
+		}
+	}
+
+	return diagnostics.String()
+}
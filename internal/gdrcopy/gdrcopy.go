@@ -0,0 +1,237 @@
+package gdrcopy
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// GDRCopyContainerName is the name of the driver pod container loading the gdrdrv kernel module.
+	GDRCopyContainerName = "nvidia-gdrcopy-ctr"
+	// GDRDrvModule is the name of the GDRCopy kernel module.
+	GDRDrvModule = "gdrdrv"
+	// SanityContainerName is the container name of the pods built by CreateSanityPod.
+	SanityContainerName = "gdrcopy-sanity-ctr"
+
+	productLabel = "nvidia.com/gpu.product"
+)
+
+var (
+	isTrue = true
+
+	// unsupportedProducts are the GPU products without the GPUDirect RDMA BAR1 mappings GDRCopy relies on.
+	unsupportedProducts = []string{"GeForce", "TITAN"}
+
+	copyBandwidthRegexp = regexp.MustCompile(`(write|read) BW: ([0-9.]+) ?MB/s`)
+)
+
+// sanityScript runs the GDRCopy unit tests, then measures the copy bandwidth between the host and GPU 0 memory
+// mapped by GDRCopy.
+const sanityScript = `set -e
+gdrcopy_sanity
+gdrcopy_copybw -s 1048576
+`
+
+// SetClusterPolicyGDRCopy sets gdrcopy.enabled in the ClusterPolicy and returns its previous value.
+func SetClusterPolicyGDRCopy(apiClient *clients.Settings, clusterPolicyName string, enabled bool) (bool, error) {
+	var previous bool
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		if definition.Spec.GDRCopy == nil {
+			definition.Spec.GDRCopy = &nvidiagpuv1.GDRCopySpec{}
+		}
+
+		gdrcopySpec := definition.Spec.GDRCopy
+		previous = gdrcopySpec.IsEnabled()
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Setting ClusterPolicy '%s' gdrcopy.enabled from '%t' to '%t'",
+			clusterPolicyName, previous, enabled)
+
+		gdrcopySpec.Enabled = &enabled
+	})
+	if err != nil {
+		return previous, fmt.Errorf("failed to set ClusterPolicy %s gdrcopy.enabled to %t: %w", clusterPolicyName,
+			enabled, err)
+	}
+
+	return previous, nil
+}
+
+// SupportedNodes returns the GPU nodes whose GPU product, as labeled by GFD, supports GDRCopy. The nodes without
+// product label are assumed to support it.
+func SupportedNodes(gpuNodes []*nodes.Builder) []*nodes.Builder {
+	var supported []*nodes.Builder
+
+	for _, gpuNode := range gpuNodes {
+		product := gpuNode.Object.Labels[productLabel]
+
+		if !productSupported(product) {
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' GPU '%s' does not support GDRCopy", gpuNode.Object.Name,
+				product)
+
+			continue
+		}
+
+		supported = append(supported, gpuNode)
+	}
+
+	return supported
+}
+
+// GDRDrvLoaded waits until the driver pod of every node runs a ready gdrcopy container and the gdrdrv kernel module
+// is loaded on the node.
+func GDRDrvLoaded(apiClient *clients.Settings, gpuNodes []*nodes.Builder, pollInterval,
+	timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			for _, gpuNode := range gpuNodes {
+				if !nodeGDRDrvLoaded(apiClient, gpuNode.Object.Name) {
+					return false, nil
+				}
+			}
+
+			return true, nil
+		})
+}
+
+// CreateSanityPod returns a privileged pod pinned to the node that runs the GDRCopy sanity tests and copy bandwidth
+// benchmark of the image, which must ship the GDRCopy userspace tools.
+func CreateSanityPod(podName, podNamespace, image, nodeName, serviceAccount string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "gdrcopy-test-app",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:      corev1.RestartPolicyNever,
+			ServiceAccountName: serviceAccount,
+			NodeSelector: map[string]string{
+				corev1.LabelHostname: nodeName,
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            SanityContainerName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/bin/bash", "-c", sanityScript},
+					// The GDRCopy tools open the /dev/gdrdrv device of the host.
+					SecurityContext: &corev1.SecurityContext{
+						Privileged: &isTrue,
+					},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							"nvidia.com/gpu": resource.MustParse("1"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ParseCopyBandwidth returns the write and read bandwidths, in MB/s, of the gdrcopy_copybw run of the pod output.
+func ParseCopyBandwidth(output string) (map[string]float64, error) {
+	bandwidths := map[string]float64{}
+
+	for _, match := range copyBandwidthRegexp.FindAllStringSubmatch(output, -1) {
+		bandwidth, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gdrcopy_copybw %s bandwidth %q: %w", match[1], match[2], err)
+		}
+
+		bandwidths[match[1]] = bandwidth
+	}
+
+	if len(bandwidths) == 0 {
+		return nil, fmt.Errorf("no gdrcopy_copybw bandwidth found in the pod output")
+	}
+
+	return bandwidths, nil
+}
+
+func productSupported(product string) bool {
+	for _, unsupported := range unsupportedProducts {
+		if strings.Contains(product, unsupported) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func nodeGDRDrvLoaded(apiClient *clients.Settings, nodeName string) bool {
+	driverPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{
+		LabelSelector: driverupgrade.DriverLabel,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil || len(driverPods) != 1 {
+		glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' does not run a single driver pod: %v", nodeName, err)
+
+		return false
+	}
+
+	driverPod := driverPods[0]
+
+	gdrcopyReady := false
+
+	for _, containerStatus := range driverPod.Object.Status.ContainerStatuses {
+		if containerStatus.Name == GDRCopyContainerName {
+			gdrcopyReady = containerStatus.Ready
+		}
+	}
+
+	if !gdrcopyReady {
+		glog.V(gpuparams.GpuLogLevel).Infof("Driver pod '%s' container '%s' is not ready", driverPod.Object.Name,
+			GDRCopyContainerName)
+
+		return false
+	}
+
+	output, err := driverPod.ExecCommand([]string{"cat", "/proc/modules"}, GDRCopyContainerName)
+	if err != nil {
+		glog.V(gpuparams.GpuLogLevel).Infof("Error reading the kernel modules in pod '%s': %v",
+			driverPod.Object.Name, err)
+
+		return false
+	}
+
+	for _, line := range strings.Split(output.String(), "\n") {
+		if strings.HasPrefix(line, GDRDrvModule+" ") {
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' loaded kernel module '%s'", nodeName, GDRDrvModule)
+
+			return true
+		}
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' did not load kernel module '%s' yet", nodeName, GDRDrvModule)
+
+	return false
+}
@@ -0,0 +1,228 @@
+package gds
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// NvidiaFSContainerName is the name of the driver pod container loading the nvidia-fs kernel module.
+	NvidiaFSContainerName = "nvidia-fs-ctr"
+	// NvidiaFSModule is the name of the GPUDirect Storage kernel module.
+	NvidiaFSModule = "nvidia_fs"
+	// GdsioContainerName is the container name of the pods built by CreateGdsioPod.
+	GdsioContainerName = "gdsio-ctr"
+	// NVMeUnsupportedMarker is printed by the pods built by CreateGdsioPod instead of running gdsio when gdscheck
+	// does not report GPUDirect Storage support for NVMe.
+	NVMeUnsupportedMarker = "gds-nvme-unsupported"
+
+	gdsioDataPath = "/data"
+	gdsToolsPath  = "/usr/local/cuda/gds/tools"
+)
+
+var (
+	isTrue = true
+
+	gdsioThroughputRegexp = regexp.MustCompile(`Throughput: ([0-9.]+) GiB/sec`)
+)
+
+// gdsioScript checks that GPUDirect Storage supports NVMe, then writes a file on the NVMe filesystem from GPU 0
+// memory with GPUDirect Storage (-x 0) and reads it back the same way.
+var gdsioScript = fmt.Sprintf(`set -e
+%[1]s/gdscheck -p | tee /tmp/gdscheck.log
+if ! grep -Eq 'NVMe +: Supported' /tmp/gdscheck.log; then
+  echo %[2]s
+  exit 0
+fi
+%[1]s/gdsio -D %[3]s -d 0 -w 4 -s 1G -i 1M -x 0 -I 1 -T 30
+%[1]s/gdsio -D %[3]s -d 0 -w 4 -s 1G -i 1M -x 0 -I 0 -T 30
+`, gdsToolsPath, NVMeUnsupportedMarker, gdsioDataPath)
+
+// SetClusterPolicyGDS sets gds.enabled in the ClusterPolicy and returns its previous value.
+func SetClusterPolicyGDS(apiClient *clients.Settings, clusterPolicyName string, enabled bool) (bool, error) {
+	var previous bool
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		if definition.Spec.GPUDirectStorage == nil {
+			definition.Spec.GPUDirectStorage = &nvidiagpuv1.GPUDirectStorageSpec{}
+		}
+
+		gdsSpec := definition.Spec.GPUDirectStorage
+		previous = gdsSpec.Enabled != nil && *gdsSpec.Enabled
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Setting ClusterPolicy '%s' gds.enabled from '%t' to '%t'",
+			clusterPolicyName, previous, enabled)
+
+		gdsSpec.Enabled = &enabled
+	})
+	if err != nil {
+		return previous, fmt.Errorf("failed to set ClusterPolicy %s gds.enabled to %t: %w", clusterPolicyName,
+			enabled, err)
+	}
+
+	return previous, nil
+}
+
+// NvidiaFSLoaded waits until the driver pod of every node runs a ready nvidia-fs container and the nvidia-fs kernel
+// module is loaded on the node.
+func NvidiaFSLoaded(apiClient *clients.Settings, gpuNodes []*nodes.Builder, pollInterval,
+	timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			for _, gpuNode := range gpuNodes {
+				if !nodeNvidiaFSLoaded(apiClient, gpuNode.Object.Name) {
+					return false, nil
+				}
+			}
+
+			return true, nil
+		})
+}
+
+// CreateGdsioPod returns a privileged pod pinned to the node that runs gdsio against the hostPath directory, which
+// must be on a local NVMe filesystem.
+func CreateGdsioPod(podName, podNamespace, image, nodeName, hostPath, serviceAccount string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "gds-test-app",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:      corev1.RestartPolicyNever,
+			ServiceAccountName: serviceAccount,
+			NodeSelector: map[string]string{
+				corev1.LabelHostname: nodeName,
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            GdsioContainerName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/bin/bash", "-c", gdsioScript},
+					// gdsio opens the /dev/nvidia-fs* devices and the NVMe block devices of the host.
+					SecurityContext: &corev1.SecurityContext{
+						Privileged: &isTrue,
+					},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							"nvidia.com/gpu": resource.MustParse("1"),
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "nvme-data",
+							MountPath: gdsioDataPath,
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "nvme-data",
+					VolumeSource: corev1.VolumeSource{
+						HostPath: &corev1.HostPathVolumeSource{
+							Path: hostPath,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ParseGdsioThroughput returns the throughputs, in GiB/s, of the gdsio runs of the pod output.
+func ParseGdsioThroughput(output string) ([]float64, error) {
+	var throughputs []float64
+
+	for _, match := range gdsioThroughputRegexp.FindAllStringSubmatch(output, -1) {
+		throughput, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gdsio throughput %q: %w", match[1], err)
+		}
+
+		throughputs = append(throughputs, throughput)
+	}
+
+	if len(throughputs) == 0 {
+		return nil, fmt.Errorf("no gdsio throughput found in the pod output")
+	}
+
+	return throughputs, nil
+}
+
+func nodeNvidiaFSLoaded(apiClient *clients.Settings, nodeName string) bool {
+	driverPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{
+		LabelSelector: driverupgrade.DriverLabel,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil || len(driverPods) != 1 {
+		glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' does not run a single driver pod: %v", nodeName, err)
+
+		return false
+	}
+
+	driverPod := driverPods[0]
+
+	nvidiaFSReady := false
+
+	for _, containerStatus := range driverPod.Object.Status.ContainerStatuses {
+		if containerStatus.Name == NvidiaFSContainerName {
+			nvidiaFSReady = containerStatus.Ready
+		}
+	}
+
+	if !nvidiaFSReady {
+		glog.V(gpuparams.GpuLogLevel).Infof("Driver pod '%s' container '%s' is not ready", driverPod.Object.Name,
+			NvidiaFSContainerName)
+
+		return false
+	}
+
+	output, err := driverPod.ExecCommand([]string{"cat", "/proc/modules"}, NvidiaFSContainerName)
+	if err != nil {
+		glog.V(gpuparams.GpuLogLevel).Infof("Error reading the kernel modules in pod '%s': %v",
+			driverPod.Object.Name, err)
+
+		return false
+	}
+
+	for _, line := range strings.Split(output.String(), "\n") {
+		if strings.HasPrefix(line, NvidiaFSModule+" ") {
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' loaded kernel module '%s'", nodeName, NvidiaFSModule)
+
+			return true
+		}
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' did not load kernel module '%s' yet", nodeName, NvidiaFSModule)
+
+	return false
+}
@@ -1,15 +1,16 @@
 package get
 
 import (
-	"fmt"
+	"strconv"
+
 	"github.com/golang/glog"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/arch"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 )
 
 // InstalledCSVFromSubscription returns installedCSV from Subscription.
@@ -63,32 +64,18 @@ func GetFirstPodNameWithLabel(apiClient *clients.Settings, podNamespace, podLabe
 	return podList[0].Definition.Name, err
 }
 
-// GetClusterArchitecture returns first node architecture of the nodes that match nodeSelector (e.g. worker nodes).
+// GetClusterArchitecture returns the architecture of the nodes that match nodeSelector (e.g. worker nodes), and an
+// error when they have mixed architectures.
 func GetClusterArchitecture(apiClient *clients.Settings, nodeSelector map[string]string) (string, error) {
-	nodeBuilder, err := nodes.List(apiClient, v1.ListOptions{LabelSelector: labels.Set(nodeSelector).String()})
+	return arch.ClusterArchitecture(apiClient, nodeSelector)
+}
 
-	// Check if at least one node matching the nodeSelector has the specific nodeLabel label set to true
-	// For example, look in all the worker nodes for specific label
+// GPUCount returns the number of physical GPUs discovered by GFD on the node, or 0 if the label is missing.
+func GPUCount(nodeBuilder *nodes.Builder) int {
+	count, err := strconv.Atoi(nodeBuilder.Object.Labels["nvidia.com/gpu.count"])
 	if err != nil {
-		glog.V(gpuparams.GpuLogLevel).Infof("could not discover %v nodes", nodeSelector)
-
-		return "", err
-	}
-
-	nodeLabel := "kubernetes.io/arch"
-
-	for _, node := range nodeBuilder {
-		labelValue, ok := node.Object.Labels[nodeLabel]
-
-		if ok {
-			glog.V(gpuparams.GpuLogLevel).Infof("Found label '%v' with label value '%v' on node '%v'",
-				nodeLabel, labelValue, node.Object.Name)
-
-			return labelValue, nil
-		}
+		return 0
 	}
 
-	err = fmt.Errorf("could not find one node with label '%s'", nodeLabel)
-
-	return "", err
+	return count
 }
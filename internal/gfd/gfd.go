@@ -0,0 +1,201 @@
+package gfd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiasmi"
+)
+
+const (
+	// ProductLabel is the GPU product name, with spaces replaced by dashes.
+	ProductLabel = "nvidia.com/gpu.product"
+	// MemoryLabel is the GPU framebuffer memory, in MiB.
+	MemoryLabel = "nvidia.com/gpu.memory"
+	// CountLabel is the number of GPUs of the node.
+	CountLabel = "nvidia.com/gpu.count"
+	// ComputeMajorLabel is the major version of the GPU compute capability.
+	ComputeMajorLabel = "nvidia.com/gpu.compute.major"
+	// ComputeMinorLabel is the minor version of the GPU compute capability.
+	ComputeMinorLabel = "nvidia.com/gpu.compute.minor"
+	// MIGCapableLabel is "true" when the GPUs of the node support MIG.
+	MIGCapableLabel = "nvidia.com/mig.capable"
+
+	// DriverVersionLabelPrefix prefixes the driver version labels, followed by major, minor and revision.
+	DriverVersionLabelPrefix = "nvidia.com/cuda.driver-version."
+	// LegacyDriverVersionLabelPrefix prefixes the driver version labels of older GFD versions.
+	LegacyDriverVersionLabelPrefix = "nvidia.com/cuda.driver."
+	// RuntimeVersionLabelPrefix prefixes the CUDA version labels, followed by major and minor.
+	RuntimeVersionLabelPrefix = "nvidia.com/cuda.runtime-version."
+	// LegacyRuntimeVersionLabelPrefix prefixes the CUDA version labels of older GFD versions.
+	LegacyRuntimeVersionLabelPrefix = "nvidia.com/cuda.runtime."
+
+	// MIGModeNotSupported is the nvidia-smi MIG mode of GPUs that do not support MIG.
+	MIGModeNotSupported = "[N/A]"
+
+	queryGPUFields = "name,memory.total,compute_cap,driver_version,mig.mode.current"
+	// openKernelModuleVersion is part of the /proc/driver/nvidia/version of the open GPU kernel modules
+	openKernelModuleVersion = "Open Kernel Module"
+)
+
+var (
+	// productSuffixRegexp matches the suffixes GFD appends to the product label of shared and MIG GPUs
+	productSuffixRegexp = regexp.MustCompile(`(-MIG-.*|-SHARED)$`)
+)
+
+// GPU holds the properties of a GPU reported by nvidia-smi.
+type GPU struct {
+	Name              string
+	MemoryMiB         string
+	ComputeCapability string
+	DriverVersion     string
+	MIGMode           string
+}
+
+// NodeGPUs holds the GPUs of a node and the CUDA version supported by its driver, as reported by nvidia-smi.
+type NodeGPUs struct {
+	GPUs        []GPU
+	CUDAVersion string
+}
+
+// QueryNodeGPUs runs nvidia-smi in the driver pod of the node and returns the GPUs it reports.
+func QueryNodeGPUs(apiClient *clients.Settings, nodeName string) (*NodeGPUs, error) {
+	driverPod, err := driverupgrade.NodeDriverPod(apiClient, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := driverPod.ExecCommand([]string{"nvidia-smi", "--query-gpu=" + queryGPUFields,
+		"--format=csv,noheader,nounits"}, driverupgrade.DriverContainerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPUs in pod %s: %w", driverPod.Object.Name, err)
+	}
+
+	gpus, err := parseQueryOutput(output.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nvidia-smi output of node %s: %w", nodeName, err)
+	}
+
+	report, err := nvidiasmi.Query(driverPod, driverupgrade.DriverContainerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.CUDAVersion == "" {
+		return nil, fmt.Errorf("no CUDA version found in nvidia-smi report of node %s", nodeName)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' runs %d GPUs with CUDA version '%s': %+v", nodeName,
+		len(gpus), report.CUDAVersion, gpus)
+
+	return &NodeGPUs{GPUs: gpus, CUDAVersion: report.CUDAVersion}, nil
+}
+
+// OpenKernelModulesLoaded returns true when the driver of the node runs the open GPU kernel modules, which the
+// Grace Hopper superchips require, rather than the proprietary ones.
+func OpenKernelModulesLoaded(apiClient *clients.Settings, nodeName string) (bool, error) {
+	driverPod, err := driverupgrade.NodeDriverPod(apiClient, nodeName)
+	if err != nil {
+		return false, err
+	}
+
+	output, err := driverPod.ExecCommand([]string{"cat", "/proc/driver/nvidia/version"},
+		driverupgrade.DriverContainerName)
+	if err != nil {
+		return false, fmt.Errorf("failed to read the driver version in pod %s: %w", driverPod.Object.Name, err)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' driver version: %s", nodeName, output.String())
+
+	return strings.Contains(output.String(), openKernelModuleVersion), nil
+}
+
+// ProductName returns the product label value GFD derives from the nvidia-smi GPU name.
+func ProductName(gpuName string) string {
+	return strings.ReplaceAll(strings.TrimSpace(gpuName), " ", "-")
+}
+
+// ModelName returns the GPU model of a product label value, without the suffixes GFD appends to it when the GPUs
+// are shared through time-slicing or MPS, or partitioned with the single MIG strategy.
+func ModelName(product string) string {
+	return productSuffixRegexp.ReplaceAllString(product, "")
+}
+
+// NodesByModel groups the GPU nodes by the GPU model of their ProductLabel. Nodes without the label are left out.
+func NodesByModel(gpuNodes []*nodes.Builder) map[string][]*nodes.Builder {
+	nodesByModel := map[string][]*nodes.Builder{}
+
+	for _, node := range gpuNodes {
+		product, ok := node.Object.Labels[ProductLabel]
+		if !ok {
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' has no %s label", node.Object.Name, ProductLabel)
+
+			continue
+		}
+
+		model := ModelName(product)
+		nodesByModel[model] = append(nodesByModel[model], node)
+	}
+
+	return nodesByModel
+}
+
+// VersionLabels returns the version label values of the node split by component, e.g. major, minor and revision,
+// from the current label prefix or, when missing, the legacy label prefix.
+func VersionLabels(nodeLabels map[string]string, prefix, legacyPrefix string,
+	components ...string) ([]string, error) {
+	for _, labelPrefix := range []string{prefix, legacyPrefix} {
+		var values []string
+
+		for _, component := range components {
+			if value, ok := nodeLabels[labelPrefix+component]; ok {
+				values = append(values, value)
+			}
+		}
+
+		if len(values) == len(components) {
+			return values, nil
+		}
+	}
+
+	return nil, fmt.Errorf("node has no %s* or %s* labels for %v", prefix, legacyPrefix, components)
+}
+func parseQueryOutput(output string) ([]GPU, error) {
+	var gpus []GPU
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fieldValues := strings.Split(line, ",")
+		if len(fieldValues) != 5 {
+			return nil, fmt.Errorf("unexpected nvidia-smi query line %q", line)
+		}
+
+		for i := range fieldValues {
+			fieldValues[i] = strings.TrimSpace(fieldValues[i])
+		}
+
+		gpus = append(gpus, GPU{
+			Name:              fieldValues[0],
+			MemoryMiB:         fieldValues[1],
+			ComputeCapability: fieldValues[2],
+			DriverVersion:     fieldValues[3],
+			MIGMode:           fieldValues[4],
+		})
+	}
+
+	if len(gpus) == 0 {
+		return nil, fmt.Errorf("nvidia-smi did not report any GPU")
+	}
+
+	return gpus, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	workloadsgpuburn "github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/gpuburn"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,6 +15,9 @@ import (
 
 // github.com/rh-ecosystem-edge/nvidia-ci/tests
 
+// Images are the gpu-burn images by architecture.
+var Images = workloadsgpuburn.Images
+
 var (
 	isFalse bool = false
 	isTrue  bool = true
@@ -0,0 +1,340 @@
+package gpudirect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidianetworkv1alpha1 "github.com/Mellanox/network-operator/api/v1alpha1"
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/networkparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidianetwork"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// NetworkOperatorNamespace is the namespace where the NVIDIA Network Operator is installed.
+	NetworkOperatorNamespace = "nvidia-network-operator"
+	// NetworkOperatorPackage is the NVIDIA Network Operator OLM package name.
+	NetworkOperatorPackage = "nvidia-network-operator"
+	// NicClusterPolicyName is the name of the NicClusterPolicy created from the CSV alm-examples.
+	NicClusterPolicyName = "nic-cluster-policy"
+	// RDMAServiceAccount is the service account used by the RDMA workload pods.
+	RDMAServiceAccount = "rdma"
+	// PeermemContainerName is the driver pod container that loads the nvidia-peermem module.
+	PeermemContainerName = "nvidia-peermem-ctr"
+
+	operatorGroupName      = "nno-og"
+	subscriptionName       = "nno-subscription"
+	catalogSourceDefault   = "certified-operators"
+	catalogSourceNamespace = "openshift-marketplace"
+	privilegedSCCRole      = "system:openshift:scc:privileged"
+)
+
+// rdmaSharedDeviceResources maps the RDMA link type to the rdma shared device plugin resource name, matching the
+// resources requested by the rdmatest workload pods.
+var rdmaSharedDeviceResources = map[string]string{
+	"ethernet":   "rdma_shared_device_eth",
+	"infiniband": "rdma_shared_device_ib",
+}
+
+// InstallNetworkOperator subscribes to the NVIDIA Network Operator and waits for its CSV to succeed.
+// The default catalog channel is used when channel is empty.
+func InstallNetworkOperator(apiClient *clients.Settings, catalogSource, channel string,
+	timeout time.Duration) (*olm.ClusterServiceVersionBuilder, error) {
+	if catalogSource == "" {
+		catalogSource = catalogSourceDefault
+	}
+
+	if channel == "" {
+		pkgManifest, err := olm.PullPackageManifestByCatalog(apiClient, NetworkOperatorPackage,
+			catalogSourceNamespace, catalogSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pull %s packagemanifest from catalog %s: %w",
+				NetworkOperatorPackage, catalogSource, err)
+		}
+
+		channel = pkgManifest.Object.Status.DefaultChannel
+	}
+
+	glog.V(networkparams.LogLevel).Infof("Installing %s from catalog %s with channel %s",
+		NetworkOperatorPackage, catalogSource, channel)
+
+	nsBuilder := namespace.NewBuilder(apiClient, NetworkOperatorNamespace)
+	if !nsBuilder.Exists() {
+		createdNsBuilder, err := nsBuilder.Create()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create namespace %s: %w", NetworkOperatorNamespace, err)
+		}
+
+		_, err = createdNsBuilder.WithMultipleLabels(map[string]string{
+			"openshift.io/cluster-monitoring":    "true",
+			"pod-security.kubernetes.io/enforce": "privileged",
+		}).Update()
+		if err != nil {
+			return nil, fmt.Errorf("failed to label namespace %s: %w", NetworkOperatorNamespace, err)
+		}
+	}
+
+	ogBuilder := olm.NewOperatorGroupBuilder(apiClient, operatorGroupName, NetworkOperatorNamespace)
+	if !ogBuilder.Exists() {
+		if _, err := ogBuilder.Create(); err != nil {
+			return nil, fmt.Errorf("failed to create operatorgroup %s: %w", operatorGroupName, err)
+		}
+	}
+
+	subBuilder := olm.NewSubscriptionBuilder(apiClient, subscriptionName, NetworkOperatorNamespace,
+		catalogSource, catalogSourceNamespace, NetworkOperatorPackage).
+		WithChannel(channel).
+		WithInstallPlanApproval(v1alpha1.ApprovalAutomatic)
+	if !subBuilder.Exists() {
+		if _, err := subBuilder.Create(); err != nil {
+			return nil, fmt.Errorf("failed to create subscription %s: %w", subscriptionName, err)
+		}
+	}
+
+	var csvName string
+
+	err := k8swait.PollUntilContextTimeout(
+		context.TODO(), 30*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+			csvBuilders, err := olm.ListClusterServiceVersion(apiClient, NetworkOperatorNamespace)
+			if err != nil {
+				return false, nil
+			}
+
+			for _, csvBuilder := range csvBuilders {
+				if strings.HasPrefix(csvBuilder.Object.Name, NetworkOperatorPackage) {
+					csvName = csvBuilder.Object.Name
+
+					return true, nil
+				}
+			}
+
+			return false, nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("timed out waiting for %s CSV to be created: %w", NetworkOperatorPackage, err)
+	}
+
+	if err := wait.CSVSucceeded(apiClient, csvName, NetworkOperatorNamespace, 30*time.Second, timeout); err != nil {
+		return nil, fmt.Errorf("CSV %s did not reach the Succeeded phase: %w", csvName, err)
+	}
+
+	return olm.PullClusterServiceVersion(apiClient, csvName, NetworkOperatorNamespace)
+}
+
+// CreateNicClusterPolicy creates the NicClusterPolicy from the CSV alm-examples with the RDMA shared device plugin
+// exposing the given interface for the link type.
+func CreateNicClusterPolicy(apiClient *clients.Settings, almExamples, linkType,
+	interfaceName string) (*nvidianetwork.NicClusterPolicyBuilder, error) {
+	resourceName, ok := rdmaSharedDeviceResources[linkType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported RDMA link type '%s'", linkType)
+	}
+
+	nicClusterPolicyBuilder := nvidianetwork.NewNicClusterPolicyBuilderFromObjectString(apiClient, almExamples)
+	if nicClusterPolicyBuilder.Definition == nil {
+		return nil, fmt.Errorf("failed to build NicClusterPolicy from alm-examples")
+	}
+
+	config, err := json.Marshal(map[string]interface{}{
+		"configList": []map[string]interface{}{
+			{
+				"resourceName": resourceName,
+				"rdmaHcaMax":   63,
+				"selectors": map[string]interface{}{
+					"ifNames": []string{interfaceName},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rdmaSharedDevicePlugin config: %w", err)
+	}
+
+	if nicClusterPolicyBuilder.Definition.Spec.RdmaSharedDevicePlugin == nil {
+		nicClusterPolicyBuilder.Definition.Spec.RdmaSharedDevicePlugin = &nvidianetworkv1alpha1.DevicePluginSpec{}
+	}
+
+	nicClusterPolicyBuilder.Definition.Spec.RdmaSharedDevicePlugin.Config = string(config)
+
+	glog.V(networkparams.LogLevel).Infof("Creating NicClusterPolicy %s with rdmaSharedDevicePlugin config %s",
+		nicClusterPolicyBuilder.Definition.Name, string(config))
+
+	return nicClusterPolicyBuilder.Create()
+}
+
+// SetGPUDirectRDMA enables or disables driver.rdma in the ClusterPolicy and returns the previous rdma spec.
+func SetGPUDirectRDMA(apiClient *clients.Settings, clusterPolicyName string,
+	rdma *nvidiagpuv1.GPUDirectRDMASpec) (*nvidiagpuv1.GPUDirectRDMASpec, error) {
+	glog.V(networkparams.LogLevel).Infof("Setting ClusterPolicy '%s' driver.rdma to %+v", clusterPolicyName, rdma)
+
+	var previousRDMA *nvidiagpuv1.GPUDirectRDMASpec
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousRDMA = definition.Spec.Driver.GPUDirectRDMA
+		definition.Spec.Driver.GPUDirectRDMA = rdma
+	})
+	if err != nil {
+		return previousRDMA, fmt.Errorf("failed to update ClusterPolicy %s driver.rdma: %w", clusterPolicyName,
+			err)
+	}
+
+	return previousRDMA, nil
+}
+
+// PeermemReady returns true when the nvidia-peermem container is ready in every driver pod.
+func PeermemReady(apiClient *clients.Settings) (bool, error) {
+	driverPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace,
+		metav1.ListOptions{LabelSelector: "app=nvidia-driver-daemonset"})
+	if err != nil {
+		return false, err
+	}
+
+	if len(driverPods) == 0 {
+		return false, fmt.Errorf("no driver pods found in namespace %s", nvidiagpu.NvidiaGPUNamespace)
+	}
+
+	for _, driverPod := range driverPods {
+		found := false
+
+		for _, containerStatus := range driverPod.Object.Status.ContainerStatuses {
+			if containerStatus.Name != PeermemContainerName {
+				continue
+			}
+
+			found = true
+
+			if !containerStatus.Ready {
+				glog.V(networkparams.LogLevel).Infof("Container %s in pod %s is not ready", PeermemContainerName,
+					driverPod.Object.Name)
+
+				return false, nil
+			}
+		}
+
+		if !found {
+			glog.V(networkparams.LogLevel).Infof("Pod %s has no %s container yet", driverPod.Object.Name,
+				PeermemContainerName)
+
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// CreateNetworkAttachmentDefinition creates the secondary network used by the RDMA workload pods. A macvlan
+// network is created for the ethernet link type and an ipoib network for the infiniband link type.
+func CreateNetworkAttachmentDefinition(apiClient *clients.Settings, name, nsname, linkType, masterInterface,
+	ipRange string) (*nadv1.NetworkAttachmentDefinition, error) {
+	cniType := "macvlan"
+	if linkType == "infiniband" {
+		cniType = "ipoib"
+	}
+
+	cniConfig, err := json.Marshal(map[string]interface{}{
+		"cniVersion": "0.3.1",
+		"name":       name,
+		"type":       cniType,
+		"master":     masterInterface,
+		"ipam": map[string]interface{}{
+			"type":  "whereabouts",
+			"range": ipRange,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal NetworkAttachmentDefinition config: %w", err)
+	}
+
+	nad := &nadv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: nsname,
+		},
+		Spec: nadv1.NetworkAttachmentDefinitionSpec{
+			Config: string(cniConfig),
+		},
+	}
+
+	glog.V(networkparams.LogLevel).Infof("Creating NetworkAttachmentDefinition %s in namespace %s: %s", name,
+		nsname, string(cniConfig))
+
+	if err := apiClient.Client.Create(context.TODO(), nad); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create NetworkAttachmentDefinition %s: %w", name, err)
+	}
+
+	return nad, nil
+}
+
+// DeleteNetworkAttachmentDefinition deletes a NetworkAttachmentDefinition, ignoring it if it was already removed.
+func DeleteNetworkAttachmentDefinition(apiClient *clients.Settings, name, nsname string) error {
+	nad := &nadv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: nsname,
+		},
+	}
+
+	if err := apiClient.Client.Delete(context.TODO(), nad); err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete NetworkAttachmentDefinition %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// CreateRDMAServiceAccount creates the RDMAServiceAccount and allows it to run privileged RDMA workload pods.
+func CreateRDMAServiceAccount(apiClient *clients.Settings, nsname string) error {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RDMAServiceAccount,
+			Namespace: nsname,
+		},
+	}
+
+	if _, err := apiClient.ServiceAccounts(nsname).Create(context.TODO(), serviceAccount,
+		metav1.CreateOptions{}); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create service account %s: %w", RDMAServiceAccount, err)
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RDMAServiceAccount + "-privileged",
+			Namespace: nsname,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     privilegedSCCRole,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      RDMAServiceAccount,
+				Namespace: nsname,
+			},
+		},
+	}
+
+	if _, err := apiClient.K8sClient.RbacV1().RoleBindings(nsname).Create(context.TODO(), roleBinding,
+		metav1.CreateOptions{}); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create rolebinding %s: %w", roleBinding.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package gpusettings
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/soak"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiasmi"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+)
+
+const (
+	// PowerLimitTolerance is the difference, in W, allowed between the power limits reported by DCGM and nvidia-smi,
+	// DCGM reporting the limit in mW rounded to W.
+	PowerLimitTolerance = 1.0
+
+	// ECCEnabled is the expected ECC mode of the GPUs with ECC enabled.
+	ECCEnabled = "enabled"
+	// ECCDisabled is the expected ECC mode of the GPUs with ECC disabled.
+	ECCDisabled = "disabled"
+
+	// allGPUsGroup is the default DCGM group of all the supported GPUs.
+	allGPUsGroup = "0"
+	// dmonFields are the DCGM fields of the settings, in the order of the Settings fields: the application SM and
+	// memory clocks, the power management limit and the current and pending ECC modes.
+	dmonFields = "110,111,160,300,301"
+	// dmonEntityPrefix prefixes the dcgmi dmon lines of the GPUs, followed by the DCGM GPU id.
+	dmonEntityPrefix = "GPU"
+)
+
+// Settings are the application clocks, power limit and ECC mode of a GPU reported by DCGM, in MHz and W, the ECC
+// modes being 1 when enabled and 0 when disabled.
+type Settings struct {
+	GPU                 int
+	ApplicationSMClock  nvidiasmi.Value
+	ApplicationMemClock nvidiasmi.Value
+	PowerLimit          nvidiasmi.Value
+	ECCCurrent          nvidiasmi.Value
+	ECCPending          nvidiasmi.Value
+}
+
+// NodeDCGMPod returns the running pod of the standalone DCGM host engine of the node.
+func NodeDCGMPod(apiClient *clients.Settings, nodeName string) (*pod.Builder, error) {
+	dcgmPods, err := soak.DCGMPods(apiClient)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dcgmPod := range dcgmPods {
+		if dcgmPod.Object.Spec.NodeName == nodeName {
+			return dcgmPod, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no running DCGM pod found on node %s", nodeName)
+}
+
+// Query returns the settings of the GPUs of the DCGM host engine, sorted by DCGM GPU id. DCGM numbers the GPUs like
+// NVML, the settings being in the order of the GPUs of the nvidia-smi report of the node.
+func Query(dcgmPod *pod.Builder) ([]Settings, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Querying the GPU settings of DCGM pod %s", dcgmPod.Object.Name)
+
+	output, err := dcgmPod.ExecCommand([]string{"dcgmi", "dmon", "-e", dmonFields, "-c", "1"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the GPU settings of DCGM pod %s: %w: %s", dcgmPod.Object.Name, err,
+			output.String())
+	}
+
+	return parseDmon(output.String())
+}
+
+// SetPowerLimit sets the power limit of all the GPUs of the DCGM host engine, in W, DCGM enforcing it until the
+// host engine restarts or the limit is set again.
+func SetPowerLimit(dcgmPod *pod.Builder, watts int) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Setting the power limit of the GPUs of DCGM pod %s to %d W",
+		dcgmPod.Object.Name, watts)
+
+	output, err := dcgmPod.ExecCommand([]string{"dcgmi", "config", "-g", allGPUsGroup, "--set", "-P",
+		strconv.Itoa(watts)})
+	if err != nil {
+		return fmt.Errorf("failed to set the power limit of the GPUs of DCGM pod %s to %d W: %w: %s",
+			dcgmPod.Object.Name, watts, err, output.String())
+	}
+
+	return nil
+}
+
+// Mismatches returns the application clocks, power limit and ECC mode DCGM reports differently from nvidia-smi for
+// the GPU, the settings either of them does not report being left out.
+func Mismatches(settings Settings, gpu nvidiasmi.GPU) []string {
+	var mismatches []string
+
+	clocks := []struct {
+		name      string
+		dcgm, smi nvidiasmi.Value
+	}{
+		{"application SM clock", settings.ApplicationSMClock, gpu.ApplicationClocks.Graphics},
+		{"application memory clock", settings.ApplicationMemClock, gpu.ApplicationClocks.Memory},
+	}
+
+	for _, clock := range clocks {
+		dcgmClock, dcgmErr := clock.dcgm.Int()
+		smiClock, smiErr := clock.smi.Int()
+
+		if dcgmErr == nil && smiErr == nil && dcgmClock != smiClock {
+			mismatches = append(mismatches, fmt.Sprintf("%s: DCGM %d MHz, nvidia-smi %d MHz", clock.name, dcgmClock,
+				smiClock))
+		}
+	}
+
+	dcgmLimit, dcgmErr := settings.PowerLimit.Float()
+	smiLimit, smiErr := gpu.PowerLimit()
+
+	if dcgmErr == nil && smiErr == nil && math.Abs(dcgmLimit-smiLimit) > PowerLimitTolerance {
+		mismatches = append(mismatches, fmt.Sprintf("power limit: DCGM %g W, nvidia-smi %g W", dcgmLimit, smiLimit))
+	}
+
+	eccModes := []struct {
+		name string
+		dcgm nvidiasmi.Value
+		smi  string
+	}{
+		{"current ECC mode", settings.ECCCurrent, gpu.ECCMode.Current},
+		{"pending ECC mode", settings.ECCPending, gpu.ECCMode.Pending},
+	}
+
+	for _, eccMode := range eccModes {
+		dcgmMode, err := eccMode.dcgm.Int()
+		if err != nil || !nvidiasmi.Value(eccMode.smi).Available() {
+			continue
+		}
+
+		if (dcgmMode != 0) != (eccMode.smi == nvidiasmi.Enabled) {
+			mismatches = append(mismatches, fmt.Sprintf("%s: DCGM %d, nvidia-smi %s", eccMode.name, dcgmMode,
+				eccMode.smi))
+		}
+	}
+
+	return mismatches
+}
+
+// Deviations returns the settings of the GPU nvidia-smi reports away from their defaults: the application clocks
+// below the default application clocks, the power limit away from the default power limit, and a pending ECC mode
+// change, applied at the next GPU reset.
+func Deviations(gpu nvidiasmi.GPU) []string {
+	var deviations []string
+
+	clocks := []struct {
+		name              string
+		current, defaults nvidiasmi.Value
+	}{
+		{"application graphics clock", gpu.ApplicationClocks.Graphics, gpu.DefaultApplicationClocks.Graphics},
+		{"application memory clock", gpu.ApplicationClocks.Memory, gpu.DefaultApplicationClocks.Memory},
+	}
+
+	for _, clock := range clocks {
+		current, currentErr := clock.current.Int()
+		defaultClock, defaultErr := clock.defaults.Int()
+
+		if currentErr == nil && defaultErr == nil && current < defaultClock {
+			deviations = append(deviations, fmt.Sprintf("%s %d MHz is below its default %d MHz", clock.name, current,
+				defaultClock))
+		}
+	}
+
+	limit, limitErr := gpu.PowerLimit()
+	defaultLimit, defaultErr := gpu.Readings().DefaultLimit.Float()
+
+	if limitErr == nil && defaultErr == nil && math.Abs(limit-defaultLimit) > PowerLimitTolerance {
+		deviations = append(deviations, fmt.Sprintf("power limit %g W is not the default %g W", limit, defaultLimit))
+	}
+
+	if gpu.ECCMode.Current != gpu.ECCMode.Pending && nvidiasmi.Value(gpu.ECCMode.Pending).Available() {
+		deviations = append(deviations, fmt.Sprintf("ECC mode %s is pending a change to %s", gpu.ECCMode.Current,
+			gpu.ECCMode.Pending))
+	}
+
+	return deviations
+}
+
+// ECCMode returns the ECC mode of the GPU, ECCEnabled or ECCDisabled, empty when the GPU does not support ECC.
+func ECCMode(gpu nvidiasmi.GPU) string {
+	if !nvidiasmi.Value(gpu.ECCMode.Current).Available() {
+		return ""
+	}
+
+	if gpu.ECCEnabled() {
+		return ECCEnabled
+	}
+
+	return ECCDisabled
+}
+
+// parseDmon parses the dcgmi dmon output, one line per GPU made of the GPU entity, its id and the field values, e.g.
+// "GPU 0   1410   1593   400.000   1   1".
+func parseDmon(output string) ([]Settings, error) {
+	var settings []Settings
+
+	fieldCount := len(strings.Split(dmonFields, ","))
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != dmonEntityPrefix {
+			continue
+		}
+
+		if len(fields) != fieldCount+2 {
+			return nil, fmt.Errorf("dcgmi dmon line %q does not have %d values", line, fieldCount)
+		}
+
+		gpu, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("dcgmi dmon line %q has no GPU id: %w", line, err)
+		}
+
+		settings = append(settings, Settings{
+			GPU:                 gpu,
+			ApplicationSMClock:  nvidiasmi.Value(fields[2]),
+			ApplicationMemClock: nvidiasmi.Value(fields[3]),
+			PowerLimit:          nvidiasmi.Value(fields[4]),
+			ECCCurrent:          nvidiasmi.Value(fields[5]),
+			ECCPending:          nvidiasmi.Value(fields[6]),
+		})
+	}
+
+	if len(settings) == 0 {
+		return nil, fmt.Errorf("dcgmi dmon reported no GPU: %s", output)
+	}
+
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].GPU < settings[j].GPU
+	})
+
+	return settings, nil
+}
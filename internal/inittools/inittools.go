@@ -9,6 +9,8 @@ import (
 	ginkgo "github.com/onsi/ginkgo/v2"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/config"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients/retry"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/timeouts"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilversion "k8s.io/apimachinery/pkg/util/version"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -20,6 +22,9 @@ var (
 	APIClient *clients.Settings
 	// GeneralConfig provides access to general configuration parameters.
 	GeneralConfig *config.GeneralConfig
+	// ClusterClients provides access to the named clusters of the general config, e.g. the management and hosted
+	// clusters, next to the KUBECONFIG cluster of APIClient.
+	ClusterClients map[string]*clients.Settings
 )
 
 // init loads all variables automatically when this package is imported. Once package is imported a user has full
@@ -35,6 +40,19 @@ func init() {
 	_ = flag.Lookup("logtostderr").Value.Set("true")
 	_ = flag.Lookup("v").Value.Set(GeneralConfig.VerboseLevel)
 
+	retry.SetBackoff(GeneralConfig.GetClientRetryBackoff())
+
+	timeoutProfile, err := GeneralConfig.GetTimeoutProfile()
+	if err != nil {
+		glog.Fatalf("can not load timeout profile: %v", err)
+	}
+
+	timeouts.SetProfile(timeoutProfile)
+
+	if ClusterClients, err = clients.NewForClusters(GeneralConfig.GetClusterKubeconfigs()); err != nil {
+		glog.Fatalf("can not load cluster clients: %v", err)
+	}
+
 	if APIClient = clients.New(""); APIClient == nil {
 		if GeneralConfig.DryRun {
 			return
@@ -44,7 +62,21 @@ func init() {
 	}
 }
 
+// GetClusterClient returns the client of the named cluster, e.g. config.ManagementCluster or config.HostedCluster.
+func GetClusterClient(clusterName string) (*clients.Settings, error) {
+	clusterClient, found := ClusterClients[clusterName]
+	if !found {
+		return nil, fmt.Errorf("no kubeconfig is set for cluster %q", clusterName)
+	}
+
+	return clusterClient, nil
+}
+
 func GetOpenShiftVersion() (string, error) {
+	if APIClient == nil {
+		return "", fmt.Errorf("no ApiClient to get the OpenShift version from, DRY_RUN is set")
+	}
+
 	clusterVersion, err := APIClient.ClusterVersions().Get(context.TODO(), "version", metav1.GetOptions{})
 	if err != nil {
 		return "", err
@@ -0,0 +1,296 @@
+package junit
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/v2/reporters"
+)
+
+const (
+	// FlakyStatus is the JUnit status of the specs that passed after failed attempts.
+	FlakyStatus = "flaky"
+	// FileSuffix is the suffix of the JUnit reports the suites write to REPORTS_DUMP_DIR.
+	FileSuffix = "_junit.xml"
+
+	suiteTestSuffix      = "_suite_test"
+	suiteSucceededName   = "SuiteSucceeded"
+	pendingStatus        = "pending"
+	suiteTimestampLayout = "2006-01-02T15:04:05"
+)
+
+// File is the JUnit report of a file, its suites being prefixed with Prefix when merged.
+type File struct {
+	Prefix string
+	Report reporters.JUnitTestSuites
+}
+
+// SuiteSummary is the outcome of a test suite of a merged report.
+type SuiteSummary struct {
+	Name     string  `json:"name"`
+	Passed   bool    `json:"passed"`
+	Tests    int     `json:"tests"`
+	Failures int     `json:"failures"`
+	Errors   int     `json:"errors"`
+	Skipped  int     `json:"skipped"`
+	Disabled int     `json:"disabled"`
+	Flaky    int     `json:"flaky"`
+	Time     float64 `json:"time"`
+}
+
+// Summary is the outcome of a merged report, passed when all its test suites passed.
+type Summary struct {
+	Passed   bool           `json:"passed"`
+	Tests    int            `json:"tests"`
+	Failures int            `json:"failures"`
+	Errors   int            `json:"errors"`
+	Skipped  int            `json:"skipped"`
+	Disabled int            `json:"disabled"`
+	Flaky    int            `json:"flaky"`
+	Time     float64        `json:"time"`
+	Suites   []SuiteSummary `json:"suites"`
+}
+
+// Read decodes the JUnit report of the file.
+func Read(reportPath string) (reporters.JUnitTestSuites, error) {
+	var report reporters.JUnitTestSuites
+
+	content, err := os.ReadFile(reportPath)
+	if err != nil {
+		return report, fmt.Errorf("failed to read JUnit report %s: %w", reportPath, err)
+	}
+
+	if err := xml.Unmarshal(content, &report); err != nil {
+		return report, fmt.Errorf("failed to decode JUnit report %s: %w", reportPath, err)
+	}
+
+	return report, nil
+}
+
+// Write encodes the JUnit report to the file, indented like the reports of ginkgo.
+func Write(reportPath string, report reporters.JUnitTestSuites) error {
+	content, err := xml.MarshalIndent(report, "  ", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JUnit report %s: %w", reportPath, err)
+	}
+
+	if err := os.WriteFile(reportPath, append([]byte(xml.Header), content...), 0666); err != nil {
+		return fmt.Errorf("failed to write JUnit report %s: %w", reportPath, err)
+	}
+
+	return nil
+}
+
+// Prefix returns the prefix of the suites of a JUnit report of REPORTS_DUMP_DIR, its file name without the report
+// suffix, e.g. chaos for chaos_suite_test_junit.xml or gpu-operator-v25.3_gpu for the
+// gpu-operator-v25.3_gpu_suite_test_junit.xml report of a version matrix.
+func Prefix(reportPath string) string {
+	prefix := strings.TrimSuffix(filepath.Base(reportPath), FileSuffix)
+	prefix = strings.TrimSuffix(prefix, filepath.Ext(prefix))
+
+	return strings.TrimSuffix(prefix, suiteTestSuffix)
+}
+
+// Merge returns a report of the test suites of the files, named after the prefix of their file and their name, e.g.
+// "chaos: Chaos", their test cases having this name as class name. The test suites of the same name, e.g. of a
+// rerun, are merged into one, with their test cases, and their properties de-duplicated. The counts of the test
+// suites and of the report are computed from the test cases.
+func Merge(files []File) reporters.JUnitTestSuites {
+	var merged reporters.JUnitTestSuites
+
+	suiteIndexes := map[string]int{}
+
+	for _, file := range files {
+		for _, suite := range file.Report.TestSuites {
+			name := suite.Name
+			if file.Prefix != "" {
+				name = fmt.Sprintf("%s: %s", file.Prefix, suite.Name)
+			}
+
+			suite.TestCases = append([]reporters.JUnitTestCase(nil), suite.TestCases...)
+			for index := range suite.TestCases {
+				suite.TestCases[index].Classname = name
+			}
+
+			index, found := suiteIndexes[name]
+			if !found {
+				suite.Name = name
+				suiteIndexes[name] = len(merged.TestSuites)
+				merged.TestSuites = append(merged.TestSuites, suite)
+
+				continue
+			}
+
+			mergedSuite := &merged.TestSuites[index]
+			mergedSuite.TestCases = append(mergedSuite.TestCases, suite.TestCases...)
+			mergedSuite.Properties.Properties = append(mergedSuite.Properties.Properties,
+				suite.Properties.Properties...)
+			mergedSuite.Time += suite.Time
+
+			if earlierTimestamp(suite.Timestamp, mergedSuite.Timestamp) {
+				mergedSuite.Timestamp = suite.Timestamp
+			}
+		}
+	}
+
+	for index := range merged.TestSuites {
+		suite := &merged.TestSuites[index]
+		suite.Properties.Properties = dedupProperties(suite.Properties.Properties)
+		countTestCases(suite)
+
+		merged.Tests += suite.Tests
+		merged.Disabled += suite.Disabled + suite.Skipped
+		merged.Errors += suite.Errors
+		merged.Failures += suite.Failures
+		merged.Time += suite.Time
+	}
+
+	return merged
+}
+
+// Summarize returns the outcome of the report and of its test suites. A test suite passes when none of its test
+// cases failed nor errored and its SuiteSucceeded property, when set, is true.
+func Summarize(report reporters.JUnitTestSuites) Summary {
+	summary := Summary{Passed: true, Suites: []SuiteSummary{}}
+
+	for _, suite := range report.TestSuites {
+		suiteSummary := SuiteSummary{
+			Name:     suite.Name,
+			Tests:    len(suite.TestCases),
+			Failures: suite.Failures,
+			Errors:   suite.Errors,
+			Skipped:  suite.Skipped,
+			Disabled: suite.Disabled,
+			Time:     suite.Time,
+		}
+
+		for _, testCase := range suite.TestCases {
+			if testCase.Status == FlakyStatus {
+				suiteSummary.Flaky++
+			}
+		}
+
+		suiteSummary.Passed = suite.Failures == 0 && suite.Errors == 0 && suiteSucceeded(suite)
+
+		summary.Passed = summary.Passed && suiteSummary.Passed
+		summary.Tests += suiteSummary.Tests
+		summary.Failures += suiteSummary.Failures
+		summary.Errors += suiteSummary.Errors
+		summary.Skipped += suiteSummary.Skipped
+		summary.Disabled += suiteSummary.Disabled
+		summary.Flaky += suiteSummary.Flaky
+		summary.Time += suiteSummary.Time
+		summary.Suites = append(summary.Suites, suiteSummary)
+	}
+
+	return summary
+}
+
+// WriteJSON writes the summary as indented JSON.
+func (summary Summary) WriteJSON(output io.Writer) error {
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(summary)
+}
+
+// WriteText writes a line per test suite followed by the overall outcome.
+func (summary Summary) WriteText(output io.Writer) error {
+	var lines []string
+
+	failedSuites := 0
+
+	for _, suite := range summary.Suites {
+		if !suite.Passed {
+			failedSuites++
+		}
+
+		lines = append(lines, fmt.Sprintf("%s %s: %s", status(suite.Passed), suite.Name,
+			counts(suite.Tests, suite.Failures, suite.Errors, suite.Skipped, suite.Flaky, suite.Time)))
+	}
+
+	lines = append(lines, fmt.Sprintf("%s: %d of %d suites failed, %s", status(summary.Passed), failedSuites,
+		len(summary.Suites), counts(summary.Tests, summary.Failures, summary.Errors, summary.Skipped, summary.Flaky,
+			summary.Time)))
+
+	_, err := fmt.Fprintln(output, strings.Join(lines, "\n"))
+
+	return err
+}
+
+// countTestCases sets the counts of the test suite from the outcome of its test cases.
+func countTestCases(suite *reporters.JUnitTestSuite) {
+	suite.Tests = len(suite.TestCases)
+	suite.Failures, suite.Errors, suite.Skipped, suite.Disabled = 0, 0, 0, 0
+
+	for _, testCase := range suite.TestCases {
+		switch {
+		case testCase.Failure != nil:
+			suite.Failures++
+		case testCase.Error != nil:
+			suite.Errors++
+		case testCase.Status == pendingStatus:
+			suite.Disabled++
+		case testCase.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+}
+
+// dedupProperties returns the properties without the repeated name and value pairs, in their first order.
+func dedupProperties(properties []reporters.JUnitProperty) []reporters.JUnitProperty {
+	seen := map[reporters.JUnitProperty]bool{}
+
+	var deduped []reporters.JUnitProperty
+
+	for _, property := range properties {
+		if !seen[property] {
+			seen[property] = true
+			deduped = append(deduped, property)
+		}
+	}
+
+	return deduped
+}
+
+// suiteSucceeded returns false when a SuiteSucceeded property of the test suite is false, e.g. when a suite node
+// failed.
+func suiteSucceeded(suite reporters.JUnitTestSuite) bool {
+	for _, property := range suite.Properties.Properties {
+		if property.Name == suiteSucceededName && property.Value == "false" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func earlierTimestamp(timestamp, other string) bool {
+	parsed, err := time.Parse(suiteTimestampLayout, timestamp)
+	if err != nil {
+		return false
+	}
+
+	parsedOther, err := time.Parse(suiteTimestampLayout, other)
+
+	return err != nil || parsed.Before(parsedOther)
+}
+
+func status(passed bool) string {
+	if passed {
+		return "PASSED"
+	}
+
+	return "FAILED"
+}
+
+func counts(tests, failures, errors, skipped, flaky int, seconds float64) string {
+	return fmt.Sprintf("%d tests, %d failures, %d errors, %d skipped, %d flaky in %s", tests, failures, errors,
+		skipped, flaky, (time.Duration(seconds * float64(time.Second))).Round(time.Second))
+}
@@ -0,0 +1,259 @@
+package kata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// OperatorNamespace is the namespace where the OpenShift sandboxed containers operator is installed.
+	OperatorNamespace = "openshift-sandboxed-containers-operator"
+	// OperatorPackage is the OpenShift sandboxed containers operator OLM package name.
+	OperatorPackage = "sandboxed-containers-operator"
+	// KataConfigName is the name of the KataConfig created by the tests.
+	KataConfigName = "nvidia-ci-kataconfig"
+
+	// WorkloadConfigLabel selects the GPU workload type the GPU operator configures on a node.
+	WorkloadConfigLabel = "nvidia.com/gpu.workload.config"
+	// WorkloadConfigVMPassthrough is the WorkloadConfigLabel value for nodes passing their GPUs through to VMs.
+	WorkloadConfigVMPassthrough = "vm-passthrough"
+	// CCCapableLabel is set to "true" by GFD on nodes whose GPUs support confidential computing.
+	CCCapableLabel = "nvidia.com/cc.capable"
+	// CCModeLabel selects the confidential computing mode the CC manager applies on the node GPUs.
+	CCModeLabel = "nvidia.com/cc.mode"
+	// CCModeStateLabel reports the confidential computing mode applied by the CC manager, or "failed".
+	CCModeStateLabel = "nvidia.com/cc.mode.state"
+	// WorkloadContainerName is the container name of the pods built by CreateKataPod.
+	WorkloadContainerName = "kata-cuda-ctr"
+	// GuestKernelLogPrefix prefixes the kernel version printed by the pods built by CreateKataPod.
+	GuestKernelLogPrefix = "guest-kernel="
+
+	operatorGroupName      = "osc-og"
+	subscriptionName       = "osc-subscription"
+	catalogSourceDefault   = "redhat-operators"
+	catalogSourceNamespace = "openshift-marketplace"
+)
+
+var (
+	isFalse = false
+	isTrue  = true
+)
+
+// InstallSandboxedContainersOperator subscribes to the OpenShift sandboxed containers operator and waits for its
+// CSV to succeed. The default catalog channel is used when channel is empty.
+func InstallSandboxedContainersOperator(apiClient *clients.Settings, catalogSource, channel string,
+	timeout time.Duration) error {
+	if catalogSource == "" {
+		catalogSource = catalogSourceDefault
+	}
+
+	if channel == "" {
+		pkgManifest, err := olm.PullPackageManifestByCatalog(apiClient, OperatorPackage, catalogSourceNamespace,
+			catalogSource)
+		if err != nil {
+			return fmt.Errorf("failed to pull %s packagemanifest from catalog %s: %w", OperatorPackage,
+				catalogSource, err)
+		}
+
+		channel = pkgManifest.Object.Status.DefaultChannel
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Installing %s from catalog %s with channel %s", OperatorPackage,
+		catalogSource, channel)
+
+	nsBuilder := namespace.NewBuilder(apiClient, OperatorNamespace)
+	if !nsBuilder.Exists() {
+		if _, err := nsBuilder.Create(); err != nil {
+			return fmt.Errorf("failed to create namespace %s: %w", OperatorNamespace, err)
+		}
+	}
+
+	ogBuilder := olm.NewOperatorGroupBuilder(apiClient, operatorGroupName, OperatorNamespace)
+	if !ogBuilder.Exists() {
+		if _, err := ogBuilder.Create(); err != nil {
+			return fmt.Errorf("failed to create operatorgroup %s: %w", operatorGroupName, err)
+		}
+	}
+
+	subBuilder := olm.NewSubscriptionBuilder(apiClient, subscriptionName, OperatorNamespace, catalogSource,
+		catalogSourceNamespace, OperatorPackage).
+		WithChannel(channel).
+		WithInstallPlanApproval(v1alpha1.ApprovalAutomatic)
+	if !subBuilder.Exists() {
+		if _, err := subBuilder.Create(); err != nil {
+			return fmt.Errorf("failed to create subscription %s: %w", subscriptionName, err)
+		}
+	}
+
+	var csvName string
+
+	err := k8swait.PollUntilContextTimeout(
+		context.TODO(), 30*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+			csvBuilders, err := olm.ListClusterServiceVersion(apiClient, OperatorNamespace)
+			if err != nil {
+				return false, nil
+			}
+
+			for _, csvBuilder := range csvBuilders {
+				if strings.HasPrefix(csvBuilder.Object.Name, OperatorPackage) {
+					csvName = csvBuilder.Object.Name
+
+					return true, nil
+				}
+			}
+
+			return false, nil
+		})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for %s CSV to be created: %w", OperatorPackage, err)
+	}
+
+	if err := wait.CSVSucceeded(apiClient, csvName, OperatorNamespace, 30*time.Second, timeout); err != nil {
+		return fmt.Errorf("CSV %s did not reach the Succeeded phase: %w", csvName, err)
+	}
+
+	return nil
+}
+
+// EnableKataInClusterPolicy enables sandbox workloads, the kata manager and the CC manager with the given default
+// CC mode. The default workload is left untouched, kata nodes are selected through the WorkloadConfigLabel. It
+// returns a copy of the previous ClusterPolicy spec so that it can be restored.
+func EnableKataInClusterPolicy(apiClient *clients.Settings, clusterPolicyName,
+	ccDefaultMode string) (*nvidiagpuv1.ClusterPolicySpec, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Enabling the kata and CC managers in ClusterPolicy '%s' with default "+
+		"CC mode '%s'", clusterPolicyName, ccDefaultMode)
+
+	var previousSpec *nvidiagpuv1.ClusterPolicySpec
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousSpec = definition.Spec.DeepCopy()
+
+		spec := &definition.Spec
+		spec.SandboxWorkloads.Enabled = &isTrue
+		spec.SandboxDevicePlugin.Enabled = &isTrue
+		spec.VFIOManager.Enabled = &isTrue
+		spec.KataManager.Enabled = &isTrue
+		spec.CCManager.Enabled = &isTrue
+		spec.CCManager.DefaultMode = ccDefaultMode
+	})
+	if err != nil {
+		return previousSpec, fmt.Errorf("failed to enable kata in ClusterPolicy %s: %w", clusterPolicyName, err)
+	}
+
+	return previousSpec, nil
+}
+
+// GetNodeLabel returns the value of a node label, or an empty string when the label is not set.
+func GetNodeLabel(apiClient *clients.Settings, nodeName, key string) (string, error) {
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		return "", err
+	}
+
+	return nodeBuilder.Object.Labels[key], nil
+}
+
+// PassthroughResourceName returns the GPU passthrough resource the sandbox device plugin advertises on the node,
+// e.g. "nvidia.com/GA100_A100_PCIE_40GB". An empty name is returned while no passthrough GPU is allocatable.
+func PassthroughResourceName(apiClient *clients.Settings, nodeName string) (string, error) {
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		return "", err
+	}
+
+	for resourceName, quantity := range nodeBuilder.Object.Status.Allocatable {
+		if !strings.HasPrefix(string(resourceName), "nvidia.com/") || resourceName == "nvidia.com/gpu" {
+			continue
+		}
+
+		if quantity.Value() > 0 {
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' advertises %d passthrough GPUs as '%s'", nodeName,
+				quantity.Value(), resourceName)
+
+			return string(resourceName), nil
+		}
+	}
+
+	return "", nil
+}
+
+// RuntimeClassExists returns true when the RuntimeClass is defined in the cluster.
+func RuntimeClassExists(apiClient *clients.Settings, name string) (bool, error) {
+	_, err := apiClient.K8sClient.NodeV1().RuntimeClasses().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to get RuntimeClass %s: %w", name, err)
+	}
+
+	return true, nil
+}
+
+// CreateKataPod returns a long running pod running in a kata VM with one passthrough GPU. It lists the GPU and
+// prints the guest kernel version, prefixed with GuestKernelLogPrefix.
+func CreateKataPod(podName, podNamespace, runtimeClassName, resourceName, image string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "kata-test-app",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RuntimeClassName: &runtimeClassName,
+			RestartPolicy:    corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &isTrue,
+				SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            WorkloadContainerName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command: []string{"/bin/sh", "-c",
+						"nvidia-smi -L; echo " + GuestKernelLogPrefix + "$(uname -r); sleep infinity"},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &isFalse,
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+					},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceName(resourceName): resource.MustParse("1"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
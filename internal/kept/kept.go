@@ -0,0 +1,86 @@
+package kept
+
+import (
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/kata"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// namespacesGVR is the resource of the namespaces, kept by name.
+	namespacesGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+	olmGVRs = []schema.GroupVersionResource{
+		{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"},
+		{Group: "operators.coreos.com", Version: "v1", Resource: "operatorgroups"},
+		{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "catalogsources"},
+		{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "installplans"},
+	}
+
+	// gpuOperatorGVRs are the resources of the GPU operator deployment and of the NFD operator it depends on, besides
+	// the OLM objects, kept with NVIDIAGPU_CLEANUP set to false.
+	gpuOperatorGVRs = []schema.GroupVersionResource{
+		{Group: "nvidia.com", Version: "v1", Resource: "clusterpolicies"},
+		{Group: "nfd.openshift.io", Version: "v1", Resource: "nodefeaturediscoveries"},
+		{Group: "machine.openshift.io", Version: "v1beta1", Resource: "machinesets"},
+		kata.GetKataConfigGVR(),
+	}
+
+	// gpuOperatorNamespaces are the namespaces of the GPU and NFD operators, kept with all their objects.
+	gpuOperatorNamespaces = []string{"nvidia-gpu-operator", "openshift-nfd"}
+
+	// networkOperatorGVRs are the resources of the network operator deployment, besides the OLM objects, kept with
+	// NVIDIANETWORK_CLEANUP set to false.
+	networkOperatorGVRs = []schema.GroupVersionResource{
+		{Group: "mellanox.com", Version: "v1alpha1", Resource: "nicclusterpolicies"},
+		{Group: "mellanox.com", Version: "v1alpha1", Resource: "macvlannetworks"},
+		{Group: "mellanox.com", Version: "v1alpha1", Resource: "ipoibnetworks"},
+	}
+
+	// networkOperatorNamespaces are the namespaces of the network operator, kept with all their objects.
+	networkOperatorNamespaces = []string{"nvidia-network-operator"}
+)
+
+// Resources are the resources of the operators deployed on purpose to be kept after the run, their cleanup being
+// disabled, so that their objects are neither reported as leaks nor deleted on interrupt.
+type Resources struct {
+	gvrs       map[schema.GroupVersionResource]bool
+	namespaces map[string]bool
+}
+
+// New returns the resources kept after the run, those of the GPU operator and of the NFD operator it depends on when
+// gpuCleanup is false, and those of the network operator when networkCleanup is false.
+func New(gpuCleanup, networkCleanup bool) *Resources {
+	resources := &Resources{gvrs: map[schema.GroupVersionResource]bool{}, namespaces: map[string]bool{}}
+
+	if !gpuCleanup {
+		resources.add(gpuOperatorNamespaces, gpuOperatorGVRs)
+		resources.add(nil, olmGVRs)
+	}
+
+	if !networkCleanup {
+		resources.add(networkOperatorNamespaces, networkOperatorGVRs)
+		resources.add(nil, olmGVRs)
+	}
+
+	return resources
+}
+
+// Contains returns true when the object of the resource, in the namespace, is kept on purpose. The namespaces are
+// identified by their name, and are kept with all their objects.
+func (resources *Resources) Contains(gvr schema.GroupVersionResource, namespace, name string) bool {
+	if gvr == namespacesGVR {
+		return resources.namespaces[name]
+	}
+
+	return resources.gvrs[gvr] || resources.namespaces[namespace]
+}
+
+func (resources *Resources) add(namespaces []string, gvrs []schema.GroupVersionResource) {
+	for _, namespace := range namespaces {
+		resources.namespaces[namespace] = true
+	}
+
+	for _, gvr := range gvrs {
+		resources.gvrs[gvr] = true
+	}
+}
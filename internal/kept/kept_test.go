@@ -0,0 +1,52 @@
+package kept
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	podsGVR            = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	clusterPoliciesGVR = schema.GroupVersionResource{Group: "nvidia.com", Version: "v1", Resource: "clusterpolicies"}
+	subscriptionsGVR   = schema.GroupVersionResource{
+		Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"}
+	nicClusterPoliciesGVR = schema.GroupVersionResource{
+		Group: "mellanox.com", Version: "v1alpha1", Resource: "nicclusterpolicies"}
+)
+
+func TestContainsWithGPUCleanupDisabled(t *testing.T) {
+	resources := New(false, true)
+
+	for _, object := range []struct {
+		gvr       schema.GroupVersionResource
+		namespace string
+		name      string
+		kept      bool
+	}{
+		{namespacesGVR, "", "nvidia-gpu-operator", true},
+		{namespacesGVR, "", "openshift-nfd", true},
+		{clusterPoliciesGVR, "", "gpu-cluster-policy", true},
+		{subscriptionsGVR, "nvidia-gpu-operator", "gpu-operator-certified", true},
+		{podsGVR, "nvidia-gpu-operator", "gpu-operator-6c8f9", true},
+		{namespacesGVR, "", "test-gpu-burn", false},
+		{podsGVR, "test-gpu-burn", "gpu-burn-x7k4q", false},
+		{namespacesGVR, "", "nvidia-network-operator", false},
+		{nicClusterPoliciesGVR, "", "nic-cluster-policy", false},
+	} {
+		if kept := resources.Contains(object.gvr, object.namespace, object.name); kept != object.kept {
+			t.Errorf("Contains(%s, %q, %q) = %t, expected %t", object.gvr.Resource, object.namespace, object.name,
+				kept, object.kept)
+		}
+	}
+}
+
+func TestContainsWithCleanupEnabled(t *testing.T) {
+	resources := New(true, true)
+
+	if resources.Contains(clusterPoliciesGVR, "", "gpu-cluster-policy") ||
+		resources.Contains(namespacesGVR, "", "nvidia-gpu-operator") ||
+		resources.Contains(subscriptionsGVR, "nvidia-network-operator", "nvidia-network-operator") {
+		t.Error("objects are kept while the GPU and network operator cleanups are enabled")
+	}
+}
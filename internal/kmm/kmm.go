@@ -0,0 +1,290 @@
+package kmm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8swait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// OperatorNamespace is the namespace where the Kernel Module Management operator is installed.
+	OperatorNamespace = "openshift-kmm"
+	// OperatorPackage is the Kernel Module Management operator OLM package name.
+	OperatorPackage = "kernel-module-management"
+	// KernelModule is the kernel module the NVIDIA Module loads, modprobe loading the nvidia module it depends on.
+	KernelModule = "nvidia_uvm"
+	// KernelVersionVariable is replaced by KMM with the kernel version of the node in the Module container image.
+	KernelVersionVariable = "${KERNEL_FULL_VERSION}"
+	// DriverDeployLabel is the node label the GPU operator deploys its driver DaemonSet on when not "false".
+	DriverDeployLabel = "nvidia.com/gpu.deploy.driver"
+	// DockerfileKey is the ConfigMap key holding the Dockerfile KMM builds the Module container image from.
+	DockerfileKey = "dockerfile"
+
+	operatorGroupName      = "kernel-module-management"
+	subscriptionName       = "kernel-module-management"
+	catalogSourceDefault   = "redhat-operators"
+	catalogSourceNamespace = "openshift-marketplace"
+	csvPollInterval        = 30 * time.Second
+)
+
+// ModuleGVR is the resource of the KMM Modules.
+var ModuleGVR = schema.GroupVersionResource{Group: "kmm.sigs.x-k8s.io", Version: "v1beta1", Resource: "modules"}
+
+// ModuleConfig is the KMM Module loading the NVIDIA kernel modules.
+type ModuleConfig struct {
+	Name      string
+	Namespace string
+	// Image is the container image shipping the kernel modules in /opt/lib/modules/<kernel version>, referencing
+	// the node kernel version with KernelVersionVariable.
+	Image string
+	// DockerfileConfigMap, when set, is the ConfigMap holding the Dockerfile KMM builds Image from on the cluster.
+	DockerfileConfigMap string
+	// FirmwarePath, when set, is the directory of Image holding the GPU firmware KMM copies on the host.
+	FirmwarePath       string
+	ServiceAccountName string
+	NodeSelector       map[string]string
+}
+
+// ModuleReadyLabel is the label KMM sets on the nodes where it loaded the kernel module of the Module.
+func ModuleReadyLabel(nsname, name string) string {
+	return fmt.Sprintf("kmm.node.kubernetes.io/%s.%s.ready", nsname, name)
+}
+
+// InstallOperator subscribes to the Kernel Module Management operator, watching all namespaces, and waits for its
+// CSV to succeed. The default catalog channel is used when channel is empty.
+func InstallOperator(apiClient *clients.Settings, catalogSource, channel string, timeout time.Duration) error {
+	if catalogSource == "" {
+		catalogSource = catalogSourceDefault
+	}
+
+	if channel == "" {
+		pkgManifest, err := olm.PullPackageManifestByCatalog(apiClient, OperatorPackage, catalogSourceNamespace,
+			catalogSource)
+		if err != nil {
+			return fmt.Errorf("failed to pull %s packagemanifest from catalog %s: %w", OperatorPackage,
+				catalogSource, err)
+		}
+
+		channel = pkgManifest.Object.Status.DefaultChannel
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Installing %s from catalog %s with channel %s", OperatorPackage,
+		catalogSource, channel)
+
+	nsBuilder := namespace.NewBuilder(apiClient, OperatorNamespace)
+	if !nsBuilder.Exists() {
+		if _, err := nsBuilder.Create(); err != nil {
+			return fmt.Errorf("failed to create namespace %s: %w", OperatorNamespace, err)
+		}
+	}
+
+	ogBuilder := olm.NewOperatorGroupBuilder(apiClient, operatorGroupName, OperatorNamespace)
+	ogBuilder.Definition.Spec.TargetNamespaces = nil
+
+	if !ogBuilder.Exists() {
+		if _, err := ogBuilder.Create(); err != nil {
+			return fmt.Errorf("failed to create operatorgroup %s: %w", operatorGroupName, err)
+		}
+	}
+
+	subBuilder := olm.NewSubscriptionBuilder(apiClient, subscriptionName, OperatorNamespace, catalogSource,
+		catalogSourceNamespace, OperatorPackage).
+		WithChannel(channel).
+		WithInstallPlanApproval(v1alpha1.ApprovalAutomatic)
+	if !subBuilder.Exists() {
+		if _, err := subBuilder.Create(); err != nil {
+			return fmt.Errorf("failed to create subscription %s: %w", subscriptionName, err)
+		}
+	}
+
+	var csvName string
+
+	err := k8swait.PollUntilContextTimeout(
+		context.TODO(), csvPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			csvBuilders, err := olm.ListClusterServiceVersion(apiClient, OperatorNamespace)
+			if err != nil {
+				return false, nil
+			}
+
+			for _, csvBuilder := range csvBuilders {
+				if strings.HasPrefix(csvBuilder.Object.Name, OperatorPackage) {
+					csvName = csvBuilder.Object.Name
+
+					return true, nil
+				}
+			}
+
+			return false, nil
+		})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for %s CSV to be created: %w", OperatorPackage, err)
+	}
+
+	if err := wait.CSVSucceeded(apiClient, csvName, OperatorNamespace, csvPollInterval, timeout); err != nil {
+		return fmt.Errorf("CSV %s did not reach the Succeeded phase: %w", csvName, err)
+	}
+
+	return nil
+}
+
+// CreateDockerfileConfigMap creates the configmap holding the Dockerfile KMM builds the Module container image from.
+func CreateDockerfileConfigMap(apiClient *clients.Settings, name, nsname, dockerfile string) (*configmap.Builder,
+	error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating KMM Dockerfile configmap '%s' in namespace '%s'", name, nsname)
+
+	return configmap.NewBuilder(apiClient, name, nsname).WithData(map[string]string{
+		DockerfileKey: dockerfile,
+	}).Create()
+}
+
+// CreateModule creates the KMM Module loading the NVIDIA kernel modules on the nodes of its selector, building its
+// container image first when it has a Dockerfile ConfigMap.
+func CreateModule(apiClient *clients.Settings, config ModuleConfig) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating KMM Module '%s' in namespace '%s' with image '%s'", config.Name,
+		config.Namespace, config.Image)
+
+	kernelMapping := map[string]any{
+		"regexp":         "^.+$",
+		"containerImage": config.Image,
+	}
+
+	if config.DockerfileConfigMap != "" {
+		kernelMapping["build"] = map[string]any{
+			"dockerfileConfigMap": map[string]any{"name": config.DockerfileConfigMap},
+		}
+	}
+
+	modprobe := map[string]any{"moduleName": KernelModule}
+	if config.FirmwarePath != "" {
+		modprobe["firmwarePath"] = config.FirmwarePath
+	}
+
+	nodeSelector := map[string]any{}
+	for key, value := range config.NodeSelector {
+		nodeSelector[key] = value
+	}
+
+	module := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": ModuleGVR.GroupVersion().String(),
+		"kind":       "Module",
+		"metadata": map[string]any{
+			"name":      config.Name,
+			"namespace": config.Namespace,
+		},
+		"spec": map[string]any{
+			"moduleLoader": map[string]any{
+				"serviceAccountName": config.ServiceAccountName,
+				"container": map[string]any{
+					"modprobe":       modprobe,
+					"kernelMappings": []any{kernelMapping},
+				},
+			},
+			"selector": nodeSelector,
+		},
+	}}
+	owner.Label(module)
+
+	if _, err := apiClient.ApplyResource(ModuleGVR, module, false); err != nil {
+		return fmt.Errorf("failed to create KMM Module %s: %w", config.Name, err)
+	}
+
+	return nil
+}
+
+// DeleteModule deletes the KMM Module, KMM then unloading its kernel modules from the nodes.
+func DeleteModule(apiClient *clients.Settings, name, nsname string) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Deleting KMM Module '%s' in namespace '%s'", name, nsname)
+
+	if err := apiClient.DeleteResource(ModuleGVR, nsname, name); err != nil {
+		return fmt.Errorf("failed to delete KMM Module %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// WaitForModuleLoaded waits until KMM reports the kernel module of the Module loaded on the node when loaded is
+// true, or unloaded when loaded is false.
+func WaitForModuleLoaded(apiClient *clients.Settings, name, nsname, nodeName string, loaded bool, pollInterval,
+	timeout time.Duration) error {
+	readyLabel := map[string]string{ModuleReadyLabel(nsname, name): ""}
+
+	if err := wait.NodeLabels(apiClient, nodeName, readyLabel, loaded, pollInterval, timeout); err != nil {
+		return fmt.Errorf("KMM Module %s loaded state on node %s is not %t: %w", name, nodeName, loaded, err)
+	}
+
+	return nil
+}
+
+// NodeDriverPods returns the phases of the GPU operator driver pods of the node, by pod name.
+func NodeDriverPods(apiClient *clients.Settings, nodeName string) (map[string]corev1.PodPhase, error) {
+	driverPods, err := apiClient.Pods(nvidiagpu.NvidiaGPUNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: driverupgrade.DriverLabel,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the driver pods of node %s: %w", nodeName, err)
+	}
+
+	phases := map[string]corev1.PodPhase{}
+	for _, driverPod := range driverPods.Items {
+		phases[driverPod.Name] = driverPod.Status.Phase
+	}
+
+	return phases, nil
+}
+
+// WaitForNodeDriverPod waits until the node runs a ready GPU operator driver pod when running is true, or no driver
+// pod when running is false.
+func WaitForNodeDriverPod(apiClient *clients.Settings, nodeName string, running bool, pollInterval,
+	timeout time.Duration) error {
+	err := k8swait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			driverPods, err := apiClient.Pods(nvidiagpu.NvidiaGPUNamespace).List(ctx, metav1.ListOptions{
+				LabelSelector: driverupgrade.DriverLabel,
+				FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+			})
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Error listing the driver pods of node '%s': %v", nodeName, err)
+
+				return false, nil
+			}
+
+			if !running {
+				return len(driverPods.Items) == 0, nil
+			}
+
+			for _, driverPod := range driverPods.Items {
+				for _, condition := range driverPod.Status.Conditions {
+					if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+						return true, nil
+					}
+				}
+			}
+
+			return false, nil
+		})
+	if err != nil {
+		return fmt.Errorf("node %s driver pod running state is not %t: %w", nodeName, running, err)
+	}
+
+	return nil
+}
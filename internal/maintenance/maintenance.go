@@ -0,0 +1,161 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nvidiagpuv1alpha1 "github.com/NVIDIA/k8s-operator-libs/api/upgrade/v1alpha1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/chaos"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodemaintenance"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// MethodAuto puts the nodes into maintenance with the Node Maintenance operator when it is installed, and drains
+	// them otherwise.
+	MethodAuto = "auto"
+	// MethodNodeMaintenance puts the nodes into maintenance with a NodeMaintenance of the Node Maintenance operator.
+	MethodNodeMaintenance = "nodemaintenance"
+	// MethodDrain cordons and drains the nodes like oc adm drain, with the drain spec of the ClusterPolicy.
+	MethodDrain = "drain"
+	// Reason is the reason of the NodeMaintenances of the suite.
+	Reason = "nvidia-ci GPU node maintenance test"
+
+	gpuResource corev1.ResourceName = "nvidia.com/gpu"
+)
+
+// Method returns the maintenance method of the configured method, MethodAuto being resolved to
+// MethodNodeMaintenance when the API server serves the NodeMaintenance API and to MethodDrain otherwise.
+func Method(apiClient *clients.Settings, configured string) (string, error) {
+	if configured != MethodAuto {
+		return configured, nil
+	}
+
+	served, err := nodemaintenance.IsServed(apiClient)
+	if err != nil {
+		return "", err
+	}
+
+	if served {
+		return MethodNodeMaintenance, nil
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Info("The Node Maintenance operator is not installed, draining the nodes instead")
+
+	return MethodDrain, nil
+}
+
+// Start puts the node into maintenance with the method and waits until it is cordoned and drained: with the
+// NodeMaintenance named name, reaching PhaseSucceeded, or with the drain spec, e.g. of the ClusterPolicy. The
+// daemonset pods, i.e. the GPU operator operands, are kept on the node by both methods.
+func Start(apiClient *clients.Settings, method, name, nodeName string, drainSpec *nvidiagpuv1alpha1.DrainSpec,
+	pollInterval, timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Putting node '%s' into maintenance with method '%s'", nodeName, method)
+
+	if method == MethodDrain {
+		return chaos.DrainNode(apiClient, nodeName, drainSpec)
+	}
+
+	maintenanceBuilder, err := nodemaintenance.NewBuilder(apiClient, name, nodeName).WithReason(Reason).Create()
+	if err != nil {
+		return fmt.Errorf("failed to create NodeMaintenance %s of node %s: %w", name, nodeName, err)
+	}
+
+	var lastError string
+
+	err = wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			var phase string
+
+			phase, lastError, err = maintenanceBuilder.Phase()
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("NodeMaintenance '%s' pull error: %v", name, err)
+
+				return false, nil
+			}
+
+			return phase == nodemaintenance.PhaseSucceeded, nil
+		})
+	if err != nil {
+		return fmt.Errorf("node %s was not drained by NodeMaintenance %s, last error '%s': %w", nodeName, name,
+			lastError, err)
+	}
+
+	return nil
+}
+
+// End takes the node out of maintenance with the method, deleting the NodeMaintenance named name or uncordoning the
+// node, and waits until the node is schedulable.
+func End(apiClient *clients.Settings, method, name, nodeName string, pollInterval, timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Taking node '%s' out of maintenance with method '%s'", nodeName, method)
+
+	if method == MethodDrain {
+		if err := chaos.UncordonNode(apiClient, nodeName); err != nil {
+			return err
+		}
+	} else if err := nodemaintenance.NewBuilder(apiClient, name, nodeName).Delete(); err != nil {
+		return err
+	}
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' pull from cluster error: %v", nodeName, err)
+
+				return false, nil
+			}
+
+			return !nodeBuilder.Object.Spec.Unschedulable, nil
+		})
+	if err != nil {
+		return fmt.Errorf("node %s is still unschedulable: %w", nodeName, err)
+	}
+
+	return nil
+}
+
+// NodeGPUPods returns the pods of the node requesting GPUs in all the namespaces, the completed pods being left out.
+func NodeGPUPods(apiClient *clients.Settings, nodeName string) ([]*pod.Builder, error) {
+	nodePods, err := pod.ListInAllNamespaces(apiClient, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods of node %s: %w", nodeName, err)
+	}
+
+	var gpuPods []*pod.Builder
+
+	for _, nodePod := range nodePods {
+		phase := nodePod.Object.Status.Phase
+		if phase == corev1.PodSucceeded || phase == corev1.PodFailed {
+			continue
+		}
+
+		if requestsGPUs(nodePod.Object) {
+			gpuPods = append(gpuPods, nodePod)
+		}
+	}
+
+	return gpuPods, nil
+}
+
+// requestsGPUs returns true when a container of the pod requests GPUs.
+func requestsGPUs(gpuPod *corev1.Pod) bool {
+	for _, container := range gpuPod.Spec.Containers {
+		if gpus, found := container.Resources.Limits[gpuResource]; found && !gpus.IsZero() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,161 @@
+package mig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	watchwait "github.com/rh-ecosystem-edge/nvidia-ci/pkg/wait"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// MIGCapableLabel is set by GFD on nodes whose GPUs support MIG.
+	MIGCapableLabel = "nvidia.com/mig.capable"
+	// MIGConfigLabel selects the mig-parted profile applied by the MIG manager.
+	MIGConfigLabel = "nvidia.com/mig.config"
+	// MIGConfigStateLabel reports the MIG manager reconfiguration state.
+	MIGConfigStateLabel = "nvidia.com/mig.config.state"
+	// MIGStrategyLabel reports the MIG strategy in use by GFD.
+	MIGStrategyLabel = "nvidia.com/mig.strategy"
+	// GPUProductLabel reports the GPU product name discovered by GFD.
+	GPUProductLabel = "nvidia.com/gpu.product"
+	// DevicePluginDeployLabel schedules the device plugin on the node, the MIG manager pausing it during a
+	// reconfiguration.
+	DevicePluginDeployLabel = "nvidia.com/gpu.deploy.device-plugin"
+
+	// MIGConfigStateSuccess is the value of MIGConfigStateLabel once mig-parted applied the profile.
+	MIGConfigStateSuccess = "success"
+	// MIGConfigStatePending is the value of MIGConfigStateLabel while the MIG manager reconfigures the node.
+	MIGConfigStatePending = "pending"
+	// MIGConfigStateFailed is the value of MIGConfigStateLabel when mig-parted failed to apply the profile.
+	MIGConfigStateFailed = "failed"
+	// GPUClientsPausedForMIGChange is the value the MIG manager sets the deploy labels of the GPU operands to while
+	// it reconfigures the node, so that they release the GPUs.
+	GPUClientsPausedForMIGChange = "paused-for-mig-change"
+	// MIGConfigAllDisabled is the mig-parted profile that disables MIG on all GPUs.
+	MIGConfigAllDisabled = "all-disabled"
+
+	// MIGResourcePrefix is the prefix of the extended resources advertised in the mixed strategy.
+	MIGResourcePrefix = "nvidia.com/mig-"
+	// GPUResourceName is the extended resource advertised in the single strategy.
+	GPUResourceName corev1.ResourceName = "nvidia.com/gpu"
+)
+
+// evictionGracePeriod is the grace period of the workloads evicted by EvictGPUWorkloads, short for the workloads to
+// release their MIG slices before the MIG manager applies the new geometry.
+const evictionGracePeriod = 1
+
+var isTrue = true
+
+// Geometry describes a mig-parted profile and the MIG slices it is expected to expose per GPU.
+type Geometry struct {
+	// Config is the value set on the nvidia.com/mig.config node label.
+	Config string
+	// Profiles maps a MIG profile name (e.g. "1g.5gb") to the number of instances per GPU.
+	Profiles map[string]int
+}
+
+// ResourceName returns the mixed strategy extended resource name for a MIG profile.
+func ResourceName(profile string) corev1.ResourceName {
+	return corev1.ResourceName(MIGResourcePrefix + profile)
+}
+
+// SetClusterPolicyStrategy enables the MIG manager and sets the MIG strategy on the ClusterPolicy.
+// It returns the previous strategy so that the caller can restore it.
+func SetClusterPolicyStrategy(apiClient *clients.Settings, clusterPolicyName string,
+	strategy nvidiagpuv1.MIGStrategy) (nvidiagpuv1.MIGStrategy, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Setting MIG strategy '%s' on ClusterPolicy '%s'", strategy,
+		clusterPolicyName)
+
+	var previousStrategy nvidiagpuv1.MIGStrategy
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousStrategy = definition.Spec.MIG.Strategy
+
+		definition.Spec.MIG.Strategy = strategy
+		definition.Spec.MIGManager.Enabled = &isTrue
+	})
+	if err != nil {
+		return previousStrategy, fmt.Errorf("failed to update ClusterPolicy %s MIG strategy: %w",
+			clusterPolicyName, err)
+	}
+
+	return previousStrategy, nil
+}
+
+// ListMIGCapableNodes returns the worker nodes that GFD labeled as MIG capable.
+func ListMIGCapableNodes(apiClient *clients.Settings, workerLabelMap map[string]string) ([]*nodes.Builder, error) {
+	selector := []string{fmt.Sprintf("%s=true", MIGCapableLabel)}
+	for key, value := range workerLabelMap {
+		if value == "" {
+			selector = append(selector, key)
+		} else {
+			selector = append(selector, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+
+	return nodes.List(apiClient, metav1.ListOptions{LabelSelector: strings.Join(selector, ",")})
+}
+
+// LabelNodeMIGConfig sets the nvidia.com/mig.config label on a node, replacing any existing value.
+func LabelNodeMIGConfig(apiClient *clients.Settings, nodeName, config string) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Labeling node '%s' with %s=%s", nodeName, MIGConfigLabel, config)
+
+	return nodes.SetLabel(apiClient, nodeName, MIGConfigLabel, config)
+}
+
+// WaitForMIGConfigState waits until the MIG manager reports the expected state on the node.
+// A "failed" state ends the wait early with an error. The node is watched, so that short-lived states are not missed.
+func WaitForMIGConfigState(apiClient *clients.Settings, nodeName, state string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+	defer cancel()
+
+	return watchwait.UntilObject(ctx, apiClient, corev1.SchemeGroupVersion.WithResource("nodes"), "", nodeName,
+		func(object *unstructured.Unstructured) (bool, error) {
+			if object == nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' not found", nodeName)
+
+				return false, nil
+			}
+
+			currentState := object.GetLabels()[MIGConfigStateLabel]
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' MIG config state is '%s'", nodeName, currentState)
+
+			if currentState == MIGConfigStateFailed && state != MIGConfigStateFailed {
+				return false, fmt.Errorf("MIG manager failed to apply config '%s' on node %s",
+					object.GetLabels()[MIGConfigLabel], nodeName)
+			}
+
+			return currentState == state, nil
+		})
+}
+
+// EvictGPUWorkloads evicts the pods of the node matching the label selector, e.g. the workloads holding MIG slices
+// that the MIG manager cannot reconfigure, with a grace period of a second, and waits until they are deleted.
+func EvictGPUWorkloads(apiClient *clients.Settings, nodeName, podSelector string, timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Evicting the pods '%s' of node '%s'", podSelector, nodeName)
+
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		return err
+	}
+
+	nodeBuilder.SetDrainHelper(false, true, true, evictionGracePeriod, 0, timeout)
+	nodeBuilder.SetDrainPodSelector(podSelector)
+
+	if err := nodeBuilder.Drain(); err != nil {
+		return fmt.Errorf("failed to evict pods '%s' of node %s: %w", podSelector, nodeName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,289 @@
+package mpioperator
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// OperatorNamespace is the namespace where the upstream manifest installs the MPI operator.
+	OperatorNamespace = "mpi-operator"
+	// OperatorDeploymentName is the name of the MPI operator deployment.
+	OperatorDeploymentName = "mpi-operator"
+	// ManifestURLFormat is the URL of the MPI operator v2beta1 manifest, formatted with the operator version.
+	ManifestURLFormat = "https://raw.githubusercontent.com/kubeflow/mpi-operator/%s/deploy/v2beta1/mpi-operator.yaml"
+	// LauncherContainerName is the container running mpirun in the launcher pod of the MPIJobs.
+	LauncherContainerName = "mpi-launcher-ctr"
+	// WorkerContainerName is the container running sshd in the worker pods of the MPIJobs.
+	WorkerContainerName = "mpi-worker-ctr"
+	// AppLabel is the label key selecting the worker pods of an MPIJob.
+	AppLabel = "nvidia-ci/mpijob"
+
+	jobNameLabel = "training.kubeflow.org/job-name"
+	jobRoleLabel = "training.kubeflow.org/job-role"
+	gpuResource  = "nvidia.com/gpu"
+	pollInterval = 10 * time.Second
+)
+
+// MPIJobGVR is the resource of the MPI operator MPIJobs.
+var MPIJobGVR = schema.GroupVersionResource{Group: "kubeflow.org", Version: "v2beta1", Resource: "mpijobs"}
+
+// NCCLJobConfig describes an MPIJob running the nccl-tests all_reduce_perf benchmark, one worker per node.
+type NCCLJobConfig struct {
+	Name               string
+	Namespace          string
+	Image              string
+	Workers            int
+	GPUsPerWorker      int
+	NodeSelector       map[string]string
+	ServiceAccountName string
+	TestArgs           []string
+}
+
+// ManifestURL returns the URL of the MPI operator manifest of the version.
+func ManifestURL(version string) string {
+	return fmt.Sprintf(ManifestURLFormat, version)
+}
+
+// OperatorInstalled checks whether the MPI operator deployment exists.
+func OperatorInstalled(apiClient *clients.Settings) bool {
+	_, err := deployment.Pull(apiClient, OperatorDeploymentName, OperatorNamespace)
+
+	return err == nil
+}
+
+// InstallOperator applies the upstream manifest of the MPI operator version with oc, then waits for the operator
+// deployment to be ready.
+func InstallOperator(apiClient *clients.Settings, version string, timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Installing MPI operator version '%s' in namespace '%s'", version,
+		OperatorNamespace)
+
+	cmd := exec.Command("oc", "apply", "--server-side", "-f", ManifestURL(version))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to install the MPI operator: %w: %s", err, output)
+	}
+
+	operatorBuilder, err := deployment.Pull(apiClient, OperatorDeploymentName, OperatorNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to get the MPI operator deployment: %w", err)
+	}
+
+	if !operatorBuilder.IsReady(timeout) {
+		return fmt.Errorf("MPI operator deployment %s is not ready after %s", OperatorDeploymentName, timeout)
+	}
+
+	return nil
+}
+
+// UninstallOperator deletes the objects of the upstream manifest of the MPI operator version with oc.
+func UninstallOperator(version string, timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Uninstalling MPI operator version '%s'", version)
+
+	cmd := exec.Command("oc", "delete", "--ignore-not-found", "--wait", "--timeout", timeout.String(),
+		"-f", ManifestURL(version))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to uninstall the MPI operator: %w: %s", err, output)
+	}
+
+	return nil
+}
+
+// CreateNCCLJob creates an MPIJob whose launcher runs one all_reduce_perf rank per GPU of every worker with mpirun.
+// The workers run sshd as root in the pod network, one per node, so the service account must be allowed to run
+// privileged pods. InfiniBand is disabled, the ranks communicating over the cluster network.
+func CreateNCCLJob(apiClient *clients.Settings, config NCCLJobConfig) (*unstructured.Unstructured, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating MPIJob '%s' in namespace '%s' with %d worker(s) of %d GPU(s)",
+		config.Name, config.Namespace, config.Workers, config.GPUsPerWorker)
+
+	if config.Workers < 1 || config.GPUsPerWorker < 1 {
+		return nil, fmt.Errorf("MPIJob %s needs at least 1 worker and 1 GPU per worker", config.Name)
+	}
+
+	isTrue := true
+	workerLabels := map[string]string{AppLabel: config.Name}
+
+	launcher := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			ServiceAccountName: config.ServiceAccountName,
+			RestartPolicy:      corev1.RestartPolicyOnFailure,
+			Containers: []corev1.Container{{
+				Name:            LauncherContainerName,
+				Image:           config.Image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command: []string{"mpirun", "--allow-run-as-root", "--bind-to", "none",
+					"-np", fmt.Sprint(config.Workers * config.GPUsPerWorker),
+					"-x", "NCCL_DEBUG=INFO", "-x", "NCCL_IB_DISABLE=1", "-x", "LD_LIBRARY_PATH", "-x", "PATH",
+					"all_reduce_perf"},
+				Args: append(append([]string{}, config.TestArgs...), "-g", "1"),
+			}},
+		},
+	}
+
+	worker := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: workerLabels},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: config.ServiceAccountName,
+			NodeSelector:       config.NodeSelector,
+			Tolerations: []corev1.Toleration{{
+				Key:      gpuResource,
+				Effect:   corev1.TaintEffectNoSchedule,
+				Operator: corev1.TolerationOpExists,
+			}},
+			Affinity: &corev1.Affinity{
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: workerLabels},
+						TopologyKey:   corev1.LabelHostname,
+					}},
+				},
+			},
+			Containers: []corev1.Container{{
+				Name:            WorkerContainerName,
+				Image:           config.Image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"/usr/sbin/sshd", "-De"},
+				SecurityContext: &corev1.SecurityContext{Privileged: &isTrue},
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						gpuResource: *resource.NewQuantity(int64(config.GPUsPerWorker), resource.DecimalSI),
+					},
+				},
+			}},
+		},
+	}
+
+	proxy.Inject(apiClient, &launcher.Spec)
+	proxy.Inject(apiClient, &worker.Spec)
+
+	launcherTemplate, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&launcher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the MPIJob %s launcher template: %w", config.Name, err)
+	}
+
+	workerTemplate, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&worker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the MPIJob %s worker template: %w", config.Name, err)
+	}
+
+	mpiJob := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": MPIJobGVR.GroupVersion().String(),
+		"kind":       "MPIJob",
+		"metadata":   map[string]any{"name": config.Name, "namespace": config.Namespace},
+		"spec": map[string]any{
+			"slotsPerWorker":    int64(config.GPUsPerWorker),
+			"mpiImplementation": "OpenMPI",
+			"runPolicy":         map[string]any{"cleanPodPolicy": "Running", "backoffLimit": int64(6)},
+			"mpiReplicaSpecs": map[string]any{
+				"Launcher": map[string]any{"replicas": int64(1), "template": launcherTemplate},
+				"Worker":   map[string]any{"replicas": int64(config.Workers), "template": workerTemplate},
+			},
+		},
+	}}
+	owner.Label(mpiJob)
+
+	return apiClient.ApplyResource(MPIJobGVR, mpiJob, false)
+}
+
+// WaitForJobSucceeded waits for the MPIJob to report its Succeeded condition, failing early on its Failed
+// condition.
+func WaitForJobSucceeded(apiClient *clients.Settings, name, nsname string, timeout time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Waiting for MPIJob '%s' in namespace '%s' to succeed", name, nsname)
+
+	var failure error
+
+	err := wait.PollUntilContextTimeout(context.TODO(), pollInterval, timeout, true,
+		func(ctx context.Context) (bool, error) {
+			mpiJob, err := apiClient.GetResource(MPIJobGVR, nsname, name)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Error getting MPIJob %s: %v", name, err)
+
+				return false, nil
+			}
+
+			conditions, _, _ := unstructured.NestedSlice(mpiJob.Object, "status", "conditions")
+			for _, item := range conditions {
+				condition, ok := item.(map[string]any)
+				if !ok || condition["status"] != string(corev1.ConditionTrue) {
+					continue
+				}
+
+				switch condition["type"] {
+				case "Succeeded":
+					return true, nil
+				case "Failed":
+					failure = fmt.Errorf("MPIJob %s failed: %v", name, condition["message"])
+
+					return false, failure
+				}
+			}
+
+			return false, nil
+		})
+	if failure != nil {
+		return failure
+	}
+
+	if err != nil {
+		return fmt.Errorf("MPIJob %s did not succeed after %s: %w", name, timeout, err)
+	}
+
+	return nil
+}
+
+// GetLauncherLog returns the log of the launcher pods of the MPIJob, the retried launchers included.
+func GetLauncherLog(apiClient *clients.Settings, name, nsname string) (string, error) {
+	launcherPods, err := pod.List(apiClient, nsname, metav1.ListOptions{
+		LabelSelector: labels.Set{jobNameLabel: name, jobRoleLabel: "launcher"}.String(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list MPIJob %s launcher pods: %w", name, err)
+	}
+
+	if len(launcherPods) == 0 {
+		return "", fmt.Errorf("no launcher pod found for MPIJob %s", name)
+	}
+
+	var logs []string
+
+	for _, launcherPod := range launcherPods {
+		log, err := launcherPod.GetFullLog(LauncherContainerName)
+		if err != nil {
+			return "", fmt.Errorf("failed to get pod %s log: %w", launcherPod.Object.Name, err)
+		}
+
+		logs = append(logs, log)
+	}
+
+	return strings.Join(logs, "\n"), nil
+}
+
+// ListWorkerPods returns the worker pods of the MPIJob.
+func ListWorkerPods(apiClient *clients.Settings, name, nsname string) ([]*pod.Builder, error) {
+	return pod.List(apiClient, nsname, metav1.ListOptions{
+		LabelSelector: labels.Set{jobNameLabel: name, jobRoleLabel: "worker"}.String(),
+	})
+}
+
+// DeleteJob deletes the MPIJob, its pods being deleted by the MPI operator.
+func DeleteJob(apiClient *clients.Settings, name, nsname string) error {
+	return apiClient.DeleteResource(MPIJobGVR, nsname, name)
+}
@@ -0,0 +1,223 @@
+package mps
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClientAllocEnv is the amount of device memory, in MiB, the MPS client pod allocates.
+	ClientAllocEnv = "MPS_CLIENT_ALLOC_MIB"
+	// ClientStartAtEnv is the Unix time the MPS client pod starts measuring its matmul throughput at, so that
+	// concurrent clients measure over the same window.
+	ClientStartAtEnv = "MPS_CLIENT_START_AT"
+	// ClientDurationEnv is how long, in seconds, the MPS client pod measures its matmul throughput.
+	ClientDurationEnv = "MPS_CLIENT_DURATION_SECONDS"
+	// ClientAppLabel is the value of the app label of the MPS client pods.
+	ClientAppLabel = "mps-client-app"
+	// ClientContainerName is the container name of the MPS client pods.
+	ClientContainerName = "mps-client-ctr"
+
+	clientConfigMapName = "mps-client-script"
+)
+
+// ClientPodConfigMapData contains the script run by the MPS client pods. The script reports the SM count and the
+// device memory the client sees, optionally tries to allocate MPS_CLIENT_ALLOC_MIB of device memory, then
+// optionally measures its matmul throughput.
+var ClientPodConfigMapData = map[string]string{
+	"mps-client.py": `import os
+import time
+
+import torch
+
+alloc_mib = int(os.environ.get("MPS_CLIENT_ALLOC_MIB", "0"))
+start_at = float(os.environ.get("MPS_CLIENT_START_AT", "0"))
+duration = float(os.environ.get("MPS_CLIENT_DURATION_SECONDS", "0"))
+
+properties = torch.cuda.get_device_properties(0)
+free, total = torch.cuda.mem_get_info()
+print(f"sm-count {properties.multi_processor_count}", flush=True)
+print(f"total-memory-mib {total // 2**20}", flush=True)
+
+if alloc_mib > 0:
+    try:
+        buffer = torch.empty(alloc_mib * 2**20, dtype=torch.uint8, device="cuda")
+        torch.cuda.synchronize()
+        print("allocation ok", flush=True)
+        del buffer
+    except torch.cuda.OutOfMemoryError as error:
+        print(f"allocation oom {error}".splitlines()[0], flush=True)
+
+if duration > 0:
+    size = 4096
+    x = torch.randn(size, size, device="cuda")
+    y = torch.randn(size, size, device="cuda")
+    torch.matmul(x, y)
+    torch.cuda.synchronize()
+
+    time.sleep(max(0, start_at - time.time()))
+    iterations = 0
+    start = time.time()
+    while time.time() - start < duration:
+        torch.matmul(x, y)
+        iterations += 1
+        if iterations % 10 == 0:
+            torch.cuda.synchronize()
+    torch.cuda.synchronize()
+    elapsed = time.time() - start
+    print(f"throughput-tflops {2 * size**3 * iterations / elapsed / 1e12:.3f}", flush=True)
+`,
+}
+
+// ClientResult is the output of an MPS client pod.
+type ClientResult struct {
+	// SMCount is the number of SMs the client sees, reduced by the MPS active thread percentage.
+	SMCount int
+	// TotalMemoryMiB is the device memory the client sees.
+	TotalMemoryMiB int64
+	// AllocationResult is "ok" or "oom" when the client tried to allocate device memory, empty otherwise.
+	AllocationResult string
+	// ThroughputTFLOPS is the matmul throughput of the client, 0 when the client did not measure it.
+	ThroughputTFLOPS float64
+}
+
+// CreateClientPodConfigMap creates a ConfigMap with the MPS client pod script.
+func CreateClientPodConfigMap(apiClient *clients.Settings, configMapNamespace string) (*configmap.Builder, error) {
+	createdConfigMapBuilder, err := configmap.NewBuilder(apiClient, clientConfigMapName, configMapNamespace).
+		WithData(ClientPodConfigMapData).Create()
+	if err != nil {
+		glog.V(gpuparams.GpuLogLevel).Infof("error creating MPS client ConfigMap %s in namespace %s: %v",
+			clientConfigMapName, configMapNamespace, err)
+
+		return nil, err
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Created MPS client ConfigMap %s in namespace %s",
+		clientConfigMapName, configMapNamespace)
+
+	return createdConfigMapBuilder, nil
+}
+
+// CreateMPSClientPod returns a Pod running the MPS client script on a GPU replica of a node matching nodeSelector,
+// with the environment variables configuring the script.
+func CreateMPSClientPod(podName, podNamespace, mpsTestImage string, nodeSelector map[string]string,
+	env []corev1.EnvVar) *corev1.Pod {
+	var volumeDefaultMode int32 = 0755
+
+	configMapVolumeSource := &corev1.ConfigMapVolumeSource{}
+	configMapVolumeSource.Name = clientConfigMapName
+	configMapVolumeSource.DefaultMode = &volumeDefaultMode
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": ClientAppLabel,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &isTrue,
+				SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Image:           mpsTestImage,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &isFalse,
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{
+								"ALL",
+							},
+						},
+					},
+					Name:    ClientContainerName,
+					Command: []string{"python3", "/bin/mps-client.py"},
+					Env:     env,
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							"nvidia.com/gpu": resource.MustParse("1"),
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "client-script",
+							MountPath: "/bin/mps-client.py",
+							ReadOnly:  true,
+							SubPath:   "mps-client.py",
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "client-script",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: configMapVolumeSource,
+					},
+				},
+			},
+			NodeSelector: nodeSelector,
+		},
+	}
+}
+
+// ParseClientOutput parses the log of an MPS client pod.
+func ParseClientOutput(output string) (*ClientResult, error) {
+	result := &ClientResult{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		var err error
+
+		switch fields[0] {
+		case "sm-count":
+			result.SMCount, err = strconv.Atoi(fields[1])
+		case "total-memory-mib":
+			result.TotalMemoryMiB, err = strconv.ParseInt(fields[1], 10, 64)
+		case "allocation":
+			result.AllocationResult = fields[1]
+		case "throughput-tflops":
+			result.ThroughputTFLOPS, err = strconv.ParseFloat(fields[1], 64)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid MPS client output line %q: %w", line, err)
+		}
+	}
+
+	if result.SMCount == 0 {
+		return nil, fmt.Errorf("no SM count found in the MPS client output")
+	}
+
+	return result, nil
+}
+
+// ClientLimits returns the default per-client pinned device memory limit, in MiB, and active thread percentage the
+// device plugin MPS control daemon sets for a GPU of gpuMemoryMiB shared between replicas clients.
+func ClientLimits(gpuMemoryMiB int64, replicas int) (int64, int) {
+	return gpuMemoryMiB / int64(replicas), 100 / replicas
+}
@@ -0,0 +1,141 @@
+package mps
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SharingStrategyLabel reports the GPU sharing strategy applied by the device plugin, discovered by GFD.
+	SharingStrategyLabel = "nvidia.com/gpu.sharing-strategy"
+	// SharingStrategyMPS is the value of SharingStrategyLabel when GPUs are shared with MPS.
+	SharingStrategyMPS = "mps"
+	// SharingStrategyTimeSlicing is the value of SharingStrategyLabel when GPUs are shared with time-slicing.
+	SharingStrategyTimeSlicing = "time-slicing"
+	// DevicePluginPodLabel selects the device plugin pods.
+	DevicePluginPodLabel = "app=nvidia-device-plugin-daemonset"
+	// DevicePluginContainerName is the name of the device plugin container.
+	DevicePluginContainerName = "nvidia-device-plugin"
+)
+
+// SharingReplicas is the number of replicas per GPU of each device plugin sharing strategy of a config, a strategy
+// is left out of the config when its replicas are 0.
+type SharingReplicas struct {
+	MPS         int
+	TimeSlicing int
+}
+
+// CreateSharingDevicePluginConfigMap creates a ConfigMap holding one device plugin config per entry of
+// replicasByConfig, keyed by the config name.
+func CreateSharingDevicePluginConfigMap(apiClient *clients.Settings, configMapName, configMapNamespace string,
+	replicasByConfig map[string]SharingReplicas) (*configmap.Builder, error) {
+	devicePluginConfig := map[string]string{}
+
+	for configName, replicas := range replicasByConfig {
+		sharing := map[string]interface{}{}
+
+		if replicas.MPS > 0 {
+			sharing["mps"] = replicatedResources(replicas.MPS)
+		}
+
+		if replicas.TimeSlicing > 0 {
+			sharing["timeSlicing"] = replicatedResources(replicas.TimeSlicing)
+		}
+
+		yamlData, err := yaml.Marshal(map[string]interface{}{
+			"version": "v1",
+			"sharing": sharing,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal device plugin config %s: %w", configName, err)
+		}
+
+		devicePluginConfig[configName] = string(yamlData)
+	}
+
+	createdConfigMap, err := configmap.NewBuilder(apiClient, configMapName, configMapNamespace).
+		WithData(devicePluginConfig).Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create device plugin ConfigMap %s in namespace %s: %w",
+			configMapName, configMapNamespace, err)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Created device plugin ConfigMap %s in namespace %s",
+		createdConfigMap.Object.Name, createdConfigMap.Object.Namespace)
+
+	return createdConfigMap, nil
+}
+
+// FindContainerLogLine returns the first line of the logs of the container of the pods matching labelSelector that
+// contains all the substrings, ignoring case. The logs of the previous container instance are searched as well, so
+// that the error of a crash looping container is found.
+func FindContainerLogLine(apiClient *clients.Settings, namespace, labelSelector, containerName string,
+	substrings ...string) (string, error) {
+	podList, err := apiClient.Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods %s in namespace %s: %w", labelSelector, namespace, err)
+	}
+
+	for _, pod := range podList.Items {
+		for _, previous := range []bool{false, true} {
+			logs, err := apiClient.Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+				Container: containerName,
+				Previous:  previous,
+			}).Do(context.TODO()).Raw()
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Error getting pod %s container %s logs (previous %t): %v",
+					pod.Name, containerName, previous, err)
+
+				continue
+			}
+
+			if line := findLine(string(logs), substrings); line != "" {
+				return line, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func replicatedResources(replicas int) map[string]interface{} {
+	return map[string]interface{}{
+		"renameByDefault": false,
+		"resources": []map[string]interface{}{
+			{
+				"name":     "nvidia.com/gpu",
+				"replicas": replicas,
+			},
+		},
+	}
+}
+
+func findLine(logs string, substrings []string) string {
+	for _, line := range strings.Split(logs, "\n") {
+		lowerLine := strings.ToLower(line)
+		found := true
+
+		for _, substring := range substrings {
+			if !strings.Contains(lowerLine, strings.ToLower(substring)) {
+				found = false
+
+				break
+			}
+		}
+
+		if found {
+			return line
+		}
+	}
+
+	return ""
+}
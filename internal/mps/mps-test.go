@@ -3,12 +3,12 @@ package mps
 import (
 	"fmt"
 
-	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
 	"github.com/golang/glog"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu/clusterpolicybuilder"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -236,35 +236,11 @@ func CreateClusterPolicyFromCSV(apiClient *clients.Settings, GPUOperatorNamespac
 		return nil, fmt.Errorf("no matching CSV found")
 	}
 
-	// Extract ALM example from CSV annotations
-	almExample, ok := csvList[0].Object.Annotations["alm-examples"]
-	if !ok {
-		return nil, fmt.Errorf("CSV does not contain alm-examples annotation")
-	}
-
-	// Create cluster policy from ALM example string
-	clusterPolicy := nvidiagpu.NewBuilderFromObjectString(apiClient, almExample)
-
-	// Set the name
-	clusterPolicy.Definition.Name = clusterPolicyName
-	// Enable device plugin
-	enabled := true
-	clusterPolicy.Definition.Spec.DevicePlugin.Enabled = &enabled
-
 	// Enable MPS in the device plugin configuration
-	if clusterPolicy.Definition.Spec.DevicePlugin.MPS == nil {
-		clusterPolicy.Definition.Spec.DevicePlugin.MPS = &nvidiagpuv1.MPSConfig{}
-	}
-
-	// Set MPS root directory
-	clusterPolicy.Definition.Spec.DevicePlugin.MPS.Root = "/run/nvidia/mps"
-
-	// Set device plugin configuration
-	if clusterPolicy.Definition.Spec.DevicePlugin.Config == nil {
-		clusterPolicy.Definition.Spec.DevicePlugin.Config = &nvidiagpuv1.DevicePluginConfig{}
-	}
-	clusterPolicy.Definition.Spec.DevicePlugin.Config.Name = "plugin-config"
-	clusterPolicy.Definition.Spec.DevicePlugin.Config.Default = "plugin-config.yaml"
+	clusterPolicy := clusterpolicybuilder.NewBuilderFromCSV(apiClient, csvList[0]).
+		WithName(clusterPolicyName).
+		WithDevicePluginConfig("plugin-config", "plugin-config.yaml").
+		WithMPSRoot("/run/nvidia/mps")
 
 	glog.V(gpuparams.GpuLogLevel).Infof("Creating ClusterPolicy %s from CSV ALM example", clusterPolicyName)
 	// Create the cluster policy
@@ -0,0 +1,54 @@
+package nccl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	ncclworkload "github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/nccl"
+)
+
+// RunAllReduce runs the nccl-tests Job and checks that the average bus bandwidth reported by all_reduce_perf reaches
+// minBusBandwidth, in GB/s. The Job is deleted once its log is collected.
+func RunAllReduce(jobBuilder *ncclworkload.Builder, timeout time.Duration, minBusBandwidth float64) error {
+	_, err := jobBuilder.Create()
+	if err != nil {
+		return fmt.Errorf("failed to create the nccl-tests Job %s: %w", jobBuilder.Definition.Name, err)
+	}
+
+	defer func() {
+		if err := jobBuilder.Delete(); err != nil {
+			glog.Errorf("Error deleting the nccl-tests Job %s: %v", jobBuilder.Definition.Name, err)
+		}
+	}()
+
+	waitErr := jobBuilder.WaitUntilComplete(timeout)
+
+	output, err := jobBuilder.GetLog()
+	if err != nil {
+		return fmt.Errorf("failed to get the nccl-tests Job %s log: %w", jobBuilder.Definition.Name, err)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("nccl-tests Job %s log:\n%s", jobBuilder.Definition.Name, output)
+
+	if waitErr != nil {
+		return fmt.Errorf("nccl-tests Job %s did not complete: %w", jobBuilder.Definition.Name, waitErr)
+	}
+
+	busBandwidth, err := ncclworkload.ParseBusBandwidth(output)
+	if err != nil {
+		return fmt.Errorf("failed to parse the all_reduce_perf output of Job %s: %w", jobBuilder.Definition.Name,
+			err)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("nccl-tests Job %s average bus bandwidth: %.2f GB/s",
+		jobBuilder.Definition.Name, busBandwidth)
+
+	if busBandwidth < minBusBandwidth {
+		return fmt.Errorf("nccl-tests Job %s average bus bandwidth %.2f GB/s is below %.2f GB/s",
+			jobBuilder.Definition.Name, busBandwidth, minBusBandwidth)
+	}
+
+	return nil
+}
@@ -2,18 +2,42 @@ package nfd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/golang/glog"
 	"github.com/kelseyhightower/envconfig"
 )
 
-// NFDConfig contains only the fallback catalog source index image for NFD.
+// NFDConfig contains the fallback catalog source index image for NFD and the channel of the NFD upgrade tests.
 type NFDConfig struct {
 	FallbackCatalogSourceIndexImage string `envconfig:"NFD_FALLBACK_CATALOGSOURCE_INDEX_IMAGE"`
+	UpgradeToChannel                string `envconfig:"NFD_SUBSCRIPTION_UPGRADE_TO_CHANNEL"`
+}
+
+// Validate returns an error describing the invalid parameters of the NFD config.
+func (cfg *NFDConfig) Validate() error {
+	var problems []string
+
+	if strings.Contains(cfg.FallbackCatalogSourceIndexImage, "://") ||
+		strings.ContainsAny(cfg.FallbackCatalogSourceIndexImage, " \t") {
+		problems = append(problems, fmt.Sprintf("NFD_FALLBACK_CATALOGSOURCE_INDEX_IMAGE %q is not an image reference",
+			cfg.FallbackCatalogSourceIndexImage))
+	}
+
+	if strings.ContainsAny(cfg.UpgradeToChannel, " \t,") {
+		problems = append(problems, fmt.Sprintf("NFD_SUBSCRIPTION_UPGRADE_TO_CHANNEL %q is not a channel name",
+			cfg.UpgradeToChannel))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid NFD_ config: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
 }
 
 // NewNFDConfig attempts to load NFDConfig from the environment.
-// Logs at V(100) and returns (*NFDConfig, nil) on success, or (nil, error) on failure.
+// Logs at V(100) and returns (*NFDConfig, nil) on success, or (nil, error) on failure, e.g. an invalid parameter.
 func NewNFDConfig() (*NFDConfig, error) {
 	glog.V(100).Info("Creating new NFDConfig")
 
@@ -22,6 +46,10 @@ func NewNFDConfig() (*NFDConfig, error) {
 		return nil, fmt.Errorf("failed to process NFD_ env vars: %w", err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	glog.V(100).Info("NFDConfig created successfully")
 	return cfg, nil
 }
@@ -0,0 +1,264 @@
+package nfdupgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// nodeFeatureDiscoveryGVR is the resource of the NodeFeatureDiscovery objects, patched through the dynamic client for
+// the fields the vendored NFD operator API does not define.
+var nodeFeatureDiscoveryGVR = schema.GroupVersionResource{
+	Group:    "nfd.openshift.io",
+	Version:  "v1",
+	Resource: "nodefeaturediscoveries",
+}
+
+// workerTolerationsPath is the NodeFeatureDiscovery field of the nfd-worker tolerations.
+var workerTolerationsPath = []string{"spec", "operand", "tolerations"}
+
+// LabelWatcher polls the labels of nodes in the background and records every time one of them is missing or changed.
+type LabelWatcher struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	mutex  sync.Mutex
+	lost   []string
+}
+
+// WatchNodeLabels starts watching the labels of the nodes until Stop is called, nodeLabels giving the labels expected
+// to be kept per node name.
+func WatchNodeLabels(apiClient *clients.Settings, nodeLabels map[string]map[string]string,
+	pollInterval time.Duration) *LabelWatcher {
+	ctx, cancel := context.WithCancel(context.TODO())
+	watcher := &LabelWatcher{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(watcher.done)
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			watcher.check(apiClient, nodeLabels)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return watcher
+}
+
+// Stop stops watching the node labels and returns the description of every label found missing or changed. It can
+// be called more than once.
+func (watcher *LabelWatcher) Stop() []string {
+	watcher.cancel()
+	<-watcher.done
+
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	return watcher.lost
+}
+
+// check records the labels of the nodes not matching the expected labels.
+func (watcher *LabelWatcher) check(apiClient *clients.Settings, nodeLabels map[string]map[string]string) {
+	for nodeName, expectedLabels := range nodeLabels {
+		nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+		if err != nil {
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' pull from cluster error: %v", nodeName, err)
+
+			continue
+		}
+
+		for key, value := range expectedLabels {
+			if nodeValue, found := nodeBuilder.Object.Labels[key]; !found || nodeValue != value {
+				lost := fmt.Sprintf("%s: node %s label %s is '%s' instead of '%s'",
+					time.Now().Format(time.RFC3339), nodeName, key, nodeValue, value)
+				glog.V(gpuparams.GpuLogLevel).Info(lost)
+
+				watcher.mutex.Lock()
+				watcher.lost = append(watcher.lost, lost)
+				watcher.mutex.Unlock()
+			}
+		}
+	}
+}
+
+// Labels returns the labels of the node among the label keys, the ones the node does not have being left out.
+func Labels(node *nodes.Builder, keys ...string) map[string]string {
+	labels := map[string]string{}
+
+	for _, key := range keys {
+		if value, found := node.Object.Labels[key]; found {
+			labels[key] = value
+		}
+	}
+
+	return labels
+}
+
+// InstalledCSVChanged waits until the subscription installs a CSV other than previousCSV and returns it.
+func InstalledCSVChanged(apiClient *clients.Settings, subscriptionName, subscriptionNamespace, previousCSV string,
+	pollInterval, timeout time.Duration) (string, error) {
+	var installedCSV string
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			subPulled, err := olm.PullSubscription(apiClient, subscriptionName, subscriptionNamespace)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Subscription '%s' pull from cluster error: %v",
+					subscriptionName, err)
+
+				return false, nil
+			}
+
+			installedCSV = subPulled.Object.Status.InstalledCSV
+			glog.V(gpuparams.GpuLogLevel).Infof("Subscription '%s' installed CSV is '%s', current CSV is '%s'",
+				subscriptionName, installedCSV, subPulled.Object.Status.CurrentCSV)
+
+			return installedCSV != "" && installedCSV != previousCSV &&
+				installedCSV == subPulled.Object.Status.CurrentCSV, nil
+		})
+	if err != nil {
+		return "", fmt.Errorf("subscription %s did not install a CSV other than %s: %w", subscriptionName,
+			previousCSV, err)
+	}
+
+	return installedCSV, nil
+}
+
+// SetWorkerTolerations sets the nfd-worker tolerations of the NodeFeatureDiscovery, removing them when tolerations
+// is nil. It returns false when the NodeFeatureDiscovery CRD of the NFD operator prunes the field, i.e. the operator
+// does not support configuring the worker tolerations.
+func SetWorkerTolerations(apiClient *clients.Settings, name, namespace string,
+	tolerations []corev1.Toleration) (bool, error) {
+	var patchTolerations interface{}
+	if tolerations != nil {
+		patchTolerations = tolerations
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"operand": map[string]interface{}{
+				"tolerations": patchTolerations,
+			},
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal the tolerations patch: %w", err)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Setting NodeFeatureDiscovery %s worker tolerations to %v", name,
+		tolerations)
+
+	patched, err := apiClient.Resource(nodeFeatureDiscoveryGVR).Namespace(namespace).Patch(context.TODO(), name,
+		types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to patch NodeFeatureDiscovery %s: %w", name, err)
+	}
+
+	if tolerations == nil {
+		return true, nil
+	}
+
+	_, found, err := unstructured.NestedSlice(patched.Object, workerTolerationsPath...)
+	if err != nil {
+		return false, fmt.Errorf("failed to read NodeFeatureDiscovery %s tolerations: %w", name, err)
+	}
+
+	return found, nil
+}
+
+// WorkerRolledOut waits until the nfd-worker daemonset tolerates all the tolerations, if any, and finishes rolling out.
+func WorkerRolledOut(apiClient *clients.Settings, tolerations []corev1.Toleration, pollInterval,
+	timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			daemonSet, err := apiClient.DaemonSets(nfd.OperatorNamespace).Get(ctx, nfd.WorkerDaemonSetName,
+				metav1.GetOptions{})
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("DaemonSet '%s' get error: %v", nfd.WorkerDaemonSetName, err)
+
+				return false, nil
+			}
+
+			for _, toleration := range tolerations {
+				if !hasToleration(daemonSet.Spec.Template.Spec.Tolerations, toleration) {
+					glog.V(gpuparams.GpuLogLevel).Infof("DaemonSet '%s' does not tolerate %s yet",
+						nfd.WorkerDaemonSetName, toleration.Key)
+
+					return false, nil
+				}
+			}
+
+			status := daemonSet.Status
+
+			return status.ObservedGeneration >= daemonSet.Generation &&
+				status.UpdatedNumberScheduled == status.DesiredNumberScheduled &&
+				status.NumberReady == status.DesiredNumberScheduled, nil
+		})
+}
+
+// AddPCIDeviceClasses adds the PCI device classes to the worker config of the NodeFeatureDiscovery definition, the
+// classes and the label fields it already lists being kept so that the existing PCI labels are left unchanged.
+func AddPCIDeviceClasses(nfdBuilder *nfd.Builder, classes ...string) error {
+	if nfdBuilder == nil || nfdBuilder.Definition == nil {
+		return fmt.Errorf("NodeFeatureDiscovery builder is not initialized")
+	}
+
+	config := nfd.NewConfig(nfdBuilder.Definition.Spec.WorkerConfig.ConfigData)
+	if config.Sources.PCI == nil {
+		return fmt.Errorf("NodeFeatureDiscovery %s worker config has no PCI source", nfdBuilder.Definition.Name)
+	}
+
+	deviceClasses := append([]string{}, config.Sources.PCI.DeviceClassWhitelist...)
+	for _, class := range classes {
+		if !slices.Contains(deviceClasses, class) {
+			deviceClasses = append(deviceClasses, class)
+		}
+	}
+
+	config.SetPciWhitelistConfig(deviceClasses, append([]string{}, config.Sources.PCI.DeviceLabelFields...))
+
+	configData, err := config.GetYamlString()
+	if err != nil {
+		return fmt.Errorf("failed to marshal NodeFeatureDiscovery %s worker config: %w", nfdBuilder.Definition.Name,
+			err)
+	}
+
+	nfdBuilder.Definition.Spec.WorkerConfig.ConfigData = configData
+
+	return nil
+}
+
+// hasToleration returns true when the tolerations include the toleration.
+func hasToleration(tolerations []corev1.Toleration, expected corev1.Toleration) bool {
+	for _, toleration := range tolerations {
+		if toleration.Key == expected.Key && toleration.Operator == expected.Operator &&
+			toleration.Value == expected.Value && toleration.Effect == expected.Effect {
+			return true
+		}
+	}
+
+	return false
+}
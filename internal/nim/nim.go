@@ -0,0 +1,247 @@
+package nim
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/dcgmexporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/prometheus"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// ServerContainerName is the container name of the NIM deployment.
+	ServerContainerName = "nim-ctr"
+	// HTTPPort is the port of the NIM OpenAI compatible API.
+	HTTPPort = 8000
+	// PullSecretName is the name of the secret pulling the NIM image from nvcr.io with the NGC API key.
+	PullSecretName = "nim-ngc-pull-secret"
+	// APIKeySecretName is the name of the secret holding the NGC API key the NIM downloads its model with.
+	APIKeySecretName = "nim-ngc-api-key"
+	// FramebufferUsedMetric is the DCGM exporter metric of the used GPU framebuffer memory, in MiB.
+	FramebufferUsedMetric = "DCGM_FI_DEV_FB_USED"
+
+	ngcRegistry  = "nvcr.io"
+	apiKeyEnv    = "NGC_API_KEY"
+	cachePath    = "/opt/nim/.cache"
+	serverApp    = "nim"
+	promptTokens = 16
+)
+
+var (
+	isFalse = false
+	isTrue  = true
+)
+
+// clientScript lists the models served by the NIM, then sends an OpenAI compatible completion request to the first
+// one, and prints the model, the completion text and the number of completion tokens as JSON.
+var clientScript = fmt.Sprintf(`import json
+import sys
+import urllib.request
+
+base = "http://localhost:%[1]d/v1"
+models = json.load(urllib.request.urlopen(base + "/models"))["data"]
+if not models:
+    sys.exit("no model served")
+model = models[0]["id"]
+request = urllib.request.Request(base + "/completions", headers={"Content-Type": "application/json"},
+                                 data=json.dumps({"model": model, "prompt": "The capital of France is",
+                                                  "max_tokens": %[2]d, "temperature": 0}).encode())
+response = json.load(urllib.request.urlopen(request, timeout=120))
+print(json.dumps({"model": model, "text": response["choices"][0]["text"],
+                  "completion_tokens": response["usage"]["completion_tokens"]}))
+`, HTTPPort, promptTokens)
+
+// CompletionResult is the output of the completion request sent by clientScript.
+type CompletionResult struct {
+	Model            string `json:"model"`
+	Text             string `json:"text"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// CreateNGCSecrets creates the secret pulling the NIM images from nvcr.io and the secret holding the NGC API key the
+// NIM downloads its model with.
+func CreateNGCSecrets(apiClient *clients.Settings, nsname, apiKey string) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating NGC secrets '%s' and '%s' in namespace '%s'", PullSecretName,
+		APIKeySecretName, nsname)
+
+	auth := base64.StdEncoding.EncodeToString([]byte("$oauthtoken:" + apiKey))
+
+	dockerConfig, err := json.Marshal(map[string]any{
+		"auths": map[string]any{
+			ngcRegistry: map[string]string{"username": "$oauthtoken", "password": apiKey, "auth": auth},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the nvcr.io docker config: %w", err)
+	}
+
+	secrets := []*corev1.Secret{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: PullSecretName, Namespace: nsname},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: dockerConfig},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: APIKeySecretName, Namespace: nsname},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{apiKeyEnv: []byte(apiKey)},
+		},
+	}
+
+	for _, secret := range secrets {
+		owner.Label(secret)
+
+		if _, err := apiClient.Secrets(nsname).Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create secret %s: %w", secret.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// CreateServerDeployment creates a single replica NIM deployment on a GPU, pulling its image and downloading its
+// model with the NGC secrets created by CreateNGCSecrets, and the service exposing its API under the same name. The
+// NIM is ready once its model is loaded.
+func CreateServerDeployment(apiClient *clients.Settings, name, nsname, image string,
+	nodeSelector map[string]string) (*deployment.Builder, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating NIM deployment '%s' in namespace '%s' with image '%s'", name,
+		nsname, image)
+
+	labels := map[string]string{"app": serverApp, "instance": name}
+
+	container := &corev1.Container{
+		Name:            ServerContainerName,
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Env: []corev1.EnvVar{
+			{
+				Name: apiKeyEnv,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: APIKeySecretName},
+					Key:                  apiKeyEnv,
+				}},
+			},
+			{Name: "NIM_CACHE_PATH", Value: cachePath},
+		},
+		Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: HTTPPort}},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/v1/health/ready", Port: intstr.FromInt32(HTTPPort)},
+			},
+			PeriodSeconds: 10,
+		},
+		SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &isFalse,
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("1"),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "nim-cache", MountPath: cachePath},
+			{Name: "dshm", MountPath: "/dev/shm"},
+		},
+	}
+
+	// The model is downloaded on every start, the cache only lives as long as the pod.
+	cacheVolume := corev1.Volume{
+		Name:         "nim-cache",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}
+
+	// The inference engine exchanges the tensors between its processes through shared memory.
+	shmVolume := corev1.Volume{
+		Name: "dshm",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+		},
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsname},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports:    []corev1.ServicePort{{Name: "http", Port: HTTPPort}},
+		},
+	}
+
+	if _, err := apiClient.Services(nsname).Create(context.TODO(), service, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create service %s: %w", name, err)
+	}
+
+	serverBuilder := deployment.NewBuilder(apiClient, name, nsname, labels, container).
+		WithNodeSelector(nodeSelector).
+		WithVolume(cacheVolume).
+		WithVolume(shmVolume).
+		WithToleration(corev1.Toleration{
+			Key:      "nvidia.com/gpu",
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		}).
+		WithSecurityContext(&corev1.PodSecurityContext{
+			RunAsNonRoot:   &isTrue,
+			SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+		})
+	serverBuilder.Definition.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{
+		{Name: PullSecretName},
+	}
+	proxy.Inject(apiClient, &serverBuilder.Definition.Spec.Template.Spec)
+
+	return serverBuilder.Create()
+}
+
+// ClientCommand returns the command sending the completion request from inside the NIM container, whose image ships
+// python.
+func ClientCommand() []string {
+	return []string{"python3", "-c", clientScript}
+}
+
+// ParseCompletion parses the output of ClientCommand.
+func ParseCompletion(output string) (*CompletionResult, error) {
+	output = strings.TrimSpace(output)
+	if index := strings.LastIndex(output, "\n"); index >= 0 {
+		output = output[index+1:]
+	}
+
+	result := &CompletionResult{}
+	if err := json.Unmarshal([]byte(output), result); err != nil {
+		return nil, fmt.Errorf("invalid completion output %q: %w", output, err)
+	}
+
+	return result, nil
+}
+
+// FramebufferUsed returns the used framebuffer memory of the GPUs of the node, in MiB, by GPU index.
+func FramebufferUsed(client *prometheus.Client, nodeName string) (map[string]float64, error) {
+	query := fmt.Sprintf("%s{%s=%s}", FramebufferUsedMetric, dcgmexporter.HostnameLabel, strconv.Quote(nodeName))
+
+	samples, err := client.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", query, err)
+	}
+
+	used := map[string]float64{}
+	for gpu, sample := range dcgmexporter.SamplesByNode(samples)[nodeName] {
+		used[gpu] = sample.Value
+	}
+
+	return used, nil
+}
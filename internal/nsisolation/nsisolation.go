@@ -0,0 +1,113 @@
+package nsisolation
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+)
+
+const (
+	// IsolatedLabelKey is the label of the namespaces created by Create.
+	IsolatedLabelKey = "nvidia-ci.rh-ecosystem-edge.io/isolated"
+	// ProcessLabelKey is the label of the namespaces created by Create, its value being the ginkgo parallel
+	// process which created them.
+	ProcessLabelKey = "nvidia-ci.rh-ecosystem-edge.io/parallel-process"
+	// SpecAnnotationKey is the annotation of the namespaces created by Create, its value being the text of the
+	// containers and spec they were created for.
+	SpecAnnotationKey = "nvidia-ci.rh-ecosystem-edge.io/spec"
+
+	// maxNameLength is the maximum length of a namespace name.
+	maxNameLength = 63
+	suffixLength  = 5
+	// suffixAlphabet holds the characters of the random name suffixes, valid in namespace names.
+	suffixAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+)
+
+var (
+	mutex sync.Mutex
+	// active holds the names of the namespaces created by Create and not yet deleted.
+	active = map[string]bool{}
+)
+
+// Name returns a namespace name starting with the prefix, unique to the ginkgo parallel process and random so that
+// the namespaces of the specs running in parallel, or left behind by a previous run, do not collide.
+func Name(prefix string) string {
+	suffix := make([]byte, suffixLength)
+	for index := range suffix {
+		suffix[index] = suffixAlphabet[rand.IntN(len(suffixAlphabet))]
+	}
+
+	name := fmt.Sprintf("-p%d-%s", ginkgo.GinkgoParallelProcess(), suffix)
+	if len(prefix)+len(name) > maxNameLength {
+		prefix = strings.TrimRight(prefix[:maxNameLength-len(name)], "-")
+	}
+
+	return prefix + name
+}
+
+// Create creates a namespace of its own for the current spec with the given labels, e.g. params.PrivilegedNSLabels,
+// and registers its deletion with ginkgo.DeferCleanup. Called from a BeforeAll node, the namespace is shared by the
+// specs of the Ordered container and deleted after its AfterAll nodes; called from a BeforeEach node or a spec, it
+// is deleted after the spec. It must be called from a setup node or a spec.
+func Create(apiClient *clients.Settings, prefix string, nsLabels map[string]string) (*namespace.Builder, error) {
+	name := Name(prefix)
+	specText := strings.Join(append(ginkgo.CurrentSpecReport().ContainerHierarchyTexts,
+		ginkgo.CurrentSpecReport().LeafNodeText), " ")
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating isolated namespace %s for '%s'", name, specText)
+
+	nsBuilder := namespace.NewBuilder(apiClient, name).
+		WithLabel(IsolatedLabelKey, "true").
+		WithLabel(ProcessLabelKey, strconv.Itoa(ginkgo.GinkgoParallelProcess())).
+		WithMultipleLabels(nsLabels)
+
+	nsBuilder.Definition.Annotations = map[string]string{SpecAnnotationKey: specText}
+
+	createdNs, err := nsBuilder.Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create isolated namespace %s: %w", name, err)
+	}
+
+	mutex.Lock()
+	active[name] = true
+	mutex.Unlock()
+
+	ginkgo.DeferCleanup(func() {
+		glog.V(gpuparams.GpuLogLevel).Infof("Deleting isolated namespace %s", name)
+
+		if err := createdNs.Delete(); err != nil {
+			glog.Errorf("Error deleting isolated namespace %s: %v", name, err)
+		}
+
+		mutex.Lock()
+		delete(active, name)
+		mutex.Unlock()
+	})
+
+	return createdNs, nil
+}
+
+// Active returns the sorted names of the namespaces created by Create in the process and not yet deleted, e.g. for
+// the reporter to dump them when a spec fails.
+func Active() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	names := make([]string, 0, len(active))
+	for name := range active {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -1,21 +1,91 @@
 package nvidiagpuconfig
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/golang/glog"
-	"github.com/kelseyhightower/envconfig"
 )
 
 // NvidiaGPUConfig contains environment information related to nvidiagpu tests.
 type NvidiaGPUConfig struct {
-	InstanceType                       string `envconfig:"NVIDIAGPU_GPU_MACHINESET_INSTANCE_TYPE"`
-	CatalogSource                      string `envconfig:"NVIDIAGPU_CATALOGSOURCE"`
-	SubscriptionChannel                string `envconfig:"NVIDIAGPU_SUBSCRIPTION_CHANNEL"`
-	CleanupAfterTest                   bool   `envconfig:"NVIDIAGPU_CLEANUP" default:"true"`
-	DeployFromBundle                   bool   `envconfig:"NVIDIAGPU_DEPLOY_FROM_BUNDLE" default:"false"`
-	BundleImage                        string `envconfig:"NVIDIAGPU_BUNDLE_IMAGE"`
-	OperatorUpgradeToChannel           string `envconfig:"NVIDIAGPU_SUBSCRIPTION_UPGRADE_TO_CHANNEL"`
-	GPUFallbackCatalogsourceIndexImage string `envconfig:"NVIDIAGPU_GPU_FALLBACK_CATALOGSOURCE_INDEX_IMAGE"`
-	ClusterPolicyPatch                 string `envconfig:"NVIDIAGPU_GPU_CLUSTER_POLICY_PATCH"`
+	InstanceType                       string        `envconfig:"NVIDIAGPU_GPU_MACHINESET_INSTANCE_TYPE"`
+	CatalogSource                      string        `envconfig:"NVIDIAGPU_CATALOGSOURCE"`
+	SubscriptionChannel                string        `envconfig:"NVIDIAGPU_SUBSCRIPTION_CHANNEL"`
+	CleanupAfterTest                   bool          `envconfig:"NVIDIAGPU_CLEANUP" default:"true"`
+	DeployFromBundle                   bool          `envconfig:"NVIDIAGPU_DEPLOY_FROM_BUNDLE" default:"false"`
+	BundleImage                        string        `envconfig:"NVIDIAGPU_BUNDLE_IMAGE"`
+	BundleCatalogSource                bool          `envconfig:"NVIDIAGPU_BUNDLE_CATALOGSOURCE" default:"false"`
+	BundleRegistryImage                string        `envconfig:"NVIDIAGPU_BUNDLE_REGISTRY_IMAGE"`
+	OperatorUpgradeToChannel           string        `envconfig:"NVIDIAGPU_SUBSCRIPTION_UPGRADE_TO_CHANNEL"`
+	SubscriptionStartingCSV            string        `envconfig:"NVIDIAGPU_SUBSCRIPTION_STARTING_CSV"`
+	GPUFallbackCatalogsourceIndexImage string        `envconfig:"NVIDIAGPU_GPU_FALLBACK_CATALOGSOURCE_INDEX_IMAGE"`
+	ClusterPolicyPatch                 string        `envconfig:"NVIDIAGPU_GPU_CLUSTER_POLICY_PATCH"`
+	VGPUManagerRepository              string        `envconfig:"NVIDIAGPU_VGPU_MANAGER_REPOSITORY"`
+	VGPUManagerVersion                 string        `envconfig:"NVIDIAGPU_VGPU_MANAGER_VERSION"`
+	VGPUConfig                         string        `envconfig:"NVIDIAGPU_VGPU_CONFIG"`
+	VGPUMdevType                       string        `envconfig:"NVIDIAGPU_VGPU_MDEV_TYPE"`
+	VGPUVMImage                        string        `envconfig:"NVIDIAGPU_VGPU_VM_IMAGE"`
+	DRADriverChartVersion              string        `envconfig:"NVIDIAGPU_DRA_DRIVER_CHART_VERSION"`
+	OCPUpgradeVersion                  string        `envconfig:"NVIDIAGPU_OCP_UPGRADE_VERSION"`
+	OCPUpgradeImage                    string        `envconfig:"NVIDIAGPU_OCP_UPGRADE_IMAGE"`
+	UsePrecompiled                     bool          `envconfig:"NVIDIAGPU_USE_PRECOMPILED" default:"false"`
+	KataRuntimeClass                   string        `envconfig:"NVIDIAGPU_KATA_RUNTIME_CLASS" default:"kata-qemu-nvidia-gpu"`
+	KataCCRuntimeClass                 string        `envconfig:"NVIDIAGPU_KATA_CC_RUNTIME_CLASS"`
+	OSCSubscriptionChannel             string        `envconfig:"NVIDIAGPU_OSC_SUBSCRIPTION_CHANNEL"`
+	DriverRollbackVersion              string        `envconfig:"NVIDIAGPU_DRIVER_ROLLBACK_VERSION"`
+	DriverUpgradeVersion               string        `envconfig:"NVIDIAGPU_DRIVER_UPGRADE_VERSION"`
+	NCCLTestsImage                     string        `envconfig:"NVIDIAGPU_NCCL_TESTS_IMAGE"`
+	NCCLMinBusBandwidth                float64       `envconfig:"NVIDIAGPU_NCCL_MIN_BUS_BANDWIDTH" default:"1"`
+	NCCLIBHCA                          string        `envconfig:"NVIDIAGPU_NCCL_IB_HCA"`
+	NCCLRDMAResource                   string        `envconfig:"NVIDIAGPU_NCCL_RDMA_RESOURCE"`
+	MPIOperatorVersion                 string        `envconfig:"NVIDIAGPU_MPI_OPERATOR_VERSION" default:"v0.6.0"`
+	SELinuxDeviceType                  string        `envconfig:"NVIDIAGPU_SELINUX_DEVICE_TYPE" default:"container_file_t"`
+	SELinuxCustomType                  string        `envconfig:"NVIDIAGPU_SELINUX_CUSTOM_TYPE" default:"spc_t"`
+	CgroupMode                         string        `envconfig:"NVIDIAGPU_CGROUP_MODE" default:"v2"`
+	HostDriverVersion                  string        `envconfig:"NVIDIAGPU_HOST_DRIVER_VERSION"`
+	KMMModuleImage                     string        `envconfig:"NVIDIAGPU_KMM_MODULE_IMAGE"`
+	KMMDockerfile                      string        `envconfig:"NVIDIAGPU_KMM_DOCKERFILE"`
+	KMMFirmwarePath                    string        `envconfig:"NVIDIAGPU_KMM_FIRMWARE_PATH"`
+	KMMSubscriptionChannel             string        `envconfig:"NVIDIAGPU_KMM_SUBSCRIPTION_CHANNEL"`
+	NVLinkMinBusBandwidth              float64       `envconfig:"NVIDIAGPU_NVLINK_MIN_BUS_BANDWIDTH" default:"100"`
+	StressDuration                     time.Duration `envconfig:"NVIDIAGPU_STRESS_DURATION" default:"10m"`
+	StressMaxTemperature               int           `envconfig:"NVIDIAGPU_STRESS_MAX_TEMPERATURE" default:"90"`
+	ScaleFactor                        int           `envconfig:"NVIDIAGPU_SCALE_FACTOR" default:"3"`
+	ScalePodDuration                   time.Duration `envconfig:"NVIDIAGPU_SCALE_POD_DURATION" default:"1m"`
+	SoakDuration                       time.Duration `envconfig:"NVIDIAGPU_SOAK_DURATION" default:"24h"`
+	SoakCycleDuration                  time.Duration `envconfig:"NVIDIAGPU_SOAK_CYCLE_DURATION" default:"30m"`
+	SoakSnapshotInterval               time.Duration `envconfig:"NVIDIAGPU_SOAK_SNAPSHOT_INTERVAL" default:"1h"`
+	TritonImage                        string        `envconfig:"NVIDIAGPU_TRITON_IMAGE" default:"nvcr.io/nvidia/tritonserver:24.08-py3"`
+	TritonSDKImage                     string        `envconfig:"NVIDIAGPU_TRITON_SDK_IMAGE" default:"nvcr.io/nvidia/tritonserver:24.08-py3-sdk"`
+	PyTorchImage                       string        `envconfig:"NVIDIAGPU_PYTORCH_IMAGE" default:"nvcr.io/nvidia/pytorch:24.08-py3"`
+	PyTorchMinScalingEfficiency        float64       `envconfig:"NVIDIAGPU_PYTORCH_MIN_SCALING_EFFICIENCY" default:"0.5"`
+	NIMImage                           string        `envconfig:"NVIDIAGPU_NIM_IMAGE" default:"nvcr.io/nim/meta/llama-3.2-1b-instruct:latest"`
+	NIMNGCAPIKey                       string        `envconfig:"NVIDIAGPU_NIM_NGC_API_KEY" secret:"true"`
+	NIMMinFramebufferUsed              float64       `envconfig:"NVIDIAGPU_NIM_MIN_FB_USED_MIB" default:"1024"`
+	GDSImage                           string        `envconfig:"NVIDIAGPU_GDS_IMAGE"`
+	GDSNVMePath                        string        `envconfig:"NVIDIAGPU_GDS_NVME_PATH"`
+	GDRCopyImage                       string        `envconfig:"NVIDIAGPU_GDRCOPY_IMAGE"`
+	SRIOVPFNames                       []string      `envconfig:"NVIDIAGPU_SRIOV_PF_NAMES"`
+	SRIOVNumVFs                        int           `envconfig:"NVIDIAGPU_SRIOV_NUM_VFS" default:"4"`
+	ConsolePluginChartVersion          string        `envconfig:"NVIDIAGPU_CONSOLE_PLUGIN_CHART_VERSION"`
+	VGPUGuestDriverRepository          string        `envconfig:"NVIDIAGPU_VGPU_GUEST_DRIVER_REPOSITORY"`
+	VGPUGuestDriverImage               string        `envconfig:"NVIDIAGPU_VGPU_GUEST_DRIVER_IMAGE" default:"driver"`
+	VGPUGuestDriverVersion             string        `envconfig:"NVIDIAGPU_VGPU_GUEST_DRIVER_VERSION"`
+	VGPUGuestDriverPullSecret          string        `envconfig:"NVIDIAGPU_VGPU_GUEST_DRIVER_PULL_SECRET"`
+	VGPULicenseTokenFile               string        `envconfig:"NVIDIAGPU_VGPU_LICENSE_TOKEN_FILE"`
+	VGPULicenseFeatureType             string        `envconfig:"NVIDIAGPU_VGPU_LICENSE_FEATURE_TYPE" default:"1"`
+	VGPULicenseTimeout                 time.Duration `envconfig:"NVIDIAGPU_VGPU_LICENSE_TIMEOUT" default:"10m"`
+	GPUECCMode                         string        `envconfig:"NVIDIAGPU_GPU_ECC_MODE"`
+	GPUSetPowerLimit                   bool          `envconfig:"NVIDIAGPU_GPU_SET_POWER_LIMIT" default:"false"`
+	XIDCUDAImage                       string        `envconfig:"NVIDIAGPU_XID_CUDA_IMAGE" default:"nvcr.io/nvidia/cuda:12.5.0-devel-ubi8"`
+	XIDBurnDuration                    time.Duration `envconfig:"NVIDIAGPU_XID_BURN_DURATION" default:"5m"`
+	MaintenanceMethod                  string        `envconfig:"NVIDIAGPU_MAINTENANCE_METHOD" default:"auto"`
 }
 
 // NewNvidiaGPUConfig returns an instance of NvidiaGPUConfig.
@@ -24,8 +94,8 @@ func NewNvidiaGPUConfig() *NvidiaGPUConfig {
 	log := glog.V(100)
 	log.Info("Creating new NvidiaGPUConfig")
 
-	cfg := &NvidiaGPUConfig{}
-	if err := envconfig.Process("nvidiagpu_", cfg); err != nil {
+	cfg, err := LoadNvidiaGPUConfig()
+	if err != nil {
 		glog.V(100).Infof("Failed to instantiate NvidiaGPUConfig: %v", err)
 		return nil
 	}
@@ -33,3 +103,249 @@ func NewNvidiaGPUConfig() *NvidiaGPUConfig {
 	log.Info("NvidiaGPUConfig created successfully")
 	return cfg
 }
+
+// LoadNvidiaGPUConfig loads NvidiaGPUConfig from the environment and validates it, returning an error listing every
+// environment variable that cannot be parsed and every invalid or inconsistent parameter.
+func LoadNvidiaGPUConfig() (*NvidiaGPUConfig, error) {
+	var problems []string
+
+	cfg := &NvidiaGPUConfig{}
+	configValue := reflect.ValueOf(cfg).Elem()
+
+	// Every field is parsed on its own, so that all the unparsable variables are listed, their defaults then applying
+	// to validate the other parameters.
+	for index := 0; index < configValue.NumField(); index++ {
+		field := configValue.Type().Field(index)
+
+		envVar := field.Tag.Get("envconfig")
+		if envVar == "" {
+			continue
+		}
+
+		defaultValue := field.Tag.Get("default")
+
+		value, set := os.LookupEnv(envVar)
+		if !set {
+			if defaultValue == "" {
+				continue
+			}
+
+			value = defaultValue
+		}
+
+		if err := parseEnvValue(value, configValue.Field(index)); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q is not a valid %s", envVar, value, field.Type))
+
+			if set && defaultValue != "" {
+				if err := parseEnvValue(defaultValue, configValue.Field(index)); err != nil {
+					return nil, fmt.Errorf("invalid %s default %q: %w", envVar, defaultValue, err)
+				}
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid NVIDIAGPU_ config: %s", strings.Join(problems, "; "))
+	}
+
+	return cfg, nil
+}
+
+// parseEnvValue sets the field to the value of its environment variable, parsed the way envconfig parses it.
+func parseEnvValue(value string, field reflect.Value) error {
+	switch {
+	case field.Type() == reflect.TypeOf(time.Duration(0)):
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(duration))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(parsed)
+	case field.Kind() == reflect.Int:
+		parsed, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(parsed)
+	case field.Kind() == reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(parsed)
+	case field.Type() == reflect.TypeOf([]string{}):
+		var values []string
+		if strings.TrimSpace(value) != "" {
+			values = strings.Split(value, ",")
+		}
+
+		field.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// Validate returns an error describing the invalid or inconsistent parameters of the GPU config.
+func (cfg *NvidiaGPUConfig) Validate() error {
+	var problems []string
+
+	positiveIntegers := []struct {
+		envVar string
+		value  int
+	}{
+		{"NVIDIAGPU_STRESS_MAX_TEMPERATURE", cfg.StressMaxTemperature},
+		{"NVIDIAGPU_SCALE_FACTOR", cfg.ScaleFactor},
+		{"NVIDIAGPU_SRIOV_NUM_VFS", cfg.SRIOVNumVFs},
+	}
+
+	for _, parameter := range positiveIntegers {
+		if parameter.value < 1 {
+			problems = append(problems, fmt.Sprintf("%s %d is not positive", parameter.envVar, parameter.value))
+		}
+	}
+
+	positiveDurations := []struct {
+		envVar string
+		value  time.Duration
+	}{
+		{"NVIDIAGPU_STRESS_DURATION", cfg.StressDuration},
+		{"NVIDIAGPU_SCALE_POD_DURATION", cfg.ScalePodDuration},
+		{"NVIDIAGPU_SOAK_DURATION", cfg.SoakDuration},
+		{"NVIDIAGPU_SOAK_CYCLE_DURATION", cfg.SoakCycleDuration},
+		{"NVIDIAGPU_SOAK_SNAPSHOT_INTERVAL", cfg.SoakSnapshotInterval},
+		{"NVIDIAGPU_VGPU_LICENSE_TIMEOUT", cfg.VGPULicenseTimeout},
+		{"NVIDIAGPU_XID_BURN_DURATION", cfg.XIDBurnDuration},
+	}
+
+	for _, parameter := range positiveDurations {
+		if parameter.value <= 0 {
+			problems = append(problems, fmt.Sprintf("%s %s is not positive", parameter.envVar, parameter.value))
+		}
+	}
+
+	if cfg.SoakCycleDuration > cfg.SoakDuration {
+		problems = append(problems, fmt.Sprintf("NVIDIAGPU_SOAK_CYCLE_DURATION %s exceeds NVIDIAGPU_SOAK_DURATION %s",
+			cfg.SoakCycleDuration, cfg.SoakDuration))
+	}
+
+	nonNegativeFloats := []struct {
+		envVar string
+		value  float64
+	}{
+		{"NVIDIAGPU_NCCL_MIN_BUS_BANDWIDTH", cfg.NCCLMinBusBandwidth},
+		{"NVIDIAGPU_NVLINK_MIN_BUS_BANDWIDTH", cfg.NVLinkMinBusBandwidth},
+		{"NVIDIAGPU_NIM_MIN_FB_USED_MIB", cfg.NIMMinFramebufferUsed},
+	}
+
+	for _, parameter := range nonNegativeFloats {
+		if parameter.value < 0 {
+			problems = append(problems, fmt.Sprintf("%s %g is negative", parameter.envVar, parameter.value))
+		}
+	}
+
+	if cfg.PyTorchMinScalingEfficiency <= 0 || cfg.PyTorchMinScalingEfficiency > 1 {
+		problems = append(problems, fmt.Sprintf("NVIDIAGPU_PYTORCH_MIN_SCALING_EFFICIENCY %g is not in (0, 1]",
+			cfg.PyTorchMinScalingEfficiency))
+	}
+
+	if cfg.CgroupMode != "" && cfg.CgroupMode != "v1" && cfg.CgroupMode != "v2" {
+		problems = append(problems, fmt.Sprintf("NVIDIAGPU_CGROUP_MODE %q is neither v1 nor v2", cfg.CgroupMode))
+	}
+
+	if cfg.GPUECCMode != "" && cfg.GPUECCMode != "enabled" && cfg.GPUECCMode != "disabled" {
+		problems = append(problems, fmt.Sprintf("NVIDIAGPU_GPU_ECC_MODE %q is neither enabled nor disabled",
+			cfg.GPUECCMode))
+	}
+
+	if cfg.MaintenanceMethod != "auto" && cfg.MaintenanceMethod != "nodemaintenance" &&
+		cfg.MaintenanceMethod != "drain" {
+		problems = append(problems, fmt.Sprintf("NVIDIAGPU_MAINTENANCE_METHOD %q is neither auto, nodemaintenance nor "+
+			"drain", cfg.MaintenanceMethod))
+	}
+
+	if _, err := strconv.Atoi(cfg.VGPULicenseFeatureType); err != nil {
+		problems = append(problems, fmt.Sprintf("NVIDIAGPU_VGPU_LICENSE_FEATURE_TYPE %q is not an integer",
+			cfg.VGPULicenseFeatureType))
+	}
+
+	if (cfg.VGPUManagerRepository == "") != (cfg.VGPUManagerVersion == "") {
+		problems = append(problems, "NVIDIAGPU_VGPU_MANAGER_REPOSITORY and NVIDIAGPU_VGPU_MANAGER_VERSION must be "+
+			"set together")
+	}
+
+	if (cfg.VGPUGuestDriverRepository == "") != (cfg.VGPUGuestDriverVersion == "") {
+		problems = append(problems, "NVIDIAGPU_VGPU_GUEST_DRIVER_REPOSITORY and NVIDIAGPU_VGPU_GUEST_DRIVER_VERSION "+
+			"must be set together")
+	}
+
+	if cfg.DriverRollbackVersion != "" && cfg.DriverUpgradeVersion == "" {
+		problems = append(problems, "NVIDIAGPU_DRIVER_ROLLBACK_VERSION requires NVIDIAGPU_DRIVER_UPGRADE_VERSION")
+	}
+
+	files := []struct {
+		envVar string
+		path   string
+	}{
+		{"NVIDIAGPU_KMM_DOCKERFILE", cfg.KMMDockerfile},
+		{"NVIDIAGPU_VGPU_LICENSE_TOKEN_FILE", cfg.VGPULicenseTokenFile},
+	}
+
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+
+		if _, err := os.Stat(file.path); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q is not readable: %v", file.envVar, file.path, err))
+		}
+	}
+
+	images := []struct {
+		envVar string
+		image  string
+	}{
+		{"NVIDIAGPU_BUNDLE_IMAGE", cfg.BundleImage},
+		{"NVIDIAGPU_BUNDLE_REGISTRY_IMAGE", cfg.BundleRegistryImage},
+		{"NVIDIAGPU_GPU_FALLBACK_CATALOGSOURCE_INDEX_IMAGE", cfg.GPUFallbackCatalogsourceIndexImage},
+		{"NVIDIAGPU_OCP_UPGRADE_IMAGE", cfg.OCPUpgradeImage},
+		{"NVIDIAGPU_NCCL_TESTS_IMAGE", cfg.NCCLTestsImage},
+		{"NVIDIAGPU_KMM_MODULE_IMAGE", cfg.KMMModuleImage},
+		{"NVIDIAGPU_TRITON_IMAGE", cfg.TritonImage},
+		{"NVIDIAGPU_TRITON_SDK_IMAGE", cfg.TritonSDKImage},
+		{"NVIDIAGPU_PYTORCH_IMAGE", cfg.PyTorchImage},
+		{"NVIDIAGPU_NIM_IMAGE", cfg.NIMImage},
+		{"NVIDIAGPU_GDS_IMAGE", cfg.GDSImage},
+		{"NVIDIAGPU_GDRCOPY_IMAGE", cfg.GDRCopyImage},
+		{"NVIDIAGPU_XID_CUDA_IMAGE", cfg.XIDCUDAImage},
+	}
+
+	for _, parameter := range images {
+		if strings.Contains(parameter.image, "://") || strings.ContainsAny(parameter.image, " \t") {
+			problems = append(problems, fmt.Sprintf("%s %q is not an image reference", parameter.envVar,
+				parameter.image))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
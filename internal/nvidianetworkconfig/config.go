@@ -14,6 +14,7 @@ type NvidiaNetworkConfig struct {
 	DeployFromBundle                   bool   `envconfig:"NVIDIANETWORK_DEPLOY_FROM_BUNDLE" default:"false"`
 	BundleImage                        string `envconfig:"NVIDIANETWORK_BUNDLE_IMAGE"`
 	OfedDriverVersion                  string `envconfig:"NVIDIANETWORK_OFED_DRIVER_VERSION"`
+	OfedDriverVersions                 string `envconfig:"NVIDIANETWORK_OFED_DRIVER_VERSIONS"`
 	OfedDriverRepository               string `envconfig:"NVIDIANETWORK_OFED_REPOSITORY"`
 	RdmaWorkloadNamespace              string `envconfig:"NVIDIANETWORK_RDMA_WORKLOAD_NAMESPACE"`
 	RdmaLinkType                       string `envconfig:"NVIDIANETWORK_RDMA_LINK_TYPE"`
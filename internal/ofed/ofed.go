@@ -0,0 +1,152 @@
+package ofed
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/networkparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidianetwork"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+
+	networkoperator "github.com/Mellanox/network-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DriverPodLabel labels the MOFED/DOCA driver pods of the NVIDIA network operator.
+	DriverPodLabel = "nvidia.com/ofed-driver"
+	// DriverContainerName is the name of the MOFED/DOCA driver container of the driver pods.
+	DriverContainerName = "mofed-container"
+
+	// activePortsScript prints the state of every port of the RDMA devices of the node, e.g. "4: ACTIVE".
+	activePortsScript = "cat /sys/class/infiniband/*/ports/*/state 2>/dev/null || true"
+	activePortState   = "ACTIVE"
+
+	networkOperatorNamespace = "nvidia-network-operator"
+)
+
+// SetNicClusterPolicyOFEDVersion sets the MOFED/DOCA driver version of the NicClusterPolicy and returns the previous
+// one. The automatic driver upgrade is enabled so that the network operator replaces the driver pods of the nodes,
+// one node at a time, with the pods of the new version.
+func SetNicClusterPolicyOFEDVersion(apiClient *clients.Settings, nicClusterPolicyName,
+	version string) (string, error) {
+	nicClusterPolicyBuilder, err := nvidianetwork.PullNicClusterPolicy(apiClient, nicClusterPolicyName)
+	if err != nil {
+		return "", fmt.Errorf("failed to pull NicClusterPolicy %s: %w", nicClusterPolicyName, err)
+	}
+
+	ofedDriver := nicClusterPolicyBuilder.Definition.Spec.OFEDDriver
+	if ofedDriver == nil {
+		return "", fmt.Errorf("NicClusterPolicy %s does not deploy the OFED driver", nicClusterPolicyName)
+	}
+
+	previous := ofedDriver.Version
+
+	glog.V(networkparams.LogLevel).Infof("Setting NicClusterPolicy '%s' ofedDriver version from '%s' to '%s'",
+		nicClusterPolicyName, previous, version)
+
+	ofedDriver.Version = version
+
+	if ofedDriver.OfedUpgradePolicy == nil {
+		ofedDriver.OfedUpgradePolicy = &networkoperator.OfedUpgradePolicySpec{MaxParallelUpgrades: 1}
+	}
+
+	ofedDriver.OfedUpgradePolicy.AutoUpgrade = true
+
+	if _, err := nicClusterPolicyBuilder.Update(false); err != nil {
+		return previous, fmt.Errorf("failed to set NicClusterPolicy %s ofedDriver version to %s: %w",
+			nicClusterPolicyName, version, err)
+	}
+
+	return previous, nil
+}
+
+// DriverRolledOut returns true when every MOFED/DOCA driver pod runs a ready driver container of the version.
+func DriverRolledOut(apiClient *clients.Settings, version string) (bool, error) {
+	driverPods, err := listDriverPods(apiClient)
+	if err != nil {
+		return false, err
+	}
+
+	if len(driverPods) == 0 {
+		glog.V(networkparams.LogLevel).Infof("No OFED driver pod found in namespace '%s'",
+			networkOperatorNamespace)
+
+		return false, nil
+	}
+
+	for _, driverPod := range driverPods {
+		if !driverContainerReady(driverPod, version) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// ActiveRDMAPorts returns the number of ACTIVE RDMA device ports of every node running a MOFED/DOCA driver pod,
+// read from the driver container, which shares the host sysfs.
+func ActiveRDMAPorts(apiClient *clients.Settings) (map[string]int, error) {
+	driverPods, err := listDriverPods(apiClient)
+	if err != nil {
+		return nil, err
+	}
+
+	activePorts := map[string]int{}
+
+	for _, driverPod := range driverPods {
+		output, err := driverPod.ExecCommand([]string{"sh", "-c", activePortsScript}, DriverContainerName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the RDMA ports of pod %s: %w", driverPod.Object.Name, err)
+		}
+
+		nodeName := driverPod.Object.Spec.NodeName
+		activePorts[nodeName] = strings.Count(output.String(), activePortState)
+
+		glog.V(networkparams.LogLevel).Infof("Node '%s' has %d active RDMA ports", nodeName, activePorts[nodeName])
+	}
+
+	return activePorts, nil
+}
+
+func listDriverPods(apiClient *clients.Settings) ([]*pod.Builder, error) {
+	driverPods, err := pod.List(apiClient, networkOperatorNamespace,
+		metav1.ListOptions{LabelSelector: DriverPodLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the OFED driver pods: %w", err)
+	}
+
+	return driverPods, nil
+}
+
+// driverContainerReady returns true when the driver container of the pod is ready and its image is tagged with the
+// version, the tags of the driver images starting with the version followed by the OS and architecture.
+func driverContainerReady(driverPod *pod.Builder, version string) bool {
+	for _, container := range driverPod.Object.Spec.Containers {
+		if container.Name != DriverContainerName {
+			continue
+		}
+
+		if !strings.Contains(container.Image, ":"+version) {
+			glog.V(networkparams.LogLevel).Infof("OFED driver pod '%s' runs image '%s', not version '%s'",
+				driverPod.Object.Name, container.Image, version)
+
+			return false
+		}
+	}
+
+	for _, containerStatus := range driverPod.Object.Status.ContainerStatuses {
+		if containerStatus.Name == DriverContainerName {
+			if !containerStatus.Ready {
+				glog.V(networkparams.LogLevel).Infof("OFED driver pod '%s' container is not ready yet",
+					driverPod.Object.Name)
+			}
+
+			return containerStatus.Ready
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,277 @@
+package operatorupgrade
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// WorkloadContainerName is the container name of the pods built by CreateWorkloadPod.
+	WorkloadContainerName = "upgrade-workload-ctr"
+	// WorkloadPodLabel is the label selector of the pods built by CreateWorkloadPod.
+	WorkloadPodLabel = "app=operator-upgrade-test-app"
+)
+
+var (
+	isFalse = false
+	isTrue  = true
+)
+
+// Operand describes a GPU operator operand daemonset and where its image comes from.
+type Operand struct {
+	// DaemonSet is the name of the operand daemonset.
+	DaemonSet string
+	// ImageEnv is the GPU operator environment variable holding the operand default image.
+	ImageEnv string
+	// Repository, Image and Version are the operand image fields set in the ClusterPolicy, if any.
+	Repository string
+	Image      string
+	Version    string
+	// OSTagged is true for the driver, whose image tag is suffixed by the OS of the node, e.g. 550.90.07-rhel9.4.
+	OSTagged bool
+}
+
+// Operands returns the operands whose image is checked after an upgrade for the given ClusterPolicy spec.
+func Operands(spec *nvidiagpuv1.ClusterPolicySpec) []Operand {
+	return []Operand{
+		{
+			DaemonSet: "nvidia-container-toolkit-daemonset", ImageEnv: "CONTAINER_TOOLKIT_IMAGE",
+			Repository: spec.Toolkit.Repository, Image: spec.Toolkit.Image, Version: spec.Toolkit.Version,
+		},
+		{
+			DaemonSet: "nvidia-device-plugin-daemonset", ImageEnv: "DEVICE_PLUGIN_IMAGE",
+			Repository: spec.DevicePlugin.Repository, Image: spec.DevicePlugin.Image,
+			Version: spec.DevicePlugin.Version,
+		},
+		{
+			DaemonSet: "gpu-feature-discovery", ImageEnv: "GFD_IMAGE",
+			Repository: spec.GPUFeatureDiscovery.Repository, Image: spec.GPUFeatureDiscovery.Image,
+			Version: spec.GPUFeatureDiscovery.Version,
+		},
+		{
+			DaemonSet: "nvidia-dcgm-exporter", ImageEnv: "DCGM_EXPORTER_IMAGE",
+			Repository: spec.DCGMExporter.Repository, Image: spec.DCGMExporter.Image,
+			Version: spec.DCGMExporter.Version,
+		},
+		{
+			DaemonSet: "nvidia-operator-validator", ImageEnv: "VALIDATOR_IMAGE",
+			Repository: spec.Validator.Repository, Image: spec.Validator.Image, Version: spec.Validator.Version,
+		},
+	}
+}
+
+// ExpectedImage returns the image the GPU operator deploys for the operand, following the operator priority:
+// the image set in the ClusterPolicy first, then the image set in the operator deployment of the CSV.
+func (operand Operand) ExpectedImage(csvBuilder *olm.ClusterServiceVersionBuilder) (string, error) {
+	if operand.Repository != "" || operand.Version != "" {
+		if strings.HasPrefix(operand.Version, "sha256:") {
+			return operand.Repository + "/" + operand.Image + "@" + operand.Version, nil
+		}
+
+		return operand.Repository + "/" + operand.Image + ":" + operand.Version, nil
+	}
+
+	if operand.Image != "" {
+		return operand.Image, nil
+	}
+
+	for _, deploymentSpec := range csvBuilder.Object.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, container := range deploymentSpec.Spec.Template.Spec.Containers {
+			for _, env := range container.Env {
+				if env.Name == operand.ImageEnv {
+					return env.Value, nil
+				}
+			}
+		}
+	}
+
+	return "", fmt.Errorf("CSV %s does not set %s", csvBuilder.Object.Name, operand.ImageEnv)
+}
+
+// DaemonSetImages returns the images of all the init and regular containers of a daemonset. The boolean is false
+// when the daemonset does not exist, e.g. for an operand disabled in the ClusterPolicy.
+func DaemonSetImages(apiClient *clients.Settings, name, nsname string) ([]string, bool, error) {
+	daemonSet, err := apiClient.DaemonSets(nsname).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+
+		return nil, false, fmt.Errorf("failed to get daemonset %s in namespace %s: %w", name, nsname, err)
+	}
+
+	var images []string
+
+	for _, container := range daemonSet.Spec.Template.Spec.InitContainers {
+		images = append(images, container.Image)
+	}
+
+	for _, container := range daemonSet.Spec.Template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+
+	return images, true, nil
+}
+
+// ApproveInstallPlan waits for the installplan of the Subscription to require approval, approves it and returns
+// the name of the CSV it installs.
+func ApproveInstallPlan(apiClient *clients.Settings, subscriptionName, subscriptionNamespace string,
+	pollInterval, timeout time.Duration) (string, error) {
+	err := wait.InstallPlanRequiresApproval(apiClient, subscriptionName, subscriptionNamespace, pollInterval,
+		timeout)
+	if err != nil {
+		return "", fmt.Errorf("no installplan of subscription %s requires approval: %w", subscriptionName, err)
+	}
+
+	subBuilder, err := olm.PullSubscription(apiClient, subscriptionName, subscriptionNamespace)
+	if err != nil {
+		return "", err
+	}
+
+	installPlan, err := subBuilder.GetInstallPlan()
+	if err != nil {
+		return "", err
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Approving installplan '%s' installing CSV '%s'",
+		installPlan.Object.Name, subBuilder.Object.Status.CurrentCSV)
+
+	if _, err := installPlan.Approve(); err != nil {
+		return "", fmt.Errorf("failed to approve installplan %s: %w", installPlan.Object.Name, err)
+	}
+
+	return subBuilder.Object.Status.CurrentCSV, nil
+}
+
+// ApprovePendingInstallPlans waits for installplans of the namespace to require approval, approves every pending
+// installplan, whichever Subscription it belongs to, and returns the CSVs they install.
+func ApprovePendingInstallPlans(apiClient *clients.Settings, nsname string,
+	pollInterval, timeout time.Duration) ([]string, error) {
+	if err := wait.InstallPlansPending(apiClient, nsname, pollInterval, timeout); err != nil {
+		return nil, fmt.Errorf("no installplan of namespace %s requires approval: %w", nsname, err)
+	}
+
+	pendingInstallPlans, err := olm.ListPendingInstallPlans(apiClient, nsname)
+	if err != nil {
+		return nil, err
+	}
+
+	var csvNames []string
+
+	for _, installPlan := range pendingInstallPlans {
+		installPlanName := installPlan.Object.Name
+		installPlanCSVs := installPlan.Object.Spec.ClusterServiceVersionNames
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Approving installplan '%s' installing CSVs %v", installPlanName,
+			installPlanCSVs)
+
+		if _, err := installPlan.Approve(); err != nil {
+			return csvNames, fmt.Errorf("failed to approve installplan %s: %w", installPlanName, err)
+		}
+
+		csvNames = append(csvNames, installPlanCSVs...)
+	}
+
+	return csvNames, nil
+}
+
+// ApproveUpgrades approves the installplans of the Subscription one upgrade step at a time, waiting for each
+// installed CSV to succeed, until no further installplan requires approval within settleTimeout. It returns the
+// CSVs installed in upgrade order.
+func ApproveUpgrades(apiClient *clients.Settings, subscriptionName, subscriptionNamespace string, maxSteps int,
+	pollInterval, settleTimeout, csvTimeout time.Duration) ([]string, error) {
+	var installed []string
+
+	for step := 0; step < maxSteps; step++ {
+		timeout := settleTimeout
+		if step == 0 {
+			timeout = csvTimeout
+		}
+
+		csvName, err := ApproveInstallPlan(apiClient, subscriptionName, subscriptionNamespace, pollInterval, timeout)
+		if err != nil {
+			if step > 0 {
+				glog.V(gpuparams.GpuLogLevel).Infof("No further upgrade of subscription '%s' after %d steps",
+					subscriptionName, step)
+
+				return installed, nil
+			}
+
+			return nil, err
+		}
+
+		if err := wait.SubscriptionInstalledCSV(apiClient, subscriptionName, subscriptionNamespace, csvName,
+			pollInterval, csvTimeout); err != nil {
+			return installed, fmt.Errorf("subscription %s did not install CSV %s: %w", subscriptionName,
+				csvName, err)
+		}
+
+		if err := wait.CSVSucceeded(apiClient, csvName, subscriptionNamespace, pollInterval,
+			csvTimeout); err != nil {
+			return installed, fmt.Errorf("CSV %s did not succeed: %w", csvName, err)
+		}
+
+		installed = append(installed, csvName)
+	}
+
+	return installed, fmt.Errorf("subscription %s still upgrading after %d steps", subscriptionName, maxSteps)
+}
+
+// CreateWorkloadPod returns a long running pod requesting one GPU that lists the GPU every 30 seconds.
+func CreateWorkloadPod(podName, podNamespace, image string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "operator-upgrade-test-app",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &isTrue,
+				SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            WorkloadContainerName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/bin/sh", "-c", "while nvidia-smi -L; do sleep 30; done"},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &isFalse,
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+					},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							"nvidia.com/gpu": resource.MustParse("1"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,130 @@
+package operatorupgrade
+
+import (
+	"fmt"
+	"strings"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DriverDaemonSet is the name, and name prefix on OpenShift, of the driver daemonsets, whose pods have the
+// app=DriverDaemonSet label like the pods of the other operands.
+const DriverDaemonSet = "nvidia-driver-daemonset"
+
+// StaleImage is an operand pod of a node not running the image expected for its operand.
+type StaleImage struct {
+	Operand  string
+	NodeName string
+	PodName  string
+	// Images are the images of the init and regular containers of the pod.
+	Images   []string
+	Expected string
+}
+
+// DriverOperand returns the driver operand for the given ClusterPolicy spec.
+func DriverOperand(spec *nvidiagpuv1.ClusterPolicySpec) Operand {
+	return Operand{
+		DaemonSet: DriverDaemonSet, ImageEnv: "DRIVER_IMAGE",
+		Repository: spec.Driver.Repository, Image: spec.Driver.Image, Version: spec.Driver.Version,
+		OSTagged: true,
+	}
+}
+
+// Runs returns true when one of the images is the expected image of the operand, followed by an OS tag suffix for
+// the driver. Digest references are never suffixed.
+func (operand Operand) Runs(images []string, expectedImage string) bool {
+	for _, image := range images {
+		if image == expectedImage {
+			return true
+		}
+
+		if operand.OSTagged && !strings.Contains(expectedImage, "@") && strings.HasPrefix(image, expectedImage+"-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GPUOperatorCSV returns the succeeded GPU operator CSV of the GPU operator namespace.
+func GPUOperatorCSV(apiClient *clients.Settings) (*olm.ClusterServiceVersionBuilder, error) {
+	csvBuilders, err := olm.ListClusterServiceVersion(apiClient, nvidiagpu.NvidiaGPUNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the CSVs of namespace %s: %w", nvidiagpu.NvidiaGPUNamespace, err)
+	}
+
+	for _, csvBuilder := range csvBuilders {
+		if strings.HasPrefix(csvBuilder.Object.Name, nvidiagpu.Package) &&
+			csvBuilder.Object.Status.Phase == v1alpha1.CSVPhaseSucceeded {
+			return csvBuilder, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no succeeded %s CSV found in namespace %s", nvidiagpu.Package,
+		nvidiagpu.NvidiaGPUNamespace)
+}
+
+// StaleImages returns the pods of the operands, the running pods of every node included, which do not run the image
+// the ClusterPolicy, or else the CSV, declares for their operand, e.g. the pods an upgrade did not replace. The
+// operands without pods, e.g. disabled in the ClusterPolicy, are left out.
+func StaleImages(apiClient *clients.Settings, operands []Operand,
+	csvBuilder *olm.ClusterServiceVersionBuilder) ([]StaleImage, error) {
+	var stale []StaleImage
+
+	for _, operand := range operands {
+		expectedImage, err := operand.ExpectedImage(csvBuilder)
+		if err != nil {
+			return nil, err
+		}
+
+		operandPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{
+			LabelSelector: "app=" + operand.DaemonSet,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the %s pods: %w", operand.DaemonSet, err)
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Checking that the %d %s pods run %s", len(operandPods),
+			operand.DaemonSet, expectedImage)
+
+		for _, operandPod := range operandPods {
+			images := PodImages(operandPod)
+			if operand.Runs(images, expectedImage) {
+				continue
+			}
+
+			stale = append(stale, StaleImage{
+				Operand:  operand.DaemonSet,
+				NodeName: operandPod.Object.Spec.NodeName,
+				PodName:  operandPod.Object.Name,
+				Images:   images,
+				Expected: expectedImage,
+			})
+		}
+	}
+
+	return stale, nil
+}
+
+// PodImages returns the images of all the init and regular containers of a pod.
+func PodImages(podBuilder *pod.Builder) []string {
+	var images []string
+
+	for _, container := range podBuilder.Object.Spec.InitContainers {
+		images = append(images, container.Image)
+	}
+
+	for _, container := range podBuilder.Object.Spec.Containers {
+		images = append(images, container.Image)
+	}
+
+	return images
+}
@@ -7,3 +7,11 @@ var PrivilegedNSLabels = map[string]string{
 	"pod-security.kubernetes.io/warn":                "privileged",
 	"security.openshift.io/scc.podSecurityLabelSync": "false",
 }
+
+// RestrictedNSLabels represents the labels enforcing the restricted Pod Security Admission level.
+var RestrictedNSLabels = map[string]string{
+	"pod-security.kubernetes.io/audit":               "restricted",
+	"pod-security.kubernetes.io/enforce":             "restricted",
+	"pod-security.kubernetes.io/warn":                "restricted",
+	"security.openshift.io/scc.podSecurityLabelSync": "false",
+}
@@ -0,0 +1,160 @@
+package precompiled
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// DriverPodLabel selects the pods of the GPU operator driver daemonsets.
+	DriverPodLabel = "app=nvidia-driver-daemonset"
+	// DriverContainerName is the name of the driver container of the driver daemonset pods.
+	DriverContainerName = "nvidia-driver-ctr"
+	// ImageResolvedCheckInterval is the polling interval of DriverImagesResolved.
+	ImageResolvedCheckInterval = 30 * time.Second
+	// ImageResolvedTimeout is the time the precompiled driver images are given to be pulled on every GPU node.
+	ImageResolvedTimeout = 10 * time.Minute
+)
+
+// ErrImageNotFound is returned when the precompiled driver image of a node kernel cannot be pulled.
+var ErrImageNotFound = errors.New("no precompiled driver image found for the node kernel")
+
+// imagePullFailureReasons are the container waiting reasons reported when an image does not exist.
+var imagePullFailureReasons = []string{"ErrImagePull", "ImagePullBackOff", "InvalidImageName"}
+
+// DriverImagesResolved waits until the driver pod of every node matching nodeSelector runs a precompiled driver
+// image built for the node kernel, and returns the driver image per node name. It returns an error wrapping
+// ErrImageNotFound as soon as the precompiled image of a node kernel fails to be pulled.
+func DriverImagesResolved(apiClient *clients.Settings, nodeSelector map[string]string, pollInterval,
+	timeout time.Duration) (map[string]string, error) {
+	gpuNodes, err := nodes.List(apiClient, metav1.ListOptions{
+		LabelSelector: labels.Set(nodeSelector).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GPU nodes: %w", err)
+	}
+
+	if len(gpuNodes) == 0 {
+		return nil, fmt.Errorf("no node matches selector %v", nodeSelector)
+	}
+
+	driverImages := map[string]string{}
+
+	err = wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			for _, gpuNode := range gpuNodes {
+				nodeName := gpuNode.Object.Name
+				if _, ok := driverImages[nodeName]; ok {
+					continue
+				}
+
+				kernelVersion := gpuNode.Object.Status.NodeInfo.KernelVersion
+
+				image, resolved, err := nodeDriverImage(apiClient, nodeName, kernelVersion)
+				if err != nil {
+					return false, err
+				}
+
+				if !resolved {
+					return false, nil
+				}
+
+				glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' with kernel '%s' runs precompiled driver image '%s'",
+					nodeName, kernelVersion, image)
+
+				driverImages[nodeName] = image
+			}
+
+			return true, nil
+		})
+
+	return driverImages, err
+}
+
+// nodeDriverImage returns the image of the driver container running on the node and whether it was pulled.
+func nodeDriverImage(apiClient *clients.Settings, nodeName, kernelVersion string) (string, bool, error) {
+	driverPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{
+		LabelSelector: DriverPodLabel,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		glog.V(gpuparams.GpuLogLevel).Infof("Error listing driver pods on node '%s': %v", nodeName, err)
+
+		return "", false, nil
+	}
+
+	if len(driverPods) == 0 {
+		glog.V(gpuparams.GpuLogLevel).Infof("No driver pod scheduled on node '%s' yet", nodeName)
+
+		return "", false, nil
+	}
+
+	driverPod := driverPods[0].Object
+
+	var image string
+
+	for _, container := range driverPod.Spec.Containers {
+		if container.Name == DriverContainerName {
+			image = container.Image
+		}
+	}
+
+	if image == "" {
+		return "", false, fmt.Errorf("driver pod %s has no %s container", driverPod.Name, DriverContainerName)
+	}
+
+	if !strings.Contains(image, kernelVersion) {
+		glog.V(gpuparams.GpuLogLevel).Infof("Driver pod '%s' image '%s' is not built for kernel '%s'",
+			driverPod.Name, image, kernelVersion)
+
+		return "", false, nil
+	}
+
+	for _, containerStatus := range driverPod.Status.ContainerStatuses {
+		if containerStatus.Name != DriverContainerName {
+			continue
+		}
+
+		if isImagePullFailure(containerStatus.State) {
+			return "", false, fmt.Errorf("%w: node %s kernel %s image %s: %s", ErrImageNotFound, nodeName,
+				kernelVersion, image, containerStatus.State.Waiting.Message)
+		}
+
+		if containerStatus.ImageID != "" {
+			return image, true, nil
+		}
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Driver pod '%s' image '%s' is not pulled yet", driverPod.Name, image)
+
+	return "", false, nil
+}
+
+func isImagePullFailure(state corev1.ContainerState) bool {
+	if state.Waiting == nil {
+		return false
+	}
+
+	for _, reason := range imagePullFailureReasons {
+		if state.Waiting.Reason == reason {
+			return true
+		}
+	}
+
+	return false
+}
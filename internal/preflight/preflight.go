@@ -0,0 +1,364 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpudirect"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clusterversion"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// SeverityOK is the severity of the checks passed.
+	SeverityOK Severity = "ok"
+	// SeverityWarning is the severity of the checks failed which do not prevent the suites from running, e.g. NFD
+	// not installed yet, which the GPU operator suite installs.
+	SeverityWarning Severity = "warning"
+	// SeverityBlocker is the severity of the checks failed which prevent the suites from running.
+	SeverityBlocker Severity = "blocker"
+
+	// NotInstalled is the version of the operators not installed.
+	NotInstalled = "not installed"
+
+	pullSecretName      = "pull-secret"
+	pullSecretNamespace = "openshift-config"
+	// entitlementSecretName is the cluster wide entitlement secret, synced by the Insights operator from the Red Hat
+	// subscription of the cluster, used by the entitled driver builds.
+	entitlementSecretName      = "etc-pki-entitlement"
+	entitlementSecretNamespace = "openshift-config-managed"
+	defaultStorageClassKey     = "storageclass.kubernetes.io/is-default-class"
+)
+
+// requiredRegistries are the registries the pull secret must authenticate to, serving the OLM catalogs and the
+// certified operator images.
+var requiredRegistries = []string{"registry.redhat.io", "registry.connect.redhat.com"}
+
+// Severity is the severity of a readiness check.
+type Severity string
+
+// Check is the result of a readiness check.
+type Check struct {
+	Name     string   `json:"name"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// GPUNode describes a worker node with an NVIDIA PCI device, and its GPUs when GFD labeled them.
+type GPUNode struct {
+	Name        string `json:"name"`
+	Product     string `json:"product,omitempty"`
+	Count       int    `json:"count,omitempty"`
+	MemoryMiB   int    `json:"memoryMiB,omitempty"`
+	Allocatable string `json:"allocatable,omitempty"`
+}
+
+// Operator describes an operator install.
+type Operator struct {
+	Package   string `json:"package"`
+	Namespace string `json:"namespace"`
+	// Version is the version of the CSV of the operator, NotInstalled when it has none.
+	Version string `json:"version"`
+	Phase   string `json:"phase,omitempty"`
+}
+
+// Options tune the checks of the readiness report.
+type Options struct {
+	// WorkerLabelMap selects the worker nodes.
+	WorkerLabelMap map[string]string
+	// ScaleCluster is true when the suites add the GPU nodes by scaling the cluster, no GPU node being no blocker.
+	ScaleCluster bool
+}
+
+// Report is the readiness report of a cluster.
+type Report struct {
+	ClusterVersion string     `json:"clusterVersion"`
+	GPUNodes       []GPUNode  `json:"gpuNodes"`
+	Operators      []Operator `json:"operators"`
+	StorageClasses []string   `json:"storageClasses"`
+	Checks         []Check    `json:"checks"`
+}
+
+// Run queries the cluster and returns its readiness report.
+func Run(apiClient *clients.Settings, options Options) *Report {
+	report := &Report{GPUNodes: []GPUNode{}, Operators: []Operator{}, StorageClasses: []string{}}
+
+	report.checkClusterVersion(apiClient)
+	report.checkOperators(apiClient)
+	report.checkGPUNodes(apiClient, options)
+	report.checkPullSecret(apiClient)
+	report.checkEntitlement(apiClient)
+	report.checkStorage(apiClient)
+
+	return report
+}
+
+// Blockers returns the checks of the report with the blocker severity.
+func (report *Report) Blockers() []Check {
+	var blockers []Check
+
+	for _, check := range report.Checks {
+		if check.Severity == SeverityBlocker {
+			blockers = append(blockers, check)
+		}
+	}
+
+	return blockers
+}
+
+// WriteJSON writes the report as indented JSON.
+func (report *Report) WriteJSON(output io.Writer) error {
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+// WriteText writes the report as text, the inventory followed by the checks.
+func (report *Report) WriteText(output io.Writer) error {
+	lines := []string{"Cluster version: " + report.ClusterVersion, "", "GPU nodes:"}
+
+	for _, gpuNode := range report.GPUNodes {
+		lines = append(lines, fmt.Sprintf("  %s: %d x %s, %d MiB, allocatable %s", gpuNode.Name, gpuNode.Count,
+			gpuNode.Product, gpuNode.MemoryMiB, gpuNode.Allocatable))
+	}
+
+	lines = append(lines, "", "Operators:")
+	for _, operator := range report.Operators {
+		lines = append(lines, fmt.Sprintf("  %s (%s): %s %s", operator.Package, operator.Namespace, operator.Version,
+			operator.Phase))
+	}
+
+	lines = append(lines, "", "Storage classes: "+strings.Join(report.StorageClasses, ", "), "", "Checks:")
+	for _, check := range report.Checks {
+		lines = append(lines, fmt.Sprintf("  [%s] %s: %s", check.Severity, check.Name, check.Message))
+	}
+
+	_, err := fmt.Fprintln(output, strings.Join(lines, "\n"))
+
+	return err
+}
+
+// operatorVersion returns the version of the operator package read by checkOperators.
+func (report *Report) operatorVersion(packageName string) string {
+	for _, operator := range report.Operators {
+		if operator.Package == packageName {
+			return operator.Version
+		}
+	}
+
+	return NotInstalled
+}
+
+func (report *Report) add(name string, severity Severity, format string, args ...interface{}) {
+	report.Checks = append(report.Checks, Check{Name: name, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkClusterVersion reads the completed OpenShift version, a blocker when it cannot be read.
+func (report *Report) checkClusterVersion(apiClient *clients.Settings) {
+	clusterVersionBuilder, err := clusterversion.Pull(apiClient)
+	if err == nil {
+		report.ClusterVersion, err = clusterVersionBuilder.GetCompletedVersion()
+	}
+
+	if err != nil {
+		report.add("cluster version", SeverityBlocker, "failed to read the OpenShift version: %v", err)
+
+		return
+	}
+
+	report.add("cluster version", SeverityOK, "OpenShift %s", report.ClusterVersion)
+}
+
+// checkOperators reads the NFD, GPU and network operator installs, and the NodeFeatureDiscovery instance. NFD not
+// being installed is a warning, the GPU operator suite installing it.
+func (report *Report) checkOperators(apiClient *clients.Settings) {
+	for _, operator := range []Operator{
+		{Package: nfd.Package, Namespace: nfd.OperatorNamespace},
+		{Package: nvidiagpu.Package, Namespace: nvidiagpu.NvidiaGPUNamespace},
+		{Package: gpudirect.NetworkOperatorPackage, Namespace: gpudirect.NetworkOperatorNamespace},
+	} {
+		operator.Version = NotInstalled
+
+		csvBuilders, err := olm.ListClusterServiceVersion(apiClient, operator.Namespace)
+		if err != nil {
+			glog.V(100).Infof("Failed to list the CSVs of namespace %s: %v", operator.Namespace, err)
+		}
+
+		for _, csvBuilder := range csvBuilders {
+			if strings.HasPrefix(csvBuilder.Object.Name, operator.Package) {
+				operator.Version = csvBuilder.Object.Spec.Version.String()
+				operator.Phase = string(csvBuilder.Object.Status.Phase)
+			}
+		}
+
+		report.Operators = append(report.Operators, operator)
+
+		name := fmt.Sprintf("operator %s", operator.Package)
+
+		switch {
+		case operator.Version == NotInstalled:
+			report.add(name, SeverityOK, "not installed")
+		case operator.Phase != string(v1alpha1.CSVPhaseSucceeded):
+			report.add(name, SeverityWarning, "CSV %s is in phase %s", operator.Version, operator.Phase)
+		default:
+			report.add(name, SeverityOK, "version %s installed", operator.Version)
+		}
+	}
+
+	if report.operatorVersion(nfd.Package) == NotInstalled {
+		report.add("NFD", SeverityWarning, "the NFD operator is not installed, the GPU nodes cannot be discovered")
+
+		return
+	}
+
+	if _, err := nfd.Pull(apiClient, nfd.CRName, nfd.OperatorNamespace); err != nil {
+		report.add("NFD", SeverityWarning, "NodeFeatureDiscovery %s not found: %v", nfd.CRName, err)
+
+		return
+	}
+
+	report.add("NFD", SeverityOK, "NodeFeatureDiscovery %s found", nfd.CRName)
+}
+
+// checkGPUNodes inventories the worker nodes labeled by NFD with an NVIDIA PCI device. No GPU node is a blocker
+// when NFD is installed and the cluster is not scaled.
+func (report *Report) checkGPUNodes(apiClient *clients.Settings, options Options) {
+	nodeSelector := labels.Set{nvidiagpu.NvidiaGPULabel: "true"}
+	for key, value := range options.WorkerLabelMap {
+		nodeSelector[key] = value
+	}
+
+	nodeBuilders, err := nodes.List(apiClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+	if err != nil {
+		report.add("GPU nodes", SeverityBlocker, "failed to list the GPU worker nodes: %v", err)
+
+		return
+	}
+
+	for _, nodeBuilder := range nodeBuilders {
+		nodeLabels := nodeBuilder.Object.Labels
+		count, _ := strconv.Atoi(nodeLabels[gfd.CountLabel])
+		memory, _ := strconv.Atoi(nodeLabels[gfd.MemoryLabel])
+		allocatable := nodeBuilder.Object.Status.Allocatable["nvidia.com/gpu"]
+
+		report.GPUNodes = append(report.GPUNodes, GPUNode{
+			Name:        nodeBuilder.Object.Name,
+			Product:     nodeLabels[gfd.ProductLabel],
+			Count:       count,
+			MemoryMiB:   memory,
+			Allocatable: allocatable.String(),
+		})
+	}
+
+	switch {
+	case len(report.GPUNodes) > 0:
+		report.add("GPU nodes", SeverityOK, "%d GPU worker node(s)", len(report.GPUNodes))
+	case options.ScaleCluster:
+		report.add("GPU nodes", SeverityWarning, "no GPU worker node, the suites scale the cluster")
+	case report.operatorVersion(nfd.Package) == NotInstalled:
+		report.add("GPU nodes", SeverityWarning, "no GPU worker node labeled, NFD is not installed")
+	default:
+		report.add("GPU nodes", SeverityBlocker, "no worker node labeled %s", nvidiagpu.NvidiaGPULabel)
+	}
+}
+
+// checkPullSecret checks that the cluster pull secret authenticates to the Red Hat registries of the catalogs and
+// certified operators.
+func (report *Report) checkPullSecret(apiClient *clients.Settings) {
+	secret, err := apiClient.Secrets(pullSecretNamespace).Get(context.TODO(), pullSecretName, metav1.GetOptions{})
+	if err != nil {
+		report.add("pull secret", SeverityBlocker, "failed to get secret %s/%s: %v", pullSecretNamespace,
+			pullSecretName, err)
+
+		return
+	}
+
+	var dockerConfig struct {
+		Auths map[string]json.RawMessage `json:"auths"`
+	}
+
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &dockerConfig); err != nil {
+		report.add("pull secret", SeverityBlocker, "failed to parse secret %s/%s: %v", pullSecretNamespace,
+			pullSecretName, err)
+
+		return
+	}
+
+	var missing []string
+
+	for _, registry := range requiredRegistries {
+		if _, found := dockerConfig.Auths[registry]; !found {
+			missing = append(missing, registry)
+		}
+	}
+
+	if len(missing) > 0 {
+		report.add("pull secret", SeverityBlocker, "no credentials for %s", strings.Join(missing, ", "))
+
+		return
+	}
+
+	report.add("pull secret", SeverityOK, "credentials for %s", strings.Join(requiredRegistries, ", "))
+}
+
+// checkEntitlement checks the cluster wide entitlement, a warning when missing as only the entitled driver builds
+// need it.
+func (report *Report) checkEntitlement(apiClient *clients.Settings) {
+	_, err := apiClient.Secrets(entitlementSecretNamespace).Get(context.TODO(), entitlementSecretName,
+		metav1.GetOptions{})
+	if err != nil {
+		report.add("entitlement", SeverityWarning, "secret %s/%s not found, the cluster is not entitled: %v",
+			entitlementSecretNamespace, entitlementSecretName, err)
+
+		return
+	}
+
+	report.add("entitlement", SeverityOK, "secret %s/%s found", entitlementSecretNamespace, entitlementSecretName)
+}
+
+// checkStorage lists the storage classes, no default storage class being a warning as only the suites with
+// persistent volumes need one.
+func (report *Report) checkStorage(apiClient *clients.Settings) {
+	storageClasses, err := apiClient.K8sClient.StorageV1().StorageClasses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		report.add("storage", SeverityWarning, "failed to list the storage classes: %v", err)
+
+		return
+	}
+
+	defaultClass := ""
+
+	for _, storageClass := range storageClasses.Items {
+		report.StorageClasses = append(report.StorageClasses, storageClass.Name)
+
+		if storageClass.Annotations[defaultStorageClassKey] == "true" {
+			defaultClass = storageClass.Name
+		}
+	}
+
+	sort.Strings(report.StorageClasses)
+
+	if defaultClass == "" {
+		report.add("storage", SeverityWarning, "no default storage class")
+
+		return
+	}
+
+	report.add("storage", SeverityOK, "default storage class %s", defaultClass)
+}
@@ -0,0 +1,205 @@
+package prereq
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/check"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ReportEntryName names the report entry of the prerequisites checked by Finish.
+	ReportEntryName = "Prerequisites"
+	// Label labels the specs checking the prerequisites of their suite, the only specs run when Only returns true.
+	Label = "prerequisites"
+
+	catalogSourceReady = "READY"
+)
+
+// Result is the outcome of a prerequisite check.
+type Result struct {
+	Name string
+	// Detail describes what was found, e.g. the GPU nodes or the channel resolved.
+	Detail string
+	// Err is why the prerequisite is not met, nil when it is.
+	Err error
+}
+
+// Checker collects the results of the prerequisite checks of a suite, without mutating the cluster.
+type Checker struct {
+	suite   string
+	results []Result
+}
+
+// Only returns true when the suites only check their prerequisites, PREREQUISITES_ONLY being set.
+func Only() bool {
+	return inittools.GeneralConfig.PrerequisitesOnly
+}
+
+// SkipUnchecked skips the current spec when Only returns true and the spec is not labeled with Label, the spec not
+// checking prerequisites and possibly changing the cluster. It is meant to be called from a top level BeforeEach node
+// of every suite, which runs before the BeforeAll nodes of the ordered containers.
+func SkipUnchecked() {
+	if !Only() || slices.Contains(ginkgo.CurrentSpecReport().Labels(), Label) {
+		return
+	}
+
+	ginkgo.Skip("PREREQUISITES_ONLY is set and the spec does not check prerequisites")
+}
+
+// NewChecker returns a checker of the prerequisites of the suite.
+func NewChecker(suite string) *Checker {
+	return &Checker{suite: suite}
+}
+
+// Results returns the results of the checks run so far.
+func (checker *Checker) Results() []Result {
+	return checker.results
+}
+
+// Failed returns the results of the checks run so far whose prerequisite is not met.
+func (checker *Checker) Failed() []Result {
+	var failed []Result
+
+	for _, result := range checker.results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// Add records the result of a check done by the suite.
+func (checker *Checker) Add(name, detail string, err error) *Checker {
+	checker.results = append(checker.results, Result{Name: name, Detail: detail, Err: err})
+
+	return checker
+}
+
+// Set checks that the setting of the environment variable is set.
+func (checker *Checker) Set(envVar, value string) *Checker {
+	var err error
+	if value == "" {
+		err = fmt.Errorf("%s is not set", envVar)
+	}
+
+	return checker.Add(envVar, "set", err)
+}
+
+// GPUNodes checks that at least one worker node has the given GPU label, e.g. the NFD PCI label before the GPU
+// operator is installed or nvidia.com/gpu.present after.
+func (checker *Checker) GPUNodes(apiClient *clients.Settings, gpuLabel string) *Checker {
+	name := fmt.Sprintf("GPU worker nodes labeled %s", gpuLabel)
+
+	found, err := check.NodeWithLabel(apiClient, gpuLabel, inittools.GeneralConfig.WorkerLabelMap)
+	if err == nil && !found {
+		err = fmt.Errorf("no worker node labeled %s", gpuLabel)
+	}
+
+	return checker.Add(name, "found", err)
+}
+
+// CatalogSource checks that the catalogsource exists and that OLM is connected to its registry.
+func (checker *Checker) CatalogSource(apiClient *clients.Settings, name, nsname string) *Checker {
+	checkName := fmt.Sprintf("catalogsource %s/%s", nsname, name)
+
+	catalogSourceBuilder, err := olm.PullCatalogSource(apiClient, name, nsname)
+	if err != nil {
+		return checker.Add(checkName, "", err)
+	}
+
+	state := ""
+	if connectionState := catalogSourceBuilder.Object.Status.GRPCConnectionState; connectionState != nil {
+		state = connectionState.LastObservedState
+	}
+
+	if state != catalogSourceReady {
+		err = fmt.Errorf("catalogsource connection state is %q instead of %s", state, catalogSourceReady)
+	}
+
+	return checker.Add(checkName, describeCatalogSource(catalogSourceBuilder.Object), err)
+}
+
+// PackageChannel checks that the catalog serves the package with a channel matching the selector, the latest channel
+// for an empty selector.
+func (checker *Checker) PackageChannel(apiClient *clients.Settings, packageName, nsname, catalog,
+	selector string) *Checker {
+	name := fmt.Sprintf("package %s of catalog %s", packageName, catalog)
+
+	channel, err := olm.ResolveChannel(apiClient, packageName, nsname, catalog, selector)
+
+	return checker.Add(name, "channel "+channel, err)
+}
+
+// Secret checks that the secret exists.
+func (checker *Checker) Secret(apiClient *clients.Settings, name, nsname string) *Checker {
+	_, err := apiClient.Secrets(nsname).Get(context.TODO(), name, metav1.GetOptions{})
+
+	return checker.Add(fmt.Sprintf("secret %s/%s", nsname, name), "found", err)
+}
+
+// Images checks that the images are reachable, through the mirrors when disconnected. Empty images are ignored.
+func (checker *Checker) Images(images ...string) *Checker {
+	var checked []string
+
+	for _, image := range images {
+		if image != "" {
+			checked = append(checked, image)
+		}
+	}
+
+	if len(checked) == 0 {
+		return checker
+	}
+
+	return checker.Add("images", strings.Join(checked, ", "), disconnected.CheckImages(checked...))
+}
+
+// Finish logs the results of the checks and adds them as a report entry of the spec. It fails the spec when a
+// prerequisite is not met, and skips it otherwise, the suite doing nothing more. It is meant to be called from the
+// BeforeAll node of the suite when Only returns true, before the suite changes the cluster.
+func (checker *Checker) Finish() {
+	var lines []string
+
+	for _, result := range checker.results {
+		line := fmt.Sprintf("[ok]     %s: %s", result.Name, result.Detail)
+		if result.Err != nil {
+			line = fmt.Sprintf("[failed] %s: %v", result.Name, result.Err)
+		}
+
+		lines = append(lines, line)
+	}
+
+	summary := strings.Join(lines, "\n")
+	glog.V(gpuparams.GpuLogLevel).Infof("Prerequisites of suite %s:\n%s", checker.suite, summary)
+	ginkgo.AddReportEntry(ReportEntryName, summary)
+
+	if failed := checker.Failed(); len(failed) > 0 {
+		ginkgo.Fail(fmt.Sprintf("%d of the %d prerequisites of suite %s are not met:\n%s", len(failed),
+			len(checker.results), checker.suite, summary))
+	}
+
+	ginkgo.Skip(fmt.Sprintf("The %d prerequisites of suite %s are met, PREREQUISITES_ONLY is set",
+		len(checker.results), checker.suite))
+}
+
+// describeCatalogSource returns the index image or address the catalogsource serves its catalog from.
+func describeCatalogSource(catalogSource *v1alpha1.CatalogSource) string {
+	if catalogSource.Spec.Image != "" {
+		return "image " + catalogSource.Spec.Image
+	}
+
+	return "address " + catalogSource.Spec.Address
+}
@@ -0,0 +1,164 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// GPURequestsQuota is the quota resource limiting the GPUs requested by the pods of a namespace.
+	GPURequestsQuota = corev1.DefaultResourceRequestsPrefix + cudasamples.GPUResource
+	// WorkloadContainerName is the container name of the pods created by CreateGPUPod.
+	WorkloadContainerName = "gpu-quota-ctr"
+
+	quotaPollInterval = 2 * time.Second
+	vectorAddCommand  = "/cuda-samples/" + string(cudasamples.VectorAdd) + " && sleep infinity"
+)
+
+var (
+	isFalse = false
+	isTrue  = true
+)
+
+// CreateGPUQuota creates a ResourceQuota limiting the GPUs requested by the pods of the namespace.
+func CreateGPUQuota(apiClient *clients.Settings, name, nsname string, gpus int64) (*corev1.ResourceQuota, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating ResourceQuota '%s' of %d GPU(s) in namespace '%s'", name, gpus,
+		nsname)
+
+	resourceQuota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsname},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{GPURequestsQuota: *resource.NewQuantity(gpus, resource.DecimalSI)},
+		},
+	}
+	owner.Label(resourceQuota)
+
+	created, err := apiClient.ResourceQuotas(nsname).Create(context.TODO(), resourceQuota, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ResourceQuota %s: %w", name, err)
+	}
+
+	return created, nil
+}
+
+// WaitForQuotaUsed waits until the quota controller reports the GPUs used in the namespace, the admission of the
+// pods being enforced once the ResourceQuota status is computed.
+func WaitForQuotaUsed(apiClient *clients.Settings, name, nsname string, gpus int64, timeout time.Duration) error {
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), quotaPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			resourceQuota, err := apiClient.ResourceQuotas(nsname).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Error getting ResourceQuota '%s': %v", name, err)
+
+				return false, nil
+			}
+
+			used, found := resourceQuota.Status.Used[GPURequestsQuota]
+
+			return found && used.Value() == gpus, nil
+		})
+	if err != nil {
+		return fmt.Errorf("ResourceQuota %s does not report %d GPU(s) used: %w", name, gpus, err)
+	}
+
+	return nil
+}
+
+// CreateLimitRange creates a LimitRange defaulting the cpu and memory requests and limits of the containers of the
+// namespace, and leaving the extended resources alone.
+func CreateLimitRange(apiClient *clients.Settings, name, nsname string, defaultRequest,
+	defaultLimit corev1.ResourceList) (*corev1.LimitRange, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating LimitRange '%s' in namespace '%s' with default requests %v and "+
+		"limits %v", name, nsname, defaultRequest, defaultLimit)
+
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsname},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{{
+				Type:           corev1.LimitTypeContainer,
+				DefaultRequest: defaultRequest,
+				Default:        defaultLimit,
+			}},
+		},
+	}
+	owner.Label(limitRange)
+
+	created, err := apiClient.LimitRanges(nsname).Create(context.TODO(), limitRange, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LimitRange %s: %w", name, err)
+	}
+
+	return created, nil
+}
+
+// CreateGPUPod creates a pod requesting the GPUs only through their limit, as the extended resources do not need a
+// request, which runs vectorAdd then keeps the GPUs until deleted. The admission error is wrapped so that
+// IsQuotaExceeded can be checked on it.
+func CreateGPUPod(apiClient *clients.Settings, name, nsname, image string, gpus int64) (*pod.Builder, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating pod '%s' requesting %d GPU(s) in namespace '%s'", name, gpus,
+		nsname)
+
+	_, err := apiClient.Pods(nsname).Create(context.TODO(), newGPUPod(name, nsname, image, gpus),
+		metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pod %s: %w", name, err)
+	}
+
+	return pod.Pull(apiClient, name, nsname)
+}
+
+// newGPUPod returns the definition of the pods created by CreateGPUPod.
+func newGPUPod(name, nsname, image string, gpus int64) *corev1.Pod {
+	gpuPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsname},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &isTrue,
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Tolerations: []corev1.Toleration{{
+				Key:      string(cudasamples.GPUResource),
+				Effect:   corev1.TaintEffectNoSchedule,
+				Operator: corev1.TolerationOpExists,
+			}},
+			Containers: []corev1.Container{{
+				Name:            WorkloadContainerName,
+				Image:           image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"/bin/sh", "-c", vectorAddCommand},
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: &isFalse,
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						cudasamples.GPUResource: *resource.NewQuantity(gpus, resource.DecimalSI),
+					},
+				},
+			}},
+		},
+	}
+	owner.Label(gpuPod)
+
+	return gpuPod
+}
+
+// IsQuotaExceeded checks whether the error is the admission rejecting a pod exceeding a ResourceQuota.
+func IsQuotaExceeded(err error) bool {
+	return k8serrors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota")
+}
@@ -13,7 +13,9 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/arch"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -29,10 +31,16 @@ var (
 		"infiniband": "rdma/rdma_shared_device_ib",
 	}
 
+	// TestImages are the default perftest images by architecture.
+	TestImages = arch.Images{
+		arch.AMD64: "quay.io/wabouham/ecosys-nvidia/rdma-tools:0.0.3",
+		arch.ARM64: "quay.io/wabouham/ecosys-nvidia/rdma-tools-aarch64:0.0.3",
+	}
+
 	// image based on cluster architecture
-	debugNodePodImageName = map[string]string{
-		"amd64": "quay.io/wabouham/ecosys-nvidia/ubi9-tools:0.0.1",
-		"arm64": "quay.io/wabouham/ecosys-nvidia/ubi9-tools-arm64:0.0.1",
+	debugNodePodImageName = arch.Images{
+		arch.AMD64: "quay.io/wabouham/ecosys-nvidia/ubi9-tools:0.0.1",
+		arch.ARM64: "quay.io/wabouham/ecosys-nvidia/ubi9-tools-arm64:0.0.1",
 	}
 )
 
@@ -322,6 +330,11 @@ func RunCommandsOnSpecificNode(clientset *clients.Settings, podName, namespace,
 		return "", fmt.Errorf("pod %s already exists in namespace %s", podName, namespace)
 	}
 
+	debugImage, err := debugNodePodImageName.Image(clusterArch)
+	if err != nil {
+		return "", err
+	}
+
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      podName,
@@ -346,7 +359,7 @@ func RunCommandsOnSpecificNode(clientset *clients.Settings, podName, namespace,
 				{
 					Name: "debugger",
 
-					Image: debugNodePodImageName[clusterArch],
+					Image: debugImage,
 
 					Command: commands,
 					SecurityContext: &corev1.SecurityContext{
@@ -363,6 +376,7 @@ func RunCommandsOnSpecificNode(clientset *clients.Settings, podName, namespace,
 		},
 	}
 
+	proxy.Inject(clientset, &pod.Spec)
 	_, err = clientset.Pods(namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
 	if err != nil {
 		return "", fmt.Errorf("failed to create pod: %v", err)
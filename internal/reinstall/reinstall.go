@@ -0,0 +1,416 @@
+package reinstall
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/operatorupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu/clusterpolicybuilder"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// GPUResourceName is the extended resource the device plugin advertises on the GPU nodes.
+	GPUResourceName corev1.ResourceName = "nvidia.com/gpu"
+	// DriverModulePath is the sysfs directory of the NVIDIA kernel module, present while the module is loaded.
+	DriverModulePath = "/sys/module/nvidia"
+
+	leftoverPrefix = "leftover "
+	hostRootVolume = "host-root"
+)
+
+var (
+	// OperatorNodeLabelPrefixes are the prefixes of the node labels set by the GPU operator and its operands, which
+	// must be removed when the GPU operator is uninstalled.
+	OperatorNodeLabelPrefixes = []string{
+		"nvidia.com/gpu.deploy.",
+		"nvidia.com/gpu-driver-upgrade",
+		"nvidia.com/gpu.product",
+		"nvidia.com/cuda.driver",
+	}
+
+	// HostRuntimePaths are the files the container toolkit installs on the host to configure the container runtime,
+	// which must be removed when the GPU operator is uninstalled.
+	HostRuntimePaths = []string{
+		"/usr/local/nvidia/toolkit",
+		"/etc/crio/crio.conf.d/99-nvidia.conf",
+		"/run/containers/oci/hooks.d/oci-nvidia-hook.json",
+	}
+)
+
+// OperatorState is what is needed to deploy the GPU operator again as it was deployed before being uninstalled.
+type OperatorState struct {
+	Channel                string
+	CatalogSource          string
+	CatalogSourceNamespace string
+	Package                string
+	InstalledCSV           string
+	NamespaceLabels        map[string]string
+	TargetNamespaces       []string
+	ClusterPolicySpec      nvidiagpuv1.ClusterPolicySpec
+}
+
+// RecordOperator returns the state of the deployed GPU operator, its Subscription and ClusterPolicy.
+func RecordOperator(apiClient *clients.Settings) (*OperatorState, error) {
+	subBuilder, err := olm.PullSubscription(apiClient, nvidiagpu.SubscriptionName, nvidiagpu.SubscriptionNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull subscription %s: %w", nvidiagpu.SubscriptionName, err)
+	}
+
+	clusterPolicyBuilder, err := nvidiagpu.Pull(apiClient, nvidiagpu.ClusterPolicyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull ClusterPolicy %s: %w", nvidiagpu.ClusterPolicyName, err)
+	}
+
+	nsBuilder, err := namespace.Pull(apiClient, nvidiagpu.NvidiaGPUNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull namespace %s: %w", nvidiagpu.NvidiaGPUNamespace, err)
+	}
+
+	state := &OperatorState{
+		Channel:                subBuilder.Object.Spec.Channel,
+		CatalogSource:          subBuilder.Object.Spec.CatalogSource,
+		CatalogSourceNamespace: subBuilder.Object.Spec.CatalogSourceNamespace,
+		Package:                subBuilder.Object.Spec.Package,
+		InstalledCSV:           subBuilder.Object.Status.InstalledCSV,
+		NamespaceLabels:        map[string]string{},
+		TargetNamespaces:       []string{nvidiagpu.NvidiaGPUNamespace},
+		ClusterPolicySpec:      *clusterPolicyBuilder.Object.Spec.DeepCopy(),
+	}
+
+	for key, value := range nsBuilder.Object.Labels {
+		// The labels set by Kubernetes and OLM are set again on the new namespace.
+		if !strings.HasPrefix(key, "kubernetes.io/") && !strings.HasPrefix(key, "olm.") {
+			state.NamespaceLabels[key] = value
+		}
+	}
+
+	if ogBuilder, err := olm.PullOperatorGroup(apiClient, nvidiagpu.OperatorGroupName,
+		nvidiagpu.NvidiaGPUNamespace); err == nil {
+		state.TargetNamespaces = ogBuilder.Object.Spec.TargetNamespaces
+	}
+
+	return state, nil
+}
+
+// Uninstall removes the ClusterPolicy, waits for the operator to remove its operand pods, then removes the
+// Subscription, the CSVs, the OperatorGroup and the namespace of the GPU operator.
+func Uninstall(apiClient *clients.Settings, pollInterval, timeout time.Duration) error {
+	clusterPolicyBuilder, err := nvidiagpu.Pull(apiClient, nvidiagpu.ClusterPolicyName)
+	if err == nil {
+		glog.V(gpuparams.GpuLogLevel).Infof("Deleting ClusterPolicy '%s'", nvidiagpu.ClusterPolicyName)
+
+		if _, err := clusterPolicyBuilder.Delete(); err != nil {
+			return fmt.Errorf("failed to delete ClusterPolicy %s: %w", nvidiagpu.ClusterPolicyName, err)
+		}
+	}
+
+	// The operator is left running until the operand pods are gone, so that it cleans up after the ClusterPolicy.
+	err = k8swait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			operatorPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{})
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Info(err)
+
+				return false, nil
+			}
+
+			operandPods := 0
+			for _, operatorPod := range operatorPods {
+				if owner := metav1.GetControllerOf(operatorPod.Object); owner != nil && owner.Kind == "DaemonSet" {
+					operandPods++
+				}
+			}
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Waiting for the removal of %d operand pods", operandPods)
+
+			return operandPods == 0, nil
+		})
+	if err != nil {
+		return fmt.Errorf("the GPU operator did not remove its operands: %w", err)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Deleting subscription '%s'", nvidiagpu.SubscriptionName)
+
+	subBuilder, err := olm.PullSubscription(apiClient, nvidiagpu.SubscriptionName, nvidiagpu.SubscriptionNamespace)
+	if err == nil {
+		if err := subBuilder.Delete(); err != nil {
+			return fmt.Errorf("failed to delete subscription %s: %w", nvidiagpu.SubscriptionName, err)
+		}
+	}
+
+	csvBuilders, err := olm.ListClusterServiceVersion(apiClient, nvidiagpu.NvidiaGPUNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to list the CSVs of namespace %s: %w", nvidiagpu.NvidiaGPUNamespace, err)
+	}
+
+	for _, csvBuilder := range csvBuilders {
+		glog.V(gpuparams.GpuLogLevel).Infof("Deleting CSV '%s'", csvBuilder.Object.Name)
+
+		if err := csvBuilder.Delete(); err != nil {
+			return fmt.Errorf("failed to delete CSV %s: %w", csvBuilder.Object.Name, err)
+		}
+	}
+
+	ogBuilder, err := olm.PullOperatorGroup(apiClient, nvidiagpu.OperatorGroupName, nvidiagpu.NvidiaGPUNamespace)
+	if err == nil {
+		if err := ogBuilder.Delete(); err != nil {
+			return fmt.Errorf("failed to delete operatorgroup %s: %w", nvidiagpu.OperatorGroupName, err)
+		}
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Deleting namespace '%s'", nvidiagpu.NvidiaGPUNamespace)
+
+	nsBuilder := namespace.NewBuilder(apiClient, nvidiagpu.NvidiaGPUNamespace)
+	if !nsBuilder.Exists() {
+		return nil
+	}
+
+	if err := nsBuilder.DeleteAndWait(timeout); err != nil {
+		return fmt.Errorf("failed to delete namespace %s: %w", nvidiagpu.NvidiaGPUNamespace, err)
+	}
+
+	return nil
+}
+
+// LeftoverDaemonSets returns the namespaced names of the daemonsets of all namespaces owned by a ClusterPolicy.
+func LeftoverDaemonSets(apiClient *clients.Settings) ([]string, error) {
+	daemonSets, err := apiClient.DaemonSets("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the daemonsets: %w", err)
+	}
+
+	var leftovers []string
+
+	for _, daemonSet := range daemonSets.Items {
+		for _, owner := range daemonSet.OwnerReferences {
+			if owner.Kind == "ClusterPolicy" {
+				leftovers = append(leftovers, daemonSet.Namespace+"/"+daemonSet.Name)
+			}
+		}
+	}
+
+	return leftovers, nil
+}
+
+// LeftoverRuntimeClasses returns the names of the NVIDIA runtime classes the GPU operator creates.
+func LeftoverRuntimeClasses(apiClient *clients.Settings) ([]string, error) {
+	runtimeClasses, err := apiClient.K8sClient.NodeV1().RuntimeClasses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the runtime classes: %w", err)
+	}
+
+	var leftovers []string
+
+	for _, runtimeClass := range runtimeClasses.Items {
+		if strings.HasPrefix(runtimeClass.Handler, "nvidia") {
+			leftovers = append(leftovers, runtimeClass.Name)
+		}
+	}
+
+	return leftovers, nil
+}
+
+// NodeLeftovers returns the GPU operator labels of the node and the GPUs it still advertises.
+func NodeLeftovers(apiClient *clients.Settings, nodeName string) ([]string, error) {
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull node %s: %w", nodeName, err)
+	}
+
+	var leftovers []string
+
+	for key, value := range nodeBuilder.Object.Labels {
+		for _, prefix := range OperatorNodeLabelPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				leftovers = append(leftovers, fmt.Sprintf("node %s label %s=%s", nodeName, key, value))
+
+				break
+			}
+		}
+	}
+
+	if gpus, found := nodeBuilder.Object.Status.Allocatable[GPUResourceName]; found && !gpus.IsZero() {
+		leftovers = append(leftovers, fmt.Sprintf("node %s allocatable %s %s", nodeName, GPUResourceName,
+			gpus.String()))
+	}
+
+	return leftovers, nil
+}
+
+// NodesCleaned waits until the nodes have no GPU operator labels left and no longer advertise GPUs, and returns the
+// leftovers found at the last check.
+func NodesCleaned(apiClient *clients.Settings, nodeNames []string, pollInterval,
+	timeout time.Duration) ([]string, error) {
+	var leftovers []string
+
+	err := k8swait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			leftovers = nil
+
+			for _, nodeName := range nodeNames {
+				nodeLeftovers, err := NodeLeftovers(apiClient, nodeName)
+				if err != nil {
+					glog.V(gpuparams.GpuLogLevel).Info(err)
+
+					return false, nil
+				}
+
+				leftovers = append(leftovers, nodeLeftovers...)
+			}
+
+			glog.V(gpuparams.GpuLogLevel).Infof("GPU operator leftovers on the nodes: %v", leftovers)
+
+			return len(leftovers) == 0, nil
+		})
+
+	return leftovers, err
+}
+
+// HostLeftovers runs a privileged debug pod on the node, in a namespace which must allow privileged pods, and
+// returns the HostRuntimePaths left on the host and whether the NVIDIA kernel module is still loaded.
+func HostLeftovers(apiClient *clients.Settings, podName, nodeName, namespace, image string,
+	timeout time.Duration) ([]string, error) {
+	checks := []string{}
+	for _, path := range HostRuntimePaths {
+		checks = append(checks, fmt.Sprintf("[ -e /host%s ] && echo '%s%s'", path, leftoverPrefix, path))
+	}
+
+	checks = append(checks, fmt.Sprintf("[ -e %s ] && echo '%s%s'", DriverModulePath, leftoverPrefix,
+		DriverModulePath), "true")
+
+	debugPod, err := pod.NewBuilder(apiClient, podName, namespace, image).
+		DefineOnNode(nodeName).
+		WithRestartPolicy(corev1.RestartPolicyNever).
+		RedefineDefaultCMD([]string{"/bin/sh", "-c", strings.Join(checks, "; ")}).
+		WithPrivilegedFlag().
+		WithHostPathVolume(hostRootVolume, "/", "/host").
+		Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create debug pod %s on node %s: %w", podName, nodeName, err)
+	}
+
+	defer func() {
+		if _, err := debugPod.Delete(); err != nil {
+			glog.V(gpuparams.GpuLogLevel).Infof("Error deleting debug pod '%s': %v", podName, err)
+		}
+	}()
+
+	if err := debugPod.WaitUntilInStatus(corev1.PodSucceeded, timeout); err != nil {
+		return nil, fmt.Errorf("debug pod %s did not complete on node %s: %w", podName, nodeName, err)
+	}
+
+	podLog, err := debugPod.GetFullLog(debugPod.Definition.Spec.Containers[0].Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get debug pod %s log: %w", podName, err)
+	}
+
+	var leftovers []string
+
+	for _, line := range strings.Split(podLog, "\n") {
+		if path, found := strings.CutPrefix(strings.TrimSpace(line), leftoverPrefix); found {
+			leftovers = append(leftovers, fmt.Sprintf("node %s path %s", nodeName, path))
+		}
+	}
+
+	return leftovers, nil
+}
+
+// Install deploys the GPU operator again as recorded in the state, the CSV it was running included, then creates
+// the recorded ClusterPolicy and returns the name of the installed CSV.
+func Install(apiClient *clients.Settings, state *OperatorState, pollInterval, timeout time.Duration) (string, error) {
+	nsBuilder := namespace.NewBuilder(apiClient, nvidiagpu.NvidiaGPUNamespace).WithMultipleLabels(state.NamespaceLabels)
+	if !nsBuilder.Exists() {
+		if _, err := nsBuilder.Create(); err != nil {
+			return "", fmt.Errorf("failed to create namespace %s: %w", nvidiagpu.NvidiaGPUNamespace, err)
+		}
+	}
+
+	ogBuilder := olm.NewOperatorGroupBuilder(apiClient, nvidiagpu.OperatorGroupName, nvidiagpu.NvidiaGPUNamespace)
+	ogBuilder.Definition.Spec.TargetNamespaces = state.TargetNamespaces
+
+	if !ogBuilder.Exists() {
+		if _, err := ogBuilder.Create(); err != nil {
+			return "", fmt.Errorf("failed to create operatorgroup %s: %w", nvidiagpu.OperatorGroupName, err)
+		}
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Subscribing to channel '%s' starting at CSV '%s'", state.Channel,
+		state.InstalledCSV)
+
+	// The installplan is approved manually so that the recorded CSV is installed instead of the channel head.
+	subBuilder := olm.NewSubscriptionBuilder(apiClient, nvidiagpu.SubscriptionName, nvidiagpu.SubscriptionNamespace,
+		state.CatalogSource, state.CatalogSourceNamespace, state.Package).
+		WithChannel(state.Channel).
+		WithStartingCSV(state.InstalledCSV).
+		WithInstallPlanApproval(v1alpha1.ApprovalManual)
+
+	if _, err := subBuilder.Create(); err != nil {
+		return "", fmt.Errorf("failed to create subscription %s: %w", nvidiagpu.SubscriptionName, err)
+	}
+
+	installedCSV, err := operatorupgrade.ApproveInstallPlan(apiClient, nvidiagpu.SubscriptionName,
+		nvidiagpu.SubscriptionNamespace, pollInterval, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	err = wait.CSVSucceeded(apiClient, installedCSV, nvidiagpu.NvidiaGPUNamespace, pollInterval, timeout)
+	if err != nil {
+		return "", fmt.Errorf("CSV %s did not succeed: %w", installedCSV, err)
+	}
+
+	csvBuilder, err := olm.PullClusterServiceVersion(apiClient, installedCSV, nvidiagpu.NvidiaGPUNamespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to pull CSV %s: %w", installedCSV, err)
+	}
+
+	clusterPolicyBuilder, err := clusterpolicybuilder.NewBuilderFromCSV(apiClient, csvBuilder).Build()
+	if err != nil {
+		return "", fmt.Errorf("failed to build ClusterPolicy from CSV %s: %w", installedCSV, err)
+	}
+
+	clusterPolicyBuilder.Definition.Spec = *state.ClusterPolicySpec.DeepCopy()
+
+	if _, err := clusterPolicyBuilder.Create(); err != nil {
+		return "", fmt.Errorf("failed to create ClusterPolicy %s: %w", nvidiagpu.ClusterPolicyName, err)
+	}
+
+	return installedCSV, nil
+}
+
+// RestartedContainers returns the containers of the GPU operator pods which restarted.
+func RestartedContainers(apiClient *clients.Settings) ([]string, error) {
+	operatorPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods of namespace %s: %w", nvidiagpu.NvidiaGPUNamespace, err)
+	}
+
+	var restarted []string
+
+	for _, operatorPod := range operatorPods {
+		for _, containerStatus := range operatorPod.Object.Status.ContainerStatuses {
+			if containerStatus.RestartCount > 0 {
+				restarted = append(restarted, fmt.Sprintf("pod %s container %s restarted %d times",
+					operatorPod.Object.Name, containerStatus.Name, containerStatus.RestartCount))
+			}
+		}
+	}
+
+	return restarted, nil
+}
@@ -0,0 +1,323 @@
+package reporter
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+)
+
+const (
+	// ArtifactReportEntryName names the spec report entries holding the URL of an uploaded artifact.
+	ArtifactReportEntryName = "Uploaded artifact"
+
+	artifactUploadTimeout = 30 * time.Minute
+	awsSigningAlgorithm   = "AWS4-HMAC-SHA256"
+	awsTimeFormat         = "20060102T150405Z"
+	awsDateFormat         = "20060102"
+)
+
+// RecordArtifact uploads the file or directory to the artifacts bucket of the general config, directories being
+// uploaded as tar.gz archives, and records its URL as a report entry of the current spec, which the JUnit and HTML
+// reports display. It is meant to be called from a spec node, e.g. JustAfterEach, and does nothing when no bucket
+// is configured or the artifact does not exist.
+func RecordArtifact(artifactPath string) {
+	if inittools.GeneralConfig.ArtifactsBucket == "" {
+		return
+	}
+
+	if _, err := os.Stat(artifactPath); err != nil {
+		glog.V(100).Infof("Skipping upload of missing artifact %s: %v", artifactPath, err)
+
+		return
+	}
+
+	artifactURL, err := uploadArtifact(artifactPath)
+	if err != nil {
+		glog.Errorf("Failed to upload artifact %s: %v", artifactPath, err)
+
+		return
+	}
+
+	glog.V(100).Infof("Uploaded artifact %s to %s", artifactPath, artifactURL)
+	ginkgo.AddReportEntry(ArtifactReportEntryName, artifactURL)
+}
+
+// uploadArtifact uploads the file or directory with an S3 PutObject request, which S3 compatible object storages,
+// e.g. GCS with HMAC keys, accept too, and returns its URL.
+func uploadArtifact(artifactPath string) (string, error) {
+	config := inittools.GeneralConfig
+
+	info, err := os.Stat(artifactPath)
+	if err != nil {
+		return "", err
+	}
+
+	objectKey := artifactObjectKey(artifactPath)
+	filePath := artifactPath
+
+	if info.IsDir() {
+		objectKey += ".tar.gz"
+
+		filePath, err = archiveDirectory(artifactPath)
+		if err != nil {
+			return "", err
+		}
+
+		defer func() {
+			_ = os.Remove(filePath)
+		}()
+	}
+
+	payloadHash, err := fileSHA256(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", filePath, err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", filePath, err)
+	}
+
+	objectURL := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(config.ArtifactsEndpoint, "/"), config.ArtifactsBucket,
+		awsURIEncode(objectKey))
+
+	request, err := http.NewRequest(http.MethodPut, objectURL, file)
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload request: %w", err)
+	}
+
+	request.ContentLength = fileInfo.Size()
+	signAWSRequest(request, payloadHash, time.Now().UTC())
+
+	client := &http.Client{Timeout: artifactUploadTimeout}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", objectKey, err)
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(response.Body)
+
+		return "", fmt.Errorf("upload of %s returned %s: %s", objectKey, response.Status, body)
+	}
+
+	if config.ArtifactsPublicURL != "" {
+		return fmt.Sprintf("%s/%s", strings.TrimSuffix(config.ArtifactsPublicURL, "/"),
+			awsURIEncode(objectKey)), nil
+	}
+
+	return objectURL, nil
+}
+
+// artifactObjectKey returns the object key of the artifact: its path relative to the reports directory, prefixed
+// by the artifacts prefix of the general config.
+func artifactObjectKey(artifactPath string) string {
+	objectKey, err := filepath.Rel(inittools.GeneralConfig.ReportsDirAbsPath, artifactPath)
+	if err != nil || strings.HasPrefix(objectKey, "..") {
+		objectKey = filepath.Base(artifactPath)
+	}
+
+	return path.Join(inittools.GeneralConfig.ArtifactsPrefix, filepath.ToSlash(objectKey))
+}
+
+// archiveDirectory writes the directory to a temporary tar.gz archive and returns the archive path.
+func archiveDirectory(dirPath string) (string, error) {
+	archive, err := os.CreateTemp("", filepath.Base(dirPath)+"-*.tar.gz")
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive of %s: %w", dirPath, err)
+	}
+
+	_ = archive.Close()
+
+	if err := writeTarGz(archive.Name(), dirPath, filepath.Base(dirPath)); err != nil {
+		return "", err
+	}
+
+	return archive.Name(), nil
+}
+
+// writeTarGz streams the files of the directory to a tar.gz archive, under the prefix directory.
+func writeTarGz(archivePath, dirPath, prefix string) error {
+	archive, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", archivePath, err)
+	}
+
+	gzipWriter := gzip.NewWriter(archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = filepath.Walk(dirPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if filePath == dirPath || (!info.Mode().IsRegular() && !info.IsDir()) {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(dirPath, filePath)
+		if err != nil {
+			return err
+		}
+
+		header.Name = filepath.ToSlash(filepath.Join(prefix, relativePath))
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = file.Close()
+		}()
+
+		_, err = io.Copy(tarWriter, file)
+
+		return err
+	})
+
+	for _, closer := range []io.Closer{tarWriter, gzipWriter, archive} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if err != nil {
+		_ = os.Remove(archivePath)
+
+		return fmt.Errorf("failed to write %s: %w", archivePath, err)
+	}
+
+	return nil
+}
+
+func fileSHA256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", filePath, err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", filePath, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// signAWSRequest signs the request with AWS signature version 4 using the artifacts credentials of the general
+// config.
+func signAWSRequest(request *http.Request, payloadHash string, now time.Time) {
+	config := inittools.GeneralConfig
+	amzDate := now.Format(awsTimeFormat)
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", now.Format(awsDateFormat), config.ArtifactsRegion)
+
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	request.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", request.URL.Host,
+		payloadHash, amzDate)
+
+	if config.ArtifactsSessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", config.ArtifactsSessionToken)
+
+		signedHeaders += ";x-amz-security-token"
+		canonicalHeaders += fmt.Sprintf("x-amz-security-token:%s\n", config.ArtifactsSessionToken)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		awsSigningAlgorithm, amzDate, scope, hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+config.ArtifactsSecretKey), now.Format(awsDateFormat))
+	signingKey = hmacSHA256(signingKey, config.ArtifactsRegion)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigningAlgorithm, config.ArtifactsAccessKey, scope, signedHeaders,
+		hex.EncodeToString(hmacSHA256(signingKey, stringToSign))))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// awsURIEncode encodes all the characters of the object key but the unreserved ones and the slashes, as AWS
+// signature version 4 requires.
+func awsURIEncode(value string) string {
+	var encoded strings.Builder
+
+	for _, char := range []byte(value) {
+		switch {
+		case 'A' <= char && char <= 'Z', 'a' <= char && char <= 'z', '0' <= char && char <= '9',
+			char == '-', char == '_', char == '.', char == '~':
+			encoded.WriteByte(char)
+		case char == '/':
+			encoded.WriteByte(char)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", char)
+		}
+	}
+
+	return encoded.String()
+}
@@ -0,0 +1,235 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpudirect"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// EventSuiteStarted is logged when the suite starts, with the cluster metadata.
+	EventSuiteStarted = "suiteStarted"
+	// EventSuiteFinished is logged when the suite ends.
+	EventSuiteFinished = "suiteFinished"
+	// EventSpecStarted is logged when a spec starts.
+	EventSpecStarted = "specStarted"
+	// EventSpecPassed is logged when a spec passes.
+	EventSpecPassed = "specPassed"
+	// EventSpecFailed is logged when a spec fails, panics, times out or is interrupted.
+	EventSpecFailed = "specFailed"
+	// EventSpecSkipped is logged when a spec is skipped or pending.
+	EventSpecSkipped = "specSkipped"
+)
+
+// Event is a line of the event log.
+type Event struct {
+	Time     time.Time        `json:"time"`
+	Event    string           `json:"event"`
+	Suite    string           `json:"suite"`
+	Spec     string           `json:"spec,omitempty"`
+	Labels   []string         `json:"labels,omitempty"`
+	State    string           `json:"state,omitempty"`
+	Duration float64          `json:"durationSeconds,omitempty"`
+	Attempts int              `json:"attempts,omitempty"`
+	Failure  string           `json:"failure,omitempty"`
+	Location string           `json:"location,omitempty"`
+	Cluster  *ClusterMetadata `json:"cluster,omitempty"`
+}
+
+// ClusterMetadata describes the cluster the suite runs on.
+type ClusterMetadata struct {
+	ClusterVersion   string             `json:"clusterVersion"`
+	OperatorVersions map[string]string  `json:"operatorVersions"`
+	GPUNodes         []GPUNodeInventory `json:"gpuNodes"`
+}
+
+// GPUNodeInventory describes the GPUs of a node, as discovered by GFD.
+type GPUNodeInventory struct {
+	Node        string `json:"node"`
+	Product     string `json:"product"`
+	Count       int    `json:"count"`
+	MemoryMiB   int    `json:"memoryMiB"`
+	Allocatable string `json:"allocatable"`
+}
+
+// suiteDescription is the suite of the events logged by the spec hooks, which do not get the suite report.
+var suiteDescription string
+
+// LogSuiteStarted prints the effective config of the suite, and logs the start of the suite and the cluster
+// metadata to the event log, when the event log is enabled in the general config. It is meant to be called from a
+// ReportBeforeSuite node of the suite.
+func LogSuiteStarted(report types.Report) {
+	suiteDescription = report.SuiteDescription
+
+	glog.Infof("Effective config of suite %s:\n%s", report.SuiteDescription, inittools.GeneralConfig.EffectiveConfig())
+
+	if !inittools.GeneralConfig.EventLog {
+		return
+	}
+
+	writeEvent(Event{
+		Time:    eventTime(report.StartTime),
+		Event:   EventSuiteStarted,
+		Suite:   report.SuiteDescription,
+		Labels:  report.SuiteLabels,
+		Cluster: newClusterMetadata(),
+	})
+}
+
+// LogSpecStarted logs the start of the spec to the event log. It is meant to be called from a ReportBeforeEach
+// node of the suite.
+func LogSpecStarted(specReport types.SpecReport) {
+	if !inittools.GeneralConfig.EventLog {
+		return
+	}
+
+	writeEvent(Event{
+		Time:     eventTime(specReport.StartTime),
+		Event:    EventSpecStarted,
+		Suite:    suiteDescription,
+		Spec:     specReport.FullText(),
+		Labels:   specReport.Labels(),
+		Location: specReport.LeafNodeLocation.String(),
+	})
+}
+
+// LogSpecFinished logs the outcome of the spec to the event log. It is meant to be called from a ReportAfterEach
+// node of the suite.
+func LogSpecFinished(specReport types.SpecReport) {
+	if !inittools.GeneralConfig.EventLog {
+		return
+	}
+
+	event := Event{
+		Time:     eventTime(specReport.EndTime),
+		Suite:    suiteDescription,
+		Spec:     specReport.FullText(),
+		Labels:   specReport.Labels(),
+		State:    specReport.State.String(),
+		Duration: specReport.RunTime.Seconds(),
+		Attempts: specReport.NumAttempts,
+		Location: specReport.LeafNodeLocation.String(),
+	}
+
+	switch {
+	case specReport.State == types.SpecStatePassed:
+		event.Event = EventSpecPassed
+	case specReport.Failed():
+		event.Event = EventSpecFailed
+		event.Failure = specReport.FailureMessage()
+		event.Location = specReport.FailureLocation().String()
+	default:
+		event.Event = EventSpecSkipped
+	}
+
+	writeEvent(event)
+}
+
+// LogSuiteFinished logs the end of the suite to the event log. It is meant to be called from a ReportAfterSuite
+// node of the suite.
+func LogSuiteFinished(report types.Report) {
+	if !inittools.GeneralConfig.EventLog {
+		return
+	}
+
+	event := Event{
+		Time:     eventTime(report.EndTime),
+		Event:    EventSuiteFinished,
+		Suite:    report.SuiteDescription,
+		Labels:   report.SuiteLabels,
+		State:    types.SpecStatePassed.String(),
+		Duration: report.RunTime.Seconds(),
+	}
+
+	if !report.SuiteSucceeded {
+		event.State = types.SpecStateFailed.String()
+		event.Failure = fmt.Sprintf("%d spec(s) failed", report.SpecReports.CountWithState(types.SpecStateFailureStates))
+	}
+
+	writeEvent(event)
+}
+
+func newClusterMetadata() *ClusterMetadata {
+	metadata := &ClusterMetadata{
+		ClusterVersion: clusterVersion(),
+		OperatorVersions: map[string]string{
+			"gpu-operator":     gpuOperatorVersion(),
+			"nfd":              operatorVersion(nfd.OperatorNamespace, nfd.Package),
+			"network-operator": operatorVersion(gpudirect.NetworkOperatorNamespace, gpudirect.NetworkOperatorPackage),
+		},
+		GPUNodes: []GPUNodeInventory{},
+	}
+
+	nodeBuilders, err := nodes.List(inittools.APIClient, metav1.ListOptions{
+		LabelSelector: labels.Set{"nvidia.com/gpu.present": "true"}.String()})
+	if err != nil {
+		glog.V(100).Infof("Failed to list the GPU nodes: %v", err)
+
+		return metadata
+	}
+
+	for _, nodeBuilder := range nodeBuilders {
+		nodeLabels := nodeBuilder.Object.Labels
+		count, _ := strconv.Atoi(nodeLabels[gfd.CountLabel])
+		memory, _ := strconv.Atoi(nodeLabels[gfd.MemoryLabel])
+		allocatable := nodeBuilder.Object.Status.Allocatable["nvidia.com/gpu"]
+
+		metadata.GPUNodes = append(metadata.GPUNodes, GPUNodeInventory{
+			Node:        nodeBuilder.Object.Name,
+			Product:     nodeLabels[gfd.ProductLabel],
+			Count:       count,
+			MemoryMiB:   memory,
+			Allocatable: allocatable.String(),
+		})
+	}
+
+	return metadata
+}
+
+// writeEvent appends the event to the event log of the reports directory.
+func writeEvent(event Event) {
+	eventLogPath := inittools.GeneralConfig.GetEventLogPath()
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		glog.Errorf("Failed to encode %s event: %v", event.Event, err)
+
+		return
+	}
+
+	eventLog, err := os.OpenFile(eventLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		glog.Errorf("Failed to open event log %s: %v", eventLogPath, err)
+
+		return
+	}
+
+	defer func() {
+		_ = eventLog.Close()
+	}()
+
+	if _, err := eventLog.Write(append(line, '\n')); err != nil {
+		glog.Errorf("Failed to write event log %s: %v", eventLogPath, err)
+	}
+}
+
+// eventTime returns the timestamp, falling back to now for unset times.
+func eventTime(timestamp time.Time) time.Time {
+	if timestamp.IsZero() {
+		return time.Now()
+	}
+
+	return timestamp
+}
@@ -0,0 +1,150 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2/reporters"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/junit"
+)
+
+// FlakyStatus is the JUnit status of the specs that passed after failed attempts.
+const FlakyStatus = junit.FlakyStatus
+
+// FlakeSummary lists the specs of the suite that were retried.
+type FlakeSummary struct {
+	Suite         string      `json:"suite"`
+	FlakeAttempts int         `json:"flakeAttempts"`
+	Flaky         []FlakySpec `json:"flaky"`
+	Failed        []FlakySpec `json:"failed"`
+}
+
+// FlakySpec is a retried spec and its final outcome.
+type FlakySpec struct {
+	Spec     string `json:"spec"`
+	Attempts int    `json:"attempts"`
+	State    string `json:"state"`
+	Location string `json:"location"`
+}
+
+// ApplyFlakeAttempts sets the number of attempts of the failed specs of the suite to the flake attempts of the
+// general config, when set. Otherwise the --flake-attempts flag and the FlakeAttempts decorators apply.
+func ApplyFlakeAttempts(suiteConfig *types.SuiteConfig) {
+	if inittools.GeneralConfig.FlakeAttempts > 0 {
+		suiteConfig.FlakeAttempts = inittools.GeneralConfig.FlakeAttempts
+	}
+}
+
+// WriteJUnitReport writes the JUnit report of the suite, the specs that passed after failed attempts having the
+// flaky status and their attempts in their system-err, and the flake summary of the suite when specs were retried.
+// It is meant to be called from a ReportAfterSuite node of the suite, in place of the JUnit report of the Ginkgo
+// reporter config.
+func WriteJUnitReport(report types.Report, testSuite string) {
+	reportPath := inittools.GeneralConfig.GetJunitReportPath(testSuite)
+
+	if err := reporters.GenerateJUnitReportWithConfig(report, reportPath, reporters.JunitReportConfig{}); err != nil {
+		glog.Errorf("Failed to write JUnit report %s: %v", reportPath, err)
+
+		return
+	}
+
+	defer IndexArtifact(JUnitReportArtifact, reportPath, "", "")
+
+	summary := newFlakeSummary(report)
+	if len(summary.Flaky) == 0 && len(summary.Failed) == 0 {
+		return
+	}
+
+	if len(summary.Flaky) > 0 {
+		if err := markFlakySpecs(reportPath, report); err != nil {
+			glog.Errorf("Failed to mark flaky specs in JUnit report %s: %v", reportPath, err)
+		}
+	}
+
+	summaryPath := inittools.GeneralConfig.GetFlakeSummaryPath(testSuite)
+
+	content, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		glog.Errorf("Failed to encode flake summary %s: %v", summaryPath, err)
+
+		return
+	}
+
+	if err := os.WriteFile(summaryPath, content, 0666); err != nil {
+		glog.Errorf("Failed to write flake summary %s: %v", summaryPath, err)
+
+		return
+	}
+
+	IndexArtifact(FlakeSummaryArtifact, summaryPath, "", "")
+
+	glog.V(100).Infof("%d flaky and %d retried failed spec(s), flake summary written to %s", len(summary.Flaky),
+		len(summary.Failed), summaryPath)
+}
+
+func newFlakeSummary(report types.Report) *FlakeSummary {
+	summary := &FlakeSummary{
+		Suite:         report.SuiteDescription,
+		FlakeAttempts: report.SuiteConfig.FlakeAttempts,
+		Flaky:         []FlakySpec{},
+		Failed:        []FlakySpec{},
+	}
+
+	for _, specReport := range report.SpecReports {
+		if specReport.NumAttempts <= 1 {
+			continue
+		}
+
+		flakySpec := FlakySpec{
+			Spec:     specReport.FullText(),
+			Attempts: specReport.NumAttempts,
+			State:    specReport.State.String(),
+			Location: specReport.LeafNodeLocation.String(),
+		}
+
+		switch {
+		case isFlaky(specReport):
+			summary.Flaky = append(summary.Flaky, flakySpec)
+		case specReport.Failed():
+			summary.Failed = append(summary.Failed, flakySpec)
+		}
+	}
+
+	return summary
+}
+
+// markFlakySpecs sets the flaky status of the test cases of the specs that passed after failed attempts. The test
+// cases of the report follow the order of the spec reports.
+func markFlakySpecs(reportPath string, report types.Report) error {
+	junitReport, err := junit.Read(reportPath)
+	if err != nil {
+		return err
+	}
+
+	if len(junitReport.TestSuites) != 1 || len(junitReport.TestSuites[0].TestCases) != len(report.SpecReports) {
+		return fmt.Errorf("the report test cases do not match the %d spec reports", len(report.SpecReports))
+	}
+
+	testCases := junitReport.TestSuites[0].TestCases
+
+	for index, specReport := range report.SpecReports {
+		if !isFlaky(specReport) {
+			continue
+		}
+
+		testCases[index].Status = FlakyStatus
+		testCases[index].SystemErr = fmt.Sprintf("Passed after %d attempts\n%s", specReport.NumAttempts,
+			testCases[index].SystemErr)
+	}
+
+	return junit.Write(reportPath, junitReport)
+}
+
+// isFlaky returns true when the spec passed after failed attempts.
+func isFlaky(specReport types.SpecReport) bool {
+	return specReport.State == types.SpecStatePassed && specReport.NumAttempts > 1
+}
@@ -0,0 +1,280 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiasmi"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// GPUMemoryCheckOff disables the GPU memory check.
+	GPUMemoryCheckOff = "off"
+	// GPUMemoryCheckStrict fails the spec or the suite leaving GPU memory allocated.
+	GPUMemoryCheckStrict = "strict"
+	// GPUMemoryReportEntryName names the report entries of the GPU memory used before and after a spec.
+	GPUMemoryReportEntryName = "GPU memory"
+	// GPUMemoryLeakReportEntryName names the report entries of the GPU memory left allocated by a spec or a suite.
+	GPUMemoryLeakReportEntryName = "GPU memory leak"
+
+	// gpuMemorySettleTimeout leaves the processes of the deleted workloads time to exit and release their memory.
+	gpuMemorySettleTimeout = 2 * time.Minute
+	gpuMemoryPollInterval  = 10 * time.Second
+)
+
+// suiteGPUMemory is the GPU memory used when the suite started, sampled by StartGPUMemoryCheck.
+var suiteGPUMemory gpuMemorySamples
+
+// gpuMemorySample is the framebuffer memory used by a GPU and the processes running on it.
+type gpuMemorySample struct {
+	usedMiB   int
+	processes []nvidiasmi.Process
+}
+
+// gpuMemorySamples are the samples of the GPUs of the GPU nodes, by node and GPU PCI bus id.
+type gpuMemorySamples map[string]gpuMemorySample
+
+// StartGPUMemoryCheck samples the framebuffer memory used and the processes of every GPU with nvidia-smi when the
+// suite starts, when the GPU memory check is enabled in the general config. The GPUs without driver yet, e.g. before
+// the GPU operator is deployed by the suite, are left out. It is meant to be called from a BeforeSuite node of the
+// suite.
+func StartGPUMemoryCheck() {
+	if !gpuMemoryCheckEnabled() {
+		return
+	}
+
+	suiteGPUMemory = sampleGPUMemory()
+}
+
+// CheckGPUMemoryLeaks reports the GPUs using more framebuffer memory than when the suite started, by more than the
+// GPU memory leak threshold of the general config, or running processes that were not running then, once the
+// cleanups of the suite are done. It waits for the processes of the deleted workloads to exit, and does nothing
+// when the GPU memory check is disabled or the suite runs in parallel, the other processes still running specs. In
+// strict mode, leaks fail the run. It is meant to be called from an AfterSuite node of the suite, after the leak
+// check.
+func CheckGPUMemoryLeaks() {
+	if !gpuMemoryCheckEnabled() || len(suiteGPUMemory) == 0 || parallelSuite() {
+		return
+	}
+
+	leaks := awaitGPUMemoryReleased(suiteGPUMemory)
+	if len(leaks) == 0 {
+		return
+	}
+
+	reportGPUMemoryLeaks(fmt.Sprintf("%d GPUs use more memory than when the suite started", len(leaks)), leaks)
+}
+
+// CheckSpecGPUMemory samples the framebuffer memory used and the processes of every GPU before the current spec and
+// records them after its cleanups, the DeferCleanup and AfterEach nodes of the spec, with a report entry, when the
+// GPU memory check is enabled in the general config. The GPU memory still allocated after a spec is reported like
+// by CheckGPUMemoryLeaks, except for the specs of the ordered containers, whose workloads can outlive the spec and
+// are checked by CheckGPUMemoryLeaks at the end of the suite, and for the suites running in parallel, whose specs
+// share the GPUs. It is meant to be called from a top level BeforeEach node of the suite.
+func CheckSpecGPUMemory() {
+	if !gpuMemoryCheckEnabled() {
+		return
+	}
+
+	before := sampleGPUMemory()
+	if len(before) == 0 {
+		return
+	}
+
+	// Registered first, the cleanup runs after the cleanups of the spec.
+	ginkgo.DeferCleanup(func() {
+		specReport := ginkgo.CurrentSpecReport()
+		if specReport.IsInOrderedContainer || parallelSuite() {
+			ginkgo.AddReportEntry(GPUMemoryReportEntryName, describeGPUMemory(before, sampleGPUMemory()))
+
+			return
+		}
+
+		leaks := awaitGPUMemoryReleased(before)
+		ginkgo.AddReportEntry(GPUMemoryReportEntryName, describeGPUMemory(before, sampleGPUMemory()))
+
+		if len(leaks) > 0 {
+			reportGPUMemoryLeaks(fmt.Sprintf("%d GPUs use more memory than before the spec", len(leaks)), leaks)
+		}
+	})
+}
+
+// parallelSuite returns true when the suite runs in more than one ginkgo process.
+func parallelSuite() bool {
+	suiteConfig, _ := ginkgo.GinkgoConfiguration()
+
+	return suiteConfig.ParallelTotal > 1
+}
+
+func gpuMemoryCheckEnabled() bool {
+	return inittools.GeneralConfig.GPUMemoryCheck != "" &&
+		inittools.GeneralConfig.GPUMemoryCheck != GPUMemoryCheckOff && inittools.APIClient != nil
+}
+
+// reportGPUMemoryLeaks logs the GPU memory leaks with a warning report entry, failing in strict mode.
+func reportGPUMemoryLeaks(summary string, leaks []string) {
+	message := fmt.Sprintf("%s:\n%s", summary, strings.Join(leaks, "\n"))
+
+	glog.Warning(message)
+	ginkgo.AddReportEntry(GPUMemoryLeakReportEntryName, message)
+
+	if inittools.GeneralConfig.GPUMemoryCheck == GPUMemoryCheckStrict {
+		ginkgo.Fail(message)
+	}
+}
+
+// awaitGPUMemoryReleased waits for the GPUs to release the memory allocated since the samples, and returns the GPUs
+// still using more memory than the threshold or running new processes after the settle timeout.
+func awaitGPUMemoryReleased(before gpuMemorySamples) []string {
+	deadline := time.Now().Add(gpuMemorySettleTimeout)
+
+	for {
+		leaks := gpuMemoryLeaks(before, sampleGPUMemory())
+		if len(leaks) == 0 || time.Now().After(deadline) {
+			return leaks
+		}
+
+		glog.V(100).Infof("Waiting for %d GPUs to release their memory", len(leaks))
+		time.Sleep(gpuMemoryPollInterval)
+	}
+}
+
+// gpuMemoryLeaks returns the GPUs of both samples using more memory than the threshold or running processes after
+// that were not running before, sorted.
+func gpuMemoryLeaks(before, after gpuMemorySamples) []string {
+	threshold := inittools.GeneralConfig.GPUMemoryLeakMiB
+
+	var leaks []string
+
+	for gpu, afterSample := range after {
+		beforeSample, found := before[gpu]
+		if !found {
+			continue
+		}
+
+		newProcesses := processesNotIn(afterSample.processes, beforeSample.processes)
+		if afterSample.usedMiB-beforeSample.usedMiB <= threshold && len(newProcesses) == 0 {
+			continue
+		}
+
+		leak := fmt.Sprintf("GPU %s: %d MiB used, %d MiB before", gpu, afterSample.usedMiB, beforeSample.usedMiB)
+		if len(newProcesses) > 0 {
+			leak += fmt.Sprintf(", new processes: %s", describeProcesses(newProcesses))
+		}
+
+		leaks = append(leaks, leak)
+	}
+
+	sort.Strings(leaks)
+
+	return leaks
+}
+
+// describeGPUMemory returns the memory used and the processes of every GPU before and after, sorted by GPU.
+func describeGPUMemory(before, after gpuMemorySamples) string {
+	var lines []string
+
+	for gpu, afterSample := range after {
+		line := fmt.Sprintf("GPU %s: %d MiB used after", gpu, afterSample.usedMiB)
+		if beforeSample, found := before[gpu]; found {
+			line += fmt.Sprintf(", %d MiB before", beforeSample.usedMiB)
+		}
+
+		if len(afterSample.processes) > 0 {
+			line += fmt.Sprintf(", processes: %s", describeProcesses(afterSample.processes))
+		}
+
+		lines = append(lines, line)
+	}
+
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
+
+// sampleGPUMemory samples the GPUs of the GPU nodes concurrently, the nodes nvidia-smi fails on being left out.
+func sampleGPUMemory() gpuMemorySamples {
+	samples := gpuMemorySamples{}
+
+	gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: gpuNodeSelector})
+	if err != nil {
+		glog.Errorf("Failed to list the GPU nodes to sample their GPU memory: %v", err)
+
+		return samples
+	}
+
+	var (
+		mutex     sync.Mutex
+		waitGroup sync.WaitGroup
+	)
+
+	for _, gpuNode := range gpuNodes {
+		waitGroup.Add(1)
+
+		go func(nodeName string) {
+			defer waitGroup.Done()
+
+			report, err := nvidiasmi.QueryNode(inittools.APIClient, nodeName)
+			if err != nil {
+				glog.V(100).Infof("Skipping the GPU memory of node %s: %v", nodeName, err)
+
+				return
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			for _, gpu := range report.GPUs {
+				usedMiB, err := gpu.Framebuffer.Used.Int()
+				if err != nil {
+					glog.V(100).Infof("Skipping the GPU memory of GPU %s of node %s: %v", gpu.ID, nodeName, err)
+
+					continue
+				}
+
+				samples[nodeName+"/"+gpu.ID] = gpuMemorySample{usedMiB: usedMiB, processes: gpu.Processes}
+			}
+		}(gpuNode.Object.Name)
+	}
+
+	waitGroup.Wait()
+
+	return samples
+}
+
+// processesNotIn returns the processes that are not in the reference processes, by PID and name.
+func processesNotIn(processes, reference []nvidiasmi.Process) []nvidiasmi.Process {
+	running := map[string]bool{}
+	for _, process := range reference {
+		running[fmt.Sprintf("%d %s", process.PID, process.Name)] = true
+	}
+
+	var missing []nvidiasmi.Process
+
+	for _, process := range processes {
+		if !running[fmt.Sprintf("%d %s", process.PID, process.Name)] {
+			missing = append(missing, process)
+		}
+	}
+
+	return missing
+}
+
+// describeProcesses returns the PID, name and used memory of the processes.
+func describeProcesses(processes []nvidiasmi.Process) string {
+	descriptions := make([]string, 0, len(processes))
+	for _, process := range processes {
+		descriptions = append(descriptions, fmt.Sprintf("%d %s (%s)", process.PID, process.Name,
+			strings.TrimSpace(string(process.UsedMemory))))
+	}
+
+	return strings.Join(descriptions, ", ")
+}
@@ -0,0 +1,247 @@
+package reporter
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+)
+
+// htmlReport is the data rendered by htmlReportTemplate.
+type htmlReport struct {
+	Suite      string
+	SuitePath  string
+	StartTime  string
+	Duration   time.Duration
+	Succeeded  bool
+	JUnitFile  string
+	Counts     map[string]int
+	SuiteNodes []htmlSpec
+	Tree       *htmlNode
+}
+
+// htmlNode is a container of the spec tree, holding its specs and nested containers in declaration order.
+type htmlNode struct {
+	Name     string
+	Failed   bool
+	Specs    []htmlSpec
+	Children []*htmlNode
+}
+
+// htmlSpec is a spec or a suite node of the report.
+type htmlSpec struct {
+	Text            string
+	State           string
+	Labels          []string
+	Duration        time.Duration
+	FailureMessage  string
+	FailureLocation string
+	Artifacts       []htmlLink
+}
+
+// htmlLink links a file or directory of the report directory, relative to the HTML report.
+type htmlLink struct {
+	Name string
+	Href string
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Suite}} test report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+summary { cursor: pointer; font-weight: bold; }
+details { margin-left: 1em; }
+table { border-collapse: collapse; margin: 0.5em 0 0.5em 1em; }
+td, th { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+pre { white-space: pre-wrap; margin: 0; }
+.passed { color: #1a7f37; }
+.failed, .panicked, .interrupted, .aborted, .timedout { color: #cf222e; font-weight: bold; }
+.skipped, .pending { color: #9a6700; }
+.label { background: #eee; border-radius: 3px; padding: 0 0.3em; margin-right: 0.3em; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>{{.Suite}}</h1>
+<p>
+{{if .Succeeded}}<span class="passed">PASSED</span>{{else}}<span class="failed">FAILED</span>{{end}}
+- started {{.StartTime}}, ran for {{.Duration}}<br>
+Suite path: {{.SuitePath}}<br>
+JUnit report: <a href="{{.JUnitFile}}">{{.JUnitFile}}</a>
+</p>
+<p>{{range $state, $count := .Counts}}<span class="{{$state}}">{{$state}}: {{$count}}</span> {{end}}</p>
+{{if .SuiteNodes}}<h2>Suite nodes</h2>{{template "specs" .SuiteNodes}}{{end}}
+<h2>Specs</h2>
+{{template "node" .Tree}}
+</body>
+</html>
+{{define "node"}}<details{{if .Failed}} open{{end}}><summary{{if .Failed}} class="failed"{{end}}>{{.Name}}</summary>
+{{if .Specs}}{{template "specs" .Specs}}{{end}}{{range .Children}}{{template "node" .}}{{end}}</details>
+{{end}}
+{{define "specs"}}<table>
+<tr><th>Spec</th><th>State</th><th>Duration</th><th>Failure</th><th>Artifacts</th></tr>
+{{range .}}<tr>
+<td>{{.Text}}{{if .Labels}}<br>{{range .Labels}}<span class="label">{{.}}</span>{{end}}{{end}}</td>
+<td class="{{.State}}">{{.State}}</td>
+<td>{{.Duration}}</td>
+<td>{{if .FailureMessage}}<pre>{{.FailureMessage}}</pre><small>{{.FailureLocation}}</small>{{end}}</td>
+<td>{{range .Artifacts}}<a href="{{.Href}}">{{.Name}}</a><br>{{end}}</td>
+</tr>
+{{end}}</table>
+{{end}}`))
+
+// WriteHTMLReport writes a standalone HTML report of the suite next to its JUnit report when the HTML report is
+// enabled in the general config. It is meant to be called from a ReportAfterSuite node of the suite.
+func WriteHTMLReport(report types.Report, testSuite string) {
+	if !inittools.GeneralConfig.HTMLReport {
+		return
+	}
+
+	reportPath := inittools.GeneralConfig.GetHTMLReportPath(testSuite)
+
+	reportFile, err := os.Create(reportPath)
+	if err != nil {
+		glog.Errorf("Failed to create HTML report %s: %v", reportPath, err)
+
+		return
+	}
+
+	defer func() {
+		_ = reportFile.Close()
+	}()
+
+	if err := htmlReportTemplate.Execute(reportFile, newHTMLReport(report, testSuite)); err != nil {
+		glog.Errorf("Failed to write HTML report %s: %v", reportPath, err)
+
+		return
+	}
+
+	IndexArtifact(HTMLReportArtifact, reportPath, "", "")
+	glog.V(100).Infof("HTML report written to %s", reportPath)
+}
+
+func newHTMLReport(report types.Report, testSuite string) *htmlReport {
+	data := &htmlReport{
+		Suite:     report.SuiteDescription,
+		SuitePath: report.SuitePath,
+		StartTime: report.StartTime.Format(time.RFC3339),
+		Duration:  report.RunTime.Round(time.Second),
+		Succeeded: report.SuiteSucceeded,
+		JUnitFile: filepath.Base(inittools.GeneralConfig.GetJunitReportPath(testSuite)),
+		Counts:    map[string]int{},
+		Tree:      &htmlNode{Name: "All specs"},
+	}
+
+	for _, specReport := range report.SpecReports {
+		spec := newHTMLSpec(specReport)
+
+		if specReport.LeafNodeType != types.NodeTypeIt {
+			// Suite level nodes, e.g. BeforeSuite, are only worth reporting when they fail.
+			if specReport.Failed() {
+				data.SuiteNodes = append(data.SuiteNodes, spec)
+				data.Tree.Failed = true
+			}
+
+			continue
+		}
+
+		data.Counts[spec.State]++
+		data.Tree.add(specReport.ContainerHierarchyTexts, spec, specReport.Failed())
+	}
+
+	return data
+}
+
+func newHTMLSpec(specReport types.SpecReport) htmlSpec {
+	spec := htmlSpec{
+		Text:     specReport.LeafNodeText,
+		State:    specReport.State.String(),
+		Labels:   specReport.Labels(),
+		Duration: specReport.RunTime.Round(time.Millisecond),
+	}
+
+	if spec.Text == "" {
+		spec.Text = specReport.LeafNodeType.String()
+	}
+
+	if specReport.Failed() {
+		spec.FailureMessage = specReport.FailureMessage()
+		spec.FailureLocation = specReport.FailureLocation().String()
+		spec.Artifacts = failedSpecArtifacts(specReport)
+	}
+
+	return spec
+}
+
+// failedSpecArtifacts returns the links to the artifacts of the spec of the artifact index, e.g. the cluster state
+// and pod exec logs dumped by ReportIfFailed, and to its uploaded artifacts.
+func failedSpecArtifacts(specReport types.SpecReport) []htmlLink {
+	var artifacts []htmlLink
+
+	for _, artifact := range specArtifacts(specReport.FullText()) {
+		name := string(artifact.Type)
+		if artifact.Node != "" {
+			name = fmt.Sprintf("%s %s", name, path.Base(artifact.Path))
+		}
+
+		artifacts = append(artifacts, htmlLink{Name: name, Href: artifact.Path})
+	}
+
+	return append(artifacts, uploadedArtifacts(specReport)...)
+}
+
+// uploadedArtifacts returns the links to the artifacts of the spec uploaded by RecordArtifact.
+func uploadedArtifacts(specReport types.SpecReport) []htmlLink {
+	var artifacts []htmlLink
+
+	for _, entry := range specReport.ReportEntries {
+		if entry.Name != ArtifactReportEntryName {
+			continue
+		}
+
+		artifactURL := entry.Value.String()
+		name := artifactURL
+
+		if parsedURL, err := url.Parse(artifactURL); err == nil {
+			name = path.Base(parsedURL.Path)
+		}
+
+		artifacts = append(artifacts, htmlLink{Name: name, Href: artifactURL})
+	}
+
+	return artifacts
+}
+
+// add inserts the spec under the containers of the hierarchy, creating the missing ones.
+func (node *htmlNode) add(hierarchy []string, spec htmlSpec, failed bool) {
+	if failed {
+		node.Failed = true
+	}
+
+	if len(hierarchy) == 0 {
+		node.Specs = append(node.Specs, spec)
+
+		return
+	}
+
+	for _, child := range node.Children {
+		if child.Name == hierarchy[0] {
+			child.add(hierarchy[1:], spec, failed)
+
+			return
+		}
+	}
+
+	child := &htmlNode{Name: hierarchy[0]}
+	node.Children = append(node.Children, child)
+	child.add(hierarchy[1:], spec, failed)
+}
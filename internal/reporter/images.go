@@ -0,0 +1,31 @@
+package reporter
+
+import (
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/images"
+)
+
+// ImageDigestsReportEntryName names the report entries of the digests of the images run by a spec.
+const ImageDigestsReportEntryName = "Image digests"
+
+// RecordImageDigests adds a report entry to the current spec with the digests of the manifests of the images for
+// the architecture, e.g. of the GPU nodes, so that a result can be traced to the exact images it ran, whatever their
+// tags point to later. The images that cannot be resolved are logged, and do not fail the spec, the pods reporting
+// the images they cannot pull.
+func RecordImageDigests(architecture string, imageRefs ...string) {
+	if inittools.APIClient == nil {
+		return
+	}
+
+	digests, err := disconnected.ImageDigests(architecture, imageRefs...)
+	if err != nil {
+		glog.Warningf("Failed to resolve the image digests: %v", err)
+	}
+
+	if len(digests) > 0 {
+		ginkgo.AddReportEntry(ImageDigestsReportEntryName, images.Describe(digests))
+	}
+}
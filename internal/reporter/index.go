@@ -0,0 +1,246 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+)
+
+// ArtifactType is the kind of an artifact of the artifact index.
+type ArtifactType string
+
+const (
+	// ClusterDumpArtifact is the cluster state dumped for a failed spec.
+	ClusterDumpArtifact ArtifactType = "cluster-dump"
+	// PodExecLogsArtifact is the pod exec logs of a failed spec.
+	PodExecLogsArtifact ArtifactType = "pod-exec-logs"
+	// KernelLogArtifact is the dmesg or the journal of a GPU node collected for a failed spec.
+	KernelLogArtifact ArtifactType = "kernel-log"
+	// MustGatherArtifact is the must-gather archive of a failed spec.
+	MustGatherArtifact ArtifactType = "must-gather"
+	// OperandLogsArtifact is the directory of the operand logs streamed during a suite.
+	OperandLogsArtifact ArtifactType = "operand-logs"
+	// SnapshotArtifact is a directory of cluster or GPU snapshots taken by a spec.
+	SnapshotArtifact ArtifactType = "snapshot"
+	// JUnitReportArtifact is the JUnit report of a suite.
+	JUnitReportArtifact ArtifactType = "junit-report"
+	// FlakeSummaryArtifact is the flake summary of a suite.
+	FlakeSummaryArtifact ArtifactType = "flake-summary"
+	// HTMLReportArtifact is the HTML report of a suite.
+	HTMLReportArtifact ArtifactType = "html-report"
+	// TimingReportArtifact is the timing report of a suite.
+	TimingReportArtifact ArtifactType = "timing-report"
+	// PolarionReportArtifact is the Polarion report of a suite.
+	PolarionReportArtifact ArtifactType = "polarion-report"
+)
+
+// IndexedArtifact is a file or directory of the artifact index, its path being relative to the reports directory.
+type IndexedArtifact struct {
+	Type ArtifactType `json:"type"`
+	Spec string       `json:"spec,omitempty"`
+	Node string       `json:"node,omitempty"`
+	Time time.Time    `json:"time"`
+	Size int64        `json:"sizeBytes"`
+	Path string       `json:"path"`
+}
+
+// ArtifactIndex is the index of the artifacts collected by the suites, written to index.json of the reports
+// directory.
+type ArtifactIndex struct {
+	Artifacts []IndexedArtifact `json:"artifacts"`
+}
+
+// IndexArtifact adds the file or directory to the artifact index of the reports directory, with the spec and the
+// node it was collected for, when any, replacing the artifact previously indexed with the same path and spec, as an
+// artifact can be shared by several specs, e.g. a must-gather archive. The artifacts removed since they were
+// indexed, e.g. the evicted must-gather archives, are dropped from the index. Errors are only logged, the index
+// being shared by the suites and their parallel processes.
+func IndexArtifact(artifactType ArtifactType, artifactPath, spec, node string) {
+	info, err := os.Stat(artifactPath)
+	if err != nil {
+		glog.V(100).Infof("Skipping indexing of missing artifact %s: %v", artifactPath, err)
+
+		return
+	}
+
+	artifact := IndexedArtifact{
+		Type: artifactType,
+		Spec: spec,
+		Node: node,
+		Time: time.Now().UTC(),
+		Size: artifactSize(artifactPath, info),
+		Path: artifactIndexPath(artifactPath),
+	}
+
+	err = updateArtifactIndex(func(index *ArtifactIndex) {
+		artifacts := []IndexedArtifact{}
+
+		for _, indexed := range index.Artifacts {
+			if indexed.Path == artifact.Path && indexed.Spec == artifact.Spec {
+				continue
+			}
+
+			if _, err := os.Stat(indexedArtifactPath(indexed)); err != nil {
+				continue
+			}
+
+			artifacts = append(artifacts, indexed)
+		}
+
+		index.Artifacts = append(artifacts, artifact)
+	})
+	if err != nil {
+		glog.Errorf("Failed to index artifact %s: %v", artifactPath, err)
+	}
+}
+
+// ReadArtifactIndex returns the artifact index of the reports directory, empty when no artifact was indexed.
+func ReadArtifactIndex() (*ArtifactIndex, error) {
+	content, err := os.ReadFile(inittools.GeneralConfig.GetArtifactIndexPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ArtifactIndex{}, nil
+		}
+
+		return nil, err
+	}
+
+	return decodeArtifactIndex(content)
+}
+
+// specArtifacts returns the indexed artifacts of the spec.
+func specArtifacts(spec string) []IndexedArtifact {
+	index, err := ReadArtifactIndex()
+	if err != nil {
+		glog.Errorf("Failed to read artifact index: %v", err)
+
+		return nil
+	}
+
+	var artifacts []IndexedArtifact
+
+	for _, artifact := range index.Artifacts {
+		if artifact.Spec == spec {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+
+	return artifacts
+}
+
+// updateArtifactIndex updates the artifact index holding an exclusive lock of its file, so that the parallel
+// processes and the suites running at the same time do not lose each other's artifacts.
+func updateArtifactIndex(update func(index *ArtifactIndex)) error {
+	indexPath := inittools.GeneralConfig.GetArtifactIndexPath()
+
+	indexFile, err := os.OpenFile(indexPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", indexPath, err)
+	}
+
+	defer func() {
+		_ = indexFile.Close()
+	}()
+
+	if err := syscall.Flock(int(indexFile.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", indexPath, err)
+	}
+
+	defer func() {
+		_ = syscall.Flock(int(indexFile.Fd()), syscall.LOCK_UN)
+	}()
+
+	content, err := io.ReadAll(indexFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", indexPath, err)
+	}
+
+	index := &ArtifactIndex{}
+
+	if len(content) > 0 {
+		index, err = decodeArtifactIndex(content)
+		if err != nil {
+			glog.Errorf("Failed to decode artifact index %s, rewriting it: %v", indexPath, err)
+
+			index = &ArtifactIndex{}
+		}
+	}
+
+	update(index)
+
+	content, err = json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", indexPath, err)
+	}
+
+	if err := indexFile.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate %s: %w", indexPath, err)
+	}
+
+	if _, err := indexFile.WriteAt(append(content, '\n'), 0); err != nil {
+		return fmt.Errorf("failed to write %s: %w", indexPath, err)
+	}
+
+	return nil
+}
+
+func decodeArtifactIndex(content []byte) (*ArtifactIndex, error) {
+	index := &ArtifactIndex{}
+	if err := json.Unmarshal(content, index); err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// artifactIndexPath returns the path of the artifact relative to the reports directory, its absolute path when it
+// is out of the reports directory.
+func artifactIndexPath(artifactPath string) string {
+	absolutePath, err := filepath.Abs(artifactPath)
+	if err != nil {
+		absolutePath = artifactPath
+	}
+
+	relativePath, err := filepath.Rel(inittools.GeneralConfig.ReportsDirAbsPath, absolutePath)
+	if err != nil || strings.HasPrefix(relativePath, "..") {
+		return absolutePath
+	}
+
+	return filepath.ToSlash(relativePath)
+}
+
+// indexedArtifactPath returns the path of the indexed artifact on the filesystem.
+func indexedArtifactPath(artifact IndexedArtifact) string {
+	if filepath.IsAbs(artifact.Path) {
+		return artifact.Path
+	}
+
+	return filepath.Join(inittools.GeneralConfig.ReportsDirAbsPath, filepath.FromSlash(artifact.Path))
+}
+
+// artifactSize returns the size of the file, or the total size of the files of the directory.
+func artifactSize(artifactPath string, info os.FileInfo) int64 {
+	if !info.IsDir() {
+		return info.Size()
+	}
+
+	var size int64
+
+	_ = filepath.Walk(artifactPath, func(_ string, fileInfo os.FileInfo, err error) error {
+		if err == nil && fileInfo.Mode().IsRegular() {
+			size += fileInfo.Size()
+		}
+
+		return nil
+	})
+
+	return size
+}
@@ -0,0 +1,120 @@
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/cleanup"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// interruptCleanupTimeout bounds the wait of the ReportAfterSuite node for the cleanups run on interrupt.
+const interruptCleanupTimeout = 2 * time.Minute
+
+// HandleInterrupts runs the cleanups registered with pkg/cleanup when the suite receives SIGINT or SIGTERM, e.g. on
+// a CI job abort. When the interrupt cleanup is enabled in the general config, the objects created by the builders
+// during the suite, identified by their owner label, are deleted last, so that no half-installed operator is left
+// on a shared cluster. It is meant to be called from a BeforeSuite node of the suite.
+func HandleInterrupts() {
+	if inittools.GeneralConfig.InterruptCleanup && inittools.APIClient != nil {
+		cleanup.Register(fmt.Sprintf("the resources of run %s", owner.RunID()), deleteRunResources)
+	}
+
+	cleanup.HandleInterrupts()
+}
+
+// WaitForInterruptCleanup waits for the cleanups run when the suite was interrupted, by a signal or by another
+// parallel process, running them when the interrupt did not reach HandleInterrupts, so that the reports and
+// artifacts of the suite are written once the cluster is cleaned up. It is meant to be called first from a
+// ReportAfterSuite node of the suite.
+func WaitForInterruptCleanup(report types.Report) {
+	if !cleanup.Interrupted() && !suiteInterrupted(report) {
+		return
+	}
+
+	completed := make(chan struct{})
+
+	go func() {
+		cleanup.Run()
+		close(completed)
+	}()
+
+	select {
+	case <-completed:
+		glog.Warningf("The suite was interrupted, the resources of run %s were cleaned up", owner.RunID())
+	case <-time.After(interruptCleanupTimeout):
+		glog.Errorf("The suite was interrupted, the cleanup of the resources of run %s did not complete within %s",
+			owner.RunID(), interruptCleanupTimeout)
+	}
+}
+
+// suiteInterrupted returns true when Ginkgo reports the suite as interrupted.
+func suiteInterrupted(report types.Report) bool {
+	for _, reason := range report.SpecialSuiteFailureReasons {
+		if strings.HasPrefix(reason, "Interrupted") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deleteRunResources deletes the objects of the leak checked resources labeled with the owner label of the run, in
+// reverse order of the resources, and then the labeled namespaces with the remaining objects they hold. The objects
+// of the operators kept on purpose, their cleanup being disabled, are left in place.
+func deleteRunResources() error {
+	listOptions := metav1.ListOptions{LabelSelector: owner.Selector()}
+	propagation := metav1.DeletePropagationBackground
+	keptObjects := keptResources()
+
+	var failed []string
+
+	gvrs := make([]schema.GroupVersionResource, 0, len(leakCheckGVRs)+1)
+	for index := len(leakCheckGVRs) - 1; index >= 0; index-- {
+		gvrs = append(gvrs, leakCheckGVRs[index])
+	}
+
+	gvrs = append(gvrs, namespacesGVR)
+
+	for _, gvr := range gvrs {
+		objects, err := inittools.APIClient.Resource(gvr).List(context.TODO(), listOptions)
+		if err != nil {
+			if !k8serrors.IsNotFound(err) {
+				failed = append(failed, fmt.Sprintf("listing %s: %v", gvr.Resource, err))
+			}
+
+			continue
+		}
+
+		for _, object := range objects.Items {
+			if object.GetDeletionTimestamp() != nil ||
+				keptObjects.Contains(gvr, object.GetNamespace(), object.GetName()) {
+				continue
+			}
+
+			glog.V(100).Infof("Deleting %s %s/%s of run %s", gvr.Resource, object.GetNamespace(), object.GetName(),
+				owner.RunID())
+
+			err := inittools.APIClient.Resource(gvr).Namespace(object.GetNamespace()).Delete(context.TODO(),
+				object.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+			if err != nil && !k8serrors.IsNotFound(err) {
+				failed = append(failed, fmt.Sprintf("deleting %s %s/%s: %v", gvr.Resource, object.GetNamespace(),
+					object.GetName(), err))
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete %d resources: %v", len(failed), failed)
+	}
+
+	return nil
+}
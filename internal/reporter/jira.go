@@ -0,0 +1,286 @@
+package reporter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+)
+
+const (
+	// JiraFingerprintLabelPrefix prefixes the label of the Jira issues holding the fingerprint of their failure.
+	JiraFingerprintLabelPrefix = "nvidia-ci-fp-"
+
+	jiraTimeout           = 30 * time.Second
+	jiraFingerprintLength = 16
+	jiraSummaryLength     = 250
+	jiraOutputLength      = 20000
+)
+
+var (
+	// jiraUUIDPattern and jiraNumberPattern match the parts of the failure messages varying between runs, e.g.
+	// object UIDs, timestamps and durations, left out of the failure fingerprints.
+	jiraUUIDPattern   = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	jiraNumberPattern = regexp.MustCompile(`[0-9]+`)
+)
+
+// jira files the failed specs of a suite as Jira issues with the REST API of the Jira project.
+type jira struct {
+	client  *http.Client
+	apiURL  string
+	project string
+	user    string
+	token   string
+}
+
+// jiraIssue is a Jira issue found by a search.
+type jiraIssue struct {
+	Key string `json:"key"`
+}
+
+// FileJiraIssues files a Jira issue per failed spec of the suite when Jira is configured in the general config, with
+// the failure output, the cluster and operator versions and the artifact links of the spec. The issues are labeled
+// with a fingerprint of the spec and of its failure message, the varying numbers and UIDs left out: a failure whose
+// fingerprint has an open issue is added as a comment of that issue instead. It is meant to be called from a
+// ReportAfterSuite node of the suite.
+func FileJiraIssues(report types.Report) {
+	config := inittools.GeneralConfig
+	if config.JiraURL == "" || config.JiraProject == "" || config.JiraToken == "" || report.SuiteSucceeded {
+		return
+	}
+
+	tracker := &jira{
+		client:  &http.Client{Timeout: jiraTimeout},
+		apiURL:  strings.TrimSuffix(config.JiraURL, "/") + "/rest/api/2",
+		project: config.JiraProject,
+		user:    config.JiraUser,
+		token:   config.JiraToken,
+	}
+
+	environment := jiraEnvironment()
+
+	for _, specReport := range report.SpecReports {
+		if !specReport.Failed() {
+			continue
+		}
+
+		issueKey, created, err := tracker.file(report, specReport, environment)
+		if err != nil {
+			glog.Errorf("Failed to file Jira issue of spec %s: %v", jiraSpecName(specReport), err)
+
+			continue
+		}
+
+		if created {
+			glog.V(100).Infof("Filed Jira issue %s of spec %s", issueKey, jiraSpecName(specReport))
+		} else {
+			glog.V(100).Infof("Updated Jira issue %s of spec %s", issueKey, jiraSpecName(specReport))
+		}
+	}
+}
+
+// file creates the issue of the failed spec, or comments the open issue of its fingerprint, and returns the issue
+// key and whether it was created.
+func (tracker *jira) file(
+	report types.Report, specReport types.SpecReport, environment string) (string, bool, error) {
+	fingerprintLabel := JiraFingerprintLabelPrefix + jiraFingerprint(report, specReport)
+	details := jiraDetails(report, specReport, environment)
+
+	issueKey, err := tracker.openIssue(fingerprintLabel)
+	if err != nil {
+		return "", false, err
+	}
+
+	if issueKey != "" {
+		comment := map[string]interface{}{
+			"body": fmt.Sprintf("The failure occurred again on %s.\n\n%s",
+				eventTime(specReport.EndTime).UTC().Format(time.RFC3339), details),
+		}
+
+		_, err := tracker.do(http.MethodPost, fmt.Sprintf("issue/%s/comment", issueKey), comment)
+
+		return issueKey, false, err
+	}
+
+	labels := []string{fingerprintLabel}
+	for _, label := range strings.Split(inittools.GeneralConfig.JiraLabels, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+
+	summary := fmt.Sprintf("[%s] %s", report.SuiteDescription, jiraSpecName(specReport))
+	if len(summary) > jiraSummaryLength {
+		summary = summary[:jiraSummaryLength-3] + "..."
+	}
+
+	issue := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": tracker.project},
+			"issuetype":   map[string]string{"name": inittools.GeneralConfig.JiraIssueType},
+			"summary":     summary,
+			"description": details,
+			"labels":      labels,
+		},
+	}
+
+	response, err := tracker.do(http.MethodPost, "issue", issue)
+	if err != nil {
+		return "", false, err
+	}
+
+	var created jiraIssue
+	if err := json.Unmarshal(response, &created); err != nil {
+		return "", false, fmt.Errorf("failed to decode issue response: %w", err)
+	}
+
+	return created.Key, true, nil
+}
+
+// openIssue returns the key of the latest issue of the project with the fingerprint label that is not done, empty
+// when there is none.
+func (tracker *jira) openIssue(fingerprintLabel string) (string, error) {
+	search := map[string]interface{}{
+		"jql": fmt.Sprintf(`project = "%s" AND labels = "%s" AND statusCategory != Done ORDER BY created DESC`,
+			tracker.project, fingerprintLabel),
+		"maxResults": 1,
+		"fields":     []string{"key"},
+	}
+
+	response, err := tracker.do(http.MethodPost, "search", search)
+	if err != nil {
+		return "", err
+	}
+
+	var found struct {
+		Issues []jiraIssue `json:"issues"`
+	}
+
+	if err := json.Unmarshal(response, &found); err != nil {
+		return "", fmt.Errorf("failed to decode search response: %w", err)
+	}
+
+	if len(found.Issues) == 0 {
+		return "", nil
+	}
+
+	return found.Issues[0].Key, nil
+}
+
+// do sends the JSON request to the Jira REST API and returns the response body, failing on non 2xx statuses. The
+// token is sent as a personal access token, or as the API token of the user with basic authentication when a user
+// is configured, as Jira Cloud expects.
+func (tracker *jira) do(method, resource string, body interface{}) ([]byte, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", resource, err)
+	}
+
+	request, err := http.NewRequest(method, fmt.Sprintf("%s/%s", tracker.apiURL, resource), bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", resource, err)
+	}
+
+	if tracker.user != "" {
+		request.SetBasicAuth(tracker.user, tracker.token)
+	} else {
+		request.Header.Set("Authorization", "Bearer "+tracker.token)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := tracker.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", resource, err)
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", resource, err)
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("jira %s request returned %s: %s", resource, response.Status, responseBody)
+	}
+
+	return responseBody, nil
+}
+
+// jiraFingerprint returns the fingerprint of the failure of the spec, a hash of the suite, the spec and the failure
+// message without its numbers and UIDs, so that the same failure of later runs has the same fingerprint.
+func jiraFingerprint(report types.Report, specReport types.SpecReport) string {
+	message := jiraUUIDPattern.ReplaceAllString(specReport.Failure.Message, "UID")
+	message = jiraNumberPattern.ReplaceAllString(message, "N")
+
+	hash := sha256.Sum256([]byte(strings.Join([]string{report.SuiteDescription, jiraSpecName(specReport),
+		specReport.Failure.FailureNodeType.String(), message}, "\n")))
+
+	return hex.EncodeToString(hash[:])[:jiraFingerprintLength]
+}
+
+// jiraDetails returns the description of the failure of the spec in Jira wiki markup.
+func jiraDetails(report types.Report, specReport types.SpecReport, environment string) string {
+	var details strings.Builder
+
+	fmt.Fprintf(&details, "*Suite:* %s\n", report.SuiteDescription)
+	fmt.Fprintf(&details, "*Spec:* %s\n", jiraSpecName(specReport))
+	fmt.Fprintf(&details, "*State:* %s after %d attempt(s)\n", specReport.State, specReport.NumAttempts)
+	fmt.Fprintf(&details, "*Location:* %s\n", specReport.LeafNodeLocation)
+	fmt.Fprintf(&details, "*Failure location:* %s\n\n", specReport.Failure.Location)
+	fmt.Fprintf(&details, "h3. Environment\n%s\n", environment)
+
+	details.WriteString("h3. Artifacts\n")
+
+	for _, entry := range specReport.ReportEntries {
+		if entry.Name == ArtifactReportEntryName {
+			fmt.Fprintf(&details, "* %s\n", entry.Value.String())
+		}
+	}
+
+	artifacts := inittools.GeneralConfig.NotificationArtifactsURL
+	if artifacts == "" {
+		artifacts = inittools.GeneralConfig.ReportsDirAbsPath
+	}
+
+	fmt.Fprintf(&details, "* %s\n\n", artifacts)
+	fmt.Fprintf(&details, "h3. Failure\n{noformat}\n%s\n{noformat}\n", specReport.Failure.Message)
+
+	if output := specReport.CapturedGinkgoWriterOutput; output != "" {
+		if len(output) > jiraOutputLength {
+			output = "...\n" + output[len(output)-jiraOutputLength:]
+		}
+
+		fmt.Fprintf(&details, "h3. Output\n{noformat}\n%s\n{noformat}\n", output)
+	}
+
+	return details.String()
+}
+
+// jiraEnvironment returns the cluster and operator versions of the issues, as a Jira wiki markup list.
+func jiraEnvironment() string {
+	return fmt.Sprintf("* Cluster: %s\n* OpenShift version: %s\n* GPU operator version: %s\n", resultsCluster(),
+		clusterVersion(), gpuOperatorVersion())
+}
+
+// jiraSpecName returns the full text of the spec, or its node type for the suite nodes.
+func jiraSpecName(specReport types.SpecReport) string {
+	if specReport.FullText() == "" {
+		return specReport.LeafNodeType.String()
+	}
+
+	return specReport.FullText()
+}
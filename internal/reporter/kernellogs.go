@@ -0,0 +1,160 @@
+package reporter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// KernelLogsDirName is the directory of the report folder of a failed spec holding the kernel logs of the GPU
+	// nodes.
+	KernelLogsDirName = "kernel-logs"
+	// KernelLogPattern selects the journal entries related to the GPU drivers, the NVIDIA driver logging its kernel
+	// messages, e.g. the XID errors, with the NVRM prefix.
+	KernelLogPattern = "nvidia|nouveau|NVRM|Xid"
+
+	gpuNodeSelector   = "nvidia.com/gpu.present=true"
+	kernelLogTimeout  = 3 * time.Minute
+	journalTimeLayout = "2006-01-02 15:04:05"
+	dmesgTimeLayout   = "2006-01-02T15:04:05,999999-07:00"
+)
+
+// collectKernelLogs writes, to the kernel logs directory of the report folder, the dmesg and the GPU driver related
+// journal entries of every GPU node, logged from the kernel log window of the general config before the failure of
+// the spec until now. It does nothing when the window is zero, and collection errors are only logged, as the
+// cluster or the nodes may be broken by the failure.
+func collectKernelLogs(reportDir string, report types.SpecReport) {
+	window := inittools.GeneralConfig.KernelLogWindow
+	if window <= 0 || inittools.APIClient == nil {
+		return
+	}
+
+	gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: gpuNodeSelector})
+	if err != nil {
+		glog.Errorf("Failed to list the GPU nodes to collect their kernel logs: %v", err)
+
+		return
+	}
+
+	if len(gpuNodes) == 0 {
+		return
+	}
+
+	logsDir := filepath.Join(reportDir, KernelLogsDirName)
+	if err := os.MkdirAll(logsDir, 0755); err != nil {
+		glog.Errorf("Failed to create kernel logs directory %s: %v", logsDir, err)
+
+		return
+	}
+
+	failureTime := report.Failure.TimelineLocation.Time
+	if failureTime.IsZero() {
+		failureTime = time.Now()
+	}
+
+	since := failureTime.Add(-window).UTC()
+	until := time.Now().UTC()
+
+	var waitGroup sync.WaitGroup
+
+	for _, gpuNode := range gpuNodes {
+		waitGroup.Add(1)
+
+		go func(nodeName string) {
+			defer waitGroup.Done()
+
+			collectNodeKernelLogs(logsDir, nodeName, report.FullText(), since, until)
+		}(gpuNode.Object.Name)
+	}
+
+	waitGroup.Wait()
+}
+
+// collectNodeKernelLogs writes the dmesg and the journal entries of the node logged between since and until to
+// <node>-dmesg.log and <node>-journal.log of the logs directory, indexing them as artifacts of the spec.
+func collectNodeKernelLogs(logsDir, nodeName, spec string, since, until time.Time) {
+	glog.V(100).Infof("Collecting the kernel logs of node %s from %s to %s", nodeName, since, until)
+
+	journal, err := runKernelLogCommand("adm", "node-logs", nodeName, "--since="+since.Format(journalTimeLayout),
+		"--until="+until.Format(journalTimeLayout), "--grep="+KernelLogPattern, "--case-sensitive=false")
+	if err != nil {
+		glog.Errorf("Failed to collect the journal of node %s: %v", nodeName, err)
+	}
+
+	writeKernelLog(filepath.Join(logsDir, nodeName+"-journal.log"), journal, nodeName, spec)
+
+	dmesg, err := runKernelLogCommand("debug", "node/"+nodeName, "--quiet", "--", "chroot", "/host", "dmesg",
+		"--time-format=iso")
+	if err != nil {
+		glog.Errorf("Failed to collect the dmesg of node %s: %v", nodeName, err)
+	}
+
+	writeKernelLog(filepath.Join(logsDir, nodeName+"-dmesg.log"), filterDmesg(dmesg, since, until), nodeName, spec)
+}
+
+// runKernelLogCommand runs the oc command and returns its output, partial when the command fails.
+func runKernelLogCommand(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), kernelLogTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, ocCommand, args...)
+	cmd.Env = os.Environ()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return string(output), fmt.Errorf("oc %s: %w", strings.Join(args, " "), err)
+	}
+
+	return string(output), nil
+}
+
+// filterDmesg returns the dmesg lines logged between since and until, the lines without timestamp following the
+// line they continue.
+func filterDmesg(dmesg string, since, until time.Time) string {
+	var filtered strings.Builder
+
+	keep := false
+	scanner := bufio.NewScanner(strings.NewReader(dmesg))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		timestamp, _, _ := strings.Cut(line, " ")
+		if logTime, err := time.Parse(dmesgTimeLayout, timestamp); err == nil {
+			keep = !logTime.Before(since) && !logTime.After(until)
+		}
+
+		if keep {
+			filtered.WriteString(line + "\n")
+		}
+	}
+
+	return filtered.String()
+}
+
+func writeKernelLog(logPath, content, nodeName, spec string) {
+	if content == "" {
+		return
+	}
+
+	if err := os.WriteFile(logPath, []byte(content), 0644); err != nil {
+		glog.Errorf("Failed to write kernel log %s: %v", logPath, err)
+
+		return
+	}
+
+	IndexArtifact(KernelLogArtifact, logPath, spec, nodeName)
+}
@@ -0,0 +1,45 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+)
+
+const (
+	// LabelCheckOff disables the label check.
+	LabelCheckOff = "off"
+	// LabelCheckStrict fails the run when a spec misses a label of the taxonomy.
+	LabelCheckStrict = "strict"
+	// LabelReportEntryName names the report entry of the specs whose labels do not follow the taxonomy.
+	LabelReportEntryName = "Invalid spec labels"
+)
+
+// CheckLabels reports the specs of the suite, run or not, whose labels do not follow the taxonomy of the labels
+// package, depending on the label check mode of the general config. In strict mode, they fail the run. It is meant to
+// be called from a ReportAfterSuite node of the suite.
+func CheckLabels(report types.Report) {
+	if inittools.GeneralConfig.LabelCheck == LabelCheckOff {
+		return
+	}
+
+	violations := cilabels.Violations(report)
+	if len(violations) == 0 {
+		return
+	}
+
+	message := fmt.Sprintf("%d specs of suite %s do not follow the label taxonomy:\n%s", len(violations),
+		report.SuiteDescription, strings.Join(violations, "\n"))
+
+	glog.Warning(message)
+	ginkgo.AddReportEntry(LabelReportEntryName, message)
+
+	if inittools.GeneralConfig.LabelCheck == LabelCheckStrict {
+		ginkgo.Fail(message)
+	}
+}
@@ -0,0 +1,167 @@
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/kept"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidianetworkconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/kata"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/kubevirt"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/prometheus"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/sriov"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// LeakCheckOff disables the leak check.
+	LeakCheckOff = "off"
+	// LeakCheckStrict fails the run when objects of the suite are left in the cluster.
+	LeakCheckStrict = "strict"
+	// LeakReportEntryName names the report entry of the objects of the suite left in the cluster.
+	LeakReportEntryName = "Leaked resources"
+)
+
+// namespacesGVR is the resource of the namespaces, listed first as the objects of a leaked namespace are reported
+// with it.
+var namespacesGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// leakCheckGVRs are the resources created by the builders, besides the namespaces, checked for leaks.
+var leakCheckGVRs = []schema.GroupVersionResource{
+	pod.GetGVR(),
+	configmap.GetGVR(),
+	deployment.GetGVR(),
+	{Group: "batch", Version: "v1", Resource: "jobs"},
+	{Group: "nvidia.com", Version: "v1", Resource: "clusterpolicies"},
+	{Group: "mellanox.com", Version: "v1alpha1", Resource: "nicclusterpolicies"},
+	{Group: "mellanox.com", Version: "v1alpha1", Resource: "macvlannetworks"},
+	{Group: "mellanox.com", Version: "v1alpha1", Resource: "ipoibnetworks"},
+	{Group: "nfd.openshift.io", Version: "v1", Resource: "nodefeaturediscoveries"},
+	{Group: "nfd.openshift.io", Version: "v1alpha1", Resource: "nodefeaturerules"},
+	{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"},
+	{Group: "operators.coreos.com", Version: "v1", Resource: "operatorgroups"},
+	{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "catalogsources"},
+	{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "installplans"},
+	{Group: "machine.openshift.io", Version: "v1beta1", Resource: "machinesets"},
+	prometheus.GetPrometheusRuleGVR(),
+	kata.GetKataConfigGVR(),
+	kubevirt.GetVirtualMachineGVR(),
+	sriov.GetNodePolicyGVR(),
+	sriov.GetNetworkGVR(),
+}
+
+// CheckLeaks reports the objects created by the builders during the suite, identified by their owner label, that are
+// still in the cluster and not being deleted, depending on the leak check mode of the general config. The objects of
+// the operators kept on purpose, their cleanup being disabled, are not leaks. In strict mode, leaks fail the run. It is
+// meant to be called from an AfterSuite node of the suite.
+func CheckLeaks() {
+	if inittools.GeneralConfig.LeakCheck == LeakCheckOff || inittools.APIClient == nil {
+		return
+	}
+
+	leaks, err := listLeaks()
+	if err != nil {
+		glog.Errorf("Failed to check the resources of run %s for leaks: %v", owner.RunID(), err)
+
+		return
+	}
+
+	if len(leaks) == 0 {
+		return
+	}
+
+	message := fmt.Sprintf("%d resources of run %s are left in the cluster:\n%s", len(leaks), owner.RunID(),
+		strings.Join(leaks, "\n"))
+
+	glog.Warning(message)
+	ginkgo.AddReportEntry(LeakReportEntryName, message)
+
+	if inittools.GeneralConfig.LeakCheck == LeakCheckStrict {
+		ginkgo.Fail(message)
+	}
+}
+
+// keptResources returns the resources of the operators kept on purpose after the run, with NVIDIAGPU_CLEANUP or
+// NVIDIANETWORK_CLEANUP set to false.
+func keptResources() *kept.Resources {
+	gpuCleanup, networkCleanup := true, true
+
+	if gpuConfig := nvidiagpuconfig.NewNvidiaGPUConfig(); gpuConfig != nil {
+		gpuCleanup = gpuConfig.CleanupAfterTest
+	}
+
+	if networkConfig := nvidianetworkconfig.NewNvidiaNetworkConfig(); networkConfig != nil {
+		networkCleanup = networkConfig.CleanupAfterTest
+	}
+
+	return kept.New(gpuCleanup, networkCleanup)
+}
+
+// listLeaks returns the resource, namespace and name of the labeled objects left in the cluster, sorted, the
+// objects of a leaked namespace and the kept objects being left out. Resources whose CRD is not installed are
+// skipped.
+func listLeaks() ([]string, error) {
+	listOptions := metav1.ListOptions{LabelSelector: owner.Selector()}
+	keptObjects := keptResources()
+
+	namespaces, err := inittools.APIClient.Resource(namespacesGVR).List(context.TODO(), listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the namespaces: %w", err)
+	}
+
+	var leaks []string
+
+	leakedNamespaces := map[string]bool{}
+
+	for _, namespace := range namespaces.Items {
+		if namespace.GetDeletionTimestamp() != nil || keptObjects.Contains(namespacesGVR, "", namespace.GetName()) {
+			continue
+		}
+
+		leakedNamespaces[namespace.GetName()] = true
+		leaks = append(leaks, fmt.Sprintf("%s %s", namespacesGVR.Resource, namespace.GetName()))
+	}
+
+	for _, gvr := range leakCheckGVRs {
+		objects, err := inittools.APIClient.Resource(gvr).List(context.TODO(), listOptions)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				glog.V(100).Infof("Resource %s is not served by the cluster", gvr.String())
+
+				continue
+			}
+
+			return nil, fmt.Errorf("failed to list the %s: %w", gvr.Resource, err)
+		}
+
+		for _, object := range objects.Items {
+			if object.GetDeletionTimestamp() != nil || leakedNamespaces[object.GetNamespace()] ||
+				keptObjects.Contains(gvr, object.GetNamespace(), object.GetName()) {
+				continue
+			}
+
+			name := object.GetName()
+			if object.GetNamespace() != "" {
+				name = object.GetNamespace() + "/" + name
+			}
+
+			leaks = append(leaks, fmt.Sprintf("%s %s", gvr.Resource, name))
+		}
+	}
+
+	sort.Strings(leaks)
+
+	return leaks, nil
+}
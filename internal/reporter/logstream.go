@@ -0,0 +1,176 @@
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// OperandPodSelector selects the driver, device plugin, container toolkit and validator pods of the GPU
+	// operator, whose logs are streamed.
+	OperandPodSelector = "app in (nvidia-driver-daemonset, nvidia-device-plugin-daemonset, " +
+		"nvidia-container-toolkit-daemonset, nvidia-operator-validator)"
+
+	logStreamPollInterval = 10 * time.Second
+)
+
+// operandLogStreamer tails the logs of the operand pods to files of its directory, one file per container instance.
+type operandLogStreamer struct {
+	logsDir   string
+	ctx       context.Context
+	cancel    context.CancelFunc
+	waitGroup sync.WaitGroup
+	mutex     sync.Mutex
+	// streaming holds the log files of the container instances being streamed.
+	streaming map[string]bool
+}
+
+// logStreamer is the streamer of the suite, started by StartOperandLogStreaming.
+var logStreamer *operandLogStreamer
+
+// StartOperandLogStreaming starts streaming the logs of the GPU operator driver, device plugin, container toolkit and
+// validator pods, init containers included, to the operand logs directory of the suite during the whole suite, when
+// enabled in the general config. Every container instance gets its own file, so the logs of the containers that
+// crashed and restarted are kept. It is meant to be called from a BeforeSuite node of the suite. When the suite runs
+// in parallel, only the first ginkgo process streams the logs, the operand pods being the same for all processes.
+func StartOperandLogStreaming(testSuite string) {
+	if !inittools.GeneralConfig.OperandLogStream || inittools.APIClient == nil || logStreamer != nil ||
+		ginkgo.GinkgoParallelProcess() != 1 {
+		return
+	}
+
+	logsDir := inittools.GeneralConfig.GetOperandLogsPath(testSuite)
+	if err := os.MkdirAll(logsDir, 0755); err != nil {
+		glog.Errorf("Failed to create operand logs directory %s: %v", logsDir, err)
+
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	logStreamer = &operandLogStreamer{logsDir: logsDir, ctx: ctx, cancel: cancel, streaming: map[string]bool{}}
+
+	glog.V(100).Infof("Streaming the operand logs to %s", logsDir)
+
+	logStreamer.waitGroup.Add(1)
+
+	go logStreamer.run()
+}
+
+// StopOperandLogStreaming stops streaming the operand logs and uploads their directory with RecordArtifact. It is
+// meant to be called from an AfterSuite node of the suite.
+func StopOperandLogStreaming() {
+	if logStreamer == nil {
+		return
+	}
+
+	logStreamer.cancel()
+	logStreamer.waitGroup.Wait()
+
+	IndexArtifact(OperandLogsArtifact, logStreamer.logsDir, "", "")
+	RecordArtifact(logStreamer.logsDir)
+
+	logStreamer = nil
+}
+
+// run streams the logs of the operand containers as they start, until the streamer is stopped.
+func (streamer *operandLogStreamer) run() {
+	defer streamer.waitGroup.Done()
+
+	ticker := time.NewTicker(logStreamPollInterval)
+	defer ticker.Stop()
+
+	for {
+		streamer.streamNewContainers()
+
+		select {
+		case <-streamer.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// streamNewContainers starts streaming the logs of the operand container instances that are not streamed yet.
+func (streamer *operandLogStreamer) streamNewContainers() {
+	pods, err := inittools.APIClient.Pods(nvidiagpu.NvidiaGPUNamespace).List(streamer.ctx,
+		metav1.ListOptions{LabelSelector: OperandPodSelector})
+	if err != nil {
+		if streamer.ctx.Err() == nil {
+			glog.V(100).Infof("Failed to list the operand pods to stream their logs: %v", err)
+		}
+
+		return
+	}
+
+	for _, operandPod := range pods.Items {
+		statuses := append(operandPod.Status.InitContainerStatuses, operandPod.Status.ContainerStatuses...)
+
+		for _, status := range statuses {
+			if status.State.Waiting != nil {
+				continue
+			}
+
+			logFile := fmt.Sprintf("%s_%s_%d.log", operandPod.Name, status.Name, status.RestartCount)
+
+			streamer.mutex.Lock()
+			started := streamer.streaming[logFile]
+			streamer.streaming[logFile] = true
+			streamer.mutex.Unlock()
+
+			if started {
+				continue
+			}
+
+			streamer.waitGroup.Add(1)
+
+			go streamer.stream(operandPod.Name, status.Name, logFile)
+		}
+	}
+}
+
+// stream copies the logs of the container to the log file until the container terminates or the streamer is
+// stopped.
+func (streamer *operandLogStreamer) stream(podName, containerName, logFile string) {
+	defer streamer.waitGroup.Done()
+
+	logPath := filepath.Join(streamer.logsDir, logFile)
+
+	output, err := os.Create(logPath)
+	if err != nil {
+		glog.Errorf("Failed to create operand log %s: %v", logPath, err)
+
+		return
+	}
+
+	defer func() {
+		_ = output.Close()
+	}()
+
+	logs, err := inittools.APIClient.Pods(nvidiagpu.NvidiaGPUNamespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: containerName, Follow: true, Timestamps: true}).Stream(streamer.ctx)
+	if err != nil {
+		glog.V(100).Infof("Failed to stream the logs of container %s of pod %s: %v", containerName, podName, err)
+
+		return
+	}
+
+	defer func() {
+		_ = logs.Close()
+	}()
+
+	if _, err := io.Copy(output, logs); err != nil && streamer.ctx.Err() == nil {
+		glog.V(100).Infof("Streaming the logs of container %s of pod %s stopped: %v", containerName, podName, err)
+	}
+}
@@ -0,0 +1,338 @@
+package reporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/timeouts"
+)
+
+const (
+	// MustGatherReportEntryName names the spec report entries referencing the must-gather archive of an earlier
+	// failed spec of the suite.
+	MustGatherReportEntryName = "Must-gather"
+
+	mustGatherDirName = "must-gather"
+	ocCommand         = "oc"
+)
+
+// MustGatherCollection is a must-gather run by MustGatherIfFailed, either a script of the must-gather scripts
+// directory or an `oc adm must-gather` image. The images and extra arguments of the general config, keyed by the
+// collection name, override the defaults of the collection.
+type MustGatherCollection struct {
+	// Name is the directory of the collection in the must-gather archive.
+	Name string
+	// Script is the file name of the collection script in the must-gather scripts directory.
+	Script string
+	// OutputDirEnvVar is the environment variable setting the output directory of the script.
+	OutputDirEnvVar string
+	// Image is the must-gather image, the default OCP must-gather image being used when neither Image nor
+	// Script are set.
+	Image string
+	// HostedControlPlane inspects the hosted control plane namespace of the management cluster with
+	// `oc adm inspect` instead of running a must-gather.
+	HostedControlPlane bool
+}
+
+var (
+	// GPUOperatorMustGather collects the GPU operator must-gather, fetched by the Makefile.
+	GPUOperatorMustGather = MustGatherCollection{
+		Name: "gpu-operator", Script: "gpu-operator-must-gather.sh", OutputDirEnvVar: "ARTIFACT_DIR"}
+	// NFDMustGather collects the NFD operator must-gather, fetched by the Makefile.
+	NFDMustGather = MustGatherCollection{Name: "nfd", Script: "nfd-must-gather.sh", OutputDirEnvVar: "OUTPUT_DIR"}
+	// NetworkOperatorMustGather collects the network operator must-gather. It has no default image, it is only
+	// collected when its image is set in the general config.
+	NetworkOperatorMustGather = MustGatherCollection{Name: "network-operator"}
+	// OCPMustGather collects the default OCP must-gather.
+	OCPMustGather = MustGatherCollection{Name: "ocp"}
+	// HostedControlPlaneMustGather collects the resources and logs of the HyperShift hosted control plane namespace
+	// of the management cluster. MustGatherIfFailed adds it to the collections when HOSTED_CLUSTER_NAME is set.
+	HostedControlPlaneMustGather = MustGatherCollection{Name: "hosted-control-plane", HostedControlPlane: true}
+
+	// DefaultMustGatherCollections are the NFD and GPU operator must-gathers.
+	DefaultMustGatherCollections = []MustGatherCollection{NFDMustGather, GPUOperatorMustGather}
+
+	mustGatherMutex sync.Mutex
+	// collectedMustGathers are the must-gathers collected during the suite, by collection name.
+	collectedMustGathers = map[string]collectedMustGather{}
+)
+
+// collectedMustGather is the archive of a must-gather collection and the failed spec it was collected for.
+type collectedMustGather struct {
+	archivePath string
+	spec        string
+}
+
+// MustGatherIfFailed runs the given must-gather collections concurrently when the spec failed, streams their output to
+// a tar.gz archive of the must-gather reports directory and uploads it with RecordArtifact. The oldest archives
+// are evicted when the archives exceed the must-gather size cap of the general config. It does nothing when no
+// must-gather scripts directory is configured. The hosted control plane namespace is collected too when the suite runs
+// against a HyperShift hosted cluster. The operator must-gathers being cluster wide, a collection runs once per suite:
+// the specs failing after it reference the archive of the first failed spec, with a report entry and in the artifact
+// index, unless the archive was evicted since.
+func MustGatherIfFailed(report types.SpecReport, collections ...MustGatherCollection) {
+	config := inittools.GeneralConfig
+	if config.MustGatherScriptsDir == "" || !types.SpecStateFailureStates.Is(report.State) {
+		return
+	}
+
+	if config.GetHostedControlPlaneNamespace() != "" {
+		collections = append(collections, HostedControlPlaneMustGather)
+	}
+
+	collections = referenceMustGathers(report, collections)
+	if len(collections) == 0 {
+		return
+	}
+
+	archiveDir := filepath.Join(config.ReportsDirAbsPath, mustGatherDirName)
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		glog.Errorf("Failed to create must-gather directory %s: %v", archiveDir, err)
+
+		return
+	}
+
+	collectDir, err := os.MkdirTemp("", "must-gather-")
+	if err != nil {
+		glog.Errorf("Failed to create must-gather collection directory: %v", err)
+
+		return
+	}
+
+	defer func() {
+		_ = os.RemoveAll(collectDir)
+	}()
+
+	runMustGatherCollections(collectDir, collections)
+
+	archivePath := filepath.Join(archiveDir, reportFolderName(report)+".tar.gz")
+	if err := writeTarGz(archivePath, collectDir, mustGatherDirName); err != nil {
+		glog.Errorf("Failed to archive must-gather of spec %s: %v", report.FullText(), err)
+
+		return
+	}
+
+	if err := evictMustGatherArchives(archiveDir, archivePath, config.MustGatherSizeCapMB<<20); err != nil {
+		glog.Errorf("Failed to evict must-gather archives: %v", err)
+	}
+
+	mustGatherMutex.Lock()
+	for _, collection := range collections {
+		collectedMustGathers[collection.Name] = collectedMustGather{archivePath: archivePath, spec: report.FullText()}
+	}
+	mustGatherMutex.Unlock()
+
+	IndexArtifact(MustGatherArtifact, archivePath, report.FullText(), "")
+	RecordArtifact(archivePath)
+}
+
+// referenceMustGathers references the archives of the collections already collected during the suite for the
+// failed spec, and returns the collections left to collect.
+func referenceMustGathers(report types.SpecReport, collections []MustGatherCollection) []MustGatherCollection {
+	mustGatherMutex.Lock()
+	defer mustGatherMutex.Unlock()
+
+	var (
+		pending    []MustGatherCollection
+		referenced = map[collectedMustGather][]string{}
+	)
+
+	for _, collection := range collections {
+		collected, found := collectedMustGathers[collection.Name]
+		if found {
+			if _, err := os.Stat(collected.archivePath); err == nil {
+				referenced[collected] = append(referenced[collected], collection.Name)
+
+				continue
+			}
+
+			delete(collectedMustGathers, collection.Name)
+		}
+
+		pending = append(pending, collection)
+	}
+
+	for collected, names := range referenced {
+		glog.V(100).Infof("Skipping %v must-gather of spec %s, collected in %s for the failed spec %s", names,
+			report.FullText(), collected.archivePath, collected.spec)
+		ginkgo.AddReportEntry(MustGatherReportEntryName, fmt.Sprintf("%v collected in %s for the failed spec %q",
+			names, artifactIndexPath(collected.archivePath), collected.spec))
+		IndexArtifact(MustGatherArtifact, collected.archivePath, report.FullText(), "")
+	}
+
+	return pending
+}
+
+// runMustGatherCollections runs the collections concurrently, each one writing to its directory of collectDir.
+// A failed collection is logged and does not prevent archiving the output of the others.
+func runMustGatherCollections(collectDir string, collections []MustGatherCollection) {
+	config := inittools.GeneralConfig
+
+	var waitGroup sync.WaitGroup
+
+	for _, collection := range collections {
+		waitGroup.Add(1)
+
+		go func(collection MustGatherCollection) {
+			defer waitGroup.Done()
+
+			outputDir := filepath.Join(collectDir, collection.Name)
+			if err := os.MkdirAll(outputDir, 0755); err != nil {
+				glog.Errorf("Failed to create %s output directory: %v", collection.Name, err)
+
+				return
+			}
+
+			name, args, found := collection.command(outputDir)
+			if !found {
+				glog.V(100).Infof("Skipping %s must-gather, no image is set", collection.Name)
+
+				return
+			}
+
+			timeout := timeouts.Scale(timeouts.MustGather, config.MustGatherTimeout)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			cmd := exec.CommandContext(ctx, name, args...)
+			cmd.Env = os.Environ()
+
+			if name != ocCommand && collection.OutputDirEnvVar != "" {
+				cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", collection.OutputDirEnvVar, outputDir))
+			}
+
+			glog.V(100).Infof("Collecting %s in %s", collection.Name, outputDir)
+
+			output, err := cmd.CombinedOutput()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				glog.Errorf("%s timed out after %s, archiving its partial output", collection.Name, timeout)
+
+				return
+			}
+
+			if err != nil {
+				glog.Errorf("Error running %s must-gather: %v\nOutput: %s", collection.Name, err, output)
+
+				return
+			}
+
+			glog.V(100).Infof("%s output: %s", collection.Name, output)
+		}(collection)
+	}
+
+	waitGroup.Wait()
+}
+
+// command returns the command collecting the must-gather to the output directory: the script of the collection, or
+// `oc adm must-gather` when an image is set in the general config or the collection has no script, or
+// `oc adm inspect` of the hosted control plane namespace. found is false for the collections without default image
+// nor script whose image is not set, and for the hosted control plane without hosted cluster.
+func (collection MustGatherCollection) command(outputDir string) (name string, args []string, found bool) {
+	config := inittools.GeneralConfig
+	extraArgs := strings.Fields(config.MustGatherArgs[collection.Name])
+
+	if collection.HostedControlPlane {
+		namespace := config.GetHostedControlPlaneNamespace()
+		if namespace == "" {
+			return "", nil, false
+		}
+
+		args = []string{"adm", "inspect", "--kubeconfig=" + config.ManagementKubeconfig,
+			"--dest-dir=" + outputDir, "ns/" + namespace}
+
+		return ocCommand, append(args, extraArgs...), true
+	}
+
+	image := collection.Image
+	if configImage := config.MustGatherImages[collection.Name]; configImage != "" {
+		image = configImage
+	}
+
+	if image == "" && collection.Script != "" {
+		return filepath.Join(config.MustGatherScriptsDir, collection.Script), extraArgs, true
+	}
+
+	if image == "" && collection.Name != OCPMustGather.Name {
+		return "", nil, false
+	}
+
+	args = []string{"adm", "must-gather", "--dest-dir=" + outputDir}
+	if image != "" {
+		args = append(args, "--image="+image)
+	}
+
+	return ocCommand, append(args, extraArgs...), true
+}
+
+// evictMustGatherArchives removes the oldest archives of the directory until they fit in sizeCap bytes, keeping
+// the archive just written. A zero size cap disables the eviction.
+func evictMustGatherArchives(archiveDir, keptArchive string, sizeCap int64) error {
+	if sizeCap <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(archiveDir)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", archiveDir, err)
+	}
+
+	var (
+		archives   []os.FileInfo
+		totalBytes int64
+	)
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tar.gz") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", entry.Name(), err)
+		}
+
+		archives = append(archives, info)
+		totalBytes += info.Size()
+	}
+
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].ModTime().Before(archives[j].ModTime())
+	})
+
+	for _, archive := range archives {
+		if totalBytes <= sizeCap {
+			break
+		}
+
+		archivePath := filepath.Join(archiveDir, archive.Name())
+		if archivePath == keptArchive {
+			continue
+		}
+
+		glog.V(100).Infof("Evicting must-gather archive %s to fit in %d MiB", archivePath, sizeCap>>20)
+
+		if err := os.Remove(archivePath); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", archivePath, err)
+		}
+
+		totalBytes -= archive.Size()
+	}
+
+	if totalBytes > sizeCap {
+		glog.Errorf("Must-gather archive %s alone exceeds the %d MiB size cap", keptArchive, sizeCap>>20)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clusterversion"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+)
+
+const (
+	notificationTimeout = 30 * time.Second
+	unknownVersion      = "unknown"
+)
+
+// Notification is the summary of a failed suite posted to the notification webhook. Text holds the whole summary,
+// so that Slack incoming webhooks render it, while the other fields are meant for generic webhooks.
+type Notification struct {
+	Text               string   `json:"text"`
+	Suite              string   `json:"suite"`
+	FailedSpecs        []string `json:"failedSpecs"`
+	ClusterVersion     string   `json:"clusterVersion"`
+	GPUOperatorVersion string   `json:"gpuOperatorVersion"`
+	Artifacts          string   `json:"artifacts"`
+}
+
+// NotifyIfFailed posts a summary of the suite to the notification webhook of the general config when the suite
+// failed. It is meant to be called from a ReportAfterSuite node of the suite, and does nothing when no webhook URL
+// is configured.
+func NotifyIfFailed(report types.Report) {
+	webhookURL := inittools.GeneralConfig.NotificationWebhookURL
+	if webhookURL == "" || report.SuiteSucceeded {
+		return
+	}
+
+	notification := newNotification(report)
+
+	if err := postNotification(webhookURL, notification); err != nil {
+		glog.Errorf("Failed to notify the failure of suite %s: %v", report.SuiteDescription, err)
+
+		return
+	}
+
+	glog.V(100).Infof("Notified the failure of suite %s", report.SuiteDescription)
+}
+
+func newNotification(report types.Report) *Notification {
+	notification := &Notification{
+		Suite:              report.SuiteDescription,
+		FailedSpecs:        []string{},
+		ClusterVersion:     clusterVersion(),
+		GPUOperatorVersion: gpuOperatorVersion(),
+		Artifacts:          inittools.GeneralConfig.NotificationArtifactsURL,
+	}
+
+	if notification.Artifacts == "" {
+		notification.Artifacts = inittools.GeneralConfig.ReportsDirAbsPath
+	}
+
+	for _, specReport := range report.SpecReports {
+		if !specReport.Failed() {
+			continue
+		}
+
+		failedSpec := specReport.FullText()
+		if failedSpec == "" {
+			failedSpec = specReport.LeafNodeType.String()
+		}
+
+		notification.FailedSpecs = append(notification.FailedSpecs, failedSpec)
+	}
+
+	var text strings.Builder
+
+	fmt.Fprintf(&text, "Suite %s failed with %d failed spec(s) after %s\n", notification.Suite,
+		len(notification.FailedSpecs), report.RunTime.Round(time.Second))
+	fmt.Fprintf(&text, "Cluster version: %s\n", notification.ClusterVersion)
+	fmt.Fprintf(&text, "GPU operator version: %s\n", notification.GPUOperatorVersion)
+	fmt.Fprintf(&text, "Artifacts: %s\n", notification.Artifacts)
+
+	for _, failedSpec := range notification.FailedSpecs {
+		fmt.Fprintf(&text, "- %s\n", failedSpec)
+	}
+
+	notification.Text = text.String()
+
+	return notification
+}
+
+func postNotification(webhookURL string, notification *Notification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode the notification: %w", err)
+	}
+
+	client := &http.Client{Timeout: notificationTimeout}
+
+	response, err := client.Post(webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to post the notification: %w", err)
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("notification webhook returned %s", response.Status)
+	}
+
+	return nil
+}
+
+// clusterVersion returns the OpenShift version the cluster completed upgrading to.
+func clusterVersion() string {
+	if inittools.APIClient == nil {
+		return unknownVersion
+	}
+
+	clusterVersionBuilder, err := clusterversion.Pull(inittools.APIClient)
+	if err != nil {
+		glog.V(100).Infof("Failed to pull the ClusterVersion: %v", err)
+
+		return unknownVersion
+	}
+
+	version, err := clusterVersionBuilder.GetCompletedVersion()
+	if err != nil {
+		glog.V(100).Infof("Failed to get the cluster version: %v", err)
+
+		return unknownVersion
+	}
+
+	return version
+}
+
+// gpuOperatorVersion returns the version of the GPU operator CSV, when the GPU operator is installed.
+func gpuOperatorVersion() string {
+	return operatorVersion(nvidiagpu.NvidiaGPUNamespace, nvidiagpu.Package)
+}
+
+// operatorVersion returns the version of the CSV of the operator package installed in the namespace.
+func operatorVersion(namespace, packageName string) string {
+	if inittools.APIClient == nil {
+		return unknownVersion
+	}
+
+	csvBuilders, err := olm.ListClusterServiceVersion(inittools.APIClient, namespace)
+	if err != nil {
+		glog.V(100).Infof("Failed to list the CSVs of namespace %s: %v", namespace, err)
+
+		return unknownVersion
+	}
+
+	for _, csvBuilder := range csvBuilders {
+		if strings.HasPrefix(csvBuilder.Object.Name, packageName) {
+			return csvBuilder.Object.Spec.Version.String()
+		}
+	}
+
+	return unknownVersion
+}
@@ -0,0 +1,168 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+)
+
+// TestCaseIDLabelPrefix prefixes the Ginkgo labels declaring the test management test case ID of a spec.
+const TestCaseIDLabelPrefix = "test-case-id:"
+
+// polarionTestSuites is the root of the Polarion XUnit importer results file.
+type polarionTestSuites struct {
+	XMLName    xml.Name           `xml:"testsuites"`
+	Properties []polarionProperty `xml:"properties>property"`
+	TestSuite  polarionTestSuite  `xml:"testsuite"`
+}
+
+type polarionTestSuite struct {
+	Name      string             `xml:"name,attr"`
+	Tests     int                `xml:"tests,attr"`
+	Failures  int                `xml:"failures,attr"`
+	Errors    int                `xml:"errors,attr"`
+	Skipped   int                `xml:"skipped,attr"`
+	Time      float64            `xml:"time,attr"`
+	TestCases []polarionTestCase `xml:"testcase"`
+}
+
+type polarionTestCase struct {
+	Name       string             `xml:"name,attr"`
+	ClassName  string             `xml:"classname,attr"`
+	Time       float64            `xml:"time,attr"`
+	Properties []polarionProperty `xml:"properties>property"`
+	Failure    *polarionFailure   `xml:"failure,omitempty"`
+	Skipped    *struct{}          `xml:"skipped,omitempty"`
+}
+
+type polarionFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Details string `xml:",chardata"`
+}
+
+type polarionProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// TestCaseID returns the Ginkgo label declaring the test management test case ID of a spec, e.g.
+// It("...", Label(reporter.TestCaseID("NVIDIACI-101")), func() {...}).
+func TestCaseID(id string) string {
+	return TestCaseIDLabelPrefix + id
+}
+
+// WritePolarionReport writes the results of the specs declaring test case IDs in the Polarion XUnit importer format
+// next to the JUnit report, when a Polarion project is set in the general config. It is meant to be called from a
+// ReportAfterSuite node of the suite.
+func WritePolarionReport(report types.Report, testSuite string) {
+	if inittools.GeneralConfig.PolarionProjectID == "" {
+		return
+	}
+
+	reportPath := inittools.GeneralConfig.GetPolarionReportPath(testSuite)
+
+	content, err := xml.MarshalIndent(newPolarionReport(report), "", "  ")
+	if err != nil {
+		glog.Errorf("Failed to encode Polarion report %s: %v", reportPath, err)
+
+		return
+	}
+
+	if err := os.WriteFile(reportPath, append([]byte(xml.Header), content...), 0666); err != nil {
+		glog.Errorf("Failed to write Polarion report %s: %v", reportPath, err)
+
+		return
+	}
+
+	IndexArtifact(PolarionReportArtifact, reportPath, "", "")
+	glog.V(100).Infof("Polarion report written to %s", reportPath)
+}
+
+func newPolarionReport(report types.Report) *polarionTestSuites {
+	config := inittools.GeneralConfig
+
+	testRunTitle := config.PolarionTestRunTitle
+	if testRunTitle == "" {
+		testRunTitle = fmt.Sprintf("%s %s", report.SuiteDescription, report.StartTime.Format("2006-01-02 15:04:05"))
+	}
+
+	results := &polarionTestSuites{
+		Properties: []polarionProperty{
+			{Name: "polarion-project-id", Value: config.PolarionProjectID},
+			{Name: "polarion-testrun-title", Value: testRunTitle},
+			{Name: "polarion-lookup-method", Value: "id"},
+		},
+		TestSuite: polarionTestSuite{Name: report.SuiteDescription, Time: report.RunTime.Seconds()},
+	}
+
+	if config.PolarionTestRunID != "" {
+		results.Properties = append(results.Properties,
+			polarionProperty{Name: "polarion-testrun-id", Value: config.PolarionTestRunID})
+	}
+
+	for _, specReport := range report.SpecReports {
+		if specReport.LeafNodeType != types.NodeTypeIt {
+			continue
+		}
+
+		// A spec covering several test cases reports its outcome to each of them.
+		for _, testCaseID := range specTestCaseIDs(specReport) {
+			results.TestSuite.TestCases = append(results.TestSuite.TestCases,
+				newPolarionTestCase(specReport, testCaseID))
+		}
+	}
+
+	for _, testCase := range results.TestSuite.TestCases {
+		results.TestSuite.Tests++
+
+		switch {
+		case testCase.Failure != nil:
+			results.TestSuite.Failures++
+		case testCase.Skipped != nil:
+			results.TestSuite.Skipped++
+		}
+	}
+
+	return results
+}
+
+func newPolarionTestCase(specReport types.SpecReport, testCaseID string) polarionTestCase {
+	testCase := polarionTestCase{
+		Name:       specReport.FullText(),
+		ClassName:  specReport.LeafNodeLocation.FileName,
+		Time:       specReport.RunTime.Seconds(),
+		Properties: []polarionProperty{{Name: "polarion-testcase-id", Value: testCaseID}},
+	}
+
+	switch {
+	case specReport.Failed():
+		testCase.Failure = &polarionFailure{
+			Message: specReport.FailureMessage(),
+			Type:    specReport.State.String(),
+			Details: specReport.FailureLocation().String(),
+		}
+	case specReport.State != types.SpecStatePassed:
+		testCase.Skipped = &struct{}{}
+	}
+
+	return testCase
+}
+
+// specTestCaseIDs returns the test case IDs declared by the labels of the spec.
+func specTestCaseIDs(specReport types.SpecReport) []string {
+	var testCaseIDs []string
+
+	for _, label := range specReport.Labels() {
+		if testCaseID, found := strings.CutPrefix(label, TestCaseIDLabelPrefix); found && testCaseID != "" {
+			testCaseIDs = append(testCaseIDs, testCaseID)
+		}
+	}
+
+	return testCaseIDs
+}
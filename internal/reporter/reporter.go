@@ -1,37 +1,53 @@
 package reporter
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path"
 	"strings"
-	"time"
 
 	"github.com/openshift-kni/k8sreporter"
 
 	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/ginkgo/v2/types"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nsisolation"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
-var (
-	pathToPodExecLogs = "/tmp/pod_exec_logs.log"
+const (
+	// podExecLogsFName is the file name of the pod exec logs in the report folder of a failed test case.
+	podExecLogsFName = "pod_exec_logs.log"
+	podExecLogsDir   = "/tmp"
 )
 
+// podExecLogsPath returns the path of the pod exec logs of the ginkgo parallel process, the processes running the
+// specs in parallel each collecting the logs of their own specs.
+func podExecLogsPath() string {
+	if process := ginkgo.GinkgoParallelProcess(); process > 1 {
+		return path.Join(podExecLogsDir, fmt.Sprintf("pod_exec_logs-%d.log", process))
+	}
+
+	return path.Join(podExecLogsDir, podExecLogsFName)
+}
+
 func newReporter(
 	reportPath string,
 	namespacesToDump map[string]string,
 	apiScheme func(scheme *runtime.Scheme) error,
 	cRDs []k8sreporter.CRData) (*k8sreporter.KubernetesReporter, error) {
+	isolatedNamespaces := map[string]bool{}
+	for _, name := range nsisolation.Active() {
+		isolatedNamespaces[name] = true
+	}
+
 	nsToDumpFilter := func(ns string) bool {
 		_, found := namespacesToDump[ns]
 
-		return found
+		return found || isolatedNamespaces[ns]
 	}
 
 	if _, err := os.Stat(reportPath); os.IsNotExist(err) {
@@ -70,25 +86,59 @@ func ReportIfFailed(
 			glog.Fatalf("Failed to create log reporter due to %s", err)
 		}
 
-		tcReportFolderName := strings.ReplaceAll(report.FullText(), " ", "_")
+		tcReportFolderName := reportFolderName(report)
 		reporter.Dump(report.RunTime, tcReportFolderName)
+		collectKernelLogs(path.Join(dumpDir, tcReportFolderName), report)
 
-		_, podExecLogsFName := path.Split(pathToPodExecLogs)
-
-		err = moveFile(
-			pathToPodExecLogs, path.Join(inittools.GeneralConfig.ReportsDirAbsPath, tcReportFolderName, podExecLogsFName))
+		err = moveFile(podExecLogsPath(),
+			path.Join(inittools.GeneralConfig.ReportsDirAbsPath, tcReportFolderName, podExecLogsFName))
 
 		if err != nil {
-			glog.Fatalf("Failed to move pod exec logs %s to report folder: %s", pathToPodExecLogs, err)
+			glog.Fatalf("Failed to move pod exec logs %s to report folder: %s", podExecLogsPath(), err)
 		}
+
+		clusterDumpPath := path.Join(dumpDir, tcReportFolderName)
+		podExecLogsReportPath := path.Join(inittools.GeneralConfig.ReportsDirAbsPath, tcReportFolderName,
+			podExecLogsFName)
+
+		IndexArtifact(ClusterDumpArtifact, clusterDumpPath, report.FullText(), "")
+		IndexArtifact(PodExecLogsArtifact, podExecLogsReportPath, report.FullText(), "")
+		RecordArtifact(clusterDumpPath)
+		RecordArtifact(podExecLogsReportPath)
 	}
 
-	err := removeFile(pathToPodExecLogs)
+	err := removeFile(podExecLogsPath())
 	if err != nil {
 		glog.Fatalf(err.Error())
 	}
 }
 
+// AppendPodExecLog appends diagnostics collected from the cluster pods to the pod exec logs, which ReportIfFailed
+// moves to the report folder of the failed test case.
+func AppendPodExecLog(diagnostics string) error {
+	podExecLogs := podExecLogsPath()
+
+	logFile, err := os.OpenFile(podExecLogs, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open pod exec logs %s: %w", podExecLogs, err)
+	}
+
+	defer func() {
+		_ = logFile.Close()
+	}()
+
+	if _, err := logFile.WriteString(diagnostics); err != nil {
+		return fmt.Errorf("failed to write pod exec logs %s: %w", podExecLogs, err)
+	}
+
+	return nil
+}
+
+// reportFolderName returns the name of the folder where the cluster state is dumped when the test case fails.
+func reportFolderName(report types.SpecReport) string {
+	return strings.ReplaceAll(report.FullText(), " ", "_")
+}
+
 func moveFile(sourcePath, destPath string) error {
 	_, err := os.Stat(sourcePath)
 	if errors.Is(err, os.ErrNotExist) {
@@ -133,26 +183,3 @@ func removeFile(fPath string) error {
 
 	return nil
 }
-
-func RunMustGather(artifactDir, mustGatherScriptPath string, timeout time.Duration) error {
-	if artifactDir == "" {
-		return fmt.Errorf("artifact directory cannot be empty")
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, mustGatherScriptPath)
-	cmd.Env = append(os.Environ(), fmt.Sprintf("ARTIFACT_DIR=%s", artifactDir))
-	output, err := cmd.CombinedOutput()
-	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-		glog.Errorf("%s script timed out: %v", mustGatherScriptPath, ctx.Err())
-		return fmt.Errorf("must gather script timed out: %w", ctx.Err())
-	}
-	if err != nil {
-		glog.Errorf("Error running %s script: %v\nOutput: %s", mustGatherScriptPath, err, output)
-		return fmt.Errorf("error running must gather script: %w", err)
-	}
-	glog.V(100).Infof("must gather script output: %s", output)
-	return nil
-}
@@ -0,0 +1,389 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+)
+
+const (
+	reportPortalTimeout = 30 * time.Second
+
+	reportPortalStatusPassed  = "passed"
+	reportPortalStatusFailed  = "failed"
+	reportPortalStatusSkipped = "skipped"
+
+	reportPortalLevelInfo  = "info"
+	reportPortalLevelError = "error"
+)
+
+// reportPortal streams the results of the running suite to ReportPortal: a launch is started per suite and a
+// test item per spec.
+type reportPortal struct {
+	client     *http.Client
+	apiURL     string
+	token      string
+	launchUUID string
+	itemUUIDs  map[string]string
+}
+
+// reportPortalAttribute is a key value attribute of a ReportPortal launch or item.
+type reportPortalAttribute struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+// reportPortalLog is a log entry of a ReportPortal launch or item, optionally carrying a file attachment.
+type reportPortalLog struct {
+	LaunchUUID string            `json:"launchUuid"`
+	ItemUUID   string            `json:"itemUuid,omitempty"`
+	Time       int64             `json:"time"`
+	Level      string            `json:"level"`
+	Message    string            `json:"message"`
+	File       *reportPortalFile `json:"file,omitempty"`
+}
+
+// reportPortalFile names the multipart part holding the attachment of a log entry.
+type reportPortalFile struct {
+	Name string `json:"name"`
+}
+
+var activeReportPortal *reportPortal
+
+// StartReportPortalLaunch starts the ReportPortal launch of the suite when ReportPortal is configured in the
+// general config. It is meant to be called from a ReportBeforeSuite node of the suite.
+func StartReportPortalLaunch(report types.Report) {
+	config := inittools.GeneralConfig
+	if config.ReportPortalURL == "" || config.ReportPortalProject == "" || config.ReportPortalToken == "" {
+		return
+	}
+
+	portal := &reportPortal{
+		client: &http.Client{Timeout: reportPortalTimeout},
+		apiURL: fmt.Sprintf("%s/api/v1/%s", strings.TrimSuffix(config.ReportPortalURL, "/"),
+			config.ReportPortalProject),
+		token:     config.ReportPortalToken,
+		itemUUIDs: map[string]string{},
+	}
+
+	attributes := append(parseReportPortalAttributes(config.ReportPortalAttributes),
+		reportPortalAttribute{Key: "clusterVersion", Value: clusterVersion()},
+		reportPortalAttribute{Key: "gpuOperatorVersion", Value: gpuOperatorVersion()})
+
+	launch := map[string]interface{}{
+		"name":        report.SuiteDescription,
+		"description": report.SuitePath,
+		"startTime":   reportPortalTime(report.StartTime),
+		"attributes":  attributes,
+		"mode":        "DEFAULT",
+	}
+
+	launchUUID, err := portal.start("launch", launch)
+	if err != nil {
+		glog.Errorf("Failed to start ReportPortal launch of suite %s: %v", report.SuiteDescription, err)
+
+		return
+	}
+
+	portal.launchUUID = launchUUID
+	activeReportPortal = portal
+
+	glog.V(100).Infof("Started ReportPortal launch %s of suite %s", launchUUID, report.SuiteDescription)
+}
+
+// StartReportPortalItem starts the ReportPortal test item of the spec. It is meant to be called from a
+// ReportBeforeEach node of the suite and does nothing when no launch was started.
+func StartReportPortalItem(specReport types.SpecReport) {
+	if activeReportPortal == nil {
+		return
+	}
+
+	var attributes []reportPortalAttribute
+	for _, label := range specReport.Labels() {
+		attributes = append(attributes, reportPortalAttribute{Value: label})
+	}
+
+	item := map[string]interface{}{
+		"name":        specReport.FullText(),
+		"type":        "TEST",
+		"launchUuid":  activeReportPortal.launchUUID,
+		"startTime":   reportPortalTime(specReport.StartTime),
+		"codeRef":     specReport.LeafNodeLocation.String(),
+		"description": strings.Join(specReport.ContainerHierarchyTexts, " / "),
+		"attributes":  attributes,
+	}
+
+	itemUUID, err := activeReportPortal.start("item", item)
+	if err != nil {
+		glog.Errorf("Failed to start ReportPortal item of spec %s: %v", specReport.FullText(), err)
+
+		return
+	}
+
+	activeReportPortal.itemUUIDs[specReport.FullText()] = itemUUID
+}
+
+// FinishReportPortalItem finishes the ReportPortal test item of the spec, attaching the failure, the GinkgoWriter
+// output and the pod exec logs dumped by ReportIfFailed. It is meant to be called from a ReportAfterEach node of
+// the suite.
+func FinishReportPortalItem(specReport types.SpecReport) {
+	if activeReportPortal == nil {
+		return
+	}
+
+	itemUUID, found := activeReportPortal.itemUUIDs[specReport.FullText()]
+	if !found {
+		return
+	}
+
+	delete(activeReportPortal.itemUUIDs, specReport.FullText())
+
+	if output := specReport.CombinedOutput(); output != "" {
+		activeReportPortal.log(itemUUID, reportPortalLevelInfo, output, "")
+	}
+
+	if specReport.Failed() {
+		activeReportPortal.log(itemUUID, reportPortalLevelError, fmt.Sprintf("%s\n%s",
+			specReport.FailureMessage(), specReport.FailureLocation()), "")
+
+		podExecLogs := filepath.Join(inittools.GeneralConfig.ReportsDirAbsPath, reportFolderName(specReport),
+			podExecLogsFName)
+
+		if _, err := os.Stat(podExecLogs); err == nil {
+			activeReportPortal.log(itemUUID, reportPortalLevelError, podExecLogsFName, podExecLogs)
+		}
+	}
+
+	finish := map[string]interface{}{
+		"launchUuid": activeReportPortal.launchUUID,
+		"endTime":    reportPortalTime(specReport.EndTime),
+		"status":     reportPortalStatus(specReport.State),
+	}
+
+	if err := activeReportPortal.finish("item/"+itemUUID, finish); err != nil {
+		glog.Errorf("Failed to finish ReportPortal item of spec %s: %v", specReport.FullText(), err)
+	}
+}
+
+// FinishReportPortalLaunch finishes the ReportPortal launch of the suite, logging the failures of the suite level
+// nodes, e.g. BeforeSuite. It is meant to be called from a ReportAfterSuite node of the suite.
+func FinishReportPortalLaunch(report types.Report) {
+	if activeReportPortal == nil {
+		return
+	}
+
+	for _, specReport := range report.SpecReports {
+		if specReport.LeafNodeType != types.NodeTypeIt && specReport.Failed() {
+			activeReportPortal.log("", reportPortalLevelError, fmt.Sprintf("%s failed: %s\n%s",
+				specReport.LeafNodeType, specReport.FailureMessage(), specReport.FailureLocation()), "")
+		}
+	}
+
+	finish := map[string]interface{}{
+		"endTime": reportPortalTime(report.EndTime),
+	}
+
+	if err := activeReportPortal.finish(fmt.Sprintf("launch/%s/finish", activeReportPortal.launchUUID),
+		finish); err != nil {
+		glog.Errorf("Failed to finish ReportPortal launch of suite %s: %v", report.SuiteDescription, err)
+	}
+
+	activeReportPortal = nil
+}
+
+// start creates a launch or an item and returns its UUID.
+func (portal *reportPortal) start(resource string, body interface{}) (string, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", resource, err)
+	}
+
+	response, err := portal.do(http.MethodPost, resource, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+
+	if err := json.Unmarshal(response, &created); err != nil {
+		return "", fmt.Errorf("failed to decode %s response: %w", resource, err)
+	}
+
+	if created.ID == "" {
+		return "", fmt.Errorf("ReportPortal returned no %s id", resource)
+	}
+
+	return created.ID, nil
+}
+
+// finish finishes a launch or an item.
+func (portal *reportPortal) finish(resource string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", resource, err)
+	}
+
+	_, err = portal.do(http.MethodPut, resource, "application/json", bytes.NewReader(payload))
+
+	return err
+}
+
+// log adds a log entry to the item, or to the launch when itemUUID is empty. The file at attachmentPath, when
+// given, is uploaded as the attachment of the entry.
+func (portal *reportPortal) log(itemUUID, level, message, attachmentPath string) {
+	entry := reportPortalLog{
+		LaunchUUID: portal.launchUUID,
+		ItemUUID:   itemUUID,
+		Time:       time.Now().UnixMilli(),
+		Level:      level,
+		Message:    message,
+	}
+
+	if err := portal.sendLog(entry, attachmentPath); err != nil {
+		glog.Errorf("Failed to send ReportPortal log: %v", err)
+	}
+}
+
+func (portal *reportPortal) sendLog(entry reportPortalLog, attachmentPath string) error {
+	if attachmentPath == "" {
+		payload, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode log: %w", err)
+		}
+
+		_, err = portal.do(http.MethodPost, "log", "application/json", bytes.NewReader(payload))
+
+		return err
+	}
+
+	attachment, err := os.ReadFile(attachmentPath)
+	if err != nil {
+		return fmt.Errorf("failed to read attachment %s: %w", attachmentPath, err)
+	}
+
+	entry.File = &reportPortalFile{Name: filepath.Base(attachmentPath)}
+
+	payload, err := json.Marshal([]reportPortalLog{entry})
+	if err != nil {
+		return fmt.Errorf("failed to encode log: %w", err)
+	}
+
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+
+	jsonHeader := textproto.MIMEHeader{}
+	jsonHeader.Set("Content-Disposition", `form-data; name="json_request_part"`)
+	jsonHeader.Set("Content-Type", "application/json")
+
+	jsonPart, err := writer.CreatePart(jsonHeader)
+	if err != nil {
+		return fmt.Errorf("failed to create log request part: %w", err)
+	}
+
+	if _, err := jsonPart.Write(payload); err != nil {
+		return fmt.Errorf("failed to write log request part: %w", err)
+	}
+
+	filePart, err := writer.CreateFormFile("file", entry.File.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment part: %w", err)
+	}
+
+	if _, err := filePart.Write(attachment); err != nil {
+		return fmt.Errorf("failed to write attachment part: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart body: %w", err)
+	}
+
+	_, err = portal.do(http.MethodPost, "log", writer.FormDataContentType(), &body)
+
+	return err
+}
+
+// do sends the request to the project API and returns the response body, failing on non 2xx statuses.
+func (portal *reportPortal) do(method, resource, contentType string, body io.Reader) ([]byte, error) {
+	request, err := http.NewRequest(method, fmt.Sprintf("%s/%s", portal.apiURL, resource), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", resource, err)
+	}
+
+	request.Header.Set("Authorization", "Bearer "+portal.token)
+	request.Header.Set("Content-Type", contentType)
+
+	response, err := portal.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", resource, err)
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", resource, err)
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("ReportPortal %s request returned %s: %s", resource, response.Status, responseBody)
+	}
+
+	return responseBody, nil
+}
+
+// parseReportPortalAttributes parses comma separated key:value attributes, a value without key being a tag.
+func parseReportPortalAttributes(attributes string) []reportPortalAttribute {
+	var parsed []reportPortalAttribute
+
+	for _, attribute := range strings.Split(attributes, ",") {
+		attribute = strings.TrimSpace(attribute)
+		if attribute == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(attribute, ":")
+		if !found {
+			parsed = append(parsed, reportPortalAttribute{Value: key})
+
+			continue
+		}
+
+		parsed = append(parsed, reportPortalAttribute{Key: key, Value: value})
+	}
+
+	return parsed
+}
+
+// reportPortalTime returns the timestamp in milliseconds, falling back to now for unset times.
+func reportPortalTime(timestamp time.Time) int64 {
+	return eventTime(timestamp).UnixMilli()
+}
+
+// reportPortalStatus maps the state of a spec to a ReportPortal item status.
+func reportPortalStatus(state types.SpecState) string {
+	switch {
+	case state == types.SpecStatePassed:
+		return reportPortalStatusPassed
+	case state.Is(types.SpecStateFailureStates):
+		return reportPortalStatusFailed
+	default:
+		return reportPortalStatusSkipped
+	}
+}
@@ -0,0 +1,139 @@
+package reporter
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2/reporters"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/junit"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/results"
+)
+
+// HistoricallyFlakyProperty is the JUnit property naming a spec of the suite that was flaky in its latest runs.
+const HistoricallyFlakyProperty = "HistoricallyFlaky"
+
+// RecordResults writes the outcome and duration of the specs of the suite to the results store of the general
+// config, when set, keyed by the cluster and the OpenShift and GPU operator versions. The specs that were flaky in
+// their latest runs with the same key are marked in the JUnit report beforehand, with a HistoricallyFlaky property
+// of the test suite and their history in their system-err. It is meant to be called from a ReportAfterSuite node of
+// the suite, after WriteJUnitReport.
+func RecordResults(report types.Report, testSuite string) {
+	config := inittools.GeneralConfig
+	if config.ResultsStore == "" {
+		return
+	}
+
+	store := results.NewStore(config.ResultsStore, config.ResultsStoreToken)
+	key := results.Key{
+		Cluster:            resultsCluster(),
+		OpenShiftVersion:   clusterVersion(),
+		GPUOperatorVersion: gpuOperatorVersion(),
+	}
+
+	history, err := store.Query(report.SuiteDescription, key)
+	if err != nil {
+		glog.Errorf("Failed to query the results of suite %s: %v", report.SuiteDescription, err)
+	} else {
+		reportPath := config.GetJunitReportPath(testSuite)
+		if err := markHistoricallyFlakySpecs(reportPath, report, results.Histories(history,
+			config.ResultsHistory)); err != nil {
+			glog.Errorf("Failed to mark historically flaky specs in JUnit report %s: %v", reportPath, err)
+		}
+	}
+
+	var specResults []results.Result
+
+	for _, specReport := range report.SpecReports {
+		if specReport.LeafNodeType != types.NodeTypeIt {
+			continue
+		}
+
+		specResults = append(specResults, results.Result{
+			Key:      key,
+			Suite:    report.SuiteDescription,
+			Spec:     specReport.FullText(),
+			Outcome:  resultOutcome(specReport.State),
+			Attempts: specReport.NumAttempts,
+			Duration: specReport.RunTime.Seconds(),
+			Time:     eventTime(specReport.EndTime),
+		})
+	}
+
+	if err := store.Write(specResults); err != nil {
+		glog.Errorf("Failed to write the results of suite %s: %v", report.SuiteDescription, err)
+
+		return
+	}
+
+	glog.V(100).Infof("%d spec results of suite %s written to %s", len(specResults), report.SuiteDescription,
+		config.ResultsStore)
+}
+
+// markHistoricallyFlakySpecs adds a HistoricallyFlaky property per historically flaky spec to the test suite of the
+// report and prepends their history to the system-err of their test cases. The test cases of the report follow the
+// order of the spec reports.
+func markHistoricallyFlakySpecs(reportPath string, report types.Report, histories map[string]results.History) error {
+	var flakySpecs []int
+
+	for index, specReport := range report.SpecReports {
+		if history, found := histories[specReport.FullText()]; found && history.IsFlaky() {
+			flakySpecs = append(flakySpecs, index)
+		}
+	}
+
+	if len(flakySpecs) == 0 {
+		return nil
+	}
+
+	junitReport, err := junit.Read(reportPath)
+	if err != nil {
+		return err
+	}
+
+	if len(junitReport.TestSuites) != 1 || len(junitReport.TestSuites[0].TestCases) != len(report.SpecReports) {
+		return fmt.Errorf("the report test cases do not match the %d spec reports", len(report.SpecReports))
+	}
+
+	testSuite := &junitReport.TestSuites[0]
+
+	for _, index := range flakySpecs {
+		history := histories[report.SpecReports[index].FullText()]
+
+		testSuite.Properties.Properties = append(testSuite.Properties.Properties,
+			reporters.JUnitProperty{Name: HistoricallyFlakyProperty, Value: history.Spec})
+		testSuite.TestCases[index].SystemErr = fmt.Sprintf("Historically flaky: %s\n%s", history,
+			testSuite.TestCases[index].SystemErr)
+
+		glog.Warningf("Spec %s is historically flaky: %s", history.Spec, history)
+	}
+
+	return junit.Write(reportPath, junitReport)
+}
+
+// resultsCluster returns the cluster of the results key, the results cluster of the general config, defaulting to
+// the API server of the cluster.
+func resultsCluster() string {
+	if inittools.GeneralConfig.ResultsCluster != "" {
+		return inittools.GeneralConfig.ResultsCluster
+	}
+
+	if inittools.APIClient == nil || inittools.APIClient.Config == nil {
+		return unknownVersion
+	}
+
+	return inittools.APIClient.Config.Host
+}
+
+// resultOutcome maps the state of a spec to the outcome of its result.
+func resultOutcome(state types.SpecState) string {
+	switch {
+	case state == types.SpecStatePassed:
+		return results.OutcomePassed
+	case state.Is(types.SpecStateFailureStates):
+		return results.OutcomeFailed
+	default:
+		return results.OutcomeSkipped
+	}
+}
@@ -0,0 +1,85 @@
+package reporter
+
+import (
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/prereq"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RegisterSuiteHooks registers the reporter nodes of the suite of the test file: the dump of the namespaces and CRDs,
+// and the must-gather of the collections, of the failed specs, the time budget, GPU memory, leak and interrupt
+// checks, the operand log streaming and the suite reports. It is meant to be called once from a top-level var
+// declaration of the suite file, e.g. var _ = reporter.RegisterSuiteHooks(currentFile, ...), so that a new reporter
+// is wired in all the suites from here.
+func RegisterSuiteHooks(
+	testFile string,
+	namespacesToDump map[string]string,
+	crdsToDump []k8sreporter.CRData,
+	apiScheme func(scheme *runtime.Scheme) error,
+	mustGatherCollections ...MustGatherCollection) bool {
+	ginkgo.JustAfterEach(func() {
+		specReport := ginkgo.CurrentSpecReport()
+		ReportIfFailed(specReport, testFile, namespacesToDump, crdsToDump, apiScheme)
+
+		if len(mustGatherCollections) > 0 {
+			MustGatherIfFailed(specReport, mustGatherCollections...)
+		}
+	})
+
+	ginkgo.JustAfterEach(func() {
+		CheckTimeBudget(ginkgo.CurrentSpecReport())
+	})
+
+	ginkgo.BeforeEach(func() {
+		CheckSpecGPUMemory()
+	})
+
+	ginkgo.BeforeEach(func() {
+		prereq.SkipUnchecked()
+	})
+
+	ginkgo.BeforeSuite(func() {
+		HandleInterrupts()
+		StartGPUMemoryCheck()
+		StartOperandLogStreaming(testFile)
+	})
+
+	ginkgo.AfterSuite(func() {
+		StopOperandLogStreaming()
+		CheckLeaks()
+		CheckGPUMemoryLeaks()
+	})
+
+	ginkgo.ReportBeforeSuite(func(report types.Report) {
+		LogSuiteStarted(report)
+		StartReportPortalLaunch(report)
+	})
+
+	ginkgo.ReportBeforeEach(func(specReport types.SpecReport) {
+		LogSpecStarted(specReport)
+		StartReportPortalItem(specReport)
+	})
+
+	ginkgo.ReportAfterEach(func(specReport types.SpecReport) {
+		LogSpecFinished(specReport)
+		FinishReportPortalItem(specReport)
+	})
+
+	ginkgo.ReportAfterSuite("Suite reports", func(report types.Report) {
+		WaitForInterruptCleanup(report)
+		CheckLabels(report)
+		WriteJUnitReport(report, testFile)
+		RecordResults(report, testFile)
+		WriteHTMLReport(report, testFile)
+		WriteTimingReport(report, testFile)
+		WritePolarionReport(report, testFile)
+		NotifyIfFailed(report)
+		FileJiraIssues(report)
+		LogSuiteFinished(report)
+		FinishReportPortalLaunch(report)
+	})
+
+	return true
+}
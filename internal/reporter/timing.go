@@ -0,0 +1,200 @@
+package reporter
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+)
+
+const (
+	// TimeBudgetLabelPrefix prefixes the Ginkgo labels declaring the time budget of a spec.
+	TimeBudgetLabelPrefix = "time-budget:"
+	// TimeBudgetActionFail fails the specs exceeding their time budget.
+	TimeBudgetActionFail = "fail"
+	// TimeBudgetActionWarn reports the specs exceeding their time budget without failing them.
+	TimeBudgetActionWarn = "warn"
+	// TimeBudgetReportEntryName names the report entries of the specs exceeding their time budget.
+	TimeBudgetReportEntryName = "Time budget exceeded"
+	// MetricReportEntryPrefix prefixes the names of the report entries of the spec metrics listed in the timing report.
+	MetricReportEntryPrefix = "metric:"
+)
+
+// TimeBudget returns the Ginkgo label declaring the time budget of a spec, e.g.
+// It("...", Label(reporter.TimeBudget(20*time.Minute)), func() {...}).
+func TimeBudget(budget time.Duration) string {
+	return TimeBudgetLabelPrefix + budget.String()
+}
+
+// RecordMetric records a measurement of the current spec, e.g. a latency or an error count, as a report entry listed
+// with the spec in the timing report.
+func RecordMetric(name string, value any) {
+	glog.V(100).Infof("Metric %s: %v", name, value)
+
+	ginkgo.AddReportEntry(MetricReportEntryPrefix+name, fmt.Sprint(value))
+}
+
+// CheckTimeBudget fails the current spec, or records a warning report entry, depending on the time budget action of
+// the general config, when the spec ran longer than the time budget declared by its labels. It is meant to be
+// called from a JustAfterEach node of the suite, specs that already failed being left untouched.
+func CheckTimeBudget(specReport types.SpecReport) {
+	budget, found := specTimeBudget(specReport)
+	if !found || specReport.Failed() {
+		return
+	}
+
+	elapsed := time.Since(specReport.StartTime)
+	if elapsed <= budget {
+		return
+	}
+
+	message := fmt.Sprintf("spec ran for %s, exceeding its %s time budget", elapsed.Round(time.Second), budget)
+
+	if inittools.GeneralConfig.TimeBudgetAction == TimeBudgetActionFail {
+		ginkgo.Fail(message)
+	}
+
+	glog.Warningf("%s: %s", specReport.FullText(), message)
+	ginkgo.AddReportEntry(TimeBudgetReportEntryName, message)
+}
+
+// WriteTimingReport writes the durations of the specs of the suite, longest first, with their time budgets next to
+// the JUnit report, when the timing report is enabled in the general config. It is meant to be called from a
+// ReportAfterSuite node of the suite.
+func WriteTimingReport(report types.Report, testSuite string) {
+	if !inittools.GeneralConfig.TimingReport {
+		return
+	}
+
+	reportPath := inittools.GeneralConfig.GetTimingReportPath(testSuite)
+
+	var specReports []types.SpecReport
+
+	for _, specReport := range report.SpecReports {
+		if specReport.State.Is(types.SpecStateSkipped | types.SpecStatePending) {
+			continue
+		}
+
+		specReports = append(specReports, specReport)
+	}
+
+	sort.SliceStable(specReports, func(i, j int) bool {
+		return specReports[i].RunTime > specReports[j].RunTime
+	})
+
+	var content strings.Builder
+
+	fmt.Fprintf(&content, "%s ran for %s\n\n", report.SuiteDescription, report.RunTime.Round(time.Second))
+
+	writer := tabwriter.NewWriter(&content, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "DURATION\tSHARE\tBUDGET\tSTATE\tSPEC")
+
+	for _, specReport := range specReports {
+		budget := "-"
+		if specBudget, found := specTimeBudget(specReport); found {
+			budget = specBudget.String()
+			if specReport.RunTime > specBudget {
+				budget += " (exceeded)"
+			}
+		}
+
+		share := 0.0
+		if report.RunTime > 0 {
+			share = 100 * specReport.RunTime.Seconds() / report.RunTime.Seconds()
+		}
+
+		text := specReport.FullText()
+		if text == "" {
+			text = fmt.Sprintf("[%s]", specReport.LeafNodeType)
+		}
+
+		fmt.Fprintf(writer, "%s\t%.1f%%\t%s\t%s\t%s\n", specReport.RunTime.Round(time.Second), share, budget,
+			specReport.State, text)
+	}
+
+	if err := writer.Flush(); err != nil {
+		glog.Errorf("Failed to format timing report %s: %v", reportPath, err)
+
+		return
+	}
+
+	writeMetrics(&content, specReports)
+
+	if err := os.WriteFile(reportPath, []byte(content.String()), 0666); err != nil {
+		glog.Errorf("Failed to write timing report %s: %v", reportPath, err)
+
+		return
+	}
+
+	IndexArtifact(TimingReportArtifact, reportPath, "", "")
+	glog.V(100).Infof("Timing report written to %s", reportPath)
+}
+
+// writeMetrics appends the metrics recorded by the specs, in the order of the specs, to the timing report content.
+func writeMetrics(content *strings.Builder, specReports []types.SpecReport) {
+	var metrics strings.Builder
+
+	writer := tabwriter.NewWriter(&metrics, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "METRIC\tVALUE\tSPEC")
+
+	found := false
+
+	for _, specReport := range specReports {
+		for _, entry := range specReport.ReportEntries {
+			name, isMetric := strings.CutPrefix(entry.Name, MetricReportEntryPrefix)
+			if !isMetric {
+				continue
+			}
+
+			found = true
+
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", name, entry.StringRepresentation(), specReport.FullText())
+		}
+	}
+
+	if !found {
+		return
+	}
+
+	if err := writer.Flush(); err != nil {
+		glog.Errorf("Failed to format the metrics of the timing report: %v", err)
+
+		return
+	}
+
+	content.WriteString("\n")
+	content.WriteString(metrics.String())
+}
+
+// specTimeBudget returns the time budget declared by the labels of the spec, the innermost label winning.
+func specTimeBudget(specReport types.SpecReport) (time.Duration, bool) {
+	var (
+		budget time.Duration
+		found  bool
+	)
+
+	for _, label := range specReport.Labels() {
+		value, isBudget := strings.CutPrefix(label, TimeBudgetLabelPrefix)
+		if !isBudget {
+			continue
+		}
+
+		parsedBudget, err := time.ParseDuration(value)
+		if err != nil {
+			glog.Errorf("Invalid time budget label %q of spec %s: %v", label, specReport.FullText(), err)
+
+			continue
+		}
+
+		budget, found = parsedBudget, true
+	}
+
+	return budget, found
+}
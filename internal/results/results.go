@@ -0,0 +1,267 @@
+package results
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// OutcomePassed is the outcome of the specs that passed, after retries or not.
+	OutcomePassed = "passed"
+	// OutcomeFailed is the outcome of the specs that failed, panicked, timed out or were interrupted.
+	OutcomeFailed = "failed"
+	// OutcomeSkipped is the outcome of the skipped and pending specs, left out of their history.
+	OutcomeSkipped = "skipped"
+
+	httpTimeout = 30 * time.Second
+)
+
+// Key is the cluster and the OpenShift and GPU operator versions a spec ran on.
+type Key struct {
+	Cluster            string `json:"cluster"`
+	OpenShiftVersion   string `json:"openshiftVersion"`
+	GPUOperatorVersion string `json:"gpuOperatorVersion"`
+}
+
+// Result is the outcome and duration of a run of a spec.
+type Result struct {
+	Key
+	Suite    string    `json:"suite"`
+	Spec     string    `json:"spec"`
+	Outcome  string    `json:"outcome"`
+	Attempts int       `json:"attempts"`
+	Duration float64   `json:"duration"`
+	Time     time.Time `json:"time"`
+}
+
+// History is the outcome of the latest runs of a spec and their average duration.
+type History struct {
+	Spec     string
+	Runs     int
+	Failures int
+	Flaky    int
+	Duration time.Duration
+}
+
+// Store keeps the results of the spec runs.
+type Store interface {
+	// Write adds the results to the store.
+	Write(results []Result) error
+	// Query returns the results of the specs of the suite that ran with the key, in any order.
+	Query(suite string, key Key) ([]Result, error)
+}
+
+// NewStore returns the store at the location: an HTTP endpoint for http and https URLs, authenticated with the
+// token when set, and a JSON lines file otherwise.
+func NewStore(location, token string) Store {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return &httpStore{client: &http.Client{Timeout: httpTimeout}, url: location, token: token}
+	}
+
+	return &fileStore{path: location}
+}
+
+// Histories returns the history of every spec of the results, computed from its latest window runs that passed or
+// failed.
+func Histories(results []Result, window int) map[string]History {
+	sorted := append([]Result(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.After(sorted[j].Time)
+	})
+
+	histories := map[string]History{}
+
+	for _, result := range sorted {
+		history := histories[result.Spec]
+		if result.Outcome == OutcomeSkipped || history.Runs >= window {
+			continue
+		}
+
+		history.Spec = result.Spec
+		history.Duration += time.Duration(result.Duration * float64(time.Second))
+		history.Runs++
+
+		switch {
+		case result.Outcome == OutcomeFailed:
+			history.Failures++
+		case result.Attempts > 1:
+			history.Flaky++
+		}
+
+		histories[result.Spec] = history
+	}
+
+	for spec, history := range histories {
+		history.Duration /= time.Duration(history.Runs)
+		histories[spec] = history
+	}
+
+	return histories
+}
+
+// IsFlaky returns true when the spec both passed and failed in its latest runs, or passed after failed attempts.
+func (history History) IsFlaky() bool {
+	return history.Flaky > 0 || (history.Failures > 0 && history.Failures < history.Runs)
+}
+
+// String describes the outcome of the latest runs of the spec.
+func (history History) String() string {
+	return fmt.Sprintf("%d of %d recent runs failed, %d passed after retries, %s on average", history.Failures,
+		history.Runs, history.Flaky, history.Duration.Round(time.Second))
+}
+
+// fileStore keeps the results in a file, a JSON result per line.
+type fileStore struct {
+	path string
+}
+
+func (store *fileStore) Write(results []Result) error {
+	var content bytes.Buffer
+
+	encoder := json.NewEncoder(&content)
+
+	for _, result := range results {
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("failed to encode result of spec %s: %w", result.Spec, err)
+		}
+	}
+
+	resultsFile, err := os.OpenFile(store.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open results file %s: %w", store.path, err)
+	}
+
+	defer func() {
+		_ = resultsFile.Close()
+	}()
+
+	if _, err := resultsFile.Write(content.Bytes()); err != nil {
+		return fmt.Errorf("failed to write results file %s: %w", store.path, err)
+	}
+
+	return nil
+}
+
+func (store *fileStore) Query(suite string, key Key) ([]Result, error) {
+	resultsFile, err := os.Open(store.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file %s: %w", store.path, err)
+	}
+
+	defer func() {
+		_ = resultsFile.Close()
+	}()
+
+	var results []Result
+
+	scanner := bufio.NewScanner(resultsFile)
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		var result Result
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			return nil, fmt.Errorf("failed to decode results file %s: %w", store.path, err)
+		}
+
+		if result.Suite == suite && result.Key == key {
+			results = append(results, result)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read results file %s: %w", store.path, err)
+	}
+
+	return results, nil
+}
+
+// httpStore keeps the results behind an HTTP endpoint, the results being posted to the endpoint as a JSON array and
+// queried with a GET request of the endpoint, the suite and the key fields as query parameters.
+type httpStore struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+func (store *httpStore) Write(results []Result) error {
+	payload, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+
+	_, err = store.do(http.MethodPost, store.url, bytes.NewReader(payload))
+
+	return err
+}
+
+func (store *httpStore) Query(suite string, key Key) ([]Result, error) {
+	query := url.Values{}
+	query.Set("suite", suite)
+	query.Set("cluster", key.Cluster)
+	query.Set("openshiftVersion", key.OpenShiftVersion)
+	query.Set("gpuOperatorVersion", key.GPUOperatorVersion)
+
+	separator := "?"
+	if strings.Contains(store.url, "?") {
+		separator = "&"
+	}
+
+	response, err := store.do(http.MethodGet, store.url+separator+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	if err := json.Unmarshal(response, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode results response: %w", err)
+	}
+
+	return results, nil
+}
+
+// do sends the request to the endpoint and returns the response body, failing on non 2xx statuses.
+func (store *httpStore) do(method, requestURL string, body io.Reader) ([]byte, error) {
+	request, err := http.NewRequest(method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create results %s request: %w", method, err)
+	}
+
+	if store.token != "" {
+		request.Header.Set("Authorization", "Bearer "+store.token)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := store.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send results %s request: %w", method, err)
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results %s response: %w", method, err)
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("results %s request returned %s: %s", method, response.Status, responseBody)
+	}
+
+	return responseBody, nil
+}
@@ -0,0 +1,190 @@
+package scale
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/autoscaling"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// GPUResourceName is the extended resource of the GPUs advertised by the device plugin.
+	GPUResourceName = "nvidia.com/gpu"
+	// PodLabel labels the pods created by CreatePods.
+	PodLabel = "nvidia-ci/scale-workload"
+
+	// admissionErrorReason is the reason of the pods the kubelet rejects because the device plugin failed to allocate
+	// their devices.
+	admissionErrorReason = "UnexpectedAdmissionError"
+	// outOfGPUReason is the reason of the pods the kubelet rejects because the node has no GPU left, i.e. the device
+	// plugin advertises more GPUs than it can allocate.
+	outOfGPUReason = "OutOf" + GPUResourceName
+)
+
+// Result holds the measurements of a batch of pods run by CreatePods until completion.
+type Result struct {
+	// Pods is the number of pods of the batch.
+	Pods int
+	// SchedulingLatencies are the durations between the creation and the scheduling of the scheduled pods.
+	SchedulingLatencies []time.Duration
+	// DrainTime is the duration between the creation of the first pod and the completion of the last pod.
+	DrainTime time.Duration
+	// AllocationErrors are the messages of the pods rejected by the kubelet on GPU allocation, by pod name.
+	AllocationErrors map[string]string
+	// Failures are the messages of the other failed pods, by pod name.
+	Failures map[string]string
+}
+
+// GPUCapacity returns the number of GPUs allocatable on the nodes of the nodeSelector.
+func GPUCapacity(apiClient *clients.Settings, nodeSelector labels.Set) (int64, error) {
+	gpuNodes, err := nodes.List(apiClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the GPU nodes: %w", err)
+	}
+
+	var capacity int64
+
+	for _, gpuNode := range gpuNodes {
+		if allocatable, found := gpuNode.Object.Status.Allocatable[GPUResourceName]; found {
+			capacity += allocatable.Value()
+		}
+	}
+
+	return capacity, nil
+}
+
+// CreatePods creates count pods named after the prefix, each requesting a GPU on the nodes of the nodeSelector and
+// holding it for the duration.
+func CreatePods(apiClient *clients.Settings, prefix, namespace, image string, nodeSelector labels.Set, count int,
+	duration time.Duration) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating %d GPU pods in namespace '%s'", count, namespace)
+
+	for index := range count {
+		name := fmt.Sprintf("%s-%d", prefix, index)
+
+		_, err := autoscaling.NewWorkloadPod(apiClient, name, namespace, image, nodeSelector, duration).
+			WithLabel(PodLabel, prefix).
+			Create()
+		if err != nil {
+			return fmt.Errorf("failed to create pod %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// WaitPodsCompleted waits until all the pods created by CreatePods with the prefix succeeded or failed, and returns
+// the measurements of the batch.
+func WaitPodsCompleted(apiClient *clients.Settings, prefix, namespace string, pollInterval,
+	timeout time.Duration) (*Result, error) {
+	var scalePods []*pod.Builder
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			var err error
+
+			scalePods, err = pod.List(apiClient, namespace, metav1.ListOptions{
+				LabelSelector: labels.Set{PodLabel: prefix}.String()})
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Error listing the pods of namespace '%s': %v", namespace, err)
+
+				return false, nil
+			}
+
+			pending := 0
+
+			for _, scalePod := range scalePods {
+				phase := scalePod.Object.Status.Phase
+				if phase != corev1.PodSucceeded && phase != corev1.PodFailed {
+					pending++
+				}
+			}
+
+			glog.V(gpuparams.GpuLogLevel).Infof("%d of %d pods did not complete yet", pending, len(scalePods))
+
+			return pending == 0, nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("the pods of namespace %s did not complete: %w", namespace, err)
+	}
+
+	return measure(scalePods), nil
+}
+
+// Percentile returns the latency under which the percentage of the latencies fall, 0 when there is no latency.
+func Percentile(latencies []time.Duration, percentage int) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	index := (len(sorted)*percentage+99)/100 - 1
+
+	return sorted[max(index, 0)]
+}
+
+// measure returns the scheduling latencies, drain time and failures of the completed pods.
+func measure(scalePods []*pod.Builder) *Result {
+	result := &Result{
+		Pods:             len(scalePods),
+		AllocationErrors: map[string]string{},
+		Failures:         map[string]string{},
+	}
+
+	var firstCreated, lastFinished time.Time
+
+	for _, scalePod := range scalePods {
+		podObject := scalePod.Object
+		created := podObject.CreationTimestamp.Time
+
+		if firstCreated.IsZero() || created.Before(firstCreated) {
+			firstCreated = created
+		}
+
+		for _, condition := range podObject.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionTrue {
+				result.SchedulingLatencies = append(result.SchedulingLatencies,
+					condition.LastTransitionTime.Sub(created))
+			}
+		}
+
+		for _, containerStatus := range podObject.Status.ContainerStatuses {
+			if terminated := containerStatus.State.Terminated; terminated != nil &&
+				terminated.FinishedAt.Time.After(lastFinished) {
+				lastFinished = terminated.FinishedAt.Time
+			}
+		}
+
+		if podObject.Status.Phase != corev1.PodFailed {
+			continue
+		}
+
+		message := strings.TrimSpace(podObject.Status.Reason + ": " + podObject.Status.Message)
+
+		if podObject.Status.Reason == admissionErrorReason || podObject.Status.Reason == outOfGPUReason {
+			result.AllocationErrors[podObject.Name] = message
+		} else {
+			result.Failures[podObject.Name] = message
+		}
+	}
+
+	if !lastFinished.IsZero() {
+		result.DrainTime = lastFinished.Sub(firstCreated)
+	}
+
+	return result
+}
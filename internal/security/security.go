@@ -0,0 +1,125 @@
+package security
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SCCAnnotation is the pod annotation set by OpenShift to the SCC the pod was admitted under.
+	SCCAnnotation = "openshift.io/scc"
+	// PSAEnforceLabel is the namespace label setting the enforced Pod Security Admission level.
+	PSAEnforceLabel = "pod-security.kubernetes.io/enforce"
+	// PrivilegedSCC is the SCC allowing privileged containers and host namespaces.
+	PrivilegedSCC = "privileged"
+	// RestrictedSCC is the default SCC of the authenticated users' pods.
+	RestrictedSCC = "restricted-v2"
+	// PSAPrivileged is the Pod Security Admission level allowing privileged pods.
+	PSAPrivileged = "privileged"
+	// PSARestricted is the most restrictive Pod Security Admission level.
+	PSARestricted = "restricted"
+)
+
+// PrivilegedOperands are the name prefixes of the GPU operator DaemonSets documented as running privileged pods,
+// as they load kernel modules, access the GPU devices or configure the container runtime of the nodes.
+var PrivilegedOperands = []string{
+	"nvidia-driver-daemonset",
+	"nvidia-gpu-driver",
+	"nvidia-container-toolkit-daemonset",
+	"nvidia-device-plugin-daemonset",
+	"nvidia-device-plugin-mps-control-daemon",
+	"nvidia-dcgm",
+	"gpu-feature-discovery",
+	"nvidia-mig-manager",
+	"nvidia-operator-validator",
+	"nvidia-node-status-exporter",
+	"nvidia-vgpu-manager-daemonset",
+	"nvidia-vgpu-device-manager",
+	"nvidia-sandbox-device-plugin-daemonset",
+	"nvidia-sandbox-validator",
+	"nvidia-vfio-manager",
+	"nvidia-kata-manager",
+	"nvidia-cc-manager",
+}
+
+// PodSecurity is the security profile of a pod.
+type PodSecurity struct {
+	Name        string
+	OwnerKind   string
+	OwnerName   string
+	SCC         string
+	Privileged  bool
+	HostNetwork bool
+	HostPID     bool
+}
+
+// String returns the pod security profile as one line of evidence.
+func (podSecurity PodSecurity) String() string {
+	return fmt.Sprintf("pod %s (%s %s): scc=%s privileged=%t hostNetwork=%t hostPID=%t", podSecurity.Name,
+		podSecurity.OwnerKind, podSecurity.OwnerName, podSecurity.SCC, podSecurity.Privileged,
+		podSecurity.HostNetwork, podSecurity.HostPID)
+}
+
+// Describe returns the security profile of the pod, the ReplicaSet owners being reported as their Deployment.
+func Describe(pod *corev1.Pod) PodSecurity {
+	podSecurity := PodSecurity{
+		Name:        pod.Name,
+		SCC:         pod.Annotations[SCCAnnotation],
+		HostNetwork: pod.Spec.HostNetwork,
+		HostPID:     pod.Spec.HostPID,
+	}
+
+	for _, container := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+		if container.SecurityContext != nil && container.SecurityContext.Privileged != nil &&
+			*container.SecurityContext.Privileged {
+			podSecurity.Privileged = true
+		}
+	}
+
+	for _, ownerReference := range pod.OwnerReferences {
+		if ownerReference.Controller == nil || !*ownerReference.Controller {
+			continue
+		}
+
+		podSecurity.OwnerKind, podSecurity.OwnerName = ownerReference.Kind, ownerReference.Name
+		if ownerReference.Kind == "ReplicaSet" {
+			if index := strings.LastIndex(ownerReference.Name, "-"); index > 0 {
+				podSecurity.OwnerKind, podSecurity.OwnerName = "Deployment", ownerReference.Name[:index]
+			}
+		}
+	}
+
+	return podSecurity
+}
+
+// IsPrivilegedOperand checks whether the DaemonSet is one of the PrivilegedOperands.
+func IsPrivilegedOperand(daemonSetName string) bool {
+	return slices.ContainsFunc(PrivilegedOperands, func(prefix string) bool {
+		return strings.HasPrefix(daemonSetName, prefix)
+	})
+}
+
+// Violations returns why the pod breaks the GPU operator security expectations: every pod is admitted under an
+// SCC, and only the PrivilegedOperands run privileged containers, under the privileged SCC.
+func (podSecurity PodSecurity) Violations() []string {
+	var violations []string
+
+	if podSecurity.SCC == "" {
+		violations = append(violations, fmt.Sprintf("pod %s was not admitted under an SCC", podSecurity.Name))
+	}
+
+	if !podSecurity.Privileged && podSecurity.SCC != PrivilegedSCC {
+		return violations
+	}
+
+	if podSecurity.OwnerKind != "DaemonSet" || !IsPrivilegedOperand(podSecurity.OwnerName) {
+		violations = append(violations, fmt.Sprintf("pod %s of %s %s runs privileged (scc %s) but is not one of "+
+			"the documented privileged operand DaemonSets", podSecurity.Name, podSecurity.OwnerKind,
+			podSecurity.OwnerName, podSecurity.SCC))
+	}
+
+	return violations
+}
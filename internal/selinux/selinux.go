@@ -0,0 +1,135 @@
+package selinux
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DevicePodName is the name of the debug pod listing the SELinux contexts of the GPU device nodes of a node.
+	DevicePodName = "selinux-gpu-devices"
+	// ContainerType is the SELinux type of the containers run by CRI-O.
+	ContainerType = "container_t"
+	// EnforcingMode is the getenforce output of the nodes enforcing the SELinux policy.
+	EnforcingMode = "Enforcing"
+
+	hostRootVolume = "host-root"
+	modePrefix     = "mode: "
+)
+
+// deviceScript prints the SELinux mode of the host, then the context and path of every NVIDIA device node.
+var deviceScript = fmt.Sprintf(`echo "%s$(chroot /host getenforce)"
+chroot /host sh -c 'ls -Zd /dev/nvidia* /dev/nvidia-caps/* 2>/dev/null' || true
+`, modePrefix)
+
+// Context is an SELinux security context.
+type Context struct {
+	User  string
+	Role  string
+	Type  string
+	Level string
+}
+
+// String returns the context in the user:role:type:level form.
+func (context Context) String() string {
+	return strings.Join([]string{context.User, context.Role, context.Type, context.Level}, ":")
+}
+
+// NodeDevices is the SELinux mode of a node and the contexts of its NVIDIA device nodes, by path.
+type NodeDevices struct {
+	Mode    string
+	Devices map[string]Context
+}
+
+// ParseContext parses an SELinux context in the user:role:type:level form, the level possibly holding categories.
+func ParseContext(value string) (Context, error) {
+	fields := strings.SplitN(strings.TrimSpace(value), ":", 4)
+	if len(fields) != 4 {
+		return Context{}, fmt.Errorf("invalid SELinux context %q", value)
+	}
+
+	return Context{User: fields[0], Role: fields[1], Type: fields[2], Level: fields[3]}, nil
+}
+
+// ParseNodeDevices parses the output of the device pod.
+func ParseNodeDevices(output string) (*NodeDevices, error) {
+	nodeDevices := &NodeDevices{Devices: map[string]Context{}}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if mode, found := strings.CutPrefix(line, modePrefix); found {
+			nodeDevices.Mode = mode
+
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid device context line %q", line)
+		}
+
+		context, err := ParseContext(fields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		nodeDevices.Devices[fields[1]] = context
+	}
+
+	if nodeDevices.Mode == "" {
+		return nil, fmt.Errorf("no SELinux mode found in output %q", output)
+	}
+
+	return nodeDevices, nil
+}
+
+// GetNodeDevices returns the SELinux mode and the contexts of the NVIDIA device nodes of the node, as labelled on
+// the host, from a privileged debug pod created in the namespace, which must allow privileged pods.
+func GetNodeDevices(apiClient *clients.Settings, nodeName, nsname, image string,
+	timeout time.Duration) (*NodeDevices, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Listing the SELinux contexts of the GPU devices of node '%s'", nodeName)
+
+	devicePod, err := pod.NewBuilder(apiClient, DevicePodName, nsname, image).
+		DefineOnNode(nodeName).
+		WithRestartPolicy(corev1.RestartPolicyNever).
+		WithToleration(corev1.Toleration{
+			Key:      "nvidia.com/gpu",
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		}).
+		RedefineDefaultCMD([]string{"/bin/sh", "-c", deviceScript}).
+		WithPrivilegedFlag().
+		WithHostPathVolume(hostRootVolume, "/", "/host").
+		Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the SELinux device pod of node %s: %w", nodeName, err)
+	}
+
+	defer func() {
+		if _, err := devicePod.DeleteAndWait(timeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", DevicePodName, err)
+		}
+	}()
+
+	if err := devicePod.WaitUntilInStatus(corev1.PodSucceeded, timeout); err != nil {
+		return nil, fmt.Errorf("SELinux device pod of node %s did not succeed: %w", nodeName, err)
+	}
+
+	output, err := devicePod.GetFullLog(devicePod.Definition.Spec.Containers[0].Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod %s log: %w", DevicePodName, err)
+	}
+
+	return ParseNodeDevices(output)
+}
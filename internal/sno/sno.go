@@ -0,0 +1,33 @@
+package sno
+
+import (
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+)
+
+// DisruptionTimeoutFactor multiplies the timeouts of the workloads recovering from a driver restart on Single-Node
+// OpenShift, where the GPU workloads cannot move to another node and wait for the driver of their node instead.
+const DisruptionTimeoutFactor = 2
+
+// IsSingleNode returns true when the cluster is a Single-Node OpenShift cluster, i.e. its Infrastructure reports a
+// single replica control plane topology.
+func IsSingleNode(apiClient *clients.Settings) (bool, error) {
+	topology, err := apiClient.ControlPlaneTopology()
+	if err != nil {
+		return false, err
+	}
+
+	return topology == configv1.SingleReplicaTopologyMode, nil
+}
+
+// DisruptionTimeout returns the time a GPU workload is given to recover from a driver restart: the timeout, or
+// DisruptionTimeoutFactor times the timeout on Single-Node OpenShift.
+func DisruptionTimeout(singleNode bool, timeout time.Duration) time.Duration {
+	if singleNode {
+		return DisruptionTimeoutFactor * timeout
+	}
+
+	return timeout
+}
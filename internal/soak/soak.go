@@ -0,0 +1,190 @@
+package soak
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/dcgmexporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// XIDMetric is the DCGM exporter metric holding the last XID error of a GPU, 0 when none occurred.
+	XIDMetric = "DCGM_FI_DEV_XID_ERRORS"
+	// FramebufferUsedMetric is the DCGM exporter metric of the used framebuffer memory of a GPU, in MiB.
+	FramebufferUsedMetric = "DCGM_FI_DEV_FB_USED"
+	// DCGMPodLabel selects the pods of the standalone DCGM host engine of the GPU operator.
+	DCGMPodLabel = "app=nvidia-dcgm"
+
+	// allGPUsGroup is the default DCGM group of all the supported GPUs.
+	allGPUsGroup = "0"
+	// overallHealthPrefix prefixes the overall health line of the dcgmi health check output.
+	overallHealthPrefix = "Overall Health"
+	healthyStatus       = "Healthy"
+)
+
+// Snapshot is the DCGM exporter metrics of the GPUs at a point of the soak.
+type Snapshot struct {
+	Time    time.Time                      `json:"time"`
+	Metrics map[string][]prometheus.Sample `json:"metrics"`
+}
+
+// snapshotMetrics are the DCGM exporter metrics recorded in the snapshots.
+func snapshotMetrics() []string {
+	metrics := []string{XIDMetric}
+	for _, metric := range dcgmexporter.DefaultMetrics {
+		metrics = append(metrics, metric.Name)
+	}
+
+	return metrics
+}
+
+// TakeSnapshot queries the DCGM exporter metrics of the GPUs from Prometheus and writes them to a JSON file of the
+// directory, named after the snapshot time.
+func TakeSnapshot(client *prometheus.Client, dirPath string) (*Snapshot, error) {
+	snapshot := &Snapshot{Time: time.Now().UTC(), Metrics: map[string][]prometheus.Sample{}}
+
+	for _, metric := range snapshotMetrics() {
+		samples, err := client.Query(metric)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query metric %s: %w", metric, err)
+		}
+
+		snapshot.Metrics[metric] = samples
+	}
+
+	content, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the metrics snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the snapshot directory %s: %w", dirPath, err)
+	}
+
+	snapshotPath := filepath.Join(dirPath,
+		fmt.Sprintf("metrics-%s.json", snapshot.Time.Format("20060102T150405Z")))
+	if err := os.WriteFile(snapshotPath, content, 0666); err != nil {
+		return nil, fmt.Errorf("failed to write the metrics snapshot %s: %w", snapshotPath, err)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Metrics snapshot written to %s", snapshotPath)
+
+	return snapshot, nil
+}
+
+// XIDErrors returns the last XID error of the GPUs that hit one, by node and GPU index.
+func XIDErrors(client *prometheus.Client) (map[string]float64, error) {
+	samples, err := client.Query(XIDMetric)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metric %s: %w", XIDMetric, err)
+	}
+
+	xidErrors := map[string]float64{}
+
+	for nodeName, gpus := range dcgmexporter.SamplesByNode(samples) {
+		for gpu, sample := range gpus {
+			if sample.Value != 0 {
+				xidErrors[fmt.Sprintf("%s GPU %s", nodeName, gpu)] = sample.Value
+			}
+		}
+	}
+
+	return xidErrors, nil
+}
+
+// FramebufferUsed returns the used framebuffer memory of the GPUs of the snapshot, in MiB, by node and GPU index.
+func FramebufferUsed(snapshot *Snapshot) map[string]float64 {
+	used := map[string]float64{}
+
+	for nodeName, gpus := range dcgmexporter.SamplesByNode(snapshot.Metrics[FramebufferUsedMetric]) {
+		for gpu, sample := range gpus {
+			used[fmt.Sprintf("%s GPU %s", nodeName, gpu)] = sample.Value
+		}
+	}
+
+	return used
+}
+
+// DCGMPods returns the pods of the standalone DCGM host engine, none when the ClusterPolicy disables it.
+func DCGMPods(apiClient *clients.Settings) ([]*pod.Builder, error) {
+	dcgmPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace,
+		metav1.ListOptions{LabelSelector: DCGMPodLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the DCGM pods: %w", err)
+	}
+
+	var runningPods []*pod.Builder
+
+	for _, dcgmPod := range dcgmPods {
+		if dcgmPod.Object.Status.Phase == corev1.PodRunning {
+			runningPods = append(runningPods, dcgmPod)
+		}
+	}
+
+	return runningPods, nil
+}
+
+// EnableHealthWatches enables all the DCGM health watches of the GPUs of every DCGM host engine, which the health
+// checks report on.
+func EnableHealthWatches(dcgmPods []*pod.Builder) error {
+	for _, dcgmPod := range dcgmPods {
+		output, err := dcgmPod.ExecCommand([]string{"dcgmi", "health", "-g", allGPUsGroup, "-s", "a"})
+		if err != nil {
+			return fmt.Errorf("failed to enable the DCGM health watches of pod %s: %w: %s", dcgmPod.Object.Name,
+				err, output.String())
+		}
+	}
+
+	return nil
+}
+
+// UnhealthyGPUs runs the DCGM health check of every DCGM host engine and returns the check output of the nodes whose
+// overall health is not healthy, by node name.
+func UnhealthyGPUs(dcgmPods []*pod.Builder) (map[string]string, error) {
+	unhealthy := map[string]string{}
+
+	for _, dcgmPod := range dcgmPods {
+		output, err := dcgmPod.ExecCommand([]string{"dcgmi", "health", "-g", allGPUsGroup, "-c"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to run the DCGM health check of pod %s: %w: %s", dcgmPod.Object.Name,
+				err, output.String())
+		}
+
+		if overallHealth(output.String()) != healthyStatus {
+			unhealthy[dcgmPod.Object.Spec.NodeName] = output.String()
+		}
+	}
+
+	return unhealthy, nil
+}
+
+// overallHealth returns the overall health of the dcgmi health check output, e.g. "Healthy" or "Warning".
+func overallHealth(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.Trim(line, " |+-")
+		if !strings.HasPrefix(line, overallHealthPrefix) {
+			continue
+		}
+
+		fields := strings.FieldsFunc(strings.TrimPrefix(line, overallHealthPrefix), func(r rune) bool {
+			return r == '|' || r == ':' || r == ' '
+		})
+		if len(fields) > 0 {
+			return fields[0]
+		}
+	}
+
+	return ""
+}
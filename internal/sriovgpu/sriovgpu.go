@@ -0,0 +1,195 @@
+package sriovgpu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/arch"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/sriov"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ResourceName is the SR-IOV device plugin resource of the VFs of the suite policy.
+	ResourceName = "nvidiacisriovgpu"
+	// WorkloadContainerName is the container name of the pods built by CreateWorkloadPod.
+	WorkloadContainerName = "sriov-gpu-ctr"
+	// NetworkInterface is the pod interface of the VF attached by the SriovNetwork.
+	NetworkInterface = "net1"
+
+	// TopologyManagerPolicyNone is the kubelet default Topology Manager policy, not aligning the devices.
+	TopologyManagerPolicyNone = "none"
+	// TopologyManagerPolicySingleNUMANode admits the pods only when their devices and CPUs are on a single NUMA node.
+	TopologyManagerPolicySingleNUMANode = "single-numa-node"
+	// TopologyManagerPolicyRestricted admits the pods only when their devices and CPUs are NUMA aligned.
+	TopologyManagerPolicyRestricted = "restricted"
+
+	gpuResourceName corev1.ResourceName = "nvidia.com/gpu"
+	networksKey                         = "k8s.v1.cni.cncf.io/networks"
+)
+
+// WorkloadImages are the images of the pods built by CreateWorkloadPod by architecture, nvidia-smi being mounted in
+// the GPU containers by the NVIDIA container toolkit.
+var WorkloadImages = arch.Images{
+	arch.AMD64: "quay.io/wabouham/ecosys-nvidia/ubi9-tools:0.0.1",
+	arch.ARM64: "quay.io/wabouham/ecosys-nvidia/ubi9-tools-arm64:0.0.1",
+}
+
+// workloadScript prints the PCI address and NUMA node of the GPU and of the VF allocated to the container. The
+// nvidia-smi bus id has an 8 digit PCI domain, sysfs a 4 digit one. The SR-IOV device plugin lists the PCI addresses
+// of the allocated VFs in the PCIDEVICE_<resource> environment variable.
+const workloadScript = `set -e
+gpu=$(nvidia-smi --query-gpu=pci.bus_id --format=csv,noheader | head -n 1 | tr 'A-F' 'a-f')
+gpu=${gpu:4}
+vf=${%[1]s%%%%,*}
+test -d /sys/class/net/%[2]s
+echo "gpu-pci-address=${gpu}"
+echo "gpu-numa-node=$(cat /sys/bus/pci/devices/${gpu}/numa_node)"
+echo "vf-pci-address=${vf}"
+echo "vf-numa-node=$(cat /sys/bus/pci/devices/${vf}/numa_node)"
+`
+
+// Allocation is the GPU and VF allocated to a workload pod.
+type Allocation struct {
+	GPUPCIAddress string
+	GPUNUMANode   int
+	VFPCIAddress  string
+	VFNUMANode    int
+}
+
+// NUMAAligned returns true when the GPU and the VF are on the same NUMA node. The -1 NUMA node of the single NUMA
+// node servers is aligned with itself.
+func (allocation Allocation) NUMAAligned() bool {
+	return allocation.GPUNUMANode == allocation.VFNUMANode
+}
+
+// TopologyManagerPolicy returns the Topology Manager policy of the kubelet of the node, read from its configz
+// endpoint.
+func TopologyManagerPolicy(apiClient *clients.Settings, nodeName string) (string, error) {
+	data, err := apiClient.CoreV1Interface.RESTClient().Get().
+		AbsPath("/api/v1/nodes", nodeName, "proxy", "configz").DoRaw(context.TODO())
+	if err != nil {
+		return "", fmt.Errorf("failed to get the kubelet configuration of node %s: %w", nodeName, err)
+	}
+
+	var configz struct {
+		KubeletConfig struct {
+			TopologyManagerPolicy string `json:"topologyManagerPolicy"`
+		} `json:"kubeletconfig"`
+	}
+
+	if err := json.Unmarshal(data, &configz); err != nil {
+		return "", fmt.Errorf("failed to decode the kubelet configuration of node %s: %w", nodeName, err)
+	}
+
+	policy := configz.KubeletConfig.TopologyManagerPolicy
+	if policy == "" {
+		policy = TopologyManagerPolicyNone
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' Topology Manager policy is '%s'", nodeName, policy)
+
+	return policy, nil
+}
+
+// CreateWorkloadPod returns a Guaranteed QoS pod pinned to the node consuming a GPU and a VF of the SriovNetwork,
+// so that the Topology Manager aligns them, which prints the PCI address and NUMA node of both.
+func CreateWorkloadPod(podName, podNamespace, image, nodeName, networkName string) *corev1.Pod {
+	vfResourceName := corev1.ResourceName(sriov.ResourcePrefix + ResourceName)
+
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+		gpuResourceName:       resource.MustParse("1"),
+		vfResourceName:        resource.MustParse("1"),
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "sriov-gpu-test-app",
+			},
+			Annotations: map[string]string{
+				networksKey: networkName,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			NodeSelector: map[string]string{
+				corev1.LabelHostname: nodeName,
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            WorkloadContainerName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command: []string{"/bin/bash", "-c",
+						fmt.Sprintf(workloadScript, deviceEnvName(vfResourceName), NetworkInterface)},
+					Resources: corev1.ResourceRequirements{
+						Requests: resources,
+						Limits:   resources,
+					},
+				},
+			},
+		},
+	}
+}
+
+// ParseAllocation returns the GPU and VF allocation printed by a pod built by CreateWorkloadPod.
+func ParseAllocation(output string) (Allocation, error) {
+	values := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if found {
+			values[key] = value
+		}
+	}
+
+	var (
+		allocation Allocation
+		err        error
+	)
+
+	for _, key := range []string{"gpu-pci-address", "vf-pci-address"} {
+		if values[key] == "" {
+			return allocation, fmt.Errorf("no %s found in the pod output", key)
+		}
+	}
+
+	allocation.GPUPCIAddress = values["gpu-pci-address"]
+	allocation.VFPCIAddress = values["vf-pci-address"]
+
+	if allocation.GPUNUMANode, err = strconv.Atoi(values["gpu-numa-node"]); err != nil {
+		return allocation, fmt.Errorf("invalid GPU NUMA node %q: %w", values["gpu-numa-node"], err)
+	}
+
+	if allocation.VFNUMANode, err = strconv.Atoi(values["vf-numa-node"]); err != nil {
+		return allocation, fmt.Errorf("invalid VF NUMA node %q: %w", values["vf-numa-node"], err)
+	}
+
+	return allocation, nil
+}
+
+// deviceEnvName returns the environment variable the SR-IOV device plugin sets to the PCI addresses of the
+// allocated devices of the resource, e.g. PCIDEVICE_OPENSHIFT_IO_NVIDIACISRIOVGPU.
+func deviceEnvName(resourceName corev1.ResourceName) string {
+	return "PCIDEVICE_" + strings.ToUpper(strings.NewReplacer(".", "_", "/", "_").Replace(string(resourceName)))
+}
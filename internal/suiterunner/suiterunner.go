@@ -0,0 +1,281 @@
+package suiterunner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/v2/types"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/config"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+)
+
+const (
+	// AllFeatures selects all the suites, like TEST_FEATURES=all of the test-runner script.
+	AllFeatures = "all"
+	// JSONReportFile is the report of all the suites, merged by ginkgo, in the artifact directory.
+	JSONReportFile = "nvidia-ci_report.json"
+	// JUnitReportFile is the JUnit report of all the suites, merged by ginkgo, in the artifact directory.
+	JUnitReportFile = "nvidia-ci_junit.xml"
+
+	suiteFileSuffix = "_suite_test.go"
+	internalDir     = "internal"
+)
+
+// Suite is a ginkgo suite of the tests directory.
+type Suite struct {
+	// Feature is the name of the suite directory, matched against the features to run.
+	Feature string
+	// Dir is the path of the suite directory.
+	Dir string
+}
+
+// Options are the options of a run of the suites.
+type Options struct {
+	// Labels is the ginkgo label filter of the specs to run.
+	Labels string
+	// ConfigFile is the config file of the suites, exported as CONFIG_FILE.
+	ConfigFile string
+	// ArtifactDir is the directory of the reports, exported as REPORTS_DUMP_DIR.
+	ArtifactDir string
+	// MustGatherScriptsDir is the directory of the must-gather scripts, exported as MUST_GATHER_SCRIPTS_DIR.
+	MustGatherScriptsDir string
+	// Timeout is the ginkgo timeout of the whole run.
+	Timeout time.Duration
+	// Procs is the number of parallel ginkgo processes of each suite, the suites running serially below 2.
+	Procs int
+	// Verbose runs ginkgo with verbose output.
+	Verbose bool
+	// DryRun walks the specs without running them nor connecting to the cluster, e.g. to check their labels.
+	DryRun bool
+	// GinkgoArgs are passed as is to ginkgo.
+	GinkgoArgs []string
+}
+
+// SuiteSummary is the outcome of a suite of the merged report.
+type SuiteSummary struct {
+	Suite     string
+	Succeeded bool
+	Passed    int
+	Failed    int
+	Skipped   int
+	Flaked    int
+	Duration  time.Duration
+	// FailedSpecs are the full texts of the specs which failed.
+	FailedSpecs []string
+}
+
+// Discover returns the suites of the tests directory matching the features, all of them for AllFeatures, sorted by
+// directory. The internal directories are skipped, like by the test-runner script.
+func Discover(testsDir string, features []string) ([]Suite, error) {
+	selected := map[string]bool{}
+	for _, feature := range features {
+		selected[feature] = true
+	}
+
+	var suites []Suite
+
+	suiteDirs := map[string]bool{}
+
+	err := filepath.WalkDir(testsDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == internalDir {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(entry.Name(), suiteFileSuffix) {
+			return nil
+		}
+
+		suiteDir := filepath.Dir(path)
+		feature := filepath.Base(suiteDir)
+
+		if (selected[AllFeatures] || selected[feature]) && !suiteDirs[suiteDir] {
+			suiteDirs[suiteDir] = true
+			suites = append(suites, Suite{Feature: feature, Dir: suiteDir})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the suites of %s: %w", testsDir, err)
+	}
+
+	if len(suites) == 0 {
+		return nil, fmt.Errorf("no suite of %s matches features %s", testsDir, strings.Join(features, ", "))
+	}
+
+	sort.Slice(suites, func(i, j int) bool { return suites[i].Dir < suites[j].Dir })
+
+	return suites, nil
+}
+
+// Command returns the ginkgo command running the suites with the options, ginkgo merging the reports of the suites
+// to JSONReportFile and JUnitReportFile of the artifact directory.
+func Command(ginkgoPath string, suites []Suite, options Options) *exec.Cmd {
+	args := []string{
+		"--keep-going", "--require-suite",
+		fmt.Sprintf("--timeout=%s", options.Timeout),
+		"--output-dir=" + options.ArtifactDir,
+		"--json-report=" + JSONReportFile,
+		"--junit-report=" + JUnitReportFile,
+	}
+
+	if options.Labels != "" {
+		args = append(args, "--label-filter="+options.Labels)
+	}
+
+	if options.Procs > 1 {
+		args = append(args, fmt.Sprintf("--procs=%d", options.Procs))
+	}
+
+	if options.Verbose {
+		args = append(args, "-vv")
+	}
+
+	if options.DryRun {
+		args = append(args, "--dry-run")
+	}
+
+	args = append(args, options.GinkgoArgs...)
+
+	for _, suite := range suites {
+		args = append(args, suite.Dir)
+	}
+
+	command := exec.Command(ginkgoPath, args...)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	command.Env = append(os.Environ(), "REPORTS_DUMP_DIR="+options.ArtifactDir)
+
+	if options.ConfigFile != "" {
+		command.Env = append(command.Env, config.ConfigFileEnvVar+"="+options.ConfigFile)
+	}
+
+	if options.DryRun {
+		command.Env = append(command.Env, "DRY_RUN=true")
+	}
+
+	if options.MustGatherScriptsDir != "" {
+		command.Env = append(command.Env, "MUST_GATHER_SCRIPTS_DIR="+options.MustGatherScriptsDir)
+	}
+
+	return command
+}
+
+// ReadReport returns the summaries of the suites of the report ginkgo merged to JSONReportFile.
+func ReadReport(reportPath string) ([]SuiteSummary, error) {
+	reports, err := readReports(reportPath)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]SuiteSummary, 0, len(reports))
+
+	for _, report := range reports {
+		summary := SuiteSummary{
+			Suite:     report.SuiteDescription,
+			Succeeded: report.SuiteSucceeded,
+			Duration:  report.RunTime,
+		}
+
+		for _, specReport := range report.SpecReports {
+			if !specReport.LeafNodeType.Is(types.NodeTypeIt) {
+				if specReport.Failed() {
+					summary.FailedSpecs = append(summary.FailedSpecs, specReport.LeafNodeType.String())
+				}
+
+				continue
+			}
+
+			switch {
+			case specReport.State.Is(types.SpecStatePassed):
+				summary.Passed++
+
+				if specReport.NumAttempts > 1 {
+					summary.Flaked++
+				}
+			case specReport.Failed():
+				summary.Failed++
+				summary.FailedSpecs = append(summary.FailedSpecs, specReport.FullText())
+			default:
+				summary.Skipped++
+			}
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// CheckLabels returns the specs of the report ginkgo merged to JSONReportFile whose labels do not follow the taxonomy
+// of the labels package, prefixed with their suite. The specs filtered out or not run, e.g. by a dry run, are checked
+// too.
+func CheckLabels(reportPath string) ([]string, error) {
+	reports, err := readReports(reportPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []string
+
+	for _, report := range reports {
+		for _, violation := range cilabels.Violations(report) {
+			violations = append(violations, report.SuiteDescription+": "+violation)
+		}
+	}
+
+	return violations, nil
+}
+
+// WriteSummary writes a line per suite, followed by its failed specs.
+func WriteSummary(output io.Writer, summaries []SuiteSummary) error {
+	var lines []string
+
+	for _, summary := range summaries {
+		status := "PASSED"
+		if !summary.Succeeded {
+			status = "FAILED"
+		}
+
+		lines = append(lines, fmt.Sprintf("%s %s: %d passed, %d failed, %d skipped, %d flaked in %s", status,
+			summary.Suite, summary.Passed, summary.Failed, summary.Skipped, summary.Flaked,
+			summary.Duration.Round(time.Second)))
+
+		for _, failedSpec := range summary.FailedSpecs {
+			lines = append(lines, "    failed: "+failedSpec)
+		}
+	}
+
+	_, err := fmt.Fprintln(output, strings.Join(lines, "\n"))
+
+	return err
+}
+
+func readReports(reportPath string) ([]types.Report, error) {
+	content, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report %s: %w", reportPath, err)
+	}
+
+	var reports []types.Report
+	if err := json.Unmarshal(content, &reports); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", reportPath, err)
+	}
+
+	return reports, nil
+}
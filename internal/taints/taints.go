@@ -0,0 +1,185 @@
+package taints
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// GPUPresentTaint is the taint commonly set on the GPU nodes to keep the workloads not requesting GPUs off them,
+// tolerated by the GPU operator operands by default.
+var GPUPresentTaint = corev1.Taint{Key: "nvidia.com/gpu", Value: "present", Effect: corev1.TaintEffectNoSchedule}
+
+// Toleration returns the toleration of the taint, matching its key, value and effect.
+func Toleration(taint corev1.Taint) corev1.Toleration {
+	return corev1.Toleration{
+		Key:      taint.Key,
+		Operator: corev1.TolerationOpEqual,
+		Value:    taint.Value,
+		Effect:   taint.Effect,
+	}
+}
+
+// TaintNode sets the taint on the node, replacing the taint with the same key and effect.
+func TaintNode(apiClient *clients.Settings, nodeName string, taint corev1.Taint) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Tainting node '%s' with '%s'", nodeName, taint.ToString())
+
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to pull node %s: %w", nodeName, err)
+	}
+
+	if _, err := nodeBuilder.WithTaint(taint).Update(); err != nil {
+		return fmt.Errorf("failed to taint node %s with %s: %w", nodeName, taint.ToString(), err)
+	}
+
+	return nil
+}
+
+// UntaintNode removes the taint with the key and effect of the taint from the node.
+func UntaintNode(apiClient *clients.Settings, nodeName string, taint corev1.Taint) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Removing taint '%s' from node '%s'", taint.ToString(), nodeName)
+
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to pull node %s: %w", nodeName, err)
+	}
+
+	if _, err := nodeBuilder.RemoveTaint(taint.Key, taint.Effect).Update(); err != nil {
+		return fmt.Errorf("failed to remove taint %s from node %s: %w", taint.ToString(), nodeName, err)
+	}
+
+	return nil
+}
+
+// AddOperandTolerations adds the tolerations to the ClusterPolicy daemonsets.tolerations, applied by the GPU
+// operator to all its operands. It returns a copy of the previous ClusterPolicy spec so that it can be restored, or
+// nil when the ClusterPolicy already had all the tolerations.
+func AddOperandTolerations(apiClient *clients.Settings, clusterPolicyName string,
+	tolerations ...corev1.Toleration) (*nvidiagpuv1.ClusterPolicySpec, error) {
+	clusterPolicyBuilder, err := nvidiagpu.Pull(apiClient, clusterPolicyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull ClusterPolicy %s: %w", clusterPolicyName, err)
+	}
+
+	var missing []corev1.Toleration
+
+	for _, toleration := range tolerations {
+		if !slices.Contains(clusterPolicyBuilder.Definition.Spec.Daemonsets.Tolerations, toleration) {
+			missing = append(missing, toleration)
+		}
+	}
+
+	if len(missing) == 0 {
+		glog.V(gpuparams.GpuLogLevel).Infof("ClusterPolicy '%s' already has the operand tolerations",
+			clusterPolicyName)
+
+		return nil, nil
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Adding operand tolerations %v to ClusterPolicy '%s'", missing,
+		clusterPolicyName)
+
+	var previousSpec *nvidiagpuv1.ClusterPolicySpec
+
+	_, err = nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousSpec = definition.Spec.DeepCopy()
+		definition.Spec.Daemonsets.Tolerations = append(definition.Spec.Daemonsets.Tolerations, missing...)
+	})
+	if err != nil {
+		return previousSpec, fmt.Errorf("failed to add operand tolerations to ClusterPolicy %s: %w",
+			clusterPolicyName, err)
+	}
+
+	return previousSpec, nil
+}
+
+// OperandScheduling returns the number of nodes every GPU operator operand DaemonSet must run a pod on, by
+// DaemonSet name, the nodes with a taint the DaemonSet does not tolerate being left out.
+func OperandScheduling(apiClient *clients.Settings) (map[string]int32, error) {
+	daemonSets, err := apiClient.DaemonSets(nvidiagpu.NvidiaGPUNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DaemonSets in namespace %s: %w", nvidiagpu.NvidiaGPUNamespace, err)
+	}
+
+	scheduling := map[string]int32{}
+	for _, daemonSet := range daemonSets.Items {
+		scheduling[daemonSet.Name] = daemonSet.Status.DesiredNumberScheduled
+	}
+
+	return scheduling, nil
+}
+
+// WaitForOperandScheduling waits until every DaemonSet of the expected scheduling, as returned by OperandScheduling,
+// must run a pod on its expected number of nodes and runs an up-to-date ready pod on all of them.
+func WaitForOperandScheduling(apiClient *clients.Settings, expected map[string]int32, pollInterval,
+	timeout time.Duration) error {
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			for name, desired := range expected {
+				daemonSet, err := apiClient.DaemonSets(nvidiagpu.NvidiaGPUNamespace).Get(ctx, name,
+					metav1.GetOptions{})
+				if err != nil {
+					glog.V(gpuparams.GpuLogLevel).Infof("Error getting DaemonSet '%s': %v", name, err)
+
+					return false, nil
+				}
+
+				status := daemonSet.Status
+				if status.DesiredNumberScheduled != desired || status.NumberReady != desired ||
+					status.UpdatedNumberScheduled != desired {
+					glog.V(gpuparams.GpuLogLevel).Infof("DaemonSet '%s' has %d desired, %d ready and %d updated "+
+						"pods, expected %d", name, status.DesiredNumberScheduled, status.NumberReady,
+						status.UpdatedNumberScheduled, desired)
+
+					return false, nil
+				}
+			}
+
+			return true, nil
+		})
+	if err != nil {
+		return fmt.Errorf("the GPU operator operands did not get scheduled on the expected nodes: %w", err)
+	}
+
+	return nil
+}
+
+// UnschedulableMessage returns the messages of the Unschedulable PodScheduled conditions of the pods of the CUDA
+// sample Job, empty while no pod is rejected by the scheduler.
+func UnschedulableMessage(apiClient *clients.Settings, nsname, jobName string) (string, error) {
+	jobPods, err := pod.List(apiClient, nsname, metav1.ListOptions{
+		LabelSelector: labels.Set{cudasamples.AppLabel: jobName}.String(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var messages []string
+
+	for _, jobPod := range jobPods {
+		for _, condition := range jobPod.Object.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse &&
+				condition.Reason == corev1.PodReasonUnschedulable {
+				messages = append(messages, condition.Message)
+			}
+		}
+	}
+
+	return strings.Join(messages, "\n"), nil
+}
@@ -0,0 +1,155 @@
+package timeslicing
+
+import (
+	"fmt"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DevicePluginConfigLabel selects the device plugin config entry used on a node.
+	DevicePluginConfigLabel = "nvidia.com/device-plugin.config"
+	// GPUReplicasLabel reports the number of time-sliced replicas per GPU discovered by GFD.
+	GPUReplicasLabel = "nvidia.com/gpu.replicas"
+	// GPUResourceName is the extended resource shared through time-slicing.
+	GPUResourceName corev1.ResourceName = "nvidia.com/gpu"
+	// WorkloadContainerName is the container name of the pods built by CreateTimeSlicingPod.
+	WorkloadContainerName = "time-slicing-ctr"
+)
+
+var (
+	isFalse = false
+	isTrue  = true
+)
+
+// CreateDevicePluginConfigMap creates a ConfigMap holding one time-slicing device plugin config per entry of
+// replicasByConfig, keyed by the config name.
+func CreateDevicePluginConfigMap(apiClient *clients.Settings, configMapName, configMapNamespace string,
+	replicasByConfig map[string]int) (*configmap.Builder, error) {
+	devicePluginConfig := map[string]string{}
+
+	for configName, replicas := range replicasByConfig {
+		config := map[string]interface{}{
+			"version": "v1",
+			"sharing": map[string]interface{}{
+				"timeSlicing": map[string]interface{}{
+					"renameByDefault": false,
+					"resources": []map[string]interface{}{
+						{
+							"name":     string(GPUResourceName),
+							"replicas": replicas,
+						},
+					},
+				},
+			},
+		}
+
+		yamlData, err := yaml.Marshal(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal time-slicing config %s: %w", configName, err)
+		}
+
+		devicePluginConfig[configName] = string(yamlData)
+	}
+
+	createdConfigMap, err := configmap.NewBuilder(apiClient, configMapName, configMapNamespace).
+		WithData(devicePluginConfig).Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create time-slicing ConfigMap %s in namespace %s: %w",
+			configMapName, configMapNamespace, err)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Created time-slicing ConfigMap %s in namespace %s",
+		createdConfigMap.Object.Name, createdConfigMap.Object.Namespace)
+
+	return createdConfigMap, nil
+}
+
+// SetClusterPolicyDevicePluginConfig points the ClusterPolicy devicePlugin.config at the given config, or clears it
+// when config is nil. It returns the previous config so that the caller can restore it.
+func SetClusterPolicyDevicePluginConfig(apiClient *clients.Settings, clusterPolicyName string,
+	config *nvidiagpuv1.DevicePluginConfig) (*nvidiagpuv1.DevicePluginConfig, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Setting ClusterPolicy '%s' devicePlugin.config to %+v",
+		clusterPolicyName, config)
+
+	var previousConfig *nvidiagpuv1.DevicePluginConfig
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousConfig = definition.Spec.DevicePlugin.Config
+		definition.Spec.DevicePlugin.Config = config
+	})
+	if err != nil {
+		return previousConfig, fmt.Errorf("failed to update ClusterPolicy %s devicePlugin.config: %w",
+			clusterPolicyName, err)
+	}
+
+	return previousConfig, nil
+}
+
+// LabelNodeDevicePluginConfig sets the nvidia.com/device-plugin.config label on a node, replacing any existing
+// value. An empty config removes the label.
+func LabelNodeDevicePluginConfig(apiClient *clients.Settings, nodeName, config string) error {
+	glog.V(gpuparams.GpuLogLevel).Infof("Labeling node '%s' with %s=%s", nodeName, DevicePluginConfigLabel,
+		config)
+
+	return nodes.SetLabel(apiClient, nodeName, DevicePluginConfigLabel, config)
+}
+
+// CreateTimeSlicingPod returns a pod pinned to the node that requests one time-sliced GPU and keeps it busy.
+func CreateTimeSlicingPod(podName, podNamespace, nodeName, image string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "time-slicing-test-app",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			NodeSelector: map[string]string{
+				"kubernetes.io/hostname": nodeName,
+			},
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &isTrue,
+				SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            WorkloadContainerName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/bin/sh", "-c", "nvidia-smi -L && sleep infinity"},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &isFalse,
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+					},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							GPUResourceName: resource.MustParse("1"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
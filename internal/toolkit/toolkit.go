@@ -0,0 +1,369 @@
+package toolkit
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/cdi"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NodePodName is the name of the debug pod reading the container toolkit and CRI-O configuration of a node.
+	NodePodName = "toolkit-node-config"
+	// DefaultInstallDir is the host directory the container toolkit is installed in when the ClusterPolicy sets none.
+	DefaultInstallDir = "/usr/local/nvidia"
+	// DefaultRuntimeClass is the runtime class of the GPU pods when the ClusterPolicy sets none.
+	DefaultRuntimeClass = "nvidia"
+	// DriverRoot is the host directory the driver container exposes the driver root filesystem in.
+	DriverRoot = "/run/nvidia/driver"
+	// HostRoot is the driver root of a driver preinstalled on the host.
+	HostRoot = "/"
+	// AcceptEnvvarUnprivilegedKey is the config.toml setting allowing unprivileged containers to select GPUs with
+	// the NVIDIA_VISIBLE_DEVICES environment variable.
+	AcceptEnvvarUnprivilegedKey = "accept-nvidia-visible-devices-envvar-when-unprivileged"
+	// AcceptVolumeMountsKey is the config.toml setting allowing containers to select GPUs with volume mounts.
+	AcceptVolumeMountsKey = "accept-nvidia-visible-devices-as-volume-mounts"
+	// CLIPathKey is the config.toml setting of the nvidia-container-cli binary.
+	CLIPathKey = "nvidia-container-cli.path"
+	// CLIRootKey is the config.toml setting of the driver root nvidia-container-cli injects the driver from.
+	CLIRootKey = "nvidia-container-cli.root"
+	// HookPathKey is the config.toml setting of the nvidia-container-runtime-hook binary.
+	HookPathKey = "nvidia-container-runtime-hook.path"
+
+	acceptEnvvarUnprivilegedEnv = "ACCEPT_NVIDIA_VISIBLE_DEVICES_ENVVAR_WHEN_UNPRIVILEGED"
+	acceptVolumeMountsEnv       = "ACCEPT_NVIDIA_VISIBLE_DEVICES_AS_VOLUME_MOUNTS"
+	volumeMountsStrategy        = "volume-mounts"
+	crioRuntimesSection         = "crio.runtime.runtimes."
+	crioRuntimePathKey          = "runtime_path"
+	hostRootVolume              = "host-root"
+	filePrefix                  = "file: "
+	hookPrefix                  = "hook: "
+	crioMarker                  = "--- crio "
+	toolkitMarker               = "--- toolkit"
+)
+
+// nodeScript prints the files of the toolkit directory, the paths of the OCI hooks, the CRI-O configuration files
+// and the nvidia-container-runtime configuration, the toolkit directory being its first argument.
+const nodeScript = `for file in /host$1/*; do [ -e "$file" ] && echo "` + filePrefix + `${file#/host}"; done
+for hook in /host/run/containers/oci/hooks.d/*.json /host/etc/containers/oci/hooks.d/*.json; do
+  [ -f "$hook" ] && grep -oE '"path"\s*:\s*"[^"]+"' "$hook" | sed -E 's/.*"([^"]+)"$/` + hookPrefix + `\1/'
+done
+for conf in /host/etc/crio/crio.conf /host/etc/crio/crio.conf.d/*; do
+  [ -f "$conf" ] && echo "` + crioMarker + `${conf#/host}" && cat "$conf" && echo
+done
+if [ -f /host$1/.config/nvidia-container-runtime/config.toml ]; then
+  echo "` + toolkitMarker + `"; cat /host$1/.config/nvidia-container-runtime/config.toml; echo
+fi
+`
+
+// Expected is the container toolkit configuration the ClusterPolicy asks for.
+type Expected struct {
+	// ToolkitDir is the host directory of the toolkit binaries.
+	ToolkitDir string
+	// RuntimeClass is the runtime class, and CRI-O runtime handler, of the GPU pods.
+	RuntimeClass string
+	// DriverRoot is DriverRoot when the ClusterPolicy deploys the driver, HostRoot otherwise.
+	DriverRoot               string
+	AcceptEnvvarUnprivileged bool
+	AcceptVolumeMounts       bool
+}
+
+// NodeConfig is the container toolkit and CRI-O configuration of a node.
+type NodeConfig struct {
+	// Files are the host paths of the files of the toolkit directory.
+	Files []string
+	// Hooks are the host paths of the OCI hooks CRI-O runs.
+	Hooks []string
+	// CRIORuntimes are the runtime paths of the CRI-O runtime handlers, by handler, the last file defining a handler
+	// winning as the CRI-O drop-ins do.
+	CRIORuntimes map[string]string
+	// Toolkit holds the config.toml settings by dotted key, nil when the file is not found on the node.
+	Toolkit map[string]string
+}
+
+// ExpectedFrom returns the container toolkit configuration the ClusterPolicy spec asks for. The visible devices
+// settings follow the toolkit defaults, switched to volume mounts by the device plugin volume-mounts list strategy,
+// then the toolkit environment of the ClusterPolicy.
+func ExpectedFrom(spec *nvidiagpuv1.ClusterPolicySpec) (*Expected, error) {
+	expected := &Expected{
+		ToolkitDir:               path.Join(DefaultInstallDir, "toolkit"),
+		RuntimeClass:             DefaultRuntimeClass,
+		DriverRoot:               HostRoot,
+		AcceptEnvvarUnprivileged: true,
+	}
+
+	if spec.Toolkit.InstallDir != "" {
+		expected.ToolkitDir = path.Join(spec.Toolkit.InstallDir, "toolkit")
+	}
+
+	if spec.Operator.RuntimeClass != "" {
+		expected.RuntimeClass = spec.Operator.RuntimeClass
+	}
+
+	if spec.Driver.IsEnabled() {
+		expected.DriverRoot = DriverRoot
+	}
+
+	for _, env := range spec.DevicePlugin.Env {
+		if env.Name == cdi.DeviceListStrategyEnv && strings.Contains(env.Value, volumeMountsStrategy) {
+			expected.AcceptEnvvarUnprivileged = false
+			expected.AcceptVolumeMounts = true
+		}
+	}
+
+	for _, env := range spec.Toolkit.Env {
+		var setting *bool
+
+		switch env.Name {
+		case acceptEnvvarUnprivilegedEnv:
+			setting = &expected.AcceptEnvvarUnprivileged
+		case acceptVolumeMountsEnv:
+			setting = &expected.AcceptVolumeMounts
+		default:
+			continue
+		}
+
+		value, err := strconv.ParseBool(env.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid toolkit env %s value %q: %w", env.Name, env.Value, err)
+		}
+
+		*setting = value
+	}
+
+	return expected, nil
+}
+
+// ParseTOML parses the flat settings of a TOML file by dotted key, e.g. "nvidia-container-cli.root" or
+// "crio.runtime.runtimes.nvidia.runtime_path", the string values being unquoted. Arrays and inline tables are
+// kept as written.
+func ParseTOML(content string) map[string]string {
+	settings := map[string]string{}
+	section := ""
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(strings.Trim(line, "[]"), `"`)
+
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		if section != "" {
+			key = section + "." + key
+		}
+
+		settings[key] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+
+	return settings
+}
+
+// ParseNodeConfig parses the output of the node pod.
+func ParseNodeConfig(output string) *NodeConfig {
+	nodeConfig := &NodeConfig{CRIORuntimes: map[string]string{}}
+
+	var (
+		block   string
+		content strings.Builder
+	)
+
+	flush := func() {
+		switch block {
+		case crioMarker:
+			for key, value := range ParseTOML(content.String()) {
+				if handler, found := strings.CutPrefix(key, crioRuntimesSection); found &&
+					strings.HasSuffix(handler, "."+crioRuntimePathKey) {
+					nodeConfig.CRIORuntimes[strings.TrimSuffix(handler, "."+crioRuntimePathKey)] = value
+				}
+			}
+		case toolkitMarker:
+			nodeConfig.Toolkit = ParseTOML(content.String())
+		}
+
+		content.Reset()
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, crioMarker):
+			flush()
+			block = crioMarker
+		case strings.HasPrefix(line, toolkitMarker):
+			flush()
+			block = toolkitMarker
+		case block != "":
+			content.WriteString(line + "\n")
+		case strings.HasPrefix(line, filePrefix):
+			nodeConfig.Files = append(nodeConfig.Files, strings.TrimSpace(strings.TrimPrefix(line, filePrefix)))
+		case strings.HasPrefix(line, hookPrefix):
+			nodeConfig.Hooks = append(nodeConfig.Hooks, strings.TrimSpace(strings.TrimPrefix(line, hookPrefix)))
+		}
+	}
+
+	flush()
+
+	return nodeConfig
+}
+
+// RuntimeDrift returns why CRI-O does not run the GPU containers through the toolkit of the node, empty when a CRI-O
+// runtime handler named after the runtime class, or else an OCI hook, runs a toolkit binary.
+func (nodeConfig *NodeConfig) RuntimeDrift(expected *Expected) []string {
+	if runtimePath, found := nodeConfig.CRIORuntimes[expected.RuntimeClass]; found {
+		return nodeConfig.toolkitBinaryDrift("CRI-O runtime "+expected.RuntimeClass, runtimePath, expected)
+	}
+
+	for _, hook := range nodeConfig.Hooks {
+		if path.Dir(hook) == expected.ToolkitDir {
+			return nodeConfig.toolkitBinaryDrift("OCI hook", hook, expected)
+		}
+	}
+
+	return []string{fmt.Sprintf("neither a CRI-O runtime %s nor an OCI hook of %s is configured, runtimes %v, hooks %v",
+		expected.RuntimeClass, expected.ToolkitDir, nodeConfig.CRIORuntimes, nodeConfig.Hooks)}
+}
+
+// HookDrift returns why the nvidia-container-runtime configuration does not point to the toolkit binaries and the
+// driver root, empty when it does.
+func (nodeConfig *NodeConfig) HookDrift(expected *Expected) []string {
+	if nodeConfig.Toolkit == nil {
+		return []string{fmt.Sprintf("config.toml not found in %s", expected.ToolkitDir)}
+	}
+
+	var drift []string
+
+	for _, key := range []string{CLIPathKey, HookPathKey} {
+		if value, found := nodeConfig.Toolkit[key]; found {
+			drift = append(drift, nodeConfig.toolkitBinaryDrift(key, value, expected)...)
+		}
+	}
+
+	if root := nodeConfig.Toolkit[CLIRootKey]; path.Clean("/"+root) != expected.DriverRoot {
+		drift = append(drift, fmt.Sprintf("%s is %q, not %q", CLIRootKey, root, expected.DriverRoot))
+	}
+
+	return drift
+}
+
+// VisibleDevicesDrift returns why the accept-nvidia-visible-devices settings do not match the expected ones, empty
+// when they do, the toolkit defaults applying to the settings config.toml does not set.
+func (nodeConfig *NodeConfig) VisibleDevicesDrift(expected *Expected) []string {
+	if nodeConfig.Toolkit == nil {
+		return []string{fmt.Sprintf("config.toml not found in %s", expected.ToolkitDir)}
+	}
+
+	var drift []string
+
+	for key, want := range map[string]bool{
+		AcceptEnvvarUnprivilegedKey: expected.AcceptEnvvarUnprivileged,
+		AcceptVolumeMountsKey:       expected.AcceptVolumeMounts,
+	} {
+		// the toolkit accepts the environment variable, and not the volume mounts, by default
+		setting := key == AcceptEnvvarUnprivilegedKey
+
+		if value, found := nodeConfig.Toolkit[key]; found {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				drift = append(drift, fmt.Sprintf("%s value %q is not a boolean", key, value))
+
+				continue
+			}
+
+			setting = parsed
+		}
+
+		if setting != want {
+			drift = append(drift, fmt.Sprintf("%s is %t, not %t", key, setting, want))
+		}
+	}
+
+	slices.Sort(drift)
+
+	return drift
+}
+
+// toolkitBinaryDrift returns why the binary referenced by the setting is not a file of the toolkit directory.
+func (nodeConfig *NodeConfig) toolkitBinaryDrift(setting, binary string, expected *Expected) []string {
+	if path.Dir(binary) != expected.ToolkitDir {
+		return []string{fmt.Sprintf("%s %s is not in %s", setting, binary, expected.ToolkitDir)}
+	}
+
+	if !slices.Contains(nodeConfig.Files, binary) {
+		return []string{fmt.Sprintf("%s %s does not exist", setting, binary)}
+	}
+
+	return nil
+}
+
+// RuntimeClassHandler returns the CRI handler of the runtime class.
+func RuntimeClassHandler(apiClient *clients.Settings, name string) (string, error) {
+	runtimeClass, err := apiClient.K8sClient.NodeV1().RuntimeClasses().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get RuntimeClass %s: %w", name, err)
+	}
+
+	return runtimeClass.Handler, nil
+}
+
+// GetNodeConfig returns the container toolkit and CRI-O configuration of the node, read on the host from a
+// privileged debug pod created in the namespace, which must allow privileged pods.
+func GetNodeConfig(apiClient *clients.Settings, nodeName, nsname, image, toolkitDir string,
+	timeout time.Duration) (*NodeConfig, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Reading the container toolkit configuration of node '%s'", nodeName)
+
+	nodePod, err := pod.NewBuilder(apiClient, NodePodName, nsname, image).
+		DefineOnNode(nodeName).
+		WithRestartPolicy(corev1.RestartPolicyNever).
+		WithToleration(corev1.Toleration{
+			Key:      string(cudasamples.GPUResource),
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		}).
+		RedefineDefaultCMD([]string{"/bin/sh", "-c", nodeScript, "sh", toolkitDir}).
+		WithPrivilegedFlag().
+		WithHostPathVolume(hostRootVolume, "/", "/host").
+		Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the toolkit node pod of node %s: %w", nodeName, err)
+	}
+
+	defer func() {
+		if _, err := nodePod.DeleteAndWait(timeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", NodePodName, err)
+		}
+	}()
+
+	if err := nodePod.WaitUntilInStatus(corev1.PodSucceeded, timeout); err != nil {
+		return nil, fmt.Errorf("toolkit node pod of node %s did not succeed: %w", nodeName, err)
+	}
+
+	output, err := nodePod.GetFullLog(nodePod.Definition.Spec.Containers[0].Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod %s log: %w", NodePodName, err)
+	}
+
+	return ParseNodeConfig(output), nil
+}
@@ -0,0 +1,288 @@
+package triton
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// ModelName is the name of the model served from the model repository built by CreateModelRepository.
+	ModelName = "add_sub"
+	// ModelInputSize is the number of FP32 values of each input and output tensor of the model.
+	ModelInputSize = 16
+	// ServerContainerName is the container name of the Triton server deployment.
+	ServerContainerName = "triton-server-ctr"
+	// ClientContainerName is the container name of the pod built by NewClientPod.
+	ClientContainerName = "test"
+	// HTTPPort is the port of the Triton HTTP/REST inference API.
+	HTTPPort = 8000
+	// GRPCPort is the port of the Triton gRPC inference API.
+	GRPCPort = 8001
+	// MetricsPort is the port of the Triton Prometheus metrics.
+	MetricsPort = 8002
+
+	modelRepositoryPath = "/models"
+	serverAppLabel      = "triton-server"
+)
+
+var (
+	isFalse = false
+	isTrue  = true
+)
+
+// modelConfig serves the model with the python backend on a GPU instance, so that Triton loads it on the GPU.
+var modelConfig = fmt.Sprintf(`name: "%s"
+backend: "python"
+max_batch_size: 0
+input [
+  { name: "INPUT0" data_type: TYPE_FP32 dims: [ %d ] },
+  { name: "INPUT1" data_type: TYPE_FP32 dims: [ %d ] }
+]
+output [
+  { name: "OUTPUT0" data_type: TYPE_FP32 dims: [ %d ] },
+  { name: "OUTPUT1" data_type: TYPE_FP32 dims: [ %d ] }
+]
+instance_group [ { kind: KIND_GPU count: 1 } ]
+`, ModelName, ModelInputSize, ModelInputSize, ModelInputSize, ModelInputSize)
+
+// modelCode returns the sum and the difference of its inputs.
+const modelCode = `import triton_python_backend_utils as pb_utils
+
+
+class TritonPythonModel:
+    def execute(self, requests):
+        responses = []
+        for request in requests:
+            in0 = pb_utils.get_input_tensor_by_name(request, "INPUT0").as_numpy()
+            in1 = pb_utils.get_input_tensor_by_name(request, "INPUT1").as_numpy()
+            out0 = pb_utils.Tensor("OUTPUT0", (in0 + in1).astype(in0.dtype))
+            out1 = pb_utils.Tensor("OUTPUT1", (in0 - in1).astype(in0.dtype))
+            responses.append(pb_utils.InferenceResponse(output_tensors=[out0, out1]))
+        return responses
+`
+
+// clientScript sends the same inference request through the HTTP and gRPC APIs with tritonclient and prints the
+// outputs, then prints the number of GPUs Triton exports metrics for.
+var clientScript = fmt.Sprintf(`import sys
+import urllib.request
+
+import numpy as np
+import tritonclient.grpc as grpcclient
+import tritonclient.http as httpclient
+
+host = sys.argv[1]
+in0 = np.arange(%[1]d, dtype=np.float32)
+in1 = np.ones(%[1]d, dtype=np.float32)
+
+for protocol, module, port in (("http", httpclient, %[2]d), ("grpc", grpcclient, %[3]d)):
+    client = module.InferenceServerClient(url=f"{host}:{port}")
+    if not client.is_server_ready() or not client.is_model_ready("%[4]s"):
+        sys.exit(f"{protocol}: server or model %[4]s is not ready")
+    inputs = [module.InferInput("INPUT0", [%[1]d], "FP32"), module.InferInput("INPUT1", [%[1]d], "FP32")]
+    inputs[0].set_data_from_numpy(in0)
+    inputs[1].set_data_from_numpy(in1)
+    result = client.infer("%[4]s", inputs)
+    for output in ("OUTPUT0", "OUTPUT1"):
+        print(protocol, output, " ".join(str(value) for value in result.as_numpy(output).tolist()))
+
+metrics = urllib.request.urlopen(f"http://{host}:%[5]d/metrics").read().decode()
+print("metrics gpus", sum(1 for line in metrics.splitlines() if line.startswith("nv_gpu_memory_total_bytes{")))
+`, ModelInputSize, HTTPPort, GRPCPort, ModelName, MetricsPort)
+
+// ClientResult is the output of the pod built by NewClientPod.
+type ClientResult struct {
+	// Outputs holds the output tensors by protocol, "http" or "grpc", then by output name.
+	Outputs map[string]map[string][]float64
+	// MetricsGPUs is the number of GPUs Triton exports metrics for.
+	MetricsGPUs int
+}
+
+// CreateModelRepository creates a configmap holding a model repository with the ModelName python model.
+func CreateModelRepository(apiClient *clients.Settings, name, nsname string) (*configmap.Builder, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating Triton model repository configmap '%s' in namespace '%s'", name,
+		nsname)
+
+	return configmap.NewBuilder(apiClient, name, nsname).WithData(map[string]string{
+		"config.pbtxt": modelConfig,
+		"model.py":     modelCode,
+	}).Create()
+}
+
+// CreateServerDeployment creates a single replica Triton server deployment on a GPU, serving the model repository
+// configmap, and the service exposing its HTTP, gRPC and metrics ports under the same name.
+func CreateServerDeployment(apiClient *clients.Settings, name, nsname, image, modelRepository string,
+	nodeSelector map[string]string) (*deployment.Builder, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Creating Triton server deployment '%s' in namespace '%s' with image '%s'",
+		name, nsname, image)
+
+	labels := map[string]string{"app": serverAppLabel, "instance": name}
+
+	container := &corev1.Container{
+		Name:            ServerContainerName,
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command: []string{"tritonserver", "--model-repository=" + modelRepositoryPath,
+			"--model-control-mode=none"},
+		Ports: []corev1.ContainerPort{
+			{Name: "http", ContainerPort: HTTPPort},
+			{Name: "grpc", ContainerPort: GRPCPort},
+			{Name: "metrics", ContainerPort: MetricsPort},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/v2/health/ready", Port: intstr.FromInt32(HTTPPort)},
+			},
+			PeriodSeconds: 10,
+		},
+		SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &isFalse,
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse("1"),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "models", MountPath: modelRepositoryPath, ReadOnly: true},
+			{Name: "dshm", MountPath: "/dev/shm"},
+		},
+	}
+
+	// The configmap keys are laid out as the version 1 of the model in the Triton model repository layout.
+	modelVolume := corev1.Volume{
+		Name: "models",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: modelRepository},
+				Items: []corev1.KeyToPath{
+					{Key: "config.pbtxt", Path: ModelName + "/config.pbtxt"},
+					{Key: "model.py", Path: ModelName + "/1/model.py"},
+				},
+			},
+		},
+	}
+
+	// The python backend exchanges the tensors with the model stub through shared memory.
+	shmVolume := corev1.Volume{
+		Name: "dshm",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+		},
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsname},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: HTTPPort},
+				{Name: "grpc", Port: GRPCPort},
+				{Name: "metrics", Port: MetricsPort},
+			},
+		},
+	}
+
+	if _, err := apiClient.Services(nsname).Create(context.TODO(), service, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create service %s: %w", name, err)
+	}
+
+	serverBuilder := deployment.NewBuilder(apiClient, name, nsname, labels, container).
+		WithNodeSelector(nodeSelector).
+		WithVolume(modelVolume).
+		WithVolume(shmVolume).
+		WithToleration(corev1.Toleration{
+			Key:      "nvidia.com/gpu",
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		}).
+		WithSecurityContext(&corev1.PodSecurityContext{
+			RunAsNonRoot:   &isTrue,
+			SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+		})
+	proxy.Inject(apiClient, &serverBuilder.Definition.Spec.Template.Spec)
+
+	return serverBuilder.Create()
+}
+
+// NewClientPod returns a pod builder running the inference requests against the Triton server service, with the
+// Triton SDK image shipping tritonclient.
+func NewClientPod(apiClient *clients.Settings, name, nsname, sdkImage, serverService string) *pod.Builder {
+	return pod.NewBuilder(apiClient, name, nsname, sdkImage).
+		RedefineDefaultCMD([]string{"python3", "-c", clientScript, serverService}).
+		WithRestartPolicy(corev1.RestartPolicyNever)
+}
+
+// ParseClientOutput parses the log of the pod built by NewClientPod.
+func ParseClientOutput(output string) (*ClientResult, error) {
+	result := &ClientResult{Outputs: map[string]map[string][]float64{}, MetricsGPUs: -1}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+
+		switch fields[0] {
+		case "metrics":
+			count, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid metrics GPU count in %q: %w", line, err)
+			}
+
+			result.MetricsGPUs = count
+		case "http", "grpc":
+			values := make([]float64, 0, len(fields)-2)
+
+			for _, field := range fields[2:] {
+				value, err := strconv.ParseFloat(field, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid output value in %q: %w", line, err)
+				}
+
+				values = append(values, value)
+			}
+
+			if result.Outputs[fields[0]] == nil {
+				result.Outputs[fields[0]] = map[string][]float64{}
+			}
+
+			result.Outputs[fields[0]][fields[1]] = values
+		}
+	}
+
+	if len(result.Outputs) == 0 {
+		return nil, fmt.Errorf("no inference output found in the client output")
+	}
+
+	return result, nil
+}
+
+// ExpectedOutputs returns the outputs of the model for the inputs sent by the client: 0 to ModelInputSize-1 and
+// ones.
+func ExpectedOutputs() map[string][]float64 {
+	expected := map[string][]float64{}
+
+	for i := 0; i < ModelInputSize; i++ {
+		expected["OUTPUT0"] = append(expected["OUTPUT0"], float64(i)+1)
+		expected["OUTPUT1"] = append(expected["OUTPUT1"], float64(i)-1)
+	}
+
+	return expected
+}
@@ -0,0 +1,25 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// AutoscalingLabels represents the range of labels that can be used for test cases selection.
+	AutoscalingLabels = append(gpuparams.Labels, LabelSuite, "autoscaling")
+
+	// AutoscalingReporterNamespacesToDump tells to the reporter from where to collect logs.
+	AutoscalingReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":         "nfd-operator",
+		"nvidia-gpu-operator":   "gpu-operator",
+		"openshift-machine-api": "machine-api",
+		"test-gpu-autoscaling":  "test-gpu-autoscaling",
+	}
+
+	// AutoscalingReporterCRDsToDump tells to the reporter what CRs to dump.
+	AutoscalingReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
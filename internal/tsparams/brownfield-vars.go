@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// BrownfieldLabels represents the range of labels that can be used for test cases selection.
+	BrownfieldLabels = append(gpuparams.Labels, LabelSuite, "brownfield")
+
+	// BrownfieldReporterNamespacesToDump tells to the reporter from where to collect logs.
+	BrownfieldReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-brownfield":     "test-brownfield",
+	}
+
+	// BrownfieldReporterCRDsToDump tells to the reporter what CRs to dump.
+	BrownfieldReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
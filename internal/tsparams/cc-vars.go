@@ -0,0 +1,25 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// CCLabels represents the range of labels that can be used for test cases selection.
+	CCLabels = append(gpuparams.Labels, LabelSuite, "cc")
+
+	// CCReporterNamespacesToDump tells to the reporter from where to collect logs.
+	CCReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":                           "nfd-operator",
+		"nvidia-gpu-operator":                     "gpu-operator",
+		"openshift-sandboxed-containers-operator": "sandboxed-containers-operator",
+		"test-cc": "test-cc",
+	}
+
+	// CCReporterCRDsToDump tells to the reporter what CRs to dump.
+	CCReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// CDILabels represents the range of labels that can be used for test cases selection.
+	CDILabels = append(gpuparams.Labels, LabelSuite, "cdi")
+
+	// CDIReporterNamespacesToDump tells to the reporter from where to collect logs.
+	CDIReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-cdi":            "test-cdi",
+	}
+
+	// CDIReporterCRDsToDump tells to the reporter what CRs to dump.
+	CDIReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// CgroupsLabels represents the range of labels that can be used for test cases selection.
+	CgroupsLabels = append(gpuparams.Labels, LabelSuite, "cgroups")
+
+	// CgroupsReporterNamespacesToDump tells to the reporter from where to collect logs.
+	CgroupsReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-cgroups":        "test-cgroups",
+	}
+
+	// CgroupsReporterCRDsToDump tells to the reporter what CRs to dump.
+	CgroupsReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
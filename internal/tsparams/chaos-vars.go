@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// ChaosLabels represents the range of labels that can be used for test cases selection.
+	ChaosLabels = append(gpuparams.Labels, LabelSuite, "chaos")
+
+	// ChaosReporterNamespacesToDump tells to the reporter from where to collect logs.
+	ChaosReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-gpu-chaos":      "test-gpu-chaos",
+	}
+
+	// ChaosReporterCRDsToDump tells to the reporter what CRs to dump.
+	ChaosReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
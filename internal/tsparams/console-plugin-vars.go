@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// ConsolePluginLabels represents the range of labels that can be used for test cases selection.
+	ConsolePluginLabels = append(gpuparams.Labels, LabelSuite, "console-plugin")
+
+	// ConsolePluginReporterNamespacesToDump tells to the reporter from where to collect logs.
+	ConsolePluginReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"openshift-console":   "console",
+	}
+
+	// ConsolePluginReporterCRDsToDump tells to the reporter what CRs to dump.
+	ConsolePluginReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -5,6 +5,10 @@ const (
 	LabelSuite = "gpu"
 	// GPUTestNamespace represents test case namespace name.
 	GPUTestNamespace = "test-gpu-burn"
+	// LabelGH200 selects the specs of the Grace Hopper (GH200) GPU nodes.
+	LabelGH200 = "gh200"
+	// LabelMultiNode selects the specs that need several nodes, skipped on Single-Node OpenShift.
+	LabelMultiNode = "multi-node"
 	// NetworkLabelSuite represents Netowrk Operator  label that can be used for test cases selection.
 	NetworkLabelSuite = "nno"
 )
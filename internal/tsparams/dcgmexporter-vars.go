@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// DCGMExporterLabels represents the range of labels that can be used for test cases selection.
+	DCGMExporterLabels = append(gpuparams.Labels, LabelSuite, "dcgm-exporter")
+
+	// DCGMExporterReporterNamespacesToDump tells to the reporter from where to collect logs.
+	DCGMExporterReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-dcgm-exporter":  "test-dcgm-exporter",
+	}
+
+	// DCGMExporterReporterCRDsToDump tells to the reporter what CRs to dump.
+	DCGMExporterReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
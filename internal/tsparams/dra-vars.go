@@ -0,0 +1,25 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// DRALabels represents the range of labels that can be used for test cases selection.
+	DRALabels = append(gpuparams.Labels, LabelSuite, "dra")
+
+	// DRAReporterNamespacesToDump tells to the reporter from where to collect logs.
+	DRAReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":         "nfd-operator",
+		"nvidia-gpu-operator":   "gpu-operator",
+		"nvidia-dra-driver-gpu": "dra-driver",
+		"test-dra":              "test-dra",
+	}
+
+	// DRAReporterCRDsToDump tells to the reporter what CRs to dump.
+	DRAReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
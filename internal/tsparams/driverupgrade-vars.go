@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// DriverUpgradeLabels represents the range of labels that can be used for test cases selection.
+	DriverUpgradeLabels = append(gpuparams.Labels, LabelSuite, "driver-upgrade")
+
+	// DriverUpgradeReporterNamespacesToDump tells to the reporter from where to collect logs.
+	DriverUpgradeReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-driver-upgrade": "test-driver-upgrade",
+	}
+
+	// DriverUpgradeReporterCRDsToDump tells to the reporter what CRs to dump.
+	DriverUpgradeReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// FIPSLabels represents the range of labels that can be used for test cases selection.
+	FIPSLabels = append(gpuparams.Labels, LabelSuite, "fips")
+
+	// FIPSReporterNamespacesToDump tells to the reporter from where to collect logs.
+	FIPSReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-gpu-fips":       "test-gpu-fips",
+	}
+
+	// FIPSReporterCRDsToDump tells to the reporter what CRs to dump.
+	FIPSReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
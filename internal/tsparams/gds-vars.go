@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// GDSLabels represents the range of labels that can be used for test cases selection.
+	GDSLabels = append(gpuparams.Labels, LabelSuite, "gds")
+
+	// GDSReporterNamespacesToDump tells to the reporter from where to collect logs.
+	GDSReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-gds":            "test-gds",
+	}
+
+	// GDSReporterCRDsToDump tells to the reporter what CRs to dump.
+	GDSReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,27 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	nfdv1 "github.com/openshift/cluster-nfd-operator/api/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	nfdv1alpha1 "github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd/api/v1alpha1"
+)
+
+var (
+	// GFDLabels represents the range of labels that can be used for test cases selection.
+	GFDLabels = append(gpuparams.Labels, LabelSuite, "gfd")
+
+	// GFDReporterNamespacesToDump tells to the reporter from where to collect logs.
+	GFDReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+	}
+
+	// GFDReporterCRDsToDump tells to the reporter what CRs to dump.
+	GFDReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+		{Cr: &nfdv1.NodeFeatureDiscoveryList{}},
+		{Cr: &nfdv1alpha1.NodeFeatureRuleList{}},
+	}
+)
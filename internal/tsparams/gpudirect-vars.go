@@ -0,0 +1,27 @@
+package tsparams
+
+import (
+	nvidianetworkv1alpha1 "github.com/Mellanox/network-operator/api/v1alpha1"
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// GPUDirectLabels represents the range of labels that can be used for test cases selection.
+	GPUDirectLabels = append(gpuparams.Labels, LabelSuite, NetworkLabelSuite, "gpudirect")
+
+	// GPUDirectReporterNamespacesToDump tells to the reporter from where to collect logs.
+	GPUDirectReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":           "nfd-operator",
+		"nvidia-gpu-operator":     "gpu-operator",
+		"nvidia-network-operator": "network-operator",
+		"test-gpudirect":          "test-gpudirect",
+	}
+
+	// GPUDirectReporterCRDsToDump tells to the reporter what CRs to dump.
+	GPUDirectReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+		{Cr: &nvidianetworkv1alpha1.NicClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,23 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// GPUSettingsLabels represents the range of labels that can be used for test cases selection.
+	GPUSettingsLabels = append(gpuparams.Labels, LabelSuite, "gpusettings")
+
+	// GPUSettingsReporterNamespacesToDump tells to the reporter from where to collect logs.
+	GPUSettingsReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+	}
+
+	// GPUSettingsReporterCRDsToDump tells to the reporter what CRs to dump.
+	GPUSettingsReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// HeterogeneousLabels represents the range of labels that can be used for test cases selection.
+	HeterogeneousLabels = append(gpuparams.Labels, LabelSuite, "heterogeneous")
+
+	// HeterogeneousReporterNamespacesToDump tells to the reporter from where to collect logs.
+	HeterogeneousReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":          "nfd-operator",
+		"nvidia-gpu-operator":    "gpu-operator",
+		"test-gpu-heterogeneous": "test-gpu-heterogeneous",
+	}
+
+	// HeterogeneousReporterCRDsToDump tells to the reporter what CRs to dump.
+	HeterogeneousReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,23 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// ImageSkewLabels represents the range of labels that can be used for test cases selection.
+	ImageSkewLabels = append(gpuparams.Labels, LabelSuite, "imageskew")
+
+	// ImageSkewReporterNamespacesToDump tells to the reporter from where to collect logs.
+	ImageSkewReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+	}
+
+	// ImageSkewReporterCRDsToDump tells to the reporter what CRs to dump.
+	ImageSkewReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
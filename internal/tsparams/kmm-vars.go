@@ -0,0 +1,25 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// KMMLabels represents the range of labels that can be used for test cases selection.
+	KMMLabels = append(gpuparams.Labels, LabelSuite, "kmm")
+
+	// KMMReporterNamespacesToDump tells to the reporter from where to collect logs.
+	KMMReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"openshift-kmm":       "kmm-operator",
+		"test-kmm":            "test-kmm",
+	}
+
+	// KMMReporterCRDsToDump tells to the reporter what CRs to dump.
+	KMMReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
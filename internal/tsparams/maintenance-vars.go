@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// MaintenanceLabels represents the range of labels that can be used for test cases selection.
+	MaintenanceLabels = append(gpuparams.Labels, LabelSuite, "maintenance")
+
+	// MaintenanceReporterNamespacesToDump tells to the reporter from where to collect logs.
+	MaintenanceReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":        "nfd-operator",
+		"nvidia-gpu-operator":  "gpu-operator",
+		"test-gpu-maintenance": "test-gpu-maintenance",
+	}
+
+	// MaintenanceReporterCRDsToDump tells to the reporter what CRs to dump.
+	MaintenanceReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
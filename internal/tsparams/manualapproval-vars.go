@@ -0,0 +1,23 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// ManualApprovalLabels represents the range of labels that can be used for test cases selection.
+	ManualApprovalLabels = append(gpuparams.Labels, LabelSuite, "manual-approval")
+
+	// ManualApprovalReporterNamespacesToDump tells to the reporter from where to collect logs.
+	ManualApprovalReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+	}
+
+	// ManualApprovalReporterCRDsToDump tells to the reporter what CRs to dump.
+	ManualApprovalReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
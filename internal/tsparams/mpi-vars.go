@@ -0,0 +1,25 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// MPILabels represents the range of labels that can be used for test cases selection.
+	MPILabels = append(gpuparams.Labels, LabelSuite, "mpi")
+
+	// MPIReporterNamespacesToDump tells to the reporter from where to collect logs.
+	MPIReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"mpi-operator":        "mpi-operator",
+		"test-mpi":            "test-mpi",
+	}
+
+	// MPIReporterCRDsToDump tells to the reporter what CRs to dump.
+	MPIReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// NCCLLabels represents the range of labels that can be used for test cases selection.
+	NCCLLabels = append(gpuparams.Labels, LabelSuite, "nccl")
+
+	// NCCLReporterNamespacesToDump tells to the reporter from where to collect logs.
+	NCCLReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-nccl":           "test-nccl",
+	}
+
+	// NCCLReporterCRDsToDump tells to the reporter what CRs to dump.
+	NCCLReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,27 @@
+package tsparams
+
+import (
+	nvidianetworkv1alpha1 "github.com/Mellanox/network-operator/api/v1alpha1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/networkparams"
+)
+
+var (
+	// NetworkOperatorLabels represents the range of labels that can be used for test cases selection.
+	NetworkOperatorLabels = append(networkparams.Labels, NetworkLabelSuite, "network-operator")
+
+	// NetworkOperatorReporterNamespacesToDump tells to the reporter from where to collect logs.
+	NetworkOperatorReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":           "nfd-operator",
+		"nvidia-network-operator": "network-operator",
+		"test-network-operator":   "test-network-operator",
+	}
+
+	// NetworkOperatorReporterCRDsToDump tells to the reporter what CRs to dump.
+	NetworkOperatorReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidianetworkv1alpha1.NicClusterPolicyList{}},
+		{Cr: &nvidianetworkv1alpha1.MacvlanNetworkList{}},
+		{Cr: &nvidianetworkv1alpha1.IPoIBNetworkList{}},
+		{Cr: &nvidianetworkv1alpha1.HostDeviceNetworkList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	"github.com/openshift-kni/k8sreporter"
+	nfdv1 "github.com/openshift/cluster-nfd-operator/api/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	nfdv1alpha1 "github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd/api/v1alpha1"
+)
+
+var (
+	// NFDRulesLabels represents the range of labels that can be used for test cases selection.
+	NFDRulesLabels = append(gpuparams.Labels, LabelSuite, "nfd-rules")
+
+	// NFDRulesReporterNamespacesToDump tells to the reporter from where to collect logs.
+	NFDRulesReporterNamespacesToDump = map[string]string{
+		"openshift-nfd": "nfd-operator",
+	}
+
+	// NFDRulesReporterCRDsToDump tells to the reporter what CRs to dump.
+	NFDRulesReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nfdv1.NodeFeatureDiscoveryList{}},
+		{Cr: &nfdv1alpha1.NodeFeatureRuleList{}},
+	}
+)
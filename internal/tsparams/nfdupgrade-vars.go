@@ -0,0 +1,25 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	nfdv1 "github.com/openshift/cluster-nfd-operator/api/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// NFDUpgradeLabels represents the range of labels that can be used for test cases selection.
+	NFDUpgradeLabels = append(gpuparams.Labels, LabelSuite, "nfd-upgrade")
+
+	// NFDUpgradeReporterNamespacesToDump tells to the reporter from where to collect logs.
+	NFDUpgradeReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+	}
+
+	// NFDUpgradeReporterCRDsToDump tells to the reporter what CRs to dump.
+	NFDUpgradeReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+		{Cr: &nfdv1.NodeFeatureDiscoveryList{}},
+	}
+)
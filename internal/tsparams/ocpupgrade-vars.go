@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// OCPUpgradeLabels represents the range of labels that can be used for test cases selection.
+	OCPUpgradeLabels = append(gpuparams.Labels, LabelSuite, "ocp-upgrade")
+
+	// OCPUpgradeReporterNamespacesToDump tells to the reporter from where to collect logs.
+	OCPUpgradeReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-ocp-upgrade":    "test-ocp-upgrade",
+	}
+
+	// OCPUpgradeReporterCRDsToDump tells to the reporter what CRs to dump.
+	OCPUpgradeReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// OperatorUpgradeLabels represents the range of labels that can be used for test cases selection.
+	OperatorUpgradeLabels = append(gpuparams.Labels, LabelSuite, "operator-upgrade")
+
+	// OperatorUpgradeReporterNamespacesToDump tells to the reporter from where to collect logs.
+	OperatorUpgradeReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":         "nfd-operator",
+		"nvidia-gpu-operator":   "gpu-operator",
+		"test-operator-upgrade": "test-operator-upgrade",
+	}
+
+	// OperatorUpgradeReporterCRDsToDump tells to the reporter what CRs to dump.
+	OperatorUpgradeReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// ProxyLabels represents the range of labels that can be used for test cases selection.
+	ProxyLabels = append(gpuparams.Labels, LabelSuite, "proxy")
+
+	// ProxyReporterNamespacesToDump tells to the reporter from where to collect logs.
+	ProxyReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-gpu-proxy":      "test-gpu-proxy",
+	}
+
+	// ProxyReporterCRDsToDump tells to the reporter what CRs to dump.
+	ProxyReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
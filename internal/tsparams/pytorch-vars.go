@@ -0,0 +1,23 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// PyTorchLabels represents the range of labels that can be used for test cases selection.
+	PyTorchLabels = append(gpuparams.Labels, LabelSuite, "pytorch")
+
+	// PyTorchReporterNamespacesToDump tells to the reporter from where to collect logs.
+	PyTorchReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+	}
+
+	// PyTorchReporterCRDsToDump tells to the reporter what CRs to dump.
+	PyTorchReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
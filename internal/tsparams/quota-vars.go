@@ -0,0 +1,30 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var (
+	// QuotaLabels represents the range of labels that can be used for test cases selection.
+	QuotaLabels = append(gpuparams.Labels, LabelSuite, "quota")
+
+	// QuotaReporterNamespacesToDump tells to the reporter from where to collect logs.
+	QuotaReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-quota":          "test-quota",
+	}
+
+	quotaTestNamespace = "test-quota"
+
+	// QuotaReporterCRDsToDump tells to the reporter what CRs to dump, the ResourceQuotas and LimitRanges of the test
+	// namespace showing the GPUs used and the defaults applied.
+	QuotaReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+		{Cr: &corev1.ResourceQuotaList{}, Namespace: &quotaTestNamespace},
+		{Cr: &corev1.LimitRangeList{}, Namespace: &quotaTestNamespace},
+	}
+)
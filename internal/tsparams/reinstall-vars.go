@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// ReinstallLabels represents the range of labels that can be used for test cases selection.
+	ReinstallLabels = append(gpuparams.Labels, LabelSuite, "reinstall")
+
+	// ReinstallReporterNamespacesToDump tells to the reporter from where to collect logs.
+	ReinstallReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-gpu-reinstall":  "test-gpu-reinstall",
+	}
+
+	// ReinstallReporterCRDsToDump tells to the reporter what CRs to dump.
+	ReinstallReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
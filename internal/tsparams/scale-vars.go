@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// ScaleLabels represents the range of labels that can be used for test cases selection.
+	ScaleLabels = append(gpuparams.Labels, LabelSuite, "scale")
+
+	// ScaleReporterNamespacesToDump tells to the reporter from where to collect logs.
+	ScaleReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-gpu-scale":      "test-gpu-scale",
+	}
+
+	// ScaleReporterCRDsToDump tells to the reporter what CRs to dump.
+	ScaleReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
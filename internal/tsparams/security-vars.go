@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// SecurityLabels represents the range of labels that can be used for test cases selection.
+	SecurityLabels = append(gpuparams.Labels, LabelSuite, "security")
+
+	// SecurityReporterNamespacesToDump tells to the reporter from where to collect logs.
+	SecurityReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-security":       "test-security",
+	}
+
+	// SecurityReporterCRDsToDump tells to the reporter what CRs to dump.
+	SecurityReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// SELinuxLabels represents the range of labels that can be used for test cases selection.
+	SELinuxLabels = append(gpuparams.Labels, LabelSuite, "selinux")
+
+	// SELinuxReporterNamespacesToDump tells to the reporter from where to collect logs.
+	SELinuxReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-selinux":        "test-selinux",
+	}
+
+	// SELinuxReporterCRDsToDump tells to the reporter what CRs to dump.
+	SELinuxReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// SoakLabels represents the range of labels that can be used for test cases selection.
+	SoakLabels = append(gpuparams.Labels, LabelSuite, "soak")
+
+	// SoakReporterNamespacesToDump tells to the reporter from where to collect logs.
+	SoakReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-gpu-soak":       "test-gpu-soak",
+	}
+
+	// SoakReporterCRDsToDump tells to the reporter what CRs to dump.
+	SoakReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,25 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// SpotLabels represents the range of labels that can be used for test cases selection.
+	SpotLabels = append(gpuparams.Labels, LabelSuite, "spot")
+
+	// SpotReporterNamespacesToDump tells to the reporter from where to collect logs.
+	SpotReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":         "nfd-operator",
+		"nvidia-gpu-operator":   "gpu-operator",
+		"openshift-machine-api": "machine-api",
+		"test-gpu-spot":         "test-gpu-spot",
+	}
+
+	// SpotReporterCRDsToDump tells to the reporter what CRs to dump.
+	SpotReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
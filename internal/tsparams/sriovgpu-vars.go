@@ -0,0 +1,25 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// SRIOVGPULabels represents the range of labels that can be used for test cases selection.
+	SRIOVGPULabels = append(gpuparams.Labels, LabelSuite, "sriov-gpu")
+
+	// SRIOVGPUReporterNamespacesToDump tells to the reporter from where to collect logs.
+	SRIOVGPUReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":                    "nfd-operator",
+		"nvidia-gpu-operator":              "gpu-operator",
+		"openshift-sriov-network-operator": "sriov-network-operator",
+		"test-sriov-gpu":                   "test-sriov-gpu",
+	}
+
+	// SRIOVGPUReporterCRDsToDump tells to the reporter what CRs to dump.
+	SRIOVGPUReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// StressLabels represents the range of labels that can be used for test cases selection.
+	StressLabels = append(gpuparams.Labels, LabelSuite, "stress")
+
+	// StressReporterNamespacesToDump tells to the reporter from where to collect logs.
+	StressReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-gpu-stress":     "test-gpu-stress",
+	}
+
+	// StressReporterCRDsToDump tells to the reporter what CRs to dump.
+	StressReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
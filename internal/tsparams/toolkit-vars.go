@@ -0,0 +1,23 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// ToolkitLabels represents the range of labels that can be used for test cases selection.
+	ToolkitLabels = append(gpuparams.Labels, LabelSuite, "toolkit")
+
+	// ToolkitReporterNamespacesToDump tells to the reporter from where to collect logs.
+	ToolkitReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+	}
+
+	// ToolkitReporterCRDsToDump tells to the reporter what CRs to dump.
+	ToolkitReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
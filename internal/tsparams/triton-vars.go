@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// TritonLabels represents the range of labels that can be used for test cases selection.
+	TritonLabels = append(gpuparams.Labels, LabelSuite, "triton")
+
+	// TritonReporterNamespacesToDump tells to the reporter from where to collect logs.
+	TritonReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-triton":         "test-triton",
+	}
+
+	// TritonReporterCRDsToDump tells to the reporter what CRs to dump.
+	TritonReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
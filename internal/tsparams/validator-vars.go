@@ -0,0 +1,23 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// ValidatorLabels represents the range of labels that can be used for test cases selection.
+	ValidatorLabels = append(gpuparams.Labels, LabelSuite, "validator")
+
+	// ValidatorReporterNamespacesToDump tells to the reporter from where to collect logs.
+	ValidatorReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+	}
+
+	// ValidatorReporterCRDsToDump tells to the reporter what CRs to dump.
+	ValidatorReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,28 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var (
+	// VGPULicensingLabels represents the range of labels that can be used for test cases selection.
+	VGPULicensingLabels = append(gpuparams.Labels, LabelSuite, "vgpu-licensing")
+
+	// VGPULicensingReporterNamespacesToDump tells to the reporter from where to collect logs.
+	VGPULicensingReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+	}
+
+	vgpuLicensingConfigMapNamespace = "nvidia-gpu-operator"
+
+	// VGPULicensingReporterCRDsToDump tells to the reporter what CRs to dump, the ConfigMaps of the GPU operator
+	// namespace include the licensing ConfigMap.
+	VGPULicensingReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+		{Cr: &corev1.ConfigMapList{}, Namespace: &vgpuLicensingConfigMapNamespace},
+	}
+)
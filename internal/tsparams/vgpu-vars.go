@@ -0,0 +1,25 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// VGPULabels represents the range of labels that can be used for test cases selection.
+	VGPULabels = append(gpuparams.Labels, LabelSuite, "vgpu")
+
+	// VGPUReporterNamespacesToDump tells to the reporter from where to collect logs.
+	VGPUReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"openshift-cnv":       "openshift-cnv",
+		"test-vgpu":           "test-vgpu",
+	}
+
+	// VGPUReporterCRDsToDump tells to the reporter what CRs to dump.
+	VGPUReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,24 @@
+package tsparams
+
+import (
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/openshift-kni/k8sreporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+)
+
+var (
+	// XIDLabels represents the range of labels that can be used for test cases selection.
+	XIDLabels = append(gpuparams.Labels, LabelSuite, "xid")
+
+	// XIDReporterNamespacesToDump tells to the reporter from where to collect logs.
+	XIDReporterNamespacesToDump = map[string]string{
+		"openshift-nfd":       "nfd-operator",
+		"nvidia-gpu-operator": "gpu-operator",
+		"test-gpu-xid":        "test-gpu-xid",
+	}
+
+	// XIDReporterCRDsToDump tells to the reporter what CRs to dump.
+	XIDReporterCRDsToDump = []k8sreporter.CRData{
+		{Cr: &nvidiagpuv1.ClusterPolicyList{}},
+	}
+)
@@ -0,0 +1,265 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/mig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/vgpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// OperatorValidatorLabel selects the validator pods of the nodes running GPU containers.
+	OperatorValidatorLabel = "app=nvidia-operator-validator"
+	// SandboxValidatorLabel selects the validator pods of the nodes running GPU virtual machines.
+	SandboxValidatorLabel = "app=nvidia-sandbox-validator"
+
+	// DriverValidation is the init container validating the driver, containerized or preinstalled on the host.
+	DriverValidation = "driver-validation"
+	// ToolkitValidation is the init container validating the container toolkit.
+	ToolkitValidation = "toolkit-validation"
+	// CUDAValidation is the init container running a CUDA workload.
+	CUDAValidation = "cuda-validation"
+	// PluginValidation is the init container running a workload allocating a GPU from the device plugin.
+	PluginValidation = "plugin-validation"
+	// VFIOPCIValidation is the sandbox init container validating the GPUs are bound to vfio-pci.
+	VFIOPCIValidation = "vfio-pci-validation"
+	// VGPUManagerValidation is the sandbox init container validating the vGPU manager.
+	VGPUManagerValidation = "vgpu-manager-validation"
+	// VGPUDevicesValidation is the sandbox init container validating the vGPU devices were created.
+	VGPUDevicesValidation = "vgpu-devices-validation"
+
+	workloadConfigContainer   = "container"
+	workloadConfigPassthrough = "vm-passthrough"
+)
+
+// Validation is the state of a validation init container of a validator pod.
+type Validation struct {
+	Name string
+	// Succeeded is true when the init container terminated with exit code 0.
+	Succeeded bool
+	// State describes the init container state, e.g. "terminated: Completed (exit code 0)" or "waiting: Error".
+	State    string
+	Restarts int32
+}
+
+// NodeValidations is what the validator pod of a node validated.
+type NodeValidations struct {
+	// Pod is the validator pod of the node.
+	Pod *pod.Builder
+	// Expected are the validations the validator pod must run on the node.
+	Expected []string
+	// Validations are the validation init containers of the pod, by name.
+	Validations map[string]Validation
+	// Ready is true when the validator container reporting all the validations succeeded is ready.
+	Ready bool
+}
+
+// ValidatorLabel returns the label of the validator pod of a node, which depends on the node workload config label.
+func ValidatorLabel(nodeLabels map[string]string) string {
+	workloadConfig, found := nodeLabels[vgpu.WorkloadConfigLabel]
+	if !found || workloadConfig == workloadConfigContainer {
+		return OperatorValidatorLabel
+	}
+
+	return SandboxValidatorLabel
+}
+
+// ExpectedValidations returns the validations the validator pod of a node with the labels must run according to the
+// ClusterPolicy spec. The validator pod may run more, optional, validations.
+func ExpectedValidations(spec *nvidiagpuv1.ClusterPolicySpec, nodeLabels map[string]string) []string {
+	switch nodeLabels[vgpu.WorkloadConfigLabel] {
+	case workloadConfigPassthrough:
+		if spec.VFIOManager.IsEnabled() {
+			return []string{VFIOPCIValidation}
+		}
+
+		return nil
+	case vgpu.WorkloadConfigVMVGPU:
+		var expected []string
+
+		if spec.VGPUManager.IsEnabled() {
+			expected = append(expected, VGPUManagerValidation)
+		}
+
+		if spec.VGPUDeviceManager.IsEnabled() {
+			expected = append(expected, VGPUDevicesValidation)
+		}
+
+		return expected
+	}
+
+	expected := []string{DriverValidation}
+
+	if spec.Toolkit.IsEnabled() {
+		expected = append(expected, ToolkitValidation)
+	}
+
+	expected = append(expected, CUDAValidation)
+
+	if spec.DevicePlugin.IsEnabled() {
+		expected = append(expected, PluginValidation)
+	}
+
+	return expected
+}
+
+// PodValidations returns the validation init containers of the validator pod, by name.
+func PodValidations(validatorPod *corev1.Pod) map[string]Validation {
+	validations := map[string]Validation{}
+
+	for _, status := range validatorPod.Status.InitContainerStatuses {
+		validation := Validation{Name: status.Name, Restarts: status.RestartCount}
+
+		switch {
+		case status.State.Terminated != nil:
+			validation.Succeeded = status.State.Terminated.ExitCode == 0
+			validation.State = fmt.Sprintf("terminated: %s (exit code %d)", status.State.Terminated.Reason,
+				status.State.Terminated.ExitCode)
+		case status.State.Running != nil:
+			validation.State = "running"
+		case status.State.Waiting != nil:
+			validation.State = "waiting: " + status.State.Waiting.Reason
+		}
+
+		validations[status.Name] = validation
+	}
+
+	return validations
+}
+
+// Incomplete returns why the validations of the node are not complete, empty when every expected validation ran and
+// succeeded, the optional validations the pod runs succeeded too, and the validator container is ready.
+func (nodeValidations *NodeValidations) Incomplete() []string {
+	var incomplete []string
+
+	for _, name := range nodeValidations.Expected {
+		if _, found := nodeValidations.Validations[name]; !found {
+			incomplete = append(incomplete, fmt.Sprintf("validation %s did not run", name))
+		}
+	}
+
+	for _, status := range nodeValidations.Pod.Object.Status.InitContainerStatuses {
+		if validation := nodeValidations.Validations[status.Name]; !validation.Succeeded {
+			incomplete = append(incomplete, fmt.Sprintf("validation %s did not succeed, %s after %d restarts",
+				validation.Name, validation.State, validation.Restarts))
+		}
+	}
+
+	if !nodeValidations.Ready {
+		incomplete = append(incomplete, fmt.Sprintf("validator pod %s is not ready", nodeValidations.Pod.Object.Name))
+	}
+
+	return incomplete
+}
+
+// MIGPending returns why the MIG manager did not apply the MIG configuration requested on the node, empty when it
+// applied it or none is requested, the plugin validation waiting for it.
+func MIGPending(nodeLabels map[string]string) string {
+	if _, found := nodeLabels[mig.MIGConfigLabel]; !found {
+		return ""
+	}
+
+	if state := nodeLabels[mig.MIGConfigStateLabel]; state != mig.MIGConfigStateSuccess {
+		return fmt.Sprintf("MIG configuration %s state is %q", nodeLabels[mig.MIGConfigLabel], state)
+	}
+
+	return ""
+}
+
+// GetNodeValidations returns what the validator pod of the node validated, expecting the validations of
+// ExpectedValidations.
+func GetNodeValidations(apiClient *clients.Settings, spec *nvidiagpuv1.ClusterPolicySpec, nodeName string,
+	nodeLabels map[string]string) (*NodeValidations, error) {
+	labelSelector := ValidatorLabel(nodeLabels)
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Reading the %s pod validations of node '%s'", labelSelector, nodeName)
+
+	validatorPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{
+		LabelSelector: labelSelector,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the %s pods of node %s: %w", labelSelector, nodeName, err)
+	}
+
+	if len(validatorPods) != 1 {
+		return nil, fmt.Errorf("found %d %s pods on node %s instead of 1", len(validatorPods), labelSelector,
+			nodeName)
+	}
+
+	validatorPod := validatorPods[0]
+	nodeValidations := &NodeValidations{
+		Pod:         validatorPod,
+		Expected:    ExpectedValidations(spec, nodeLabels),
+		Validations: PodValidations(validatorPod.Object),
+		Ready:       len(validatorPod.Object.Status.ContainerStatuses) > 0,
+	}
+
+	for _, status := range validatorPod.Object.Status.ContainerStatuses {
+		nodeValidations.Ready = nodeValidations.Ready && status.Ready
+	}
+
+	return nodeValidations, nil
+}
+
+// WaitForNodeValidations waits until the validations of the node are complete, and returns the validations last read
+// from the validator pod of the node, to be inspected when they did not complete.
+func WaitForNodeValidations(apiClient *clients.Settings, spec *nvidiagpuv1.ClusterPolicySpec, nodeName string,
+	nodeLabels map[string]string, pollInterval, timeout time.Duration) (*NodeValidations, error) {
+	var (
+		nodeValidations *NodeValidations
+		incomplete      []string
+	)
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			current, err := GetNodeValidations(apiClient, spec, nodeName, nodeLabels)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Info(err)
+
+				return false, nil
+			}
+
+			nodeValidations = current
+			incomplete = current.Incomplete()
+
+			return len(incomplete) == 0, nil
+		})
+	if err != nil {
+		return nodeValidations, fmt.Errorf("validations of node %s did not complete: %s: %w", nodeName,
+			strings.Join(incomplete, ", "), err)
+	}
+
+	return nodeValidations, nil
+}
+
+// Logs returns the logs of the validation init containers and of the validator container of the pod, by container
+// name, the containers whose log cannot be read having the error instead.
+func (nodeValidations *NodeValidations) Logs() map[string]string {
+	logs := map[string]string{}
+	podSpec := nodeValidations.Pod.Object.Spec
+
+	for _, container := range append(podSpec.InitContainers, podSpec.Containers...) {
+		log, err := nodeValidations.Pod.GetFullLog(container.Name)
+		if err != nil {
+			log = fmt.Sprintf("failed to get container %s log: %v", container.Name, err)
+		}
+
+		logs[container.Name] = log
+	}
+
+	return logs
+}
@@ -0,0 +1,188 @@
+package vgpu
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// LicensingConfigMapName is the name of the licensing ConfigMap referenced by the ClusterPolicy.
+	LicensingConfigMapName = "licensing-config"
+	// LicenseStatusLicensed prefixes the License Status reported by nvidia-smi -q once the vGPU is licensed.
+	LicenseStatusLicensed = "Licensed"
+
+	griddConfKey           = "gridd.conf"
+	clientTokenKey         = "client_configuration_token.tok"
+	licenseStatusField     = "License Status"
+	licensedProductSection = "vGPU Software Licensed Product"
+)
+
+// licensingLogKeywords select the driver container log lines related to licensing, dumped when licensing fails.
+var licensingLogKeywords = []string{"gridd", "licens"}
+
+// CreateLicensingConfigMap creates the licensing ConfigMap holding the gridd.conf with the feature type and the
+// NLS client configuration token, either of a CLS or of a DLS instance.
+func CreateLicensingConfigMap(apiClient *clients.Settings, configMapNamespace, clientToken,
+	featureType string) (*configmap.Builder, error) {
+	createdConfigMap, err := configmap.NewBuilder(apiClient, LicensingConfigMapName, configMapNamespace).
+		WithData(map[string]string{
+			griddConfKey:   fmt.Sprintf("FeatureType=%s\n", featureType),
+			clientTokenKey: clientToken,
+		}).Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create licensing ConfigMap %s in namespace %s: %w",
+			LicensingConfigMapName, configMapNamespace, err)
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Created licensing ConfigMap %s in namespace %s",
+		createdConfigMap.Object.Name, createdConfigMap.Object.Namespace)
+
+	return createdConfigMap, nil
+}
+
+// EnableGuestDriverLicensing sets the ClusterPolicy driver to the vGPU guest driver image and enables NLS
+// licensing with the licensing ConfigMap. It returns a copy of the previous ClusterPolicy spec so that it can be
+// restored.
+func EnableGuestDriverLicensing(apiClient *clients.Settings, clusterPolicyName, repository, image, version,
+	pullSecret string) (*nvidiagpuv1.ClusterPolicySpec, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Setting ClusterPolicy '%s' driver to vGPU guest driver '%s/%s:%s' "+
+		"licensed with ConfigMap '%s'", clusterPolicyName, repository, image, version, LicensingConfigMapName)
+
+	var previousSpec *nvidiagpuv1.ClusterPolicySpec
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousSpec = definition.Spec.DeepCopy()
+
+		driverSpec := &definition.Spec.Driver
+		driverSpec.Repository = repository
+		driverSpec.Image = image
+		driverSpec.Version = version
+		driverSpec.LicensingConfig = &nvidiagpuv1.DriverLicensingConfigSpec{
+			ConfigMapName: LicensingConfigMapName,
+			NLSEnabled:    &isTrue,
+		}
+
+		if pullSecret != "" {
+			driverSpec.ImagePullSecrets = []string{pullSecret}
+		}
+	})
+	if err != nil {
+		return previousSpec, fmt.Errorf("failed to enable vGPU licensing in ClusterPolicy %s: %w",
+			clusterPolicyName, err)
+	}
+
+	return previousSpec, nil
+}
+
+// NodeLicenseStatus returns the License Status reported by nvidia-smi -q in the driver pod of the node, along with
+// the nvidia-smi -q output.
+func NodeLicenseStatus(apiClient *clients.Settings, nodeName string) (string, string, error) {
+	driverPod, err := driverupgrade.NodeDriverPod(apiClient, nodeName)
+	if err != nil {
+		return "", "", err
+	}
+
+	output, err := driverPod.ExecCommand([]string{"nvidia-smi", "-q"}, driverupgrade.DriverContainerName)
+	if err != nil {
+		return "", output.String(), fmt.Errorf("failed to run nvidia-smi -q in pod %s: %w", driverPod.Object.Name,
+			err)
+	}
+
+	status, err := ParseLicenseStatus(output.String())
+
+	return status, output.String(), err
+}
+
+// ParseLicenseStatus returns the License Status of the vGPU Software Licensed Product section of nvidia-smi -q,
+// e.g. "Licensed (Expiry: 2025-1-31 12:0:0 GMT)" or "Unlicensed (Restricted)".
+func ParseLicenseStatus(output string) (string, error) {
+	if !strings.Contains(output, licensedProductSection) {
+		return "", fmt.Errorf("nvidia-smi -q has no '%s' section, the driver is not a vGPU guest driver",
+			licensedProductSection)
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		field, value, found := strings.Cut(line, ":")
+		if found && strings.TrimSpace(field) == licenseStatusField {
+			return strings.TrimSpace(value), nil
+		}
+	}
+
+	return "", fmt.Errorf("nvidia-smi -q has no '%s' field", licenseStatusField)
+}
+
+// WaitForLicensed waits until nvidia-smi -q reports a licensed vGPU on the node.
+func WaitForLicensed(apiClient *clients.Settings, nodeName string, pollInterval, timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(
+		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			status, _, err := NodeLicenseStatus(apiClient, nodeName)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Error getting node '%s' license status: %v", nodeName, err)
+
+				return false, nil
+			}
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' vGPU license status is '%s'", nodeName, status)
+
+			return strings.HasPrefix(status, LicenseStatusLicensed), nil
+		})
+}
+
+// LicensingDiagnostics returns the licensing section of nvidia-smi -q and the licensing related log lines of the
+// driver container of the node, to troubleshoot a vGPU that does not get licensed.
+func LicensingDiagnostics(apiClient *clients.Settings, nodeName string) string {
+	var diagnostics strings.Builder
+
+	fmt.Fprintf(&diagnostics, "==== Node %s vGPU licensing diagnostics ====\n", nodeName)
+
+	driverPod, err := driverupgrade.NodeDriverPod(apiClient, nodeName)
+	if err != nil {
+		fmt.Fprintf(&diagnostics, "%v\n", err)
+
+		return diagnostics.String()
+	}
+
+	_, smiOutput, err := NodeLicenseStatus(apiClient, nodeName)
+	if err != nil {
+		fmt.Fprintf(&diagnostics, "%v\n", err)
+	}
+
+	if _, section, found := strings.Cut(smiOutput, licensedProductSection); found {
+		fmt.Fprintf(&diagnostics, "---- nvidia-smi -q %s ----\n%s%s\n", licensedProductSection,
+			licensedProductSection, section)
+	}
+
+	logs, err := driverPod.GetFullLog(driverupgrade.DriverContainerName)
+	if err != nil {
+		fmt.Fprintf(&diagnostics, "failed to get pod %s logs: %v\n", driverPod.Object.Name, err)
+
+		return diagnostics.String()
+	}
+
+	fmt.Fprintf(&diagnostics, "---- pod %s licensing logs ----\n", driverPod.Object.Name)
+
+	for _, line := range strings.Split(logs, "\n") {
+		lowerLine := strings.ToLower(line)
+
+		for _, keyword := range licensingLogKeywords {
+			if strings.Contains(lowerLine, keyword) {
+				fmt.Fprintln(&diagnostics, line)
+
+				break
+			}
+		}
+	}
+
+	return diagnostics.String()
+}
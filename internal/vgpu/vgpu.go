@@ -0,0 +1,93 @@
+package vgpu
+
+import (
+	"fmt"
+	"strings"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/kubevirt"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// WorkloadConfigLabel selects the GPU workload type the GPU operator configures on a node.
+	WorkloadConfigLabel = "nvidia.com/gpu.workload.config"
+	// WorkloadConfigVMVGPU is the WorkloadConfigLabel value for nodes running vGPU backed VMs.
+	WorkloadConfigVMVGPU = "vm-vgpu"
+	// VGPUConfigLabel selects the vGPU device manager config applied on a node.
+	VGPUConfigLabel = "nvidia.com/vgpu.config"
+	// VGPUManagerImage is the name of the vGPU manager image built from the NVIDIA vGPU host driver.
+	VGPUManagerImage = "vgpu-manager"
+
+	// GuestCheckBeginMarker is written to the VM serial console before the guest nvidia-smi output.
+	GuestCheckBeginMarker = "NVIDIA-CI-VGPU-CHECK-BEGIN"
+	// GuestCheckEndMarker is written to the VM serial console after the guest nvidia-smi output.
+	GuestCheckEndMarker = "NVIDIA-CI-VGPU-CHECK-END"
+)
+
+var isTrue = true
+
+// ResourceName returns the extended resource name the sandbox device plugin advertises for a mediated device type,
+// e.g. "NVIDIA A10-2Q" is advertised as "nvidia.com/NVIDIA_A10-2Q".
+func ResourceName(mdevType string) string {
+	return "nvidia.com/" + strings.ReplaceAll(mdevType, " ", "_")
+}
+
+// EnableVGPUInClusterPolicy enables sandbox workloads and deploys the vGPU manager and vGPU device manager from the
+// given vGPU manager image repository and version. The default workload is left untouched, vGPU nodes are selected
+// through the WorkloadConfigLabel. It returns a copy of the previous ClusterPolicy spec so that it can be restored.
+func EnableVGPUInClusterPolicy(apiClient *clients.Settings, clusterPolicyName, vgpuManagerRepository,
+	vgpuManagerVersion string) (*nvidiagpuv1.ClusterPolicySpec, error) {
+	glog.V(gpuparams.GpuLogLevel).Infof("Enabling vGPU in ClusterPolicy '%s' with vGPU manager %s/%s:%s",
+		clusterPolicyName, vgpuManagerRepository, VGPUManagerImage, vgpuManagerVersion)
+
+	var previousSpec *nvidiagpuv1.ClusterPolicySpec
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousSpec = definition.Spec.DeepCopy()
+
+		spec := &definition.Spec
+		spec.SandboxWorkloads.Enabled = &isTrue
+		spec.SandboxDevicePlugin.Enabled = &isTrue
+		spec.VGPUDeviceManager.Enabled = &isTrue
+		spec.VGPUManager.Enabled = &isTrue
+		spec.VGPUManager.Repository = vgpuManagerRepository
+		spec.VGPUManager.Image = VGPUManagerImage
+		spec.VGPUManager.Version = vgpuManagerVersion
+	})
+	if err != nil {
+		return previousSpec, fmt.Errorf("failed to enable vGPU in ClusterPolicy %s: %w", clusterPolicyName, err)
+	}
+
+	return previousSpec, nil
+}
+
+// GuestCheckUserData returns cloud-init user data that prints the guest nvidia-smi output to the serial console
+// between GuestCheckBeginMarker and GuestCheckEndMarker.
+func GuestCheckUserData() string {
+	return fmt.Sprintf(`#cloud-config
+runcmd:
+  - sh -c 'echo %[1]s > /dev/ttyS0; nvidia-smi -L > /dev/ttyS0 2>&1; echo "%[2]s rc=$?" > /dev/ttyS0'
+`, GuestCheckBeginMarker, GuestCheckEndMarker)
+}
+
+// GetGuestConsoleLog returns the serial console log of a VirtualMachine from its virt-launcher pod.
+func GetGuestConsoleLog(apiClient *clients.Settings, vmName, vmNamespace string) (string, error) {
+	launcherPods, err := pod.List(apiClient, vmNamespace,
+		metav1.ListOptions{LabelSelector: fmt.Sprintf("vm.kubevirt.io/name=%s", vmName)})
+	if err != nil {
+		return "", fmt.Errorf("failed to list virt-launcher pods of VirtualMachine %s: %w", vmName, err)
+	}
+
+	if len(launcherPods) == 0 {
+		return "", fmt.Errorf("no virt-launcher pod found for VirtualMachine %s in namespace %s", vmName,
+			vmNamespace)
+	}
+
+	return launcherPods[0].GetFullLog(kubevirt.GuestConsoleLogContainer)
+}
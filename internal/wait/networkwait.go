@@ -2,14 +2,19 @@ package wait
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidianetwork"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/sriov"
 
 	"github.com/golang/glog"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/networkparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/ofed"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
-	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/timeouts"
 
 	networkoperator "github.com/Mellanox/network-operator/api/v1alpha1"
 )
@@ -17,62 +22,96 @@ import (
 // NicClusterPolicyReady Waits until nicClusterPolicy is Ready.
 func NicClusterPolicyReady(apiClient *clients.Settings, nicClusterPolicyName string, pollInterval,
 	timeout time.Duration) error {
-	return wait.PollUntilContextTimeout(
-		context.Background(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
-			nicClusterPolicy, err := nvidianetwork.PullNicClusterPolicy(apiClient, nicClusterPolicyName)
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.DriverReady, timeout)
 
+	return await.For(context.Background(), fmt.Sprintf("NicClusterPolicy %s to be ready", nicClusterPolicyName),
+		pollInterval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+			nicClusterPolicy, err := nvidianetwork.PullNicClusterPolicy(apiClient, nicClusterPolicyName)
 			if err != nil {
-				glog.V(networkparams.LogLevel).Infof("NicClusterPolicy pull from cluster error: %s\n", err)
-
-				return false, err
+				return nil, false, err
 			}
 
 			glog.V(networkparams.LogLevel).Infof("NicClusterPolicy %s in now in %s state",
 				nicClusterPolicy.Object.Name, nicClusterPolicy.Object.Status.State)
 
-			// returns true, nil when NicClusterPolicy is ready, this exits out of the PollUntilContextTimeout()
-			return nicClusterPolicy.Object.Status.State == networkoperator.StateReady, nil
+			return fmt.Sprintf("state %s", nicClusterPolicy.Object.Status.State),
+				nicClusterPolicy.Object.Status.State == networkoperator.StateReady, nil
 		})
 }
 
 // MacvlanNetworkReady Waits until macvlanNetwork is Ready.
 func MacvlanNetworkReady(apiClient *clients.Settings, macvlanNetworkName string, pollInterval,
 	timeout time.Duration) error {
-	return wait.PollUntilContextTimeout(
-		context.Background(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
-			macVlanNetwork, err := nvidianetwork.PullMacvlanNetwork(apiClient, macvlanNetworkName)
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.DriverReady, timeout)
 
+	return await.For(context.Background(), fmt.Sprintf("MacvlanNetwork %s to be ready", macvlanNetworkName),
+		pollInterval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+			macVlanNetwork, err := nvidianetwork.PullMacvlanNetwork(apiClient, macvlanNetworkName)
 			if err != nil {
-				glog.V(networkparams.LogLevel).Infof("MacvlanNetwork pull from cluster error: %s\n", err)
-
-				return false, err
+				return nil, false, err
 			}
 
 			glog.V(networkparams.LogLevel).Infof("MacvlanNetwork %s in now in %s state",
 				macVlanNetwork.Object.Name, macVlanNetwork.Object.Status.State)
 
-			// returns true, nil when MacvlanNetwork is ready, this exits out of the PollUntilContextTimeout()
-			return macVlanNetwork.Object.Status.State == networkoperator.StateReady, nil
+			return fmt.Sprintf("state %s", macVlanNetwork.Object.Status.State),
+				macVlanNetwork.Object.Status.State == networkoperator.StateReady, nil
 		})
 }
 
 // IPoIBNetworkReady Waits until ipoibNetwork is Ready.
 func IPoIBNetworkReady(apiClient *clients.Settings, ipoibNetworkName string, pollInterval,
 	timeout time.Duration) error {
-	return wait.PollUntilContextTimeout(
-		context.Background(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
-			ipoIBNetwork, err := nvidianetwork.PullIPoIBNetwork(apiClient, ipoibNetworkName)
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.DriverReady, timeout)
 
+	return await.For(context.Background(), fmt.Sprintf("IPoIBNetwork %s to be ready", ipoibNetworkName),
+		pollInterval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+			ipoIBNetwork, err := nvidianetwork.PullIPoIBNetwork(apiClient, ipoibNetworkName)
 			if err != nil {
-				glog.V(networkparams.LogLevel).Infof("IPoIBNetwork pull from cluster error: %s\n", err)
-
-				return false, err
+				return nil, false, err
 			}
 
 			glog.V(networkparams.LogLevel).Infof("IPoIBNetwork %s in now in %s state",
 				ipoIBNetwork.Object.Name, ipoIBNetwork.Object.Status.State)
 
-			// returns true, nil when IPoIBNetwork is ready, this exits out of the PollUntilContextTimeout()
-			return ipoIBNetwork.Object.Status.State == networkoperator.StateReady, nil
+			return fmt.Sprintf("state %s", ipoIBNetwork.Object.Status.State),
+				ipoIBNetwork.Object.Status.State == networkoperator.StateReady, nil
+		})
+}
+
+// SriovNodeStatesSynced waits until the SR-IOV config daemon of every node applied the SriovNetworkNodePolicies.
+func SriovNodeStatesSynced(apiClient *clients.Settings, nodeNames []string, pollInterval,
+	timeout time.Duration) error {
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.DriverReady, timeout)
+
+	return await.For(context.Background(), fmt.Sprintf("the SriovNetworkNodeStates of nodes %v to sync", nodeNames),
+		pollInterval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+			// The node states stay unavailable while the nodes reboot to apply the VF configuration.
+			synced, err := sriov.NodeStatesSynced(apiClient, nodeNames)
+			if err != nil {
+				return nil, false, err
+			}
+
+			return fmt.Sprintf("synced: %t", synced), synced, nil
+		})
+}
+
+// OFEDDriverRolledOut waits until every MOFED/DOCA driver pod runs a ready driver container of the version.
+func OFEDDriverRolledOut(apiClient *clients.Settings, version string, pollInterval, timeout time.Duration) error {
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.DriverReady, timeout)
+
+	return await.For(context.Background(), fmt.Sprintf("the OFED driver %s to roll out", version), pollInterval,
+		timeout, func(ctx context.Context) (interface{}, bool, error) {
+			rolledOut, err := ofed.DriverRolledOut(apiClient, version)
+			if err != nil {
+				return nil, false, err
+			}
+
+			return fmt.Sprintf("rolled out: %t", rolledOut), rolledOut, nil
 		})
 }
@@ -2,104 +2,268 @@ package wait
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
 	"github.com/golang/glog"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clusterversion"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
-	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/machine"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/timeouts"
+	watchwait "github.com/rh-ecosystem-edge/nvidia-ci/pkg/wait"
+	corev1 "k8s.io/api/core/v1"
 )
 
-// ClusterPolicyReady Waits until clusterPolicy is Ready.
-func ClusterPolicyReady(apiClient *clients.Settings, clusterPolicyName string, pollInterval,
+// ClusterPolicyReady Waits until clusterPolicy is Ready. The ClusterPolicy is watched.
+func ClusterPolicyReady(apiClient *clients.Settings, clusterPolicyName string, timeout time.Duration) error {
+	timeout = timeouts.Scale(timeouts.DriverReady, timeout)
+
+	ctx, cancel := await.Context(context.TODO(), timeout)
+	defer cancel()
+
+	err := watchwait.ClusterPolicyState(ctx, apiClient, clusterPolicyName, nvidiagpuv1.Ready)
+	if err != nil {
+		glog.V(gpuparams.GpuLogLevel).Infof("ClusterPolicy %s is not ready: %v", clusterPolicyName, err)
+
+		return err
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("ClusterPolicy %s in now in %s state", clusterPolicyName, nvidiagpuv1.Ready)
+
+	return nil
+}
+
+// CSVSucceeded waits for a defined period of time for CSV to be in Succeeded state.
+func CSVSucceeded(apiClient *clients.Settings, csvName, csvNamespace string, pollInterval,
 	timeout time.Duration) error {
-	return wait.PollUntilContextTimeout(
-		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
-			clusterPolicy, err := nvidiagpu.Pull(apiClient, clusterPolicyName)
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.OperatorInstall, timeout)
 
+	return await.For(context.TODO(), fmt.Sprintf("ClusterServiceVersion %s/%s to succeed", csvNamespace, csvName),
+		pollInterval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+			csvPulled, err := olm.PullClusterServiceVersion(apiClient, csvName, csvNamespace)
 			if err != nil {
-				glog.V(gpuparams.GpuLogLevel).Infof("ClusterPolicy pull from cluster error: %s\n", err)
+				return nil, false, err
+			}
+
+			glog.V(gpuparams.GpuLogLevel).Infof("ClusterServiceVersion %s in now in %s state",
+				csvPulled.Object.Name, csvPulled.Object.Status.Phase)
 
-				return false, err
+			return fmt.Sprintf("phase %s: %s", csvPulled.Object.Status.Phase, csvPulled.Object.Status.Message),
+				csvPulled.Object.Status.Phase == v1alpha1.CSVPhaseSucceeded, nil
+		})
+}
+
+// DeploymentCreated waits for a defined period of time for deployment to be created.
+func DeploymentCreated(apiClient *clients.Settings, deploymentName, deploymentNamespace string, pollInterval,
+	timeout time.Duration) bool {
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.OperatorInstall, timeout)
+
+	// Note: the first check waits for the polling interval, the first check right away was causing an error and
+	//       failing testcase.
+	time.Sleep(pollInterval)
+
+	err := await.For(context.TODO(),
+		fmt.Sprintf("deployment %s/%s to be created", deploymentNamespace, deploymentName), pollInterval, timeout,
+		func(ctx context.Context) (interface{}, bool, error) {
+			deploymentPulled, err := deployment.Pull(apiClient, deploymentName, deploymentNamespace)
+			if err != nil {
+				return nil, false, err
 			}
 
-			if clusterPolicy.Object != nil && clusterPolicy.Object.Status.State == "ready" {
-				glog.V(gpuparams.GpuLogLevel).Infof("ClusterPolicy %s in now in %s state",
-					clusterPolicy.Object.Name, clusterPolicy.Object.Status.State)
+			if !deploymentPulled.Exists() {
+				return "not created", false, nil
+			}
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Deployment '%s' in namespace '%s' has been created",
+				deploymentPulled.Object.Name, deploymentNamespace)
+
+			return "created", true, nil
+		})
+	if err != nil {
+		glog.V(gpuparams.GpuLogLevel).Infof("Deployment '%s' in namespace '%s' was not created: %v", deploymentName,
+			deploymentNamespace, err)
+	}
+
+	return err == nil
+}
+
+// NodeAllocatable waits until the node advertises at least the expected count of an extended resource.
+func NodeAllocatable(apiClient *clients.Settings, nodeName string, resourceName corev1.ResourceName,
+	expected int64, pollInterval, timeout time.Duration) error {
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.DriverReady, timeout)
 
-				// this exits out of the PollUntilContextTimeout()
-				return true, nil
+	return await.For(context.TODO(), fmt.Sprintf("node %s to advertise %d %s", nodeName, expected, resourceName),
+		pollInterval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+			nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+			if err != nil {
+				return nil, false, err
 			}
-			if clusterPolicy.Object == nil {
-				glog.V(gpuparams.GpuLogLevel).Info("ClusterPolicy object is nil")
-				return false, nil
+
+			quantity, ok := nodeBuilder.Object.Status.Allocatable[resourceName]
+			if !ok {
+				return fmt.Sprintf("no allocatable %s", resourceName), false, nil
 			}
 
-			glog.V(gpuparams.GpuLogLevel).Infof("ClusterPolicy %s in now in %s state",
-				clusterPolicy.Object.Name, clusterPolicy.Object.Status.State)
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' allocatable '%s' is %d, expecting %d",
+				nodeName, resourceName, quantity.Value(), expected)
 
-			return false, nil
+			return fmt.Sprintf("%d allocatable %s", quantity.Value(), resourceName), quantity.Value() >= expected, nil
 		})
 }
 
-// CSVSucceeded waits for a defined period of time for CSV to be in Succeeded state.
-func CSVSucceeded(apiClient *clients.Settings, csvName, csvNamespace string, pollInterval,
+// NodeLabels waits until the node has all the labels with their values when present is true, or none of the label
+// keys when present is false.
+func NodeLabels(apiClient *clients.Settings, nodeName string, labels map[string]string, present bool, pollInterval,
 	timeout time.Duration) error {
-	return wait.PollUntilContextTimeout(
-		context.TODO(), pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
-			csvPulled, err := olm.PullClusterServiceVersion(apiClient, csvName, csvNamespace)
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.DriverReady, timeout)
+
+	what := fmt.Sprintf("node %s to have the labels %v", nodeName, labels)
+	if !present {
+		what = fmt.Sprintf("node %s to lose the labels %v", nodeName, labels)
+	}
 
+	return await.For(context.TODO(), what, pollInterval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+		nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+		if err != nil {
+			return nil, false, err
+		}
+
+		for key, value := range labels {
+			nodeValue, found := nodeBuilder.Object.Labels[key]
+			if present && (!found || nodeValue != value) {
+				return fmt.Sprintf("label %s is %q", key, nodeValue), false, nil
+			}
+
+			if !present && found {
+				return fmt.Sprintf("label %s is still set to %q", key, nodeValue), false, nil
+			}
+		}
+
+		return "", true, nil
+	})
+}
+
+// InstallPlanRequiresApproval waits until the Subscription references an installplan pending manual approval that
+// installs the Subscription current CSV.
+func InstallPlanRequiresApproval(apiClient *clients.Settings, subscriptionName, subscriptionNamespace string,
+	pollInterval, timeout time.Duration) error {
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.OperatorInstall, timeout)
+
+	return await.For(context.TODO(), fmt.Sprintf("an installplan of Subscription %s/%s to require approval",
+		subscriptionNamespace, subscriptionName), pollInterval, timeout,
+		func(ctx context.Context) (interface{}, bool, error) {
+			subPulled, err := olm.PullSubscription(apiClient, subscriptionName, subscriptionNamespace)
 			if err != nil {
-				glog.V(gpuparams.GpuLogLevel).Infof("ClusterServiceVersion pull from cluster error: %s\n", err)
+				return nil, false, err
+			}
 
-				return false, err
+			installPlan, err := subPulled.GetInstallPlan()
+			if err != nil {
+				return nil, false, err
 			}
 
-			if csvPulled.Object.Status.Phase == "Succeeded" {
-				glog.V(gpuparams.GpuLogLevel).Infof("ClusterServiceVersion %s in now in %s state",
-					csvPulled.Object.Name, csvPulled.Object.Status.Phase)
+			observed := fmt.Sprintf("InstallPlan %s for CSVs %v is in phase %s, approved: %t, current CSV %s",
+				installPlan.Object.Name, installPlan.Object.Spec.ClusterServiceVersionNames,
+				installPlan.Object.Status.Phase, installPlan.Object.Spec.Approved, subPulled.Object.Status.CurrentCSV)
 
-				// this exists out of the wait.PollImmediate().
-				return true, nil
+			if installPlan.Object.Status.Phase != v1alpha1.InstallPlanPhaseRequiresApproval ||
+				installPlan.Object.Spec.Approved {
+				return observed, false, nil
 			}
 
-			glog.V(gpuparams.GpuLogLevel).Infof("clusterPolicy %s in now in %s state",
-				csvPulled.Object.Name, csvPulled.Object.Status.Phase)
+			for _, csvName := range installPlan.Object.Spec.ClusterServiceVersionNames {
+				if csvName == subPulled.Object.Status.CurrentCSV {
+					return observed, true, nil
+				}
+			}
 
-			return false, err
+			return observed, false, nil
 		})
 }
 
-// DeploymentCreated waits for a defined period of time for deployment to be created.
-func DeploymentCreated(apiClient *clients.Settings, deploymentName, deploymentNamespace string, pollInterval,
-	timeout time.Duration) bool {
-	// Note: the value for boolean variable "immediate" is false here, meaning check AFTER polling interval
-	//       on the very first try.  Otherwise the first check was causing an error and failing testcase.
-	err := wait.PollUntilContextTimeout(
-		context.TODO(), pollInterval, timeout, false, func(ctx context.Context) (bool, error) {
-			var err error
-			deploymentPulled, err := deployment.Pull(apiClient, deploymentName, deploymentNamespace)
+// InstallPlansPending waits until at least one installplan of the namespace is pending manual approval.
+func InstallPlansPending(apiClient *clients.Settings, nsname string, pollInterval, timeout time.Duration) error {
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.OperatorInstall, timeout)
 
+	return await.For(context.TODO(), fmt.Sprintf("an installplan of namespace %s to be pending approval", nsname),
+		pollInterval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+			pendingInstallPlans, err := olm.ListPendingInstallPlans(apiClient, nsname)
 			if err != nil {
-				glog.V(gpuparams.GpuLogLevel).Infof("Deployment '%s' pull from cluster namespace '%s' error:"+
-					" %v", deploymentName, deploymentNamespace, err)
-
-				return false, err
+				return nil, false, err
 			}
 
-			if deploymentPulled.Exists() {
-				glog.V(gpuparams.GpuLogLevel).Infof("Deployment '%s' in namespace '%s' has been created",
-					deploymentPulled.Object.Name, deploymentNamespace)
+			return fmt.Sprintf("%d installplan(s) pending approval", len(pendingInstallPlans)),
+				len(pendingInstallPlans) > 0, nil
+		})
+}
+
+// SubscriptionInstalledCSV waits until the Subscription reports the given CSV as installed.
+func SubscriptionInstalledCSV(apiClient *clients.Settings, subscriptionName, subscriptionNamespace,
+	csvName string, pollInterval, timeout time.Duration) error {
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.OperatorInstall, timeout)
+
+	return await.For(context.TODO(), fmt.Sprintf("Subscription %s/%s to install CSV %s", subscriptionNamespace,
+		subscriptionName, csvName), pollInterval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+		subPulled, err := olm.PullSubscription(apiClient, subscriptionName, subscriptionNamespace)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return fmt.Sprintf("installed CSV %q", subPulled.Object.Status.InstalledCSV),
+			subPulled.Object.Status.InstalledCSV == csvName, nil
+	})
+}
+
+// ClusterVersionUpdated waits until the cluster completes upgrading to the given version or release image. The
+// API server is expected to be briefly unavailable during a cluster upgrade, the errors being retried.
+func ClusterVersionUpdated(apiClient *clients.Settings, version, image string, pollInterval,
+	timeout time.Duration) error {
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.DriverReady, timeout)
+
+	return await.For(context.TODO(), fmt.Sprintf("the cluster to update to version %q image %q", version, image),
+		pollInterval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+			clusterVersionBuilder, err := clusterversion.Pull(apiClient)
+			if err != nil {
+				return nil, false, err
+			}
 
-				// this exists out of the wait.PollImmediate().
-				return true, nil
+			completed, err := clusterVersionBuilder.IsUpdateCompleted(version, image)
+			if err != nil {
+				return nil, false, err
 			}
 
-			return false, nil
+			return fmt.Sprintf("desired version %s", clusterVersionBuilder.Object.Status.Desired.Version),
+				completed, nil
 		})
+}
 
-	return err == nil
+// MachineConfigPoolsUpdated waits until every MachineConfigPool runs its rendered configuration on all its machines.
+func MachineConfigPoolsUpdated(apiClient *clients.Settings, pollInterval, timeout time.Duration) error {
+	pollInterval = timeouts.PollInterval(pollInterval)
+	timeout = timeouts.Scale(timeouts.DriverReady, timeout)
+
+	return await.For(context.TODO(), "the MachineConfigPools to be updated", pollInterval, timeout,
+		func(ctx context.Context) (interface{}, bool, error) {
+			updated, err := machine.ConfigPoolsUpdated(apiClient)
+			if err != nil {
+				return nil, false, err
+			}
+
+			return fmt.Sprintf("updated: %t", updated), updated, nil
+		})
 }
@@ -0,0 +1,273 @@
+package xid
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiasmi"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// FaultContainerName is the container of the fault pod provoking an XID error.
+	FaultContainerName = "xid-fault-ctr"
+	// EnableHealthChecksEnv is the device plugin environment variable listing the XID errors it marks the GPUs
+	// unhealthy on, on top of its default ones, the XID errors of the application faults being skipped otherwise.
+	EnableHealthChecksEnv = "DP_ENABLE_HEALTHCHECKS"
+	// DevicePluginPodLabel selects the device plugin pods of the GPU operator.
+	DevicePluginPodLabel = "app=nvidia-device-plugin-daemonset"
+	// DCGMXIDField is the DCGM field of the last XID error of a GPU, 0 when none occurred.
+	DCGMXIDField = "230"
+
+	dmesgTimeLayout  = "2006-01-02T15:04:05,999999-07:00"
+	dmonEntityPrefix = "GPU"
+)
+
+// ApplicationXIDs are the XID errors caused by a faulty application, e.g. an illegal memory access, which the GPU
+// recovers from once the faulty context is torn down, and which the device plugin does not mark the GPU unhealthy on
+// by default.
+var ApplicationXIDs = []int{13, 31, 43, 45, 68, 109}
+
+// FaultXIDs are the XID errors the fault pod provokes, depending on the GPU architecture.
+var FaultXIDs = []int{13, 31}
+
+// xidRegexp matches the XID errors of the NVIDIA driver in the dmesg output with ISO timestamps, e.g.
+// "2024-05-06T10:12:13,123456+00:00 NVRM: Xid (PCI:0000:3b:00): 31, pid=4242, name=xid-fault, Ch 00000008".
+var xidRegexp = regexp.MustCompile(`^(\S+)\s+NVRM: Xid \(PCI:([^)]+)\): (\d+),\s*(.*)$`)
+
+// faultScript compiles and runs a CUDA kernel writing to an unmapped address, the MMU fault being reported by the
+// driver as XID 31, or XID 13 by the older GPUs, and by the CUDA runtime as an illegal address. It succeeds when the
+// kernel faulted.
+const faultScript = `set -e
+cat > /tmp/xid-fault.cu <<'EOF'
+#include <cstdio>
+
+__global__ void fault(int *address) { *address = 1; }
+
+int main() {
+  fault<<<1, 1>>>((int *)0x10);
+  cudaError_t status = cudaDeviceSynchronize();
+  printf("kernel status: %s\n", cudaGetErrorString(status));
+  return status == cudaErrorIllegalAddress ? 0 : 1;
+}
+EOF
+nvcc -o /tmp/xid-fault /tmp/xid-fault.cu
+/tmp/xid-fault
+`
+
+// Event is an XID error logged by the NVIDIA driver in the kernel log of a node.
+type Event struct {
+	Time     time.Time
+	NodeName string
+	PCIBusID string
+	XID      int
+	Details  string
+}
+
+// String describes the XID error.
+func (event Event) String() string {
+	return fmt.Sprintf("node %s GPU %s XID %d at %s: %s", event.NodeName, event.PCIBusID, event.XID,
+		event.Time.Format(time.RFC3339), event.Details)
+}
+
+// Recoverable returns true when the XID error is caused by an application fault the GPU recovers from.
+func Recoverable(xid int) bool {
+	return slices.Contains(ApplicationXIDs, xid)
+}
+
+// ParseKernelLog returns the XID errors of the dmesg output of the node, logged with ISO timestamps.
+func ParseKernelLog(nodeName, dmesg string) []Event {
+	var events []Event
+
+	for _, line := range strings.Split(dmesg, "\n") {
+		match := xidRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+
+		logTime, err := time.Parse(dmesgTimeLayout, match[1])
+		if err != nil {
+			glog.V(gpuparams.GpuLogLevel).Infof("Skipping XID line %q without timestamp: %v", line, err)
+
+			continue
+		}
+
+		xid, err := strconv.Atoi(match[3])
+		if err != nil {
+			continue
+		}
+
+		events = append(events, Event{
+			Time:     logTime,
+			NodeName: nodeName,
+			PCIBusID: match[2],
+			XID:      xid,
+			Details:  match[4],
+		})
+	}
+
+	return events
+}
+
+// NodeEvents returns the XID errors logged in the kernel log of the node since the given time, read with dmesg in the
+// driver container of the node, which shares the kernel of the host.
+func NodeEvents(apiClient *clients.Settings, nodeName string, since time.Time) ([]Event, error) {
+	driverPod, err := nvidiasmi.NodeDriverPod(apiClient, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := driverPod.ExecCommand([]string{"dmesg", "--time-format=iso"}, nvidiasmi.DriverContainerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the kernel log of node %s: %w", nodeName, err)
+	}
+
+	var events []Event
+
+	for _, event := range ParseKernelLog(nodeName, output.String()) {
+		if !event.Time.Before(since) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// DCGMLastXIDs returns the last XID error of the GPUs of the DCGM host engine, by DCGM GPU id, 0 when a GPU hit none.
+func DCGMLastXIDs(dcgmPod *pod.Builder) (map[int]int, error) {
+	output, err := dcgmPod.ExecCommand([]string{"dcgmi", "dmon", "-e", DCGMXIDField, "-c", "1"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the XID errors of DCGM pod %s: %w: %s", dcgmPod.Object.Name, err,
+			output.String())
+	}
+
+	lastXIDs := map[int]int{}
+
+	for _, line := range strings.Split(output.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != dmonEntityPrefix {
+			continue
+		}
+
+		gpu, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("dcgmi dmon line %q has no GPU id: %w", line, err)
+		}
+
+		// DCGM reports N/A until a GPU hits an XID error.
+		lastXID, err := nvidiasmi.Value(fields[2]).Int()
+		if err != nil {
+			lastXID = 0
+		}
+
+		lastXIDs[gpu] = lastXID
+	}
+
+	if len(lastXIDs) == 0 {
+		return nil, fmt.Errorf("dcgmi dmon reported no GPU: %s", output.String())
+	}
+
+	return lastXIDs, nil
+}
+
+// CreateFaultPod returns a pod pinned to the node that provokes a recoverable XID error on the GPU it is allocated.
+// The image must ship the CUDA compiler, e.g. a CUDA devel image.
+func CreateFaultPod(podName, podNamespace, image, nodeName string) *corev1.Pod {
+	isFalse := false
+	isTrue := true
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "xid-fault-app",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			NodeSelector: map[string]string{
+				corev1.LabelHostname: nodeName,
+			},
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &isTrue,
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Tolerations: []corev1.Toleration{
+				{
+					Key:      "nvidia.com/gpu",
+					Effect:   corev1.TaintEffectNoSchedule,
+					Operator: corev1.TolerationOpExists,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            FaultContainerName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/bin/bash", "-c", faultScript},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &isFalse,
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+					},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							"nvidia.com/gpu": resource.MustParse("1"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// EnableHealthChecks sets EnableHealthChecksEnv to the XID errors in the device plugin env of the ClusterPolicy, so
+// that the device plugin marks the GPUs hitting them unhealthy. It returns a copy of the previous ClusterPolicy spec
+// so that it can be restored.
+func EnableHealthChecks(apiClient *clients.Settings, clusterPolicyName string,
+	xids []int) (*nvidiagpuv1.ClusterPolicySpec, error) {
+	sorted := slices.Clone(xids)
+	sort.Ints(sorted)
+
+	values := make([]string, 0, len(sorted))
+	for _, xid := range sorted {
+		values = append(values, strconv.Itoa(xid))
+	}
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Enabling the device plugin health checks of XID errors %v in ClusterPolicy "+
+		"'%s'", sorted, clusterPolicyName)
+
+	var previousSpec *nvidiagpuv1.ClusterPolicySpec
+
+	_, err := nvidiagpu.PullAndUpdate(apiClient, clusterPolicyName, func(definition *nvidiagpuv1.ClusterPolicy) {
+		previousSpec = definition.Spec.DeepCopy()
+
+		env := []nvidiagpuv1.EnvVar{{Name: EnableHealthChecksEnv, Value: strings.Join(values, ",")}}
+		for _, envVar := range definition.Spec.DevicePlugin.Env {
+			if envVar.Name != EnableHealthChecksEnv {
+				env = append(env, envVar)
+			}
+		}
+
+		definition.Spec.DevicePlugin.Env = env
+	})
+	if err != nil {
+		return previousSpec, fmt.Errorf("failed to enable the device plugin health checks in ClusterPolicy %s: %w",
+			clusterPolicyName, err)
+	}
+
+	return previousSpec, nil
+}
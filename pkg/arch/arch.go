@@ -0,0 +1,126 @@
+package arch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// AMD64 is the Kubernetes architecture of x86_64 nodes.
+	AMD64 = "amd64"
+	// ARM64 is the Kubernetes architecture of aarch64 nodes, e.g. the Grace CPU of Grace Hopper nodes.
+	ARM64 = "arm64"
+	// NodeLabel is the node label holding the Kubernetes architecture of the node.
+	NodeLabel = "kubernetes.io/arch"
+	// GPUProductLabel is the GPU product name labeled by GPU feature discovery.
+	GPUProductLabel = "nvidia.com/gpu.product"
+	// GraceHopperProduct is the GPU product name prefix of the Grace Hopper superchips.
+	GraceHopperProduct = "NVIDIA-GH200"
+)
+
+// Images holds the images of a workload by architecture, for the workloads without multi-architecture images.
+type Images map[string]string
+
+// Image returns the image of the architecture, and an error naming the supported architectures when there is none.
+func (images Images) Image(architecture string) (string, error) {
+	image, found := images[Normalize(architecture)]
+	if !found || image == "" {
+		supported := make([]string, 0, len(images))
+		for imageArchitecture := range images {
+			supported = append(supported, imageArchitecture)
+		}
+
+		sort.Strings(supported)
+
+		return "", fmt.Errorf("no image for architecture %q, supported architectures are %v", architecture,
+			supported)
+	}
+
+	return image, nil
+}
+
+// Normalize returns the Kubernetes architecture of the kernel architecture reported by uname or by the node info,
+// e.g. amd64 for x86_64.
+func Normalize(architecture string) string {
+	switch strings.ToLower(strings.TrimSpace(architecture)) {
+	case "x86_64", "x86-64", AMD64:
+		return AMD64
+	case "aarch64", ARM64:
+		return ARM64
+	default:
+		return strings.ToLower(strings.TrimSpace(architecture))
+	}
+}
+
+// KernelArchitecture returns the kernel architecture of the Kubernetes architecture, e.g. x86_64 for amd64, as found
+// in kernel versions and RPM names.
+func KernelArchitecture(architecture string) string {
+	switch Normalize(architecture) {
+	case AMD64:
+		return "x86_64"
+	case ARM64:
+		return "aarch64"
+	default:
+		return architecture
+	}
+}
+
+// NodeArchitecture returns the Kubernetes architecture of the node, from its NodeLabel or else its node info.
+func NodeArchitecture(node *corev1.Node) string {
+	if node == nil {
+		return ""
+	}
+
+	if architecture, found := node.Labels[NodeLabel]; found {
+		return Normalize(architecture)
+	}
+
+	return Normalize(node.Status.NodeInfo.Architecture)
+}
+
+// ClusterArchitecture returns the architecture of the nodes matching the nodeSelector, e.g. the GPU worker nodes. It
+// returns an error when the nodes do not share a single architecture, as the images selected for it would not run on
+// every node.
+func ClusterArchitecture(apiClient *clients.Settings, nodeSelector map[string]string) (string, error) {
+	nodeBuilders, err := nodes.List(apiClient, metav1.ListOptions{LabelSelector: labels.Set(nodeSelector).String()})
+	if err != nil {
+		return "", fmt.Errorf("failed to list the %v nodes: %w", nodeSelector, err)
+	}
+
+	nodeNames := map[string][]string{}
+
+	for _, nodeBuilder := range nodeBuilders {
+		architecture := NodeArchitecture(nodeBuilder.Object)
+		if architecture == "" {
+			continue
+		}
+
+		nodeNames[architecture] = append(nodeNames[architecture], nodeBuilder.Object.Name)
+	}
+
+	switch len(nodeNames) {
+	case 0:
+		return "", fmt.Errorf("could not find the architecture of any of the %v nodes", nodeSelector)
+	case 1:
+		for architecture := range nodeNames {
+			glog.V(100).Infof("The %v nodes architecture is %s", nodeSelector, architecture)
+
+			return architecture, nil
+		}
+	}
+
+	return "", fmt.Errorf("the %v nodes have mixed architectures: %v", nodeSelector, nodeNames)
+}
+
+// IsGraceHopper returns true when GPU feature discovery labeled the GPUs of the node as Grace Hopper superchips.
+func IsGraceHopper(node *corev1.Node) bool {
+	return node != nil && strings.HasPrefix(node.Labels[GPUProductLabel], GraceHopperProduct)
+}
@@ -0,0 +1,159 @@
+package await
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/onsi/ginkgo/v2"
+)
+
+// SuiteTimeoutMargin is the time before the suite timeout the waits end at, so that their timeout errors are reported
+// before Ginkgo interrupts the suite.
+const SuiteTimeoutMargin = time.Minute
+
+var (
+	// ErrWaitTimeout is the cause of the waits whose own timeout expired.
+	ErrWaitTimeout = errors.New("the wait timeout expired")
+	// ErrSuiteTimeout is the cause of the waits ended by the suite timeout.
+	ErrSuiteTimeout = errors.New("the suite timeout is about to expire")
+
+	// suiteStart approximates the start of the suite with the start of its test process.
+	suiteStart = time.Now()
+)
+
+// Condition checks the awaited state and returns the observed state, described in the timeout error, and whether the
+// awaited state is reached. An error is recorded as the last observation and the condition checked again.
+type Condition func(ctx context.Context) (observed interface{}, done bool, err error)
+
+// Matcher matches the observed state, e.g. a Gomega matcher.
+type Matcher interface {
+	Match(actual interface{}) (bool, error)
+	FailureMessage(actual interface{}) string
+}
+
+// TimeoutError is the error of a wait ended before the awaited state was reached, describing what was awaited and
+// the last observed state.
+type TimeoutError struct {
+	What         string
+	Elapsed      time.Duration
+	Attempts     int
+	LastObserved string
+	LastErr      error
+	Cause        error
+}
+
+// Error describes the wait, the cause of its end and its last observation.
+func (timeoutError *TimeoutError) Error() string {
+	message := fmt.Sprintf("timed out waiting for %s after %s and %d attempts (%v)", timeoutError.What,
+		timeoutError.Elapsed.Round(time.Second), timeoutError.Attempts, timeoutError.Cause)
+
+	if timeoutError.LastErr != nil {
+		return fmt.Sprintf("%s, last error: %v", message, timeoutError.LastErr)
+	}
+
+	return fmt.Sprintf("%s, last observed: %s", message, timeoutError.LastObserved)
+}
+
+// Unwrap returns the cause of the end of the wait, e.g. ErrWaitTimeout, ErrSuiteTimeout or the error of the parent
+// context, e.g. its deadline.
+func (timeoutError *TimeoutError) Unwrap() error {
+	return timeoutError.Cause
+}
+
+// Context returns a context of the parent ending after the timeout or before the suite timeout, whichever comes
+// first.
+func Context(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancelTimeout := context.WithTimeoutCause(parent, timeout, ErrWaitTimeout)
+
+	suiteConfig, _ := ginkgo.GinkgoConfiguration()
+	if suiteConfig.Timeout <= 0 {
+		return ctx, cancelTimeout
+	}
+
+	suiteDeadline := suiteStart.Add(suiteConfig.Timeout - SuiteTimeoutMargin)
+	if deadline, found := ctx.Deadline(); found && deadline.Before(suiteDeadline) {
+		return ctx, cancelTimeout
+	}
+
+	ctx, cancelSuite := context.WithDeadlineCause(ctx, suiteDeadline, ErrSuiteTimeout)
+
+	return ctx, func() {
+		cancelSuite()
+		cancelTimeout()
+	}
+}
+
+// Until checks the condition right away and then every interval until the awaited state is reached or the context
+// is done, returning a TimeoutError describing what was awaited and the last observed state in the latter case.
+func Until(ctx context.Context, what string, interval time.Duration, condition Condition) error {
+	start := time.Now()
+	timeoutError := &TimeoutError{What: what}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		observed, done, err := condition(ctx)
+		timeoutError.Attempts++
+
+		if err == nil && done {
+			glog.V(100).Infof("Reached %s after %s", what, time.Since(start).Round(time.Second))
+
+			return nil
+		}
+
+		timeoutError.LastErr = err
+		if err == nil {
+			timeoutError.LastObserved = fmt.Sprintf("%v", observed)
+		}
+
+		glog.V(100).Infof("Waiting for %s, observed: %s", what, lastObservation(timeoutError))
+
+		select {
+		case <-ctx.Done():
+			timeoutError.Elapsed = time.Since(start)
+			timeoutError.Cause = context.Cause(ctx)
+
+			return timeoutError
+		case <-ticker.C:
+		}
+	}
+}
+
+// For waits for the condition with a context of the parent ending after the timeout or before the suite timeout.
+func For(parent context.Context, what string, interval, timeout time.Duration, condition Condition) error {
+	ctx, cancel := Context(parent, timeout)
+	defer cancel()
+
+	return Until(ctx, what, interval, condition)
+}
+
+// Match waits with a context of the parent ending after the timeout or before the suite timeout until the value
+// returned by poll matches, the last value being described by the failure message of the matcher.
+func Match(parent context.Context, what string, interval, timeout time.Duration,
+	poll func(ctx context.Context) (interface{}, error), matcher Matcher) error {
+	return For(parent, what, interval, timeout, func(ctx context.Context) (interface{}, bool, error) {
+		actual, err := poll(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+
+		matched, err := matcher.Match(actual)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return matcher.FailureMessage(actual), matched, nil
+	})
+}
+
+func lastObservation(timeoutError *TimeoutError) string {
+	if timeoutError.LastErr != nil {
+		return timeoutError.LastErr.Error()
+	}
+
+	return timeoutError.LastObserved
+}
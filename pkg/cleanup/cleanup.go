@@ -0,0 +1,105 @@
+package cleanup
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/golang/glog"
+)
+
+// entry is a registered cleanup.
+type entry struct {
+	id          int
+	description string
+	cleanup     func() error
+}
+
+var (
+	entriesMutex sync.Mutex
+	entries      []entry
+	nextID       int
+
+	// runMutex serializes the runs of the cleanups, so that a run returns once a concurrent run completed.
+	runMutex sync.Mutex
+
+	handleOnce sync.Once
+	// interrupted is closed once SIGINT or SIGTERM is received.
+	interrupted = make(chan struct{})
+)
+
+// Register registers the cleanup of resources created by the suite, run when the suite is interrupted, in reverse
+// order of registration. The returned function unregisters it, e.g. once the suite deleted the resources itself.
+func Register(description string, cleanup func() error) func() {
+	entriesMutex.Lock()
+	defer entriesMutex.Unlock()
+
+	nextID++
+	id := nextID
+
+	entries = append(entries, entry{id: id, description: description, cleanup: cleanup})
+
+	return func() {
+		entriesMutex.Lock()
+		defer entriesMutex.Unlock()
+
+		for index, registered := range entries {
+			if registered.id == id {
+				entries = append(entries[:index], entries[index+1:]...)
+
+				return
+			}
+		}
+	}
+}
+
+// Run runs and unregisters the registered cleanups in reverse order of registration, logging their errors. It returns
+// once the cleanups of a concurrent run, e.g. started on an interrupt, completed.
+func Run() {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+
+	entriesMutex.Lock()
+	registered := entries
+	entries = nil
+	entriesMutex.Unlock()
+
+	for index := len(registered) - 1; index >= 0; index-- {
+		glog.V(100).Infof("Cleaning up %s", registered[index].description)
+
+		if err := registered[index].cleanup(); err != nil {
+			glog.Errorf("Failed to clean up %s: %v", registered[index].description, err)
+		}
+	}
+}
+
+// HandleInterrupts runs the registered cleanups once SIGINT or SIGTERM is received, e.g. on a CI job abort, next to
+// the interrupt handling of Ginkgo running the cleanup and report nodes of the suite. It can be called more than
+// once.
+func HandleInterrupts() {
+	handleOnce.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+		go func() {
+			received := <-signals
+			signal.Stop(signals)
+
+			close(interrupted)
+			glog.Warningf("Received %s, cleaning up the resources of the suite", received)
+
+			Run()
+		}()
+	})
+}
+
+// Interrupted returns true once SIGINT or SIGTERM was received by HandleInterrupts.
+func Interrupted() bool {
+	select {
+	case <-interrupted:
+		return true
+	default:
+		return false
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients/retry"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
@@ -36,14 +37,18 @@ import (
 
 	nvidianetworkv1alpha1 "github.com/Mellanox/network-operator/api/v1alpha1"
 
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
 	machinev1beta1client "github.com/openshift/client-go/machine/clientset/versioned/typed/machine/v1beta1"
 	operatorv1alpha1 "github.com/openshift/client-go/operator/clientset/versioned/typed/operator/v1alpha1"
 	nfdv1 "github.com/openshift/cluster-nfd-operator/api/v1"
+	nfdv1alpha1 "github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd/api/v1alpha1"
 )
 
 // Settings provides the struct to talk with relevant API.
 type Settings struct {
 	KubeconfigPath string
+	ClusterName    string
 	K8sClient      kubernetes.Interface
 	coreV1Client.CoreV1Interface
 	clientConfigV1.ConfigV1Interface
@@ -86,6 +91,8 @@ func New(kubeconfig string) *Settings {
 		return nil
 	}
 
+	config.Wrap(retry.WrapTransport)
+
 	clientSet := &Settings{}
 	clientSet.CoreV1Interface = coreV1Client.NewForConfigOrDie(config)
 	clientSet.ConfigV1Interface = clientConfigV1.NewForConfigOrDie(config)
@@ -124,6 +131,29 @@ func New(kubeconfig string) *Settings {
 	return clientSet
 }
 
+// NewForClusters returns the *Settings of the named clusters, e.g. a management cluster and its hosted clusters,
+// created from their kubeconfig paths. Clusters with an empty kubeconfig path are skipped.
+func NewForClusters(kubeconfigs map[string]string) (map[string]*Settings, error) {
+	clusters := map[string]*Settings{}
+
+	for clusterName, kubeconfig := range kubeconfigs {
+		if kubeconfig == "" {
+			continue
+		}
+
+		clusterClient := New(kubeconfig)
+		if clusterClient == nil {
+			return nil, fmt.Errorf("failed to load kube client config of cluster %q from path %q", clusterName,
+				kubeconfig)
+		}
+
+		clusterClient.ClusterName = clusterName
+		clusters[clusterName] = clusterClient
+	}
+
+	return clusters, nil
+}
+
 // SetScheme returns mutated apiClient's scheme.
 //
 //nolint:funlen
@@ -156,10 +186,18 @@ func SetScheme(crScheme *runtime.Scheme) error {
 		return err
 	}
 
+	if err := nadv1.AddToScheme(crScheme); err != nil {
+		return err
+	}
+
 	if err := nfdv1.AddToScheme(crScheme); err != nil {
 		return err
 	}
 
+	if err := nfdv1alpha1.AddToScheme(crScheme); err != nil {
+		return err
+	}
+
 	if err := pkgManifestV1.AddToScheme(crScheme); err != nil {
 		return err
 	}
@@ -0,0 +1,171 @@
+package retry
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientretry "k8s.io/client-go/util/retry"
+)
+
+var (
+	backoffMutex sync.RWMutex
+	// backoff is the backoff of the client operations, at most about a minute of retries by default.
+	backoff = wait.Backoff{
+		Steps:    6,
+		Duration: time.Second,
+		Factor:   2.0,
+		Jitter:   0.1,
+		Cap:      30 * time.Second,
+	}
+)
+
+// SetBackoff sets the backoff of the retried client operations and requests. Steps is the number of attempts,
+// e.g. 1 disables the retries.
+func SetBackoff(newBackoff wait.Backoff) {
+	backoffMutex.Lock()
+	defer backoffMutex.Unlock()
+
+	backoff = newBackoff
+}
+
+// Backoff returns the backoff of the retried client operations and requests.
+func Backoff() wait.Backoff {
+	backoffMutex.RLock()
+	defer backoffMutex.RUnlock()
+
+	return backoff
+}
+
+// IsRetriable returns true for the errors of client operations that may succeed when retried: conflicts,
+// throttling, and the transient API unavailability of apiserver restarts, e.g. during upgrades.
+func IsRetriable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return apierrors.IsConflict(err) || isTransient(err)
+}
+
+// OnError calls fn until it succeeds, returns an error that is not retriable, or the backoff is exhausted, in which
+// case the last error is returned. fn must fetch the objects it updates so that conflicts are resolved.
+func OnError(fn func() error) error {
+	return clientretry.OnError(Backoff(), IsRetriable, fn)
+}
+
+// WrapTransport returns a round tripper retrying with the backoff the requests that did not reach the apiserver,
+// and the read requests throttled or failed by a transient API unavailability. It is meant to be set as the
+// WrapTransport of a rest.Config.
+func WrapTransport(roundTripper http.RoundTripper) http.RoundTripper {
+	return &retryRoundTripper{delegate: roundTripper}
+}
+
+type retryRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (roundTripper *retryRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestBackoff := Backoff()
+	attempts := requestBackoff.Steps
+
+	for attempt := 1; ; attempt++ {
+		response, err := roundTripper.delegate.RoundTrip(request)
+		if attempt >= attempts || !shouldRetryRequest(request, response, err) {
+			return response, err
+		}
+
+		retryRequest, rewound := rewind(request)
+		if !rewound {
+			return response, err
+		}
+
+		if err != nil {
+			glog.V(100).Infof("Retrying %s %s after attempt %d failed: %v", request.Method, request.URL.Path,
+				attempt, err)
+		} else {
+			glog.V(100).Infof("Retrying %s %s after attempt %d returned %s", request.Method, request.URL.Path,
+				attempt, response.Status)
+
+			_ = response.Body.Close()
+		}
+
+		timer := time.NewTimer(requestBackoff.Step())
+		select {
+		case <-request.Context().Done():
+			timer.Stop()
+
+			return nil, request.Context().Err()
+		case <-timer.C:
+		}
+
+		request = retryRequest
+	}
+}
+
+// WrappedRoundTripper returns the wrapped round tripper, implementing utilnet.RoundTripperWrapper.
+func (roundTripper *retryRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return roundTripper.delegate
+}
+
+// shouldRetryRequest returns true when the request did not reach the apiserver, or is a read request throttled or
+// failed by a transient API unavailability. Watches are left to their informers.
+func shouldRetryRequest(request *http.Request, response *http.Response, err error) bool {
+	if request.URL.Query().Get("watch") == "true" {
+		return false
+	}
+
+	if err != nil && utilnet.IsConnectionRefused(err) {
+		return true
+	}
+
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		return false
+	}
+
+	if err != nil {
+		return utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err) || utilnet.IsHTTP2ConnectionLost(err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// rewind returns a copy of the request to send again, rewound is false when the request body cannot be replayed.
+func rewind(request *http.Request) (*http.Request, bool) {
+	retryRequest := request.Clone(request.Context())
+
+	if request.Body == nil || request.Body == http.NoBody {
+		return retryRequest, true
+	}
+
+	if request.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, false
+	}
+
+	retryRequest.Body = body
+
+	return retryRequest, true
+}
+
+// isTransient returns true for throttling, server timeouts, internal errors and API unavailability.
+func isTransient(err error) bool {
+	return apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err) ||
+		utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err) ||
+		utilnet.IsHTTP2ConnectionLost(err)
+}
@@ -0,0 +1,39 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InfrastructureName is the name of the cluster wide Infrastructure object.
+const InfrastructureName = "cluster"
+
+// ControlPlaneTopology returns the control plane topology reported by the Infrastructure of the cluster:
+// HighlyAvailable, SingleReplica on Single-Node OpenShift, or External on HyperShift hosted clusters, whose control
+// plane runs in a namespace of their management cluster.
+func (settings *Settings) ControlPlaneTopology() (configv1.TopologyMode, error) {
+	infrastructure, err := settings.Infrastructures().Get(context.TODO(), InfrastructureName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get Infrastructure %s: %w", InfrastructureName, err)
+	}
+
+	glog.V(100).Infof("The cluster control plane topology is '%s' and the infrastructure topology is '%s'",
+		infrastructure.Status.ControlPlaneTopology, infrastructure.Status.InfrastructureTopology)
+
+	return infrastructure.Status.ControlPlaneTopology, nil
+}
+
+// IsHostedCluster returns true when the cluster is a HyperShift hosted cluster. Hosted clusters have neither
+// MachineConfigs nor MachineSets, their nodes are managed by the NodePools of the management cluster.
+func (settings *Settings) IsHostedCluster() (bool, error) {
+	topology, err := settings.ControlPlaneTopology()
+	if err != nil {
+		return false, err
+	}
+
+	return topology == configv1.ExternalTopologyMode, nil
+}
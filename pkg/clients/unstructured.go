@@ -0,0 +1,102 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// FieldManager is the field manager of the objects applied with server-side apply.
+const FieldManager = "nvidia-ci"
+
+// GetResource returns the object of the resource, e.g. an experimental operand whose types are not registered in
+// the scheme. The namespace is empty for cluster-scoped resources.
+func (settings *Settings) GetResource(gvr schema.GroupVersionResource, namespace,
+	name string) (*unstructured.Unstructured, error) {
+	glog.V(100).Infof("Getting %s %s in namespace %q", gvr.String(), name, namespace)
+
+	object, err := settings.Interface.Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s in namespace %q: %w", gvr.Resource, name, namespace, err)
+	}
+
+	return object, nil
+}
+
+// ListResources returns the objects of the resource matching the options, in all namespaces when the namespace is
+// empty.
+func (settings *Settings) ListResources(gvr schema.GroupVersionResource, namespace string,
+	options metav1.ListOptions) ([]unstructured.Unstructured, error) {
+	glog.V(100).Infof("Listing %s in namespace %q with options %v", gvr.String(), namespace, options)
+
+	list, err := settings.Interface.Resource(gvr).Namespace(namespace).List(context.TODO(), options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s in namespace %q: %w", gvr.Resource, namespace, err)
+	}
+
+	return list.Items, nil
+}
+
+// ApplyResource creates or updates the object of the resource with server-side apply, the fields of the object
+// being owned by FieldManager. When force is true, the fields owned by other field managers are taken over instead
+// of failing with a conflict.
+func (settings *Settings) ApplyResource(gvr schema.GroupVersionResource, object *unstructured.Unstructured,
+	force bool) (*unstructured.Unstructured, error) {
+	if object == nil {
+		return nil, fmt.Errorf("cannot apply nil %s object", gvr.Resource)
+	}
+
+	glog.V(100).Infof("Applying %s %s in namespace %q", gvr.String(), object.GetName(), object.GetNamespace())
+
+	applied, err := settings.Interface.Resource(gvr).Namespace(object.GetNamespace()).Apply(context.TODO(),
+		object.GetName(), object, metav1.ApplyOptions{FieldManager: FieldManager, Force: force})
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %s %s in namespace %q: %w", gvr.Resource, object.GetName(),
+			object.GetNamespace(), err)
+	}
+
+	return applied, nil
+}
+
+// ApplyManifest applies the YAML or JSON manifest of an object of the resource with ApplyResource.
+func (settings *Settings) ApplyManifest(gvr schema.GroupVersionResource, manifest string,
+	force bool) (*unstructured.Unstructured, error) {
+	object, err := DecodeUnstructured(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings.ApplyResource(gvr, object, force)
+}
+
+// DeleteResource deletes the object of the resource, a missing object not being an error.
+func (settings *Settings) DeleteResource(gvr schema.GroupVersionResource, namespace, name string) error {
+	glog.V(100).Infof("Deleting %s %s in namespace %q", gvr.String(), name, namespace)
+
+	err := settings.Interface.Resource(gvr).Namespace(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s %s in namespace %q: %w", gvr.Resource, name, namespace, err)
+	}
+
+	return nil
+}
+
+// DecodeUnstructured decodes the YAML or JSON manifest of an object, which must set its apiVersion, kind and name.
+func DecodeUnstructured(manifest string) (*unstructured.Unstructured, error) {
+	object := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(manifest), &object.Object); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	if object.GetAPIVersion() == "" || object.GetKind() == "" || object.GetName() == "" {
+		return nil, fmt.Errorf("manifest must set apiVersion, kind and metadata.name")
+	}
+
+	return object, nil
+}
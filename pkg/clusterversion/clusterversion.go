@@ -0,0 +1,190 @@
+package clusterversion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang/glog"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClusterVersionName is the name of the cluster wide ClusterVersion object.
+	ClusterVersionName = "version"
+)
+
+// Builder provides struct for the ClusterVersion object containing connection to the cluster and the
+// ClusterVersion definitions.
+type Builder struct {
+	// ClusterVersion definition. Used to update the ClusterVersion object.
+	Definition *configv1.ClusterVersion
+	// Created ClusterVersion object.
+	Object *configv1.ClusterVersion
+	// Used in functions that define or mutate the ClusterVersion definition. errorMsg is processed before the
+	// ClusterVersion object is updated.
+	errorMsg  string
+	apiClient *clients.Settings
+}
+
+// Pull retrieves the cluster wide ClusterVersion object from the cluster.
+func Pull(apiClient *clients.Settings) (*Builder, error) {
+	glog.V(100).Infof("Pulling ClusterVersion object name: %s", ClusterVersionName)
+
+	builder := Builder{
+		apiClient: apiClient,
+		Definition: &configv1.ClusterVersion{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: ClusterVersionName,
+			},
+		},
+	}
+
+	if !builder.Exists() {
+		return nil, fmt.Errorf("ClusterVersion object %s doesn't exist", ClusterVersionName)
+	}
+
+	builder.Definition = builder.Object
+
+	return &builder, nil
+}
+
+// WithDesiredUpdate requests a cluster upgrade to the given version. When image is set the release image is used
+// as is, and force allows upgrading to a release the cluster cannot verify or that is not in the channel.
+func (builder *Builder) WithDesiredUpdate(version, image string, force bool) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterVersion desired update to version: %s, image: %s, force: %t",
+		version, image, force)
+
+	if version == "" && image == "" {
+		builder.errorMsg = "ClusterVersion desired update 'version' and 'image' cannot both be empty"
+
+		return builder
+	}
+
+	builder.Definition.Spec.DesiredUpdate = &configv1.Update{
+		Version: version,
+		Image:   image,
+		Force:   force,
+	}
+
+	return builder
+}
+
+// Update renovates the ClusterVersion in the cluster and stores the updated object in struct.
+func (builder *Builder) Update() (*Builder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Updating the ClusterVersion %s", builder.Definition.Name)
+
+	var err error
+	builder.Object, err = builder.apiClient.ClusterVersions().Update(context.TODO(), builder.Definition,
+		metav1.UpdateOptions{})
+
+	return builder, err
+}
+
+// Exists checks whether the given ClusterVersion exists.
+func (builder *Builder) Exists() bool {
+	if valid, _ := builder.validate(); !valid {
+		return false
+	}
+
+	glog.V(100).Infof("Checking if ClusterVersion %s exists", builder.Definition.Name)
+
+	var err error
+	builder.Object, err = builder.apiClient.ClusterVersions().Get(context.TODO(), builder.Definition.Name,
+		metav1.GetOptions{})
+
+	return err == nil || !k8serrors.IsNotFound(err)
+}
+
+// GetCompletedVersion returns the most recent version the cluster completed upgrading to.
+func (builder *Builder) GetCompletedVersion() (string, error) {
+	if valid, err := builder.validate(); !valid {
+		return "", err
+	}
+
+	if !builder.Exists() {
+		return "", fmt.Errorf("ClusterVersion object %s doesn't exist", builder.Definition.Name)
+	}
+
+	for _, history := range builder.Object.Status.History {
+		if history.State == configv1.CompletedUpdate {
+			return history.Version, nil
+		}
+	}
+
+	return "", fmt.Errorf("no completed version found in ClusterVersion %s history", builder.Definition.Name)
+}
+
+// IsUpdateCompleted returns true when the latest update in the ClusterVersion history is completed and matches
+// the given version or release image.
+func (builder *Builder) IsUpdateCompleted(version, image string) (bool, error) {
+	if valid, err := builder.validate(); !valid {
+		return false, err
+	}
+
+	if !builder.Exists() {
+		return false, fmt.Errorf("ClusterVersion object %s doesn't exist", builder.Definition.Name)
+	}
+
+	if len(builder.Object.Status.History) == 0 {
+		return false, nil
+	}
+
+	latest := builder.Object.Status.History[0]
+	glog.V(100).Infof("ClusterVersion latest update is version %s, image %s, state %s", latest.Version,
+		latest.Image, latest.State)
+
+	if version != "" && latest.Version != version {
+		return false, nil
+	}
+
+	if image != "" && latest.Image != image {
+		return false, nil
+	}
+
+	return latest.State == configv1.CompletedUpdate, nil
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *Builder) validate() (bool, error) {
+	resourceCRD := "ClusterVersion"
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
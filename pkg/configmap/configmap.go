@@ -8,6 +8,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -110,6 +111,8 @@ func (builder *Builder) Create() (*Builder, error) {
 
 	var err error
 	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
 		builder.Object, err = builder.apiClient.ConfigMaps(builder.Definition.Namespace).Create(
 			context.TODO(), builder.Definition, metav1.CreateOptions{})
 	}
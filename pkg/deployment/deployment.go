@@ -12,6 +12,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -391,6 +392,8 @@ func (builder *Builder) Create() (*Builder, error) {
 
 	var err error
 	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
 		builder.Object, err = builder.apiClient.Deployments(builder.Definition.Namespace).Create(
 			context.TODO(), builder.Definition, metav1.CreateOptions{})
 	}
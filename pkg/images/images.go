@@ -0,0 +1,269 @@
+package images
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/arch"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/mirror"
+)
+
+const (
+	// linuxOS is the operating system of the images run by the suites.
+	linuxOS = "linux"
+)
+
+// indexMediaTypes are the media types of the multi-architecture image indexes.
+var indexMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+// Digest is the digest of the manifest of an image for an architecture.
+type Digest struct {
+	// Image is the image reference, with tag or digest, as configured in the suites.
+	Image string
+	// Architecture is the Kubernetes architecture of the manifest, e.g. amd64.
+	Architecture string
+	// Digest is the digest of the manifest of the architecture, e.g. sha256:...
+	Digest string
+	// Pinned is the image repository with the digest of the manifest of the architecture, which the mirror sets
+	// redirect like the image itself.
+	Pinned string
+	// Resolved is the image the manifest was fetched from, the image on its mirror in disconnected mode.
+	Resolved string
+	// MultiArch is true when the image is a multi-architecture image index, the digest being the one of the manifest
+	// of the architecture in the index.
+	MultiArch bool
+}
+
+// String describes the digest of the image.
+func (digest *Digest) String() string {
+	description := fmt.Sprintf("%s (%s): %s", digest.Image, digest.Architecture, digest.Pinned)
+	if digest.Resolved != digest.Image {
+		description += fmt.Sprintf(", resolved to %s", digest.Resolved)
+	}
+
+	return description
+}
+
+// Resolver resolves the images to the digests of their manifests by architecture, through the mirrors of the cluster
+// when there are some, with the credentials of the cluster pull secret.
+type Resolver struct {
+	checker *mirror.Checker
+	mirrors *mirror.Resolver
+	// digests are the resolved digests, by image and architecture.
+	digests map[string]*Digest
+	mutex   sync.Mutex
+}
+
+// NewResolver returns the Resolver of the registries of the cluster pull secret, fetching the manifests through the
+// mirrors of the mirror resolver, or from the image registries when it is nil. insecure skips the verification of the
+// registry certificates, e.g. for the self-signed mirror registries of disconnected labs.
+func NewResolver(apiClient *clients.Settings, mirrors *mirror.Resolver, insecure bool) (*Resolver, error) {
+	checker, err := mirror.NewChecker(apiClient, insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resolver{
+		checker: checker,
+		mirrors: mirrors,
+		digests: map[string]*Digest{},
+	}, nil
+}
+
+// Resolve returns the digest of the manifest of the image for the architecture. The manifest of the architecture is
+// looked up in the image index of the multi-architecture images, and the architecture of the single architecture
+// images is checked against their configuration, so that an image that would not run on the nodes is reported before
+// a pod is scheduled with it. The digests are cached by image and architecture.
+func (resolver *Resolver) Resolve(image, architecture string) (*Digest, error) {
+	architecture = arch.Normalize(architecture)
+	key := image + " " + architecture
+
+	resolver.mutex.Lock()
+	defer resolver.mutex.Unlock()
+
+	if digest, found := resolver.digests[key]; found {
+		return digest, nil
+	}
+
+	resolved := resolver.mirrors.Resolve(image)
+
+	manifest, err := resolver.checker.FetchManifest(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &Digest{
+		Image:        image,
+		Architecture: architecture,
+		Resolved:     resolved,
+	}
+
+	if isIndex(manifest) {
+		digest.MultiArch = true
+		digest.Digest, err = indexDigest(manifest, architecture)
+	} else {
+		digest.Digest = manifest.Digest
+		err = resolver.checkArchitecture(resolved, manifest, architecture)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve image %s for architecture %s: %w", image, architecture, err)
+	}
+
+	digest.Pinned = Pin(image, digest.Digest)
+	resolver.digests[key] = digest
+
+	glog.V(100).Infof("Image %s is resolved to %s", image, digest)
+
+	return digest, nil
+}
+
+// ResolveAll returns the digests of the images for the architecture, the empty images being skipped. It returns the
+// digests of the images it resolved and an error listing the images it could not resolve.
+func (resolver *Resolver) ResolveAll(architecture string, images ...string) ([]*Digest, error) {
+	var (
+		digests  []*Digest
+		failures []string
+	)
+
+	for _, image := range images {
+		if image == "" {
+			continue
+		}
+
+		digest, err := resolver.Resolve(image, architecture)
+		if err != nil {
+			failures = append(failures, err.Error())
+
+			continue
+		}
+
+		digests = append(digests, digest)
+	}
+
+	if len(failures) > 0 {
+		return digests, fmt.Errorf("%d image(s) could not be resolved: %s", len(failures),
+			strings.Join(failures, "; "))
+	}
+
+	return digests, nil
+}
+
+// Pin returns the image repository with the digest in place of the tag or digest of the image, e.g.
+// nvcr.io/nvidia/cuda@sha256:... for nvcr.io/nvidia/cuda:12.5.0-devel-ubi8.
+func Pin(image, digest string) string {
+	repository, _, _ := strings.Cut(image, "@")
+	if index := strings.LastIndex(repository, ":"); index > strings.LastIndex(repository, "/") {
+		repository = repository[:index]
+	}
+
+	return repository + "@" + digest
+}
+
+// Describe returns the digests, one image per line, sorted.
+func Describe(digests []*Digest) string {
+	lines := make([]string, 0, len(digests))
+	for _, digest := range digests {
+		lines = append(lines, digest.String())
+	}
+
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
+
+// checkArchitecture returns an error when the configuration of the single architecture image manifest is not of the
+// architecture.
+func (resolver *Resolver) checkArchitecture(image string, manifest *mirror.Manifest, architecture string) error {
+	var imageManifest struct {
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+	}
+
+	if err := json.Unmarshal(manifest.Content, &imageManifest); err != nil {
+		return fmt.Errorf("failed to parse the manifest: %w", err)
+	}
+
+	if imageManifest.Config.Digest == "" {
+		return fmt.Errorf("manifest %s of media type %q has no configuration", manifest.Digest, manifest.MediaType)
+	}
+
+	content, err := resolver.checker.FetchBlob(image, imageManifest.Config.Digest)
+	if err != nil {
+		return err
+	}
+
+	var config struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	}
+
+	if err := json.Unmarshal(content, &config); err != nil {
+		return fmt.Errorf("failed to parse the configuration of manifest %s: %w", manifest.Digest, err)
+	}
+
+	if arch.Normalize(config.Architecture) != architecture || config.OS != "" && config.OS != linuxOS {
+		return fmt.Errorf("single architecture image is built for %s/%s", config.OS, config.Architecture)
+	}
+
+	return nil
+}
+
+// isIndex returns true when the manifest is a multi-architecture image index, from its media type or else from its
+// content, some registries serving the OCI indexes without their media type.
+func isIndex(manifest *mirror.Manifest) bool {
+	mediaType, _, _ := strings.Cut(manifest.MediaType, ";")
+	for _, indexMediaType := range indexMediaTypes {
+		if strings.TrimSpace(mediaType) == indexMediaType {
+			return true
+		}
+	}
+
+	var content struct {
+		Manifests []json.RawMessage `json:"manifests"`
+	}
+
+	return json.Unmarshal(manifest.Content, &content) == nil && len(content.Manifests) > 0
+}
+
+// indexDigest returns the digest of the linux manifest of the architecture in the image index, and an error naming
+// the architectures of the index when it has none.
+func indexDigest(manifest *mirror.Manifest, architecture string) (string, error) {
+	var index struct {
+		Manifests []struct {
+			Digest   string `json:"digest"`
+			Platform struct {
+				Architecture string `json:"architecture"`
+				OS           string `json:"os"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+
+	if err := json.Unmarshal(manifest.Content, &index); err != nil {
+		return "", fmt.Errorf("failed to parse image index %s: %w", manifest.Digest, err)
+	}
+
+	var platforms []string
+
+	for _, entry := range index.Manifests {
+		if entry.Platform.OS == linuxOS && arch.Normalize(entry.Platform.Architecture) == architecture {
+			return entry.Digest, nil
+		}
+
+		if entry.Platform.Architecture != "" {
+			platforms = append(platforms, entry.Platform.OS+"/"+entry.Platform.Architecture)
+		}
+	}
+
+	return "", fmt.Errorf("image index %s has no linux/%s manifest, only %v", manifest.Digest, architecture,
+		platforms)
+}
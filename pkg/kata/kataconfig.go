@@ -0,0 +1,236 @@
+package kata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// KataConfigKind is the kind of the KataConfig CR of the OpenShift sandboxed containers operator.
+	KataConfigKind = "KataConfig"
+	// KataConfigAPIVersion is the apiVersion of the KataConfig CR.
+	KataConfigAPIVersion = "kataconfiguration.openshift.io/v1"
+)
+
+// Builder provides struct for the KataConfig object containing connection to the cluster and the KataConfig
+// definitions.
+type Builder struct {
+	// KataConfig definition. Used to create the KataConfig object.
+	Definition *unstructured.Unstructured
+	// Created KataConfig object.
+	Object *unstructured.Unstructured
+	// Used in functions that define or mutate the KataConfig definition. errorMsg is processed before the
+	// KataConfig object is created.
+	errorMsg  string
+	apiClient *clients.Settings
+}
+
+// NewBuilder creates a new instance of a cluster scoped KataConfig builder.
+func NewBuilder(apiClient *clients.Settings, name string) *Builder {
+	glog.V(100).Infof("Initializing new KataConfig structure with the following params: name: %s", name)
+
+	builder := &Builder{
+		apiClient: apiClient,
+		Definition: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": KataConfigAPIVersion,
+				"kind":       KataConfigKind,
+				"metadata": map[string]interface{}{
+					"name": name,
+				},
+				"spec": map[string]interface{}{},
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the KataConfig is empty")
+
+		builder.errorMsg = "KataConfig 'name' cannot be empty"
+	}
+
+	return builder
+}
+
+// Pull retrieves an existing KataConfig object from the cluster.
+func Pull(apiClient *clients.Settings, name string) (*Builder, error) {
+	glog.V(100).Infof("Pulling KataConfig object name: %s", name)
+
+	if name == "" {
+		return nil, fmt.Errorf("KataConfig 'name' cannot be empty")
+	}
+
+	builder := NewBuilder(apiClient, name)
+
+	if !builder.Exists() {
+		return nil, fmt.Errorf("KataConfig object %s doesn't exist", name)
+	}
+
+	builder.Definition = builder.Object
+
+	return builder, nil
+}
+
+// WithPoolSelector restricts the kata runtime installation to the nodes matching the label selector.
+func (builder *Builder) WithPoolSelector(matchLabels map[string]string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting KataConfig %s pool selector to %v", builder.Definition.GetName(), matchLabels)
+
+	if len(matchLabels) == 0 {
+		builder.errorMsg = "KataConfig pool selector 'matchLabels' cannot be empty"
+
+		return builder
+	}
+
+	labels := map[string]interface{}{}
+	for key, value := range matchLabels {
+		labels[key] = value
+	}
+
+	if err := unstructured.SetNestedMap(builder.Definition.Object, labels,
+		"spec", "kataConfigPoolSelector", "matchLabels"); err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to set KataConfig pool selector: %v", err)
+	}
+
+	return builder
+}
+
+// Create makes a KataConfig in the cluster and stores the created object in struct.
+func (builder *Builder) Create() (*Builder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating the KataConfig %s", builder.Definition.GetName())
+
+	var err error
+	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
+		builder.Object, err = builder.apiClient.Resource(GetKataConfigGVR()).Create(context.TODO(),
+			builder.Definition, metav1.CreateOptions{})
+	}
+
+	return builder, err
+}
+
+// Delete removes the KataConfig from the cluster. The operator uninstalls the kata runtime from the nodes.
+func (builder *Builder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting the KataConfig %s", builder.Definition.GetName())
+
+	if !builder.Exists() {
+		return nil
+	}
+
+	err := builder.apiClient.Resource(GetKataConfigGVR()).Delete(context.TODO(), builder.Definition.GetName(),
+		metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete KataConfig %s: %w", builder.Definition.GetName(), err)
+	}
+
+	builder.Object = nil
+
+	return nil
+}
+
+// Exists checks whether the given KataConfig exists.
+func (builder *Builder) Exists() bool {
+	if valid, _ := builder.validate(); !valid {
+		return false
+	}
+
+	glog.V(100).Infof("Checking if KataConfig %s exists", builder.Definition.GetName())
+
+	var err error
+	builder.Object, err = builder.apiClient.Resource(GetKataConfigGVR()).Get(context.TODO(),
+		builder.Definition.GetName(), metav1.GetOptions{})
+
+	return err == nil || !k8serrors.IsNotFound(err)
+}
+
+// IsInstalled returns true when the kata runtime installation is no longer in progress and the kata runtime is
+// ready on all the selected nodes.
+func (builder *Builder) IsInstalled() (bool, error) {
+	if valid, err := builder.validate(); !valid {
+		return false, err
+	}
+
+	if !builder.Exists() || builder.Object == nil {
+		return false, fmt.Errorf("KataConfig object %s doesn't exist", builder.Definition.GetName())
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(builder.Object.Object, "status", "conditions")
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]interface{})
+		if ok && conditionMap["type"] == "InProgress" && conditionMap["status"] == "True" {
+			glog.V(100).Infof("KataConfig %s installation is in progress: %v", builder.Definition.GetName(),
+				conditionMap["message"])
+
+			return false, nil
+		}
+	}
+
+	nodeCount, _, _ := unstructured.NestedInt64(builder.Object.Object, "status", "kataNodes", "nodeCount")
+	readyNodeCount, _, _ := unstructured.NestedInt64(builder.Object.Object, "status", "kataNodes", "readyNodeCount")
+
+	glog.V(100).Infof("KataConfig %s has %d ready nodes out of %d", builder.Definition.GetName(),
+		readyNodeCount, nodeCount)
+
+	return nodeCount > 0 && readyNodeCount == nodeCount, nil
+}
+
+// GetKataConfigGVR returns the KataConfig GroupVersionResource.
+func GetKataConfigGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: "kataconfiguration.openshift.io", Version: "v1", Resource: "kataconfigs",
+	}
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *Builder) validate() (bool, error) {
+	resourceCRD := KataConfigKind
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
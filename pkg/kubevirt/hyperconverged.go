@@ -0,0 +1,210 @@
+package kubevirt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// HyperConvergedName is the name of the HyperConverged CR created by OpenShift Virtualization.
+	HyperConvergedName = "kubevirt-hyperconverged"
+	// HyperConvergedNamespace is the namespace where OpenShift Virtualization is installed.
+	HyperConvergedNamespace = "openshift-cnv"
+)
+
+// HyperConvergedBuilder provides struct for the HyperConverged object containing connection to the cluster and
+// the HyperConverged definitions.
+type HyperConvergedBuilder struct {
+	// HyperConverged definition. Used to update the HyperConverged object.
+	Definition *unstructured.Unstructured
+	// Created HyperConverged object.
+	Object *unstructured.Unstructured
+	// Used in functions that define or mutate the HyperConverged definition. errorMsg is processed before the
+	// HyperConverged object is updated.
+	errorMsg  string
+	apiClient *clients.Settings
+}
+
+// PullHyperConverged retrieves an existing HyperConverged object from the cluster.
+func PullHyperConverged(apiClient *clients.Settings, name, nsname string) (*HyperConvergedBuilder, error) {
+	glog.V(100).Infof("Pulling HyperConverged object name: %s in namespace: %s", name, nsname)
+
+	builder := HyperConvergedBuilder{
+		apiClient:  apiClient,
+		Definition: &unstructured.Unstructured{Object: map[string]interface{}{}},
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("HyperConverged 'name' cannot be empty")
+	}
+
+	if nsname == "" {
+		return nil, fmt.Errorf("HyperConverged 'nsname' cannot be empty")
+	}
+
+	builder.Definition.SetName(name)
+	builder.Definition.SetNamespace(nsname)
+
+	if !builder.Exists() {
+		return nil, fmt.Errorf("HyperConverged object %s doesn't exist in namespace %s", name, nsname)
+	}
+
+	builder.Definition = builder.Object
+
+	return &builder, nil
+}
+
+// WithPermittedMediatedDevice permits KubeVirt to consume a mediated device type advertised by an external
+// device plugin, such as the NVIDIA sandbox device plugin, under resourceName.
+func (builder *HyperConvergedBuilder) WithPermittedMediatedDevice(mdevNameSelector,
+	resourceName string) *HyperConvergedBuilder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Permitting mediated device %s as %s in HyperConverged %s", mdevNameSelector,
+		resourceName, builder.Definition.GetName())
+
+	if mdevNameSelector == "" || resourceName == "" {
+		builder.errorMsg = "HyperConverged 'mdevNameSelector' and 'resourceName' cannot be empty"
+
+		return builder
+	}
+
+	mediatedDevices, _, _ := unstructured.NestedSlice(builder.Definition.Object,
+		"spec", "permittedHostDevices", "mediatedDevices")
+
+	for _, device := range mediatedDevices {
+		if deviceMap, ok := device.(map[string]interface{}); ok && deviceMap["resourceName"] == resourceName {
+			return builder
+		}
+	}
+
+	mediatedDevices = append(mediatedDevices, map[string]interface{}{
+		"mdevNameSelector":         mdevNameSelector,
+		"resourceName":             resourceName,
+		"externalResourceProvider": true,
+	})
+
+	if err := unstructured.SetNestedSlice(builder.Definition.Object, mediatedDevices,
+		"spec", "permittedHostDevices", "mediatedDevices"); err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to set HyperConverged mediatedDevices: %v", err)
+	}
+
+	return builder
+}
+
+// WithoutPermittedMediatedDevice removes the mediated device permitted under resourceName.
+func (builder *HyperConvergedBuilder) WithoutPermittedMediatedDevice(resourceName string) *HyperConvergedBuilder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Removing permitted mediated device %s from HyperConverged %s", resourceName,
+		builder.Definition.GetName())
+
+	mediatedDevices, found, _ := unstructured.NestedSlice(builder.Definition.Object,
+		"spec", "permittedHostDevices", "mediatedDevices")
+	if !found {
+		return builder
+	}
+
+	var remaining []interface{}
+
+	for _, device := range mediatedDevices {
+		if deviceMap, ok := device.(map[string]interface{}); ok && deviceMap["resourceName"] == resourceName {
+			continue
+		}
+
+		remaining = append(remaining, device)
+	}
+
+	if err := unstructured.SetNestedSlice(builder.Definition.Object, remaining,
+		"spec", "permittedHostDevices", "mediatedDevices"); err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to set HyperConverged mediatedDevices: %v", err)
+	}
+
+	return builder
+}
+
+// Update renovates the HyperConverged in the cluster and stores the updated object in struct.
+func (builder *HyperConvergedBuilder) Update() (*HyperConvergedBuilder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Updating the HyperConverged %s in namespace %s", builder.Definition.GetName(),
+		builder.Definition.GetNamespace())
+
+	var err error
+	builder.Object, err = builder.apiClient.Resource(GetHyperConvergedGVR()).
+		Namespace(builder.Definition.GetNamespace()).Update(context.TODO(), builder.Definition,
+		metav1.UpdateOptions{})
+
+	return builder, err
+}
+
+// Exists checks whether the given HyperConverged exists.
+func (builder *HyperConvergedBuilder) Exists() bool {
+	if valid, _ := builder.validate(); !valid {
+		return false
+	}
+
+	glog.V(100).Infof("Checking if HyperConverged %s exists in namespace %s", builder.Definition.GetName(),
+		builder.Definition.GetNamespace())
+
+	var err error
+	builder.Object, err = builder.apiClient.Resource(GetHyperConvergedGVR()).
+		Namespace(builder.Definition.GetNamespace()).Get(context.TODO(), builder.Definition.GetName(),
+		metav1.GetOptions{})
+
+	return err == nil || !k8serrors.IsNotFound(err)
+}
+
+// GetHyperConvergedGVR returns the HyperConverged GroupVersionResource.
+func GetHyperConvergedGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: "hco.kubevirt.io", Version: "v1beta1", Resource: "hyperconvergeds",
+	}
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *HyperConvergedBuilder) validate() (bool, error) {
+	resourceCRD := "HyperConverged"
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,359 @@
+package kubevirt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// GuestConsoleLogContainer is the virt-launcher container that streams the VM serial console.
+	GuestConsoleLogContainer = "guest-console-log"
+
+	kubevirtGroup   = "kubevirt.io"
+	kubevirtVersion = "v1"
+)
+
+// Builder provides struct for the VirtualMachine object containing connection to the cluster and the
+// VirtualMachine definitions. KubeVirt types are not vendored, so the object is handled as unstructured.
+type Builder struct {
+	// VirtualMachine definition. Used to create the VirtualMachine object.
+	Definition *unstructured.Unstructured
+	// Created VirtualMachine object.
+	Object *unstructured.Unstructured
+	// Used in functions that define or mutate the VirtualMachine definition. errorMsg is processed before the
+	// VirtualMachine object is created.
+	errorMsg  string
+	apiClient *clients.Settings
+}
+
+// NewBuilder creates a new instance of a running VirtualMachine Builder booting from a containerDisk image.
+func NewBuilder(apiClient *clients.Settings, name, nsname, containerDiskImage, memory string) *Builder {
+	glog.V(100).Infof(
+		"Initializing new VirtualMachine structure with the following params: %s, %s, %s, %s",
+		name, nsname, containerDiskImage, memory)
+
+	builder := &Builder{
+		apiClient: apiClient,
+		Definition: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": kubevirtGroup + "/" + kubevirtVersion,
+				"kind":       "VirtualMachine",
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": nsname,
+				},
+				"spec": map[string]interface{}{
+					"running": true,
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"labels": map[string]interface{}{
+								"kubevirt.io/vm": name,
+							},
+						},
+						"spec": map[string]interface{}{
+							"domain": map[string]interface{}{
+								"devices": map[string]interface{}{
+									"logSerialConsole": true,
+									"disks": []interface{}{
+										map[string]interface{}{
+											"name": "containerdisk",
+											"disk": map[string]interface{}{"bus": "virtio"},
+										},
+									},
+								},
+								"resources": map[string]interface{}{
+									"requests": map[string]interface{}{"memory": memory},
+								},
+							},
+							"volumes": []interface{}{
+								map[string]interface{}{
+									"name": "containerdisk",
+									"containerDisk": map[string]interface{}{
+										"image": containerDiskImage,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the VirtualMachine is empty")
+
+		builder.errorMsg = "VirtualMachine 'name' cannot be empty"
+
+		return builder
+	}
+
+	if nsname == "" {
+		glog.V(100).Infof("The namespace of the VirtualMachine is empty")
+
+		builder.errorMsg = "VirtualMachine 'nsname' cannot be empty"
+
+		return builder
+	}
+
+	if containerDiskImage == "" {
+		glog.V(100).Infof("The containerDisk image of the VirtualMachine is empty")
+
+		builder.errorMsg = "VirtualMachine 'containerDiskImage' cannot be empty"
+	}
+
+	return builder
+}
+
+// Pull retrieves an existing VirtualMachine object from the cluster.
+func Pull(apiClient *clients.Settings, name, nsname string) (*Builder, error) {
+	glog.V(100).Infof("Pulling VirtualMachine object name: %s in namespace: %s", name, nsname)
+
+	builder := Builder{
+		apiClient: apiClient,
+		Definition: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": kubevirtGroup + "/" + kubevirtVersion,
+				"kind":       "VirtualMachine",
+			},
+		},
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("VirtualMachine 'name' cannot be empty")
+	}
+
+	if nsname == "" {
+		return nil, fmt.Errorf("VirtualMachine 'nsname' cannot be empty")
+	}
+
+	builder.Definition.SetName(name)
+	builder.Definition.SetNamespace(nsname)
+
+	if !builder.Exists() {
+		return nil, fmt.Errorf("VirtualMachine object %s doesn't exist in namespace %s", name, nsname)
+	}
+
+	builder.Definition = builder.Object
+
+	return &builder, nil
+}
+
+// WithGPU attaches a host device or mediated device advertised under deviceName to the VirtualMachine.
+func (builder *Builder) WithGPU(name, deviceName string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Adding GPU %s with deviceName %s to VirtualMachine %s", name, deviceName,
+		builder.Definition.GetName())
+
+	if name == "" || deviceName == "" {
+		builder.errorMsg = "VirtualMachine GPU 'name' and 'deviceName' cannot be empty"
+
+		return builder
+	}
+
+	gpus, _, _ := unstructured.NestedSlice(builder.Definition.Object,
+		"spec", "template", "spec", "domain", "devices", "gpus")
+	gpus = append(gpus, map[string]interface{}{
+		"name":       name,
+		"deviceName": deviceName,
+	})
+
+	if err := unstructured.SetNestedSlice(builder.Definition.Object, gpus,
+		"spec", "template", "spec", "domain", "devices", "gpus"); err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to set VirtualMachine GPUs: %v", err)
+	}
+
+	return builder
+}
+
+// WithCloudInitUserData adds a cloudInitNoCloud disk with the given user data to the VirtualMachine.
+func (builder *Builder) WithCloudInitUserData(userData string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Adding cloud-init user data to VirtualMachine %s", builder.Definition.GetName())
+
+	if userData == "" {
+		builder.errorMsg = "VirtualMachine cloud-init 'userData' cannot be empty"
+
+		return builder
+	}
+
+	disks, _, _ := unstructured.NestedSlice(builder.Definition.Object,
+		"spec", "template", "spec", "domain", "devices", "disks")
+	disks = append(disks, map[string]interface{}{
+		"name": "cloudinitdisk",
+		"disk": map[string]interface{}{"bus": "virtio"},
+	})
+
+	volumes, _, _ := unstructured.NestedSlice(builder.Definition.Object, "spec", "template", "spec", "volumes")
+	volumes = append(volumes, map[string]interface{}{
+		"name": "cloudinitdisk",
+		"cloudInitNoCloud": map[string]interface{}{
+			"userData": userData,
+		},
+	})
+
+	if err := unstructured.SetNestedSlice(builder.Definition.Object, disks,
+		"spec", "template", "spec", "domain", "devices", "disks"); err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to set VirtualMachine disks: %v", err)
+
+		return builder
+	}
+
+	if err := unstructured.SetNestedSlice(builder.Definition.Object, volumes,
+		"spec", "template", "spec", "volumes"); err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to set VirtualMachine volumes: %v", err)
+	}
+
+	return builder
+}
+
+// WithNodeSelector sets the nodeSelector of the VirtualMachine instances.
+func (builder *Builder) WithNodeSelector(nodeSelector map[string]string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting nodeSelector %v on VirtualMachine %s", nodeSelector, builder.Definition.GetName())
+
+	if len(nodeSelector) == 0 {
+		builder.errorMsg = "VirtualMachine 'nodeSelector' cannot be empty"
+
+		return builder
+	}
+
+	if err := unstructured.SetNestedStringMap(builder.Definition.Object, nodeSelector,
+		"spec", "template", "spec", "nodeSelector"); err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to set VirtualMachine nodeSelector: %v", err)
+	}
+
+	return builder
+}
+
+// Create makes a VirtualMachine in the cluster and stores the created object in struct.
+func (builder *Builder) Create() (*Builder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating the VirtualMachine %s in namespace %s", builder.Definition.GetName(),
+		builder.Definition.GetNamespace())
+
+	var err error
+	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
+		builder.Object, err = builder.apiClient.Resource(GetVirtualMachineGVR()).
+			Namespace(builder.Definition.GetNamespace()).Create(context.TODO(), builder.Definition,
+			metav1.CreateOptions{})
+	}
+
+	return builder, err
+}
+
+// Delete removes a VirtualMachine.
+func (builder *Builder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting the VirtualMachine %s from namespace %s", builder.Definition.GetName(),
+		builder.Definition.GetNamespace())
+
+	if !builder.Exists() {
+		return nil
+	}
+
+	err := builder.apiClient.Resource(GetVirtualMachineGVR()).Namespace(builder.Definition.GetNamespace()).
+		Delete(context.TODO(), builder.Definition.GetName(), metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+
+	builder.Object = nil
+
+	return nil
+}
+
+// Exists checks whether the given VirtualMachine exists.
+func (builder *Builder) Exists() bool {
+	if valid, _ := builder.validate(); !valid {
+		return false
+	}
+
+	glog.V(100).Infof("Checking if VirtualMachine %s exists in namespace %s", builder.Definition.GetName(),
+		builder.Definition.GetNamespace())
+
+	var err error
+	builder.Object, err = builder.apiClient.Resource(GetVirtualMachineGVR()).
+		Namespace(builder.Definition.GetNamespace()).Get(context.TODO(), builder.Definition.GetName(),
+		metav1.GetOptions{})
+
+	return err == nil || !k8serrors.IsNotFound(err)
+}
+
+// IsReady returns true once the VirtualMachine reports status.ready.
+func (builder *Builder) IsReady() (bool, error) {
+	if !builder.Exists() {
+		return false, fmt.Errorf("VirtualMachine %s doesn't exist in namespace %s", builder.Definition.GetName(),
+			builder.Definition.GetNamespace())
+	}
+
+	ready, _, err := unstructured.NestedBool(builder.Object.Object, "status", "ready")
+
+	return ready, err
+}
+
+// GetVirtualMachineGVR returns the VirtualMachine GroupVersionResource.
+func GetVirtualMachineGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: kubevirtGroup, Version: kubevirtVersion, Resource: "virtualmachines",
+	}
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *Builder) validate() (bool, error) {
+	resourceCRD := "VirtualMachine"
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,192 @@
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+// Tier is the label of the CI tier of a spec, the tiers running with a growing frequency from the extended to the
+// smoke tier.
+type Tier string
+
+// Duration is the label of the order of magnitude of the time a spec takes.
+type Duration string
+
+// Disruption is the label of how much a spec disturbs the cluster and the workloads running next to it.
+type Disruption string
+
+const (
+	// TierKey is the key of the tier label set, e.g. `tier: {smoke, regression}` in a label filter.
+	TierKey = "tier"
+	// DurationKey is the key of the duration label set.
+	DurationKey = "duration"
+	// DisruptionKey is the key of the disruption label set.
+	DisruptionKey = "disruption"
+	// RequiresKey is the key of the hardware requirement label set, e.g. `requires: isEmpty` in a label filter
+	// selects the specs running on any GPU cluster.
+	RequiresKey = "requires"
+)
+
+const (
+	// Smoke specs check the basic health of a deployment and run on every change.
+	Smoke Tier = TierKey + ":smoke"
+	// Regression specs cover the features of the operators and run nightly.
+	Regression Tier = TierKey + ":regression"
+	// Extended specs need special hardware or long runs, and run weekly or on demand.
+	Extended Tier = TierKey + ":extended"
+)
+
+const (
+	// Short specs take less than 10 minutes.
+	Short Duration = DurationKey + ":short"
+	// Medium specs take less than an hour.
+	Medium Duration = DurationKey + ":medium"
+	// Long specs take an hour or more.
+	Long Duration = DurationKey + ":long"
+)
+
+const (
+	// NonDisruptive specs only create objects in their own namespaces.
+	NonDisruptive Disruption = DisruptionKey + ":none"
+	// DisruptsOperands specs change the ClusterPolicy, the NicClusterPolicy or other cluster scoped configuration,
+	// restarting the operands and the GPU workloads of the cluster.
+	DisruptsOperands Disruption = DisruptionKey + ":operands"
+	// DisruptsCluster specs install, upgrade or uninstall operators, add, drain or reboot nodes or upgrade the
+	// cluster.
+	DisruptsCluster Disruption = DisruptionKey + ":cluster"
+)
+
+// The hardware or cluster requirements of a spec, which is skipped on the clusters not meeting them. They are plain
+// labels, passed to Spec for a whole suite or to Label for the specs with more requirements than their suite, e.g.
+// Label(tsparams.LabelMultiNode, labels.MultiNode).
+const (
+	// MIG requires MIG capable GPUs.
+	MIG = RequiresKey + ":mig"
+	// MultiGPU requires a node with at least 2 GPUs.
+	MultiGPU = RequiresKey + ":multi-gpu"
+	// MultiNode requires several GPU nodes, not met by Single-Node OpenShift.
+	MultiNode = RequiresKey + ":multi-node"
+	// NVSwitch requires GPUs linked by NVSwitches, e.g. HGX systems.
+	NVSwitch = RequiresKey + ":nvswitch"
+	// RDMA requires Mellanox ConnectX NICs.
+	RDMA = RequiresKey + ":rdma"
+	// SRIOV requires SR-IOV capable GPUs.
+	SRIOV = RequiresKey + ":sriov"
+	// VGPU requires vGPU capable GPUs and the vGPU software.
+	VGPU = RequiresKey + ":vgpu"
+	// CC requires GPUs and nodes supporting confidential computing.
+	CC = RequiresKey + ":cc"
+	// GH200 requires Grace Hopper GPU nodes.
+	GH200 = RequiresKey + ":gh200"
+	// BareMetal requires bare metal GPU nodes, e.g. to pass the GPUs through to virtual machines.
+	BareMetal = RequiresKey + ":bare-metal"
+	// MachineSet requires a cloud cluster whose GPU nodes are provisioned from a MachineSet.
+	MachineSet = RequiresKey + ":machineset"
+	// FIPS requires a cluster installed in FIPS mode.
+	FIPS = RequiresKey + ":fips"
+	// Proxy requires a cluster wide HTTP or HTTPS proxy.
+	Proxy = RequiresKey + ":proxy"
+)
+
+var (
+	// Tiers are the valid tiers.
+	Tiers = []Tier{Smoke, Regression, Extended}
+	// Durations are the valid durations.
+	Durations = []Duration{Short, Medium, Long}
+	// Disruptions are the valid disruptions.
+	Disruptions = []Disruption{NonDisruptive, DisruptsOperands, DisruptsCluster}
+	// Requirements are the valid hardware requirements.
+	Requirements = []string{MIG, MultiGPU, MultiNode, NVSwitch, RDMA, SRIOV, VGPU, CC, GH200, BareMetal, MachineSet,
+		FIPS, Proxy}
+)
+
+// Spec returns the labels of the tier, duration, disruption and hardware requirements of a container or a spec, to be
+// passed as a decorator, e.g. to the Describe of a suite. Every spec must inherit or carry one label of each of the
+// tier, duration and disruption sets.
+func Spec(tier Tier, duration Duration, disruption Disruption, requires ...string) ginkgo.Labels {
+	return append(ginkgo.Labels{string(tier), string(duration), string(disruption)}, requires...)
+}
+
+// Validate checks that the labels of a spec, including the ones inherited from its containers, have exactly one
+// label of each of the tier, duration and disruption sets, and that the labels of the taxonomy are known.
+func Validate(specLabels []string) error {
+	sets := map[string][]string{}
+
+	for _, label := range specLabels {
+		key, _, found := strings.Cut(label, ":")
+		if !found {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		if _, known := validLabels[key]; known && !slices.Contains(sets[key], label) {
+			sets[key] = append(sets[key], label)
+		}
+	}
+
+	var errs []error
+
+	for _, key := range []string{TierKey, DurationKey, DisruptionKey} {
+		switch len(sets[key]) {
+		case 0:
+			errs = append(errs, fmt.Errorf("no %s label, expected one of %s", key,
+				strings.Join(validLabels[key], ", ")))
+		case 1:
+		default:
+			errs = append(errs, fmt.Errorf("several %s labels %s", key, strings.Join(sets[key], ", ")))
+		}
+	}
+
+	for _, key := range []string{TierKey, DurationKey, DisruptionKey, RequiresKey} {
+		for _, label := range sets[key] {
+			if !slices.Contains(validLabels[key], label) {
+				errs = append(errs, fmt.Errorf("unknown %s label %s, expected one of %s", key, label,
+					strings.Join(validLabels[key], ", ")))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Violations returns the full text of the specs of the report, run or not, whose labels do not pass Validate, with
+// the reasons.
+func Violations(report types.Report) []string {
+	var violations []string
+
+	for _, specReport := range report.SpecReports {
+		if !specReport.LeafNodeType.Is(types.NodeTypeIt) {
+			continue
+		}
+
+		if err := Validate(specReport.Labels()); err != nil {
+			violations = append(violations, fmt.Sprintf("%s: %s", specReport.FullText(),
+				strings.ReplaceAll(err.Error(), "\n", "; ")))
+		}
+	}
+
+	return violations
+}
+
+// validLabels are the valid labels of each set of the taxonomy.
+var validLabels = map[string][]string{
+	TierKey:       toStrings(Tiers),
+	DurationKey:   toStrings(Durations),
+	DisruptionKey: toStrings(Disruptions),
+	RequiresKey:   Requirements,
+}
+
+func toStrings[T ~string](values []T) []string {
+	out := make([]string, 0, len(values))
+
+	for _, value := range values {
+		out = append(out, string(value))
+	}
+
+	return out
+}
@@ -0,0 +1,108 @@
+package machine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// ClusterAutoscalerName is the name of the ClusterAutoscaler, the cluster autoscaler operator only reconciles
+	// the one named default.
+	ClusterAutoscalerName = "default"
+	// AcceleratorLabel is the node label the cluster autoscaler reads the GPU type of a node from. It must be set on
+	// the nodes of the MachineSets scaled for GPUs, see WithNodeLabel, with the GPU type of the resource limits.
+	AcceleratorLabel = "cluster-api/accelerator"
+)
+
+var (
+	// ClusterAutoscalerGVR is the resource of the ClusterAutoscalers of the cluster autoscaler operator.
+	ClusterAutoscalerGVR = schema.GroupVersionResource{
+		Group: "autoscaling.openshift.io", Version: "v1", Resource: "clusterautoscalers"}
+	// MachineAutoscalerGVR is the resource of the MachineAutoscalers of the cluster autoscaler operator.
+	MachineAutoscalerGVR = schema.GroupVersionResource{
+		Group: "autoscaling.openshift.io", Version: "v1beta1", Resource: "machineautoscalers"}
+)
+
+// ApplyClusterAutoscaler creates or updates the ClusterAutoscaler, limiting the cluster to maxGPUs GPUs of the gpuType,
+// the AcceleratorLabel value of the GPU nodes. The nodes unneeded for unneededTime are scaled down, starting
+// delayAfterAdd after a scale up.
+func ApplyClusterAutoscaler(apiClient *clients.Settings, gpuType string, maxGPUs int32,
+	delayAfterAdd, unneededTime time.Duration) (*unstructured.Unstructured, error) {
+	glog.V(100).Infof("Applying ClusterAutoscaler %s with at most %d %s GPUs", ClusterAutoscalerName, maxGPUs,
+		gpuType)
+
+	if gpuType == "" {
+		return nil, fmt.Errorf("ClusterAutoscaler 'gpuType' cannot be empty")
+	}
+
+	clusterAutoscaler := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": ClusterAutoscalerGVR.GroupVersion().String(),
+		"kind":       "ClusterAutoscaler",
+		"metadata": map[string]interface{}{
+			"name": ClusterAutoscalerName,
+		},
+		"spec": map[string]interface{}{
+			"resourceLimits": map[string]interface{}{
+				"gpus": []interface{}{
+					map[string]interface{}{"type": gpuType, "min": int64(0), "max": int64(maxGPUs)},
+				},
+			},
+			"scaleDown": map[string]interface{}{
+				"enabled":       true,
+				"delayAfterAdd": delayAfterAdd.String(),
+				"unneededTime":  unneededTime.String(),
+			},
+		},
+	}}
+
+	return apiClient.ApplyResource(ClusterAutoscalerGVR, clusterAutoscaler, true)
+}
+
+// ApplyMachineAutoscaler creates or updates a MachineAutoscaler of the same name as the MachineSet, scaling it between
+// minReplicas, possibly zero, and maxReplicas.
+func ApplyMachineAutoscaler(apiClient *clients.Settings, machineSetName, namespace string, minReplicas,
+	maxReplicas int32) (*unstructured.Unstructured, error) {
+	glog.V(100).Infof("Applying MachineAutoscaler %s in namespace %s with %d to %d replicas", machineSetName,
+		namespace, minReplicas, maxReplicas)
+
+	if minReplicas < 0 || maxReplicas < minReplicas || maxReplicas == 0 {
+		return nil, fmt.Errorf("invalid MachineAutoscaler replicas range %d to %d", minReplicas, maxReplicas)
+	}
+
+	machineAutoscaler := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": MachineAutoscalerGVR.GroupVersion().String(),
+		"kind":       "MachineAutoscaler",
+		"metadata": map[string]interface{}{
+			"name":      machineSetName,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"minReplicas": int64(minReplicas),
+			"maxReplicas": int64(maxReplicas),
+			"scaleTargetRef": map[string]interface{}{
+				"apiVersion": machinev1beta1.SchemeGroupVersion.String(),
+				"kind":       "MachineSet",
+				"name":       machineSetName,
+			},
+		},
+	}}
+
+	return apiClient.ApplyResource(MachineAutoscalerGVR, machineAutoscaler, true)
+}
+
+// DeleteClusterAutoscaler deletes the ClusterAutoscaler, a missing ClusterAutoscaler not being an error.
+func DeleteClusterAutoscaler(apiClient *clients.Settings) error {
+	return apiClient.DeleteResource(ClusterAutoscalerGVR, "", ClusterAutoscalerName)
+}
+
+// DeleteMachineAutoscaler deletes the MachineAutoscaler of the MachineSet, a missing MachineAutoscaler not being an
+// error.
+func DeleteMachineAutoscaler(apiClient *clients.Settings, machineSetName, namespace string) error {
+	return apiClient.DeleteResource(MachineAutoscalerGVR, namespace, machineSetName)
+}
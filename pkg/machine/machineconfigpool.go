@@ -0,0 +1,50 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MachineConfigPoolGVR is the resource of the MachineConfigPools of the machine config operator.
+var MachineConfigPoolGVR = schema.GroupVersionResource{
+	Group: "machineconfiguration.openshift.io", Version: "v1", Resource: "machineconfigpools"}
+
+// ConfigPoolsUpdated returns true when every MachineConfigPool runs its rendered configuration on all its machines,
+// i.e. the pools report Updated and not Updating, e.g. once the registries configuration of new mirror sets is
+// rolled out to the nodes.
+func ConfigPoolsUpdated(apiClient *clients.Settings) (bool, error) {
+	pools, err := apiClient.Resource(MachineConfigPoolGVR).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to list MachineConfigPools: %w", err)
+	}
+
+	for _, pool := range pools.Items {
+		if !poolConditionTrue(pool, "Updated") || poolConditionTrue(pool, "Updating") {
+			glog.V(100).Infof("MachineConfigPool %s is not updated yet", pool.GetName())
+
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// poolConditionTrue returns true when the condition of the MachineConfigPool has status True.
+func poolConditionTrue(pool unstructured.Unstructured, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(pool.Object, "status", "conditions")
+
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]interface{})
+		if ok && conditionMap["type"] == conditionType {
+			return conditionMap["status"] == "True"
+		}
+	}
+
+	return false
+}
@@ -13,6 +13,7 @@ import (
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -95,10 +96,10 @@ func NewSetBuilderFromCopy(
 		builder.errorMsg = "MachineSet 'instanceType' cannot be empty"
 	}
 
-	if replicas == 0 {
-		glog.V(100).Infof("The replicas of the MachineSet is zero")
+	if replicas < 0 {
+		glog.V(100).Infof("The replicas of the MachineSet is negative")
 
-		builder.errorMsg = "MachineSet 'replicas' cannot be zero"
+		builder.errorMsg = "MachineSet 'replicas' cannot be negative"
 	}
 
 	if workerLabel == "" {
@@ -116,6 +117,53 @@ func NewSetBuilderFromCopy(
 	return &builder
 }
 
+// WithNameSuffix renames the MachineSet with a suffix, e.g. to not collide with another MachineSet copied for the same
+// instance type.
+func (builder *SetBuilder) WithNameSuffix(suffix string) *SetBuilder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	if suffix == "" {
+		builder.errorMsg = "MachineSet name 'suffix' cannot be empty"
+
+		return builder
+	}
+
+	name := fmt.Sprintf("%s-%s", builder.Definition.Name, suffix)
+
+	glog.V(100).Infof("Renaming MachineSet %s to %s", builder.Definition.Name, name)
+
+	builder.Definition.Name = name
+	builder.Definition.Spec.Selector.MatchLabels[MachineSetLabel] = name
+	builder.Definition.Spec.Template.ObjectMeta.Labels[MachineSetLabel] = name
+
+	return builder
+}
+
+// WithNodeLabel sets a label on the nodes of the MachineSet Machines.
+func (builder *SetBuilder) WithNodeLabel(key, value string) *SetBuilder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Adding label %s=%s to the nodes of MachineSet %s", key, value, builder.Definition.Name)
+
+	if key == "" {
+		builder.errorMsg = "MachineSet node label 'key' cannot be empty"
+
+		return builder
+	}
+
+	if builder.Definition.Spec.Template.Spec.ObjectMeta.Labels == nil {
+		builder.Definition.Spec.Template.Spec.ObjectMeta.Labels = map[string]string{}
+	}
+
+	builder.Definition.Spec.Template.Spec.ObjectMeta.Labels[key] = value
+
+	return builder
+}
+
 // PullSet loads an existing MachineSet into Builder struct.
 func PullSet(apiClient *clients.Settings, name, namespace string) (*SetBuilder, error) {
 	glog.V(100).Infof("Pulling existing machineSet name %s in namespace %s", name, namespace)
@@ -178,6 +226,8 @@ func (builder *SetBuilder) Create() (*SetBuilder, error) {
 
 	var err error
 	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
 		builder.Object, err = builder.apiClient.MachineSets(builder.Definition.Namespace).Create(
 			context.TODO(), builder.Definition, metav1.CreateOptions{})
 	}
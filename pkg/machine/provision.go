@@ -0,0 +1,305 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// MachineSetLabel is the label of the Machines of a MachineSet, set to the name of the MachineSet.
+	MachineSetLabel = "machine.openshift.io/cluster-api-machineset"
+	// provisionPollInterval is the polling interval of the provisioning waits.
+	provisionPollInterval = 30 * time.Second
+)
+
+// ProvisionGPUMachineSet creates a MachineSet of the GPU instance type, e.g. the instance type of the GPU config,
+// copied from the first worker MachineSet of the namespace, and waits until its replicas are nodes labeled with
+// nodeLabel, e.g. the NFD NVIDIA PCI label. It returns the MachineSet, to be removed with DeleteAndWait, and its
+// nodes.
+func ProvisionGPUMachineSet(apiClient *clients.Settings, namespace, instanceType, workerLabel string, replicas int32,
+	nodeLabel string, timeout time.Duration) (*SetBuilder, []*nodes.Builder, error) {
+	glog.V(100).Infof("Provisioning %d GPU node(s) of instance type %s in namespace %s", replicas, instanceType,
+		namespace)
+
+	setBuilder, err := NewSetBuilderFromCopy(apiClient, namespace, instanceType, workerLabel, replicas).Create()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the %s MachineSet: %w", instanceType, err)
+	}
+
+	machineNodes, err := WaitForMachineSetNodes(apiClient, setBuilder.Definition.Name, namespace, nodeLabel, timeout)
+	if err != nil {
+		return setBuilder, nil, err
+	}
+
+	return setBuilder, machineNodes, nil
+}
+
+// ListMachines returns the Machines of the MachineSet.
+func ListMachines(apiClient *clients.Settings, machineSetName, namespace string) ([]machinev1beta1.Machine, error) {
+	machineList, err := apiClient.Machines(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.Set{MachineSetLabel: machineSetName}.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the Machines of MachineSet %s: %w", machineSetName, err)
+	}
+
+	return machineList.Items, nil
+}
+
+// DeleteMachine deletes the Machine, which terminates its instance and removes its node, e.g. to simulate the
+// interruption of a spot instance. The MachineSet of the Machine then creates a replacement Machine.
+func DeleteMachine(apiClient *clients.Settings, name, namespace string) error {
+	glog.V(100).Infof("Deleting Machine %s in namespace %s", name, namespace)
+
+	err := apiClient.Machines(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete Machine %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// WaitForMachineReplaced waits until the deleted Machine and its node are removed and its MachineSet replaced it
+// with a Machine whose node is Ready and has the nodeLabel, as WaitForMachineSetNodes. It returns the nodes of the
+// MachineSet.
+func WaitForMachineReplaced(apiClient *clients.Settings, deleted machinev1beta1.Machine, nodeLabel string,
+	timeout time.Duration) ([]*nodes.Builder, error) {
+	machineSetName := deleted.Labels[MachineSetLabel]
+	if machineSetName == "" {
+		return nil, fmt.Errorf("machine %s has no %s label", deleted.Name, MachineSetLabel)
+	}
+
+	deletedNodeName := ""
+	if deleted.Status.NodeRef != nil {
+		deletedNodeName = deleted.Status.NodeRef.Name
+	}
+
+	start := time.Now()
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), provisionPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			_, err := apiClient.Machines(deleted.Namespace).Get(ctx, deleted.Name, metav1.GetOptions{})
+			if err == nil || !k8serrors.IsNotFound(err) {
+				glog.V(100).Infof("Machine %s is not removed yet: %v", deleted.Name, err)
+
+				return false, nil
+			}
+
+			if deletedNodeName == "" {
+				return true, nil
+			}
+
+			_, err = apiClient.CoreV1Interface.Nodes().Get(ctx, deletedNodeName, metav1.GetOptions{})
+			if err == nil || !k8serrors.IsNotFound(err) {
+				glog.V(100).Infof("Node %s of Machine %s is not removed yet: %v", deletedNodeName, deleted.Name, err)
+
+				return false, nil
+			}
+
+			return true, nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("machine %s was not removed: %w", deleted.Name, err)
+	}
+
+	return WaitForMachineSetNodes(apiClient, machineSetName, deleted.Namespace, nodeLabel, timeout-time.Since(start))
+}
+
+// WaitForMachineSetNodes waits until every replica of the MachineSet is a Machine whose node joined the cluster, is
+// Ready and has the nodeLabel, e.g. set by NFD. An empty nodeLabel only waits for the nodes to be Ready. It returns
+// the nodes of the MachineSet.
+func WaitForMachineSetNodes(apiClient *clients.Settings, machineSetName, namespace, nodeLabel string,
+	timeout time.Duration) ([]*nodes.Builder, error) {
+	var machineNodes []*nodes.Builder
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), provisionPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			machineSet, err := apiClient.MachineSets(namespace).Get(ctx, machineSetName, metav1.GetOptions{})
+			if err != nil {
+				glog.V(100).Infof("Failed to get MachineSet %s: %v", machineSetName, err)
+
+				return false, nil
+			}
+
+			machines, err := ListMachines(apiClient, machineSetName, namespace)
+			if err != nil {
+				glog.V(100).Infof("%v", err)
+
+				return false, nil
+			}
+
+			replicas := int32(1)
+			if machineSet.Spec.Replicas != nil {
+				replicas = *machineSet.Spec.Replicas
+			}
+
+			if int32(len(machines)) != replicas {
+				glog.V(100).Infof("MachineSet %s has %d/%d Machines", machineSetName, len(machines), replicas)
+
+				return false, nil
+			}
+
+			machineNodes = nil
+
+			for _, machine := range machines {
+				nodeBuilder, ready := machineNodeReady(apiClient, machine, nodeLabel)
+				if !ready {
+					return false, nil
+				}
+
+				machineNodes = append(machineNodes, nodeBuilder)
+			}
+
+			glog.V(100).Infof("MachineSet %s has %d ready node(s)", machineSetName, len(machineNodes))
+
+			return true, nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("the nodes of MachineSet %s are not ready: %w", machineSetName, err)
+	}
+
+	return machineNodes, nil
+}
+
+// DeleteAndWait deletes the MachineSet and waits until its Machines, and therefore their nodes, are removed.
+func (builder *SetBuilder) DeleteAndWait(timeout time.Duration) error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	name, namespace := builder.Definition.Name, builder.Definition.Namespace
+
+	if err := builder.Delete(); err != nil {
+		return err
+	}
+
+	return waitForNoMachines(builder.apiClient, name, namespace, timeout)
+}
+
+// WaitForMachineSetScaleUp waits until the MachineSet is scaled to at least one replica, e.g. by the cluster
+// autoscaler, and returns its replicas.
+func WaitForMachineSetScaleUp(apiClient *clients.Settings, machineSetName, namespace string,
+	timeout time.Duration) (int32, error) {
+	var replicas int32
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), provisionPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			machineSet, err := apiClient.MachineSets(namespace).Get(ctx, machineSetName, metav1.GetOptions{})
+			if err != nil {
+				glog.V(100).Infof("Failed to get MachineSet %s: %v", machineSetName, err)
+
+				return false, nil
+			}
+
+			if machineSet.Spec.Replicas != nil {
+				replicas = *machineSet.Spec.Replicas
+			}
+
+			glog.V(100).Infof("MachineSet %s has %d replicas", machineSetName, replicas)
+
+			return replicas > 0, nil
+		})
+	if err != nil {
+		return 0, fmt.Errorf("MachineSet %s was not scaled up: %w", machineSetName, err)
+	}
+
+	return replicas, nil
+}
+
+// WaitForMachineSetScaleDown waits until the MachineSet is scaled to zero replicas, e.g. by the cluster autoscaler,
+// and its Machines are removed.
+func WaitForMachineSetScaleDown(apiClient *clients.Settings, machineSetName, namespace string,
+	timeout time.Duration) error {
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), provisionPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			machineSet, err := apiClient.MachineSets(namespace).Get(ctx, machineSetName, metav1.GetOptions{})
+			if err != nil {
+				glog.V(100).Infof("Failed to get MachineSet %s: %v", machineSetName, err)
+
+				return false, nil
+			}
+
+			if machineSet.Spec.Replicas != nil && *machineSet.Spec.Replicas > 0 {
+				glog.V(100).Infof("MachineSet %s still has %d replicas", machineSetName, *machineSet.Spec.Replicas)
+
+				return false, nil
+			}
+
+			return true, nil
+		})
+	if err != nil {
+		return fmt.Errorf("MachineSet %s was not scaled down: %w", machineSetName, err)
+	}
+
+	return waitForNoMachines(apiClient, machineSetName, namespace, timeout)
+}
+
+// waitForNoMachines waits until the MachineSet has no Machines left.
+func waitForNoMachines(apiClient *clients.Settings, machineSetName, namespace string, timeout time.Duration) error {
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), provisionPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			machines, err := ListMachines(apiClient, machineSetName, namespace)
+			if err != nil {
+				glog.V(100).Infof("%v", err)
+
+				return false, nil
+			}
+
+			glog.V(100).Infof("MachineSet %s still has %d Machine(s)", machineSetName, len(machines))
+
+			return len(machines) == 0, nil
+		})
+	if err != nil {
+		return fmt.Errorf("the Machines of MachineSet %s were not removed: %w", machineSetName, err)
+	}
+
+	return nil
+}
+
+// machineNodeReady returns the node of the Machine when it joined the cluster, is Ready and has the nodeLabel.
+func machineNodeReady(apiClient *clients.Settings, machine machinev1beta1.Machine,
+	nodeLabel string) (*nodes.Builder, bool) {
+	if machine.Status.NodeRef == nil {
+		phase := ""
+		if machine.Status.Phase != nil {
+			phase = *machine.Status.Phase
+		}
+
+		glog.V(100).Infof("Machine %s in phase %q has no node yet", machine.Name, phase)
+
+		return nil, false
+	}
+
+	nodeName := machine.Status.NodeRef.Name
+
+	nodeBuilder, err := nodes.Pull(apiClient, nodeName)
+	if err != nil {
+		glog.V(100).Infof("Node %s of Machine %s has not joined the cluster yet: %v", nodeName, machine.Name, err)
+
+		return nil, false
+	}
+
+	if ready, err := nodeBuilder.IsReady(); !ready {
+		glog.V(100).Infof("Node %s of Machine %s is not Ready: %v", nodeName, machine.Name, err)
+
+		return nil, false
+	}
+
+	if _, labeled := nodeBuilder.Object.Labels[nodeLabel]; nodeLabel != "" && !labeled {
+		glog.V(100).Infof("Node %s of Machine %s has no %s label yet", nodeName, machine.Name, nodeLabel)
+
+		return nil, false
+	}
+
+	return nodeBuilder, true
+}
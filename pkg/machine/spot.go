@@ -0,0 +1,79 @@
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/glog"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+)
+
+// InterruptibleInstanceLabel is the label set by the machine API on the nodes of spot and preemptible instances.
+const InterruptibleInstanceLabel = "machine.openshift.io/interruptible-instance"
+
+// WithSpotInstances makes the MachineSet provision spot instances on AWS and Azure, at most at the on-demand price,
+// and preemptible instances on GCP. Their nodes are labeled with InterruptibleInstanceLabel.
+func (builder *SetBuilder) WithSpotInstances() *SetBuilder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting MachineSet %s to provision spot instances on %s", builder.Definition.Name,
+		builder.publicCloud)
+
+	var err error
+
+	switch builder.publicCloud {
+	case AwsCloud:
+		providerSpec := &machinev1beta1.AWSMachineProviderConfig{}
+		err = builder.updateProviderSpec(providerSpec, func() {
+			providerSpec.SpotMarketOptions = &machinev1beta1.SpotMarketOptions{}
+		})
+	case GcpCloud:
+		providerSpec := &machinev1beta1.GCPMachineProviderSpec{}
+		err = builder.updateProviderSpec(providerSpec, func() {
+			providerSpec.Preemptible = true
+		})
+	case AzureCloud:
+		providerSpec := &machinev1beta1.AzureMachineProviderSpec{}
+		err = builder.updateProviderSpec(providerSpec, func() {
+			providerSpec.SpotVMOptions = &machinev1beta1.SpotVMOptions{}
+		})
+	default:
+		err = fmt.Errorf("could not find supported public cloud")
+	}
+
+	if err != nil {
+		glog.V(100).Infof("Failed to set MachineSet %s spot instances: %v", builder.Definition.Name, err)
+
+		builder.errorMsg = fmt.Sprintf("error setting spot instances: %v", err)
+	}
+
+	return builder
+}
+
+// updateProviderSpec decodes the ProviderSpec Value into the cloud-specific providerSpec, applies the update to it
+// and encodes it back.
+func (builder *SetBuilder) updateProviderSpec(providerSpec interface{}, update func()) error {
+	byteArray, err := json.Marshal(builder.Definition.Spec.Template.Spec.ProviderSpec.Value)
+	if err != nil {
+		return fmt.Errorf("error marshalling machineSet providerSpec.Value into byte array: %w", err)
+	}
+
+	if err := json.Unmarshal(byteArray, providerSpec); err != nil {
+		return fmt.Errorf("error unmarshalling byte array into %T: %w", providerSpec, err)
+	}
+
+	update()
+
+	byteArray, err = json.Marshal(providerSpec)
+	if err != nil {
+		return fmt.Errorf("error marshalling %T into byte array: %w", providerSpec, err)
+	}
+
+	if err := json.Unmarshal(byteArray, builder.Definition.Spec.Template.Spec.ProviderSpec.Value); err != nil {
+		return fmt.Errorf("error unmarshalling %T byte array into ProviderSpec.Value: %w", providerSpec, err)
+	}
+
+	return nil
+}
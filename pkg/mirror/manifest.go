@@ -0,0 +1,83 @@
+package mirror
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// contentDigestHeader is the registry response header holding the digest of the manifest.
+	contentDigestHeader = "Docker-Content-Digest"
+	// maxManifestSize bounds the manifests and configuration blobs read from the registries.
+	maxManifestSize = 4 << 20
+)
+
+// Manifest is the manifest of an image as served by its registry, either a single architecture image manifest or a
+// multi-architecture image index.
+type Manifest struct {
+	// MediaType is the media type of the manifest, from the registry response.
+	MediaType string
+	// Digest is the digest of the manifest, from the registry response or else computed from its content.
+	Digest string
+	// Content is the raw manifest.
+	Content []byte
+}
+
+// FetchManifest returns the manifest of the image from its registry, with the credentials of the cluster pull secret.
+func (checker *Checker) FetchManifest(image string) (*Manifest, error) {
+	_, repository := SplitRegistry(image)
+	_, reference := splitReference(repository)
+
+	content, response, err := checker.fetch(image, "manifests/"+reference, manifestMediaTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the manifest of image %s: %w", image, err)
+	}
+
+	manifest := &Manifest{
+		MediaType: response.Header.Get("Content-Type"),
+		Digest:    response.Header.Get(contentDigestHeader),
+		Content:   content,
+	}
+
+	if manifest.Digest == "" {
+		sum := sha256.Sum256(content)
+		manifest.Digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return manifest, nil
+}
+
+// FetchBlob returns the blob of the image repository with the digest, e.g. the configuration of an image manifest.
+func (checker *Checker) FetchBlob(image, digest string) ([]byte, error) {
+	content, _, err := checker.fetch(image, "blobs/"+digest, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch blob %s of image %s: %w", digest, image, err)
+	}
+
+	return content, nil
+}
+
+// fetch returns the content of the resource of the image repository and the registry response.
+func (checker *Checker) fetch(image, resource string, accept []string) ([]byte, *http.Response, error) {
+	response, err := checker.registryRequest(http.MethodGet, image, resource, accept)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s request returned %s", strings.Split(resource, "/")[0], response.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxManifestSize))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the %s response: %w", resource, err)
+	}
+
+	return content, response, nil
+}
@@ -0,0 +1,140 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// dockerHubRegistry is the registry of the images whose reference has no registry host, e.g. ubuntu:22.04.
+const dockerHubRegistry = "docker.io"
+
+// Resolver resolves the images of the suites through the mirrors of a disconnected cluster: the mirrors of its
+// ImageDigestMirrorSets and ImageTagMirrorSets, then the mirror registry.
+type Resolver struct {
+	// Registry is the mirror registry host, with an optional path prefix, replacing the registry host of the images
+	// without mirror set, e.g. mirror.lab:5000 or mirror.lab:5000/nvidia-ci.
+	Registry string
+	// digestMirrors are the mirrors of the ImageDigestMirrorSets, by source.
+	digestMirrors map[string][]string
+	// tagMirrors are the mirrors of the ImageTagMirrorSets, by source.
+	tagMirrors map[string][]string
+}
+
+// NewResolver returns the Resolver of the mirror registry and of the ImageDigestMirrorSets and ImageTagMirrorSets of
+// the cluster.
+func NewResolver(apiClient *clients.Settings, registry string) (*Resolver, error) {
+	resolver := &Resolver{
+		Registry:      strings.TrimSuffix(registry, "/"),
+		digestMirrors: map[string][]string{},
+		tagMirrors:    map[string][]string{},
+	}
+
+	digestMirrorSets, err := apiClient.ImageDigestMirrorSets().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ImageDigestMirrorSets: %w", err)
+	}
+
+	for _, digestMirrorSet := range digestMirrorSets.Items {
+		for _, digestMirrors := range digestMirrorSet.Spec.ImageDigestMirrors {
+			resolver.digestMirrors[digestMirrors.Source] = append(resolver.digestMirrors[digestMirrors.Source],
+				mirrorStrings(digestMirrors.Mirrors)...)
+		}
+	}
+
+	tagMirrorSets, err := apiClient.ImageTagMirrorSets().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ImageTagMirrorSets: %w", err)
+	}
+
+	for _, tagMirrorSet := range tagMirrorSets.Items {
+		for _, tagMirrors := range tagMirrorSet.Spec.ImageTagMirrors {
+			resolver.tagMirrors[tagMirrors.Source] = append(resolver.tagMirrors[tagMirrors.Source],
+				mirrorStrings(tagMirrors.Mirrors)...)
+		}
+	}
+
+	glog.V(100).Infof("Resolving images through mirror registry '%s', %d digest and %d tag mirror source(s)",
+		resolver.Registry, len(resolver.digestMirrors), len(resolver.tagMirrors))
+
+	return resolver, nil
+}
+
+// Resolve returns the image the cluster can pull in place of the image:
+//   - the image itself when a mirror set of its kind, i.e. an ImageDigestMirrorSet for the digest references and an
+//     ImageTagMirrorSet for the tag references, covers it, the nodes pulling it from the mirror.
+//   - the image on the first mirror of any mirror set covering it otherwise, e.g. a tag reference under an
+//     ImageDigestMirrorSet source, the nodes only redirecting the pulls by digest.
+//   - the image on the mirror registry, with the same repository path, when no mirror set covers it.
+func (resolver *Resolver) Resolve(image string) string {
+	if resolver == nil || image == "" {
+		return image
+	}
+
+	byDigest := strings.Contains(image, "@")
+
+	ownMirrors, otherMirrors := resolver.tagMirrors, resolver.digestMirrors
+	if byDigest {
+		ownMirrors, otherMirrors = resolver.digestMirrors, resolver.tagMirrors
+	}
+
+	if source, _ := longestSource(ownMirrors, image); source != "" {
+		return image
+	}
+
+	if source, mirrors := longestSource(otherMirrors, image); source != "" && len(mirrors) > 0 {
+		return mirrors[0] + strings.TrimPrefix(image, source)
+	}
+
+	if resolver.Registry == "" {
+		return image
+	}
+
+	_, repository := SplitRegistry(image)
+
+	return resolver.Registry + "/" + repository
+}
+
+// SplitRegistry splits the image reference into its registry host and its repository path with tag or digest, the
+// references without registry host being Docker Hub images.
+func SplitRegistry(image string) (string, string) {
+	registry, repository, found := strings.Cut(image, "/")
+	if !found || !strings.ContainsAny(registry, ".:") && registry != "localhost" {
+		return dockerHubRegistry, image
+	}
+
+	return registry, repository
+}
+
+// longestSource returns the most specific source of the mirrors matching the image, and its mirrors. A source matches
+// the image when it is the image repository or one of its parent paths, as in the containers registries.conf.
+func longestSource(mirrors map[string][]string, image string) (string, []string) {
+	var longest string
+
+	for source := range mirrors {
+		if len(source) <= len(longest) || !strings.HasPrefix(image, source) {
+			continue
+		}
+
+		if rest := image[len(source):]; rest == "" || strings.ContainsAny(rest[:1], "/:@") {
+			longest = source
+		}
+	}
+
+	return longest, mirrors[longest]
+}
+
+// mirrorStrings returns the mirrors as strings.
+func mirrorStrings(mirrors []configv1.ImageMirror) []string {
+	mirrorStrings := make([]string, 0, len(mirrors))
+	for _, mirror := range mirrors {
+		mirrorStrings = append(mirrorStrings, string(mirror))
+	}
+
+	return mirrorStrings
+}
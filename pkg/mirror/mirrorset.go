@@ -0,0 +1,359 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DigestMirrorSetBuilder provides struct for the ImageDigestMirrorSet object redirecting the pulls by digest of the
+// sources to their mirrors, and its definition.
+type DigestMirrorSetBuilder struct {
+	// ImageDigestMirrorSet definition. Used to create the ImageDigestMirrorSet object.
+	Definition *configv1.ImageDigestMirrorSet
+	// Created ImageDigestMirrorSet object.
+	Object *configv1.ImageDigestMirrorSet
+	// errorMsg is processed before the ImageDigestMirrorSet object is created.
+	errorMsg  string
+	apiClient *clients.Settings
+}
+
+// NewDigestMirrorSetBuilder creates a new instance of DigestMirrorSetBuilder with the mirror of each source, e.g.
+// "mirror.lab:5000/nvidia" for "nvcr.io/nvidia".
+func NewDigestMirrorSetBuilder(apiClient *clients.Settings, name string,
+	mirrors map[string]string) *DigestMirrorSetBuilder {
+	glog.V(100).Infof("Initializing new ImageDigestMirrorSet structure with name '%s' and mirrors %v", name, mirrors)
+
+	builder := &DigestMirrorSetBuilder{
+		apiClient: apiClient,
+		Definition: &configv1.ImageDigestMirrorSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		},
+	}
+
+	for _, source := range sortedSources(mirrors) {
+		builder.Definition.Spec.ImageDigestMirrors = append(builder.Definition.Spec.ImageDigestMirrors,
+			configv1.ImageDigestMirrors{
+				Source:  source,
+				Mirrors: []configv1.ImageMirror{configv1.ImageMirror(mirrors[source])},
+			})
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the ImageDigestMirrorSet is empty")
+
+		builder.errorMsg = "ImageDigestMirrorSet 'name' cannot be empty"
+	}
+
+	if len(mirrors) == 0 {
+		glog.V(100).Infof("The mirrors of the ImageDigestMirrorSet are empty")
+
+		builder.errorMsg = "ImageDigestMirrorSet 'mirrors' cannot be empty"
+	}
+
+	return builder
+}
+
+// Exists checks whether the ImageDigestMirrorSet exists.
+func (builder *DigestMirrorSetBuilder) Exists() bool {
+	if valid, _ := builder.validate(); !valid {
+		return false
+	}
+
+	glog.V(100).Infof("Checking if ImageDigestMirrorSet %s exists", builder.Definition.Name)
+
+	var err error
+	builder.Object, err = builder.apiClient.ImageDigestMirrorSets().Get(
+		context.TODO(), builder.Definition.Name, metav1.GetOptions{})
+
+	return err == nil || !k8serrors.IsNotFound(err)
+}
+
+// IsUpToDate returns true when the ImageDigestMirrorSet exists with the mirrors of the definition, its update not
+// needing to be rolled out to the nodes.
+func (builder *DigestMirrorSetBuilder) IsUpToDate() bool {
+	if !builder.Exists() || builder.Object == nil {
+		return false
+	}
+
+	return equality.Semantic.DeepEqual(builder.Object.Spec, builder.Definition.Spec)
+}
+
+// Create makes the ImageDigestMirrorSet in the cluster, or updates the mirrors of the existing one, and stores the
+// object in struct.
+func (builder *DigestMirrorSetBuilder) Create() (*DigestMirrorSetBuilder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating the ImageDigestMirrorSet %s", builder.Definition.Name)
+
+	var err error
+
+	if builder.Exists() && builder.Object != nil {
+		builder.Object.Spec = builder.Definition.Spec
+		builder.Object, err = builder.apiClient.ImageDigestMirrorSets().Update(
+			context.TODO(), builder.Object, metav1.UpdateOptions{})
+
+		return builder, err
+	}
+
+	owner.Label(builder.Definition)
+	builder.Object, err = builder.apiClient.ImageDigestMirrorSets().Create(
+		context.TODO(), builder.Definition, metav1.CreateOptions{})
+
+	return builder, err
+}
+
+// Delete removes the ImageDigestMirrorSet.
+func (builder *DigestMirrorSetBuilder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting the ImageDigestMirrorSet %s", builder.Definition.Name)
+
+	if !builder.Exists() {
+		return nil
+	}
+
+	err := builder.apiClient.ImageDigestMirrorSets().Delete(
+		context.TODO(), builder.Definition.Name, metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+
+	builder.Object = nil
+
+	return nil
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *DigestMirrorSetBuilder) validate() (bool, error) {
+	resourceCRD := "ImageDigestMirrorSet"
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
+
+// ContentSourcePolicyBuilder provides struct for the ImageContentSourcePolicy object, replaced by the
+// ImageDigestMirrorSets since OpenShift 4.13, redirecting the pulls by digest of the sources to their mirrors, and its
+// definition.
+type ContentSourcePolicyBuilder struct {
+	// ImageContentSourcePolicy definition. Used to create the ImageContentSourcePolicy object.
+	Definition *operatorv1alpha1.ImageContentSourcePolicy
+	// Created ImageContentSourcePolicy object.
+	Object *operatorv1alpha1.ImageContentSourcePolicy
+	// errorMsg is processed before the ImageContentSourcePolicy object is created.
+	errorMsg  string
+	apiClient *clients.Settings
+}
+
+// NewContentSourcePolicyBuilder creates a new instance of ContentSourcePolicyBuilder with the mirror of each source.
+func NewContentSourcePolicyBuilder(apiClient *clients.Settings, name string,
+	mirrors map[string]string) *ContentSourcePolicyBuilder {
+	glog.V(100).Infof("Initializing new ImageContentSourcePolicy structure with name '%s' and mirrors %v",
+		name, mirrors)
+
+	builder := &ContentSourcePolicyBuilder{
+		apiClient: apiClient,
+		Definition: &operatorv1alpha1.ImageContentSourcePolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		},
+	}
+
+	for _, source := range sortedSources(mirrors) {
+		builder.Definition.Spec.RepositoryDigestMirrors = append(builder.Definition.Spec.RepositoryDigestMirrors,
+			operatorv1alpha1.RepositoryDigestMirrors{
+				Source:  source,
+				Mirrors: []string{mirrors[source]},
+			})
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the ImageContentSourcePolicy is empty")
+
+		builder.errorMsg = "ImageContentSourcePolicy 'name' cannot be empty"
+	}
+
+	if len(mirrors) == 0 {
+		glog.V(100).Infof("The mirrors of the ImageContentSourcePolicy are empty")
+
+		builder.errorMsg = "ImageContentSourcePolicy 'mirrors' cannot be empty"
+	}
+
+	return builder
+}
+
+// Exists checks whether the ImageContentSourcePolicy exists.
+func (builder *ContentSourcePolicyBuilder) Exists() bool {
+	if valid, _ := builder.validate(); !valid {
+		return false
+	}
+
+	glog.V(100).Infof("Checking if ImageContentSourcePolicy %s exists", builder.Definition.Name)
+
+	var err error
+	builder.Object, err = builder.apiClient.ImageContentSourcePolicies().Get(
+		context.TODO(), builder.Definition.Name, metav1.GetOptions{})
+
+	return err == nil || !k8serrors.IsNotFound(err)
+}
+
+// IsUpToDate returns true when the ImageContentSourcePolicy exists with the mirrors of the definition, its update not
+// needing to be rolled out to the nodes.
+func (builder *ContentSourcePolicyBuilder) IsUpToDate() bool {
+	if !builder.Exists() || builder.Object == nil {
+		return false
+	}
+
+	return equality.Semantic.DeepEqual(builder.Object.Spec, builder.Definition.Spec)
+}
+
+// Create makes the ImageContentSourcePolicy in the cluster, or updates the mirrors of the existing one, and stores
+// the object in struct.
+func (builder *ContentSourcePolicyBuilder) Create() (*ContentSourcePolicyBuilder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating the ImageContentSourcePolicy %s", builder.Definition.Name)
+
+	var err error
+
+	if builder.Exists() && builder.Object != nil {
+		builder.Object.Spec = builder.Definition.Spec
+		builder.Object, err = builder.apiClient.ImageContentSourcePolicies().Update(
+			context.TODO(), builder.Object, metav1.UpdateOptions{})
+
+		return builder, err
+	}
+
+	owner.Label(builder.Definition)
+	builder.Object, err = builder.apiClient.ImageContentSourcePolicies().Create(
+		context.TODO(), builder.Definition, metav1.CreateOptions{})
+
+	return builder, err
+}
+
+// Delete removes the ImageContentSourcePolicy.
+func (builder *ContentSourcePolicyBuilder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting the ImageContentSourcePolicy %s", builder.Definition.Name)
+
+	if !builder.Exists() {
+		return nil
+	}
+
+	err := builder.apiClient.ImageContentSourcePolicies().Delete(
+		context.TODO(), builder.Definition.Name, metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+
+	builder.Object = nil
+
+	return nil
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *ContentSourcePolicyBuilder) validate() (bool, error) {
+	resourceCRD := "ImageContentSourcePolicy"
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
+
+// DigestMirrorSetsServed returns true when the cluster serves the ImageDigestMirrorSet API, i.e. OpenShift 4.13 and
+// later, the older clusters only serving the ImageContentSourcePolicies.
+func DigestMirrorSetsServed(apiClient *clients.Settings) (bool, error) {
+	_, err := apiClient.ImageDigestMirrorSets().List(context.TODO(), metav1.ListOptions{Limit: 1})
+	if err == nil {
+		return true, nil
+	}
+
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	return false, fmt.Errorf("failed to list ImageDigestMirrorSets: %w", err)
+}
+
+// sortedSources returns the sources of the mirrors in a stable order, for the updates of the mirror sets not to
+// reorder their mirrors.
+func sortedSources(mirrors map[string]string) []string {
+	sources := make([]string, 0, len(mirrors))
+	for source := range mirrors {
+		sources = append(sources, source)
+	}
+
+	sort.Strings(sources)
+
+	return sources
+}
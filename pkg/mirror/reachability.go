@@ -0,0 +1,261 @@
+package mirror
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PullSecretNamespace is the namespace of the cluster wide pull secret.
+	PullSecretNamespace = "openshift-config"
+	// PullSecretName is the name of the cluster wide pull secret, holding the credentials of the mirror registry.
+	PullSecretName = "pull-secret"
+	// dockerHubEndpoint is the registry API endpoint of the Docker Hub images.
+	dockerHubEndpoint = "registry-1.docker.io"
+	// reachabilityTimeout is the timeout of each registry request of the reachability check.
+	reachabilityTimeout = 30 * time.Second
+)
+
+// manifestMediaTypes are the manifest media types accepted by the reachability check, covering the single and
+// multi-architecture images.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Checker checks that images are reachable on their registry, with the credentials of the cluster pull secret.
+type Checker struct {
+	// auths are the base64 encoded user:password credentials of the pull secret, by registry host.
+	auths  map[string]string
+	client *http.Client
+}
+
+// NewChecker returns the Checker of the registries of the cluster pull secret. insecure skips the verification of the
+// registry certificates, e.g. for the self-signed mirror registries of disconnected labs.
+func NewChecker(apiClient *clients.Settings, insecure bool) (*Checker, error) {
+	secret, err := apiClient.Secrets(PullSecretNamespace).Get(context.TODO(), PullSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull secret %s/%s: %w", PullSecretNamespace, PullSecretName, err)
+	}
+
+	var dockerConfig struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &dockerConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse pull secret %s/%s: %w", PullSecretNamespace, PullSecretName, err)
+	}
+
+	checker := &Checker{
+		auths: map[string]string{},
+		client: &http.Client{
+			Timeout: reachabilityTimeout,
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				//nolint:gosec // Self-signed mirror registries are only skipped when explicitly configured.
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			},
+		},
+	}
+
+	for registry, auth := range dockerConfig.Auths {
+		checker.auths[registry] = auth.Auth
+	}
+
+	return checker, nil
+}
+
+// CheckReachable returns an error when the manifest of the image cannot be fetched from its registry, e.g. when the
+// image was not mirrored or the registry is unreachable from a disconnected lab.
+func (checker *Checker) CheckReachable(image string) error {
+	_, repository := SplitRegistry(image)
+	_, reference := splitReference(repository)
+
+	response, err := checker.registryRequest(http.MethodHead, image, "manifests/"+reference, manifestMediaTypes)
+	if err != nil {
+		return fmt.Errorf("image %s is not reachable: %w", image, err)
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("image %s is not reachable: manifest request returned %s", image, response.Status)
+	}
+
+	glog.V(100).Infof("Image %s is reachable", image)
+
+	return nil
+}
+
+// registryRequest sends the request of the resource of the image repository on its registry, e.g. manifests/latest,
+// answering the authentication challenge of the registry with the pull secret credentials. The caller closes the body
+// of the response.
+func (checker *Checker) registryRequest(method, image, resource string, accept []string) (*http.Response, error) {
+	registry, repository := SplitRegistry(image)
+	name, _ := splitReference(repository)
+
+	endpoint := registry
+	if registry == dockerHubRegistry {
+		endpoint = dockerHubEndpoint
+
+		if !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+
+	resourceURL := fmt.Sprintf("https://%s/v2/%s/%s", endpoint, name, resource)
+
+	response, err := checker.send(method, resourceURL, accept, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		return response, nil
+	}
+
+	response.Body.Close()
+
+	authorization, err := checker.authorization(response.Header.Get("WWW-Authenticate"),
+		checker.registryAuth(registry, repository))
+	if err != nil {
+		return nil, err
+	}
+
+	return checker.send(method, resourceURL, accept, authorization)
+}
+
+// registryAuth returns the pull secret credentials of the image, the pull secret entries being registry hosts or
+// repository paths.
+func (checker *Checker) registryAuth(registry, repository string) string {
+	path := registry + "/" + repository
+
+	var longest, auth string
+
+	for key, keyAuth := range checker.auths {
+		if len(key) > len(longest) && (key == registry || strings.HasPrefix(path, key+"/")) {
+			longest, auth = key, keyAuth
+		}
+	}
+
+	return auth
+}
+
+// authorization returns the Authorization header answering the registry challenge: the basic credentials, or a bearer
+// token requested from the realm of the challenge with the basic credentials when there are some.
+func (checker *Checker) authorization(challenge, auth string) (string, error) {
+	scheme, parameters, _ := strings.Cut(challenge, " ")
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if auth == "" {
+			return "", fmt.Errorf("registry requires credentials and the pull secret has none")
+		}
+
+		return "Basic " + auth, nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported registry authentication challenge %q", challenge)
+	}
+
+	values := url.Values{}
+
+	var realm string
+
+	for _, parameter := range strings.Split(parameters, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(parameter), "=")
+		value = strings.Trim(value, `"`)
+
+		if key == "realm" {
+			realm = value
+		} else if key == "service" || key == "scope" {
+			values.Set(key, value)
+		}
+	}
+
+	if realm == "" {
+		return "", fmt.Errorf("registry authentication challenge %q has no realm", challenge)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, realm+"?"+values.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if auth != "" {
+		request.Header.Set("Authorization", "Basic "+auth)
+	}
+
+	response, err := checker.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to request a registry token: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token request returned %s", response.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode the registry token: %w", err)
+	}
+
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+
+	return "Bearer " + token.Token, nil
+}
+
+// send sends the request accepting the media types, with the Authorization header when set.
+func (checker *Checker) send(method, resourceURL string, accept []string, authorization string) (*http.Response,
+	error) {
+	request, err := http.NewRequest(method, resourceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accept) > 0 {
+		request.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	return checker.client.Do(request)
+}
+
+// splitReference splits the repository path with tag or digest into its name and reference, latest by default.
+func splitReference(repository string) (string, string) {
+	if name, digest, found := strings.Cut(repository, "@"); found {
+		return name, digest
+	}
+
+	if index := strings.LastIndex(repository, ":"); index > strings.LastIndex(repository, "/") {
+		return repository[:index], repository[index+1:]
+	}
+
+	return repository, "latest"
+}
@@ -0,0 +1,20 @@
+// Package v1alpha1 contains the API Schema definitions of the nfd.openshift.io v1alpha1 API group, served by the
+// NFD operator next to the NodeFeatureDiscovery API of github.com/openshift/cluster-nfd-operator/api/v1, which the
+// vendored operator API does not provide.
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is group version used to register these objects.
+	GroupVersion = schema.GroupVersion{Group: "nfd.openshift.io", Version: "v1alpha1"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme.
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
@@ -0,0 +1,124 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MatchOp is the match operator of a MatchExpression.
+type MatchOp string
+
+const (
+	// MatchAny matches any value.
+	MatchAny MatchOp = ""
+	// MatchIn matches when the value is one of the expression values.
+	MatchIn MatchOp = "In"
+	// MatchNotIn matches when the value is none of the expression values.
+	MatchNotIn MatchOp = "NotIn"
+	// MatchInRegexp matches when the value matches one of the expression regexps.
+	MatchInRegexp MatchOp = "InRegexp"
+	// MatchExists matches when the key exists.
+	MatchExists MatchOp = "Exists"
+	// MatchDoesNotExist matches when the key does not exist.
+	MatchDoesNotExist MatchOp = "DoesNotExist"
+	// MatchGt matches when the value is greater than the expression value.
+	MatchGt MatchOp = "Gt"
+	// MatchLt matches when the value is lower than the expression value.
+	MatchLt MatchOp = "Lt"
+	// MatchGtLt matches when the value is between the two expression values.
+	MatchGtLt MatchOp = "GtLt"
+	// MatchIsTrue matches when the value is true.
+	MatchIsTrue MatchOp = "IsTrue"
+	// MatchIsFalse matches when the value is false.
+	MatchIsFalse MatchOp = "IsFalse"
+)
+
+// NodeFeatureRuleSpec describes a NodeFeatureRule.
+type NodeFeatureRuleSpec struct {
+	// Rules is a list of node customization rules.
+	Rules []Rule `json:"rules"`
+}
+
+// Rule labels, annotates and taints the nodes whose features match.
+type Rule struct {
+	// Name of the rule.
+	Name string `json:"name"`
+	// Labels to create if the rule matches.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// LabelsTemplate specifies a template to expand for dynamically generating multiple labels.
+	// +optional
+	LabelsTemplate string `json:"labelsTemplate,omitempty"`
+	// Annotations to create if the rule matches.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Vars is the variables to store if the rule matches, for the backreferences of other rules.
+	// +optional
+	Vars map[string]string `json:"vars,omitempty"`
+	// VarsTemplate specifies a template to expand for dynamically generating multiple variables.
+	// +optional
+	VarsTemplate string `json:"varsTemplate,omitempty"`
+	// Taints to create if the rule matches.
+	// +optional
+	Taints []corev1.Taint `json:"taints,omitempty"`
+	// ExtendedResources to create if the rule matches.
+	// +optional
+	ExtendedResources map[string]string `json:"extendedResources,omitempty"`
+	// MatchFeatures specifies a set of matcher terms all of which must match.
+	// +optional
+	MatchFeatures []FeatureMatcherTerm `json:"matchFeatures,omitempty"`
+	// MatchAny specifies a list of matchers one of which must match.
+	// +optional
+	MatchAny []MatchAnyElem `json:"matchAny,omitempty"`
+}
+
+// MatchAnyElem specifies one sub-matcher of MatchAny.
+type MatchAnyElem struct {
+	// MatchFeatures specifies a set of matcher terms all of which must match.
+	MatchFeatures []FeatureMatcherTerm `json:"matchFeatures"`
+}
+
+// FeatureMatcherTerm defines requirements against one feature set, e.g. kernel.loadedmodule or pci.device.
+type FeatureMatcherTerm struct {
+	// Feature is the name of the feature set to match against.
+	Feature string `json:"feature"`
+	// MatchExpressions is the set of per-element expressions evaluated against the feature set.
+	// +optional
+	MatchExpressions map[string]*MatchExpression `json:"matchExpressions,omitempty"`
+	// MatchName is an expression evaluated against the names of the elements of the feature set.
+	// +optional
+	MatchName *MatchExpression `json:"matchName,omitempty"`
+}
+
+// MatchExpression specifies an expression to evaluate against a set of input values.
+type MatchExpression struct {
+	// Op is the operation to apply when matching.
+	Op MatchOp `json:"op"`
+	// Value is the list of values that the operand evaluates the input against.
+	// +optional
+	Value []string `json:"value,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// NodeFeatureRule customizes node features, e.g. labels, based on the features discovered by NFD.
+type NodeFeatureRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NodeFeatureRuleSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// NodeFeatureRuleList contains a list of NodeFeatureRule.
+type NodeFeatureRuleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeFeatureRule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NodeFeatureRule{}, &NodeFeatureRuleList{})
+}
@@ -14,6 +14,8 @@ const (
 	CatalogSourceDefault                = "redhat-operators"
 	CatalogSourceNamespace              = "openshift-marketplace"
 	OperatorDeploymentName              = "nfd-controller-manager"
+	SubscriptionName                    = "nfd-subscription"
+	WorkerDaemonSetName                 = "nfd-worker"
 	Package                             = "nfd"
 	CRName                              = "nfd-instance"
 
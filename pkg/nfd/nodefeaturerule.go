@@ -0,0 +1,232 @@
+package nfd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	nfdv1alpha1 "github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd/api/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	goclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// nodeFeatureRuleCRD is the kind of the NodeFeatureRule objects.
+const nodeFeatureRuleCRD = "NodeFeatureRule"
+
+// RuleBuilder provides a struct for NodeFeatureRule object
+// from the cluster and a NodeFeatureRule definition.
+type RuleBuilder struct {
+	// RuleBuilder definition. Used to create
+	// RuleBuilder object with minimum set of required elements.
+	Definition *nfdv1alpha1.NodeFeatureRule
+	// Created RuleBuilder object on the cluster.
+	Object *nfdv1alpha1.NodeFeatureRule
+	// api client to interact with the cluster.
+	apiClient *clients.Settings
+	// errorMsg is processed before RuleBuilder object is created.
+	errorMsg string
+}
+
+// NewRuleBuilder creates a RuleBuilder of a cluster scoped NodeFeatureRule without rules.
+func NewRuleBuilder(apiClient *clients.Settings, name string) *RuleBuilder {
+	glog.V(LogLevel).Infof("Initializing new NodeFeatureRule structure with name: %s", name)
+
+	builder := RuleBuilder{
+		apiClient: apiClient,
+		Definition: &nfdv1alpha1.NodeFeatureRule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(LogLevel).Infof("The name of the NodeFeatureRule is empty")
+
+		builder.errorMsg = "NodeFeatureRule 'name' cannot be empty"
+	}
+
+	return &builder
+}
+
+// PullRule loads an existing NodeFeatureRule into RuleBuilder struct.
+func PullRule(apiClient *clients.Settings, name string) (*RuleBuilder, error) {
+	glog.V(LogLevel).Infof("Pulling existing NodeFeatureRule name: %s", name)
+
+	builder := NewRuleBuilder(apiClient, name)
+
+	if !builder.Exists() {
+		return nil, fmt.Errorf("NodeFeatureRule object %s doesn't exist", name)
+	}
+
+	builder.Definition = builder.Object
+
+	return builder, nil
+}
+
+// WithRule appends a rule to the NodeFeatureRule.
+func (builder *RuleBuilder) WithRule(rule nfdv1alpha1.Rule) *RuleBuilder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(LogLevel).Infof("Appending rule %s to NodeFeatureRule %s", rule.Name, builder.Definition.Name)
+
+	if rule.Name == "" {
+		builder.errorMsg = "NodeFeatureRule rule 'name' cannot be empty"
+
+		return builder
+	}
+
+	if len(rule.MatchFeatures) == 0 && len(rule.MatchAny) == 0 {
+		builder.errorMsg = fmt.Sprintf("NodeFeatureRule rule %s matches no feature", rule.Name)
+
+		return builder
+	}
+
+	builder.Definition.Spec.Rules = append(builder.Definition.Spec.Rules, rule)
+
+	return builder
+}
+
+// Get returns NodeFeatureRule object if found.
+func (builder *RuleBuilder) Get() (*nfdv1alpha1.NodeFeatureRule, error) {
+	if valid, err := builder.validate(); !valid {
+		return nil, err
+	}
+
+	glog.V(LogLevel).Infof("Collecting NodeFeatureRule object %s", builder.Definition.Name)
+
+	nodeFeatureRule := &nfdv1alpha1.NodeFeatureRule{}
+	err := builder.apiClient.Get(context.TODO(), goclient.ObjectKey{
+		Name: builder.Definition.Name,
+	}, nodeFeatureRule)
+
+	if err != nil {
+		glog.V(LogLevel).Infof("NodeFeatureRule object %s doesn't exist", builder.Definition.Name)
+
+		return nil, err
+	}
+
+	return nodeFeatureRule, err
+}
+
+// Exists checks whether the given NodeFeatureRule exists.
+func (builder *RuleBuilder) Exists() bool {
+	if valid, _ := builder.validate(); !valid {
+		return false
+	}
+
+	glog.V(LogLevel).Infof("Checking if NodeFeatureRule %s exists", builder.Definition.Name)
+
+	var err error
+	builder.Object, err = builder.Get()
+
+	if err != nil {
+		glog.V(LogLevel).Infof("Failed to collect NodeFeatureRule object due to %s", err.Error())
+	}
+
+	return err == nil || !k8serrors.IsNotFound(err)
+}
+
+// Create makes a NodeFeatureRule in the cluster and stores the created object in struct.
+func (builder *RuleBuilder) Create() (*RuleBuilder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(LogLevel).Infof("Creating the NodeFeatureRule %s", builder.Definition.Name)
+
+	if len(builder.Definition.Spec.Rules) == 0 {
+		return builder, fmt.Errorf("NodeFeatureRule %s cannot be created without rules", builder.Definition.Name)
+	}
+
+	var err error
+	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
+		err = builder.apiClient.Create(context.TODO(), builder.Definition)
+
+		if err == nil {
+			builder.Object = builder.Definition
+		}
+	}
+
+	return builder, err
+}
+
+// Delete removes a NodeFeatureRule.
+func (builder *RuleBuilder) Delete() (*RuleBuilder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(LogLevel).Infof("Deleting NodeFeatureRule %s", builder.Definition.Name)
+
+	if !builder.Exists() {
+		return builder, fmt.Errorf("NodeFeatureRule cannot be deleted because it does not exist")
+	}
+
+	err := builder.apiClient.Delete(context.TODO(), builder.Definition)
+
+	if err != nil {
+		return builder, fmt.Errorf("cannot delete NodeFeatureRule: %w", err)
+	}
+
+	builder.Object = nil
+
+	return builder, nil
+}
+
+// DeleteAndWait removes a NodeFeatureRule and waits until it is deleted from the cluster.
+func (builder *RuleBuilder) DeleteAndWait(timeout time.Duration) error {
+	if _, err := builder.Delete(); err != nil {
+		return err
+	}
+
+	return wait.PollUntilContextTimeout(
+		context.TODO(), DeletionPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			_, err := builder.Get()
+			if k8serrors.IsNotFound(err) {
+				return true, nil
+			}
+
+			return false, nil
+		})
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *RuleBuilder) validate() (bool, error) {
+	if builder == nil {
+		glog.V(LogLevel).Infof("The %s builder is uninitialized", nodeFeatureRuleCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", nodeFeatureRuleCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(LogLevel).Infof("The %s is undefined", nodeFeatureRuleCRD)
+
+		builder.errorMsg = fmt.Sprintf("%s definition is nil", nodeFeatureRuleCRD)
+	}
+
+	if builder.apiClient == nil {
+		glog.V(LogLevel).Infof("The %s builder apiclient is nil", nodeFeatureRuleCRD)
+
+		builder.errorMsg = fmt.Sprintf("%s builder cannot have nil apiClient", nodeFeatureRuleCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(LogLevel).Infof("The %s builder has error message: %s", nodeFeatureRuleCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
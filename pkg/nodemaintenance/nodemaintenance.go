@@ -0,0 +1,245 @@
+package nodemaintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// NodeMaintenanceKind is the kind of the NodeMaintenance CR of the Node Maintenance operator.
+	NodeMaintenanceKind = "NodeMaintenance"
+	// NodeMaintenanceAPIVersion is the apiVersion of the NodeMaintenance CR.
+	NodeMaintenanceAPIVersion = "nodemaintenance.medik8s.io/v1beta1"
+
+	// PhaseRunning is the phase of a NodeMaintenance cordoning and draining its node.
+	PhaseRunning = "Running"
+	// PhaseSucceeded is the phase of a NodeMaintenance whose node is cordoned and drained.
+	PhaseSucceeded = "Succeeded"
+	// PhaseFailed is the phase of a NodeMaintenance whose node could not be drained.
+	PhaseFailed = "Failed"
+)
+
+// Builder provides struct for the NodeMaintenance object containing connection to the cluster and the
+// NodeMaintenance definitions.
+type Builder struct {
+	// NodeMaintenance definition. Used to create the NodeMaintenance object.
+	Definition *unstructured.Unstructured
+	// Created NodeMaintenance object.
+	Object *unstructured.Unstructured
+	// Used in functions that define or mutate the NodeMaintenance definition. errorMsg is processed before the
+	// NodeMaintenance object is created.
+	errorMsg  string
+	apiClient *clients.Settings
+}
+
+// NewBuilder creates a new instance of a cluster scoped NodeMaintenance builder putting the node into maintenance.
+func NewBuilder(apiClient *clients.Settings, name, nodeName string) *Builder {
+	glog.V(100).Infof("Initializing new NodeMaintenance structure with the following params: name: %s, "+
+		"nodeName: %s", name, nodeName)
+
+	builder := &Builder{
+		apiClient: apiClient,
+		Definition: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": NodeMaintenanceAPIVersion,
+				"kind":       NodeMaintenanceKind,
+				"metadata": map[string]interface{}{
+					"name": name,
+				},
+				"spec": map[string]interface{}{
+					"nodeName": nodeName,
+				},
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the NodeMaintenance is empty")
+
+		builder.errorMsg = "NodeMaintenance 'name' cannot be empty"
+	}
+
+	if nodeName == "" {
+		glog.V(100).Infof("The node name of the NodeMaintenance is empty")
+
+		builder.errorMsg = "NodeMaintenance 'nodeName' cannot be empty"
+	}
+
+	return builder
+}
+
+// Pull retrieves an existing NodeMaintenance object from the cluster.
+func Pull(apiClient *clients.Settings, name string) (*Builder, error) {
+	glog.V(100).Infof("Pulling NodeMaintenance object name: %s", name)
+
+	if name == "" {
+		return nil, fmt.Errorf("NodeMaintenance 'name' cannot be empty")
+	}
+
+	builder := NewBuilder(apiClient, name, "unknown")
+
+	if !builder.Exists() {
+		return nil, fmt.Errorf("NodeMaintenance object %s doesn't exist", name)
+	}
+
+	builder.Definition = builder.Object
+
+	return builder, nil
+}
+
+// WithReason sets the reason of the maintenance, reported by the node events.
+func (builder *Builder) WithReason(reason string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting NodeMaintenance %s reason to '%s'", builder.Definition.GetName(), reason)
+
+	if err := unstructured.SetNestedField(builder.Definition.Object, reason, "spec", "reason"); err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to set NodeMaintenance reason: %v", err)
+	}
+
+	return builder
+}
+
+// Create makes a NodeMaintenance in the cluster and stores the created object in struct. The operator then cordons
+// and drains the node.
+func (builder *Builder) Create() (*Builder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating the NodeMaintenance %s", builder.Definition.GetName())
+
+	var err error
+	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
+		builder.Object, err = builder.apiClient.Resource(GetNodeMaintenanceGVR()).Create(context.TODO(),
+			builder.Definition, metav1.CreateOptions{})
+	}
+
+	return builder, err
+}
+
+// Delete removes the NodeMaintenance from the cluster. The operator then uncordons the node.
+func (builder *Builder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting the NodeMaintenance %s", builder.Definition.GetName())
+
+	if !builder.Exists() {
+		return nil
+	}
+
+	err := builder.apiClient.Resource(GetNodeMaintenanceGVR()).Delete(context.TODO(), builder.Definition.GetName(),
+		metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete NodeMaintenance %s: %w", builder.Definition.GetName(), err)
+	}
+
+	builder.Object = nil
+
+	return nil
+}
+
+// Exists checks whether the given NodeMaintenance exists.
+func (builder *Builder) Exists() bool {
+	if valid, _ := builder.validate(); !valid {
+		return false
+	}
+
+	glog.V(100).Infof("Checking if NodeMaintenance %s exists", builder.Definition.GetName())
+
+	var err error
+	builder.Object, err = builder.apiClient.Resource(GetNodeMaintenanceGVR()).Get(context.TODO(),
+		builder.Definition.GetName(), metav1.GetOptions{})
+
+	return err == nil || !k8serrors.IsNotFound(err)
+}
+
+// Phase returns the phase of the maintenance, one of PhaseRunning, PhaseSucceeded and PhaseFailed, and the last
+// error of the operator draining the node, empty until the operator reconciles the NodeMaintenance.
+func (builder *Builder) Phase() (string, string, error) {
+	if valid, err := builder.validate(); !valid {
+		return "", "", err
+	}
+
+	if !builder.Exists() || builder.Object == nil {
+		return "", "", fmt.Errorf("NodeMaintenance object %s doesn't exist", builder.Definition.GetName())
+	}
+
+	phase, _, _ := unstructured.NestedString(builder.Object.Object, "status", "phase")
+	lastError, _, _ := unstructured.NestedString(builder.Object.Object, "status", "lastError")
+
+	glog.V(100).Infof("NodeMaintenance %s is in phase '%s', last error '%s'", builder.Definition.GetName(), phase,
+		lastError)
+
+	return phase, lastError, nil
+}
+
+// IsServed returns true when the API server serves the NodeMaintenance API, i.e. the Node Maintenance operator is
+// installed.
+func IsServed(apiClient *clients.Settings) (bool, error) {
+	_, err := apiClient.Resource(GetNodeMaintenanceGVR()).List(context.TODO(), metav1.ListOptions{Limit: 1})
+	if err == nil {
+		return true, nil
+	}
+
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	return false, fmt.Errorf("failed to list NodeMaintenances: %w", err)
+}
+
+// GetNodeMaintenanceGVR returns the NodeMaintenance GroupVersionResource.
+func GetNodeMaintenanceGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: "nodemaintenance.medik8s.io", Version: "v1beta1", Resource: "nodemaintenances",
+	}
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *Builder) validate() (bool, error) {
+	resourceCRD := NodeMaintenanceKind
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
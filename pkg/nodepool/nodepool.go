@@ -0,0 +1,177 @@
+package nodepool
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// NodePoolLabel is the label set by HyperShift on the nodes of a NodePool, set to the name of the NodePool.
+	NodePoolLabel = "hypershift.openshift.io/nodePool"
+	// provisionPollInterval is the polling interval of the provisioning waits.
+	provisionPollInterval = 30 * time.Second
+)
+
+// GVR is the resource of the HyperShift NodePools, in the hosted cluster namespace of the management cluster.
+var GVR = schema.GroupVersionResource{Group: "hypershift.openshift.io", Version: "v1beta1", Resource: "nodepools"}
+
+// instanceTypeFields are the NodePool spec.platform fields holding the instance type of each platform.
+var instanceTypeFields = map[string][]string{
+	"AWS":   {"aws", "instanceType"},
+	"Azure": {"azure", "vmSize"},
+}
+
+// List returns the NodePools of the hosted cluster, in the hosted cluster namespace of the management cluster.
+func List(mngClient *clients.Settings, hostedClusterName, namespace string) ([]unstructured.Unstructured, error) {
+	nodePools, err := mngClient.ListResources(GVR, namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var clusterNodePools []unstructured.Unstructured
+
+	for _, nodePool := range nodePools {
+		clusterName, _, _ := unstructured.NestedString(nodePool.Object, "spec", "clusterName")
+		if clusterName == hostedClusterName {
+			clusterNodePools = append(clusterNodePools, nodePool)
+		}
+	}
+
+	return clusterNodePools, nil
+}
+
+// CreateGPUNodePool creates a NodePool of the GPU instance type for the hosted cluster, copied from its first
+// NodePool and named after it with the suffix. Only the AWS and Azure NodePools have an instance type.
+func CreateGPUNodePool(mngClient *clients.Settings, hostedClusterName, namespace, instanceType, suffix string,
+	replicas int32) (*unstructured.Unstructured, error) {
+	glog.V(100).Infof("Creating a NodePool of %d %s instances for hosted cluster %s", replicas, instanceType,
+		hostedClusterName)
+
+	if instanceType == "" || suffix == "" {
+		return nil, fmt.Errorf("NodePool 'instanceType' and 'suffix' cannot be empty")
+	}
+
+	if replicas < 0 {
+		return nil, fmt.Errorf("NodePool 'replicas' cannot be negative")
+	}
+
+	nodePools, err := List(mngClient, hostedClusterName, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodePools) == 0 {
+		return nil, fmt.Errorf("hosted cluster %s has no NodePool in namespace %s to copy", hostedClusterName,
+			namespace)
+	}
+
+	source := nodePools[0]
+
+	platform, _, _ := unstructured.NestedString(source.Object, "spec", "platform", "type")
+
+	instanceTypeField, found := instanceTypeFields[platform]
+	if !found {
+		return nil, fmt.Errorf("NodePool %s platform %q has no instance type", source.GetName(), platform)
+	}
+
+	spec, _, _ := unstructured.NestedMap(source.Object, "spec")
+	delete(spec, "autoScaling")
+
+	nodePool := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": GVR.GroupVersion().String(),
+		"kind":       "NodePool",
+		"metadata": map[string]interface{}{
+			"name":      fmt.Sprintf("%s-%s", source.GetName(), suffix),
+			"namespace": namespace,
+		},
+		"spec": spec,
+	}}
+
+	if err := unstructured.SetNestedField(nodePool.Object, int64(replicas), "spec", "replicas"); err != nil {
+		return nil, fmt.Errorf("failed to set NodePool replicas: %w", err)
+	}
+
+	field := append([]string{"spec", "platform"}, instanceTypeField...)
+	if err := unstructured.SetNestedField(nodePool.Object, instanceType, field...); err != nil {
+		return nil, fmt.Errorf("failed to set NodePool instance type: %w", err)
+	}
+
+	return mngClient.ApplyResource(GVR, nodePool, true)
+}
+
+// WaitForNodePoolNodes waits until the hosted cluster has the replicas of the NodePool as nodes that are Ready and
+// have the nodeLabel, e.g. set by NFD. An empty nodeLabel only waits for the nodes to be Ready. It returns the nodes
+// of the NodePool.
+func WaitForNodePoolNodes(hostedClient *clients.Settings, nodePoolName string, replicas int, nodeLabel string,
+	timeout time.Duration) ([]*nodes.Builder, error) {
+	var nodePoolNodes []*nodes.Builder
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), provisionPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			nodeBuilders, err := nodes.List(hostedClient, metav1.ListOptions{
+				LabelSelector: labels.Set{NodePoolLabel: nodePoolName}.String()})
+			if err != nil {
+				glog.V(100).Infof("Failed to list the nodes of NodePool %s: %v", nodePoolName, err)
+
+				return false, nil
+			}
+
+			nodePoolNodes = nil
+
+			for _, nodeBuilder := range nodeBuilders {
+				if ready, _ := nodeBuilder.IsReady(); !ready {
+					continue
+				}
+
+				if _, labeled := nodeBuilder.Object.Labels[nodeLabel]; nodeLabel != "" && !labeled {
+					continue
+				}
+
+				nodePoolNodes = append(nodePoolNodes, nodeBuilder)
+			}
+
+			glog.V(100).Infof("NodePool %s has %d/%d ready node(s)", nodePoolName, len(nodePoolNodes), replicas)
+
+			return len(nodePoolNodes) == replicas, nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("the nodes of NodePool %s are not ready: %w", nodePoolName, err)
+	}
+
+	return nodePoolNodes, nil
+}
+
+// DeleteAndWait deletes the NodePool and waits until it is removed, HyperShift removing its nodes first.
+func DeleteAndWait(mngClient *clients.Settings, name, namespace string, timeout time.Duration) error {
+	if err := mngClient.DeleteResource(GVR, namespace, name); err != nil {
+		return err
+	}
+
+	err := wait.PollUntilContextTimeout(
+		context.TODO(), provisionPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+			_, err := mngClient.GetResource(GVR, namespace, name)
+			if k8serrors.IsNotFound(err) {
+				return true, nil
+			}
+
+			glog.V(100).Infof("NodePool %s is still being deleted: %v", name, err)
+
+			return false, nil
+		})
+	if err != nil {
+		return fmt.Errorf("NodePool %s was not removed: %w", name, err)
+	}
+
+	return nil
+}
@@ -75,6 +75,14 @@ func (builder *Builder) SetDrainHelper(
 	}
 }
 
+// SetDrainPodSelector restricts the pods that drain evicts or deletes to the ones matching the label selector.
+func (builder *Builder) SetDrainPodSelector(podSelector string) {
+	builder.ensureDrainHelperIsSet()
+	glog.V(100).Infof("Setting node %s drain pod selector to '%s'", builder.Definition.Name, podSelector)
+
+	builder.drainHelper.PodSelector = podSelector
+}
+
 // Drain evicts or deletes all pods.
 func (builder *Builder) Drain() error {
 	if valid, err := builder.validate(); !valid {
@@ -277,6 +285,76 @@ func (builder *Builder) RemoveLabel(key, value string) *Builder {
 	return builder
 }
 
+// SetLabel sets the label on the node, replacing its existing value. An empty value removes the label.
+func SetLabel(apiClient *clients.Settings, nodeName, key, value string) error {
+	glog.V(100).Infof("Setting label %s=%s on node %s", key, value, nodeName)
+
+	builder, err := Pull(apiClient, nodeName)
+	if err != nil {
+		return err
+	}
+
+	current, ok := builder.Definition.Labels[key]
+	if (ok && current == value) || (!ok && value == "") {
+		return nil
+	}
+
+	if ok {
+		builder.RemoveLabel(key, current)
+	}
+
+	if value != "" {
+		builder.WithNewLabel(key, value)
+	}
+
+	_, err = builder.Update()
+
+	return err
+}
+
+// WithTaint defines the new taint placed in the Node spec, replacing the taint with the same key and effect.
+func (builder *Builder) WithTaint(taint corev1.Taint) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Adding taint %s to node %s", taint.ToString(), builder.Definition.Name)
+
+	if taint.Key == "" || taint.Effect == "" {
+		glog.V(100).Infof("Failed to apply taint without key or effect to node %s", builder.Definition.Name)
+
+		builder.errorMsg = "error to set taint without key or effect to node"
+
+		return builder
+	}
+
+	builder.RemoveTaint(taint.Key, taint.Effect)
+	builder.Definition.Spec.Taints = append(builder.Definition.Spec.Taints, taint)
+
+	return builder
+}
+
+// RemoveTaint removes the taint with the given key and effect from Node spec.
+func (builder *Builder) RemoveTaint(key string, effect corev1.TaintEffect) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Removing taint %s:%s from node %s", key, effect, builder.Definition.Name)
+
+	var taints []corev1.Taint
+
+	for _, taint := range builder.Definition.Spec.Taints {
+		if taint.Key != key || taint.Effect != effect {
+			taints = append(taints, taint)
+		}
+	}
+
+	builder.Definition.Spec.Taints = taints
+
+	return builder
+}
+
 // ExternalIPv4Network returns nodes external ip address.
 func (builder *Builder) ExternalIPv4Network() (string, error) {
 	if valid, err := builder.validate(); !valid {
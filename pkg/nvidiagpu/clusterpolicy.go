@@ -9,9 +9,12 @@ import (
 	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/cleanup"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients/retry"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sjson "k8s.io/apimachinery/pkg/util/json"
@@ -203,6 +206,8 @@ func (builder *Builder) Create() (*Builder, error) {
 
 	var err error
 	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
 		err = builder.apiClient.Create(context.TODO(), builder.Definition)
 
 		if err == nil {
@@ -243,6 +248,58 @@ func (builder *Builder) Update(force bool) (*Builder, error) {
 	return builder, err
 }
 
+// PullAndUpdate pulls the ClusterPolicy, applies mutate to its definition and updates it, pulling it again and
+// reapplying mutate when the update conflicts or fails on a transient API error.
+func PullAndUpdate(apiClient *clients.Settings, name string,
+	mutate func(definition *nvidiagpuv1.ClusterPolicy)) (*Builder, error) {
+	var builder *Builder
+
+	err := retry.OnError(func() error {
+		var err error
+
+		builder, err = Pull(apiClient, name)
+		if err != nil {
+			return err
+		}
+
+		mutate(builder.Definition)
+
+		_, err = builder.Update(false)
+
+		return err
+	})
+
+	return builder, err
+}
+
+// RestoreSpec replaces the spec of the ClusterPolicy with a spec saved before a suite changed it.
+func RestoreSpec(apiClient *clients.Settings, name string, spec *nvidiagpuv1.ClusterPolicySpec) error {
+	glog.V(100).Infof("Restoring ClusterPolicy %s spec", name)
+
+	_, err := PullAndUpdate(apiClient, name, func(definition *nvidiagpuv1.ClusterPolicy) {
+		definition.Spec = *spec
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore ClusterPolicy %s spec: %w", name, err)
+	}
+
+	return nil
+}
+
+// RegisterRestoreSpec registers the restore of the ClusterPolicy spec saved before a suite changed it with
+// pkg/cleanup, so that an interrupted suite does not leave the ClusterPolicy changed. The returned function
+// unregisters it, e.g. from a DeferCleanup node once the suite restored the spec itself. A nil spec, the ClusterPolicy
+// being left unchanged, registers nothing.
+func RegisterRestoreSpec(apiClient *clients.Settings, name string, spec *nvidiagpuv1.ClusterPolicySpec) func() {
+	if spec == nil {
+		return func() {}
+	}
+
+	return cleanup.Register(fmt.Sprintf("the ClusterPolicy %s spec", name), func() error {
+		return RestoreSpec(apiClient, name, spec)
+	})
+}
+
 // validate will check that the builder and builder definition are properly initialized before
 // accessing any member fields.
 func (builder *Builder) validate() (bool, error) {
@@ -0,0 +1,381 @@
+package clusterpolicybuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	nvidiagpuv1alpha1 "github.com/NVIDIA/k8s-operator-libs/api/upgrade/v1alpha1"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+)
+
+// Builder provides a fluent API to define a ClusterPolicy, defaulted from the alm-examples of the GPU operator CSV,
+// so that the suites customize the same default ClusterPolicy instead of patching its raw JSON.
+type Builder struct {
+	// clusterPolicyBuilder is the ClusterPolicy builder holding the definition being customized.
+	clusterPolicyBuilder *nvidiagpu.Builder
+	// errorMsg is processed before the ClusterPolicy is built.
+	errorMsg string
+}
+
+// NewBuilder creates a Builder defaulting the ClusterPolicy definition from the first item of the CSV alm-examples.
+func NewBuilder(apiClient *clients.Settings, almExamples string) *Builder {
+	glog.V(100).Infof("Initializing new ClusterPolicy builder from almExamples string")
+
+	builder := &Builder{
+		clusterPolicyBuilder: nvidiagpu.NewBuilderFromObjectString(apiClient, almExamples),
+	}
+
+	if apiClient == nil {
+		glog.V(100).Infof("The ClusterPolicy builder apiclient is nil")
+
+		builder.errorMsg = "ClusterPolicy builder cannot have nil apiClient"
+	}
+
+	return builder
+}
+
+// NewBuilderFromCSV creates a Builder defaulting the ClusterPolicy definition from the alm-examples of the CSV.
+func NewBuilderFromCSV(apiClient *clients.Settings, csvBuilder *olm.ClusterServiceVersionBuilder) *Builder {
+	glog.V(100).Infof("Initializing new ClusterPolicy builder from the CSV almExamples")
+
+	almExamples, err := csvBuilder.GetAlmExamples()
+	if err != nil {
+		glog.V(100).Infof("Error getting the CSV almExamples: %v", err)
+
+		builder := NewBuilder(apiClient, "")
+		builder.errorMsg = fmt.Sprintf("failed to get the CSV almExamples: %v", err)
+
+		return builder
+	}
+
+	return NewBuilder(apiClient, almExamples)
+}
+
+// WithName sets the name of the ClusterPolicy.
+func (builder *Builder) WithName(name string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy name to %s", name)
+
+	if name == "" {
+		builder.errorMsg = "ClusterPolicy 'name' cannot be empty"
+
+		return builder
+	}
+
+	builder.definition().Name = name
+
+	return builder
+}
+
+// WithMIGStrategy sets the MIG strategy of the device plugin and of GFD.
+func (builder *Builder) WithMIGStrategy(strategy nvidiagpuv1.MIGStrategy) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy MIG strategy to %s", strategy)
+
+	switch strategy {
+	case nvidiagpuv1.MIGStrategyNone, nvidiagpuv1.MIGStrategySingle, nvidiagpuv1.MIGStrategyMixed:
+	default:
+		builder.errorMsg = fmt.Sprintf("invalid MIG strategy '%s'", strategy)
+
+		return builder
+	}
+
+	builder.definition().Spec.MIG.Strategy = strategy
+
+	return builder
+}
+
+// WithDriverVersion sets the version of the driver image.
+func (builder *Builder) WithDriverVersion(version string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy driver version to %s", version)
+
+	if version == "" {
+		builder.errorMsg = "ClusterPolicy driver version cannot be empty"
+
+		return builder
+	}
+
+	builder.definition().Spec.Driver.Version = version
+
+	return builder
+}
+
+// WithDriverImage sets the repository and the image name of the driver image, keeping the default of an empty one.
+func (builder *Builder) WithDriverImage(repository, image string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy driver repository to '%s' and image to '%s'", repository, image)
+
+	if repository != "" {
+		builder.definition().Spec.Driver.Repository = repository
+	}
+
+	if image != "" {
+		builder.definition().Spec.Driver.Image = image
+	}
+
+	return builder
+}
+
+// WithPrecompiledDriver enables or disables the precompiled driver containers.
+func (builder *Builder) WithPrecompiledDriver(enabled bool) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy driver usePrecompiled to %t", enabled)
+
+	builder.definition().Spec.Driver.UsePrecompiled = &enabled
+
+	return builder
+}
+
+// WithKernelModuleType sets the type of the driver kernel modules, e.g. auto, open or proprietary.
+func (builder *Builder) WithKernelModuleType(kernelModuleType string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy driver kernelModuleType to %s", kernelModuleType)
+
+	if kernelModuleType == "" {
+		builder.errorMsg = "ClusterPolicy driver kernelModuleType cannot be empty"
+
+		return builder
+	}
+
+	builder.definition().Spec.Driver.KernelModuleType = kernelModuleType
+
+	return builder
+}
+
+// WithDriverAutoUpgrade enables or disables the automatic driver upgrades, the driver daemonset being left running
+// across operator upgrades when disabled.
+func (builder *Builder) WithDriverAutoUpgrade(enabled bool) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy driver autoUpgrade to %t", enabled)
+
+	driverSpec := &builder.definition().Spec.Driver
+	if driverSpec.UpgradePolicy == nil {
+		driverSpec.UpgradePolicy = &nvidiagpuv1alpha1.DriverUpgradePolicySpec{}
+	}
+
+	driverSpec.UpgradePolicy.AutoUpgrade = enabled
+
+	return builder
+}
+
+// WithRDMA enables or disables GPUDirect RDMA, using the MOFED drivers installed on the host when useHostMOFED is
+// set instead of the ones of the network operator.
+func (builder *Builder) WithRDMA(enabled, useHostMOFED bool) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy driver rdma enabled to %t and useHostMofed to %t", enabled,
+		useHostMOFED)
+
+	builder.definition().Spec.Driver.GPUDirectRDMA = &nvidiagpuv1.GPUDirectRDMASpec{
+		Enabled:      &enabled,
+		UseHostMOFED: &useHostMOFED,
+	}
+
+	return builder
+}
+
+// WithGDS enables or disables GPUDirect Storage.
+func (builder *Builder) WithGDS(enabled bool) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy gds enabled to %t", enabled)
+
+	spec := &builder.definition().Spec
+	if spec.GPUDirectStorage == nil {
+		spec.GPUDirectStorage = &nvidiagpuv1.GPUDirectStorageSpec{}
+	}
+
+	spec.GPUDirectStorage.Enabled = &enabled
+
+	return builder
+}
+
+// WithDevicePluginConfig enables the device plugin with the configuration of the ConfigMap, defaultConfig being the
+// ConfigMap key applied to the nodes without the nvidia.com/device-plugin.config label.
+func (builder *Builder) WithDevicePluginConfig(configMapName, defaultConfig string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy device plugin config to ConfigMap %s with default '%s'",
+		configMapName, defaultConfig)
+
+	if configMapName == "" {
+		builder.errorMsg = "ClusterPolicy device plugin config name cannot be empty"
+
+		return builder
+	}
+
+	enabled := true
+	devicePlugin := &builder.definition().Spec.DevicePlugin
+	devicePlugin.Enabled = &enabled
+	devicePlugin.Config = &nvidiagpuv1.DevicePluginConfig{
+		Name:    configMapName,
+		Default: defaultConfig,
+	}
+
+	return builder
+}
+
+// WithMPSRoot sets the host directory of the MPS control daemons of the device plugin.
+func (builder *Builder) WithMPSRoot(root string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy device plugin MPS root to %s", root)
+
+	if root == "" {
+		builder.errorMsg = "ClusterPolicy device plugin MPS root cannot be empty"
+
+		return builder
+	}
+
+	builder.definition().Spec.DevicePlugin.MPS = &nvidiagpuv1.MPSConfig{Root: root}
+
+	return builder
+}
+
+// WithSandboxWorkloads enables or disables the operands of the sandbox workloads, i.e. virtual machines.
+func (builder *Builder) WithSandboxWorkloads(enabled bool) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting ClusterPolicy sandboxWorkloads enabled to %t", enabled)
+
+	builder.definition().Spec.SandboxWorkloads.Enabled = &enabled
+
+	return builder
+}
+
+// WithPatch applies an RFC6902 JSON patch to the ClusterPolicy definition as customized so far, for the fields the
+// builder has no option for. An empty patch leaves the definition unchanged.
+func (builder *Builder) WithPatch(patchJSON string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	if strings.TrimSpace(patchJSON) == "" {
+		return builder
+	}
+
+	glog.V(100).Infof("Applying a JSON patch to the ClusterPolicy definition")
+
+	patch, err := jsonpatch.DecodePatch([]byte(patchJSON))
+	if err != nil {
+		builder.errorMsg = fmt.Sprintf("invalid JSON patch: %v", err)
+
+		return builder
+	}
+
+	definitionJSON, err := json.Marshal(builder.definition())
+	if err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to marshal the ClusterPolicy definition: %v", err)
+
+		return builder
+	}
+
+	patchedJSON, err := patch.Apply(definitionJSON)
+	if err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to apply the JSON patch: %v", err)
+
+		return builder
+	}
+
+	patched := &nvidiagpuv1.ClusterPolicy{}
+	if err := json.Unmarshal(patchedJSON, patched); err != nil {
+		builder.errorMsg = fmt.Sprintf("failed to unmarshal the patched ClusterPolicy definition: %v", err)
+
+		return builder
+	}
+
+	builder.clusterPolicyBuilder.Definition = patched
+
+	return builder
+}
+
+// Build returns the ClusterPolicy builder of the customized definition.
+func (builder *Builder) Build() (*nvidiagpu.Builder, error) {
+	if valid, err := builder.validate(); !valid {
+		return nil, err
+	}
+
+	return builder.clusterPolicyBuilder, nil
+}
+
+// Create builds the ClusterPolicy and creates it on the cluster.
+func (builder *Builder) Create() (*nvidiagpu.Builder, error) {
+	clusterPolicyBuilder, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	glog.V(100).Infof("Creating ClusterPolicy %s", clusterPolicyBuilder.Definition.Name)
+
+	return clusterPolicyBuilder.Create()
+}
+
+// definition returns the ClusterPolicy definition being customized.
+func (builder *Builder) definition() *nvidiagpuv1.ClusterPolicy {
+	return builder.clusterPolicyBuilder.Definition
+}
+
+// validate will check that the builder and its definition are properly initialized before accessing any member
+// fields.
+func (builder *Builder) validate() (bool, error) {
+	resourceCRD := "ClusterPolicy"
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.clusterPolicyBuilder == nil || builder.clusterPolicyBuilder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		builder.errorMsg = fmt.Sprintf("%s builder has no definition", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
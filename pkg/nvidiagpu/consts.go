@@ -20,6 +20,8 @@ const (
 
 	CustomCatalogSourceDisplayName = "Certified Operators Custom"
 
+	BundleCatalogSourceName = "gpu-operator-bundle"
+
 	SleepDuration = 30 * time.Second
 
 	WaitDuration = 4 * time.Minute
@@ -45,8 +47,7 @@ const (
     CsvSucceededCheckInterval = 60 * time.Second
     CsvSucceededTimeout       = 15 * time.Minute
 
-	ClusterPolicyReadyCheckInterval = 60 * time.Second
-	ClusterPolicyReadyTimeout       = 12 * time.Minute
+	ClusterPolicyReadyTimeout = 12 * time.Minute
 
 	BurnPodCreationTimeout = 5 * time.Minute
 
@@ -10,6 +10,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/json"
@@ -75,6 +76,43 @@ func NewIPoIBNetworkBuilderFromObjectString(apiClient *clients.Settings, almExam
 	return &builder
 }
 
+// NewIPoIBNetworkBuilder creates an IPoIBNetworkBuilder object generating a NetworkAttachmentDefinition in
+// networkNamespace that enslaves the master interface with the given IPAM configuration.
+func NewIPoIBNetworkBuilder(apiClient *clients.Settings, name, networkNamespace, master,
+	ipam string) *IPoIBNetworkBuilder {
+	glog.V(100).Infof(
+		"Initializing new IPoIBNetworkBuilder structure with name: %s, networkNamespace: %s, master: %s",
+		name, networkNamespace, master)
+
+	builder := IPoIBNetworkBuilder{
+		apiClient: apiClient,
+		Definition: &nvidianetworkv1alpha1.IPoIBNetwork{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: nvidianetworkv1alpha1.IPoIBNetworkSpec{
+				NetworkNamespace: networkNamespace,
+				Master:           master,
+				IPAM:             ipam,
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the IPoIBNetwork is empty")
+
+		builder.errorMsg = "IPoIBNetwork 'name' cannot be empty"
+	}
+
+	if networkNamespace == "" {
+		glog.V(100).Infof("The networkNamespace of the IPoIBNetwork is empty")
+
+		builder.errorMsg = "IPoIBNetwork 'networkNamespace' cannot be empty"
+	}
+
+	return &builder
+}
+
 // Get returns IPoIBNetwork object if found.
 func (builder *IPoIBNetworkBuilder) Get() (*nvidianetworkv1alpha1.IPoIBNetwork, error) {
 	if valid, err := builder.validate(); !valid {
@@ -180,6 +218,8 @@ func (builder *IPoIBNetworkBuilder) Create() (*IPoIBNetworkBuilder, error) {
 
 	var err error
 	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
 		err = builder.apiClient.Create(context.TODO(), builder.Definition)
 
 		if err == nil {
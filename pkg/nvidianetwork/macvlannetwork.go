@@ -10,6 +10,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/json"
@@ -75,6 +76,44 @@ func NewMacvlanNetworkBuilderFromObjectString(apiClient *clients.Settings, almEx
 	return &builder
 }
 
+// NewMacvlanNetworkBuilder creates a MacvlanNetworkBuilder object generating a NetworkAttachmentDefinition in
+// networkNamespace that enslaves the master interface in bridge mode with the given IPAM configuration.
+func NewMacvlanNetworkBuilder(apiClient *clients.Settings, name, networkNamespace, master,
+	ipam string) *MacvlanNetworkBuilder {
+	glog.V(100).Infof(
+		"Initializing new MacvlanNetworkBuilder structure with name: %s, networkNamespace: %s, master: %s",
+		name, networkNamespace, master)
+
+	builder := MacvlanNetworkBuilder{
+		apiClient: apiClient,
+		Definition: &nvidianetworkv1alpha1.MacvlanNetwork{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: nvidianetworkv1alpha1.MacvlanNetworkSpec{
+				NetworkNamespace: networkNamespace,
+				Master:           master,
+				Mode:             "bridge",
+				IPAM:             ipam,
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the MacvlanNetwork is empty")
+
+		builder.errorMsg = "MacvlanNetwork 'name' cannot be empty"
+	}
+
+	if networkNamespace == "" {
+		glog.V(100).Infof("The networkNamespace of the MacvlanNetwork is empty")
+
+		builder.errorMsg = "MacvlanNetwork 'networkNamespace' cannot be empty"
+	}
+
+	return &builder
+}
+
 // Get returns MacvlanNetwork object if found.
 func (builder *MacvlanNetworkBuilder) Get() (*nvidianetworkv1alpha1.MacvlanNetwork, error) {
 	if valid, err := builder.validate(); !valid {
@@ -180,6 +219,8 @@ func (builder *MacvlanNetworkBuilder) Create() (*MacvlanNetworkBuilder, error) {
 
 	var err error
 	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
 		err = builder.apiClient.Create(context.TODO(), builder.Definition)
 
 		if err == nil {
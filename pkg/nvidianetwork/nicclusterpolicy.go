@@ -10,6 +10,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/json"
@@ -180,6 +181,8 @@ func (builder *NicClusterPolicyBuilder) Create() (*NicClusterPolicyBuilder, erro
 
 	var err error
 	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
 		err = builder.apiClient.Create(context.TODO(), builder.Definition)
 
 		if err == nil {
@@ -0,0 +1,289 @@
+package nvidiasmi
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+const (
+	// DriverPodLabel selects the driver pods of the GPU operator.
+	DriverPodLabel = "app=nvidia-driver-daemonset"
+	// DriverContainerName is the container of the driver pods running nvidia-smi.
+	DriverContainerName = "nvidia-driver-ctr"
+	// NotAvailable is the value nvidia-smi reports for the fields not supported by the GPU or the driver.
+	NotAvailable = "N/A"
+	// Enabled is the value nvidia-smi reports for the enabled MIG and ECC modes.
+	Enabled = "Enabled"
+)
+
+// Value is a field of the nvidia-smi XML report, e.g. "81559 MiB", "1410 MHz", "34 C" or "N/A".
+type Value string
+
+// Available returns true when nvidia-smi reports the value, i.e. it is neither empty nor N/A.
+func (value Value) Available() bool {
+	trimmed := strings.TrimSpace(string(value))
+
+	return trimmed != "" && trimmed != NotAvailable && trimmed != "["+NotAvailable+"]"
+}
+
+// Int returns the integer part of the value, its unit being dropped, e.g. 81559 for "81559 MiB".
+func (value Value) Int() (int, error) {
+	number, err := value.Float()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(number), nil
+}
+
+// Float returns the number of the value, its unit being dropped, e.g. 250.5 for "250.50 W".
+func (value Value) Float() (float64, error) {
+	if !value.Available() {
+		return 0, fmt.Errorf("value %q is not available", string(value))
+	}
+
+	fieldValues := strings.Fields(string(value))
+
+	number, err := strconv.ParseFloat(fieldValues[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("value %q is not a number: %w", string(value), err)
+	}
+
+	return number, nil
+}
+
+// Log is the nvidia-smi -q -x report of a node.
+type Log struct {
+	DriverVersion string `xml:"driver_version"`
+	CUDAVersion   string `xml:"cuda_version"`
+	AttachedGPUs  int    `xml:"attached_gpus"`
+	GPUs          []GPU  `xml:"gpu"`
+}
+
+// GPU is a GPU of the nvidia-smi report.
+type GPU struct {
+	// ID is the PCI bus id of the GPU.
+	ID           string      `xml:"id,attr"`
+	ProductName  string      `xml:"product_name"`
+	Architecture string      `xml:"product_architecture"`
+	UUID         string      `xml:"uuid"`
+	MinorNumber  Value       `xml:"minor_number"`
+	MIGMode      MIGMode     `xml:"mig_mode"`
+	MIGDevices   []MIGDevice `xml:"mig_devices>mig_device"`
+	Framebuffer  Memory      `xml:"fb_memory_usage"`
+	Utilization  Usage       `xml:"utilization"`
+	ECCMode      ECCMode     `xml:"ecc_mode"`
+	ECCErrors    ECCErrors   `xml:"ecc_errors"`
+	Temperature  Value       `xml:"temperature>gpu_temp"`
+	// PowerReadings are reported in gpu_power_readings by the recent drivers and in power_readings by the older ones.
+	PowerReadings            PowerReadings `xml:"gpu_power_readings"`
+	LegacyPowerReadings      PowerReadings `xml:"power_readings"`
+	Clocks                   Clocks        `xml:"clocks"`
+	ApplicationClocks        Clocks        `xml:"applications_clocks"`
+	DefaultApplicationClocks Clocks        `xml:"default_applications_clocks"`
+	MaxClocks                Clocks        `xml:"max_clocks"`
+	Processes                []Process     `xml:"processes>process_info"`
+	Fabric                   Fabric        `xml:"fabric"`
+}
+
+// MIGMode is the current and pending MIG mode of a GPU, Enabled, Disabled or N/A when the GPU does not support MIG.
+type MIGMode struct {
+	Current string `xml:"current_mig"`
+	Pending string `xml:"pending_mig"`
+}
+
+// MIGDevice is a MIG device of a GPU.
+type MIGDevice struct {
+	Index               int    `xml:"index"`
+	GPUInstanceID       int    `xml:"gpu_instance_id"`
+	ComputeInstanceID   int    `xml:"compute_instance_id"`
+	MultiprocessorCount Value  `xml:"device_attributes>shared>multiprocessor_count"`
+	Framebuffer         Memory `xml:"fb_memory_usage"`
+}
+
+// Memory is the memory usage of a GPU or a MIG device, in MiB.
+type Memory struct {
+	Total    Value `xml:"total"`
+	Reserved Value `xml:"reserved"`
+	Used     Value `xml:"used"`
+	Free     Value `xml:"free"`
+}
+
+// Usage is the utilization of a GPU, in percent.
+type Usage struct {
+	GPU    Value `xml:"gpu_util"`
+	Memory Value `xml:"memory_util"`
+}
+
+// ECCMode is the current and pending ECC mode of a GPU, Enabled, Disabled or N/A when the GPU does not support ECC.
+type ECCMode struct {
+	Current string `xml:"current_ecc"`
+	Pending string `xml:"pending_ecc"`
+}
+
+// ECCErrors are the ECC error counts of a GPU since the driver loaded, volatile, and over its lifetime, aggregate.
+type ECCErrors struct {
+	Volatile  ECCCounts `xml:"volatile"`
+	Aggregate ECCCounts `xml:"aggregate"`
+}
+
+// ECCCounts are ECC error counts of the SRAM and DRAM of a GPU.
+type ECCCounts struct {
+	SRAMCorrectable   Value `xml:"sram_correctable"`
+	SRAMUncorrectable Value `xml:"sram_uncorrectable"`
+	DRAMCorrectable   Value `xml:"dram_correctable"`
+	DRAMUncorrectable Value `xml:"dram_uncorrectable"`
+}
+
+// Clocks are the clocks of a GPU, in MHz.
+type Clocks struct {
+	Graphics Value `xml:"graphics_clock"`
+	SM       Value `xml:"sm_clock"`
+	Memory   Value `xml:"mem_clock"`
+	Video    Value `xml:"video_clock"`
+}
+
+// PowerReadings are the power draw and the power limits of a GPU, in W. The recent drivers report the enforced
+// limit as current_power_limit and the older ones as enforced_power_limit.
+type PowerReadings struct {
+	Draw          Value `xml:"power_draw"`
+	CurrentLimit  Value `xml:"current_power_limit"`
+	EnforcedLimit Value `xml:"enforced_power_limit"`
+	DefaultLimit  Value `xml:"default_power_limit"`
+	MinLimit      Value `xml:"min_power_limit"`
+	MaxLimit      Value `xml:"max_power_limit"`
+}
+
+// Fabric is the NVLink fabric registration of a GPU, reported by the recent drivers on the NVSwitch systems where the
+// fabric manager registers the GPUs, e.g. Completed and Success, and N/A elsewhere.
+type Fabric struct {
+	State  Value `xml:"state"`
+	Status Value `xml:"status"`
+}
+
+// Process is a process running on a GPU.
+type Process struct {
+	PID               int    `xml:"pid"`
+	Type              string `xml:"type"`
+	Name              string `xml:"process_name"`
+	UsedMemory        Value  `xml:"used_memory"`
+	GPUInstanceID     Value  `xml:"gpu_instance_id"`
+	ComputeInstanceID Value  `xml:"compute_instance_id"`
+}
+
+// MIGEnabled returns true when MIG mode is currently enabled on the GPU.
+func (gpu GPU) MIGEnabled() bool {
+	return gpu.MIGMode.Current == Enabled
+}
+
+// ECCEnabled returns true when ECC is currently enabled on the GPU.
+func (gpu GPU) ECCEnabled() bool {
+	return gpu.ECCMode.Current == Enabled
+}
+
+// Power returns the power draw of the GPU, in W, whichever driver reports it.
+func (gpu GPU) Power() (float64, error) {
+	return gpu.Readings().Draw.Float()
+}
+
+// PowerLimit returns the power limit enforced on the GPU, in W, whichever driver reports it.
+func (gpu GPU) PowerLimit() (float64, error) {
+	readings := gpu.Readings()
+	if readings.CurrentLimit.Available() {
+		return readings.CurrentLimit.Float()
+	}
+
+	return readings.EnforcedLimit.Float()
+}
+
+// Readings returns the power readings of the GPU reported by the driver, in gpu_power_readings or in power_readings.
+func (gpu GPU) Readings() PowerReadings {
+	if gpu.PowerReadings != (PowerReadings{}) {
+		return gpu.PowerReadings
+	}
+
+	return gpu.LegacyPowerReadings
+}
+
+// UncorrectableErrors returns the volatile uncorrectable ECC error count of the GPU, the counts nvidia-smi does not
+// report being left out.
+func (gpu GPU) UncorrectableErrors() int {
+	total := 0
+
+	for _, count := range []Value{gpu.ECCErrors.Volatile.SRAMUncorrectable, gpu.ECCErrors.Volatile.DRAMUncorrectable} {
+		if errorCount, err := count.Int(); err == nil {
+			total += errorCount
+		}
+	}
+
+	return total
+}
+
+// Parse parses the nvidia-smi -q -x output.
+func Parse(output []byte) (*Log, error) {
+	log := &Log{}
+
+	if err := xml.Unmarshal(output, log); err != nil {
+		return nil, fmt.Errorf("failed to parse the nvidia-smi XML report: %w", err)
+	}
+
+	if log.DriverVersion == "" {
+		return nil, errors.New("the nvidia-smi XML report has no driver version")
+	}
+
+	return log, nil
+}
+
+// Query runs nvidia-smi -q -x in the container of the pod and parses its output.
+func Query(podBuilder *pod.Builder, containerName string) (*Log, error) {
+	if podBuilder == nil || podBuilder.Object == nil {
+		return nil, errors.New("pod to run nvidia-smi in does not exist")
+	}
+
+	glog.V(100).Infof("Running nvidia-smi -q -x in container %s of pod %s in namespace %s", containerName,
+		podBuilder.Object.Name, podBuilder.Object.Namespace)
+
+	output, err := podBuilder.ExecCommand([]string{"nvidia-smi", "-q", "-x"}, containerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run nvidia-smi -q -x in pod %s: %w", podBuilder.Object.Name, err)
+	}
+
+	return Parse(output.Bytes())
+}
+
+// QueryNode runs nvidia-smi -q -x in the driver pod of the node and parses its output.
+func QueryNode(apiClient *clients.Settings, nodeName string) (*Log, error) {
+	driverPod, err := NodeDriverPod(apiClient, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	return Query(driverPod, DriverContainerName)
+}
+
+// NodeDriverPod returns the driver pod of the node, running nvidia-smi in its DriverContainerName container.
+func NodeDriverPod(apiClient *clients.Settings, nodeName string) (*pod.Builder, error) {
+	driverPods, err := pod.List(apiClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{
+		LabelSelector: DriverPodLabel,
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list driver pods on node %s: %w", nodeName, err)
+	}
+
+	if len(driverPods) == 0 {
+		return nil, fmt.Errorf("no driver pod found on node %s", nodeName)
+	}
+
+	return driverPods[0], nil
+}
@@ -0,0 +1,124 @@
+package nvidiasmi
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+)
+
+// InactiveLink is the speed nvidia-smi nvlink -s reports for the NVLinks that are down.
+const InactiveLink = "<inactive>"
+
+var (
+	nvlinkGPURegexp  = regexp.MustCompile(`^GPU (\d+): (.+?)(?: \(UUID: (\S+)\))?$`)
+	nvlinkLinkRegexp = regexp.MustCompile(`^Link (\d+): (.+)$`)
+)
+
+// NVLinkGPU is the NVLink status of a GPU, reported by nvidia-smi nvlink -s.
+type NVLinkGPU struct {
+	Index       int
+	ProductName string
+	UUID        string
+	Links       []NVLink
+}
+
+// NVLink is an NVLink of a GPU.
+type NVLink struct {
+	Index int
+	// Speed is the link speed, e.g. "25 GB/s", or InactiveLink when the link is down.
+	Speed Value
+}
+
+// Active returns true when the link is up.
+func (link NVLink) Active() bool {
+	return strings.TrimSpace(string(link.Speed)) != InactiveLink && link.Speed.Available()
+}
+
+// InactiveLinks returns the indexes of the links of the GPU that are down.
+func (gpu NVLinkGPU) InactiveLinks() []int {
+	var inactive []int
+
+	for _, link := range gpu.Links {
+		if !link.Active() {
+			inactive = append(inactive, link.Index)
+		}
+	}
+
+	return inactive
+}
+
+// ParseNVLinkStatus parses the nvidia-smi nvlink -s output.
+func ParseNVLinkStatus(output string) ([]NVLinkGPU, error) {
+	var gpus []NVLinkGPU
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if match := nvlinkGPURegexp.FindStringSubmatch(line); match != nil {
+			index, err := strconv.Atoi(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid NVLink GPU line %q: %w", line, err)
+			}
+
+			gpus = append(gpus, NVLinkGPU{Index: index, ProductName: match[2], UUID: match[3]})
+
+			continue
+		}
+
+		if match := nvlinkLinkRegexp.FindStringSubmatch(line); match != nil {
+			if len(gpus) == 0 {
+				return nil, fmt.Errorf("NVLink line %q does not follow a GPU line", line)
+			}
+
+			index, err := strconv.Atoi(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid NVLink line %q: %w", line, err)
+			}
+
+			current := &gpus[len(gpus)-1]
+			current.Links = append(current.Links, NVLink{Index: index, Speed: Value(strings.TrimSpace(match[2]))})
+		}
+	}
+
+	if len(gpus) == 0 {
+		return nil, fmt.Errorf("no GPU found in the nvidia-smi nvlink output %q", output)
+	}
+
+	return gpus, nil
+}
+
+// QueryNVLinkStatus runs nvidia-smi nvlink -s in the container of the pod and parses its output.
+func QueryNVLinkStatus(podBuilder *pod.Builder, containerName string) ([]NVLinkGPU, error) {
+	if podBuilder == nil || podBuilder.Object == nil {
+		return nil, errors.New("pod to run nvidia-smi in does not exist")
+	}
+
+	glog.V(100).Infof("Running nvidia-smi nvlink -s in container %s of pod %s in namespace %s", containerName,
+		podBuilder.Object.Name, podBuilder.Object.Namespace)
+
+	output, err := podBuilder.ExecCommand([]string{"nvidia-smi", "nvlink", "-s"}, containerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run nvidia-smi nvlink -s in pod %s: %w", podBuilder.Object.Name, err)
+	}
+
+	return ParseNVLinkStatus(output.String())
+}
+
+// QueryNodeNVLinkStatus runs nvidia-smi nvlink -s in the driver pod of the node and parses its output.
+func QueryNodeNVLinkStatus(apiClient *clients.Settings, nodeName string) ([]NVLinkGPU, error) {
+	driverPod, err := NodeDriverPod(apiClient, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	return QueryNVLinkStatus(driverPod, DriverContainerName)
+}
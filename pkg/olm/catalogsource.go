@@ -12,6 +12,7 @@ import (
 	oplmV1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -115,6 +116,32 @@ func NewCatalogSourceBuilderWithIndexImage(apiClient *clients.Settings,
 	return &builder
 }
 
+// NewCatalogSourceBuilderWithAddress creates new instance of CatalogSourceBuilder served by the registry at the
+// gRPC address, e.g. "10.128.2.15:50051", instead of a registry pod of an index image.
+func NewCatalogSourceBuilderWithAddress(apiClient *clients.Settings,
+	name, nsname, address, displayName, publisher string) *CatalogSourceBuilder {
+	glog.V(100).Infof("Initializing new catalogsource structure with "+
+		"name '%s', namespace '%s', address '%s', display name '%s', and publisher '%s'",
+		name, nsname, address, displayName, publisher)
+
+	builder := NewCatalogSourceBuilder(apiClient, name, nsname)
+
+	builder.Definition.Spec = oplmV1alpha1.CatalogSourceSpec{
+		SourceType:  oplmV1alpha1.SourceTypeGrpc,
+		Address:     address,
+		DisplayName: displayName,
+		Publisher:   publisher,
+	}
+
+	if address == "" {
+		glog.V(100).Infof("The address of the catalogsource is empty")
+
+		builder.errorMsg = "catalogsource 'address' cannot be empty"
+	}
+
+	return builder
+}
+
 // PullCatalogSource loads an existing catalogsource into Builder struct.
 func PullCatalogSource(apiClient *clients.Settings, name, nsname string) (*CatalogSourceBuilder,
 	error) {
@@ -158,6 +185,8 @@ func (builder *CatalogSourceBuilder) Create() (*CatalogSourceBuilder, error) {
 
 	var err error
 	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
 		builder.Object, err = builder.apiClient.CatalogSources(builder.Definition.Namespace).Create(context.TODO(),
 			builder.Definition, metav1.CreateOptions{})
 	}
@@ -165,6 +194,76 @@ func (builder *CatalogSourceBuilder) Create() (*CatalogSourceBuilder, error) {
 	return builder, err
 }
 
+// Update renews the catalogsource in the cluster with the spec of its definition, e.g. the new index image of
+// WithImage, and stores the updated object in struct.
+func (builder *CatalogSourceBuilder) Update() (*CatalogSourceBuilder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Updating the catalogsource %s in namespace %s",
+		builder.Definition.Name, builder.Definition.Namespace)
+
+	if !builder.Exists() || builder.Object == nil {
+		return builder, fmt.Errorf("catalogsource %s doesn't exist in namespace %s",
+			builder.Definition.Name, builder.Definition.Namespace)
+	}
+
+	builder.Object.Spec = builder.Definition.Spec
+
+	var err error
+	builder.Object, err = builder.apiClient.CatalogSources(builder.Definition.Namespace).Update(context.TODO(),
+		builder.Object, metav1.UpdateOptions{})
+
+	return builder, err
+}
+
+// WithImage sets the index image of the catalogsource, served by a registry pod of the catalog operator.
+func (builder *CatalogSourceBuilder) WithImage(indexImage string) *CatalogSourceBuilder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting catalogsource %s index image to %s", builder.Definition.Name, indexImage)
+
+	if indexImage == "" {
+		builder.errorMsg = "catalogsource 'indexImage' cannot be empty"
+
+		return builder
+	}
+
+	builder.Definition.Spec.SourceType = oplmV1alpha1.SourceTypeGrpc
+	builder.Definition.Spec.Image = indexImage
+	builder.Definition.Spec.Address = ""
+
+	return builder
+}
+
+// WithPollInterval sets the interval of the catalog operator polls of the index image, so that the catalogsource of a
+// moving index image tag, e.g. of the nightly operator builds, serves the latest pushed image.
+func (builder *CatalogSourceBuilder) WithPollInterval(interval time.Duration) *CatalogSourceBuilder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting catalogsource %s poll interval to %s", builder.Definition.Name, interval)
+
+	if interval <= 0 {
+		builder.errorMsg = "catalogsource poll 'interval' must be positive"
+
+		return builder
+	}
+
+	builder.Definition.Spec.UpdateStrategy = &oplmV1alpha1.UpdateStrategy{
+		RegistryPoll: &oplmV1alpha1.RegistryPoll{
+			RawInterval: interval.String(),
+			Interval:    &metav1.Duration{Duration: interval},
+		},
+	}
+
+	return builder
+}
+
 // Exists checks whether the given catalogsource exists.
 func (builder *CatalogSourceBuilder) Exists() bool {
 	if valid, _ := builder.validate(); !valid {
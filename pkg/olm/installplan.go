@@ -11,6 +11,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -56,6 +57,41 @@ func NewInstallPlanBuilder(apiClient *clients.Settings, name, nsname string) *In
 	return &builder
 }
 
+// PullInstallPlan loads an existing installplan into the InstallPlanBuilder struct.
+func PullInstallPlan(apiClient *clients.Settings, name, nsname string) (*InstallPlanBuilder, error) {
+	glog.V(100).Infof("Pulling existing installplan %s from cluster in namespace %s", name, nsname)
+
+	builder := &InstallPlanBuilder{
+		apiClient: apiClient,
+		Definition: &v1alpha1.InstallPlan{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: nsname,
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the installplan is empty")
+
+		builder.errorMsg = "installplan 'name' cannot be empty"
+	}
+
+	if nsname == "" {
+		glog.V(100).Infof("The nsname of the installplan is empty")
+
+		builder.errorMsg = "installplan 'nsname' cannot be empty"
+	}
+
+	if !builder.Exists() {
+		return nil, fmt.Errorf("installplan object named %s doesn't exist in namespace %s", name, nsname)
+	}
+
+	builder.Definition = builder.Object
+
+	return builder, nil
+}
+
 // Create makes an InstallPlanBuilder in cluster and stores the created object in struct.
 func (builder *InstallPlanBuilder) Create() (*InstallPlanBuilder, error) {
 	if valid, err := builder.validate(); !valid {
@@ -67,6 +103,8 @@ func (builder *InstallPlanBuilder) Create() (*InstallPlanBuilder, error) {
 
 	var err error
 	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
 		builder.Object, err = builder.apiClient.InstallPlans(builder.Definition.Namespace).Create(context.TODO(),
 			builder.Definition, metav1.CreateOptions{})
 	}
@@ -131,6 +169,26 @@ func (builder *InstallPlanBuilder) Update() (*InstallPlanBuilder, error) {
 	return builder, err
 }
 
+// Approve sets the installplan approved flag so that OLM proceeds with a manually approved installplan.
+func (builder *InstallPlanBuilder) Approve() (*InstallPlanBuilder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Approving installplan %s in namespace %s",
+		builder.Definition.Name, builder.Definition.Namespace)
+
+	if !builder.Exists() {
+		return nil, fmt.Errorf("installplan named %s in namespace %s doesn't exist",
+			builder.Definition.Name, builder.Definition.Namespace)
+	}
+
+	builder.Definition = builder.Object
+	builder.Definition.Spec.Approved = true
+
+	return builder.Update()
+}
+
 // validate will check that the builder and builder definition are properly initialized before
 // accessing any member fields.
 func (builder *InstallPlanBuilder) validate() (bool, error) {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/golang/glog"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -62,3 +63,41 @@ func ListInstallPlan(
 
 	return installPlanObjects, nil
 }
+
+// ListPendingInstallPlans returns the installplans of the namespace waiting for a manual approval, i.e. in the
+// RequiresApproval phase and not approved yet. The list is empty when no installplan is pending.
+func ListPendingInstallPlans(apiClient *clients.Settings, nsname string) ([]*InstallPlanBuilder, error) {
+	if nsname == "" {
+		glog.V(100).Info("The nsname of the installplan is empty")
+
+		return nil, fmt.Errorf("the nsname of the installplan is empty")
+	}
+
+	glog.V(100).Infof("Listing the InstallPlans pending approval in namespace %s", nsname)
+
+	installPlanList, err := apiClient.InstallPlans(nsname).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		glog.V(100).Infof("Failed to list all installplan in namespace %s due to %s",
+			nsname, err.Error())
+
+		return nil, err
+	}
+
+	var pendingInstallPlans []*InstallPlanBuilder
+
+	for _, foundInstallPlan := range installPlanList.Items {
+		if foundInstallPlan.Spec.Approved ||
+			foundInstallPlan.Status.Phase != operatorsv1alpha1.InstallPlanPhaseRequiresApproval {
+			continue
+		}
+
+		copiedInstallPlan := foundInstallPlan
+		pendingInstallPlans = append(pendingInstallPlans, &InstallPlanBuilder{
+			apiClient:  apiClient,
+			Object:     &copiedInstallPlan,
+			Definition: &copiedInstallPlan,
+		})
+	}
+
+	return pendingInstallPlans, nil
+}
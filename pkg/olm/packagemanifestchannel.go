@@ -0,0 +1,164 @@
+package olm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+)
+
+const (
+	// ChannelSelectorLatest selects the channel of the latest version of the package, "latest-1" the channel of
+	// the version before it and so on.
+	ChannelSelectorLatest = "latest"
+	// ChannelSelectorDefault selects the default channel of the package.
+	ChannelSelectorDefault = "default"
+)
+
+// PackageChannelVersion is a channel of a PackageManifest with the CSV at its head.
+type PackageChannelVersion struct {
+	// Channel is the name of the channel, e.g. v24.9 or stable.
+	Channel string
+	// CSV is the name of the CSV at the head of the channel, e.g. gpu-operator-certified.v24.9.2.
+	CSV string
+	// Version is the version of the CSV at the head of the channel, e.g. 24.9.2.
+	Version string
+}
+
+// ChannelVersions returns the channels of the PackageManifest with the CSV at their head, the latest version first.
+// The channels named after a version, e.g. v24.9 or 4.16, are ordered by their name version, and only them when the
+// package has any, the other channels, e.g. stable, tracking one of them. The packages without versioned channels
+// have their channels ordered by head CSV version.
+func (builder *PackageManifestBuilder) ChannelVersions() ([]PackageChannelVersion, error) {
+	if valid, err := builder.validate(); !valid {
+		return nil, err
+	}
+
+	type orderedChannel struct {
+		PackageChannelVersion
+		order semver.Version
+	}
+
+	var versioned, unversioned []orderedChannel
+
+	for _, channel := range builder.Object.Status.Channels {
+		channelVersion := orderedChannel{
+			PackageChannelVersion: PackageChannelVersion{
+				Channel: channel.Name,
+				CSV:     channel.CurrentCSV,
+				Version: channel.CurrentCSVDesc.Version.String(),
+			},
+			order: channel.CurrentCSVDesc.Version.Version,
+		}
+
+		if nameVersion, err := semver.ParseTolerant(channel.Name); err == nil {
+			channelVersion.order = nameVersion
+			versioned = append(versioned, channelVersion)
+
+			continue
+		}
+
+		unversioned = append(unversioned, channelVersion)
+	}
+
+	ordered := versioned
+	if len(ordered) == 0 {
+		ordered = unversioned
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].order.GT(ordered[j].order)
+	})
+
+	channelVersions := make([]PackageChannelVersion, 0, len(ordered))
+	for _, channel := range ordered {
+		channelVersions = append(channelVersions, channel.PackageChannelVersion)
+	}
+
+	glog.V(100).Infof("PackageManifest %s channels, latest first: %v", builder.Object.Name, channelVersions)
+
+	return channelVersions, nil
+}
+
+// SelectChannel returns the channel of the PackageManifest matching the selector:
+//   - "latest" for the channel of the latest version, "latest-N" for the channel N versions before it.
+//   - "default" or an empty selector for the default channel.
+//   - a channel name, e.g. "v24.9" or "stable".
+//   - a version, e.g. "24.9" for the channel v24.9, or "24.9.2" for the channel whose head CSV has this version.
+func (builder *PackageManifestBuilder) SelectChannel(selector string) (PackageChannelVersion, error) {
+	if valid, err := builder.validate(); !valid {
+		return PackageChannelVersion{}, err
+	}
+
+	glog.V(100).Infof("Selecting channel '%s' of PackageManifest %s", selector, builder.Object.Name)
+
+	if selector == "" || selector == ChannelSelectorDefault {
+		selector = builder.Object.Status.DefaultChannel
+	}
+
+	for _, channel := range builder.Object.Status.Channels {
+		if channel.Name == selector {
+			return PackageChannelVersion{
+				Channel: channel.Name,
+				CSV:     channel.CurrentCSV,
+				Version: channel.CurrentCSVDesc.Version.String(),
+			}, nil
+		}
+	}
+
+	channelVersions, err := builder.ChannelVersions()
+	if err != nil {
+		return PackageChannelVersion{}, err
+	}
+
+	if strings.HasPrefix(selector, ChannelSelectorLatest) {
+		previous := 0
+
+		if offset := strings.TrimPrefix(selector, ChannelSelectorLatest); offset != "" {
+			previous, err = strconv.Atoi(strings.TrimPrefix(offset, "-"))
+			if err != nil || !strings.HasPrefix(offset, "-") {
+				return PackageChannelVersion{}, fmt.Errorf("invalid channel selector %q, expected latest-N", selector)
+			}
+		}
+
+		if previous >= len(channelVersions) {
+			return PackageChannelVersion{}, fmt.Errorf("PackageManifest %s has %d channel versions, no %s",
+				builder.Object.Name, len(channelVersions), selector)
+		}
+
+		return channelVersions[previous], nil
+	}
+
+	for _, channelVersion := range channelVersions {
+		if strings.TrimPrefix(channelVersion.Channel, "v") == strings.TrimPrefix(selector, "v") ||
+			channelVersion.Version == strings.TrimPrefix(selector, "v") {
+			return channelVersion, nil
+		}
+	}
+
+	return PackageChannelVersion{}, fmt.Errorf("no channel of PackageManifest %s matches %q", builder.Object.Name,
+		selector)
+}
+
+// ResolveChannel returns the name of the channel of the package of the catalog matching the selector, see
+// SelectChannel, so that the suites can follow "latest" or "latest-1" instead of hard-coded channels.
+func ResolveChannel(apiClient *clients.Settings, name, nsname, catalog, selector string) (string, error) {
+	pkgManifestBuilder, err := PullPackageManifestByCatalog(apiClient, name, nsname, catalog)
+	if err != nil {
+		return "", fmt.Errorf("failed to pull PackageManifest %s of catalog %s: %w", name, catalog, err)
+	}
+
+	channelVersion, err := pkgManifestBuilder.SelectChannel(selector)
+	if err != nil {
+		return "", err
+	}
+
+	glog.V(100).Infof("Channel selector '%s' of package %s resolved to channel '%s' at CSV %s", selector, name,
+		channelVersion.Channel, channelVersion.CSV)
+
+	return channelVersion.Channel, nil
+}
@@ -9,6 +9,7 @@ import (
 	operatorsV1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -162,6 +163,8 @@ func (builder *SubscriptionBuilder) Create() (*SubscriptionBuilder, error) {
 
 	var err error
 	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
 		builder.Object, err = builder.apiClient.Subscriptions(builder.Definition.Namespace).Create(context.TODO(),
 			builder.Definition, metav1.CreateOptions{})
 	}
@@ -233,6 +236,29 @@ func (builder *SubscriptionBuilder) Update() (*SubscriptionBuilder, error) {
 	return builder, err
 }
 
+// GetInstallPlan returns the installplan currently referenced by the Subscription status.
+func (builder *SubscriptionBuilder) GetInstallPlan() (*InstallPlanBuilder, error) {
+	if valid, err := builder.validate(); !valid {
+		return nil, err
+	}
+
+	glog.V(100).Infof("Getting the installplan of Subscription %s in namespace %s",
+		builder.Definition.Name, builder.Definition.Namespace)
+
+	if !builder.Exists() {
+		return nil, fmt.Errorf("subscription named %s in namespace %s doesn't exist",
+			builder.Definition.Name, builder.Definition.Namespace)
+	}
+
+	if builder.Object.Status.InstallPlanRef == nil {
+		return nil, fmt.Errorf("subscription named %s in namespace %s doesn't reference an installplan",
+			builder.Definition.Name, builder.Definition.Namespace)
+	}
+
+	return PullInstallPlan(builder.apiClient, builder.Object.Status.InstallPlanRef.Name,
+		builder.Object.Status.InstallPlanRef.Namespace)
+}
+
 // PullSubscription loads existing Subscription from cluster into the SubscriptionBuilder struct.
 func PullSubscription(apiClient *clients.Settings, subName, subNamespace string) (*SubscriptionBuilder, error) {
 	glog.V(100).Infof("Pulling existing Subscription %s from cluster in namespace %s",
@@ -0,0 +1,49 @@
+package owner
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// LabelKey is the label the builders set on the objects they create, its value identifying the test run that
+// created them.
+const LabelKey = "nvidia-ci.rh-ecosystem-edge.io/owner"
+
+// runID identifies the test run of the process, every suite running in its own process.
+var runID = fmt.Sprintf("run-%d-%d", time.Now().Unix(), os.Getpid())
+
+// RunID returns the owner label value of the objects created by the test run.
+func RunID() string {
+	return runID
+}
+
+// Label sets the owner label of the test run on the object, before it is created.
+func Label(object metav1.Object) {
+	if object == nil {
+		return
+	}
+
+	objectLabels := object.GetLabels()
+	if objectLabels == nil {
+		objectLabels = map[string]string{}
+	}
+
+	if _, found := objectLabels[LabelKey]; found {
+		return
+	}
+
+	glog.V(100).Infof("Setting owner label %s=%s on %s", LabelKey, runID, object.GetName())
+
+	objectLabels[LabelKey] = runID
+	object.SetLabels(objectLabels)
+}
+
+// Selector returns the label selector of the objects created by the test run.
+func Selector() string {
+	return labels.Set{LabelKey: runID}.String()
+}
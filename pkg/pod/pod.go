@@ -27,6 +27,9 @@ import (
 
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/timeouts"
 )
 
 // Builder provides a struct for pod object from the cluster and a pod definition.
@@ -158,7 +161,8 @@ func (builder *Builder) DefineOnNode(nodeName string) *Builder {
 	return builder
 }
 
-// Create makes a pod according to the pod definition and stores the created object in the pod builder.
+// Create makes a pod according to the pod definition and stores the created object in the pod builder. The
+// containers get the proxy environment of the cluster wide proxy.
 func (builder *Builder) Create() (*Builder, error) {
 	if valid, err := builder.validate(); !valid {
 		return builder, err
@@ -169,6 +173,9 @@ func (builder *Builder) Create() (*Builder, error) {
 
 	var err error
 	if !builder.Exists() {
+		proxy.Inject(builder.apiClient, &builder.Definition.Spec)
+		owner.Label(builder.Definition)
+
 		builder.Object, err = builder.apiClient.Pods(builder.Definition.Namespace).Create(
 			context.TODO(), builder.Definition, metav1.CreateOptions{})
 	}
@@ -261,6 +268,8 @@ func (builder *Builder) WaitUntilRunning(timeout time.Duration) error {
 
 // WaitUntilInStatus waits for the duration of the defined timeout or until the pod gets to a specific status.
 func (builder *Builder) WaitUntilInStatus(status corev1.PodPhase, timeout time.Duration) error {
+	timeout = timeouts.Scale(timeouts.WorkloadCompletion, timeout)
+
 	if valid, err := builder.validate(); !valid {
 		return err
 	}
@@ -327,6 +336,8 @@ func (builder *Builder) WaitUntilReady(timeout time.Duration) error {
 
 // WaitUntilCondition waits for the duration of the defined timeout or until the pod gets to a specific condition.
 func (builder *Builder) WaitUntilCondition(condition corev1.PodConditionType, timeout time.Duration) error {
+	timeout = timeouts.Scale(timeouts.WorkloadCompletion, timeout)
+
 	if valid, err := builder.validate(); !valid {
 		return err
 	}
@@ -754,6 +765,56 @@ func (builder *Builder) WithLocalVolume(volumeName, mountPath string) *Builder {
 	return builder
 }
 
+// WithHostPathVolume mounts the given host path to all pod's containers.
+func (builder *Builder) WithHostPathVolume(volumeName, hostPath, mountPath string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Configuring host path %s volume %s for all pod's: %s containers. MountPath %s",
+		hostPath, volumeName, builder.Definition.Name, mountPath)
+
+	builder.isMutationAllowed("HostPathVolume")
+
+	if volumeName == "" {
+		glog.V(100).Infof("The 'volumeName' of the pod is empty")
+
+		builder.errorMsg = "'volumeName' parameter is empty"
+	}
+
+	if hostPath == "" {
+		glog.V(100).Infof("The 'hostPath' of the pod is empty")
+
+		builder.errorMsg = "'hostPath' parameter is empty"
+	}
+
+	if mountPath == "" {
+		glog.V(100).Infof("The 'mountPath' of the pod is empty")
+
+		builder.errorMsg = "'mountPath' parameter is empty"
+	}
+
+	mountConfig := corev1.VolumeMount{Name: volumeName, MountPath: mountPath, ReadOnly: false}
+
+	builder.isMountAlreadyInUseInPod(mountConfig)
+
+	if builder.errorMsg != "" {
+		return builder
+	}
+
+	for index := range builder.Definition.Spec.Containers {
+		builder.Definition.Spec.Containers[index].VolumeMounts = append(
+			builder.Definition.Spec.Containers[index].VolumeMounts, mountConfig)
+	}
+
+	builder.Definition.Spec.Volumes = append(builder.Definition.Spec.Volumes,
+		corev1.Volume{Name: volumeName, VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{Path: hostPath},
+		}})
+
+	return builder
+}
+
 // WithAdditionalContainer appends additional container to pod.
 func (builder *Builder) WithAdditionalContainer(container *corev1.Container) *Builder {
 	if valid, _ := builder.validate(); !valid {
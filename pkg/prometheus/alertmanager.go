@@ -0,0 +1,94 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AlertmanagerPodLabel selects the pods of the platform Alertmanager instances.
+	AlertmanagerPodLabel = "app.kubernetes.io/name=alertmanager,alertmanager=main"
+	// AlertmanagerContainer is the container of the Alertmanager pods serving the Alertmanager API.
+	AlertmanagerContainer = "alertmanager"
+	// AlertStateActive is the Alertmanager state of the firing alerts that are neither silenced nor inhibited.
+	AlertStateActive = "active"
+
+	alertmanagerURL = "http://localhost:9093"
+)
+
+// Alert is an alert received by Alertmanager.
+type Alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	Status      struct {
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+// AlertmanagerClient queries the Alertmanager API with amtool from inside a platform Alertmanager pod, so that no
+// route or token is needed.
+type AlertmanagerClient struct {
+	alertmanagerPod *pod.Builder
+}
+
+// NewAlertmanagerClient returns an AlertmanagerClient executing its queries in the first running platform
+// Alertmanager pod.
+func NewAlertmanagerClient(apiClient *clients.Settings) (*AlertmanagerClient, error) {
+	glog.V(100).Infof("Looking for a running Alertmanager pod in namespace %s", MonitoringNamespace)
+
+	alertmanagerPods, err := pod.List(apiClient, MonitoringNamespace, metav1.ListOptions{
+		LabelSelector: AlertmanagerPodLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Alertmanager pods: %w", err)
+	}
+
+	for _, alertmanagerPod := range alertmanagerPods {
+		if alertmanagerPod.Object.Status.Phase == corev1.PodRunning {
+			glog.V(100).Infof("Using Alertmanager pod %s", alertmanagerPod.Object.Name)
+
+			return &AlertmanagerClient{alertmanagerPod: alertmanagerPod}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no running Alertmanager pod found in namespace %s", MonitoringNamespace)
+}
+
+// FiringAlerts returns the active alerts named alertName received by Alertmanager.
+func (client *AlertmanagerClient) FiringAlerts(alertName string) ([]Alert, error) {
+	if client == nil || client.alertmanagerPod == nil {
+		return nil, errors.New("alertmanager client is not initialized")
+	}
+
+	glog.V(100).Infof("Querying the Alertmanager alerts named %s", alertName)
+
+	output, err := client.alertmanagerPod.ExecCommand([]string{"amtool", "alert", "query", "-o", "json",
+		"--alertmanager.url=" + alertmanagerURL, "alertname=" + alertName}, AlertmanagerContainer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the Alertmanager alerts %s: %w", alertName, err)
+	}
+
+	var alerts []Alert
+	if err := json.Unmarshal(output.Bytes(), &alerts); err != nil {
+		return nil, fmt.Errorf("failed to decode the Alertmanager alerts %q: %w", output.String(), err)
+	}
+
+	var firing []Alert
+
+	for _, alert := range alerts {
+		if alert.Status.State == AlertStateActive {
+			firing = append(firing, alert)
+		}
+	}
+
+	return firing, nil
+}
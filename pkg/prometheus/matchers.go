@@ -0,0 +1,123 @@
+package prometheus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+const (
+	// HostnameLabel is the DCGM exporter metric label holding the node name.
+	HostnameLabel = "Hostname"
+	// GPULabel is the DCGM exporter metric label holding the GPU index.
+	GPULabel = "gpu"
+)
+
+// MetricExistsForEachGPU succeeds when the []Sample of a DCGM exporter metric holds a series for each GPU of the
+// nodes, gpusByNode being the GPU count by node name, e.g.
+// Expect(samples).To(prometheus.MetricExistsForEachGPU(map[string]int{"worker-0": 2})).
+func MetricExistsForEachGPU(gpusByNode map[string]int) types.GomegaMatcher {
+	return &gpuSeriesMatcher{gpusByNode: gpusByNode}
+}
+
+// HaveValuesBetween succeeds when every value of the []Sample or []Series is within minValue and maxValue, bounds
+// included.
+func HaveValuesBetween(minValue, maxValue float64) types.GomegaMatcher {
+	return &valueRangeMatcher{minValue: minValue, maxValue: maxValue}
+}
+
+type gpuSeriesMatcher struct {
+	gpusByNode map[string]int
+	missing    []string
+}
+
+// Match implements types.GomegaMatcher.
+func (matcher *gpuSeriesMatcher) Match(actual interface{}) (bool, error) {
+	samples, ok := actual.([]Sample)
+	if !ok {
+		return false, fmt.Errorf("MetricExistsForEachGPU expects a []Sample, got\n%s", format.Object(actual, 1))
+	}
+
+	gpusFound := map[string]map[string]bool{}
+
+	for _, sample := range samples {
+		nodeName := sample.Metric[HostnameLabel]
+		if gpusFound[nodeName] == nil {
+			gpusFound[nodeName] = map[string]bool{}
+		}
+
+		gpusFound[nodeName][sample.Metric[GPULabel]] = true
+	}
+
+	matcher.missing = nil
+
+	for nodeName, gpuCount := range matcher.gpusByNode {
+		if len(gpusFound[nodeName]) != gpuCount {
+			matcher.missing = append(matcher.missing, fmt.Sprintf("node %s has %d GPU series instead of %d",
+				nodeName, len(gpusFound[nodeName]), gpuCount))
+		}
+	}
+
+	sort.Strings(matcher.missing)
+
+	return len(matcher.missing) == 0, nil
+}
+
+// FailureMessage implements types.GomegaMatcher.
+func (matcher *gpuSeriesMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected the metric to exist for each GPU, but:\n%s", strings.Join(matcher.missing, "\n"))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher.
+func (matcher *gpuSeriesMatcher) NegatedFailureMessage(actual interface{}) string {
+	return "Expected the metric not to exist for each GPU"
+}
+
+type valueRangeMatcher struct {
+	minValue   float64
+	maxValue   float64
+	outOfRange []string
+}
+
+// Match implements types.GomegaMatcher.
+func (matcher *valueRangeMatcher) Match(actual interface{}) (bool, error) {
+	matcher.outOfRange = nil
+
+	switch typedActual := actual.(type) {
+	case []Sample:
+		for _, sample := range typedActual {
+			matcher.check(sample.Metric, sample.Value)
+		}
+	case []Series:
+		for _, series := range typedActual {
+			for _, point := range series.Points {
+				matcher.check(series.Metric, point.Value)
+			}
+		}
+	default:
+		return false, fmt.Errorf("HaveValuesBetween expects a []Sample or a []Series, got\n%s",
+			format.Object(actual, 1))
+	}
+
+	return len(matcher.outOfRange) == 0, nil
+}
+
+// FailureMessage implements types.GomegaMatcher.
+func (matcher *valueRangeMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected the values to be between %g and %g, but got:\n%s", matcher.minValue,
+		matcher.maxValue, strings.Join(matcher.outOfRange, "\n"))
+}
+
+// NegatedFailureMessage implements types.GomegaMatcher.
+func (matcher *valueRangeMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected some value not to be between %g and %g", matcher.minValue, matcher.maxValue)
+}
+
+func (matcher *valueRangeMatcher) check(metric map[string]string, value float64) {
+	if value < matcher.minValue || value > matcher.maxValue {
+		matcher.outOfRange = append(matcher.outOfRange, fmt.Sprintf("%g for %v", value, metric))
+	}
+}
@@ -0,0 +1,309 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// MonitoringNamespace is the namespace of the OpenShift platform monitoring stack.
+	MonitoringNamespace = "openshift-monitoring"
+	// PrometheusPodLabel selects the pods of the platform Prometheus instances.
+	PrometheusPodLabel = "app.kubernetes.io/name=prometheus,prometheus=k8s"
+	// PrometheusContainer is the container of the Prometheus pods serving the Prometheus API.
+	PrometheusContainer = "prometheus"
+
+	apiURL = "http://localhost:9090/api/v1"
+)
+
+// Sample is a single series of an instant vector query result.
+type Sample struct {
+	Metric map[string]string
+	Value  float64
+}
+
+// Target is a Prometheus scrape target.
+type Target struct {
+	Labels     map[string]string `json:"labels"`
+	ScrapePool string            `json:"scrapePool"`
+	ScrapeURL  string            `json:"scrapeUrl"`
+	Health     string            `json:"health"`
+	LastError  string            `json:"lastError"`
+}
+
+// Series is a single series of a range vector query result.
+type Series struct {
+	Metric map[string]string
+	Points []Point
+}
+
+// Point is a timestamped value of a Series.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Client queries the Prometheus API, either from inside a platform Prometheus pod, so that no route or token is
+// needed, or through a monitoring route with a bearer token, see NewRouteClient.
+type Client struct {
+	prometheusPod *pod.Builder
+	baseURL       string
+	token         string
+	httpClient    *http.Client
+}
+
+type apiResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+}
+
+// NewClient returns a Client executing its queries in the first running platform Prometheus pod, or querying the
+// Thanos querier route when no Prometheus pod runs.
+func NewClient(apiClient *clients.Settings) (*Client, error) {
+	glog.V(100).Infof("Looking for a running Prometheus pod in namespace %s", MonitoringNamespace)
+
+	prometheusPods, err := pod.List(apiClient, MonitoringNamespace, metav1.ListOptions{
+		LabelSelector: PrometheusPodLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Prometheus pods: %w", err)
+	}
+
+	for _, prometheusPod := range prometheusPods {
+		if prometheusPod.Object.Status.Phase == corev1.PodRunning {
+			glog.V(100).Infof("Using Prometheus pod %s", prometheusPod.Object.Name)
+
+			return &Client{prometheusPod: prometheusPod}, nil
+		}
+	}
+
+	glog.V(100).Infof("No running Prometheus pod found in namespace %s, using route %s", MonitoringNamespace,
+		ThanosQuerierRoute)
+
+	client, err := NewRouteClient(apiClient, ThanosQuerierRoute)
+	if err != nil {
+		return nil, fmt.Errorf("no running Prometheus pod found in namespace %s and %w", MonitoringNamespace, err)
+	}
+
+	return client, nil
+}
+
+// Query runs an instant PromQL query and returns the samples of the resulting vector.
+func (client *Client) Query(query string) ([]Sample, error) {
+	glog.V(100).Infof("Running Prometheus query: %s", query)
+
+	data, err := client.get("/query?" + url.Values{"query": []string{query}}.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var vector struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		} `json:"result"`
+	}
+
+	if err := json.Unmarshal(data, &vector); err != nil {
+		return nil, fmt.Errorf("failed to decode the result of query %s: %w", query, err)
+	}
+
+	if vector.ResultType != "vector" {
+		return nil, fmt.Errorf("query %s returned a %s instead of a vector", query, vector.ResultType)
+	}
+
+	samples := make([]Sample, 0, len(vector.Result))
+
+	for _, result := range vector.Result {
+		point, err := parsePoint(query, result.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		samples = append(samples, Sample{Metric: result.Metric, Value: point.Value})
+	}
+
+	return samples, nil
+}
+
+// QueryRange runs a PromQL query over the time range, evaluated every step, and returns the series of the resulting
+// matrix.
+func (client *Client) QueryRange(query string, start, end time.Time, step time.Duration) ([]Series, error) {
+	glog.V(100).Infof("Running Prometheus range query from %s to %s every %s: %s", start, end, step, query)
+
+	if step <= 0 {
+		return nil, fmt.Errorf("range query %s step %s is not positive", query, step)
+	}
+
+	data, err := client.get("/query_range?" + url.Values{
+		"query": []string{query},
+		"start": []string{strconv.FormatInt(start.Unix(), 10)},
+		"end":   []string{strconv.FormatInt(end.Unix(), 10)},
+		"step":  []string{strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var matrix struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]interface{}   `json:"values"`
+		} `json:"result"`
+	}
+
+	if err := json.Unmarshal(data, &matrix); err != nil {
+		return nil, fmt.Errorf("failed to decode the result of range query %s: %w", query, err)
+	}
+
+	if matrix.ResultType != "matrix" {
+		return nil, fmt.Errorf("range query %s returned a %s instead of a matrix", query, matrix.ResultType)
+	}
+
+	series := make([]Series, 0, len(matrix.Result))
+
+	for _, result := range matrix.Result {
+		points := make([]Point, 0, len(result.Values))
+
+		for _, value := range result.Values {
+			point, err := parsePoint(query, value)
+			if err != nil {
+				return nil, err
+			}
+
+			points = append(points, point)
+		}
+
+		series = append(series, Series{Metric: result.Metric, Points: points})
+	}
+
+	return series, nil
+}
+
+// ActiveTargets returns the active scrape targets of Prometheus.
+func (client *Client) ActiveTargets() ([]Target, error) {
+	glog.V(100).Info("Listing Prometheus active targets")
+
+	data, err := client.get("/targets?state=active")
+	if err != nil {
+		return nil, err
+	}
+
+	var targets struct {
+		ActiveTargets []Target `json:"activeTargets"`
+	}
+
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("failed to decode Prometheus targets: %w", err)
+	}
+
+	return targets.ActiveTargets, nil
+}
+
+func (client *Client) get(path string) (json.RawMessage, error) {
+	if client == nil || (client.prometheusPod == nil && client.httpClient == nil) {
+		return nil, errors.New("prometheus client is not initialized")
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+
+	if client.httpClient != nil {
+		body, err = client.getRoute(path)
+	} else {
+		var output bytes.Buffer
+
+		output, err = client.prometheusPod.ExecCommand([]string{"curl", "-s", apiURL + path}, PrometheusContainer)
+		body = output.Bytes()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Prometheus API %s: %w", path, err)
+	}
+
+	var response apiResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode Prometheus API %s response %q: %w", path, string(body), err)
+	}
+
+	if response.Status != "success" {
+		return nil, fmt.Errorf("prometheus API %s returned %s: %s", path, response.ErrorType, response.Error)
+	}
+
+	return response.Data, nil
+}
+
+// parsePoint parses a [timestamp, "value"] pair of a query result.
+func parsePoint(query string, pair []interface{}) (Point, error) {
+	if len(pair) != 2 {
+		return Point{}, fmt.Errorf("query %s returned a malformed sample %v", query, pair)
+	}
+
+	timestamp, ok := pair[0].(float64)
+	if !ok {
+		return Point{}, fmt.Errorf("query %s returned a non numeric sample timestamp %v", query, pair[0])
+	}
+
+	rawValue, ok := pair[1].(string)
+	if !ok {
+		return Point{}, fmt.Errorf("query %s returned a non string sample value %v", query, pair[1])
+	}
+
+	value, err := strconv.ParseFloat(rawValue, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("query %s returned an invalid sample value %s: %w", query, rawValue, err)
+	}
+
+	return Point{Time: time.UnixMilli(int64(timestamp * 1000)), Value: value}, nil
+}
+
+// PullServiceMonitor retrieves an existing ServiceMonitor from the cluster.
+func PullServiceMonitor(apiClient *clients.Settings, name, nsname string) (*monitoringv1.ServiceMonitor, error) {
+	glog.V(100).Infof("Pulling ServiceMonitor %s in namespace %s", name, nsname)
+
+	if name == "" || nsname == "" {
+		return nil, errors.New("ServiceMonitor 'name' and 'nsname' cannot be empty")
+	}
+
+	object, err := apiClient.Resource(GetServiceMonitorGVR()).Namespace(nsname).Get(context.TODO(), name,
+		metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ServiceMonitor %s in namespace %s: %w", name, nsname, err)
+	}
+
+	serviceMonitor := &monitoringv1.ServiceMonitor{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, serviceMonitor); err != nil {
+		return nil, fmt.Errorf("failed to convert ServiceMonitor %s: %w", name, err)
+	}
+
+	return serviceMonitor, nil
+}
+
+// GetServiceMonitorGVR returns the ServiceMonitor GroupVersionResource.
+func GetServiceMonitorGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors",
+	}
+}
@@ -0,0 +1,152 @@
+package prometheus
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// ThanosQuerierRoute is the route of the Thanos querier of the platform monitoring, querying all the Prometheus
+	// instances.
+	ThanosQuerierRoute = "thanos-querier"
+	// PrometheusRoute is the route of the platform Prometheus instances, which also serves their scrape targets.
+	PrometheusRoute = "prometheus-k8s"
+	// TokenServiceAccount is the service account of the platform Prometheus, allowed to query the monitoring routes,
+	// the bearer token of the route clients is requested for when the API client does not have one.
+	TokenServiceAccount = "prometheus-k8s"
+	// IngressCANamespace is the namespace of the configmap holding the CA of the default ingress certificate.
+	IngressCANamespace = "openshift-config-managed"
+	// IngressCAConfigMap is the configmap holding the CA of the default ingress certificate, which signs the routes.
+	IngressCAConfigMap = "default-ingress-cert"
+
+	ingressCAKey      = "ca-bundle.crt"
+	tokenExpiration   = int64(3600)
+	routeQueryTimeout = time.Minute
+)
+
+// routeGVR is the resource of the OpenShift routes.
+var routeGVR = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+
+// NewRouteClient returns a Client querying the Prometheus API through the monitoring route, e.g. ThanosQuerierRoute,
+// with the bearer token of the API client, or a token requested for TokenServiceAccount. The route certificate is
+// verified with the CA of the default ingress certificate, falling back to the system roots.
+func NewRouteClient(apiClient *clients.Settings, routeName string) (*Client, error) {
+	glog.V(100).Infof("Looking for route %s in namespace %s", routeName, MonitoringNamespace)
+
+	route, err := apiClient.Resource(routeGVR).Namespace(MonitoringNamespace).Get(context.TODO(), routeName,
+		metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route %s in namespace %s: %w", routeName, MonitoringNamespace, err)
+	}
+
+	host, found, err := unstructured.NestedString(route.Object, "spec", "host")
+	if err != nil || !found || host == "" {
+		return nil, fmt.Errorf("route %s in namespace %s has no host", routeName, MonitoringNamespace)
+	}
+
+	token, err := bearerToken(apiClient)
+	if err != nil {
+		return nil, err
+	}
+
+	glog.V(100).Infof("Using Prometheus route %s with host %s", routeName, host)
+
+	return &Client{
+		baseURL: fmt.Sprintf("https://%s/api/v1", host),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: routeQueryTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{RootCAs: ingressCAs(apiClient)},
+			},
+		},
+	}, nil
+}
+
+// getRoute returns the body of the Prometheus API path served through the route.
+func (client *Client) getRoute(path string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, client.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+client.token)
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response: %w", err)
+	}
+
+	// Prometheus answers the query errors with a JSON body and a 4xx status, which get decodes.
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("route request returned %s", response.Status)
+	}
+
+	return body, nil
+}
+
+// bearerToken returns the bearer token of the API client, or a token requested for TokenServiceAccount when the API
+// client authenticates with a client certificate.
+func bearerToken(apiClient *clients.Settings) (string, error) {
+	if apiClient.Config != nil && apiClient.Config.BearerToken != "" {
+		return apiClient.Config.BearerToken, nil
+	}
+
+	glog.V(100).Infof("Requesting a token for service account %s in namespace %s", TokenServiceAccount,
+		MonitoringNamespace)
+
+	expiration := tokenExpiration
+
+	tokenRequest, err := apiClient.K8sClient.CoreV1().ServiceAccounts(MonitoringNamespace).CreateToken(
+		context.TODO(), TokenServiceAccount, &authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expiration},
+		}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to request a token for service account %s: %w", TokenServiceAccount, err)
+	}
+
+	return tokenRequest.Status.Token, nil
+}
+
+// ingressCAs returns the system roots with the CA of the default ingress certificate, the system roots alone when the
+// CA cannot be read.
+func ingressCAs(apiClient *clients.Settings) *x509.CertPool {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	configMap, err := apiClient.ConfigMaps(IngressCANamespace).Get(context.TODO(), IngressCAConfigMap,
+		metav1.GetOptions{})
+	if err != nil {
+		glog.V(100).Infof("Failed to get the default ingress CA, using the system roots: %v", err)
+
+		return pool
+	}
+
+	if !pool.AppendCertsFromPEM([]byte(configMap.Data[ingressCAKey])) {
+		glog.V(100).Infof("Configmap %s/%s holds no CA certificate", IngressCANamespace, IngressCAConfigMap)
+	}
+
+	return pool
+}
@@ -0,0 +1,110 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/golang/glog"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Rule is an alerting or recording rule loaded by Prometheus.
+type Rule struct {
+	Name   string `json:"name"`
+	Query  string `json:"query"`
+	Health string `json:"health"`
+	// State is the inactive, pending or firing state of an alerting rule, empty for the recording rules.
+	State string `json:"state"`
+	Type  string `json:"type"`
+	// Group is the name of the rule group of the rule.
+	Group string `json:"-"`
+}
+
+// Rules returns the rules loaded by Prometheus.
+func (client *Client) Rules() ([]Rule, error) {
+	glog.V(100).Info("Listing Prometheus rules")
+
+	data, err := client.get("/rules")
+	if err != nil {
+		return nil, err
+	}
+
+	var ruleGroups struct {
+		Groups []struct {
+			Name  string `json:"name"`
+			Rules []Rule `json:"rules"`
+		} `json:"groups"`
+	}
+
+	if err := json.Unmarshal(data, &ruleGroups); err != nil {
+		return nil, fmt.Errorf("failed to decode Prometheus rules: %w", err)
+	}
+
+	var rules []Rule
+
+	for _, group := range ruleGroups.Groups {
+		for _, rule := range group.Rules {
+			rule.Group = group.Name
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// CreatePrometheusRule creates the PrometheusRule in the cluster, the platform Prometheus loading the rules of the
+// namespaces labeled for cluster monitoring.
+func CreatePrometheusRule(apiClient *clients.Settings, rule *monitoringv1.PrometheusRule) error {
+	if rule == nil || rule.Name == "" || rule.Namespace == "" {
+		return errors.New("PrometheusRule 'name' and 'namespace' cannot be empty")
+	}
+
+	glog.V(100).Infof("Creating PrometheusRule %s in namespace %s", rule.Name, rule.Namespace)
+
+	owner.Label(rule)
+
+	rule.TypeMeta = metav1.TypeMeta{APIVersion: monitoringv1.SchemeGroupVersion.String(),
+		Kind: monitoringv1.PrometheusRuleKind}
+
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(rule)
+	if err != nil {
+		return fmt.Errorf("failed to convert PrometheusRule %s: %w", rule.Name, err)
+	}
+
+	_, err = apiClient.Resource(GetPrometheusRuleGVR()).Namespace(rule.Namespace).Create(context.TODO(),
+		&unstructured.Unstructured{Object: object}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create PrometheusRule %s in namespace %s: %w", rule.Name, rule.Namespace, err)
+	}
+
+	return nil
+}
+
+// DeletePrometheusRule removes the PrometheusRule from the cluster, a missing PrometheusRule being no error.
+func DeletePrometheusRule(apiClient *clients.Settings, name, nsname string) error {
+	glog.V(100).Infof("Deleting PrometheusRule %s in namespace %s", name, nsname)
+
+	err := apiClient.Resource(GetPrometheusRuleGVR()).Namespace(nsname).Delete(context.TODO(), name,
+		metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PrometheusRule %s in namespace %s: %w", name, nsname, err)
+	}
+
+	return nil
+}
+
+// GetPrometheusRuleGVR returns the PrometheusRule GroupVersionResource.
+func GetPrometheusRuleGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheusrules",
+	}
+}
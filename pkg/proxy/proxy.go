@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClusterProxyName is the name of the cluster wide Proxy object.
+	ClusterProxyName = "cluster"
+	// HTTPProxyEnv is the environment variable of the HTTP proxy.
+	HTTPProxyEnv = "HTTP_PROXY"
+	// HTTPSProxyEnv is the environment variable of the HTTPS proxy.
+	HTTPSProxyEnv = "HTTPS_PROXY"
+	// NoProxyEnv is the environment variable of the destinations reached without proxy.
+	NoProxyEnv = "NO_PROXY"
+)
+
+// EnvVars returns the proxy environment variables of the cluster wide Proxy status, in upper and lower case as the
+// tools read either, or none when the cluster has no proxy. The status NO_PROXY includes the cluster and service
+// networks next to the configured destinations.
+func EnvVars(apiClient *clients.Settings) ([]corev1.EnvVar, error) {
+	clusterProxy, err := apiClient.Proxies().Get(context.TODO(), ClusterProxyName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Proxy %s: %w", ClusterProxyName, err)
+	}
+
+	if clusterProxy.Status.HTTPProxy == "" && clusterProxy.Status.HTTPSProxy == "" {
+		return nil, nil
+	}
+
+	var envVars []corev1.EnvVar
+
+	for _, envVar := range []corev1.EnvVar{
+		{Name: HTTPProxyEnv, Value: clusterProxy.Status.HTTPProxy},
+		{Name: HTTPSProxyEnv, Value: clusterProxy.Status.HTTPSProxy},
+		{Name: NoProxyEnv, Value: clusterProxy.Status.NoProxy},
+	} {
+		if envVar.Value == "" {
+			continue
+		}
+
+		envVars = append(envVars, envVar, corev1.EnvVar{Name: lowerCase(envVar.Name), Value: envVar.Value})
+	}
+
+	return envVars, nil
+}
+
+// InjectEnv adds the environment variables to the containers and init containers of the pod spec, keeping the
+// variables a container already sets.
+func InjectEnv(podSpec *corev1.PodSpec, envVars []corev1.EnvVar) {
+	if podSpec == nil || len(envVars) == 0 {
+		return
+	}
+
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for index := range containers {
+			container := &containers[index]
+
+			for _, envVar := range envVars {
+				if !hasEnv(container.Env, envVar.Name) {
+					container.Env = append(container.Env, envVar)
+				}
+			}
+		}
+	}
+}
+
+// Inject adds the proxy environment variables of the cluster to the pod spec, so that the test workloads fetching
+// external content go through the cluster wide proxy. Failing to get the Proxy is logged, the pod spec being left
+// unchanged.
+func Inject(apiClient *clients.Settings, podSpec *corev1.PodSpec) {
+	envVars, err := EnvVars(apiClient)
+	if err != nil {
+		glog.V(100).Infof("Not injecting the cluster proxy environment: %v", err)
+
+		return
+	}
+
+	InjectEnv(podSpec, envVars)
+}
+
+// MissingEnv returns the names of the containers that do not set all the environment variables to their values, e.g.
+// the GPU operator operands not configured with the cluster wide proxy.
+func MissingEnv(containers []corev1.Container, envVars []corev1.EnvVar) []string {
+	var missing []string
+
+	for _, container := range containers {
+		for _, envVar := range envVars {
+			if !hasEnvValue(container.Env, envVar) {
+				missing = append(missing, container.Name)
+
+				break
+			}
+		}
+	}
+
+	return missing
+}
+
+// hasEnv returns true when the environment variable is set.
+func hasEnv(envVars []corev1.EnvVar, name string) bool {
+	for _, envVar := range envVars {
+		if envVar.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasEnvValue returns true when the environment variable is set to the value.
+func hasEnvValue(envVars []corev1.EnvVar, expected corev1.EnvVar) bool {
+	for _, envVar := range envVars {
+		if envVar.Name == expected.Name && envVar.Value == expected.Value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lowerCase returns the lower case name of the proxy environment variable, e.g. https_proxy.
+func lowerCase(name string) string {
+	switch name {
+	case HTTPProxyEnv:
+		return "http_proxy"
+	case HTTPSProxyEnv:
+		return "https_proxy"
+	default:
+		return "no_proxy"
+	}
+}
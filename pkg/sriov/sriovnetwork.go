@@ -0,0 +1,179 @@
+package sriov
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const networkKind = "SriovNetwork"
+
+// NetworkBuilder provides struct for the SriovNetwork object containing connection to the cluster and the
+// SriovNetwork definitions.
+type NetworkBuilder struct {
+	// SriovNetwork definition. Used to create the SriovNetwork object.
+	Definition *unstructured.Unstructured
+	// Created SriovNetwork object.
+	Object *unstructured.Unstructured
+	// Used in functions that define or mutate the SriovNetwork definition. errorMsg is processed before the
+	// SriovNetwork object is created.
+	errorMsg  string
+	apiClient *clients.Settings
+}
+
+// NewNetworkBuilder creates a new instance of a SriovNetwork builder. The SR-IOV network operator renders the
+// SriovNetwork as a NetworkAttachmentDefinition of the same name in networkNamespace, attaching a VF of the
+// resourceName resource with the ipam CNI configuration.
+func NewNetworkBuilder(apiClient *clients.Settings, name, networkNamespace, resourceName,
+	ipam string) *NetworkBuilder {
+	glog.V(100).Infof("Initializing new SriovNetwork structure with the following params: %s, %s, %s, %s",
+		name, networkNamespace, resourceName, ipam)
+
+	builder := &NetworkBuilder{
+		apiClient: apiClient,
+		Definition: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": sriovGroup + "/" + sriovVersion,
+				"kind":       networkKind,
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": OperatorNamespace,
+				},
+				"spec": map[string]interface{}{
+					"resourceName":     resourceName,
+					"networkNamespace": networkNamespace,
+					"ipam":             ipam,
+				},
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the SriovNetwork is empty")
+
+		builder.errorMsg = "SriovNetwork 'name' cannot be empty"
+
+		return builder
+	}
+
+	if networkNamespace == "" {
+		glog.V(100).Infof("The networkNamespace of the SriovNetwork is empty")
+
+		builder.errorMsg = "SriovNetwork 'networkNamespace' cannot be empty"
+
+		return builder
+	}
+
+	if resourceName == "" {
+		glog.V(100).Infof("The resourceName of the SriovNetwork is empty")
+
+		builder.errorMsg = "SriovNetwork 'resourceName' cannot be empty"
+	}
+
+	return builder
+}
+
+// Create makes a SriovNetwork in the cluster and stores the created object in struct.
+func (builder *NetworkBuilder) Create() (*NetworkBuilder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating the SriovNetwork %s", builder.Definition.GetName())
+
+	var err error
+	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
+		builder.Object, err = builder.apiClient.Resource(GetNetworkGVR()).Namespace(OperatorNamespace).
+			Create(context.TODO(), builder.Definition, metav1.CreateOptions{})
+	}
+
+	return builder, err
+}
+
+// Delete removes the SriovNetwork from the cluster, the operator deleting its NetworkAttachmentDefinition.
+func (builder *NetworkBuilder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting the SriovNetwork %s", builder.Definition.GetName())
+
+	if !builder.Exists() {
+		return nil
+	}
+
+	err := builder.apiClient.Resource(GetNetworkGVR()).Namespace(OperatorNamespace).Delete(context.TODO(),
+		builder.Definition.GetName(), metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete SriovNetwork %s: %w", builder.Definition.GetName(), err)
+	}
+
+	builder.Object = nil
+
+	return nil
+}
+
+// Exists checks whether the given SriovNetwork exists.
+func (builder *NetworkBuilder) Exists() bool {
+	if valid, _ := builder.validate(); !valid {
+		return false
+	}
+
+	glog.V(100).Infof("Checking if SriovNetwork %s exists", builder.Definition.GetName())
+
+	var err error
+	builder.Object, err = builder.apiClient.Resource(GetNetworkGVR()).Namespace(OperatorNamespace).
+		Get(context.TODO(), builder.Definition.GetName(), metav1.GetOptions{})
+
+	return err == nil || !k8serrors.IsNotFound(err)
+}
+
+// GetNetworkGVR returns the SriovNetwork GroupVersionResource.
+func GetNetworkGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: sriovGroup, Version: sriovVersion, Resource: "sriovnetworks",
+	}
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *NetworkBuilder) validate() (bool, error) {
+	resourceCRD := networkKind
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,213 @@
+package sriov
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// OperatorNamespace is the namespace of the SR-IOV network operator and of its custom resources.
+	OperatorNamespace = "openshift-sriov-network-operator"
+	// DeviceTypeNetDevice exposes the VFs as kernel network interfaces in the pods.
+	DeviceTypeNetDevice = "netdevice"
+	// ResourcePrefix prefixes the resource names of the SR-IOV device plugin in the pod resources.
+	ResourcePrefix = "openshift.io/"
+
+	sriovGroup   = "sriovnetwork.openshift.io"
+	sriovVersion = "v1"
+
+	nodePolicyKind = "SriovNetworkNodePolicy"
+)
+
+// NodePolicyBuilder provides struct for the SriovNetworkNodePolicy object containing connection to the cluster and
+// the SriovNetworkNodePolicy definitions.
+type NodePolicyBuilder struct {
+	// SriovNetworkNodePolicy definition. Used to create the SriovNetworkNodePolicy object.
+	Definition *unstructured.Unstructured
+	// Created SriovNetworkNodePolicy object.
+	Object *unstructured.Unstructured
+	// Used in functions that define or mutate the SriovNetworkNodePolicy definition. errorMsg is processed before the
+	// SriovNetworkNodePolicy object is created.
+	errorMsg  string
+	apiClient *clients.Settings
+}
+
+// NewNodePolicyBuilder creates a new instance of a SriovNetworkNodePolicy builder creating numVFs netdevice VFs on
+// the physical functions of the selected nodes, advertised as the resourceName resource of the SR-IOV device plugin.
+func NewNodePolicyBuilder(apiClient *clients.Settings, name, resourceName string, numVFs int64,
+	pfNames []string, nodeSelector map[string]string) *NodePolicyBuilder {
+	glog.V(100).Infof("Initializing new SriovNetworkNodePolicy structure with the following params: %s, %s, %d, "+
+		"%v, %v", name, resourceName, numVFs, pfNames, nodeSelector)
+
+	pfNameList := []interface{}{}
+	for _, pfName := range pfNames {
+		pfNameList = append(pfNameList, pfName)
+	}
+
+	nodeSelectorMap := map[string]interface{}{}
+	for key, value := range nodeSelector {
+		nodeSelectorMap[key] = value
+	}
+
+	builder := &NodePolicyBuilder{
+		apiClient: apiClient,
+		Definition: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": sriovGroup + "/" + sriovVersion,
+				"kind":       nodePolicyKind,
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": OperatorNamespace,
+				},
+				"spec": map[string]interface{}{
+					"resourceName": resourceName,
+					"numVfs":       numVFs,
+					"deviceType":   DeviceTypeNetDevice,
+					"nicSelector": map[string]interface{}{
+						"pfNames": pfNameList,
+					},
+					"nodeSelector": nodeSelectorMap,
+				},
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the SriovNetworkNodePolicy is empty")
+
+		builder.errorMsg = "SriovNetworkNodePolicy 'name' cannot be empty"
+
+		return builder
+	}
+
+	if resourceName == "" {
+		glog.V(100).Infof("The resourceName of the SriovNetworkNodePolicy is empty")
+
+		builder.errorMsg = "SriovNetworkNodePolicy 'resourceName' cannot be empty"
+
+		return builder
+	}
+
+	if numVFs <= 0 {
+		glog.V(100).Infof("The numVfs of the SriovNetworkNodePolicy is not positive")
+
+		builder.errorMsg = "SriovNetworkNodePolicy 'numVfs' must be positive"
+
+		return builder
+	}
+
+	if len(pfNames) == 0 {
+		glog.V(100).Infof("The pfNames of the SriovNetworkNodePolicy are empty")
+
+		builder.errorMsg = "SriovNetworkNodePolicy 'pfNames' cannot be empty"
+	}
+
+	return builder
+}
+
+// Create makes a SriovNetworkNodePolicy in the cluster and stores the created object in struct. The SR-IOV config
+// daemon then configures the VFs of the selected nodes, which may drain and reboot them.
+func (builder *NodePolicyBuilder) Create() (*NodePolicyBuilder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating the SriovNetworkNodePolicy %s", builder.Definition.GetName())
+
+	var err error
+	if !builder.Exists() {
+		owner.Label(builder.Definition)
+
+		builder.Object, err = builder.apiClient.Resource(GetNodePolicyGVR()).Namespace(OperatorNamespace).
+			Create(context.TODO(), builder.Definition, metav1.CreateOptions{})
+	}
+
+	return builder, err
+}
+
+// Delete removes the SriovNetworkNodePolicy from the cluster. The SR-IOV config daemon then removes its VFs.
+func (builder *NodePolicyBuilder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting the SriovNetworkNodePolicy %s", builder.Definition.GetName())
+
+	if !builder.Exists() {
+		return nil
+	}
+
+	err := builder.apiClient.Resource(GetNodePolicyGVR()).Namespace(OperatorNamespace).Delete(context.TODO(),
+		builder.Definition.GetName(), metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete SriovNetworkNodePolicy %s: %w", builder.Definition.GetName(), err)
+	}
+
+	builder.Object = nil
+
+	return nil
+}
+
+// Exists checks whether the given SriovNetworkNodePolicy exists.
+func (builder *NodePolicyBuilder) Exists() bool {
+	if valid, _ := builder.validate(); !valid {
+		return false
+	}
+
+	glog.V(100).Infof("Checking if SriovNetworkNodePolicy %s exists", builder.Definition.GetName())
+
+	var err error
+	builder.Object, err = builder.apiClient.Resource(GetNodePolicyGVR()).Namespace(OperatorNamespace).
+		Get(context.TODO(), builder.Definition.GetName(), metav1.GetOptions{})
+
+	return err == nil || !k8serrors.IsNotFound(err)
+}
+
+// GetNodePolicyGVR returns the SriovNetworkNodePolicy GroupVersionResource.
+func GetNodePolicyGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: sriovGroup, Version: sriovVersion, Resource: "sriovnetworknodepolicies",
+	}
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *NodePolicyBuilder) validate() (bool, error) {
+	resourceCRD := nodePolicyKind
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
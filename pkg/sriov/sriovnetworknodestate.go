@@ -0,0 +1,65 @@
+package sriov
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// SyncStatusSucceeded is the sync status of the SriovNetworkNodeStates whose node runs the VF configuration of
+	// the policies.
+	SyncStatusSucceeded = "Succeeded"
+
+	defaultOperatorConfigName = "default"
+)
+
+// OperatorDeployed returns true when the default SriovOperatorConfig of the SR-IOV network operator exists.
+func OperatorDeployed(apiClient *clients.Settings) bool {
+	glog.V(100).Infof("Checking if the SR-IOV network operator is deployed in namespace %s", OperatorNamespace)
+
+	_, err := apiClient.Resource(GetOperatorConfigGVR()).Namespace(OperatorNamespace).Get(context.TODO(),
+		defaultOperatorConfigName, metav1.GetOptions{})
+
+	return err == nil
+}
+
+// NodeStatesSynced returns true when the SriovNetworkNodeState of every node reports that the SR-IOV config daemon
+// applied the policies.
+func NodeStatesSynced(apiClient *clients.Settings, nodeNames []string) (bool, error) {
+	for _, nodeName := range nodeNames {
+		nodeState, err := apiClient.Resource(GetNodeStateGVR()).Namespace(OperatorNamespace).Get(context.TODO(),
+			nodeName, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to get the SriovNetworkNodeState of node %s: %w", nodeName, err)
+		}
+
+		syncStatus, _, _ := unstructured.NestedString(nodeState.Object, "status", "syncStatus")
+		if syncStatus != SyncStatusSucceeded {
+			glog.V(100).Infof("SriovNetworkNodeState %s sync status is '%s'", nodeName, syncStatus)
+
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// GetNodeStateGVR returns the SriovNetworkNodeState GroupVersionResource.
+func GetNodeStateGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: sriovGroup, Version: sriovVersion, Resource: "sriovnetworknodestates",
+	}
+}
+
+// GetOperatorConfigGVR returns the SriovOperatorConfig GroupVersionResource.
+func GetOperatorConfigGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group: sriovGroup, Version: sriovVersion, Resource: "sriovoperatorconfigs",
+	}
+}
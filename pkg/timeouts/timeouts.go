@@ -0,0 +1,130 @@
+package timeouts
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Category is a kind of operation whose timeouts are scaled together by the timeout profile.
+type Category string
+
+const (
+	// OperatorInstall is the category of the operator installs, e.g. the CSVs, installplans and deployments.
+	OperatorInstall Category = "operator-install"
+	// DriverReady is the category of the driver and operand readiness, e.g. the ClusterPolicy and the GPU resources
+	// of the nodes.
+	DriverReady Category = "driver-ready"
+	// WorkloadCompletion is the category of the workload pods and jobs running and completing.
+	WorkloadCompletion Category = "workload-completion"
+	// MustGather is the category of the must-gather collections of the failed specs.
+	MustGather Category = "must-gather"
+
+	// DefaultProfileName is the name of the profile of the timeouts as they are hard-coded, tuned for cloud clusters.
+	DefaultProfileName = "default"
+	// FastProfileName is the name of the profile halving the timeouts and poll intervals, failing early on fast
+	// clusters.
+	FastProfileName = "fast"
+	// SlowHardwareProfileName is the name of the profile of the bare-metal labs, e.g. long reboots and driver
+	// builds, multiplying the timeouts.
+	SlowHardwareProfileName = "slow-hardware"
+)
+
+// Profile is a set of factors of the timeouts of each category and of the poll intervals.
+type Profile struct {
+	Name               string
+	Factors            map[Category]float64
+	PollIntervalFactor float64
+}
+
+var (
+	// Profiles are the preset timeout profiles by name.
+	Profiles = map[string]Profile{
+		FastProfileName: {
+			Name: FastProfileName,
+			Factors: map[Category]float64{
+				OperatorInstall: 0.5, DriverReady: 0.5, WorkloadCompletion: 0.5, MustGather: 0.5,
+			},
+			PollIntervalFactor: 0.5,
+		},
+		DefaultProfileName: {
+			Name: DefaultProfileName,
+			Factors: map[Category]float64{
+				OperatorInstall: 1, DriverReady: 1, WorkloadCompletion: 1, MustGather: 1,
+			},
+			PollIntervalFactor: 1,
+		},
+		SlowHardwareProfileName: {
+			Name: SlowHardwareProfileName,
+			Factors: map[Category]float64{
+				OperatorInstall: 2, DriverReady: 3, WorkloadCompletion: 3, MustGather: 3,
+			},
+			PollIntervalFactor: 2,
+		},
+	}
+
+	profileMutex sync.RWMutex
+	// profile is the profile of the timeouts, the default profile until the general config sets it.
+	profile = Profiles[DefaultProfileName]
+)
+
+// GetProfile returns the preset profile of the name.
+func GetProfile(name string) (Profile, error) {
+	preset, found := Profiles[name]
+	if !found {
+		return Profile{}, fmt.Errorf("unknown timeout profile %q, expected one of %v", name, ProfileNames())
+	}
+
+	return preset, nil
+}
+
+// ProfileNames returns the sorted names of the preset profiles.
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// SetProfile sets the profile scaling the timeouts and poll intervals.
+func SetProfile(newProfile Profile) {
+	profileMutex.Lock()
+	defer profileMutex.Unlock()
+
+	profile = newProfile
+}
+
+// CurrentProfile returns the profile scaling the timeouts and poll intervals.
+func CurrentProfile() Profile {
+	profileMutex.RLock()
+	defer profileMutex.RUnlock()
+
+	return profile
+}
+
+// Scale returns the timeout of the category scaled by the factor of the current profile, the timeout itself when
+// the profile has no factor for the category.
+func Scale(category Category, timeout time.Duration) time.Duration {
+	factor, found := CurrentProfile().Factors[category]
+	if !found || factor <= 0 {
+		return timeout
+	}
+
+	return time.Duration(float64(timeout) * factor)
+}
+
+// PollInterval returns the poll interval scaled by the poll interval factor of the current profile, not going below
+// a second, or below the interval itself when it is shorter.
+func PollInterval(interval time.Duration) time.Duration {
+	factor := CurrentProfile().PollIntervalFactor
+	if factor <= 0 {
+		return interval
+	}
+
+	return max(time.Duration(float64(interval)*factor), min(interval, time.Second))
+}
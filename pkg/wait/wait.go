@@ -0,0 +1,254 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients/retry"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var (
+	deploymentsGVR     = appsv1.SchemeGroupVersion.WithResource("deployments")
+	daemonSetsGVR      = appsv1.SchemeGroupVersion.WithResource("daemonsets")
+	nodesGVR           = corev1.SchemeGroupVersion.WithResource("nodes")
+	clusterPoliciesGVR = nvidiagpuv1.SchemeGroupVersion.WithResource("clusterpolicies")
+)
+
+// Condition returns true when the watched object reached the expected state. The object is nil when it does not
+// exist or was deleted.
+type Condition func(object *unstructured.Unstructured) (bool, error)
+
+// DeploymentReady waits until the deployment rolled its latest generation out and all its replicas are available,
+// or the context is done.
+func DeploymentReady(ctx context.Context, apiClient *clients.Settings, name, namespace string) error {
+	return UntilObject(ctx, apiClient, deploymentsGVR, namespace, name, func(object *unstructured.Unstructured) (
+		bool, error) {
+		deployment := &appsv1.Deployment{}
+		if object == nil {
+			return false, nil
+		}
+
+		if err := fromUnstructured(object, deployment); err != nil {
+			return false, err
+		}
+
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+
+		glog.V(100).Infof("Deployment %s/%s has %d/%d updated and %d available replicas", namespace, name,
+			deployment.Status.UpdatedReplicas, replicas, deployment.Status.AvailableReplicas)
+
+		return deployment.Status.ObservedGeneration >= deployment.Generation &&
+			deployment.Status.UpdatedReplicas == replicas && deployment.Status.AvailableReplicas == replicas, nil
+	})
+}
+
+// DaemonSetReady waits until the daemonset rolled its latest generation out and its pods are ready on every
+// scheduled node, or the context is done.
+func DaemonSetReady(ctx context.Context, apiClient *clients.Settings, name, namespace string) error {
+	return UntilObject(ctx, apiClient, daemonSetsGVR, namespace, name, func(object *unstructured.Unstructured) (
+		bool, error) {
+		daemonSet := &appsv1.DaemonSet{}
+		if object == nil {
+			return false, nil
+		}
+
+		if err := fromUnstructured(object, daemonSet); err != nil {
+			return false, err
+		}
+
+		status := daemonSet.Status
+
+		glog.V(100).Infof("DaemonSet %s/%s has %d/%d updated and %d ready pods", namespace, name,
+			status.UpdatedNumberScheduled, status.DesiredNumberScheduled, status.NumberReady)
+
+		return status.ObservedGeneration >= daemonSet.Generation &&
+			status.UpdatedNumberScheduled == status.DesiredNumberScheduled &&
+			status.NumberReady == status.DesiredNumberScheduled, nil
+	})
+}
+
+// ClusterPolicyState waits until the ClusterPolicy reports the state, e.g. nvidiagpuv1.Ready, or the context is
+// done.
+func ClusterPolicyState(ctx context.Context, apiClient *clients.Settings, name string,
+	state nvidiagpuv1.State) error {
+	return UntilObject(ctx, apiClient, clusterPoliciesGVR, "", name, func(object *unstructured.Unstructured) (
+		bool, error) {
+		if object == nil {
+			return false, nil
+		}
+
+		currentState, _, err := unstructured.NestedString(object.Object, "status", "state")
+		if err != nil {
+			return false, err
+		}
+
+		glog.V(100).Infof("ClusterPolicy %s is in %q state, waiting for %q", name, currentState, state)
+
+		return nvidiagpuv1.State(currentState) == state, nil
+	})
+}
+
+// NodeLabel waits until the node has the label, with the value unless value is empty, or the context is done.
+func NodeLabel(ctx context.Context, apiClient *clients.Settings, nodeName, key, value string) error {
+	return UntilObject(ctx, apiClient, nodesGVR, "", nodeName, func(object *unstructured.Unstructured) (
+		bool, error) {
+		if object == nil {
+			return false, nil
+		}
+
+		labelValue, found := object.GetLabels()[key]
+
+		glog.V(100).Infof("Node %s label %s is %q (set: %t), waiting for %q", nodeName, key, labelValue, found,
+			value)
+
+		return found && (value == "" || labelValue == value), nil
+	})
+}
+
+// UntilObject watches the object of the resource until the condition is met, or the context is done. The condition
+// is evaluated on the current object and on every change of the object, so short-lived states are not missed. The
+// watch is reestablished when it is closed, e.g. when the apiserver restarts.
+func UntilObject(ctx context.Context, apiClient *clients.Settings, resource schema.GroupVersionResource,
+	namespace, name string, condition Condition) error {
+	resourceClient := apiClient.Interface.Resource(resource).Namespace(namespace)
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+
+	resourceVersion := ""
+
+	for {
+		if resourceVersion == "" {
+			list, err := resourceClient.List(ctx, metav1.ListOptions{FieldSelector: fieldSelector})
+			if err != nil {
+				if err := waitBeforeRetry(ctx, resource, name, err); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			var object *unstructured.Unstructured
+			if len(list.Items) > 0 {
+				object = &list.Items[0]
+			}
+
+			if met, err := condition(object); err != nil || met {
+				return err
+			}
+
+			resourceVersion = list.GetResourceVersion()
+		}
+
+		watcher, err := resourceClient.Watch(ctx, metav1.ListOptions{
+			FieldSelector:       fieldSelector,
+			ResourceVersion:     resourceVersion,
+			AllowWatchBookmarks: true,
+		})
+		if err != nil {
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				resourceVersion = ""
+
+				continue
+			}
+
+			if err := waitBeforeRetry(ctx, resource, name, err); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		met, nextResourceVersion, err := watchUntil(ctx, watcher, resourceVersion, condition)
+		watcher.Stop()
+
+		if err != nil || met {
+			return err
+		}
+
+		resourceVersion = nextResourceVersion
+	}
+}
+
+// watchUntil evaluates the condition on the events of the watch started at the resource version until it is met,
+// the watch is closed or the context is done. It returns the resource version to resume watching from, empty when
+// the objects must be listed again.
+func watchUntil(ctx context.Context, watcher watch.Interface, resourceVersion string,
+	condition Condition) (bool, string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, "", fmt.Errorf("condition not met: %w", ctx.Err())
+		case event, open := <-watcher.ResultChan():
+			if !open {
+				return false, resourceVersion, nil
+			}
+
+			switch event.Type {
+			case watch.Error:
+				status := apierrors.FromObject(event.Object)
+				if apierrors.IsResourceExpired(status) || apierrors.IsGone(status) {
+					return false, "", nil
+				}
+
+				glog.V(100).Infof("Watch error, watching again: %v", status)
+
+				return false, resourceVersion, nil
+			case watch.Bookmark, watch.Added, watch.Modified, watch.Deleted:
+				object, isUnstructured := event.Object.(*unstructured.Unstructured)
+				if !isUnstructured {
+					continue
+				}
+
+				resourceVersion = object.GetResourceVersion()
+
+				if event.Type == watch.Bookmark {
+					continue
+				}
+
+				if event.Type == watch.Deleted {
+					object = nil
+				}
+
+				if met, err := condition(object); err != nil || met {
+					return met, resourceVersion, err
+				}
+			}
+		}
+	}
+}
+
+// waitBeforeRetry waits before listing or watching again after a retriable error, and returns the other errors
+// and the context errors.
+func waitBeforeRetry(ctx context.Context, resource schema.GroupVersionResource, name string, err error) error {
+	if !retry.IsRetriable(err) {
+		return fmt.Errorf("failed to watch %s %s: %w", resource.Resource, name, err)
+	}
+
+	glog.V(100).Infof("Failed to watch %s %s, watching again: %v", resource.Resource, name, err)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("condition not met: %w", ctx.Err())
+	case <-time.After(retry.Backoff().Duration):
+		return nil
+	}
+}
+
+func fromUnstructured(object *unstructured.Unstructured, typed runtime.Object) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, typed)
+}
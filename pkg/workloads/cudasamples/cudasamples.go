@@ -0,0 +1,467 @@
+package cudasamples
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/timeouts"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Sample is a CUDA sample binary of the cuda-samples images.
+type Sample string
+
+const (
+	// VectorAdd adds two vectors on the GPU and checks the result.
+	VectorAdd Sample = "vectorAdd"
+	// DeviceQuery reports the properties of the CUDA devices.
+	DeviceQuery Sample = "deviceQuery"
+	// BandwidthTest measures the host to device, device to host and device to device memory bandwidth.
+	BandwidthTest Sample = "bandwidthTest"
+	// NBody runs the n-body simulation benchmark.
+	NBody Sample = "nbody"
+
+	// ContainerName is the container running the CUDA sample.
+	ContainerName = "cuda-sample-ctr"
+	// AppLabel is the label key selecting the pods of a Builder.
+	AppLabel = "nvidia-ci/cuda-sample"
+	// GPUResource is the resource requested by default by the CUDA sample pods.
+	GPUResource corev1.ResourceName = "nvidia.com/gpu"
+
+	// VectorAddImage is the NVIDIA published vectorAdd image.
+	VectorAddImage = "nvcr.io/nvidia/k8s/cuda-sample:vectoradd-cuda12.5.0-ubi8"
+	// DeviceQueryImage is the NVIDIA published deviceQuery image.
+	DeviceQueryImage = "nvcr.io/nvidia/k8s/cuda-sample:devicequery-cuda12.5.0-ubi8"
+	// NBodyImage is the NVIDIA published nbody image.
+	NBodyImage = "nvcr.io/nvidia/k8s/cuda-sample:nbody-cuda12.5.0-ubi8"
+
+	samplesDir = "/cuda-samples"
+)
+
+// DefaultImages are the images of the samples when NewBuilder is given none. NVIDIA publishes no bandwidthTest
+// image, its Builder must be given an image built from the cuda-samples sources, with the binary in /cuda-samples.
+var DefaultImages = map[Sample]string{
+	VectorAdd:   VectorAddImage,
+	DeviceQuery: DeviceQueryImage,
+	NBody:       NBodyImage,
+}
+
+// defaultArgs are the arguments the samples are run with unless set with WithArgs.
+var defaultArgs = map[Sample][]string{
+	BandwidthTest: {"--memory=pinned", "--mode=quick"},
+	NBody:         {"-benchmark", "-numbodies=256000"},
+}
+
+// Builder provides struct for a Job running a CUDA sample on GPUs.
+type Builder struct {
+	// Definition of the Job. Used to create the Job object.
+	Definition *batchv1.Job
+	// Created Job object.
+	Object *batchv1.Job
+	// Used in functions that define or mutate the Job definition. errorMsg is processed before the Job is created.
+	errorMsg    string
+	apiClient   *clients.Settings
+	sample      Sample
+	gpuResource corev1.ResourceName
+}
+
+// NewBuilder creates a new instance of Builder running the sample on one GPU. An empty image selects the sample
+// image of DefaultImages.
+func NewBuilder(apiClient *clients.Settings, name, nsname string, sample Sample, image string) *Builder {
+	glog.V(100).Infof("Initializing new CUDA sample Job structure with the following params: name: %s, "+
+		"namespace: %s, sample: %s, image: %s", name, nsname, sample, image)
+
+	if image == "" {
+		image = DefaultImages[sample]
+	}
+
+	isFalse := false
+	isTrue := true
+	backoffLimit := int32(0)
+	labels := map[string]string{AppLabel: name}
+
+	builder := &Builder{
+		apiClient:   apiClient,
+		sample:      sample,
+		gpuResource: GPUResource,
+		Definition: &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: nsname,
+				Labels:    labels,
+			},
+			Spec: batchv1.JobSpec{
+				BackoffLimit: &backoffLimit,
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						RestartPolicy: corev1.RestartPolicyNever,
+						SecurityContext: &corev1.PodSecurityContext{
+							RunAsNonRoot:   &isTrue,
+							SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+						},
+						Tolerations: []corev1.Toleration{{
+							Key:      string(GPUResource),
+							Effect:   corev1.TaintEffectNoSchedule,
+							Operator: corev1.TolerationOpExists,
+						}},
+						Containers: []corev1.Container{{
+							Name:            ContainerName,
+							Image:           image,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command:         []string{samplesDir + "/" + string(sample)},
+							Args:            defaultArgs[sample],
+							SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: &isFalse,
+								Capabilities: &corev1.Capabilities{
+									Drop: []corev1.Capability{"ALL"},
+								},
+							},
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{
+									GPUResource: resource.MustParse("1"),
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the CUDA sample Job is empty")
+
+		builder.errorMsg = "CUDA sample Job 'name' cannot be empty"
+	}
+
+	if nsname == "" {
+		glog.V(100).Infof("The namespace of the CUDA sample Job is empty")
+
+		builder.errorMsg = "CUDA sample Job 'namespace' cannot be empty"
+	}
+
+	switch sample {
+	case VectorAdd, DeviceQuery, BandwidthTest, NBody:
+	default:
+		glog.V(100).Infof("The CUDA sample %q is not supported", sample)
+
+		builder.errorMsg = fmt.Sprintf("CUDA sample Job sample %q is not supported", sample)
+	}
+
+	if image == "" {
+		glog.V(100).Infof("The image of the CUDA sample Job is empty")
+
+		builder.errorMsg = fmt.Sprintf("CUDA sample Job 'image' cannot be empty, no default %s image", sample)
+	}
+
+	return builder
+}
+
+// WithGPUs sets the number of units of the GPU resource requested by the sample pod.
+func (builder *Builder) WithGPUs(gpus int) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting CUDA sample Job %s GPUs to %d", builder.Definition.Name, gpus)
+
+	if gpus < 1 {
+		builder.errorMsg = "CUDA sample Job GPUs must be at least 1"
+
+		return builder
+	}
+
+	builder.Definition.Spec.Template.Spec.Containers[0].Resources.Limits[builder.gpuResource] =
+		*resource.NewQuantity(int64(gpus), resource.DecimalSI)
+
+	return builder
+}
+
+// WithGPUResource sets the GPU resource requested by the sample pod, e.g. a MIG resource such as
+// nvidia.com/mig-1g.5gb, keeping the number of units requested.
+func (builder *Builder) WithGPUResource(resourceName corev1.ResourceName) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting CUDA sample Job %s GPU resource to %s", builder.Definition.Name, resourceName)
+
+	if resourceName == "" {
+		builder.errorMsg = "CUDA sample Job GPU resource cannot be empty"
+
+		return builder
+	}
+
+	limits := builder.Definition.Spec.Template.Spec.Containers[0].Resources.Limits
+	limits[resourceName] = limits[builder.gpuResource]
+
+	if resourceName != builder.gpuResource {
+		delete(limits, builder.gpuResource)
+	}
+
+	builder.gpuResource = resourceName
+
+	return builder
+}
+
+// WithResources sets the cpu and memory requests and limits of the sample container. The GPU resource is set with
+// WithGPUs and WithGPUResource.
+func (builder *Builder) WithResources(requests, limits corev1.ResourceList) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting CUDA sample Job %s resource requests to %v and limits to %v",
+		builder.Definition.Name, requests, limits)
+
+	resources := &builder.Definition.Spec.Template.Spec.Containers[0].Resources
+	resources.Requests = requests
+
+	for name, limit := range limits {
+		resources.Limits[name] = limit
+	}
+
+	return builder
+}
+
+// WithArgs sets the arguments of the sample binary, replacing its default arguments.
+func (builder *Builder) WithArgs(args ...string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting CUDA sample Job %s args to %v", builder.Definition.Name, args)
+
+	builder.Definition.Spec.Template.Spec.Containers[0].Args = args
+
+	return builder
+}
+
+// WithNodeSelector sets the node selector of the sample pod.
+func (builder *Builder) WithNodeSelector(nodeSelector map[string]string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting CUDA sample Job %s node selector to %v", builder.Definition.Name, nodeSelector)
+
+	builder.Definition.Spec.Template.Spec.NodeSelector = nodeSelector
+
+	return builder
+}
+
+// WithRuntimeClassName sets the runtime class of the sample pod, e.g. to run it in a kata VM.
+func (builder *Builder) WithRuntimeClassName(runtimeClassName string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting CUDA sample Job %s runtime class to %s", builder.Definition.Name, runtimeClassName)
+
+	builder.Definition.Spec.Template.Spec.RuntimeClassName = &runtimeClassName
+
+	return builder
+}
+
+// WithToleration adds a toleration to the sample pod, which tolerates the nvidia.com/gpu taint by default.
+func (builder *Builder) WithToleration(toleration corev1.Toleration) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Adding toleration %v to CUDA sample Job %s", toleration, builder.Definition.Name)
+
+	builder.Definition.Spec.Template.Spec.Tolerations = append(builder.Definition.Spec.Template.Spec.Tolerations,
+		toleration)
+
+	return builder
+}
+
+// WithServiceAccount sets the service account of the sample pod, e.g. one allowed to use a custom SELinux context.
+func (builder *Builder) WithServiceAccount(serviceAccountName string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting CUDA sample Job %s service account to %s", builder.Definition.Name,
+		serviceAccountName)
+
+	builder.Definition.Spec.Template.Spec.ServiceAccountName = serviceAccountName
+
+	return builder
+}
+
+// WithSELinuxOptions sets the SELinux context the sample pod runs with.
+func (builder *Builder) WithSELinuxOptions(seLinuxOptions *corev1.SELinuxOptions) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting CUDA sample Job %s SELinux options to %v", builder.Definition.Name, seLinuxOptions)
+
+	if seLinuxOptions == nil {
+		builder.errorMsg = "CUDA sample Job SELinux options cannot be nil"
+
+		return builder
+	}
+
+	builder.Definition.Spec.Template.Spec.SecurityContext.SELinuxOptions = seLinuxOptions
+
+	return builder
+}
+
+// Create makes the CUDA sample Job in the cluster and stores the created object in the builder.
+func (builder *Builder) Create() (*Builder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating CUDA sample %s Job %s in namespace %s", builder.sample, builder.Definition.Name,
+		builder.Definition.Namespace)
+
+	job := builder.Definition.DeepCopy()
+	proxy.Inject(builder.apiClient, &job.Spec.Template.Spec)
+	owner.Label(job)
+
+	var err error
+
+	builder.Object, err = builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Create(
+		context.TODO(), job, metav1.CreateOptions{})
+	if err != nil {
+		return builder, fmt.Errorf("failed to create CUDA sample Job %s: %w", builder.Definition.Name, err)
+	}
+
+	return builder, nil
+}
+
+// WaitUntilComplete waits until the sample pod has completed, and returns an error if it failed.
+func (builder *Builder) WaitUntilComplete(timeout time.Duration) error {
+	timeout = timeouts.Scale(timeouts.WorkloadCompletion, timeout)
+
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Waiting for CUDA sample Job %s to complete", builder.Definition.Name)
+
+	return wait.PollUntilContextTimeout(
+		context.TODO(), 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+			job, err := builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Get(ctx,
+				builder.Definition.Name, metav1.GetOptions{})
+			if err != nil {
+				glog.V(100).Infof("Failed to get CUDA sample Job %s: %v", builder.Definition.Name, err)
+
+				return false, nil
+			}
+
+			builder.Object = job
+
+			if job.Status.Failed > 0 {
+				return false, fmt.Errorf("CUDA sample Job %s has %d failed pods", builder.Definition.Name,
+					job.Status.Failed)
+			}
+
+			return job.Status.Succeeded > 0, nil
+		})
+}
+
+// GetResult returns the result of the sample, parsed from the log of the sample pod.
+func (builder *Builder) GetResult() (*Result, error) {
+	if valid, err := builder.validate(); !valid {
+		return nil, err
+	}
+
+	samplePods, err := pod.List(builder.apiClient, builder.Definition.Namespace, metav1.ListOptions{
+		LabelSelector: "job-name=" + builder.Definition.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CUDA sample Job %s pods: %w", builder.Definition.Name, err)
+	}
+
+	if len(samplePods) == 0 {
+		return nil, fmt.Errorf("no pod found for CUDA sample Job %s", builder.Definition.Name)
+	}
+
+	output, err := samplePods[0].GetFullLog(ContainerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod %s log: %w", samplePods[0].Object.Name, err)
+	}
+
+	result, err := Parse(builder.sample, output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pod %s log: %w", samplePods[0].Object.Name, err)
+	}
+
+	result.PodName = samplePods[0].Object.Name
+	result.NodeName = samplePods[0].Object.Spec.NodeName
+
+	return result, nil
+}
+
+// Delete removes the CUDA sample Job and its pod from the cluster.
+func (builder *Builder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting CUDA sample Job %s in namespace %s", builder.Definition.Name,
+		builder.Definition.Namespace)
+
+	propagation := metav1.DeletePropagationForeground
+
+	err := builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Delete(context.TODO(),
+		builder.Definition.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete CUDA sample Job %s: %w", builder.Definition.Name, err)
+	}
+
+	builder.Object = nil
+
+	return nil
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *Builder) validate() (bool, error) {
+	resourceCRD := "CUDA sample Job"
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,219 @@
+package cudasamples
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	deviceCountRegexp       = regexp.MustCompile(`Detected (\d+) CUDA Capable device`)
+	deviceRegexp            = regexp.MustCompile(`^Device (\d+): "(.*)"`)
+	computeCapabilityRegexp = regexp.MustCompile(`CUDA Capability Major/Minor version number:\s*([0-9.]+)`)
+	globalMemoryRegexp      = regexp.MustCompile(`Total amount of global memory:\s*(\d+) MBytes`)
+	bandwidthRegexp         = regexp.MustCompile(`^\d+\s+([0-9.]+)$`)
+	interactionsRegexp      = regexp.MustCompile(`=\s*([0-9.]+) billion interactions per second`)
+	gflopsRegexp            = regexp.MustCompile(`=\s*([0-9.]+) (?:single|double)-precision GFLOP/s`)
+)
+
+// Result is the result of a CUDA sample run.
+type Result struct {
+	Sample   Sample
+	PodName  string
+	NodeName string
+	// Passed is true when the sample reported success, "Test PASSED" or "Result = PASS", or, for nbody that reports
+	// no verdict, a benchmark result.
+	Passed bool
+	// Devices are the CUDA devices reported by deviceQuery.
+	Devices []Device
+	// Bandwidth is the memory bandwidth measured by bandwidthTest.
+	Bandwidth *Bandwidth
+	// NBody is the benchmark result of nbody.
+	NBody *NBodyBenchmark
+}
+
+// Device is a CUDA device reported by deviceQuery.
+type Device struct {
+	Index             int
+	Name              string
+	ComputeCapability string
+	GlobalMemoryMB    int
+}
+
+// Bandwidth is the memory bandwidth in GB/s measured by bandwidthTest for the largest transfer size.
+type Bandwidth struct {
+	HostToDevice   float64
+	DeviceToHost   float64
+	DeviceToDevice float64
+}
+
+// NBodyBenchmark is the nbody benchmark result.
+type NBodyBenchmark struct {
+	BillionInteractionsPerSecond float64
+	GFLOPS                       float64
+}
+
+// Parse parses the log of a CUDA sample pod.
+func Parse(sample Sample, output string) (*Result, error) {
+	switch sample {
+	case VectorAdd:
+		return ParseVectorAdd(output)
+	case DeviceQuery:
+		return ParseDeviceQuery(output)
+	case BandwidthTest:
+		return ParseBandwidthTest(output)
+	case NBody:
+		return ParseNBody(output)
+	default:
+		return nil, fmt.Errorf("CUDA sample %q is not supported", sample)
+	}
+}
+
+// ParseVectorAdd parses the log of vectorAdd.
+func ParseVectorAdd(output string) (*Result, error) {
+	return &Result{
+		Sample: VectorAdd,
+		Passed: strings.Contains(output, "Test PASSED"),
+	}, nil
+}
+
+// ParseDeviceQuery parses the devices reported by deviceQuery, and returns an error when their number differs
+// from the detected device count.
+func ParseDeviceQuery(output string) (*Result, error) {
+	result := &Result{
+		Sample: DeviceQuery,
+		Passed: strings.Contains(output, "Result = PASS"),
+	}
+
+	match := deviceCountRegexp.FindStringSubmatch(output)
+	if match == nil {
+		if result.Passed {
+			return nil, errors.New("no CUDA device count found")
+		}
+
+		return result, nil
+	}
+
+	deviceCount, _ := strconv.Atoi(match[1])
+
+	var device *Device
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if match := deviceRegexp.FindStringSubmatch(line); match != nil {
+			index, _ := strconv.Atoi(match[1])
+			result.Devices = append(result.Devices, Device{Index: index, Name: match[2]})
+			device = &result.Devices[len(result.Devices)-1]
+
+			continue
+		}
+
+		if device == nil {
+			continue
+		}
+
+		if match := computeCapabilityRegexp.FindStringSubmatch(line); match != nil {
+			device.ComputeCapability = match[1]
+		}
+
+		if match := globalMemoryRegexp.FindStringSubmatch(line); match != nil {
+			device.GlobalMemoryMB, _ = strconv.Atoi(match[1])
+		}
+	}
+
+	if len(result.Devices) != deviceCount {
+		return nil, fmt.Errorf("deviceQuery detected %d CUDA devices, but reported %d", deviceCount,
+			len(result.Devices))
+	}
+
+	return result, nil
+}
+
+// ParseBandwidthTest parses the bandwidth measured by bandwidthTest, converted to GB/s from the MB/s reported by the
+// older cuda-samples releases.
+func ParseBandwidthTest(output string) (*Result, error) {
+	result := &Result{
+		Sample:    BandwidthTest,
+		Passed:    strings.Contains(output, "Result = PASS"),
+		Bandwidth: &Bandwidth{},
+	}
+
+	var (
+		current *float64
+		scale   = 1.0
+		found   int
+	)
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "Host to Device Bandwidth"):
+			current = &result.Bandwidth.HostToDevice
+		case strings.HasPrefix(line, "Device to Host Bandwidth"):
+			current = &result.Bandwidth.DeviceToHost
+		case strings.HasPrefix(line, "Device to Device Bandwidth"):
+			current = &result.Bandwidth.DeviceToDevice
+		case strings.Contains(line, "Bandwidth(MB/s)"):
+			scale = 1.0 / 1000
+		case strings.Contains(line, "Bandwidth(GB/s)"):
+			scale = 1.0
+		case current != nil:
+			match := bandwidthRegexp.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+
+			bandwidth, err := strconv.ParseFloat(match[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bandwidth in %q: %w", line, err)
+			}
+
+			// The transfer sizes are listed in increasing order, the last one is the largest
+			if *current == 0 {
+				found++
+			}
+
+			*current = bandwidth * scale
+		}
+	}
+
+	if result.Passed && found != 3 {
+		return nil, fmt.Errorf("bandwidthTest passed, but %d of the 3 bandwidths were found", found)
+	}
+
+	return result, nil
+}
+
+// ParseNBody parses the benchmark result of nbody -benchmark.
+func ParseNBody(output string) (*Result, error) {
+	result := &Result{Sample: NBody}
+
+	interactions := interactionsRegexp.FindStringSubmatch(output)
+	gflops := gflopsRegexp.FindStringSubmatch(output)
+
+	if interactions == nil || gflops == nil {
+		return result, nil
+	}
+
+	result.NBody = &NBodyBenchmark{}
+
+	var err error
+
+	result.NBody.BillionInteractionsPerSecond, err = strconv.ParseFloat(interactions[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nbody interactions per second %q: %w", interactions[1], err)
+	}
+
+	result.NBody.GFLOPS, err = strconv.ParseFloat(gflops[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nbody GFLOP/s %q: %w", gflops[1], err)
+	}
+
+	result.Passed = true
+
+	return result, nil
+}
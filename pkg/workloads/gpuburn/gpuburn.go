@@ -0,0 +1,494 @@
+package gpuburn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/arch"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/timeouts"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// ContainerName is the container running gpu-burn and sampling the GPU temperature and throttling.
+	ContainerName = "gpu-burn-ctr"
+	// AppLabel is the label key selecting the pods of a Builder.
+	AppLabel = "nvidia-ci/gpu-burn"
+
+	statsPrefix    = "GPU-STATS"
+	gpuResource    = "nvidia.com/gpu"
+	sampleInterval = 10
+)
+
+// Images are the gpu-burn images by architecture.
+var Images = arch.Images{
+	arch.AMD64: "quay.io/wabouham/gpu_burn_amd64:ubi9",
+	arch.ARM64: "quay.io/wabouham/gpu_burn_arm64:ubi9",
+}
+
+// throttleReasons are the nvidia-smi clock throttle reasons sampled during the burn, reported when active.
+var throttleReasons = []string{
+	"clocks_throttle_reasons.hw_slowdown",
+	"clocks_throttle_reasons.hw_thermal_slowdown",
+	"clocks_throttle_reasons.hw_power_brake_slowdown",
+	"clocks_throttle_reasons.sw_thermal_slowdown",
+}
+
+// GPUStats is the result of gpu-burn for a GPU and the GPU state sampled during the burn.
+type GPUStats struct {
+	Index            string
+	MaxTemperature   int
+	ActiveThrottling []string
+	Result           string
+}
+
+// Report is the gpu-burn result of the GPUs of a node.
+type Report struct {
+	NodeName string
+	GPUs     []*GPUStats
+}
+
+// Builder provides struct for a gpu-burn Job running one pod per GPU node, each pod burning all the GPUs of its node.
+type Builder struct {
+	// Definition of the Job. Used to create the Job object.
+	Definition *batchv1.Job
+	// Created Job object.
+	Object *batchv1.Job
+	// Used in functions that define or mutate the Job definition. errorMsg is processed before the Job is created.
+	errorMsg  string
+	apiClient *clients.Settings
+	duration  time.Duration
+}
+
+// NewBuilder creates a new instance of Builder burning a single GPU of a single node for 5 minutes.
+func NewBuilder(apiClient *clients.Settings, name, nsname, image string) *Builder {
+	glog.V(100).Infof("Initializing new gpu-burn Job structure with the following params: name: %s, "+
+		"namespace: %s, image: %s", name, nsname, image)
+
+	isFalse := false
+	isTrue := true
+	one := int32(1)
+	backoffLimit := int32(0)
+	labels := map[string]string{AppLabel: name}
+
+	builder := &Builder{
+		apiClient: apiClient,
+		duration:  5 * time.Minute,
+		Definition: &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: nsname,
+				Labels:    labels,
+			},
+			Spec: batchv1.JobSpec{
+				Parallelism:  &one,
+				Completions:  &one,
+				BackoffLimit: &backoffLimit,
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						RestartPolicy: corev1.RestartPolicyNever,
+						SecurityContext: &corev1.PodSecurityContext{
+							RunAsNonRoot:   &isTrue,
+							SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+						},
+						Tolerations: []corev1.Toleration{{
+							Key:      gpuResource,
+							Effect:   corev1.TaintEffectNoSchedule,
+							Operator: corev1.TolerationOpExists,
+						}},
+						// A single gpu-burn pod per node, so that each pod burns all of its node GPUs
+						Affinity: &corev1.Affinity{
+							PodAntiAffinity: &corev1.PodAntiAffinity{
+								RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+									LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+									TopologyKey:   corev1.LabelHostname,
+								}},
+							},
+						},
+						Containers: []corev1.Container{{
+							Name:            ContainerName,
+							Image:           image,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: &isFalse,
+								Capabilities: &corev1.Capabilities{
+									Drop: []corev1.Capability{"ALL"},
+								},
+							},
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{
+									gpuResource: resource.MustParse("1"),
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the gpu-burn Job is empty")
+
+		builder.errorMsg = "gpu-burn Job 'name' cannot be empty"
+	}
+
+	if nsname == "" {
+		glog.V(100).Infof("The namespace of the gpu-burn Job is empty")
+
+		builder.errorMsg = "gpu-burn Job 'namespace' cannot be empty"
+	}
+
+	if image == "" {
+		glog.V(100).Infof("The image of the gpu-burn Job is empty")
+
+		builder.errorMsg = "gpu-burn Job 'image' cannot be empty"
+	}
+
+	return builder
+}
+
+// NewAcrossNodes creates a new instance of Builder burning in parallel, one pod per node, as many GPUs of each of the
+// GPU nodes as GPUsPerNode returns.
+func NewAcrossNodes(apiClient *clients.Settings, name, nsname, image string, gpuNodes []*nodes.Builder) *Builder {
+	return NewBuilder(apiClient, name, nsname, image).
+		WithNodeCount(len(gpuNodes)).
+		WithGPUsPerNode(GPUsPerNode(gpuNodes))
+}
+
+// GPUsPerNode returns the GPU count of the GPU node with the fewest GPUs, a Job having a single pod template so that
+// every node burns as many GPUs as the smallest node has. It returns 0 when a node does not report its GPU count.
+func GPUsPerNode(gpuNodes []*nodes.Builder) int {
+	if len(gpuNodes) == 0 {
+		return 0
+	}
+
+	gpusPerNode := get.GPUCount(gpuNodes[0])
+	for _, node := range gpuNodes[1:] {
+		gpusPerNode = min(gpusPerNode, get.GPUCount(node))
+	}
+
+	return gpusPerNode
+}
+
+// WithDuration sets how long gpu-burn loads the GPUs, rounded to the second.
+func (builder *Builder) WithDuration(duration time.Duration) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting gpu-burn Job %s duration to %s", builder.Definition.Name, duration)
+
+	if duration < time.Second {
+		builder.errorMsg = "gpu-burn Job duration must be at least 1s"
+
+		return builder
+	}
+
+	builder.duration = duration
+
+	return builder
+}
+
+// WithNodeCount sets the number of GPU nodes burnt in parallel, one pod per node.
+func (builder *Builder) WithNodeCount(nodeCount int) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting gpu-burn Job %s node count to %d", builder.Definition.Name, nodeCount)
+
+	if nodeCount < 1 {
+		builder.errorMsg = "gpu-burn Job node count must be at least 1"
+
+		return builder
+	}
+
+	count := int32(nodeCount)
+	builder.Definition.Spec.Parallelism = &count
+	builder.Definition.Spec.Completions = &count
+
+	return builder
+}
+
+// WithGPUsPerNode sets the number of GPUs requested, and burnt, by every pod.
+func (builder *Builder) WithGPUsPerNode(gpusPerNode int) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting gpu-burn Job %s GPUs per node to %d", builder.Definition.Name, gpusPerNode)
+
+	if gpusPerNode < 1 {
+		builder.errorMsg = "gpu-burn Job GPUs per node must be at least 1"
+
+		return builder
+	}
+
+	builder.Definition.Spec.Template.Spec.Containers[0].Resources.Limits[gpuResource] =
+		*resource.NewQuantity(int64(gpusPerNode), resource.DecimalSI)
+
+	return builder
+}
+
+// WithNodeSelector sets the node selector of the gpu-burn pods.
+func (builder *Builder) WithNodeSelector(nodeSelector map[string]string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting gpu-burn Job %s node selector to %v", builder.Definition.Name, nodeSelector)
+
+	builder.Definition.Spec.Template.Spec.NodeSelector = nodeSelector
+
+	return builder
+}
+
+// Create makes the gpu-burn Job in the cluster and stores the created object in the builder.
+func (builder *Builder) Create() (*Builder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating gpu-burn Job %s in namespace %s for %s", builder.Definition.Name,
+		builder.Definition.Namespace, builder.duration)
+
+	job := builder.Definition.DeepCopy()
+	job.Spec.Template.Spec.Containers[0].Command = []string{"/bin/bash", "-c", builder.script()}
+	proxy.Inject(builder.apiClient, &job.Spec.Template.Spec)
+	owner.Label(job)
+
+	var err error
+
+	builder.Object, err = builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Create(
+		context.TODO(), job, metav1.CreateOptions{})
+	if err != nil {
+		return builder, fmt.Errorf("failed to create gpu-burn Job %s: %w", builder.Definition.Name, err)
+	}
+
+	return builder, nil
+}
+
+// WaitUntilComplete waits until all the gpu-burn pods have completed, and returns an error if any of them failed.
+func (builder *Builder) WaitUntilComplete(timeout time.Duration) error {
+	timeout = timeouts.Scale(timeouts.WorkloadCompletion, timeout)
+
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Waiting for gpu-burn Job %s to complete", builder.Definition.Name)
+
+	return wait.PollUntilContextTimeout(
+		context.TODO(), 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+			job, err := builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Get(ctx,
+				builder.Definition.Name, metav1.GetOptions{})
+			if err != nil {
+				glog.V(100).Infof("Failed to get gpu-burn Job %s: %v", builder.Definition.Name, err)
+
+				return false, nil
+			}
+
+			builder.Object = job
+
+			if job.Status.Failed > 0 {
+				return false, fmt.Errorf("gpu-burn Job %s has %d failed pods", builder.Definition.Name,
+					job.Status.Failed)
+			}
+
+			return job.Status.Succeeded >= *job.Spec.Completions, nil
+		})
+}
+
+// GetReports returns the gpu-burn report of every node a gpu-burn pod ran on, parsed from the pod logs.
+func (builder *Builder) GetReports() ([]*Report, error) {
+	if valid, err := builder.validate(); !valid {
+		return nil, err
+	}
+
+	burnPods, err := pod.List(builder.apiClient, builder.Definition.Namespace, metav1.ListOptions{
+		LabelSelector: "job-name=" + builder.Definition.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gpu-burn Job %s pods: %w", builder.Definition.Name, err)
+	}
+
+	if len(burnPods) == 0 {
+		return nil, fmt.Errorf("no pod found for gpu-burn Job %s", builder.Definition.Name)
+	}
+
+	var reports []*Report
+
+	for _, burnPod := range burnPods {
+		output, err := burnPod.GetFullLog(ContainerName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pod %s log: %w", burnPod.Object.Name, err)
+		}
+
+		report, err := ParseReport(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pod %s log: %w", burnPod.Object.Name, err)
+		}
+
+		report.NodeName = burnPod.Object.Spec.NodeName
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// Delete removes the gpu-burn Job and its pods from the cluster.
+func (builder *Builder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting gpu-burn Job %s in namespace %s", builder.Definition.Name,
+		builder.Definition.Namespace)
+
+	propagation := metav1.DeletePropagationForeground
+
+	err := builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Delete(context.TODO(),
+		builder.Definition.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete gpu-burn Job %s: %w", builder.Definition.Name, err)
+	}
+
+	builder.Object = nil
+
+	return nil
+}
+
+// ParseReport parses the log of a gpu-burn pod: the GPU samples taken during the burn and the gpu-burn verdict of
+// every GPU, "OK" or "FAULTY".
+func ParseReport(output string) (*Report, error) {
+	gpus := map[string]*GPUStats{}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, statsPrefix):
+			fieldValues := strings.Split(strings.TrimPrefix(line, statsPrefix), ",")
+			if len(fieldValues) != 2+len(throttleReasons) {
+				return nil, fmt.Errorf("unexpected GPU sample %q", line)
+			}
+
+			for i := range fieldValues {
+				fieldValues[i] = strings.TrimSpace(fieldValues[i])
+			}
+
+			stats := gpuStats(gpus, fieldValues[0])
+
+			temperature, err := strconv.Atoi(fieldValues[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid GPU temperature in sample %q: %w", line, err)
+			}
+
+			stats.MaxTemperature = max(stats.MaxTemperature, temperature)
+
+			for i, reason := range throttleReasons {
+				if fieldValues[2+i] == "Active" && !slices.Contains(stats.ActiveThrottling, reason) {
+					stats.ActiveThrottling = append(stats.ActiveThrottling, reason)
+				}
+			}
+		case strings.HasPrefix(line, "GPU ") && (strings.HasSuffix(line, ": OK") ||
+			strings.Contains(line, ": FAULTY")):
+			index, result, _ := strings.Cut(strings.TrimPrefix(line, "GPU "), ":")
+			gpuStats(gpus, strings.TrimSpace(index)).Result = strings.Fields(result)[0]
+		}
+	}
+
+	if len(gpus) == 0 {
+		return nil, errors.New("no GPU sample nor gpu-burn result found")
+	}
+
+	report := &Report{}
+	for _, stats := range gpus {
+		report.GPUs = append(report.GPUs, stats)
+	}
+
+	sort.Slice(report.GPUs, func(i, j int) bool {
+		left, _ := strconv.Atoi(report.GPUs[i].Index)
+		right, _ := strconv.Atoi(report.GPUs[j].Index)
+
+		return left < right
+	})
+
+	return report, nil
+}
+
+// script samples the GPU temperature and throttle reasons in the background while gpu-burn runs.
+func (builder *Builder) script() string {
+	return fmt.Sprintf(`if [ "$(nvidia-smi -L | wc -l)" -eq 0 ]; then
+  echo "ERROR No GPUs found"
+  exit 1
+fi
+nvidia-smi --query-gpu=index,temperature.gpu,%s --format=csv,noheader,nounits -l %d | sed -u 's/^/%s /' &
+sampler=$!
+./gpu_burn %d
+result=$?
+kill $sampler
+exit $result
+`, strings.Join(throttleReasons, ","), sampleInterval, statsPrefix, int(builder.duration.Seconds()))
+}
+
+func gpuStats(gpus map[string]*GPUStats, index string) *GPUStats {
+	if gpus[index] == nil {
+		gpus[index] = &GPUStats{Index: index}
+	}
+
+	return gpus[index]
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *Builder) validate() (bool, error) {
+	resourceCRD := "gpu-burn Job"
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,582 @@
+package nccl
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/timeouts"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// LauncherContainerName is the container running all_reduce_perf, or mpirun in multi-node mode.
+	LauncherContainerName = "nccl-launcher-ctr"
+	// WorkerContainerName is the container running the sshd the launcher starts the remote ranks through.
+	WorkerContainerName = "nccl-worker-ctr"
+	// AppLabel is the label key selecting the launcher and worker pods of a Builder.
+	AppLabel = "nvidia-ci/nccl-tests"
+
+	sshPort      = 2222
+	sshMountPath = "/etc/nccl-ssh"
+	sshKeyName   = "id_rsa"
+	gpuResource  = "nvidia.com/gpu"
+)
+
+var (
+	busBandwidthRegexp = regexp.MustCompile(`#\s*Avg bus bandwidth\s*:\s*([0-9.]+)`)
+	rankRegexp         = regexp.MustCompile(`#\s*Rank\s+(\d+)\s+Group\s+\d+\s+Pid\s+\d+\s+on\s+(\S+)\s+device`)
+)
+
+// Builder provides struct for an nccl-tests Job running all_reduce_perf on one node with several GPUs, or across
+// several nodes through mpirun on the host network.
+type Builder struct {
+	// Definition of the launcher Job. Used to create the launcher Job object.
+	Definition *batchv1.Job
+	// Created launcher Job object.
+	Object *batchv1.Job
+	// Used in functions that define or mutate the Job definition. errorMsg is processed before the Job is created.
+	errorMsg    string
+	apiClient   *clients.Settings
+	nodeCount   int
+	gpusPerNode int
+	testArgs    []string
+	env         []corev1.EnvVar
+}
+
+// NewBuilder creates a new instance of Builder running all_reduce_perf on a single GPU of a single node.
+func NewBuilder(apiClient *clients.Settings, name, nsname, image string) *Builder {
+	glog.V(100).Infof("Initializing new nccl-tests Job structure with the following params: name: %s, "+
+		"namespace: %s, image: %s", name, nsname, image)
+
+	backoffLimit := int32(0)
+	labels := map[string]string{AppLabel: name}
+
+	builder := &Builder{
+		apiClient:   apiClient,
+		nodeCount:   1,
+		gpusPerNode: 1,
+		testArgs:    []string{"-b", "8", "-e", "1G", "-f", "2"},
+		Definition: &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: nsname,
+				Labels:    labels,
+			},
+			Spec: batchv1.JobSpec{
+				BackoffLimit: &backoffLimit,
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						RestartPolicy: corev1.RestartPolicyNever,
+						Tolerations: []corev1.Toleration{{
+							Key:      gpuResource,
+							Effect:   corev1.TaintEffectNoSchedule,
+							Operator: corev1.TolerationOpExists,
+						}},
+						Containers: []corev1.Container{{
+							Name:            LauncherContainerName,
+							Image:           image,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the nccl-tests Job is empty")
+
+		builder.errorMsg = "nccl-tests Job 'name' cannot be empty"
+	}
+
+	if nsname == "" {
+		glog.V(100).Infof("The namespace of the nccl-tests Job is empty")
+
+		builder.errorMsg = "nccl-tests Job 'namespace' cannot be empty"
+	}
+
+	if image == "" {
+		glog.V(100).Infof("The image of the nccl-tests Job is empty")
+
+		builder.errorMsg = "nccl-tests Job 'image' cannot be empty"
+	}
+
+	return builder
+}
+
+// WithGPUsPerNode sets the number of GPUs used on every node, one all-reduce rank per GPU.
+func (builder *Builder) WithGPUsPerNode(gpusPerNode int) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting nccl-tests Job %s GPUs per node to %d", builder.Definition.Name, gpusPerNode)
+
+	if gpusPerNode < 1 {
+		builder.errorMsg = "nccl-tests Job GPUs per node must be at least 1"
+
+		return builder
+	}
+
+	builder.gpusPerNode = gpusPerNode
+
+	return builder
+}
+
+// WithNodeCount sets the number of nodes the all-reduce spans. With more than one node, the launcher and the
+// worker pods run on the host network, one per node, and the launcher starts the remote ranks with mpirun over ssh.
+func (builder *Builder) WithNodeCount(nodeCount int) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting nccl-tests Job %s node count to %d", builder.Definition.Name, nodeCount)
+
+	if nodeCount < 1 {
+		builder.errorMsg = "nccl-tests Job node count must be at least 1"
+
+		return builder
+	}
+
+	builder.nodeCount = nodeCount
+
+	return builder
+}
+
+// WithNodeSelector sets the node selector of the launcher and worker pods.
+func (builder *Builder) WithNodeSelector(nodeSelector map[string]string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting nccl-tests Job %s node selector to %v", builder.Definition.Name, nodeSelector)
+
+	builder.Definition.Spec.Template.Spec.NodeSelector = nodeSelector
+
+	return builder
+}
+
+// WithServiceAccount sets the service account of the launcher and worker pods. Multi-node runs use the host
+// network and need a service account allowed to run privileged pods.
+func (builder *Builder) WithServiceAccount(serviceAccountName string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting nccl-tests Job %s service account to %s", builder.Definition.Name,
+		serviceAccountName)
+
+	builder.Definition.Spec.Template.Spec.ServiceAccountName = serviceAccountName
+
+	return builder
+}
+
+// WithResource requests an additional extended resource for every pod, e.g. an RDMA shared device.
+func (builder *Builder) WithResource(resourceName string, count int64) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Requesting %d %s for the nccl-tests Job %s pods", count, resourceName,
+		builder.Definition.Name)
+
+	container := &builder.Definition.Spec.Template.Spec.Containers[0]
+	if container.Resources.Limits == nil {
+		container.Resources.Limits = corev1.ResourceList{}
+	}
+
+	container.Resources.Limits[corev1.ResourceName(resourceName)] = *resource.NewQuantity(count, resource.DecimalSI)
+
+	return builder
+}
+
+// WithEnv sets an environment variable of the all-reduce ranks, e.g. NCCL_IB_HCA or NCCL_DEBUG.
+func (builder *Builder) WithEnv(name, value string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting nccl-tests Job %s environment variable %s=%s", builder.Definition.Name, name, value)
+
+	builder.env = append(builder.env, corev1.EnvVar{Name: name, Value: value})
+
+	return builder
+}
+
+// WithTestArgs replaces the all_reduce_perf message size arguments, "-b 8 -e 1G -f 2" by default.
+func (builder *Builder) WithTestArgs(args ...string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting nccl-tests Job %s all_reduce_perf arguments to %v", builder.Definition.Name, args)
+
+	builder.testArgs = args
+
+	return builder
+}
+
+// Create makes the nccl-tests Job in the cluster, with its worker Job, service and ssh key secret in multi-node
+// mode, and stores the created launcher Job in the builder.
+func (builder *Builder) Create() (*Builder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating nccl-tests Job %s in namespace %s over %d node(s) with %d GPU(s) each",
+		builder.Definition.Name, builder.Definition.Namespace, builder.nodeCount, builder.gpusPerNode)
+
+	launcher := builder.Definition.DeepCopy()
+	launcherSpec := &launcher.Spec.Template.Spec
+	launcherSpec.Containers[0].Env = append(launcherSpec.Containers[0].Env, builder.env...)
+	setGPULimit(&launcherSpec.Containers[0], builder.gpusPerNode)
+
+	if builder.nodeCount == 1 {
+		launcherSpec.Containers[0].Command = []string{"all_reduce_perf"}
+		launcherSpec.Containers[0].Args = append(append([]string{}, builder.testArgs...), "-g",
+			strconv.Itoa(builder.gpusPerNode))
+	} else {
+		if err := builder.createWorkers(); err != nil {
+			return builder, err
+		}
+
+		configureMultiNodePod(launcherSpec, builder.Definition.Name, builder.sshSecretName())
+		launcherSpec.Containers[0].Command = []string{"/bin/bash", "-c", builder.launcherScript()}
+	}
+
+	proxy.Inject(builder.apiClient, launcherSpec)
+	owner.Label(launcher)
+
+	var err error
+
+	builder.Object, err = builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Create(
+		context.TODO(), launcher, metav1.CreateOptions{})
+	if err != nil {
+		return builder, fmt.Errorf("failed to create nccl-tests Job %s: %w", builder.Definition.Name, err)
+	}
+
+	return builder, nil
+}
+
+// WaitUntilComplete waits until the launcher Job succeeds, or returns an error as soon as it fails.
+func (builder *Builder) WaitUntilComplete(timeout time.Duration) error {
+	timeout = timeouts.Scale(timeouts.WorkloadCompletion, timeout)
+
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Waiting for nccl-tests Job %s to complete", builder.Definition.Name)
+
+	return wait.PollUntilContextTimeout(
+		context.TODO(), 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+			job, err := builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Get(ctx,
+				builder.Definition.Name, metav1.GetOptions{})
+			if err != nil {
+				glog.V(100).Infof("Failed to get nccl-tests Job %s: %v", builder.Definition.Name, err)
+
+				return false, nil
+			}
+
+			builder.Object = job
+
+			if job.Status.Failed > 0 {
+				return false, fmt.Errorf("nccl-tests Job %s failed", builder.Definition.Name)
+			}
+
+			return job.Status.Succeeded > 0, nil
+		})
+}
+
+// GetLog returns the log of the launcher pod, holding the all_reduce_perf results.
+func (builder *Builder) GetLog() (string, error) {
+	if valid, err := builder.validate(); !valid {
+		return "", err
+	}
+
+	launcherPods, err := pod.List(builder.apiClient, builder.Definition.Namespace, metav1.ListOptions{
+		LabelSelector: "job-name=" + builder.Definition.Name,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list nccl-tests Job %s pods: %w", builder.Definition.Name, err)
+	}
+
+	if len(launcherPods) == 0 {
+		return "", fmt.Errorf("no pod found for nccl-tests Job %s", builder.Definition.Name)
+	}
+
+	return launcherPods[0].GetFullLog(LauncherContainerName)
+}
+
+// Delete removes the launcher Job and, in multi-node mode, the worker Job, service and ssh key secret.
+func (builder *Builder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting nccl-tests Job %s in namespace %s", builder.Definition.Name,
+		builder.Definition.Namespace)
+
+	nsname := builder.Definition.Namespace
+	propagation := metav1.DeletePropagationForeground
+	deleteOptions := metav1.DeleteOptions{PropagationPolicy: &propagation}
+
+	var errs []error
+
+	for _, jobName := range []string{builder.Definition.Name, builder.workerName()} {
+		err := builder.apiClient.K8sClient.BatchV1().Jobs(nsname).Delete(context.TODO(), jobName, deleteOptions)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete Job %s: %w", jobName, err))
+		}
+	}
+
+	err := builder.apiClient.Services(nsname).Delete(context.TODO(), builder.workerName(), metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		errs = append(errs, fmt.Errorf("failed to delete service %s: %w", builder.workerName(), err))
+	}
+
+	err = builder.apiClient.Secrets(nsname).Delete(context.TODO(), builder.sshSecretName(), metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		errs = append(errs, fmt.Errorf("failed to delete secret %s: %w", builder.sshSecretName(), err))
+	}
+
+	builder.Object = nil
+
+	return errors.Join(errs...)
+}
+
+// ParseBusBandwidth returns the average bus bandwidth, in GB/s, reported by all_reduce_perf.
+func ParseBusBandwidth(output string) (float64, error) {
+	match := busBandwidthRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("no average bus bandwidth found in the all_reduce_perf output")
+	}
+
+	busBandwidth, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid average bus bandwidth %s: %w", match[1], err)
+	}
+
+	return busBandwidth, nil
+}
+
+// ParseRankHosts returns the hosts the all_reduce_perf ranks ran on, by rank.
+func ParseRankHosts(output string) map[int]string {
+	hosts := map[int]string{}
+
+	for _, match := range rankRegexp.FindAllStringSubmatch(output, -1) {
+		rank, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+
+		hosts[rank] = match[2]
+	}
+
+	return hosts
+}
+
+// createWorkers creates the ssh key secret shared by all the pods, the worker Job running one sshd pod per remote
+// node and the headless service the launcher resolves the worker addresses through.
+func (builder *Builder) createWorkers() error {
+	nsname := builder.Definition.Namespace
+	workerName := builder.workerName()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 3072)
+	if err != nil {
+		return fmt.Errorf("failed to generate the nccl-tests ssh key: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: builder.sshSecretName(), Namespace: nsname},
+		Data: map[string][]byte{
+			sshKeyName: pem.EncodeToMemory(&pem.Block{
+				Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+			}),
+		},
+	}
+
+	if _, err := builder.apiClient.Secrets(nsname).Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create secret %s: %w", secret.Name, err)
+	}
+
+	workerLabels := map[string]string{AppLabel: builder.Definition.Name, "role": "worker"}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: workerName, Namespace: nsname},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  workerLabels,
+			Ports:     []corev1.ServicePort{{Name: "ssh", Port: sshPort}},
+		},
+	}
+
+	if _, err := builder.apiClient.Services(nsname).Create(context.TODO(), service,
+		metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create service %s: %w", workerName, err)
+	}
+
+	workerCount := int32(builder.nodeCount - 1)
+	worker := builder.Definition.DeepCopy()
+	worker.Name = workerName
+	worker.Spec.Parallelism = &workerCount
+	worker.Spec.Completions = &workerCount
+	worker.Spec.Template.Labels = workerLabels
+
+	workerSpec := &worker.Spec.Template.Spec
+	workerSpec.Containers[0].Name = WorkerContainerName
+	workerSpec.Containers[0].Command = []string{"/bin/bash", "-c", workerScript}
+	workerSpec.Containers[0].ReadinessProbe = &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: []string{"test", "-f", "/tmp/sshd-ready"}},
+		},
+	}
+	setGPULimit(&workerSpec.Containers[0], builder.gpusPerNode)
+	configureMultiNodePod(workerSpec, builder.Definition.Name, builder.sshSecretName())
+	proxy.Inject(builder.apiClient, workerSpec)
+
+	if _, err := builder.apiClient.K8sClient.BatchV1().Jobs(nsname).Create(context.TODO(), worker,
+		metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create worker Job %s: %w", workerName, err)
+	}
+
+	return nil
+}
+
+// launcherScript waits for the worker addresses to be published by the headless service, then runs one
+// all_reduce_perf rank per GPU on every node with mpirun.
+func (builder *Builder) launcherScript() string {
+	ranks := builder.nodeCount * builder.gpusPerNode
+
+	var exports []string
+	for _, env := range builder.env {
+		exports = append(exports, "-x "+env.Name)
+	}
+
+	return fmt.Sprintf(`set -e
+%s
+for i in $(seq 120); do
+  workers=$(getent ahostsv4 %s | awk '{print $1}' | sort -u)
+  [ "$(echo "$workers" | grep -c .)" -ge %d ] && break
+  sleep 5
+done
+{ echo "$(hostname -i | awk '{print $1}') slots=%d"; for w in $workers; do echo "$w slots=%d"; done; } > /tmp/hostfile
+cat /tmp/hostfile
+mpirun --allow-run-as-root -np %d --hostfile /tmp/hostfile --bind-to none \
+  -mca plm_rsh_args "-p %d -i %s/%s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null" \
+  -x LD_LIBRARY_PATH -x PATH %s \
+  all_reduce_perf %s -g 1
+`, sshSetup, builder.workerName(), builder.nodeCount-1, builder.gpusPerNode, builder.gpusPerNode, ranks, sshPort,
+		sshMountPath, sshKeyName, strings.Join(exports, " "), strings.Join(builder.testArgs, " "))
+}
+
+// sshSetup authorizes the shared ssh key for root, deriving its public key from the mounted private key.
+const sshSetup = `mkdir -p /root/.ssh && chmod 700 /root/.ssh
+ssh-keygen -y -f ` + sshMountPath + `/` + sshKeyName + ` > /root/.ssh/authorized_keys
+chmod 600 /root/.ssh/authorized_keys`
+
+// workerScript runs sshd on the sshPort until the worker Job is deleted.
+var workerScript = fmt.Sprintf(`set -e
+%s
+ssh-keygen -A
+touch /tmp/sshd-ready
+exec /usr/sbin/sshd -D -e -p %d
+`, sshSetup, sshPort)
+
+// configureMultiNodePod runs the pod on the host network, one pod of the Builder per node, with the ssh key secret.
+func configureMultiNodePod(podSpec *corev1.PodSpec, name, sshSecretName string) {
+	isTrue := true
+	defaultMode := int32(0400)
+
+	podSpec.HostNetwork = true
+	podSpec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	podSpec.Affinity = &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{AppLabel: name}},
+				TopologyKey:   corev1.LabelHostname,
+			}},
+		},
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "ssh-key",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: sshSecretName, DefaultMode: &defaultMode},
+		},
+	})
+
+	container := &podSpec.Containers[0]
+	container.SecurityContext = &corev1.SecurityContext{Privileged: &isTrue}
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name: "ssh-key", MountPath: sshMountPath, ReadOnly: true,
+	})
+}
+
+func setGPULimit(container *corev1.Container, gpuCount int) {
+	if container.Resources.Limits == nil {
+		container.Resources.Limits = corev1.ResourceList{}
+	}
+
+	container.Resources.Limits[gpuResource] = *resource.NewQuantity(int64(gpuCount), resource.DecimalSI)
+}
+
+func (builder *Builder) workerName() string {
+	return builder.Definition.Name + "-worker"
+}
+
+func (builder *Builder) sshSecretName() string {
+	return builder.Definition.Name + "-ssh"
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *Builder) validate() (bool, error) {
+	resourceCRD := "nccl-tests Job"
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
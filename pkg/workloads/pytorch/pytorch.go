@@ -0,0 +1,495 @@
+package pytorch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/msg"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/owner"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/timeouts"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// DefaultImage is the NVIDIA PyTorch container image, shipping torch and torchvision.
+	DefaultImage = "nvcr.io/nvidia/pytorch:24.08-py3"
+	// ContainerName is the container running the training benchmark.
+	ContainerName = "pytorch-train-ctr"
+	// AppLabel is the label key selecting the pods of a Builder.
+	AppLabel = "nvidia-ci/pytorch-train"
+
+	resultPrefix = "PYTORCH-RESULT"
+	gpuResource  = "nvidia.com/gpu"
+	scriptPath   = "/tmp/train.py"
+	// NCCL exchanges the gradients of the ranks through shared memory, larger than the default 64Mi of /dev/shm
+	shmSizeLimit = "2Gi"
+)
+
+var resultRegexp = regexp.MustCompile(resultPrefix + ` gpus=(\d+) images_per_sec=([0-9.]+) loss=(\S+)`)
+
+// trainScript trains a torchvision model on synthetic ImageNet sized batches, so that no dataset is downloaded,
+// with one DistributedDataParallel rank per GPU when started by torchrun. It prints the training throughput of all
+// the ranks, and the last loss, which must be finite.
+const trainScript = `import os
+import time
+
+import torch
+import torch.distributed as dist
+import torchvision
+
+model_name = os.environ["MODEL"]
+batch_size = int(os.environ["BATCH_SIZE"])
+warmup = int(os.environ["WARMUP_ITERATIONS"])
+iterations = int(os.environ["ITERATIONS"])
+rank = int(os.environ.get("LOCAL_RANK", "0"))
+world_size = int(os.environ.get("WORLD_SIZE", "1"))
+
+if world_size > 1:
+    dist.init_process_group("nccl")
+torch.cuda.set_device(rank)
+if rank == 0:
+    print(f"PYTORCH-INFO torch={torch.__version__} cuda={torch.version.cuda} devices={torch.cuda.device_count()}",
+          flush=True)
+
+model = getattr(torchvision.models, model_name)(weights=None).cuda()
+if world_size > 1:
+    model = torch.nn.parallel.DistributedDataParallel(model, device_ids=[rank])
+optimizer = torch.optim.SGD(model.parameters(), lr=0.01, momentum=0.9)
+criterion = torch.nn.CrossEntropyLoss()
+images = torch.randn(batch_size, 3, 224, 224, device="cuda")
+targets = torch.randint(0, 1000, (batch_size,), device="cuda")
+
+
+def step():
+    optimizer.zero_grad(set_to_none=True)
+    loss = criterion(model(images), targets)
+    loss.backward()
+    optimizer.step()
+    return loss
+
+
+for _ in range(warmup):
+    step()
+torch.cuda.synchronize()
+start = time.time()
+for _ in range(iterations):
+    loss = step()
+torch.cuda.synchronize()
+elapsed = time.time() - start
+
+rate = torch.tensor([batch_size * iterations / elapsed], device="cuda")
+if world_size > 1:
+    dist.all_reduce(rate)
+if rank == 0:
+    print(f"PYTORCH-RESULT gpus={world_size} images_per_sec={rate.item():.2f} loss={loss.item():.4f}", flush=True)
+if world_size > 1:
+    dist.destroy_process_group()
+`
+
+// Result is the result of a PyTorch training benchmark run.
+type Result struct {
+	PodName         string
+	NodeName        string
+	GPUs            int
+	ImagesPerSecond float64
+	Loss            float64
+}
+
+// Builder provides struct for a Job training a torchvision model on synthetic data on one or several GPUs of a node.
+type Builder struct {
+	// Definition of the Job. Used to create the Job object.
+	Definition *batchv1.Job
+	// Created Job object.
+	Object *batchv1.Job
+	// Used in functions that define or mutate the Job definition. errorMsg is processed before the Job is created.
+	errorMsg   string
+	apiClient  *clients.Settings
+	gpus       int
+	model      string
+	batchSize  int
+	warmup     int
+	iterations int
+}
+
+// NewBuilder creates a new instance of Builder training resnet50 on a single GPU, with batches of 64 images, for 10
+// warmup and 50 measured iterations.
+func NewBuilder(apiClient *clients.Settings, name, nsname, image string) *Builder {
+	glog.V(100).Infof("Initializing new PyTorch training Job structure with the following params: name: %s, "+
+		"namespace: %s, image: %s", name, nsname, image)
+
+	isFalse := false
+	isTrue := true
+	backoffLimit := int32(0)
+	labels := map[string]string{AppLabel: name}
+	shmSize := resource.MustParse(shmSizeLimit)
+
+	builder := &Builder{
+		apiClient:  apiClient,
+		gpus:       1,
+		model:      "resnet50",
+		batchSize:  64,
+		warmup:     10,
+		iterations: 50,
+		Definition: &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: nsname,
+				Labels:    labels,
+			},
+			Spec: batchv1.JobSpec{
+				BackoffLimit: &backoffLimit,
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						RestartPolicy: corev1.RestartPolicyNever,
+						SecurityContext: &corev1.PodSecurityContext{
+							RunAsNonRoot:   &isTrue,
+							SeccompProfile: &corev1.SeccompProfile{Type: "RuntimeDefault"},
+						},
+						Tolerations: []corev1.Toleration{{
+							Key:      gpuResource,
+							Effect:   corev1.TaintEffectNoSchedule,
+							Operator: corev1.TolerationOpExists,
+						}},
+						Volumes: []corev1.Volume{{
+							Name: "dshm",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{
+									Medium:    corev1.StorageMediumMemory,
+									SizeLimit: &shmSize,
+								},
+							},
+						}},
+						Containers: []corev1.Container{{
+							Name:            ContainerName,
+							Image:           image,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							// The pod runs with an arbitrary uid whose home directory is not writable
+							Env: []corev1.EnvVar{
+								{Name: "HOME", Value: "/tmp"},
+								{Name: "TORCH_HOME", Value: "/tmp/torch"},
+							},
+							VolumeMounts: []corev1.VolumeMount{{Name: "dshm", MountPath: "/dev/shm"}},
+							SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: &isFalse,
+								Capabilities: &corev1.Capabilities{
+									Drop: []corev1.Capability{"ALL"},
+								},
+							},
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{
+									gpuResource: resource.MustParse("1"),
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	if name == "" {
+		glog.V(100).Infof("The name of the PyTorch training Job is empty")
+
+		builder.errorMsg = "PyTorch training Job 'name' cannot be empty"
+	}
+
+	if nsname == "" {
+		glog.V(100).Infof("The namespace of the PyTorch training Job is empty")
+
+		builder.errorMsg = "PyTorch training Job 'namespace' cannot be empty"
+	}
+
+	if image == "" {
+		glog.V(100).Infof("The image of the PyTorch training Job is empty")
+
+		builder.errorMsg = "PyTorch training Job 'image' cannot be empty"
+	}
+
+	return builder
+}
+
+// WithGPUs sets the number of GPUs requested by the pod, one DistributedDataParallel rank per GPU.
+func (builder *Builder) WithGPUs(gpus int) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting PyTorch training Job %s GPUs to %d", builder.Definition.Name, gpus)
+
+	if gpus < 1 {
+		builder.errorMsg = "PyTorch training Job GPUs must be at least 1"
+
+		return builder
+	}
+
+	builder.gpus = gpus
+	builder.Definition.Spec.Template.Spec.Containers[0].Resources.Limits[gpuResource] =
+		*resource.NewQuantity(int64(gpus), resource.DecimalSI)
+
+	return builder
+}
+
+// WithModel sets the torchvision model trained, e.g. resnet18 or resnet50.
+func (builder *Builder) WithModel(model string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting PyTorch training Job %s model to %s", builder.Definition.Name, model)
+
+	if model == "" {
+		builder.errorMsg = "PyTorch training Job model cannot be empty"
+
+		return builder
+	}
+
+	builder.model = model
+
+	return builder
+}
+
+// WithIterations sets the batch size of every rank, the number of warmup iterations and the number of measured
+// iterations.
+func (builder *Builder) WithIterations(batchSize, warmup, iterations int) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting PyTorch training Job %s batch size to %d, warmup iterations to %d and iterations "+
+		"to %d", builder.Definition.Name, batchSize, warmup, iterations)
+
+	if batchSize < 1 || warmup < 0 || iterations < 1 {
+		builder.errorMsg = "PyTorch training Job batch size and iterations must be at least 1, warmup iterations " +
+			"at least 0"
+
+		return builder
+	}
+
+	builder.batchSize = batchSize
+	builder.warmup = warmup
+	builder.iterations = iterations
+
+	return builder
+}
+
+// WithNodeSelector sets the node selector of the training pod.
+func (builder *Builder) WithNodeSelector(nodeSelector map[string]string) *Builder {
+	if valid, _ := builder.validate(); !valid {
+		return builder
+	}
+
+	glog.V(100).Infof("Setting PyTorch training Job %s node selector to %v", builder.Definition.Name, nodeSelector)
+
+	builder.Definition.Spec.Template.Spec.NodeSelector = nodeSelector
+
+	return builder
+}
+
+// Create makes the PyTorch training Job in the cluster and stores the created object in the builder.
+func (builder *Builder) Create() (*Builder, error) {
+	if valid, err := builder.validate(); !valid {
+		return builder, err
+	}
+
+	glog.V(100).Infof("Creating PyTorch training Job %s in namespace %s, %s on %d GPU(s)", builder.Definition.Name,
+		builder.Definition.Namespace, builder.model, builder.gpus)
+
+	job := builder.Definition.DeepCopy()
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Command = []string{"/bin/bash", "-c", builder.script()}
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "MODEL", Value: builder.model},
+		corev1.EnvVar{Name: "BATCH_SIZE", Value: strconv.Itoa(builder.batchSize)},
+		corev1.EnvVar{Name: "WARMUP_ITERATIONS", Value: strconv.Itoa(builder.warmup)},
+		corev1.EnvVar{Name: "ITERATIONS", Value: strconv.Itoa(builder.iterations)})
+	proxy.Inject(builder.apiClient, &job.Spec.Template.Spec)
+	owner.Label(job)
+
+	var err error
+
+	builder.Object, err = builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Create(
+		context.TODO(), job, metav1.CreateOptions{})
+	if err != nil {
+		return builder, fmt.Errorf("failed to create PyTorch training Job %s: %w", builder.Definition.Name, err)
+	}
+
+	return builder, nil
+}
+
+// WaitUntilComplete waits until the training pod has completed, and returns an error if it failed.
+func (builder *Builder) WaitUntilComplete(timeout time.Duration) error {
+	timeout = timeouts.Scale(timeouts.WorkloadCompletion, timeout)
+
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Waiting for PyTorch training Job %s to complete", builder.Definition.Name)
+
+	return wait.PollUntilContextTimeout(
+		context.TODO(), 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+			job, err := builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Get(ctx,
+				builder.Definition.Name, metav1.GetOptions{})
+			if err != nil {
+				glog.V(100).Infof("Failed to get PyTorch training Job %s: %v", builder.Definition.Name, err)
+
+				return false, nil
+			}
+
+			builder.Object = job
+
+			if job.Status.Failed > 0 {
+				return false, fmt.Errorf("PyTorch training Job %s has %d failed pods", builder.Definition.Name,
+					job.Status.Failed)
+			}
+
+			return job.Status.Succeeded > 0, nil
+		})
+}
+
+// GetResult returns the training benchmark result, parsed from the log of the training pod.
+func (builder *Builder) GetResult() (*Result, error) {
+	if valid, err := builder.validate(); !valid {
+		return nil, err
+	}
+
+	trainPods, err := pod.List(builder.apiClient, builder.Definition.Namespace, metav1.ListOptions{
+		LabelSelector: "job-name=" + builder.Definition.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PyTorch training Job %s pods: %w", builder.Definition.Name, err)
+	}
+
+	if len(trainPods) == 0 {
+		return nil, fmt.Errorf("no pod found for PyTorch training Job %s", builder.Definition.Name)
+	}
+
+	output, err := trainPods[0].GetFullLog(ContainerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod %s log: %w", trainPods[0].Object.Name, err)
+	}
+
+	result, err := ParseResult(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pod %s log: %w", trainPods[0].Object.Name, err)
+	}
+
+	result.PodName = trainPods[0].Object.Name
+	result.NodeName = trainPods[0].Object.Spec.NodeName
+
+	return result, nil
+}
+
+// Delete removes the PyTorch training Job and its pod from the cluster.
+func (builder *Builder) Delete() error {
+	if valid, err := builder.validate(); !valid {
+		return err
+	}
+
+	glog.V(100).Infof("Deleting PyTorch training Job %s in namespace %s", builder.Definition.Name,
+		builder.Definition.Namespace)
+
+	propagation := metav1.DeletePropagationForeground
+
+	err := builder.apiClient.K8sClient.BatchV1().Jobs(builder.Definition.Namespace).Delete(context.TODO(),
+		builder.Definition.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PyTorch training Job %s: %w", builder.Definition.Name, err)
+	}
+
+	builder.Object = nil
+
+	return nil
+}
+
+// ParseResult parses the log of a training pod: the number of GPUs, the training throughput of all the GPUs in
+// images per second and the last loss.
+func ParseResult(output string) (*Result, error) {
+	match := resultRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return nil, errors.New("no PyTorch training result found")
+	}
+
+	gpus, err := strconv.Atoi(match[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid GPU count %q: %w", match[1], err)
+	}
+
+	imagesPerSecond, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid images per second %q: %w", match[2], err)
+	}
+
+	loss, err := strconv.ParseFloat(match[3], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loss %q: %w", match[3], err)
+	}
+
+	if math.IsNaN(loss) || math.IsInf(loss, 0) {
+		return nil, fmt.Errorf("the training diverged, loss is %s", match[3])
+	}
+
+	return &Result{GPUs: gpus, ImagesPerSecond: imagesPerSecond, Loss: loss}, nil
+}
+
+// script writes the training script and runs it, through torchrun with one rank per GPU on several GPUs.
+func (builder *Builder) script() string {
+	run := "python " + scriptPath
+	if builder.gpus > 1 {
+		run = fmt.Sprintf("torchrun --standalone --nproc_per_node=%d %s", builder.gpus, scriptPath)
+	}
+
+	return fmt.Sprintf(`set -e
+nvidia-smi -L
+cat > %s <<'EOF'
+%sEOF
+%s
+`, scriptPath, trainScript, run)
+}
+
+// validate will check that the builder and builder definition are properly initialized before
+// accessing any member fields.
+func (builder *Builder) validate() (bool, error) {
+	resourceCRD := "PyTorch training Job"
+
+	if builder == nil {
+		glog.V(100).Infof("The %s builder is uninitialized", resourceCRD)
+
+		return false, fmt.Errorf("error: received nil %s builder", resourceCRD)
+	}
+
+	if builder.Definition == nil {
+		glog.V(100).Infof("The %s is undefined", resourceCRD)
+
+		return false, errors.New(msg.UndefinedCrdObjectErrString(resourceCRD))
+	}
+
+	if builder.apiClient == nil {
+		glog.V(100).Infof("The %s builder apiclient is nil", resourceCRD)
+
+		return false, fmt.Errorf("%s builder cannot have nil apiClient", resourceCRD)
+	}
+
+	if builder.errorMsg != "" {
+		glog.V(100).Infof("The %s builder has error message: %s", resourceCRD, builder.errorMsg)
+
+		return false, errors.New(builder.errorMsg)
+	}
+
+	return true, nil
+}
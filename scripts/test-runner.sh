@@ -46,7 +46,7 @@ fi
 
 
 # Build ginkgo command
-cmd="PATH_TO_MUST_GATHER_SCRIPT=$(pwd)/scripts/gpu-operator-tests-must-gather.sh ginkgo -timeout=24h --keep-going --require-suite -r"
+cmd="MUST_GATHER_SCRIPTS_DIR=${MUST_GATHER_SCRIPTS_DIR:-$(pwd)/scripts} ginkgo -timeout=${TEST_TIMEOUT:-24h} --keep-going --require-suite -r"
 
 if [[ "${TEST_VERBOSE}" == "true" ]]; then
     cmd+=" -vv"
@@ -56,12 +56,63 @@ if [[ "${TEST_TRACE}" == "true" ]]; then
     cmd+=" --trace"
 fi
 
+# Run the specs of each suite in parallel ginkgo processes, one per CPU with "true" or the given number of processes
+if [[ "${TEST_PARALLEL}" == "true" ]]; then
+    cmd+=" -p"
+elif [[ "${TEST_PARALLEL}" =~ ^[0-9]+$ ]] && [[ "${TEST_PARALLEL}" -gt 1 ]]; then
+    cmd+=" --procs=${TEST_PARALLEL}"
+fi
+
 if [[ ! -z "${TEST_LABELS}" ]]; then
     cmd+=" --label-filter=\"${TEST_LABELS}\""
 fi
 cmd+=" "$feature_dirs" $@"   # + user args --xxx=yyy...
 
 
+# Run the GPU operator version matrix when GPU_OPERATOR_MATRIX is set: the ginkgo command runs once per
+# subscription channel of the matrix, installing, validating and uninstalling the GPU operator of the channel,
+# with the reports of each run in a directory of the channel.
+if [[ -n "${GPU_OPERATOR_MATRIX}" ]]; then
+    matrix_reports_dir=${REPORTS_DUMP_DIR:-/tmp/reports}
+    failed_channels=""
+
+    if [[ "${NVIDIAGPU_CLEANUP}" == "false" ]]; then
+        echo "NVIDIAGPU_CLEANUP=false is ignored by the GPU operator version matrix"
+    fi
+
+    # Every version is uninstalled so that the next one is installed on a clean cluster
+    export NVIDIAGPU_CLEANUP=true
+
+    for channel in ${GPU_OPERATOR_MATRIX//,/ }; do
+        echo "Running the GPU operator version matrix with channel ${channel}"
+
+        export NVIDIAGPU_SUBSCRIPTION_CHANNEL=${channel}
+        export REPORTS_DUMP_DIR=${matrix_reports_dir}/gpu-operator-${channel}
+        mkdir -p "${REPORTS_DUMP_DIR}"
+
+        echo $cmd
+        if ! eval $cmd; then
+            failed_channels+=" ${channel}"
+        fi
+
+        # Copy the JUnit reports of the channel to the matrix reports directory, with the channel in their file
+        # and test suite names so that the results of the versions do not collide
+        for junit_file in "${REPORTS_DUMP_DIR}"/*_junit.xml; do
+            [[ -e "${junit_file}" ]] || continue
+            sed -E "s/(<testsuite name=\"[^\"]*)\"/\1 [${channel}]\"/" "${junit_file}" > \
+                "${matrix_reports_dir}/gpu-operator-${channel}_$(basename "${junit_file}")"
+        done
+    done
+
+    if [[ -n "${failed_channels}" ]]; then
+        echo "GPU operator version matrix failed for channels:${failed_channels}"
+        exit 1
+    fi
+
+    echo "GPU operator version matrix passed for channels: ${GPU_OPERATOR_MATRIX}"
+    exit 0
+fi
+
 # Execute ginkgo command
 echo $cmd
 eval $cmd
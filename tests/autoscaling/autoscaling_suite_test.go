@@ -0,0 +1,27 @@
+package autoscaling
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+)
+
+var _, currentFile, _, _ = runtime.Caller(0)
+
+func TestAutoscaling(t *testing.T) {
+	suiteConfig, reporterConfig := GinkgoConfiguration()
+	reporter.ApplyFlakeAttempts(&suiteConfig)
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Autoscaling", Label("nvidia-ci", "autoscaling"), suiteConfig, reporterConfig)
+}
+
+var _ = reporter.RegisterSuiteHooks(
+	currentFile, tsparams.AutoscalingReporterNamespacesToDump, tsparams.AutoscalingReporterCRDsToDump,
+	clients.SetScheme)
@@ -0,0 +1,292 @@
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/autoscaling"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/sno"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/machine"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	watchwait "github.com/rh-ecosystem-edge/nvidia-ci/pkg/wait"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// TestNamespace is the namespace where the GPU workload pods run
+	TestNamespace = "test-gpu-autoscaling"
+	// WorkloadImage is the container image of the GPU workload pods
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+	// MachineSetNamespace is the namespace of the autoscaled GPU MachineSet
+	MachineSetNamespace = "openshift-machine-api"
+	// MachineSetNameSuffix is appended to the copied worker MachineSet name to name the autoscaled GPU MachineSet
+	MachineSetNameSuffix = "autoscaling"
+
+	workerMachineSetLabel = "machine.openshift.io/cluster-api-machine-role"
+	// A single GPU node is enough to validate the scale up from and down to zero
+	maxReplicas      = 1
+	maxGPUs          = 16
+	workloadPods     = 1
+	workloadDuration = 2 * time.Minute
+
+	scaleDownDelayAfterAdd = 5 * time.Minute
+	scaleDownUnneededTime  = 5 * time.Minute
+
+	// The scale up includes the cloud instance provisioning and the NFD labeling of the new node
+	scaleUpTimeout    = 30 * time.Minute
+	driverTimeout     = 30 * time.Minute
+	workloadTimeout   = 10 * time.Minute
+	scaleDownTimeout  = 30 * time.Minute
+	machineSetTimeout = 15 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Autoscaling", Ordered, Label(tsparams.LabelSuite, tsparams.LabelMultiNode,
+	"autoscaling"), cilabels.Spec(cilabels.Extended, cilabels.Long, cilabels.DisruptsCluster, cilabels.MultiNode,
+	cilabels.MachineSet), func() {
+	var (
+		nsBuilder         *namespace.Builder
+		machineSetBuilder *machine.SetBuilder
+		workloadBuilders  []*pod.Builder
+		gpuNodeNames      []string
+		scaledUp          bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting autoscaling test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.InstanceType == "" {
+			Skip("NVIDIAGPU_GPU_MACHINESET_INSTANCE_TYPE is not set, no GPU MachineSet to autoscale")
+		}
+
+		singleNode, err := sno.IsSingleNode(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error detecting Single-Node OpenShift: %v", err)
+
+		if singleNode {
+			Skip("Single-Node OpenShift has no worker MachineSet to autoscale GPU nodes from")
+		}
+
+		hostedCluster, err := inittools.APIClient.IsHostedCluster()
+		Expect(err).ToNot(HaveOccurred(), "error detecting a HyperShift hosted cluster: %v", err)
+
+		if hostedCluster {
+			Skip("HyperShift hosted clusters scale their nodes with NodePools, not with MachineSets")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		_, err = inittools.APIClient.GetResource(machine.ClusterAutoscalerGVR, "", machine.ClusterAutoscalerName)
+		if err == nil {
+			Skip(fmt.Sprintf("ClusterAutoscaler '%s' already exists, not overriding it",
+				machine.ClusterAutoscalerName))
+		}
+
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue(), "error getting ClusterAutoscaler %s: %v",
+			machine.ClusterAutoscalerName, err)
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		By(fmt.Sprintf("Create a GPU MachineSet of instance type %s with zero replicas", nvidiaGPUConfig.InstanceType))
+		machineSetBuilder, err = machine.NewSetBuilderFromCopy(inittools.APIClient, MachineSetNamespace,
+			nvidiaGPUConfig.InstanceType, workerMachineSetLabel, 0).
+			WithNameSuffix(MachineSetNameSuffix).
+			WithNodeLabel(machine.AcceleratorLabel, autoscaling.GPUType).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating the GPU MachineSet: %v", err)
+
+		machineSetName := machineSetBuilder.Definition.Name
+
+		By("Create the ClusterAutoscaler and the MachineAutoscaler of the GPU MachineSet")
+		_, err = machine.ApplyClusterAutoscaler(inittools.APIClient, autoscaling.GPUType, maxGPUs,
+			scaleDownDelayAfterAdd, scaleDownUnneededTime)
+		Expect(err).ToNot(HaveOccurred(), "error creating ClusterAutoscaler: %v", err)
+
+		_, err = machine.ApplyMachineAutoscaler(inittools.APIClient, machineSetName, MachineSetNamespace, 0,
+			maxReplicas)
+		Expect(err).ToNot(HaveOccurred(), "error creating MachineAutoscaler %s: %v", machineSetName, err)
+	})
+
+	AfterAll(func() {
+		for _, workloadBuilder := range workloadBuilders {
+			if _, err := workloadBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting pod %s: %v", workloadBuilder.Definition.Name, err)
+			}
+		}
+
+		if machineSetBuilder != nil {
+			machineSetName := machineSetBuilder.Definition.Name
+
+			if err := machine.DeleteMachineAutoscaler(inittools.APIClient, machineSetName,
+				MachineSetNamespace); err != nil {
+				glog.Errorf("Error deleting MachineAutoscaler %s: %v", machineSetName, err)
+			}
+
+			if err := machine.DeleteClusterAutoscaler(inittools.APIClient); err != nil {
+				glog.Errorf("Error deleting ClusterAutoscaler %s: %v", machine.ClusterAutoscalerName, err)
+			}
+
+			if machineSetBuilder.Exists() {
+				if err := machineSetBuilder.DeleteAndWait(machineSetTimeout); err != nil {
+					glog.Errorf("Error deleting MachineSet %s: %v", machineSetName, err)
+				}
+			}
+		}
+
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should scale the GPU MachineSet up from zero for pending GPU pods", Label("autoscaling-scale-up"), func() {
+		machineSetName := machineSetBuilder.Definition.Name
+		nodeSelector := map[string]string{machine.AcceleratorLabel: autoscaling.GPUType}
+
+		By(fmt.Sprintf("Create %d GPU workload pod(s) selecting the nodes of the GPU MachineSet", workloadPods))
+		for index := 0; index < workloadPods; index++ {
+			podName := fmt.Sprintf("gpu-autoscaling-workload-%d", index)
+
+			workloadBuilder, err := autoscaling.NewWorkloadPod(inittools.APIClient, podName, TestNamespace,
+				disconnected.Image(WorkloadImage), nodeSelector, workloadDuration).Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", podName, err)
+
+			workloadBuilders = append(workloadBuilders, workloadBuilder)
+		}
+
+		By(fmt.Sprintf("Wait for the cluster autoscaler to scale MachineSet %s up", machineSetName))
+		replicas, err := machine.WaitForMachineSetScaleUp(inittools.APIClient, machineSetName, MachineSetNamespace,
+			scaleUpTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for the scale up: %v", err)
+		Expect(replicas).To(BeNumerically("<=", maxReplicas), "MachineSet %s scaled above the MachineAutoscaler "+
+			"maximum", machineSetName)
+
+		By("Wait for the new GPU nodes to join the cluster and be labeled by NFD")
+		gpuNodes, err := machine.WaitForMachineSetNodes(inittools.APIClient, machineSetName, MachineSetNamespace,
+			nvidiagpu.NvidiaGPULabel, scaleUpTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for the GPU nodes: %v", err)
+		Expect(gpuNodes).ToNot(BeEmpty(), "MachineSet %s has no GPU node", machineSetName)
+
+		gpuNodeNames = nil
+		for _, gpuNode := range gpuNodes {
+			gpuNodeNames = append(gpuNodeNames, gpuNode.Object.Name)
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("MachineSet %s scaled up to GPU node(s) %v", machineSetName,
+			gpuNodeNames)
+
+		scaledUp = true
+	})
+
+	It("Should install the GPU driver and run the GPU pods on the new nodes", Label("autoscaling-workload"), func() {
+		if !scaledUp {
+			Skip("The GPU MachineSet was not scaled up")
+		}
+
+		for _, nodeName := range gpuNodeNames {
+			By(fmt.Sprintf("Wait for the GPU operator to deploy the GPU stack on node %s", nodeName))
+			ctx, cancel := context.WithTimeout(context.TODO(), driverTimeout)
+			err := watchwait.NodeLabel(ctx, inittools.APIClient, nodeName, "nvidia.com/gpu.present", "true")
+			cancel()
+			Expect(err).ToNot(HaveOccurred(), "node %s was not labeled by GPU feature discovery: %v", nodeName, err)
+
+			err = await.Match(context.TODO(), fmt.Sprintf("node %s to advertise a GPU", nodeName),
+				30*time.Second, driverTimeout, func(context.Context) (interface{}, error) {
+					nodeBuilder, err := nodes.Pull(inittools.APIClient, nodeName)
+					if err != nil {
+						return 0, err
+					}
+
+					allocatable := nodeBuilder.Object.Status.Allocatable[corev1.ResourceName("nvidia.com/gpu")]
+
+					return allocatable.Value(), nil
+				}, BeNumerically(">", 0))
+			Expect(err).ToNot(HaveOccurred(), "node %s has no allocatable GPU: %v", nodeName, err)
+		}
+
+		for _, workloadBuilder := range workloadBuilders {
+			podName := workloadBuilder.Definition.Name
+
+			By(fmt.Sprintf("Wait for GPU workload pod %s to complete", podName))
+			err := workloadBuilder.WaitUntilInStatus(corev1.PodSucceeded, workloadTimeout+workloadDuration)
+			Expect(err).ToNot(HaveOccurred(), "pod %s did not complete: %v", podName, err)
+
+			workloadPod, err := pod.Pull(inittools.APIClient, podName, TestNamespace)
+			Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", podName, err)
+			Expect(gpuNodeNames).To(ContainElement(workloadPod.Object.Spec.NodeName),
+				"pod %s did not run on a node of the GPU MachineSet", podName)
+
+			output, err := workloadPod.GetFullLog(autoscaling.WorkloadContainerName)
+			Expect(err).ToNot(HaveOccurred(), "error getting pod %s log: %v", podName, err)
+			glog.V(gpuparams.GpuLogLevel).Infof("Pod %s log:\n%s", podName, output)
+			Expect(strings.Contains(output, "GPU 0:")).To(BeTrue(), "pod %s did not list its GPU", podName)
+		}
+	})
+
+	It("Should scale the GPU MachineSet down to zero once the workload finished", Label("autoscaling-scale-down"),
+		func() {
+			if !scaledUp {
+				Skip("The GPU MachineSet was not scaled up")
+			}
+
+			machineSetName := machineSetBuilder.Definition.Name
+
+			By("Delete the completed GPU workload pods")
+			for _, workloadBuilder := range workloadBuilders {
+				_, err := workloadBuilder.Delete()
+				Expect(err).ToNot(HaveOccurred(), "error deleting pod %s: %v", workloadBuilder.Definition.Name, err)
+			}
+
+			workloadBuilders = nil
+
+			By(fmt.Sprintf("Wait for the cluster autoscaler to scale MachineSet %s down to zero", machineSetName))
+			err := machine.WaitForMachineSetScaleDown(inittools.APIClient, machineSetName, MachineSetNamespace,
+				scaleDownDelayAfterAdd+scaleDownUnneededTime+scaleDownTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error waiting for the scale down: %v", err)
+
+			for _, nodeName := range gpuNodeNames {
+				err := await.Match(context.TODO(), fmt.Sprintf("node %s to be removed", nodeName),
+					30*time.Second, machineSetTimeout, func(context.Context) (interface{}, error) {
+						_, err := nodes.Pull(inittools.APIClient, nodeName)
+
+						return err != nil, nil
+					}, BeTrue())
+				Expect(err).ToNot(HaveOccurred(), "node %s was not removed: %v", nodeName, err)
+			}
+		})
+})
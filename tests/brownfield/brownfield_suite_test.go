@@ -0,0 +1,26 @@
+package brownfield
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+)
+
+var _, currentFile, _, _ = runtime.Caller(0)
+
+func TestBrownfield(t *testing.T) {
+	suiteConfig, reporterConfig := GinkgoConfiguration()
+	reporter.ApplyFlakeAttempts(&suiteConfig)
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Brownfield", Label("nvidia-ci", "brownfield"), suiteConfig, reporterConfig)
+}
+
+var _ = reporter.RegisterSuiteHooks(
+	currentFile, tsparams.BrownfieldReporterNamespacesToDump, tsparams.BrownfieldReporterCRDsToDump, clients.SetScheme)
@@ -0,0 +1,190 @@
+package brownfield
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/brownfield"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the brownfield testcases pods run
+	TestNamespace = "test-brownfield"
+	// DebugImage is the container image of the privileged debug pod inspecting the host driver
+	DebugImage = "registry.access.redhat.com/ubi9/ubi:latest"
+	// JobPrefix is the name prefix of the CUDA Jobs run on the host driver, followed by the node index
+	JobPrefix = "cuda-host-driver"
+
+	clusterPolicyReadyTimeout = 5 * time.Minute
+	hostDriverPodTimeout      = 5 * time.Minute
+	workloadSuccessTimeout    = 10 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Brownfield", Ordered, Label(tsparams.LabelSuite, "brownfield"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.DisruptsOperands), func() {
+	var (
+		gpuNodes    []*nodes.Builder
+		nsBuilder   *namespace.Builder
+		hostDrivers map[string]*brownfield.HostDriver
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Brownfield test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if clusterPolicyBuilder.Definition.Spec.Driver.IsEnabled() {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' deploys the driver, the GPU operator must be deployed with "+
+				"NVIDIAGPU_GPU_CLUSTER_POLICY_PATCH='%s' on nodes with a preinstalled driver",
+				nvidiagpu.ClusterPolicyName, brownfield.DisableDriverPatch))
+		}
+
+		By("Check the CUDA sample and debug images are reachable")
+		Expect(disconnected.CheckImages(cudasamples.VectorAddImage, DebugImage)).ToNot(HaveOccurred(),
+			"the CUDA sample and debug images are not reachable through the mirrors")
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By(fmt.Sprintf("Create the privileged namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.PrivilegedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should get ready without driver containers", Label("brownfield-no-driver-pods"), func() {
+		err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "ClusterPolicy is not ready with the host driver: %v", err)
+
+		driverPods, err := brownfield.DriverPods(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error listing the driver pods: %v", err)
+		Expect(driverPods).To(BeEmpty(), "the GPU operator deployed driver pods with the driver disabled")
+	})
+
+	It("Should validate the driver preinstalled on every GPU node", Label("brownfield-host-driver"), func() {
+		hostDrivers = map[string]*brownfield.HostDriver{}
+
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+
+			By(fmt.Sprintf("Inspect the host driver of node %s", nodeName))
+			hostDriver, err := brownfield.GetHostDriver(inittools.APIClient, nodeName, TestNamespace,
+				disconnected.Image(DebugImage), hostDriverPodTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error inspecting node %s host driver: %v", nodeName, err)
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Node %s host driver %+v", nodeName, hostDriver)
+			Expect(hostDriver.Version).ToNot(BeEmpty(), "node %s has no driver preinstalled on the host", nodeName)
+			Expect(hostDriver.ModuleLoaded).To(BeTrue(), "node %s has not loaded the NVIDIA kernel module",
+				nodeName)
+			Expect(hostDriver.Validated).To(BeTrue(), "the operator validator did not write %s on node %s",
+				brownfield.HostDriverReadyFile, nodeName)
+
+			if nvidiaGPUConfig.HostDriverVersion != "" {
+				Expect(hostDriver.Version).To(Equal(nvidiaGPUConfig.HostDriverVersion),
+					"node %s host driver version", nodeName)
+			}
+
+			hostDrivers[nodeName] = hostDriver
+		}
+	})
+
+	It("Should label the GPU nodes with the host driver version", Label("brownfield-driver-labels"), func() {
+		if len(hostDrivers) == 0 {
+			Skip("The host drivers were not inspected")
+		}
+
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+
+			nodeBuilder, err := nodes.Pull(inittools.APIClient, nodeName)
+			Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", nodeName, err)
+
+			driverVersion, err := gfd.VersionLabels(nodeBuilder.Object.Labels, gfd.DriverVersionLabelPrefix,
+				gfd.LegacyDriverVersionLabelPrefix, "major", "minor", "revision")
+			Expect(err).ToNot(HaveOccurred(), "error reading the driver version labels of node %s: %v", nodeName,
+				err)
+			Expect(strings.Join(driverVersion, ".")).To(Equal(hostDrivers[nodeName].Version),
+				"node %s driver version labels do not match the host driver", nodeName)
+		}
+	})
+
+	It("Should run GPU workloads on the host driver", Label("brownfield-workload"), func() {
+		for index, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+			jobName := fmt.Sprintf("%s-%d", JobPrefix, index)
+
+			By(fmt.Sprintf("Run cuda vectorAdd Job %s on node %s", jobName, nodeName))
+			sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, jobName, TestNamespace,
+				cudasamples.VectorAdd, disconnected.Image(cudasamples.VectorAddImage)).
+				WithNodeSelector(map[string]string{corev1.LabelHostname: nodeName}).
+				Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", jobName, err)
+
+			err = sampleBuilder.WaitUntilComplete(workloadSuccessTimeout)
+			Expect(err).ToNot(HaveOccurred(), "Job %s did not succeed: %v", jobName, err)
+
+			result, err := sampleBuilder.GetResult()
+			Expect(err).ToNot(HaveOccurred(), "error getting Job %s result: %v", jobName, err)
+			Expect(result.Passed).To(BeTrue(), "cuda vectorAdd failed on node %s", nodeName)
+
+			if err := sampleBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting Job %s: %v", jobName, err)
+			}
+		}
+	})
+})
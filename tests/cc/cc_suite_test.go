@@ -0,0 +1,26 @@
+package cc
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+)
+
+var _, currentFile, _, _ = runtime.Caller(0)
+
+func TestCC(t *testing.T) {
+	suiteConfig, reporterConfig := GinkgoConfiguration()
+	reporter.ApplyFlakeAttempts(&suiteConfig)
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CC", Label("nvidia-ci", "cc"), suiteConfig, reporterConfig)
+}
+
+var _ = reporter.RegisterSuiteHooks(
+	currentFile, tsparams.CCReporterNamespacesToDump, tsparams.CCReporterCRDsToDump, clients.SetScheme)
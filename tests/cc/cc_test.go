@@ -0,0 +1,282 @@
+package cc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/cc"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/kata"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	katacfg "github.com/rh-ecosystem-edge/nvidia-ci/pkg/kata"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the confidential workload pods will run
+	TestNamespace = "test-cc"
+	// CUDAImage is the container image of the confidential workload pods
+	CUDAImage = "nvcr.io/nvidia/k8s/cuda-sample:vectoradd-cuda12.5.0-ubi8"
+
+	operatorInstallTimeout    = 10 * time.Minute
+	kataConfigPollInterval    = time.Minute
+	kataConfigTimeout         = time.Hour
+	clusterPolicyReadyTimeout = 20 * time.Minute
+	ccManagerPollInterval     = 30 * time.Second
+	ccManagerTimeout          = 10 * time.Minute
+	ccModePollInterval        = 30 * time.Second
+	ccModeTimeout             = 15 * time.Minute
+	allocatablePollInterval   = 30 * time.Second
+	allocatableTimeout        = 15 * time.Minute
+	workloadSuccessTimeout    = 10 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("CC", Ordered, Label(tsparams.LabelSuite, "cc"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.DisruptsCluster, cilabels.CC), func() {
+	var (
+		nsBuilder         *namespace.Builder
+		ccNode            *nodes.Builder
+		kataConfigBuilder *katacfg.Builder
+		previousSpec      *nvidiagpuv1.ClusterPolicySpec
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting CC test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(CUDAImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		By("Find a GPU worker node supporting confidential computing")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		ccNodes, err := cc.ListCCCapableNodes(inittools.APIClient, nodeSelector.String())
+		Expect(err).ToNot(HaveOccurred(), "error listing CC capable GPU nodes: %v", err)
+
+		if len(ccNodes) == 0 {
+			Skip("No GPU worker node supports confidential computing")
+		}
+
+		ccNode = ccNodes[0]
+		glog.V(gpuparams.GpuLogLevel).Infof("Using node '%s' with GPU '%s' for CC tests", ccNode.Object.Name,
+			ccNode.Object.Labels[cc.GPUProductLabel])
+
+		By("Install the OpenShift sandboxed containers operator")
+		err = kata.InstallSandboxedContainersOperator(inittools.APIClient, "",
+			nvidiaGPUConfig.OSCSubscriptionChannel, operatorInstallTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error installing the sandboxed containers operator: %v", err)
+
+		By("Install the kata runtime on the node")
+		if _, err := katacfg.Pull(inittools.APIClient, kata.KataConfigName); err != nil {
+			kataConfigBuilder, err = katacfg.NewBuilder(inittools.APIClient, kata.KataConfigName).
+				WithPoolSelector(map[string]string{"kubernetes.io/hostname": ccNode.Object.Name}).
+				Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating KataConfig %s: %v", kata.KataConfigName, err)
+		} else {
+			glog.V(gpuparams.GpuLogLevel).Infof("KataConfig '%s' already exists", kata.KataConfigName)
+		}
+
+		kataConfig, err := katacfg.Pull(inittools.APIClient, kata.KataConfigName)
+		Expect(err).ToNot(HaveOccurred(), "error pulling KataConfig %s: %v", kata.KataConfigName, err)
+
+		err = await.Match(context.TODO(), fmt.Sprintf("KataConfig %s to install the kata runtime", kata.KataConfigName),
+			kataConfigPollInterval, kataConfigTimeout, func(context.Context) (interface{}, error) {
+				return kataConfig.IsInstalled()
+			}, BeTrue())
+		Expect(err).ToNot(HaveOccurred(), "kata runtime is not installed by KataConfig %s: %v", kata.KataConfigName,
+			err)
+
+		By("Create the CC test namespace")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.DeleteAndWait(workloadSuccessTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if ccNode != nil {
+			for _, label := range []string{kata.WorkloadConfigLabel, kata.CCModeLabel} {
+				if err := nodes.SetLabel(inittools.APIClient, ccNode.Object.Name, label, ""); err != nil {
+					glog.Errorf("Error removing label %s from node %s: %v", label, ccNode.Object.Name, err)
+				}
+			}
+		}
+
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			} else if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				clusterPolicyReadyTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+
+		if kataConfigBuilder != nil && nvidiaGPUConfig.CleanupAfterTest {
+			By("Uninstall the kata runtime from the node")
+			if err := kataConfigBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting KataConfig %s: %v", kata.KataConfigName, err)
+			}
+		}
+	})
+
+	It("Should deploy the CC manager on the CC capable node", Label("cc-manager"), func() {
+		By("Enable sandbox workloads and the CC manager in the ClusterPolicy")
+		var err error
+		previousSpec, err = kata.EnableKataInClusterPolicy(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+			cc.ModeOff)
+		Expect(err).ToNot(HaveOccurred(), "error enabling the CC manager in ClusterPolicy: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		By(fmt.Sprintf("Label node %s with %s=%s", ccNode.Object.Name, kata.WorkloadConfigLabel,
+			kata.WorkloadConfigVMPassthrough))
+		err = nodes.SetLabel(inittools.APIClient, ccNode.Object.Name, kata.WorkloadConfigLabel,
+			kata.WorkloadConfigVMPassthrough)
+		Expect(err).ToNot(HaveOccurred(), "error labeling node %s: %v", ccNode.Object.Name, err)
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+		By(fmt.Sprintf("Wait for a running CC manager pod on node %s", ccNode.Object.Name))
+		err = await.Match(context.TODO(), fmt.Sprintf("a running CC manager pod on node %s", ccNode.Object.Name),
+			ccManagerPollInterval, ccManagerTimeout, func(context.Context) (interface{}, error) {
+				ccManagerPods, err := pod.List(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace, metav1.ListOptions{
+					LabelSelector: cc.CCManagerPodLabel,
+					FieldSelector: fields.AndSelectors(
+						fields.OneTermEqualSelector("spec.nodeName", ccNode.Object.Name),
+						fields.OneTermEqualSelector("status.phase", string(corev1.PodRunning))).String(),
+				})
+
+				return len(ccManagerPods), err
+			}, Equal(1))
+		Expect(err).ToNot(HaveOccurred(), "no running CC manager pod on node %s: %v", ccNode.Object.Name, err)
+
+		err = cc.WaitForCCModeState(inittools.APIClient, ccNode.Object.Name, cc.ModeOff, ccModePollInterval,
+			ccModeTimeout)
+		Expect(err).ToNot(HaveOccurred(), "CC manager did not report mode %s on node %s: %v", cc.ModeOff,
+			ccNode.Object.Name, err)
+	})
+
+	It("Should run a confidential workload with CC mode on", Label("cc-mode-on"), func() {
+		if previousSpec == nil {
+			Skip("The CC manager is not enabled in the ClusterPolicy")
+		}
+
+		err := cc.SetMode(inittools.APIClient, ccNode.Object.Name, cc.ModeOn, ccModePollInterval, ccModeTimeout)
+		Expect(err).ToNot(HaveOccurred(), "CC manager did not apply mode %s: %v", cc.ModeOn, err)
+		runCCWorkload("cc-on-cuda-pod", ccNode.Object.Name, "ON")
+	})
+
+	It("Should run a confidential workload with CC mode devtools", Label("cc-mode-devtools"), func() {
+		if previousSpec == nil {
+			Skip("The CC manager is not enabled in the ClusterPolicy")
+		}
+
+		err := cc.SetMode(inittools.APIClient, ccNode.Object.Name, cc.ModeDevtools, ccModePollInterval, ccModeTimeout)
+		Expect(err).ToNot(HaveOccurred(), "CC manager did not apply mode %s: %v", cc.ModeDevtools, err)
+		runCCWorkload("cc-devtools-cuda-pod", ccNode.Object.Name, "DEVTOOLS")
+	})
+
+	It("Should disable the CC mode", Label("cc-mode-off"), func() {
+		if previousSpec == nil {
+			Skip("The CC manager is not enabled in the ClusterPolicy")
+		}
+
+		err := cc.SetMode(inittools.APIClient, ccNode.Object.Name, cc.ModeOff, ccModePollInterval, ccModeTimeout)
+		Expect(err).ToNot(HaveOccurred(), "CC manager did not apply mode %s: %v", cc.ModeOff, err)
+	})
+})
+
+// runCCWorkload runs a cuda vectorAdd pod in a confidential kata VM and checks that the GPU reports the expected CC
+// status, is ready for confidential workloads and runs vectorAdd.
+func runCCWorkload(podName, nodeName, ccStatus string) {
+	if nvidiaGPUConfig.KataCCRuntimeClass == "" {
+		Skip("NVIDIAGPU_KATA_CC_RUNTIME_CLASS is not set, not running a confidential workload")
+	}
+
+	var resourceName string
+
+	By(fmt.Sprintf("Wait for node %s to advertise a passthrough GPU", nodeName))
+	err := await.Match(context.TODO(), fmt.Sprintf("node %s to advertise a passthrough GPU", nodeName),
+		allocatablePollInterval, allocatableTimeout, func(context.Context) (interface{}, error) {
+			var err error
+			resourceName, err = kata.PassthroughResourceName(inittools.APIClient, nodeName)
+
+			return resourceName, err
+		}, Not(BeEmpty()))
+	Expect(err).ToNot(HaveOccurred(), "node %s does not advertise a passthrough GPU: %v", nodeName, err)
+
+	By(fmt.Sprintf("Run confidential pod %s with RuntimeClass %s", podName, nvidiaGPUConfig.KataCCRuntimeClass))
+	workloadPod := cc.CreateCCWorkloadPod(podName, TestNamespace, nodeName, nvidiaGPUConfig.KataCCRuntimeClass,
+		resourceName, disconnected.Image(CUDAImage))
+
+	proxy.Inject(inittools.APIClient, &workloadPod.Spec)
+	_, err = inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), workloadPod, metav1.CreateOptions{})
+	Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", podName, err)
+
+	podBuilder, err := pod.Pull(inittools.APIClient, podName, TestNamespace)
+	Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", podName, err)
+
+	defer func() {
+		if _, err := podBuilder.DeleteAndWait(workloadSuccessTimeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", podName, err)
+		}
+	}()
+
+	err = podBuilder.WaitUntilInStatus(corev1.PodSucceeded, workloadSuccessTimeout)
+	Expect(err).ToNot(HaveOccurred(), "pod %s did not succeed: %v", podName, err)
+
+	logs, err := podBuilder.GetFullLog(cc.WorkloadContainerName)
+	Expect(err).ToNot(HaveOccurred(), "error getting pod %s logs: %v", podName, err)
+	glog.V(gpuparams.GpuLogLevel).Infof("Pod %s logs:\n%s", podName, logs)
+
+	status, err := cc.ParseConfComputeStatus(logs)
+	Expect(err).ToNot(HaveOccurred(), "error parsing pod %s conf-compute status: %v", podName, err)
+	Expect(status.CCStatus).To(Equal(ccStatus), "GPU of pod %s reports CC status %s", podName, status.CCStatus)
+	Expect(status.ReadyState).To(Equal("ready"), "GPU of pod %s is not ready for confidential workloads", podName)
+	Expect(logs).To(ContainSubstring("Test PASSED"), "cuda vectorAdd failed in pod %s", podName)
+}
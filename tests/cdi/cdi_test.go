@@ -0,0 +1,239 @@
+package cdi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/cdi"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/cgroups"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the CDI testcases pods run
+	TestNamespace = "test-cdi"
+	// DebugImage is the container image of the privileged debug pods reading the node CDI specs and toolkit config
+	DebugImage = "registry.access.redhat.com/ubi9/ubi:latest"
+	// LegacyPodPrefix is the name prefix of the GPU workload pods run through the legacy hook, followed by the node
+	// index
+	LegacyPodPrefix = "cuda-legacy-workload"
+	// CDIPodPrefix is the name prefix of the GPU workload pods run through CDI, followed by the node index
+	CDIPodPrefix = "cuda-cdi-workload"
+	// CDIJobName is the name of the CUDA Job run through CDI
+	CDIJobName = "cuda-cdi"
+
+	clusterPolicyReadyTimeout = 20 * time.Minute
+	debugPodTimeout           = 5 * time.Minute
+	workloadPodTimeout        = 10 * time.Minute
+	workloadSuccessTimeout    = 10 * time.Minute
+)
+
+var _ = Describe("CDI", Ordered, Label(tsparams.LabelSuite, "cdi"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.DisruptsOperands), func() {
+	var (
+		gpuNodes        []*nodes.Builder
+		nodeSelector    labels.Set
+		nsBuilder       *namespace.Builder
+		previousSpec    *nvidiagpuv1.ClusterPolicySpec
+		cdiEnabled      bool
+		legacyWorkloads map[string]*cgroups.Workload
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting CDI test suite")
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the CUDA sample and debug images are reachable")
+		Expect(disconnected.CheckImages(cudasamples.VectorAddImage, DebugImage)).ToNot(HaveOccurred(),
+			"the CUDA sample and debug images are not reachable through the mirrors")
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		cdiSpec := clusterPolicyBuilder.Definition.Spec.CDI
+		glog.V(gpuparams.GpuLogLevel).Infof("ClusterPolicy CDI enabled %t, default %t", cdiSpec.IsEnabled(),
+			cdiSpec.IsDefault())
+
+		By(fmt.Sprintf("Create the privileged namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.PrivilegedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			}
+
+			if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				clusterPolicyReadyTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+	})
+
+	It("Should run GPU workloads through the legacy hook", Label("cdi-legacy-baseline"), func() {
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		Expect(err).ToNot(HaveOccurred(), "error pulling ClusterPolicy: %v", err)
+
+		if clusterPolicyBuilder.Definition.Spec.CDI.IsDefault() {
+			Skip("CDI is already the default in the ClusterPolicy, the legacy hook is not used")
+		}
+
+		legacyWorkloads = runWorkloads(gpuNodes, LegacyPodPrefix)
+	})
+
+	It("Should enable CDI as the default in the ClusterPolicy", Label("cdi-enable"), func() {
+		By("Set cdi.enabled and cdi.default in the ClusterPolicy")
+		var err error
+		previousSpec, err = cdi.EnableCDI(inittools.APIClient, nvidiagpu.ClusterPolicyName, true)
+		Expect(err).ToNot(HaveOccurred(), "error enabling CDI: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+		By("Check the device plugin passes the allocated devices as CDI annotations")
+		strategy, err := cdi.DevicePluginListStrategy(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error getting the device plugin list strategy: %v", err)
+		Expect(strings.Split(strategy, ",")).To(ContainElement(cdi.CDIAnnotationsStrategy),
+			"the device plugin %s is %q", cdi.DeviceListStrategyEnv, strategy)
+
+		cdiEnabled = true
+	})
+
+	It("Should generate the CDI specs and switch the toolkit to CDI", Label("cdi-node-specs"), func() {
+		if !cdiEnabled {
+			Skip("CDI is not enabled in the ClusterPolicy")
+		}
+
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+
+			By(fmt.Sprintf("List the CDI specs of node %s", nodeName))
+			specs, err := cdi.GetNodeSpecs(inittools.APIClient, nodeName, TestNamespace,
+				disconnected.Image(DebugImage), debugPodTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error listing node %s CDI specs: %v", nodeName, err)
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Node %s CDI specs %v", nodeName, specs)
+			Expect(cdi.HasKind(specs, cdi.GPUKind)).To(BeTrue(), "node %s has no CDI spec of kind %s in %v",
+				nodeName, cdi.GPUKind, cdi.SpecDirs)
+
+			By(fmt.Sprintf("Check the container toolkit of node %s runs in CDI mode", nodeName))
+			nodeConfig, err := cgroups.GetNodeConfig(inittools.APIClient, nodeName, TestNamespace,
+				disconnected.Image(DebugImage), debugPodTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error reading node %s toolkit configuration: %v", nodeName, err)
+			Expect(nodeConfig.Toolkit).ToNot(BeNil(), "node %s has no container toolkit configuration", nodeName)
+			Expect(nodeConfig.Toolkit.Mode).To(Equal(cgroups.CDIMode), "node %s nvidia-container-runtime mode is %q",
+				nodeName, nodeConfig.Toolkit.Mode)
+		}
+	})
+
+	It("Should run GPU workloads through CDI like through the legacy hook", Label("cdi-workload"), func() {
+		if !cdiEnabled {
+			Skip("CDI is not enabled in the ClusterPolicy")
+		}
+
+		cdiWorkloads := runWorkloads(gpuNodes, CDIPodPrefix)
+
+		for nodeName, legacyWorkload := range legacyWorkloads {
+			cdiWorkload := cdiWorkloads[nodeName]
+			Expect(cdiWorkload.Mode).To(Equal(legacyWorkload.Mode),
+				"node %s CDI workload cgroup mode differs from the legacy hook one", nodeName)
+			Expect(cdiWorkload.Devices).To(HaveLen(len(legacyWorkload.Devices)),
+				"node %s CDI workload GPU devices %v differ from the legacy hook ones %v", nodeName,
+				cdiWorkload.Devices, legacyWorkload.Devices)
+		}
+
+		By(fmt.Sprintf("Run cuda vectorAdd Job %s through CDI", CDIJobName))
+		sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, CDIJobName, TestNamespace,
+			cudasamples.VectorAdd, disconnected.Image(cudasamples.VectorAddImage)).
+			WithNodeSelector(nodeSelector).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", CDIJobName, err)
+
+		defer func() {
+			if err := sampleBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting Job %s: %v", CDIJobName, err)
+			}
+		}()
+
+		err = sampleBuilder.WaitUntilComplete(workloadSuccessTimeout)
+		Expect(err).ToNot(HaveOccurred(), "Job %s did not succeed: %v", CDIJobName, err)
+
+		result, err := sampleBuilder.GetResult()
+		Expect(err).ToNot(HaveOccurred(), "error getting Job %s result: %v", CDIJobName, err)
+		Expect(result.Passed).To(BeTrue(), "cuda vectorAdd failed through CDI")
+	})
+})
+
+// runWorkloads runs a GPU workload pod requesting one GPU on every GPU node, checks it passed with a single GPU
+// device, and returns what the pods reported, by node name.
+func runWorkloads(gpuNodes []*nodes.Builder, podPrefix string) map[string]*cgroups.Workload {
+	workloads := map[string]*cgroups.Workload{}
+
+	for index, gpuNode := range gpuNodes {
+		nodeName := gpuNode.Object.Name
+		podName := fmt.Sprintf("%s-%d", podPrefix, index)
+
+		By(fmt.Sprintf("Run GPU workload pod %s on node %s", podName, nodeName))
+		workload, err := cgroups.RunWorkload(inittools.APIClient, podName, TestNamespace,
+			disconnected.Image(cudasamples.VectorAddImage), nodeName, 1, workloadPodTimeout)
+		Expect(err).ToNot(HaveOccurred(), "GPU workload pod %s failed on node %s: %v", podName, nodeName, err)
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Pod %s saw cgroup %s and GPU devices %v", podName, workload.Mode,
+			workload.Devices)
+		Expect(workload.Devices).To(HaveLen(1), "pod %s requesting 1 GPU got the GPU devices %v", podName,
+			workload.Devices)
+		Expect(workload.Passed).To(BeTrue(), "cuda vectorAdd failed in pod %s on node %s", podName, nodeName)
+
+		workloads[nodeName] = workload
+	}
+
+	return workloads
+}
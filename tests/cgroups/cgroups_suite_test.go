@@ -0,0 +1,26 @@
+package cgroups
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+)
+
+var _, currentFile, _, _ = runtime.Caller(0)
+
+func TestCgroups(t *testing.T) {
+	suiteConfig, reporterConfig := GinkgoConfiguration()
+	reporter.ApplyFlakeAttempts(&suiteConfig)
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cgroups", Label("nvidia-ci", "cgroups"), suiteConfig, reporterConfig)
+}
+
+var _ = reporter.RegisterSuiteHooks(
+	currentFile, tsparams.CgroupsReporterNamespacesToDump, tsparams.CgroupsReporterCRDsToDump, clients.SetScheme)
@@ -0,0 +1,167 @@
+package cgroups
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/cgroups"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the cgroup testcases pods run
+	TestNamespace = "test-cgroups"
+	// DebugImage is the container image of the privileged debug pod reading the node cgroup configuration
+	DebugImage = "registry.access.redhat.com/ubi9/ubi:latest"
+	// WorkloadPodPrefix is the name prefix of the GPU workload pods, followed by the node index
+	WorkloadPodPrefix = "cuda-cgroup-workload"
+
+	nodePodTimeout     = 5 * time.Minute
+	workloadPodTimeout = 10 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Cgroups", Ordered, Label(tsparams.LabelSuite, "cgroups"), cilabels.Spec(
+	cilabels.Regression, cilabels.Short, cilabels.NonDisruptive), func() {
+	var (
+		gpuNodes    []*nodes.Builder
+		nodeConfigs map[string]*cgroups.NodeConfig
+		nsBuilder   *namespace.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Cgroups test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the CUDA sample and debug images are reachable")
+		Expect(disconnected.CheckImages(cudasamples.VectorAddImage, DebugImage)).ToNot(HaveOccurred(),
+			"the CUDA sample and debug images are not reachable through the mirrors")
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		var err error
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By(fmt.Sprintf("Create the privileged namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.PrivilegedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+
+		By("Read the cgroup configuration of every GPU node")
+		nodeConfigs = map[string]*cgroups.NodeConfig{}
+
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+
+			nodeConfig, err := cgroups.GetNodeConfig(inittools.APIClient, nodeName, TestNamespace,
+				disconnected.Image(DebugImage), nodePodTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error reading node %s cgroup configuration: %v", nodeName, err)
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Node %s cgroup %s, CRI-O default runtime %q, cgroup manager %q, "+
+				"kubelet cgroup driver %q, toolkit %+v", nodeName, nodeConfig.Mode, nodeConfig.DefaultRuntime,
+				nodeConfig.CgroupManager, nodeConfig.KubeletCgroupDriver, nodeConfig.Toolkit)
+
+			nodeConfigs[nodeName] = nodeConfig
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should run the GPU nodes in the expected cgroup mode", Label("cgroups-mode"), func() {
+		modes := map[string]bool{}
+
+		for nodeName, nodeConfig := range nodeConfigs {
+			modes[nodeConfig.Mode] = true
+
+			if nvidiaGPUConfig.CgroupMode != "" {
+				Expect(nodeConfig.Mode).To(Equal(nvidiaGPUConfig.CgroupMode), "node %s runs cgroup %s", nodeName,
+					nodeConfig.Mode)
+			}
+		}
+
+		Expect(modes).To(HaveLen(1), "the GPU nodes run different cgroup modes: %v", modes)
+	})
+
+	It("Should use the systemd cgroup driver", Label("cgroups-systemd-driver"), func() {
+		for nodeName, nodeConfig := range nodeConfigs {
+			Expect(nodeConfig.CgroupManager).To(Equal(cgroups.SystemdDriver),
+				"node %s CRI-O cgroup manager is %s", nodeName, nodeConfig.CgroupManager)
+			Expect(nodeConfig.KubeletCgroupDriver).To(Equal(cgroups.SystemdDriver),
+				"node %s kubelet cgroup driver is %s", nodeName, nodeConfig.KubeletCgroupDriver)
+		}
+	})
+
+	It("Should configure the container toolkit for the node cgroup mode", Label("cgroups-toolkit-config"), func() {
+		for nodeName, nodeConfig := range nodeConfigs {
+			Expect(nodeConfig.Mismatches()).To(BeEmpty(),
+				"node %s container toolkit configuration does not match its cgroup %s setup", nodeName,
+				nodeConfig.Mode)
+		}
+	})
+
+	It("Should give GPU workloads access to their GPU devices", Label("cgroups-device-access"), func() {
+		for index, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+			podName := fmt.Sprintf("%s-%d", WorkloadPodPrefix, index)
+
+			By(fmt.Sprintf("Run GPU workload pod %s on node %s", podName, nodeName))
+			workload, err := cgroups.RunWorkload(inittools.APIClient, podName, TestNamespace,
+				disconnected.Image(cudasamples.VectorAddImage), nodeName, 1, workloadPodTimeout)
+			Expect(err).ToNot(HaveOccurred(), "GPU workload pod %s failed on node %s: %v", podName, nodeName, err)
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Pod %s saw cgroup %s and GPU devices %v", podName, workload.Mode,
+				workload.Devices)
+			Expect(workload.Mode).To(Equal(nodeConfigs[nodeName].Mode),
+				"pod %s cgroup namespace does not match node %s cgroup mode", podName, nodeName)
+			Expect(workload.Devices).To(HaveLen(1), "pod %s requesting 1 GPU got the GPU devices %v", podName,
+				workload.Devices)
+			Expect(workload.Passed).To(BeTrue(), "cuda vectorAdd failed in pod %s on node %s", podName, nodeName)
+		}
+	})
+})
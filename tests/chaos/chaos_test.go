@@ -0,0 +1,299 @@
+package chaos
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/autoscaling"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/chaos"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/clusterupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/sno"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the chaos workloads run
+	TestNamespace = "test-gpu-chaos"
+	// WorkloadImage is the CUDA container image of the chaos workloads
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+	// RunningWorkloadName is the name of the CUDA workload running while the driver pod is killed
+	RunningWorkloadName = "chaos-running-workload"
+	// RecoveryWorkloadName is the name of the CUDA workload scheduled after the driver recovered
+	RecoveryWorkloadName = "chaos-recovery-workload"
+	// DrainWorkloadName is the name of the CUDA deployment evicted when the node is drained
+	DrainWorkloadName = "chaos-drain-workload"
+	// DebugImage is the container image of the privileged debug pod rebooting the node
+	DebugImage = "registry.access.redhat.com/ubi9/ubi:latest"
+
+	runningWorkloadDuration = 60 * time.Minute
+	recoveryPollInterval    = 15 * time.Second
+	driverRecoveryTimeout   = 15 * time.Minute
+	validatorRerunTimeout   = 10 * time.Minute
+	workloadScheduleTimeout = 5 * time.Minute
+	clusterPolicyTimeout    = 15 * time.Minute
+	nodePollInterval        = 30 * time.Second
+	nodeRebootTimeout       = 30 * time.Minute
+)
+
+var _ = Describe("Chaos", Ordered, Label(tsparams.LabelSuite, "chaos"), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.DisruptsCluster), func() {
+	var (
+		nsBuilder    *namespace.Builder
+		nodeName     string
+		nodeSelector labels.Set
+		singleNode   bool
+		killTime     time.Time
+		driverKilled bool
+		nodeCordoned bool
+		nodeRebooted bool
+		drainedPods  *deployment.Builder
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting driver chaos test suite")
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage, DebugImage)).ToNot(HaveOccurred(),
+			"the workload images are not reachable through the mirrors")
+
+		By("Find the GPU worker node of the chaos tests")
+		gpuSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			gpuSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: gpuSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		nodeName = gpuNodes[0].Object.Name
+		nodeSelector = labels.Set{corev1.LabelHostname: gpuNodes[0].Object.Labels[corev1.LabelHostname]}
+		glog.V(gpuparams.GpuLogLevel).Infof("Running the chaos tests on node '%s'", nodeName)
+
+		singleNode, err = sno.IsSingleNode(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error detecting Single-Node OpenShift: %v", err)
+
+		// The namespace must allow the privileged debug pod rebooting the node.
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace).
+			WithMultipleLabels(params.PrivilegedNSLabels)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		By(fmt.Sprintf("Start CUDA workload %s on node %s", RunningWorkloadName, nodeName))
+		_, err = autoscaling.NewWorkloadPod(inittools.APIClient, RunningWorkloadName, TestNamespace,
+			disconnected.Image(WorkloadImage), nodeSelector, runningWorkloadDuration).
+			CreateAndWaitUntilRunning(workloadScheduleTimeout)
+		Expect(err).ToNot(HaveOccurred(), "pod %s is not running: %v", RunningWorkloadName, err)
+	})
+
+	AfterAll(func() {
+		if nodeCordoned {
+			if err := chaos.UncordonNode(inittools.APIClient, nodeName); err != nil {
+				glog.Errorf("Error uncordoning node %s: %v", nodeName, err)
+			}
+		}
+
+		if driverKilled || nodeRebooted {
+			err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyTimeout)
+			if err != nil {
+				glog.Errorf("ClusterPolicy %s is not ready after the chaos tests: %v", nvidiagpu.ClusterPolicyName,
+					err)
+			}
+		}
+
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.DeleteAndWait(workloadScheduleTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should re-initialize the driver after its pod is killed", Label("chaos-driver-kill"), func() {
+		By(fmt.Sprintf("Kill the driver pod of node %s", nodeName))
+		killTime = time.Now()
+		killedUID, err := chaos.KillPod(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace, driverupgrade.DriverLabel,
+			nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error killing the driver pod of node %s: %v", nodeName, err)
+		driverKilled = true
+
+		By("Wait for the driver to re-initialize")
+		err = chaos.NodeDriverReady(inittools.APIClient, nodeName, killedUID, recoveryPollInterval,
+			sno.DisruptionTimeout(singleNode, driverRecoveryTimeout))
+		Expect(err).ToNot(HaveOccurred(), "the driver of node %s did not re-initialize: %v", nodeName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Driver of node '%s' re-initialized in %v", nodeName,
+			time.Since(killTime).Round(time.Second))
+	})
+
+	It("Should re-run the validator after the driver restart", Label("chaos-validator"), func() {
+		if !driverKilled {
+			Skip("The driver pod was not killed")
+		}
+
+		err := chaos.ValidatorRerun(inittools.APIClient, nodeName, killTime, recoveryPollInterval,
+			sno.DisruptionTimeout(singleNode, validatorRerunTimeout))
+		Expect(err).ToNot(HaveOccurred(), "the validator of node %s did not re-run: %v", nodeName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Validator of node '%s' re-ran %v after the driver pod was killed",
+			nodeName, time.Since(killTime).Round(time.Second))
+	})
+
+	It("Should schedule new GPU workloads after the driver restart", Label("chaos-workload"), func() {
+		if !driverKilled {
+			Skip("The driver pod was not killed")
+		}
+
+		if runningPod, err := pod.Pull(inittools.APIClient, RunningWorkloadName, TestNamespace); err == nil {
+			glog.V(gpuparams.GpuLogLevel).Infof("Pod '%s' running across the driver restart is in phase '%s'",
+				RunningWorkloadName, runningPod.Object.Status.Phase)
+		}
+
+		By(fmt.Sprintf("Run CUDA workload %s on node %s", RecoveryWorkloadName, nodeName))
+		podBuilder, err := autoscaling.NewWorkloadPod(inittools.APIClient, RecoveryWorkloadName, TestNamespace,
+			disconnected.Image(WorkloadImage), nodeSelector, 0).Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", RecoveryWorkloadName, err)
+
+		err = podBuilder.WaitUntilInStatus(corev1.PodSucceeded, sno.DisruptionTimeout(singleNode,
+			workloadScheduleTimeout))
+		Expect(err).ToNot(HaveOccurred(), "pod %s did not complete on node %s: %v", RecoveryWorkloadName, nodeName,
+			err)
+
+		logs, err := podBuilder.GetFullLog(autoscaling.WorkloadContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error getting pod %s logs: %v", RecoveryWorkloadName, err)
+		Expect(logs).To(ContainSubstring("GPU 0"), "pod %s did not get a GPU", RecoveryWorkloadName)
+	})
+
+	It("Should evict the GPU workloads and keep the operands when the node is drained", Label("chaos-drain"), func() {
+		if singleNode {
+			Skip("Draining the node of Single-Node OpenShift evicts the cluster workloads")
+		}
+
+		clusterPolicy, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		Expect(err).ToNot(HaveOccurred(), "error pulling ClusterPolicy %s: %v", nvidiagpu.ClusterPolicyName, err)
+
+		drainSpec := chaos.ClusterPolicyDrainSpec(clusterPolicy)
+		if !drainSpec.Enable {
+			glog.V(gpuparams.GpuLogLevel).Infof("ClusterPolicy '%s' does not drain the nodes on driver upgrades, "+
+				"draining with its drain spec anyway", nvidiagpu.ClusterPolicyName)
+		}
+
+		podSelector, err := labels.Parse(drainSpec.PodSelector)
+		Expect(err).ToNot(HaveOccurred(), "error parsing the drain pod selector '%s': %v", drainSpec.PodSelector, err)
+
+		By(fmt.Sprintf("Start CUDA deployment %s on node %s", DrainWorkloadName, nodeName))
+		drainedPods, err = clusterupgrade.NewWorkloadDeployment(inittools.APIClient, DrainWorkloadName, TestNamespace,
+			disconnected.Image(WorkloadImage)).WithNodeSelector(nodeSelector).
+			CreateAndWaitUntilReady(workloadScheduleTimeout)
+		Expect(err).ToNot(HaveOccurred(), "deployment %s is not ready: %v", DrainWorkloadName, err)
+
+		// The drain only deletes the pods without a controller when forced.
+		By(fmt.Sprintf("Delete pod %s not managed by a controller", RunningWorkloadName))
+		if runningPod, err := pod.Pull(inittools.APIClient, RunningWorkloadName, TestNamespace); err == nil {
+			_, err = runningPod.DeleteAndWait(workloadScheduleTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error deleting pod %s: %v", RunningWorkloadName, err)
+		}
+
+		workloadUIDs, err := chaos.NodePodUIDs(inittools.APIClient, TestNamespace, clusterupgrade.WorkloadPodLabel,
+			nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error listing the pods of deployment %s: %v", DrainWorkloadName, err)
+		Expect(workloadUIDs).ToNot(BeEmpty(), "deployment %s has no pod on node %s", DrainWorkloadName, nodeName)
+
+		operandUIDs, err := chaos.NodeOperandPodUIDs(inittools.APIClient, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error listing the operands of node %s: %v", nodeName, err)
+
+		By(fmt.Sprintf("Drain node %s with the ClusterPolicy drain spec", nodeName))
+		nodeCordoned = true
+		err = chaos.DrainNode(inittools.APIClient, nodeName, drainSpec)
+		Expect(err).ToNot(HaveOccurred(), "error draining node %s: %v", nodeName, err)
+
+		remainingWorkloadUIDs, err := chaos.NodePodUIDs(inittools.APIClient, TestNamespace,
+			clusterupgrade.WorkloadPodLabel, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error listing the pods of deployment %s: %v", DrainWorkloadName, err)
+
+		evicted := podSelector.Matches(labels.Set(drainedPods.Definition.Spec.Template.Labels))
+		for uid, name := range workloadUIDs {
+			if evicted {
+				Expect(remainingWorkloadUIDs).ToNot(HaveKey(uid), "pod %s matching the drain pod selector '%s' was "+
+					"not evicted", name, drainSpec.PodSelector)
+			} else {
+				Expect(remainingWorkloadUIDs).To(HaveKey(uid), "pod %s not matching the drain pod selector '%s' was "+
+					"evicted", name, drainSpec.PodSelector)
+			}
+		}
+
+		remainingOperandUIDs, err := chaos.NodeOperandPodUIDs(inittools.APIClient, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error listing the operands of node %s: %v", nodeName, err)
+
+		for uid, name := range operandUIDs {
+			Expect(remainingOperandUIDs).To(HaveKey(uid), "operand pod %s was evicted from node %s", name, nodeName)
+		}
+	})
+
+	It("Should recover all the operands after the node reboots", Label("chaos-reboot"), func() {
+		bootID, err := chaos.BootID(inittools.APIClient, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error getting the boot ID of node %s: %v", nodeName, err)
+
+		By(fmt.Sprintf("Reboot node %s", nodeName))
+		rebootTime := time.Now()
+		_, err = chaos.RebootNode(inittools.APIClient, nodeName, TestNamespace, disconnected.Image(DebugImage))
+		Expect(err).ToNot(HaveOccurred(), "error rebooting node %s: %v", nodeName, err)
+		nodeRebooted = true
+
+		err = chaos.NodeRebooted(inittools.APIClient, nodeName, bootID, nodePollInterval,
+			sno.DisruptionTimeout(singleNode, nodeRebootTimeout))
+		Expect(err).ToNot(HaveOccurred(), "node %s did not reboot: %v", nodeName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' rebooted and was ready again in %v", nodeName,
+			time.Since(rebootTime).Round(time.Second))
+
+		By("Wait for the operands of the node to restart")
+		err = chaos.NodeOperandsRestarted(inittools.APIClient, nodeName, rebootTime, recoveryPollInterval,
+			sno.DisruptionTimeout(singleNode, driverRecoveryTimeout))
+		Expect(err).ToNot(HaveOccurred(), "the operands of node %s did not recover: %v", nodeName, err)
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "ClusterPolicy %s is not ready after the reboot: %v",
+			nvidiagpu.ClusterPolicyName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Operands of node '%s' recovered %v after the reboot", nodeName,
+			time.Since(rebootTime).Round(time.Second))
+	})
+
+	It("Should run the drained GPU workloads again after the node is uncordoned", Label("chaos-uncordon"), func() {
+		if drainedPods == nil {
+			Skip("The node was not drained")
+		}
+
+		By(fmt.Sprintf("Uncordon node %s", nodeName))
+		err := chaos.UncordonNode(inittools.APIClient, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error uncordoning node %s: %v", nodeName, err)
+		nodeCordoned = false
+
+		Expect(drainedPods.IsReady(sno.DisruptionTimeout(singleNode, workloadScheduleTimeout))).To(BeTrue(),
+			"deployment %s is not ready on node %s", DrainWorkloadName, nodeName)
+	})
+})
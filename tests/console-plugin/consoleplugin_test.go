@@ -0,0 +1,219 @@
+package consoleplugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/consoleplugin"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/dcgmexporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	clusterPolicyReadyTimeout = 15 * time.Minute
+	pluginInstallTimeout      = 10 * time.Minute
+	pluginReadyTimeout        = 5 * time.Minute
+	backendPollInterval       = 15 * time.Second
+	backendTimeout            = 5 * time.Minute
+	scrapePollInterval        = 30 * time.Second
+	scrapeTimeout             = 5 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Console Plugin", Ordered, Label(tsparams.LabelSuite, "console-plugin"), cilabels.Spec(
+	cilabels.Regression, cilabels.Short, cilabels.DisruptsOperands), func() {
+	var (
+		gpuNodes         []*nodes.Builder
+		previousSpec     *nvidiagpuv1.ClusterPolicySpec
+		prometheusClient *prometheus.Client
+		pluginInstalled  bool
+		consoleChanged   bool
+		backendService   *consoleplugin.BackendService
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Console Plugin test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if !clusterPolicyBuilder.Definition.Spec.DCGMExporter.IsEnabled() {
+			Skip(fmt.Sprintf("The DCGM exporter is disabled in ClusterPolicy '%s', the console plugin has no "+
+				"GPU data to serve", nvidiagpu.ClusterPolicyName))
+		}
+
+		By("Find the GPU worker nodes")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By("Enable the DCGM exporter ServiceMonitor in the ClusterPolicy")
+		previousSpec, err = dcgmexporter.EnableServiceMonitor(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		Expect(err).ToNot(HaveOccurred(), "error enabling the DCGM exporter ServiceMonitor: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		if previousSpec != nil {
+			err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+		}
+
+		prometheusClient, err = prometheus.NewClient(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error creating the Prometheus client: %v", err)
+	})
+
+	AfterAll(func() {
+		if consoleChanged {
+			By("Disable the console plugin in the OpenShift console")
+			if _, err := consoleplugin.SetEnabledInConsole(inittools.APIClient, false); err != nil {
+				glog.Errorf("Error disabling console plugin %s: %v", consoleplugin.PluginName, err)
+			}
+		}
+
+		if pluginInstalled && nvidiaGPUConfig.CleanupAfterTest {
+			By("Uninstall the console plugin")
+			if err := consoleplugin.Uninstall(pluginInstallTimeout); err != nil {
+				glog.Errorf("Error uninstalling console plugin %s: %v", consoleplugin.PluginName, err)
+			}
+		}
+
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			}
+		}
+	})
+
+	It("Should deploy the console plugin", Label("console-plugin-deploy"), func() {
+		if _, err := deployment.Pull(inittools.APIClient, consoleplugin.PluginName,
+			consoleplugin.PluginNamespace); err != nil {
+			By(fmt.Sprintf("Install the console plugin chart version '%s'", nvidiaGPUConfig.ConsolePluginChartVersion))
+			err = consoleplugin.Install(nvidiaGPUConfig.ConsolePluginChartVersion, pluginInstallTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error installing the console plugin: %v", err)
+
+			pluginInstalled = true
+		} else {
+			glog.V(gpuparams.GpuLogLevel).Infof("Console plugin '%s' is already deployed", consoleplugin.PluginName)
+		}
+
+		By(fmt.Sprintf("Wait for deployment %s to be ready", consoleplugin.PluginName))
+		pluginDeployment, err := deployment.Pull(inittools.APIClient, consoleplugin.PluginName,
+			consoleplugin.PluginNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling deployment %s: %v", consoleplugin.PluginName, err)
+		Expect(pluginDeployment.IsReady(pluginReadyTimeout)).To(BeTrue(), "deployment %s is not ready",
+			consoleplugin.PluginName)
+
+		By("Enable the console plugin in the OpenShift console")
+		previous, err := consoleplugin.SetEnabledInConsole(inittools.APIClient, true)
+		Expect(err).ToNot(HaveOccurred(), "error enabling console plugin %s: %v", consoleplugin.PluginName, err)
+
+		consoleChanged = !previous
+	})
+
+	It("Should register the ConsolePlugin with the plugin service as backend", Label("console-plugin-cr"), func() {
+		consolePlugin, err := consoleplugin.PullConsolePlugin(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error pulling ConsolePlugin %s: %v", consoleplugin.PluginName, err)
+
+		backendService, err = consoleplugin.GetBackendService(consolePlugin)
+		Expect(err).ToNot(HaveOccurred(), "error getting ConsolePlugin %s backend: %v", consoleplugin.PluginName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("ConsolePlugin '%s' backend is %+v", consoleplugin.PluginName,
+			*backendService)
+
+		Expect(backendService.Name).To(Equal(consoleplugin.PluginName),
+			"ConsolePlugin %s does not use the plugin service", consoleplugin.PluginName)
+		Expect(backendService.Namespace).To(Equal(consoleplugin.PluginNamespace),
+			"ConsolePlugin %s backend is not in namespace %s", consoleplugin.PluginName, consoleplugin.PluginNamespace)
+		Expect(backendService.Port).To(BeNumerically(">", 0), "ConsolePlugin %s backend has no port",
+			consoleplugin.PluginName)
+	})
+
+	It("Should serve the plugin manifest from the plugin backend", Label("console-plugin-backend"), func() {
+		if backendService == nil {
+			Skip(fmt.Sprintf("The backend of ConsolePlugin %s is unknown", consoleplugin.PluginName))
+		}
+
+		var manifest *consoleplugin.Manifest
+
+		err := await.Match(context.TODO(), fmt.Sprintf("the plugin backend to serve %s", consoleplugin.ManifestPath),
+			backendPollInterval, backendTimeout, func(context.Context) (interface{}, error) {
+				var err error
+				manifest, err = consoleplugin.GetManifest(inittools.APIClient, backendService)
+
+				return nil, err
+			}, Succeed())
+		Expect(err).ToNot(HaveOccurred(), "plugin backend does not serve %s: %v", consoleplugin.ManifestPath, err)
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Plugin manifest '%s' version '%s' declares %d extensions",
+			manifest.Name, manifest.Version, len(manifest.Extensions))
+
+		Expect(manifest.Name).To(Equal(consoleplugin.PluginName), "plugin backend serves the manifest of %s",
+			manifest.Name)
+		Expect(manifest.Extensions).ToNot(BeEmpty(), "plugin manifest declares no console extension")
+	})
+
+	It("Should have the GPU inventory of every GPU node to display", Label("console-plugin-gpu-inventory"), func() {
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+			gpuCount := get.GPUCount(gpuNode)
+
+			By(fmt.Sprintf("Check the %d GPU(s) of node %s are reported by the DCGM exporter metrics", gpuCount,
+				nodeName))
+			err := await.Match(context.TODO(), fmt.Sprintf("the GPU inventory of node %s", nodeName),
+				scrapePollInterval, scrapeTimeout, func(context.Context) (interface{}, error) {
+					samples, err := prometheusClient.Query(fmt.Sprintf("%s{%s=%q}", dcgmexporter.UtilizationMetric,
+						dcgmexporter.HostnameLabel, nodeName))
+					if err != nil {
+						return 0, err
+					}
+
+					for _, sample := range samples {
+						if sample.Metric[dcgmexporter.ModelNameLabel] == "" {
+							return 0, fmt.Errorf("GPU %s of node %s has no %s", sample.Metric[dcgmexporter.GPULabel],
+								nodeName, dcgmexporter.ModelNameLabel)
+						}
+					}
+
+					return len(dcgmexporter.SamplesByNode(samples)[nodeName]), nil
+				}, Equal(gpuCount))
+			Expect(err).ToNot(HaveOccurred(), "the GPU inventory of node %s is incomplete: %v", nodeName, err)
+		}
+	})
+})
@@ -0,0 +1,400 @@
+package dcgmexporter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/dcgmexporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	gpuburn "github.com/rh-ecosystem-edge/nvidia-ci/internal/gpu-burn"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/prometheus"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	burnjob "github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/gpuburn"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the GPU workload will run
+	TestNamespace = "test-dcgm-exporter"
+	// WorkloadPodName is the name of the gpu-burn pod loading the GPU
+	WorkloadPodName = "dcgm-exporter-gpu-burn"
+	// WorkloadConfigMapName is the name of the gpu-burn entrypoint configmap mounted by the gpu-burn pod
+	WorkloadConfigMapName = "gpu-burn-entrypoint"
+	// AlertJobName is the name of the gpu-burn Job loading a GPU until the high utilization alert fires
+	AlertJobName = "dcgm-exporter-alert-burn"
+
+	clusterPolicyReadyTimeout = 15 * time.Minute
+	scrapePollInterval        = 30 * time.Second
+	scrapeTimeout             = 5 * time.Minute
+	utilizationWindow         = "15m"
+	rulesLoadTimeout          = 5 * time.Minute
+	alertPollInterval         = 30 * time.Second
+	alertFiringTimeout        = 10 * time.Minute
+	alertBurnDuration         = 15 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("DCGM Exporter", Ordered, Label(tsparams.LabelSuite, "dcgm-exporter"), cilabels.Spec(
+	cilabels.Regression, cilabels.Short, cilabels.DisruptsOperands), func() {
+	var (
+		nsBuilder        *namespace.Builder
+		gpuNodes         []*nodes.Builder
+		previousSpec     *nvidiagpuv1.ClusterPolicySpec
+		prometheusClient *prometheus.Client
+		nodeSelector     labels.Set
+		rulesCreated     bool
+		alertJob         *burnjob.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting DCGM Exporter test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if !clusterPolicyBuilder.Definition.Spec.DCGMExporter.IsEnabled() {
+			Skip(fmt.Sprintf("The DCGM exporter is disabled in ClusterPolicy '%s'", nvidiagpu.ClusterPolicyName))
+		}
+
+		By("Find the GPU worker nodes")
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By("Enable the DCGM exporter ServiceMonitor in the ClusterPolicy")
+		previousSpec, err = dcgmexporter.EnableServiceMonitor(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		Expect(err).ToNot(HaveOccurred(), "error enabling the DCGM exporter ServiceMonitor: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		if previousSpec != nil {
+			err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+		}
+
+		prometheusClient, err = prometheus.NewClient(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error creating the Prometheus client: %v", err)
+	})
+
+	AfterAll(func() {
+		if alertJob != nil {
+			if err := alertJob.Delete(); err != nil {
+				glog.Errorf("Error deleting gpu-burn Job %s: %v", AlertJobName, err)
+			}
+		}
+
+		if rulesCreated {
+			if err := prometheus.DeletePrometheusRule(inittools.APIClient, dcgmexporter.AlertRuleName,
+				nvidiagpu.NvidiaGPUNamespace); err != nil {
+				glog.Errorf("Error deleting PrometheusRule %s: %v", dcgmexporter.AlertRuleName, err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			}
+		}
+	})
+
+	It("Should have Prometheus scrape the DCGM exporter through its ServiceMonitor",
+		Label("dcgm-exporter-servicemonitor"), func() {
+			By(fmt.Sprintf("Check namespace %s is monitored by the platform Prometheus",
+				nvidiagpu.NvidiaGPUNamespace))
+			gpuNsBuilder, err := namespace.Pull(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace)
+			Expect(err).ToNot(HaveOccurred(), "error pulling namespace %s: %v", nvidiagpu.NvidiaGPUNamespace, err)
+			Expect(gpuNsBuilder.Object.Labels).To(HaveKeyWithValue("openshift.io/cluster-monitoring", "true"),
+				"namespace %s is not labeled for cluster monitoring", nvidiagpu.NvidiaGPUNamespace)
+
+			By(fmt.Sprintf("Check ServiceMonitor %s selects the DCGM exporter service", dcgmexporter.ServiceName))
+			err = await.Match(context.TODO(),
+				fmt.Sprintf("ServiceMonitor %s to select the DCGM exporter service", dcgmexporter.ServiceName),
+				scrapePollInterval, scrapeTimeout, func(context.Context) (interface{}, error) {
+					serviceMonitor, err := prometheus.PullServiceMonitor(inittools.APIClient, dcgmexporter.ServiceName,
+						nvidiagpu.NvidiaGPUNamespace)
+					if err != nil {
+						return nil, err
+					}
+
+					return nil, dcgmexporter.ValidateServiceMonitor(inittools.APIClient, serviceMonitor)
+				}, Succeed())
+			Expect(err).ToNot(HaveOccurred(), "ServiceMonitor %s is not wired to the DCGM exporter service: %v",
+				dcgmexporter.ServiceName, err)
+
+			By("Check Prometheus scrapes one healthy DCGM exporter target per GPU node")
+			err = await.Match(context.TODO(), "Prometheus to scrape every DCGM exporter",
+				scrapePollInterval, scrapeTimeout, func(context.Context) (interface{}, error) {
+					targets, err := dcgmexporter.DCGMExporterTargets(prometheusClient)
+					if err != nil {
+						return 0, err
+					}
+
+					healthy := 0
+
+					for _, target := range targets {
+						glog.V(gpuparams.GpuLogLevel).Infof("DCGM exporter target '%s' is '%s' %s",
+							target.ScrapeURL, target.Health, target.LastError)
+
+						if target.Health == "up" {
+							healthy++
+						}
+					}
+
+					return healthy, nil
+				}, BeNumerically(">=", len(gpuNodes)))
+			Expect(err).ToNot(HaveOccurred(), "Prometheus does not scrape every DCGM exporter: %v", err)
+		})
+
+	It("Should report the GPU utilization of a workload", Label("dcgm-exporter-workload"), func() {
+		workloadNode := gpuNodes[0].Object.Name
+
+		By("Create the DCGM exporter test namespace")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		clusterArch, err := get.GetClusterArchitecture(inittools.APIClient, nodeSelector)
+		Expect(err).ToNot(HaveOccurred(), "error getting cluster architecture: %v", err)
+
+		burnImage, err := gpuburn.Images.Image(clusterArch)
+		Expect(err).ToNot(HaveOccurred(), "error selecting the gpu-burn image: %v", err)
+
+		By("Check the gpu-burn image is reachable")
+		Expect(disconnected.CheckImages(burnImage)).ToNot(HaveOccurred(),
+			"the gpu-burn image is not reachable through the mirrors")
+		reporter.RecordImageDigests(clusterArch, burnImage)
+		burnImage = disconnected.Image(burnImage)
+
+		By(fmt.Sprintf("Run gpu-burn on node %s", workloadNode))
+		_, err = gpuburn.CreateGPUBurnConfigMap(inittools.APIClient, WorkloadConfigMapName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error creating gpu-burn configmap: %v", err)
+
+		burnPod, err := gpuburn.CreateGPUBurnPod(inittools.APIClient, WorkloadPodName, TestNamespace,
+			burnImage, nvidiagpu.BurnPodCreationTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error building gpu-burn pod: %v", err)
+		burnPod.Spec.NodeSelector["kubernetes.io/hostname"] = workloadNode
+
+		proxy.Inject(inittools.APIClient, &burnPod.Spec)
+		_, err = inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), burnPod, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", WorkloadPodName, err)
+
+		burnPodBuilder, err := pod.Pull(inittools.APIClient, WorkloadPodName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", WorkloadPodName, err)
+
+		err = burnPodBuilder.WaitUntilRunning(nvidiagpu.BurnPodRunningTimeout)
+		Expect(err).ToNot(HaveOccurred(), "pod %s is not running: %v", WorkloadPodName, err)
+
+		err = burnPodBuilder.WaitUntilInStatus(corev1.PodSucceeded, nvidiagpu.BurnPodSuccessTimeout)
+		Expect(err).ToNot(HaveOccurred(), "pod %s did not succeed: %v", WorkloadPodName, err)
+
+		By(fmt.Sprintf("Check %s reported a non-zero utilization on node %s", dcgmexporter.UtilizationMetric,
+			workloadNode))
+		query := fmt.Sprintf(`max(max_over_time(%s{%s="%s"}[%s]))`, dcgmexporter.UtilizationMetric,
+			dcgmexporter.HostnameLabel, workloadNode, utilizationWindow)
+		err = await.Match(context.TODO(), fmt.Sprintf("the GPU utilization of node %s", workloadNode),
+			scrapePollInterval, scrapeTimeout, func(context.Context) (interface{}, error) {
+				samples, err := prometheusClient.Query(query)
+				if err != nil || len(samples) == 0 {
+					return 0, err
+				}
+
+				return samples[0].Value, nil
+			}, BeNumerically(">", 0))
+		Expect(err).ToNot(HaveOccurred(), "no GPU utilization reported on node %s: %v", workloadNode, err)
+	})
+
+	It("Should export the DCGM metrics of every GPU", Label("dcgm-exporter-metrics"), func() {
+		gpusByNode := map[string]int{}
+		for _, gpuNode := range gpuNodes {
+			gpusByNode[gpuNode.Object.Name] = get.GPUCount(gpuNode)
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("GPU count by node: %v", gpusByNode)
+
+		for _, metric := range dcgmexporter.DefaultMetrics {
+			By(fmt.Sprintf("Check %s is exported for every GPU", metric.Name))
+			samples, err := prometheusClient.Query(metric.Name)
+			Expect(err).ToNot(HaveOccurred(), "error querying %s: %v", metric.Name, err)
+			Expect(samples).To(prometheus.MetricExistsForEachGPU(gpusByNode),
+				"%s is not exported for every GPU", metric.Name)
+		}
+	})
+
+	It("Should export sane DCGM metric values", Label("dcgm-exporter-values"), func() {
+		for _, metric := range dcgmexporter.DefaultMetrics {
+			samples, err := prometheusClient.Query(metric.Name)
+			Expect(err).ToNot(HaveOccurred(), "error querying %s: %v", metric.Name, err)
+			Expect(samples).ToNot(BeEmpty(), "%s is not exported", metric.Name)
+			Expect(samples).To(prometheus.HaveValuesBetween(metric.Min, metric.Max),
+				"%s is out of its sane range", metric.Name)
+		}
+	})
+
+	It("Should have data for every query of the GPU dashboards", Label("dcgm-exporter-dashboards"), func() {
+		By(fmt.Sprintf("List the GPU console dashboards in namespace %s", dcgmexporter.DashboardNamespace))
+		dashboards, err := dcgmexporter.GPUDashboards(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error listing the GPU dashboards: %v", err)
+		Expect(dashboards).ToNot(BeEmpty(), "no console dashboard queries the DCGM exporter metrics")
+
+		var brokenQueries []string
+
+		for _, dashboard := range dashboards {
+			By(fmt.Sprintf("Run the %d queries of dashboard '%s'", len(dashboard.Queries), dashboard.Title))
+			for _, query := range dashboard.Queries {
+				expr := dcgmexporter.ExpandQuery(query.Expr)
+
+				samples, err := prometheusClient.Query(expr)
+				if err != nil || len(samples) == 0 {
+					glog.V(gpuparams.GpuLogLevel).Infof("Query of panel '%s' returned no data: %v", query.Panel, err)
+					brokenQueries = append(brokenQueries, fmt.Sprintf("%s/%s: %s", dashboard.ConfigMap, query.Panel,
+						expr))
+				}
+			}
+		}
+
+		Expect(brokenQueries).To(BeEmpty(), "dashboard queries returned no data")
+	})
+
+	It("Should load the recommended GPU alerting rules", Label("dcgm-exporter-rules"), func() {
+		By(fmt.Sprintf("Create PrometheusRule %s in namespace %s", dcgmexporter.AlertRuleName,
+			nvidiagpu.NvidiaGPUNamespace))
+		err := prometheus.CreatePrometheusRule(inittools.APIClient, dcgmexporter.RecommendedAlertRules())
+		Expect(err).ToNot(HaveOccurred(), "error creating PrometheusRule %s: %v", dcgmexporter.AlertRuleName, err)
+		rulesCreated = true
+
+		By("Check Prometheus loads every recommended alerting rule")
+		err = await.Match(context.TODO(), "Prometheus to load the recommended alerting rules",
+			scrapePollInterval, rulesLoadTimeout, func(context.Context) (interface{}, error) {
+				rules, err := prometheusClient.Rules()
+				if err != nil {
+					return nil, err
+				}
+
+				health := map[string]string{}
+
+				for _, rule := range rules {
+					if rule.Group == dcgmexporter.AlertRuleGroup {
+						health[rule.Name] = rule.Health
+					}
+				}
+
+				return health, nil
+			}, SatisfyAll(HaveLen(len(dcgmexporter.RecommendedAlertNames())), HaveEach("ok")))
+		Expect(err).ToNot(HaveOccurred(), "Prometheus did not load the recommended alerting rules: %v", err)
+	})
+
+	It("Should not fire the GPU fault alerts on healthy GPUs", Label("dcgm-exporter-alerts-idle"), func() {
+		if !rulesCreated {
+			Skip("The recommended GPU alerting rules are not loaded")
+		}
+
+		alertmanagerClient, err := prometheus.NewAlertmanagerClient(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error creating the Alertmanager client: %v", err)
+
+		for _, alertName := range dcgmexporter.HealthyGPUAlerts {
+			By(fmt.Sprintf("Check alert %s is not firing", alertName))
+			alerts, err := alertmanagerClient.FiringAlerts(alertName)
+			Expect(err).ToNot(HaveOccurred(), "error querying alert %s: %v", alertName, err)
+			Expect(alerts).To(BeEmpty(), "alert %s is firing on healthy GPUs", alertName)
+		}
+	})
+
+	It("Should fire the high utilization alert under gpu-burn", Label("dcgm-exporter-alerts-firing"), func() {
+		if !rulesCreated {
+			Skip("The recommended GPU alerting rules are not loaded")
+		}
+
+		workloadNode := gpuNodes[0].Object.Name
+
+		By("Create the DCGM exporter test namespace")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		clusterArch, err := get.GetClusterArchitecture(inittools.APIClient, nodeSelector)
+		Expect(err).ToNot(HaveOccurred(), "error getting cluster architecture: %v", err)
+
+		burnImage, err := burnjob.Images.Image(clusterArch)
+		Expect(err).ToNot(HaveOccurred(), "error selecting the gpu-burn image: %v", err)
+
+		By("Check the gpu-burn image is reachable")
+		Expect(disconnected.CheckImages(burnImage)).ToNot(HaveOccurred(),
+			"the gpu-burn image is not reachable through the mirrors")
+		reporter.RecordImageDigests(clusterArch, burnImage)
+
+		By(fmt.Sprintf("Burn a GPU of node %s for %s", workloadNode, alertBurnDuration))
+		alertJob, err = burnjob.NewBuilder(inittools.APIClient, AlertJobName, TestNamespace,
+			disconnected.Image(burnImage)).
+			WithDuration(alertBurnDuration).
+			WithNodeSelector(map[string]string{"kubernetes.io/hostname": workloadNode}).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating gpu-burn Job %s: %v", AlertJobName, err)
+
+		alertmanagerClient, err := prometheus.NewAlertmanagerClient(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error creating the Alertmanager client: %v", err)
+
+		By(fmt.Sprintf("Check alert %s fires for node %s", dcgmexporter.HighUtilizationAlert, workloadNode))
+		err = await.Match(context.TODO(),
+			fmt.Sprintf("alert %s to fire for node %s", dcgmexporter.HighUtilizationAlert, workloadNode),
+			alertPollInterval, nvidiagpu.BurnPodCreationTimeout+alertFiringTimeout,
+			func(context.Context) (interface{}, error) {
+				return alertmanagerClient.FiringAlerts(dcgmexporter.HighUtilizationAlert)
+			}, ContainElement(HaveField("Labels", HaveKeyWithValue(dcgmexporter.HostnameLabel, workloadNode))))
+		Expect(err).ToNot(HaveOccurred(), "alert %s did not fire for node %s: %v", dcgmexporter.HighUtilizationAlert,
+			workloadNode, err)
+
+		By(fmt.Sprintf("Delete gpu-burn Job %s", AlertJobName))
+		Expect(alertJob.Delete()).To(Succeed(), "error deleting gpu-burn Job %s", AlertJobName)
+		alertJob = nil
+	})
+})
@@ -0,0 +1,296 @@
+package dra
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/dra"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where DRA workloads will run
+	TestNamespace = "test-dra"
+	// DeviceClassName is the name of the DeviceClass created by the tests
+	DeviceClassName = "nvidia-ci-gpu"
+	// WorkloadImage is the container image of the DRA workload pods
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+
+	driverInstallTimeout      = 15 * time.Minute
+	resourceSlicePollInterval = 15 * time.Second
+	resourceSliceTimeout      = 10 * time.Minute
+	workloadRunningTimeout    = 5 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("DRA", Ordered, Label(tsparams.LabelSuite, "dra"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.DisruptsOperands), func() {
+	var (
+		nsBuilder          *namespace.Builder
+		gpuNode            *nodes.Builder
+		gpuCount           int
+		driverInstalled    bool
+		deviceClassCreated bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting DRA test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		available, err := dra.ResourceAPIAvailable(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error discovering the DRA API: %v", err)
+
+		if !available {
+			Skip(fmt.Sprintf("%s is not served, the DynamicResourceAllocation feature gate must be enabled",
+				dra.ResourceAPIGroupVersion))
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		By("Find a GPU worker node")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		gpuNode = gpuNodes[0]
+		gpuCount = get.GPUCount(gpuNode)
+		Expect(gpuCount).To(BeNumerically(">", 0), "node %s has no nvidia.com/gpu.count label",
+			gpuNode.Object.Name)
+		glog.V(gpuparams.GpuLogLevel).Infof("Using node '%s' with %d GPUs for DRA tests",
+			gpuNode.Object.Name, gpuCount)
+
+		if nvidiaGPUConfig.DRADriverChartVersion != "" {
+			By(fmt.Sprintf("Install the NVIDIA DRA driver chart version %s", nvidiaGPUConfig.DRADriverChartVersion))
+			err = dra.InstallDriver(inittools.APIClient, nvidiaGPUConfig.DRADriverChartVersion, driverInstallTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error installing the NVIDIA DRA driver: %v", err)
+			driverInstalled = true
+		} else {
+			slices, err := dra.ListResourceSlices(inittools.APIClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error listing ResourceSlices: %v", err)
+
+			if len(slices) == 0 {
+				Skip(fmt.Sprintf("NVIDIAGPU_DRA_DRIVER_CHART_VERSION is not set and no ResourceSlice of driver "+
+					"%s found for node %s", dra.DriverName, gpuNode.Object.Name))
+			}
+		}
+
+		By("Create the DRA test namespace")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		By(fmt.Sprintf("Create DeviceClass %s", DeviceClassName))
+		_, err = dra.CreateDeviceClass(inittools.APIClient, DeviceClassName)
+		Expect(err).ToNot(HaveOccurred(), "error creating DeviceClass %s: %v", DeviceClassName, err)
+		deviceClassCreated = true
+	})
+
+	AfterEach(func() {
+		deleteWorkloadPods()
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.DeleteAndWait(workloadRunningTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if deviceClassCreated {
+			if err := dra.DeleteDeviceClass(inittools.APIClient, DeviceClassName); err != nil {
+				glog.Errorf("Error deleting DeviceClass %s: %v", DeviceClassName, err)
+			}
+		}
+
+		if driverInstalled && nvidiaGPUConfig.CleanupAfterTest {
+			By("Uninstall the NVIDIA DRA driver")
+			if err := dra.UninstallDriver(inittools.APIClient, driverInstallTimeout); err != nil {
+				glog.Errorf("Error uninstalling the NVIDIA DRA driver: %v", err)
+			}
+		}
+	})
+
+	It("Should publish the node GPUs in ResourceSlices", Label("dra-resourceslices"), func() {
+		By(fmt.Sprintf("Wait for driver %s to publish %d devices for node %s", dra.DriverName, gpuCount,
+			gpuNode.Object.Name))
+		err := await.Match(context.TODO(),
+			fmt.Sprintf("driver %s to publish the GPUs of node %s", dra.DriverName, gpuNode.Object.Name),
+			resourceSlicePollInterval, resourceSliceTimeout, func(context.Context) (interface{}, error) {
+				slices, err := dra.ListResourceSlices(inittools.APIClient, gpuNode.Object.Name)
+				if err != nil {
+					return nil, err
+				}
+
+				return dra.CountDevices(slices), nil
+			}, BeNumerically(">=", gpuCount))
+		Expect(err).ToNot(HaveOccurred(), "driver %s did not publish the GPUs of node %s: %v", dra.DriverName,
+			gpuNode.Object.Name, err)
+	})
+
+	It("Should allocate a GPU to a pod through a ResourceClaim", Label("dra-claim"), func() {
+		claimName := "dra-gpu-claim"
+
+		By(fmt.Sprintf("Create ResourceClaim %s for DeviceClass %s", claimName, DeviceClassName))
+		_, err := dra.CreateResourceClaim(inittools.APIClient, claimName, TestNamespace, DeviceClassName)
+		Expect(err).ToNot(HaveOccurred(), "error creating ResourceClaim %s: %v", claimName, err)
+
+		DeferCleanup(func() {
+			if err := dra.DeleteResourceClaim(inittools.APIClient, claimName, TestNamespace); err != nil {
+				glog.Errorf("Error deleting ResourceClaim %s: %v", claimName, err)
+			}
+		})
+
+		podBuilder := createWorkloadPod("dra-pod", gpuNode.Object.Name, claimName)
+		err = podBuilder.WaitUntilRunning(workloadRunningTimeout)
+		Expect(err).ToNot(HaveOccurred(), "pod %s is not running: %v", podBuilder.Definition.Name, err)
+
+		By("Verify the ResourceClaim is allocated by the NVIDIA DRA driver")
+		claim, err := dra.GetResourceClaim(inittools.APIClient, claimName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error getting ResourceClaim %s: %v", claimName, err)
+		Expect(claim.Status.Allocation).ToNot(BeNil(), "ResourceClaim %s is not allocated", claimName)
+		Expect(claim.Status.Allocation.Devices.Results).To(HaveLen(1),
+			"ResourceClaim %s should be allocated exactly one device", claimName)
+		Expect(claim.Status.Allocation.Devices.Results[0].Driver).To(Equal(dra.DriverName))
+		Expect(claim.Status.ReservedFor).ToNot(BeEmpty(), "ResourceClaim %s is not reserved for the pod",
+			claimName)
+
+		By("Verify the pod sees exactly the allocated GPU")
+		gpus := listPodGPUs(podBuilder)
+		Expect(gpus).To(HaveLen(1), "pod %s should see exactly one GPU", podBuilder.Definition.Name)
+	})
+
+	It("Should share the GPU of a ResourceClaim between pods", Label("dra-shared-claim"), func() {
+		claimName := "dra-shared-gpu-claim"
+
+		By(fmt.Sprintf("Create ResourceClaim %s shared by two pods", claimName))
+		_, err := dra.CreateResourceClaim(inittools.APIClient, claimName, TestNamespace, DeviceClassName)
+		Expect(err).ToNot(HaveOccurred(), "error creating ResourceClaim %s: %v", claimName, err)
+
+		DeferCleanup(func() {
+			if err := dra.DeleteResourceClaim(inittools.APIClient, claimName, TestNamespace); err != nil {
+				glog.Errorf("Error deleting ResourceClaim %s: %v", claimName, err)
+			}
+		})
+
+		var podGPUs [][]string
+
+		for _, podName := range []string{"dra-shared-pod-0", "dra-shared-pod-1"} {
+			podBuilder := createWorkloadPod(podName, gpuNode.Object.Name, claimName)
+			err = podBuilder.WaitUntilRunning(workloadRunningTimeout)
+			Expect(err).ToNot(HaveOccurred(), "pod %s is not running: %v", podName, err)
+
+			gpus := listPodGPUs(podBuilder)
+			Expect(gpus).To(HaveLen(1), "pod %s should see exactly one GPU", podName)
+			podGPUs = append(podGPUs, gpus)
+		}
+
+		Expect(podGPUs[0]).To(Equal(podGPUs[1]), "pods sharing ResourceClaim %s see different GPUs", claimName)
+
+		claim, err := dra.GetResourceClaim(inittools.APIClient, claimName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error getting ResourceClaim %s: %v", claimName, err)
+		Expect(claim.Status.ReservedFor).To(HaveLen(2), "ResourceClaim %s should be reserved for both pods",
+			claimName)
+	})
+})
+
+// createWorkloadPod creates a DRA workload pod pinned to the node and consuming the ResourceClaim.
+func createWorkloadPod(podName, nodeName, claimName string) *pod.Builder {
+	workloadPod := dra.CreateDRAPod(podName, TestNamespace, nodeName, disconnected.Image(WorkloadImage), claimName)
+
+	proxy.Inject(inittools.APIClient, &workloadPod.Spec)
+	_, err := inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), workloadPod, metav1.CreateOptions{})
+	Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", podName, err)
+
+	podBuilder, err := pod.Pull(inittools.APIClient, podName, TestNamespace)
+	Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", podName, err)
+
+	return podBuilder
+}
+
+// listPodGPUs returns the GPUs listed by nvidia-smi -L in the workload pod log.
+func listPodGPUs(podBuilder *pod.Builder) []string {
+	var gpus []string
+
+	err := await.Match(context.TODO(), fmt.Sprintf("pod %s to list its GPUs", podBuilder.Definition.Name),
+		5*time.Second, time.Minute, func(context.Context) (interface{}, error) {
+			podLog, err := podBuilder.GetFullLog(dra.WorkloadContainerName)
+			if err != nil {
+				return nil, err
+			}
+
+			gpus = nil
+
+			for _, line := range strings.Split(podLog, "\n") {
+				if strings.HasPrefix(line, "GPU ") {
+					gpus = append(gpus, strings.TrimSpace(line))
+				}
+			}
+
+			return gpus, nil
+		}, Not(BeEmpty()))
+	Expect(err).ToNot(HaveOccurred(), "pod %s did not list any GPU: %v", podBuilder.Definition.Name, err)
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Pod %s sees GPUs: %v", podBuilder.Definition.Name, gpus)
+
+	return gpus
+}
+
+// deleteWorkloadPods deletes all the DRA workload pods from the test namespace.
+func deleteWorkloadPods() {
+	workloadPods, err := pod.List(inittools.APIClient, TestNamespace,
+		metav1.ListOptions{LabelSelector: "app=dra-test-app"})
+	if err != nil {
+		glog.Errorf("Error listing pods in namespace %s: %v", TestNamespace, err)
+
+		return
+	}
+
+	for _, workloadPod := range workloadPods {
+		if _, err := workloadPod.DeleteAndWait(workloadRunningTimeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", workloadPod.Object.Name, err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package drivercustom
+
+import (
+	"fmt"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/drivercustom"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/cleanup"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// DriverEnvName is the environment variable set in the driver container
+	DriverEnvName = "NVIDIA_CI_DRIVER_ENV"
+	// DriverEnvValue is the value of DriverEnvName
+	DriverEnvValue = "customized"
+	// ModuleParameter is the nvidia module parameter set through the kernelModuleConfig ConfigMap
+	ModuleParameter = "RestrictProfilingToAdminUsers"
+	// ModuleParameterValue is the value of ModuleParameter, the driver default being 1
+	ModuleParameterValue = "0"
+
+	clusterPolicyReadyTimeout = 15 * time.Minute
+)
+
+var _ = Describe("Driver customization", Ordered, Label(tsparams.LabelSuite, "driver-custom"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.DisruptsOperands), func() {
+	var (
+		gpuNodes              []*nodes.Builder
+		previousSpec          *nvidiagpuv1.DriverSpec
+		kernelModuleConfigMap *configmap.Builder
+		repoConfigMap         *configmap.Builder
+	)
+
+	customization := drivercustom.Customization{
+		Env:              map[string]string{DriverEnvName: DriverEnvValue},
+		ModuleParameters: map[string]string{ModuleParameter: ModuleParameterValue},
+	}
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting driver customization test suite")
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if !clusterPolicyBuilder.Definition.Spec.Driver.IsEnabled() {
+			Skip(fmt.Sprintf("The driver is disabled in ClusterPolicy '%s'", nvidiagpu.ClusterPolicyName))
+		}
+
+		By("Find the GPU worker nodes")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By(fmt.Sprintf("Create the kernelModuleConfig ConfigMap %s", drivercustom.KernelModuleConfigMapName))
+		kernelModuleConfigMap, err = configmap.NewBuilder(inittools.APIClient, drivercustom.KernelModuleConfigMapName,
+			nvidiagpu.NvidiaGPUNamespace).
+			WithData(map[string]string{drivercustom.KernelModuleConfigKey: customization.KernelModuleConfig()}).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating ConfigMap %s: %v", drivercustom.KernelModuleConfigMapName,
+			err)
+
+		By(fmt.Sprintf("Create the repoConfig ConfigMap %s", drivercustom.RepoConfigMapName))
+		repoConfigMap, err = configmap.NewBuilder(inittools.APIClient, drivercustom.RepoConfigMapName,
+			nvidiagpu.NvidiaGPUNamespace).
+			WithData(map[string]string{drivercustom.RepoConfigKey: drivercustom.RepoConfig()}).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating ConfigMap %s: %v", drivercustom.RepoConfigMapName, err)
+
+		By("Customize the driver env, kernel module parameters and repoConfig in the ClusterPolicy")
+		previousSpec, err = drivercustom.ApplyCustomization(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+			customization)
+		Expect(err).ToNot(HaveOccurred(), "error customizing the driver: %v", err)
+		DeferCleanup(cleanup.Register("the ClusterPolicy driver spec", func() error {
+			return drivercustom.RestoreDriverSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec)
+		}))
+
+		By("Wait for the customized driver to roll out")
+		err = driverupgrade.DriverDaemonSetsReady(inittools.APIClient, driverupgrade.DriverRolloutCheckInterval,
+			driverupgrade.DriverRolloutTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for the customized driver to roll out: %v", err)
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+	})
+
+	AfterAll(func() {
+		if previousSpec != nil {
+			By("Restore the original driver spec")
+			if err := drivercustom.RestoreDriverSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring the driver spec: %v", err)
+			}
+
+			if err := driverupgrade.DriverDaemonSetsReady(inittools.APIClient,
+				driverupgrade.DriverRolloutCheckInterval, driverupgrade.DriverRolloutTimeout); err != nil {
+				glog.Errorf("Error waiting for the original driver to roll out: %v", err)
+			}
+		}
+
+		for _, configMapBuilder := range []*configmap.Builder{kernelModuleConfigMap, repoConfigMap} {
+			if configMapBuilder != nil && configMapBuilder.Exists() {
+				if err := configMapBuilder.Delete(); err != nil {
+					glog.Errorf("Error deleting ConfigMap %s: %v", configMapBuilder.Definition.Name, err)
+				}
+			}
+		}
+	})
+
+	It("Should set the custom env in the driver container", Label("driver-custom-env"), func() {
+		for _, gpuNode := range gpuNodes {
+			By(fmt.Sprintf("Check %s in the driver container of node %s", DriverEnvName, gpuNode.Object.Name))
+			value, err := drivercustom.DriverEnv(inittools.APIClient, gpuNode.Object.Name, DriverEnvName)
+			Expect(err).ToNot(HaveOccurred(), "error reading %s on node %s: %v", DriverEnvName, gpuNode.Object.Name,
+				err)
+			Expect(value).To(Equal(DriverEnvValue), "the driver container of node %s has the wrong %s",
+				gpuNode.Object.Name, DriverEnvName)
+		}
+	})
+
+	It("Should load the nvidia module with the custom parameters", Label("driver-custom-module-params"), func() {
+		for _, gpuNode := range gpuNodes {
+			By(fmt.Sprintf("Check the nvidia module parameters of node %s", gpuNode.Object.Name))
+			parameters, err := drivercustom.ModuleParameters(inittools.APIClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error reading the nvidia module parameters of node %s: %v",
+				gpuNode.Object.Name, err)
+			Expect(parameters).To(HaveKeyWithValue(ModuleParameter, ModuleParameterValue),
+				"the nvidia module of node %s was not loaded with the kernelModuleConfig parameters",
+				gpuNode.Object.Name)
+		}
+	})
+
+	It("Should mount the custom repoConfig in the driver container", Label("driver-custom-repo-config"), func() {
+		for _, gpuNode := range gpuNodes {
+			By(fmt.Sprintf("Check the custom repository file in the driver container of node %s", gpuNode.Object.Name))
+			repoFile, err := drivercustom.RepoConfigFile(inittools.APIClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error reading the custom repository file of node %s: %v",
+				gpuNode.Object.Name, err)
+			Expect(repoFile).To(ContainSubstring("["+drivercustom.RepoID+"]"),
+				"the driver container of node %s does not have the repoConfig repository", gpuNode.Object.Name)
+		}
+	})
+})
@@ -0,0 +1,219 @@
+package driverupgrade
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/clusterupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/sno"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the workload running across the driver upgrade and rollback is deployed
+	TestNamespace = "test-driver-upgrade"
+	// WorkloadDeploymentName is the name of the GPU workload deployment running across the driver version changes
+	WorkloadDeploymentName = "driver-upgrade-workload"
+	// WorkloadImage is the container image of the GPU workload
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+
+	clusterPolicyReadyTimeout = 15 * time.Minute
+	workloadReadyTimeout      = 10 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Driver Upgrade", Ordered, Label(tsparams.LabelSuite, "driver-upgrade"), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.DisruptsOperands), func() {
+	var (
+		nodeSelector    labels.Set
+		originalVersion string
+		rollbackVersion string
+		upgradeVersion  string
+		versionChanged  bool
+		upgradeDone     bool
+		singleNode      bool
+		nsBuilder       *namespace.Builder
+		workloadBuilder *deployment.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Driver Upgrade test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.DriverUpgradeVersion == "" {
+			Skip("NVIDIAGPU_DRIVER_UPGRADE_VERSION must be set to run the driver upgrade tests")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		originalVersion = clusterPolicyBuilder.Definition.Spec.Driver.Version
+		upgradeVersion = nvidiaGPUConfig.DriverUpgradeVersion
+
+		rollbackVersion = nvidiaGPUConfig.DriverRollbackVersion
+		if rollbackVersion == "" {
+			rollbackVersion = originalVersion
+		}
+
+		if rollbackVersion == "" {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' does not pin a driver version, NVIDIAGPU_DRIVER_ROLLBACK_VERSION "+
+				"must be set to run the driver upgrade tests", nvidiagpu.ClusterPolicyName))
+		}
+
+		if rollbackVersion == upgradeVersion {
+			Skip(fmt.Sprintf("The rollback and upgrade driver versions are both '%s'", upgradeVersion))
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Driver versions: original '%s', rollback '%s', upgrade '%s'",
+			originalVersion, rollbackVersion, upgradeVersion)
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		singleNode, err = sno.IsSingleNode(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error detecting Single-Node OpenShift: %v", err)
+	})
+
+	AfterAll(func() {
+		if workloadBuilder != nil {
+			if err := workloadBuilder.DeleteAndWait(workloadReadyTimeout); err != nil {
+				glog.Errorf("Error deleting deployment %s: %v", WorkloadDeploymentName, err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if versionChanged {
+			By(fmt.Sprintf("Restore the original driver version '%s'", originalVersion))
+			if _, err := driverupgrade.SetDriverVersion(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				originalVersion); err != nil {
+				glog.Errorf("Error restoring the ClusterPolicy driver version: %v", err)
+			}
+		}
+	})
+
+	It("Should run a GPU workload on the pinned driver version", Label("driver-upgrade-pin"), func() {
+		versionChanged = rollbackVersion != originalVersion
+		setDriverVersion(nodeSelector, rollbackVersion)
+
+		By("Start a GPU workload running across the driver version changes")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		var err error
+		workloadBuilder, err = clusterupgrade.CreateWorkloadDeployment(inittools.APIClient, WorkloadDeploymentName,
+			TestNamespace, disconnected.Image(WorkloadImage))
+		Expect(err).ToNot(HaveOccurred(), "error creating deployment %s: %v", WorkloadDeploymentName, err)
+
+		expectWorkloadRunning(workloadBuilder, sno.DisruptionTimeout(singleNode, workloadReadyTimeout))
+	})
+
+	It("Should upgrade the driver and recover the GPU workload", Label("driver-upgrade-upgrade"), func() {
+		if workloadBuilder == nil {
+			Skip("The GPU workload was not started on the pinned driver version")
+		}
+
+		versionChanged = true
+		setDriverVersion(nodeSelector, upgradeVersion)
+		upgradeDone = true
+
+		expectWorkloadRunning(workloadBuilder, sno.DisruptionTimeout(singleNode, workloadReadyTimeout))
+	})
+
+	It("Should roll the driver back and recover the GPU workload", Label("driver-upgrade-rollback"), func() {
+		if !upgradeDone {
+			Skip("The driver was not upgraded")
+		}
+
+		setDriverVersion(nodeSelector, rollbackVersion)
+		versionChanged = rollbackVersion != originalVersion
+
+		expectWorkloadRunning(workloadBuilder, sno.DisruptionTimeout(singleNode, workloadReadyTimeout))
+	})
+})
+
+// setDriverVersion sets the ClusterPolicy driver version and waits for every GPU node to run it.
+func setDriverVersion(nodeSelector labels.Set, version string) {
+	By(fmt.Sprintf("Set the ClusterPolicy driver version to '%s'", version))
+	_, err := driverupgrade.SetDriverVersion(inittools.APIClient, nvidiagpu.ClusterPolicyName, version)
+	Expect(err).ToNot(HaveOccurred(), "error setting the driver version: %v", err)
+
+	By(fmt.Sprintf("Wait for the driver daemonset to converge on version '%s'", version))
+	err = driverupgrade.DriverVersionReady(inittools.APIClient, nodeSelector, version,
+		driverupgrade.DriverRolloutCheckInterval, driverupgrade.DriverRolloutTimeout)
+	Expect(err).ToNot(HaveOccurred(), "driver version %s was not rolled out: %v", version, err)
+
+	err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+	Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+}
+
+// expectWorkloadRunning checks that the workload deployment is ready within the timeout and its pods list their GPU.
+// On Single-Node OpenShift the workload pods cannot move away from the node of the restarted driver, hence the
+// longer sno.DisruptionTimeout.
+func expectWorkloadRunning(workloadBuilder *deployment.Builder, timeout time.Duration) {
+	By(fmt.Sprintf("Wait for deployment %s to be ready", WorkloadDeploymentName))
+	Expect(workloadBuilder.IsReady(timeout)).To(BeTrue(), "deployment %s is not ready",
+		WorkloadDeploymentName)
+
+	workloadPods, err := pod.List(inittools.APIClient, TestNamespace,
+		metav1.ListOptions{LabelSelector: clusterupgrade.WorkloadPodLabel})
+	Expect(err).ToNot(HaveOccurred(), "error listing pods in namespace %s: %v", TestNamespace, err)
+	Expect(workloadPods).ToNot(BeEmpty(), "no workload pod found in namespace %s", TestNamespace)
+
+	for _, workloadPod := range workloadPods {
+		err := await.Match(context.TODO(), fmt.Sprintf("pod %s to list the GPU", workloadPod.Object.Name),
+			10*time.Second, time.Minute, func(context.Context) (interface{}, error) {
+				return workloadPod.GetFullLog(clusterupgrade.WorkloadContainerName)
+			}, ContainSubstring("GPU 0:"))
+		Expect(err).ToNot(HaveOccurred(), "pod %s does not list the GPU: %v", workloadPod.Object.Name, err)
+	}
+}
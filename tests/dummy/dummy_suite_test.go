@@ -6,7 +6,6 @@ import (
 	"runtime"
 	"testing"
 
-	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -16,14 +15,12 @@ import (
 var _, currentFile, _, _ = runtime.Caller(0)
 
 func TestDummy(t *testing.T) {
-	_, reporterConfig := GinkgoConfiguration()
-	reporterConfig.JUnitReport = inittools.GeneralConfig.GetJunitReportPath(currentFile)
+	suiteConfig, reporterConfig := GinkgoConfiguration()
+	reporter.ApplyFlakeAttempts(&suiteConfig)
 
 	RegisterFailHandler(Fail)
-	RunSpecs(t, "Dummy", Label(), reporterConfig)
+	RunSpecs(t, "Dummy", Label(), suiteConfig, reporterConfig)
 }
 
-var _ = JustAfterEach(func() {
-	reporter.ReportIfFailed(
-		CurrentSpecReport(), currentFile, tsparams.ReporterNamespacesToDump, tsparams.ReporterCRDsToDump, clients.SetScheme)
-})
+var _ = reporter.RegisterSuiteHooks(
+	currentFile, tsparams.ReporterNamespacesToDump, tsparams.ReporterCRDsToDump, clients.SetScheme)
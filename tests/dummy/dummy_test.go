@@ -8,6 +8,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
 )
 
 type Config struct {
@@ -18,7 +19,8 @@ var (
 	config *Config
 )
 
-var _ = Describe("Dummy", Ordered, Label("dummy"), func() {
+var _ = Describe("Dummy", Ordered, Label("dummy"), cilabels.Spec(
+	cilabels.Smoke, cilabels.Short, cilabels.NonDisruptive), func() {
 
 	Context("Dummy for testing basic CI image functionality without access to a GPU", Label("dummy"), func() {
 
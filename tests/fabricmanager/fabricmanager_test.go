@@ -0,0 +1,181 @@
+package fabricmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/fabricmanager"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nccl"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiasmi"
+	ncclworkload "github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/nccl"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the NVLink nccl-tests Jobs run
+	TestNamespace = "test-fabricmanager"
+	// JobPrefix is the name prefix of the NVLink nccl-tests Jobs, followed by the node index
+	JobPrefix = "nccl-nvlink"
+
+	jobCompleteTimeout = 20 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("FabricManager", Ordered, Label(tsparams.LabelSuite, "fabricmanager"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.NonDisruptive, cilabels.NVSwitch), func() {
+	var (
+		nvswitchNodes []*nodes.Builder
+		nsBuilder     *namespace.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting FabricManager test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		for _, gpuNode := range gpuNodes {
+			nvswitchCount, err := fabricmanager.NVSwitchCount(inittools.APIClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error counting the NVSwitches of node %s: %v", gpuNode.Object.Name,
+				err)
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Node %s has %d NVSwitches", gpuNode.Object.Name, nvswitchCount)
+
+			if nvswitchCount > 0 {
+				nvswitchNodes = append(nvswitchNodes, gpuNode)
+			}
+		}
+
+		if len(nvswitchNodes) == 0 {
+			Skip("No GPU worker node with NVSwitches found")
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should run a healthy fabric manager on the NVSwitch nodes", Label("fabricmanager-deployed"), func() {
+		for _, nvswitchNode := range nvswitchNodes {
+			nodeName := nvswitchNode.Object.Name
+
+			By(fmt.Sprintf("Check the fabric manager runs on node %s", nodeName))
+			running, err := fabricmanager.ProcessRunning(inittools.APIClient, nodeName)
+			Expect(err).ToNot(HaveOccurred(), "error looking for the fabric manager on node %s: %v", nodeName, err)
+			Expect(running).To(BeTrue(), "%s does not run in the driver container of node %s",
+				fabricmanager.ProcessName, nodeName)
+
+			report, err := nvidiasmi.QueryNode(inittools.APIClient, nodeName)
+			Expect(err).ToNot(HaveOccurred(), "error running nvidia-smi on node %s: %v", nodeName, err)
+			Expect(fabricmanager.UnregisteredGPUs(report)).To(BeEmpty(),
+				"the fabric manager did not register every GPU of node %s", nodeName)
+		}
+	})
+
+	It("Should bring every NVLink of the NVSwitch nodes up", Label("fabricmanager-nvlink"), func() {
+		for _, nvswitchNode := range nvswitchNodes {
+			nodeName := nvswitchNode.Object.Name
+
+			By(fmt.Sprintf("Check the NVLink status of node %s", nodeName))
+			nvlinkGPUs, err := nvidiasmi.QueryNodeNVLinkStatus(inittools.APIClient, nodeName)
+			Expect(err).ToNot(HaveOccurred(), "error getting node %s NVLink status: %v", nodeName, err)
+
+			if gpuCount := get.GPUCount(nvswitchNode); gpuCount > 0 {
+				Expect(nvlinkGPUs).To(HaveLen(gpuCount), "nvidia-smi nvlink does not report every GPU of node %s",
+					nodeName)
+			}
+
+			for _, nvlinkGPU := range nvlinkGPUs {
+				glog.V(gpuparams.GpuLogLevel).Infof("Node %s GPU %d NVLinks %+v", nodeName, nvlinkGPU.Index,
+					nvlinkGPU.Links)
+
+				Expect(nvlinkGPU.Links).ToNot(BeEmpty(), "GPU %d of node %s reports no NVLink", nvlinkGPU.Index,
+					nodeName)
+				Expect(nvlinkGPU.InactiveLinks()).To(BeEmpty(), "GPU %d of node %s has inactive NVLinks",
+					nvlinkGPU.Index, nodeName)
+			}
+		}
+	})
+
+	It("Should all-reduce at NVLink bandwidth across the GPUs of a node", Label("fabricmanager-nccl"), func() {
+		if nvidiaGPUConfig.NCCLTestsImage == "" {
+			Skip("NVIDIAGPU_NCCL_TESTS_IMAGE must be set to run the NVLink all-reduce")
+		}
+
+		By("Check the NCCL tests image is reachable")
+		Expect(disconnected.CheckImages(nvidiaGPUConfig.NCCLTestsImage)).ToNot(HaveOccurred(),
+			"the NCCL tests image is not reachable through the mirrors")
+
+		By(fmt.Sprintf("Create the privileged namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.PrivilegedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+
+		for index, nvswitchNode := range nvswitchNodes {
+			nodeName := nvswitchNode.Object.Name
+
+			gpuCount := get.GPUCount(nvswitchNode)
+			if gpuCount < 2 {
+				glog.V(gpuparams.GpuLogLevel).Infof("Skipping node %s with %d GPUs", nodeName, gpuCount)
+
+				continue
+			}
+
+			hostname := nvswitchNode.Object.Labels[corev1.LabelHostname]
+
+			// NCCL_P2P_LEVEL=NVL keeps NCCL from falling back to PCIe peer-to-peer transfers.
+			jobBuilder := ncclworkload.NewBuilder(inittools.APIClient, fmt.Sprintf("%s-%d", JobPrefix, index),
+				TestNamespace, disconnected.Image(nvidiaGPUConfig.NCCLTestsImage)).
+				WithGPUsPerNode(gpuCount).
+				WithNodeSelector(map[string]string{corev1.LabelHostname: hostname}).
+				WithEnv("NCCL_P2P_LEVEL", "NVL")
+
+			By(fmt.Sprintf("Run all_reduce_perf across %d GPUs of node %s", gpuCount, nodeName))
+			err := nccl.RunAllReduce(jobBuilder, jobCompleteTimeout, nvidiaGPUConfig.NVLinkMinBusBandwidth)
+			Expect(err).ToNot(HaveOccurred(), "error running all_reduce_perf: %v", err)
+		}
+	})
+})
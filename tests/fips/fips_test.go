@@ -0,0 +1,176 @@
+package fips
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/autoscaling"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/fips"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the FIPS workloads run
+	TestNamespace = "test-gpu-fips"
+	// WorkloadImage is the container image of the toolkit workload
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+	// CUDAImage is the cuda sample image of the CUDA workload
+	CUDAImage = cudasamples.VectorAddImage
+
+	clusterPolicyReadyTimeout = 15 * time.Minute
+	workloadTimeout           = 5 * time.Minute
+)
+
+var _ = Describe("FIPS", Ordered, Label(tsparams.LabelSuite, "fips"), cilabels.Spec(
+	cilabels.Regression, cilabels.Short, cilabels.NonDisruptive, cilabels.FIPS), func() {
+	var (
+		nsBuilder    *namespace.Builder
+		gpuNodes     []*nodes.Builder
+		nodeSelector labels.Set
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting FIPS test suite")
+
+		fipsCluster, err := fips.IsClusterFIPS(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error detecting FIPS mode: %v", err)
+
+		if !fipsCluster {
+			Skip("The cluster was not installed in FIPS mode")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload images are reachable")
+		Expect(disconnected.CheckImages(WorkloadImage, CUDAImage)).ToNot(HaveOccurred(),
+			"the workload images are not reachable through the mirrors")
+
+		By("Find the GPU worker nodes")
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.DeleteAndWait(workloadTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	// The FIPS diagnostics are appended to the pod exec logs before the suite reporter moves them to the report
+	// folder of the failed spec.
+	JustAfterEach(func() {
+		if !CurrentSpecReport().Failed() {
+			return
+		}
+
+		for _, gpuNode := range gpuNodes {
+			diagnostics := fips.Diagnostics(inittools.APIClient, gpuNode.Object.Name)
+			glog.V(gpuparams.GpuLogLevel).Info(diagnostics)
+
+			if err := reporter.AppendPodExecLog(diagnostics); err != nil {
+				glog.Errorf("Error saving node %s FIPS diagnostics: %v", gpuNode.Object.Name, err)
+			}
+		}
+	})
+
+	It("Should run the GPU nodes in FIPS mode", Label("fips-mode"), func() {
+		for _, gpuNode := range gpuNodes {
+			By(fmt.Sprintf("Check the kernel of node %s runs in FIPS mode", gpuNode.Object.Name))
+			enabled, err := fips.NodeFIPSEnabled(inittools.APIClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error reading node %s FIPS mode: %v", gpuNode.Object.Name, err)
+			Expect(enabled).To(BeTrue(), "node %s does not run in FIPS mode", gpuNode.Object.Name)
+		}
+	})
+
+	It("Should build, sign and load the GPU driver", Label("fips-driver"), func() {
+		By("Wait for the ClusterPolicy to be ready")
+		err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+		for _, gpuNode := range gpuNodes {
+			By(fmt.Sprintf("Check the nvidia module of node %s is loaded and signed", gpuNode.Object.Name))
+			signing, err := fips.NodeModuleSigning(inittools.APIClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error reading node %s module signing: %v", gpuNode.Object.Name, err)
+
+			if !signing.Enforced {
+				glog.V(gpuparams.GpuLogLevel).Infof("Node %s does not enforce module signatures, the nvidia "+
+					"module is unsigned: %v", gpuNode.Object.Name, signing.Unsigned)
+
+				continue
+			}
+
+			Expect(signing.Unsigned).To(BeFalse(), "node %s enforces module signatures and loaded an unsigned "+
+				"nvidia module", gpuNode.Object.Name)
+		}
+	})
+
+	It("Should run GPU containers with the container toolkit", Label("fips-toolkit"), func() {
+		podBuilder, err := autoscaling.NewWorkloadPod(inittools.APIClient, "fips-toolkit", TestNamespace,
+			disconnected.Image(WorkloadImage), nodeSelector, 0).Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating pod fips-toolkit: %v", err)
+
+		err = podBuilder.WaitUntilInStatus(corev1.PodSucceeded, workloadTimeout)
+		Expect(err).ToNot(HaveOccurred(), "pod fips-toolkit did not complete: %v", err)
+
+		logs, err := podBuilder.GetFullLog(autoscaling.WorkloadContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error getting pod fips-toolkit logs: %v", err)
+		Expect(logs).To(ContainSubstring("GPU 0"), "the toolkit did not expose a GPU to pod fips-toolkit")
+	})
+
+	It("Should run CUDA workloads", Label("fips-cuda"), func() {
+		sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, "fips-cuda", TestNamespace,
+			cudasamples.VectorAdd, disconnected.Image(CUDAImage)).
+			WithNodeSelector(nodeSelector).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating Job fips-cuda: %v", err)
+
+		defer func() {
+			if err := sampleBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting Job fips-cuda: %v", err)
+			}
+		}()
+
+		err = sampleBuilder.WaitUntilComplete(workloadTimeout)
+		Expect(err).ToNot(HaveOccurred(), "Job fips-cuda did not complete: %v", err)
+
+		result, err := sampleBuilder.GetResult()
+		Expect(err).ToNot(HaveOccurred(), "error getting Job fips-cuda result: %v", err)
+		Expect(result.Passed).To(BeTrue(), "the CUDA sample failed in Job fips-cuda")
+	})
+})
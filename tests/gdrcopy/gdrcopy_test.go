@@ -0,0 +1,225 @@
+package gdrcopy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gdrcopy"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpudirect"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/cleanup"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the GDRCopy sanity pod runs
+	TestNamespace = "test-gdrcopy"
+	// SanityPodName is the name of the pod running the GDRCopy sanity tests and benchmark
+	SanityPodName = "gdrcopy-sanity"
+
+	sanityCompleteTimeout = 15 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("GDRCopy", Ordered, Label(tsparams.LabelSuite, "gdrcopy"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.DisruptsOperands), func() {
+	var (
+		gpuNodes        []*nodes.Builder
+		nsBuilder       *namespace.Builder
+		gdrcopyChanged  bool
+		previousGDRCopy bool
+		gdrdrvUp        bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting GDRCopy test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		// The gdrdrv module is built and loaded by the driver container.
+		if !clusterPolicyBuilder.Definition.Spec.Driver.IsEnabled() {
+			Skip(fmt.Sprintf("The driver is disabled in ClusterPolicy '%s'", nvidiagpu.ClusterPolicyName))
+		}
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		allGPUNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(allGPUNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		gpuNodes = gdrcopy.SupportedNodes(allGPUNodes)
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node has a GPU supporting GDRCopy")
+		}
+
+		By(fmt.Sprintf("Create the privileged namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.PrivilegedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+
+		// The GDRCopy tools open the gdrdrv device of the host from a privileged pod.
+		err = gpudirect.CreateRDMAServiceAccount(inittools.APIClient, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error creating the privileged service account: %v", err)
+
+		DeferCleanup(cleanup.Register("the ClusterPolicy gdrcopy.enabled", func() error {
+			if !gdrcopyChanged {
+				return nil
+			}
+
+			_, err := gdrcopy.SetClusterPolicyGDRCopy(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousGDRCopy)
+
+			return err
+		}))
+	})
+
+	AfterAll(func() {
+		if gdrcopyChanged {
+			By(fmt.Sprintf("Restore the ClusterPolicy gdrcopy.enabled to %t", previousGDRCopy))
+			if _, err := gdrcopy.SetClusterPolicyGDRCopy(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousGDRCopy); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy gdrcopy.enabled: %v", err)
+			} else if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				driverupgrade.DriverRolloutTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should enable GDRCopy in the ClusterPolicy", Label("gdrcopy-enable"), func() {
+		var err error
+		gdrcopyChanged = true
+		previousGDRCopy, err = gdrcopy.SetClusterPolicyGDRCopy(inittools.APIClient, nvidiagpu.ClusterPolicyName, true)
+		Expect(err).ToNot(HaveOccurred(), "error enabling GDRCopy: %v", err)
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+			driverupgrade.DriverRolloutTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+	})
+
+	It("Should load the gdrdrv kernel module on the GPU nodes", Label("gdrcopy-gdrdrv"), func() {
+		By(fmt.Sprintf("Wait for kernel module %s on %d GPU node(s)", gdrcopy.GDRDrvModule, len(gpuNodes)))
+		err := gdrcopy.GDRDrvLoaded(inittools.APIClient, gpuNodes, driverupgrade.DriverRolloutCheckInterval,
+			driverupgrade.DriverRolloutTimeout)
+		Expect(err).ToNot(HaveOccurred(), "kernel module %s is not loaded on every GPU node: %v",
+			gdrcopy.GDRDrvModule, err)
+
+		By("Check the driver daemonsets running the gdrcopy container are healthy")
+		err = driverupgrade.DriverDaemonSetsReady(inittools.APIClient, driverupgrade.DriverRolloutCheckInterval,
+			driverupgrade.DriverRolloutTimeout)
+		Expect(err).ToNot(HaveOccurred(), "the driver daemonsets are not ready: %v", err)
+		gdrdrvUp = true
+	})
+
+	It("Should run the GDRCopy sanity tests and copy benchmark", Label("gdrcopy-benchmark"), func() {
+		if !gdrdrvUp {
+			Skip(fmt.Sprintf("Kernel module %s is not loaded", gdrcopy.GDRDrvModule))
+		}
+
+		if nvidiaGPUConfig.GDRCopyImage == "" {
+			Skip("NVIDIAGPU_GDRCOPY_IMAGE must be set to run the GDRCopy benchmark")
+		}
+
+		By("Check the GDRCopy image is reachable")
+		Expect(disconnected.CheckImages(nvidiaGPUConfig.GDRCopyImage)).ToNot(HaveOccurred(),
+			"the GDRCopy image is not reachable through the mirrors")
+
+		gpuNode := gpuNodes[0]
+
+		By(fmt.Sprintf("Run gdrcopy_sanity and gdrcopy_copybw on node %s", gpuNode.Object.Name))
+		sanityPod := gdrcopy.CreateSanityPod(SanityPodName, TestNamespace,
+			disconnected.Image(nvidiaGPUConfig.GDRCopyImage), gpuNode.Object.Name, gpudirect.RDMAServiceAccount)
+		proxy.Inject(inittools.APIClient, &sanityPod.Spec)
+		_, err := inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), sanityPod, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", SanityPodName, err)
+
+		var podBuilder *pod.Builder
+
+		DeferCleanup(func() {
+			if podBuilder == nil {
+				return
+			}
+
+			if _, err := podBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting pod %s: %v", SanityPodName, err)
+			}
+		})
+
+		err = await.Match(context.TODO(), fmt.Sprintf("pod %s to complete", SanityPodName),
+			10*time.Second, sanityCompleteTimeout, func(context.Context) (interface{}, error) {
+				podBuilder, err = pod.Pull(inittools.APIClient, SanityPodName, TestNamespace)
+				if err != nil {
+					return "", err
+				}
+
+				return podBuilder.Object.Status.Phase, nil
+			}, BeElementOf(corev1.PodSucceeded, corev1.PodFailed))
+		Expect(err).ToNot(HaveOccurred(), "pod %s did not complete: %v", SanityPodName, err)
+
+		output, err := podBuilder.GetFullLog(gdrcopy.SanityContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error getting pod %s log: %v", SanityPodName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Pod %s log:\n%s", SanityPodName, output)
+
+		Expect(podBuilder.Object.Status.Phase).To(Equal(corev1.PodSucceeded), "pod %s failed", SanityPodName)
+
+		bandwidths, err := gdrcopy.ParseCopyBandwidth(output)
+		Expect(err).ToNot(HaveOccurred(), "error parsing pod %s log: %v", SanityPodName, err)
+		Expect(bandwidths).To(HaveKey("write"), "gdrcopy_copybw did not report a write bandwidth")
+		Expect(bandwidths).To(HaveKey("read"), "gdrcopy_copybw did not report a read bandwidth")
+
+		for direction, bandwidth := range bandwidths {
+			Expect(bandwidth).To(BeNumerically(">", 0), "gdrcopy_copybw %s bandwidth is zero", direction)
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("GDRCopy bandwidth on node %s: write %.2f MB/s, read %.2f MB/s",
+			gpuNode.Object.Name, bandwidths["write"], bandwidths["read"])
+	})
+})
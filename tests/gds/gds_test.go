@@ -0,0 +1,215 @@
+package gds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gds"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpudirect"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/cleanup"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the gdsio pod runs
+	TestNamespace = "test-gds"
+	// GdsioPodName is the name of the pod running the gdsio benchmark
+	GdsioPodName = "gdsio"
+
+	gdsioCompleteTimeout = 20 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("GDS", Ordered, Label(tsparams.LabelSuite, "gds"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.DisruptsOperands), func() {
+	var (
+		gpuNodes     []*nodes.Builder
+		nodeSelector labels.Set
+		nsBuilder    *namespace.Builder
+		gdsChanged   bool
+		previousGDS  bool
+		nvidiaFSUp   bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting GDS test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		var err error
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By(fmt.Sprintf("Create the privileged namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.PrivilegedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+
+		// gdsio accesses the GPUDirect Storage and NVMe devices of the host from a privileged pod.
+		err = gpudirect.CreateRDMAServiceAccount(inittools.APIClient, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error creating the privileged service account: %v", err)
+
+		DeferCleanup(cleanup.Register("the ClusterPolicy gds.enabled", func() error {
+			if !gdsChanged {
+				return nil
+			}
+
+			_, err := gds.SetClusterPolicyGDS(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousGDS)
+
+			return err
+		}))
+	})
+
+	AfterAll(func() {
+		if gdsChanged {
+			By(fmt.Sprintf("Restore the ClusterPolicy gds.enabled to %t", previousGDS))
+			if _, err := gds.SetClusterPolicyGDS(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousGDS); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy gds.enabled: %v", err)
+			} else if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				driverupgrade.DriverRolloutTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should enable GPUDirect Storage in the ClusterPolicy", Label("gds-enable"), func() {
+		var err error
+		gdsChanged = true
+		previousGDS, err = gds.SetClusterPolicyGDS(inittools.APIClient, nvidiagpu.ClusterPolicyName, true)
+		Expect(err).ToNot(HaveOccurred(), "error enabling GDS: %v", err)
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+			driverupgrade.DriverRolloutTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+	})
+
+	It("Should load the nvidia-fs kernel module on the GPU nodes", Label("gds-nvidia-fs"), func() {
+		By(fmt.Sprintf("Wait for kernel module %s on %d GPU node(s)", gds.NvidiaFSModule, len(gpuNodes)))
+		err := gds.NvidiaFSLoaded(inittools.APIClient, gpuNodes, driverupgrade.DriverRolloutCheckInterval,
+			driverupgrade.DriverRolloutTimeout)
+		Expect(err).ToNot(HaveOccurred(), "kernel module %s is not loaded on every GPU node: %v",
+			gds.NvidiaFSModule, err)
+		nvidiaFSUp = true
+	})
+
+	It("Should run the gdsio benchmark against local NVMe", Label("gds-gdsio"), func() {
+		if !nvidiaFSUp {
+			Skip(fmt.Sprintf("Kernel module %s is not loaded", gds.NvidiaFSModule))
+		}
+
+		if nvidiaGPUConfig.GDSImage == "" || nvidiaGPUConfig.GDSNVMePath == "" {
+			Skip("NVIDIAGPU_GDS_IMAGE and NVIDIAGPU_GDS_NVME_PATH must be set to run gdsio")
+		}
+
+		By("Check the gdsio image is reachable")
+		Expect(disconnected.CheckImages(nvidiaGPUConfig.GDSImage)).ToNot(HaveOccurred(),
+			"the gdsio image is not reachable through the mirrors")
+
+		gpuNode := gpuNodes[0]
+
+		By(fmt.Sprintf("Run gdsio on node %s against %s", gpuNode.Object.Name, nvidiaGPUConfig.GDSNVMePath))
+		gdsioPod := gds.CreateGdsioPod(GdsioPodName, TestNamespace, disconnected.Image(nvidiaGPUConfig.GDSImage),
+			gpuNode.Object.Name, nvidiaGPUConfig.GDSNVMePath, gpudirect.RDMAServiceAccount)
+		proxy.Inject(inittools.APIClient, &gdsioPod.Spec)
+		_, err := inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), gdsioPod, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", GdsioPodName, err)
+
+		var podBuilder *pod.Builder
+
+		DeferCleanup(func() {
+			if podBuilder == nil {
+				return
+			}
+
+			if _, err := podBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting pod %s: %v", GdsioPodName, err)
+			}
+		})
+
+		err = await.Match(context.TODO(), fmt.Sprintf("pod %s to complete", GdsioPodName),
+			10*time.Second, gdsioCompleteTimeout, func(context.Context) (interface{}, error) {
+				podBuilder, err = pod.Pull(inittools.APIClient, GdsioPodName, TestNamespace)
+				if err != nil {
+					return "", err
+				}
+
+				return podBuilder.Object.Status.Phase, nil
+			}, BeElementOf(corev1.PodSucceeded, corev1.PodFailed))
+		Expect(err).ToNot(HaveOccurred(), "pod %s did not complete: %v", GdsioPodName, err)
+
+		output, err := podBuilder.GetFullLog(gds.GdsioContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error getting pod %s log: %v", GdsioPodName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Pod %s log:\n%s", GdsioPodName, output)
+
+		Expect(podBuilder.Object.Status.Phase).To(Equal(corev1.PodSucceeded), "pod %s failed", GdsioPodName)
+
+		if strings.Contains(output, gds.NVMeUnsupportedMarker) {
+			Skip(fmt.Sprintf("gdscheck does not report GDS support for NVMe on node %s", gpuNode.Object.Name))
+		}
+
+		throughputs, err := gds.ParseGdsioThroughput(output)
+		Expect(err).ToNot(HaveOccurred(), "error parsing pod %s log: %v", GdsioPodName, err)
+		Expect(throughputs).To(HaveLen(2), "expected a write and a read gdsio run")
+
+		for _, throughput := range throughputs {
+			Expect(throughput).To(BeNumerically(">", 0), "gdsio did not transfer any data")
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("gdsio GPUDirect Storage throughput on node %s: write %.2f GiB/s, "+
+			"read %.2f GiB/s", gpuNode.Object.Name, throughputs[0], throughputs[1])
+	})
+})
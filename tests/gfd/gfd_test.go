@@ -0,0 +1,185 @@
+package gfd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/arch"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+
+var _ = Describe("GFD", Ordered, Label(tsparams.LabelSuite, "gfd"), cilabels.Spec(
+	cilabels.Smoke, cilabels.Short, cilabels.NonDisruptive), func() {
+	var (
+		gpuNodes         []*nodes.Builder
+		nodeGPUs         map[string]*gfd.NodeGPUs
+		kernelModuleType string
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting GPU Feature Discovery test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		kernelModuleType = clusterPolicyBuilder.Definition.Spec.Driver.KernelModuleType
+
+		if !clusterPolicyBuilder.Definition.Spec.GPUFeatureDiscovery.IsEnabled() {
+			Skip(fmt.Sprintf("GPU Feature Discovery is disabled in ClusterPolicy '%s'", nvidiagpu.ClusterPolicyName))
+		}
+
+		By("Find the GPU worker nodes")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By("Query the GPUs of each node with nvidia-smi")
+		nodeGPUs = map[string]*gfd.NodeGPUs{}
+		for _, node := range gpuNodes {
+			nodeGPUs[node.Object.Name], err = gfd.QueryNodeGPUs(inittools.APIClient, node.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error querying the GPUs of node %s: %v", node.Object.Name, err)
+		}
+	})
+
+	It("Should label the GPU count", Label("gfd-count"), func() {
+		for _, node := range gpuNodes {
+			expected := strconv.Itoa(len(nodeGPUs[node.Object.Name].GPUs))
+			Expect(node.Object.Labels).To(HaveKeyWithValue(gfd.CountLabel, expected),
+				"node %s has an unexpected %s label", node.Object.Name, gfd.CountLabel)
+		}
+	})
+
+	It("Should label the GPU product", Label("gfd-product"), func() {
+		for _, node := range gpuNodes {
+			productName := gfd.ProductName(nodeGPUs[node.Object.Name].GPUs[0].Name)
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' GPU product is '%s', labeled '%s'", node.Object.Name,
+				productName, node.Object.Labels[gfd.ProductLabel])
+
+			// GFD appends a MIG strategy suffix, such as -SHARED or -MIG-1g.5gb, to the product name
+			Expect(node.Object.Labels).To(HaveKeyWithValue(gfd.ProductLabel, HavePrefix(productName)),
+				"node %s has an unexpected %s label", node.Object.Name, gfd.ProductLabel)
+		}
+	})
+
+	It("Should label the GPU memory", Label("gfd-memory"), func() {
+		for _, node := range gpuNodes {
+			gpu := nodeGPUs[node.Object.Name].GPUs[0]
+			if gpu.MIGMode == "Enabled" {
+				glog.V(gpuparams.GpuLogLevel).Infof("Skipping memory check of node '%s', MIG is enabled",
+					node.Object.Name)
+
+				continue
+			}
+
+			Expect(node.Object.Labels).To(HaveKeyWithValue(gfd.MemoryLabel, gpu.MemoryMiB),
+				"node %s has an unexpected %s label", node.Object.Name, gfd.MemoryLabel)
+		}
+	})
+
+	It("Should label the GPU compute capability", Label("gfd-compute"), func() {
+		for _, node := range gpuNodes {
+			computeCapability := nodeGPUs[node.Object.Name].GPUs[0].ComputeCapability
+			major, minor, found := strings.Cut(computeCapability, ".")
+			Expect(found).To(BeTrue(), "node %s reports an invalid compute capability '%s'", node.Object.Name,
+				computeCapability)
+
+			Expect(node.Object.Labels).To(HaveKeyWithValue(gfd.ComputeMajorLabel, major),
+				"node %s has an unexpected %s label", node.Object.Name, gfd.ComputeMajorLabel)
+			Expect(node.Object.Labels).To(HaveKeyWithValue(gfd.ComputeMinorLabel, minor),
+				"node %s has an unexpected %s label", node.Object.Name, gfd.ComputeMinorLabel)
+		}
+	})
+
+	It("Should label the CUDA driver and runtime versions", Label("gfd-cuda"), func() {
+		for _, node := range gpuNodes {
+			gpus := nodeGPUs[node.Object.Name]
+
+			By(fmt.Sprintf("Check the driver version labels of node %s", node.Object.Name))
+			driverVersion, err := gfd.VersionLabels(node.Object.Labels, gfd.DriverVersionLabelPrefix,
+				gfd.LegacyDriverVersionLabelPrefix, "major", "minor", "revision")
+			Expect(err).ToNot(HaveOccurred(), "error reading the driver version labels of node %s: %v",
+				node.Object.Name, err)
+			Expect(strings.Join(driverVersion, ".")).To(Equal(gpus.GPUs[0].DriverVersion),
+				"node %s driver version labels do not match nvidia-smi", node.Object.Name)
+
+			By(fmt.Sprintf("Check the CUDA runtime version labels of node %s", node.Object.Name))
+			runtimeVersion, err := gfd.VersionLabels(node.Object.Labels, gfd.RuntimeVersionLabelPrefix,
+				gfd.LegacyRuntimeVersionLabelPrefix, "major", "minor")
+			Expect(err).ToNot(HaveOccurred(), "error reading the CUDA runtime version labels of node %s: %v",
+				node.Object.Name, err)
+			Expect(strings.Join(runtimeVersion, ".")).To(Equal(gpus.CUDAVersion),
+				"node %s CUDA runtime version labels do not match nvidia-smi", node.Object.Name)
+		}
+	})
+
+	It("Should run the Grace Hopper nodes on arm64 with the open kernel modules", Label(tsparams.LabelGH200),
+		Label(cilabels.GH200), func() {
+			var gh200Nodes []*nodes.Builder
+			for _, node := range gpuNodes {
+				if arch.IsGraceHopper(node.Object) {
+					gh200Nodes = append(gh200Nodes, node)
+				}
+			}
+
+			if len(gh200Nodes) == 0 {
+				Skip("No Grace Hopper GPU node found")
+			}
+
+			Expect(kernelModuleType).ToNot(Equal("proprietary"), "the Grace Hopper GPUs require the open kernel "+
+				"modules, the ClusterPolicy driver kernelModuleType must be auto or open")
+
+			for _, node := range gh200Nodes {
+				By(fmt.Sprintf("Check the architecture and the driver of Grace Hopper node %s", node.Object.Name))
+				Expect(arch.NodeArchitecture(node.Object)).To(Equal(arch.ARM64),
+					"Grace Hopper node %s is not labeled %s=%s", node.Object.Name, arch.NodeLabel, arch.ARM64)
+
+				openKernelModules, err := gfd.OpenKernelModulesLoaded(inittools.APIClient, node.Object.Name)
+				Expect(err).ToNot(HaveOccurred(), "error reading the driver of node %s: %v", node.Object.Name, err)
+				Expect(openKernelModules).To(BeTrue(), "node %s does not run the open GPU kernel modules",
+					node.Object.Name)
+
+				// Hopper GPUs have the compute capability 9.0
+				Expect(node.Object.Labels).To(HaveKeyWithValue(gfd.ComputeMajorLabel, "9"),
+					"node %s has an unexpected %s label", node.Object.Name, gfd.ComputeMajorLabel)
+			}
+		})
+
+	It("Should label the MIG capability", Label("gfd-mig"), func() {
+		for _, node := range gpuNodes {
+			migCapable := strconv.FormatBool(nodeGPUs[node.Object.Name].GPUs[0].MIGMode != gfd.MIGModeNotSupported)
+			Expect(node.Object.Labels).To(HaveKeyWithValue(gfd.MIGCapableLabel, migCapable),
+				"node %s has an unexpected %s label", node.Object.Name, gfd.MIGCapableLabel)
+		}
+	})
+})
@@ -0,0 +1,295 @@
+package gpudirect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpudirect"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/networkparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidianetworkconfig"
+	rdmatest "github.com/rh-ecosystem-edge/nvidia-ci/internal/rdma"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/sno"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/cleanup"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidianetwork"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TestNamespace is the default namespace where the RDMA workload pods will run
+	TestNamespace = "test-gpudirect"
+	// NetworkName is the name of the NetworkAttachmentDefinition used by the RDMA workload pods
+	NetworkName = "gpudirect-rdma-net"
+
+	nnoInstallTimeout         = 10 * time.Minute
+	nicClusterPolicyTimeout   = 30 * time.Minute
+	clusterPolicyReadyTimeout = 30 * time.Minute
+	peermemPollInterval       = 30 * time.Second
+	peermemTimeout            = 15 * time.Minute
+	rdmaPodRunningTimeout     = 5 * time.Minute
+	rdmaPodSuccessTimeout     = 10 * time.Minute
+	mellanoxInterfaceDefault  = "ens1f0np0"
+)
+
+var (
+	nvidiaNetworkConfig *nvidianetworkconfig.NvidiaNetworkConfig
+)
+
+var _ = Describe("GPUDirect RDMA", Ordered, Label(tsparams.LabelSuite, "gpudirect"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.DisruptsOperands, cilabels.RDMA), func() {
+	var (
+		nsBuilder            *namespace.Builder
+		workloadNamespace    string
+		rdmaTestImage        string
+		nnoCSV               *olm.ClusterServiceVersionBuilder
+		nicClusterPolicy     *nvidianetwork.NicClusterPolicyBuilder
+		previousRDMA         *nvidiagpuv1.GPUDirectRDMASpec
+		rdmaChanged          bool
+		networkCreated       bool
+		cleanupAfterTest     = true
+		gpuDirectRDMAEnabled = true
+	)
+	nvidiaNetworkConfig = nvidianetworkconfig.NewNvidiaNetworkConfig()
+
+	BeforeAll(func() {
+		glog.V(networkparams.LogLevel).Info("Starting GPUDirect RDMA test suite")
+
+		if nvidiaNetworkConfig == nil {
+			Skip("Failed to load the NVIDIANETWORK_ environment configuration")
+		}
+
+		if nvidiaNetworkConfig.RdmaClientHostname == "" || nvidiaNetworkConfig.RdmaServerHostname == "" ||
+			nvidiaNetworkConfig.RdmaMlxDevice == "" || nvidiaNetworkConfig.RdmaLinkType == "" ||
+			nvidiaNetworkConfig.MacvlanNetworkIPAMRange == "" {
+			Skip("NVIDIANETWORK_RDMA_CLIENT_HOSTNAME, NVIDIANETWORK_RDMA_SERVER_HOSTNAME, " +
+				"NVIDIANETWORK_RDMA_MLX_DEVICE, NVIDIANETWORK_RDMA_LINK_TYPE and " +
+				"NVIDIANETWORK_MACVLANNETWORK_IPAM_RANGE must be set to run the GPUDirect RDMA tests")
+		}
+
+		cleanupAfterTest = nvidiaNetworkConfig.CleanupAfterTest
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		workloadNamespace = nvidiaNetworkConfig.RdmaWorkloadNamespace
+		if workloadNamespace == "" {
+			workloadNamespace = TestNamespace
+		}
+
+		rdmaTestImage = nvidiaNetworkConfig.RdmaTestImage
+		if rdmaTestImage == "" {
+			clusterArch, err := get.GetClusterArchitecture(inittools.APIClient,
+				inittools.GeneralConfig.WorkerLabelMap)
+			Expect(err).ToNot(HaveOccurred(), "error getting cluster architecture: %v", err)
+
+			rdmaTestImage, err = rdmatest.TestImages.Image(clusterArch)
+			Expect(err).ToNot(HaveOccurred(), "error selecting the perftest image: %v", err)
+		}
+
+		By("Check the perftest image is reachable")
+		Expect(disconnected.CheckImages(rdmaTestImage)).ToNot(HaveOccurred(),
+			"the perftest image is not reachable through the mirrors")
+		rdmaTestImage = disconnected.Image(rdmaTestImage)
+
+		By("Install the NVIDIA Network Operator")
+		var err error
+		nnoCSV, err = gpudirect.InstallNetworkOperator(inittools.APIClient, nvidiaNetworkConfig.CatalogSource,
+			nvidiaNetworkConfig.SubscriptionChannel, nnoInstallTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error installing the NVIDIA Network Operator: %v", err)
+
+		By("Create the NicClusterPolicy with the RDMA shared device plugin")
+		nicClusterPolicy, err = nvidianetwork.PullNicClusterPolicy(inittools.APIClient, gpudirect.NicClusterPolicyName)
+		if err != nil {
+			almExamples, err := nnoCSV.GetAlmExamples()
+			Expect(err).ToNot(HaveOccurred(), "error getting alm-examples from CSV: %v", err)
+
+			nicClusterPolicy, err = gpudirect.CreateNicClusterPolicy(inittools.APIClient, almExamples,
+				nvidiaNetworkConfig.RdmaLinkType, mellanoxInterface())
+			Expect(err).ToNot(HaveOccurred(), "error creating NicClusterPolicy: %v", err)
+		}
+
+		err = wait.NicClusterPolicyReady(inittools.APIClient, gpudirect.NicClusterPolicyName, 60*time.Second,
+			nicClusterPolicyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for NicClusterPolicy to be ready: %v", err)
+
+		By("Enable driver.rdma in the ClusterPolicy")
+		previousRDMA, err = gpudirect.SetGPUDirectRDMA(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+			&nvidiagpuv1.GPUDirectRDMASpec{Enabled: &gpuDirectRDMAEnabled})
+		Expect(err).ToNot(HaveOccurred(), "error enabling GPUDirect RDMA in ClusterPolicy: %v", err)
+		rdmaChanged = true
+		DeferCleanup(cleanup.Register("the ClusterPolicy driver.rdma", func() error {
+			_, err := gpudirect.SetGPUDirectRDMA(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousRDMA)
+
+			return err
+		}))
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+		By("Prepare the RDMA workload namespace")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, workloadNamespace)
+		if !nsBuilder.Exists() {
+			createdNsBuilder, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", workloadNamespace, err)
+
+			_, err = createdNsBuilder.WithMultipleLabels(map[string]string{
+				"pod-security.kubernetes.io/enforce": "privileged",
+			}).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", workloadNamespace, err)
+		}
+
+		err = gpudirect.CreateRDMAServiceAccount(inittools.APIClient, workloadNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error creating RDMA service account: %v", err)
+
+		_, err = gpudirect.CreateNetworkAttachmentDefinition(inittools.APIClient, NetworkName, workloadNamespace,
+			nvidiaNetworkConfig.RdmaLinkType, mellanoxInterface(), nvidiaNetworkConfig.MacvlanNetworkIPAMRange)
+		Expect(err).ToNot(HaveOccurred(), "error creating NetworkAttachmentDefinition: %v", err)
+		networkCreated = true
+	})
+
+	AfterAll(func() {
+		if networkCreated {
+			if err := gpudirect.DeleteNetworkAttachmentDefinition(inittools.APIClient, NetworkName,
+				workloadNamespace); err != nil {
+				glog.Errorf("Error deleting NetworkAttachmentDefinition %s: %v", NetworkName, err)
+			}
+		}
+
+		if rdmaChanged {
+			By("Restore the original ClusterPolicy driver.rdma")
+			if _, err := gpudirect.SetGPUDirectRDMA(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousRDMA); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy driver.rdma: %v", err)
+			}
+		}
+
+		if !cleanupAfterTest {
+			return
+		}
+
+		if nsBuilder != nil && workloadNamespace == TestNamespace {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", workloadNamespace, err)
+			}
+		}
+
+		if nicClusterPolicy != nil {
+			if _, err := nicClusterPolicy.Delete(); err != nil {
+				glog.Errorf("Error deleting NicClusterPolicy: %v", err)
+			}
+		}
+	})
+
+	It("Should load nvidia-peermem in the GPU driver pods", Label("gpudirect-peermem"), func() {
+		err := await.Match(context.TODO(),
+			fmt.Sprintf("container %s to be ready in the driver pods", gpudirect.PeermemContainerName),
+			peermemPollInterval, peermemTimeout, func(context.Context) (interface{}, error) {
+				return gpudirect.PeermemReady(inittools.APIClient)
+			}, BeTrue())
+		Expect(err).ToNot(HaveOccurred(), "container %s is not ready in the driver pods: %v",
+			gpudirect.PeermemContainerName, err)
+	})
+
+	It("Should run ib_write_bw with GPU memory across two nodes", Label(tsparams.LabelMultiNode,
+		cilabels.MultiNode, "gpudirect-rdma"), func() {
+		singleNode, err := sno.IsSingleNode(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error detecting Single-Node OpenShift: %v", err)
+
+		if singleNode {
+			Skip("Single-Node OpenShift has no second node to run ib_write_bw across")
+		}
+
+		serverPodName := "gpudirect-rdma-server-" + nvidiaNetworkConfig.RdmaLinkType
+		clientPodName := "gpudirect-rdma-client-" + nvidiaNetworkConfig.RdmaLinkType
+
+		By(fmt.Sprintf("Create ib_write_bw server pod %s on node %s", serverPodName,
+			nvidiaNetworkConfig.RdmaServerHostname))
+		serverPod := createRDMAPod(rdmatest.CreateRdmaWorkloadPod(serverPodName, workloadNamespace, "yes",
+			"server", nvidiaNetworkConfig.RdmaServerHostname, nvidiaNetworkConfig.RdmaMlxDevice, NetworkName,
+			rdmaTestImage, nvidiaNetworkConfig.RdmaLinkType, "none", "shared-device"))
+		defer deleteRDMAPod(serverPod)
+
+		err = serverPod.WaitUntilRunning(rdmaPodRunningTimeout)
+		Expect(err).ToNot(HaveOccurred(), "RDMA server pod %s is not running: %v", serverPodName, err)
+
+		serverIP, err := rdmatest.GetMyServerIP(inittools.APIClient, serverPodName, workloadNamespace, "net1")
+		Expect(err).ToNot(HaveOccurred(), "error getting RDMA server pod net1 address: %v", err)
+		glog.V(networkparams.LogLevel).Infof("RDMA server pod net1 address is '%s'", serverIP)
+
+		By(fmt.Sprintf("Create ib_write_bw client pod %s on node %s", clientPodName,
+			nvidiaNetworkConfig.RdmaClientHostname))
+		clientPod := createRDMAPod(rdmatest.CreateRdmaWorkloadPod(clientPodName, workloadNamespace, "yes",
+			"client", nvidiaNetworkConfig.RdmaClientHostname, nvidiaNetworkConfig.RdmaMlxDevice, NetworkName,
+			rdmaTestImage, nvidiaNetworkConfig.RdmaLinkType, serverIP, "shared-device"))
+		defer deleteRDMAPod(clientPod)
+
+		err = clientPod.WaitUntilInStatus(corev1.PodSucceeded, rdmaPodSuccessTimeout)
+		Expect(err).ToNot(HaveOccurred(), "RDMA client pod %s did not succeed: %v", clientPodName, err)
+
+		By("Validate the ib_write_bw results from the server pod logs")
+		serverLogs, err := rdmatest.GetPodLogs(inittools.APIClient, workloadNamespace, serverPodName)
+		Expect(err).ToNot(HaveOccurred(), "error getting RDMA server pod logs: %v", err)
+		glog.V(networkparams.LogLevel).Infof("RDMA server logs:\n%s", serverLogs)
+
+		results, err := rdmatest.ParseRdmaOutput(serverLogs)
+		Expect(err).ToNot(HaveOccurred(), "error parsing RDMA server pod logs: %v", err)
+
+		if resultsJSON, err := json.MarshalIndent(results, "", "  "); err == nil {
+			glog.V(networkparams.LogLevel).Infof("Parsed RDMA results:\n%s", string(resultsJSON))
+		}
+
+		passed, err := rdmatest.ValidateRDMAResults(results)
+		Expect(passed).To(BeTrue(), "GPUDirect RDMA ib_write_bw validation failed: %v", err)
+	})
+})
+
+// mellanoxInterface returns the Mellanox interface for the configured RDMA link type.
+func mellanoxInterface() string {
+	interfaceName := nvidiaNetworkConfig.MellanoxEthernetInterfaceName
+	if nvidiaNetworkConfig.RdmaLinkType == "infiniband" {
+		interfaceName = nvidiaNetworkConfig.MellanoxInfinibandInterfaceName
+	}
+
+	if interfaceName == "" {
+		return mellanoxInterfaceDefault
+	}
+
+	return interfaceName
+}
+
+func createRDMAPod(rdmaPod *corev1.Pod) *pod.Builder {
+	proxy.Inject(inittools.APIClient, &rdmaPod.Spec)
+	_, err := inittools.APIClient.Pods(rdmaPod.Namespace).Create(context.TODO(), rdmaPod, metav1.CreateOptions{})
+	Expect(err).ToNot(HaveOccurred(), "error creating RDMA pod %s: %v", rdmaPod.Name, err)
+
+	podBuilder, err := pod.Pull(inittools.APIClient, rdmaPod.Name, rdmaPod.Namespace)
+	Expect(err).ToNot(HaveOccurred(), "error pulling RDMA pod %s: %v", rdmaPod.Name, err)
+
+	return podBuilder
+}
+
+func deleteRDMAPod(podBuilder *pod.Builder) {
+	if _, err := podBuilder.DeleteAndWait(rdmaPodRunningTimeout); err != nil {
+		glog.Errorf("Error deleting RDMA pod %s: %v", podBuilder.Definition.Name, err)
+	}
+}
@@ -0,0 +1,297 @@
+package gpusettings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpusettings"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiasmi"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	powerLimitPollInterval = 10 * time.Second
+	powerLimitTimeout      = 2 * time.Minute
+	// minPowerLimitRange is the smallest range, in W, between the minimum and the default power limits of the GPUs
+	// of a node to set a power limit in between.
+	minPowerLimitRange = 2 * gpusettings.PowerLimitTolerance
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("GPU Settings", Ordered, Label(tsparams.LabelSuite, "gpusettings"), cilabels.Spec(
+	cilabels.Regression, cilabels.Short, cilabels.NonDisruptive), func() {
+	var (
+		gpuNodes []*nodes.Builder
+		dcgmPods map[string]*pod.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	// nodeSettings returns the settings DCGM reports for the GPUs of the node and its nvidia-smi report.
+	nodeSettings := func(nodeName string) ([]gpusettings.Settings, *nvidiasmi.Log) {
+		settings, err := gpusettings.Query(dcgmPods[nodeName])
+		Expect(err).ToNot(HaveOccurred(), "error querying the DCGM GPU settings of node %s: %v", nodeName, err)
+
+		report, err := nvidiasmi.QueryNode(inittools.APIClient, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error running nvidia-smi on node %s: %v", nodeName, err)
+		Expect(settings).To(HaveLen(len(report.GPUs)), "DCGM and nvidia-smi report different GPUs on node %s",
+			nodeName)
+
+		return settings, report
+	}
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting GPU Settings test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if !clusterPolicyBuilder.Definition.Spec.DCGM.IsEnabled() {
+			Skip(fmt.Sprintf("The standalone DCGM is disabled in ClusterPolicy '%s'", nvidiagpu.ClusterPolicyName))
+		}
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		dcgmPods = map[string]*pod.Builder{}
+
+		for _, gpuNode := range gpuNodes {
+			dcgmPod, err := gpusettings.NodeDCGMPod(inittools.APIClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error getting the DCGM pod of node %s: %v", gpuNode.Object.Name, err)
+
+			dcgmPods[gpuNode.Object.Name] = dcgmPod
+		}
+	})
+
+	It("Should report the same application clocks, power limits and ECC modes through DCGM and nvidia-smi",
+		Label("gpusettings-consistency"), func() {
+			var mismatches []string
+
+			for _, gpuNode := range gpuNodes {
+				settings, report := nodeSettings(gpuNode.Object.Name)
+
+				for index, gpu := range report.GPUs {
+					AddReportEntry(fmt.Sprintf("GPU settings %s GPU %s", gpuNode.Object.Name, gpu.ID),
+						describeSettings(settings[index], gpu))
+
+					for _, mismatch := range gpusettings.Mismatches(settings[index], gpu) {
+						mismatches = append(mismatches, fmt.Sprintf("node %s GPU %s %s", gpuNode.Object.Name, gpu.ID,
+							mismatch))
+					}
+				}
+			}
+
+			Expect(mismatches).To(BeEmpty(), "DCGM and nvidia-smi report different GPU settings")
+		})
+
+	It("Should run the GPUs at their default application clocks and power limits and the expected ECC mode",
+		Label("gpusettings-defaults"), func() {
+			var deviations []string
+
+			for _, gpuNode := range gpuNodes {
+				report, err := nvidiasmi.QueryNode(inittools.APIClient, gpuNode.Object.Name)
+				Expect(err).ToNot(HaveOccurred(), "error running nvidia-smi on node %s: %v", gpuNode.Object.Name, err)
+
+				for _, gpu := range report.GPUs {
+					gpuDeviations := gpusettings.Deviations(gpu)
+
+					eccMode := gpusettings.ECCMode(gpu)
+					if nvidiaGPUConfig.GPUECCMode != "" && eccMode != "" && eccMode != nvidiaGPUConfig.GPUECCMode {
+						gpuDeviations = append(gpuDeviations, fmt.Sprintf("ECC is %s, expected %s", eccMode,
+							nvidiaGPUConfig.GPUECCMode))
+					}
+
+					for _, deviation := range gpuDeviations {
+						deviations = append(deviations, fmt.Sprintf("node %s GPU %s %s", gpuNode.Object.Name, gpu.ID,
+							deviation))
+					}
+				}
+			}
+
+			Expect(deviations).To(BeEmpty(), "GPUs do not run with their default settings")
+		})
+
+	It("Should set and restore the power limit of the GPUs through DCGM", Label("gpusettings-power-limit"), func() {
+		if !nvidiaGPUConfig.GPUSetPowerLimit {
+			Skip("Setting the GPU power limits is disabled by NVIDIAGPU_GPU_SET_POWER_LIMIT")
+		}
+
+		setNodes := 0
+
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+			dcgmPod := dcgmPods[nodeName]
+
+			_, report := nodeSettings(nodeName)
+
+			lowest, defaultLimit, err := powerLimitRange(report)
+			if err != nil {
+				glog.V(gpuparams.GpuLogLevel).Infof("Skipping the power limit of the GPUs of node %s: %v",
+					nodeName, err)
+
+				continue
+			}
+
+			setNodes++
+
+			target := int((lowest + defaultLimit) / 2)
+
+			By(fmt.Sprintf("Set the power limit of the GPUs of node %s to %d W", nodeName, target))
+			Expect(gpusettings.SetPowerLimit(dcgmPod, target)).To(Succeed(),
+				"error setting the power limit of the GPUs of node %s", nodeName)
+
+			DeferCleanup(func() {
+				By(fmt.Sprintf("Restore the default power limit of the GPUs of node %s", nodeName))
+				Expect(gpusettings.SetPowerLimit(dcgmPod, int(math.Round(defaultLimit)))).To(Succeed(),
+					"error restoring the power limit of the GPUs of node %s", nodeName)
+				Expect(awaitPowerLimit(dcgmPod, nodeName, defaultLimit)).To(Succeed(),
+					"the GPUs of node %s did not get their default power limit back", nodeName)
+			})
+
+			Expect(awaitPowerLimit(dcgmPod, nodeName, float64(target))).To(Succeed(),
+				"the GPUs of node %s did not get the power limit set through DCGM", nodeName)
+
+			By(fmt.Sprintf("Check DCGM and nvidia-smi report the other settings consistently on node %s", nodeName))
+			settings, report := nodeSettings(nodeName)
+
+			for index, gpu := range report.GPUs {
+				Expect(gpusettings.Mismatches(settings[index], gpu)).To(BeEmpty(),
+					"DCGM and nvidia-smi report different settings for node %s GPU %s", nodeName, gpu.ID)
+			}
+		}
+
+		if setNodes == 0 {
+			Skip("No GPU node allows lowering the power limit of its GPUs")
+		}
+	})
+})
+
+// powerLimitRange returns the highest minimum power limit and the default power limit of the GPUs of the report, in
+// W, DCGM setting the same power limit on every GPU of the node, which must stay between the minimum and the default
+// power limits of all of them.
+func powerLimitRange(report *nvidiasmi.Log) (float64, float64, error) {
+	lowest, defaultLimit := 0.0, 0.0
+
+	for index, gpu := range report.GPUs {
+		readings := gpu.Readings()
+
+		minLimit, err := readings.MinLimit.Float()
+		if err != nil {
+			return 0, 0, fmt.Errorf("GPU %s reports no minimum power limit: %w", gpu.ID, err)
+		}
+
+		gpuDefault, err := readings.DefaultLimit.Float()
+		if err != nil {
+			return 0, 0, fmt.Errorf("GPU %s reports no default power limit: %w", gpu.ID, err)
+		}
+
+		if index > 0 && gpuDefault != defaultLimit {
+			return 0, 0, fmt.Errorf("GPU %s has a %g W default power limit, the other GPUs %g W", gpu.ID,
+				gpuDefault, defaultLimit)
+		}
+
+		lowest, defaultLimit = math.Max(lowest, minLimit), gpuDefault
+	}
+
+	if defaultLimit-lowest < minPowerLimitRange {
+		return 0, 0, fmt.Errorf("the power limit cannot be lowered from %g W, the minimum being %g W", defaultLimit,
+			lowest)
+	}
+
+	return lowest, defaultLimit, nil
+}
+
+// awaitPowerLimit waits until DCGM and nvidia-smi both report the power limit, in W, for every GPU of the node.
+func awaitPowerLimit(dcgmPod *pod.Builder, nodeName string, watts float64) error {
+	return await.For(context.TODO(), fmt.Sprintf("the GPUs of node %s to have a %g W power limit", nodeName, watts),
+		powerLimitPollInterval, powerLimitTimeout, func(ctx context.Context) (interface{}, bool, error) {
+			settings, err := gpusettings.Query(dcgmPod)
+			if err != nil {
+				return nil, false, err
+			}
+
+			report, err := nvidiasmi.QueryNode(inittools.APIClient, nodeName)
+			if err != nil {
+				return nil, false, err
+			}
+
+			var observed []string
+
+			for _, setting := range settings {
+				observed = append(observed, fmt.Sprintf("DCGM GPU %d %s W", setting.GPU, setting.PowerLimit))
+			}
+
+			for _, gpu := range report.GPUs {
+				limit, err := gpu.PowerLimit()
+				if err != nil {
+					return nil, false, err
+				}
+
+				observed = append(observed, fmt.Sprintf("nvidia-smi GPU %s %g W", gpu.ID, limit))
+			}
+
+			for _, setting := range settings {
+				limit, err := setting.PowerLimit.Float()
+				if err != nil || math.Abs(limit-watts) > gpusettings.PowerLimitTolerance {
+					return strings.Join(observed, ", "), false, nil
+				}
+			}
+
+			for _, gpu := range report.GPUs {
+				if limit, _ := gpu.PowerLimit(); math.Abs(limit-watts) > gpusettings.PowerLimitTolerance {
+					return strings.Join(observed, ", "), false, nil
+				}
+			}
+
+			return strings.Join(observed, ", "), true, nil
+		})
+}
+
+// describeSettings describes the settings DCGM and nvidia-smi report for the GPU.
+func describeSettings(settings gpusettings.Settings, gpu nvidiasmi.GPU) string {
+	powerLimit := "N/A"
+	if limit, err := gpu.PowerLimit(); err == nil {
+		powerLimit = fmt.Sprintf("%g W", limit)
+	}
+
+	return fmt.Sprintf("DCGM GPU %d: application clocks SM %s MHz memory %s MHz, power limit %s W, ECC %s "+
+		"(pending %s); nvidia-smi: application clocks graphics %s memory %s, default graphics %s memory %s, "+
+		"power limit %s, ECC %s (pending %s)", settings.GPU, settings.ApplicationSMClock,
+		settings.ApplicationMemClock, settings.PowerLimit, settings.ECCCurrent, settings.ECCPending,
+		gpu.ApplicationClocks.Graphics, gpu.ApplicationClocks.Memory, gpu.DefaultApplicationClocks.Graphics,
+		gpu.DefaultApplicationClocks.Memory, powerLimit, gpu.ECCMode.Current, gpu.ECCMode.Pending)
+}
@@ -0,0 +1,299 @@
+package heterogeneous
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/autoscaling"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/sno"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/timeslicing"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/cleanup"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the GPU workloads run
+	TestNamespace = "test-gpu-heterogeneous"
+	// WorkloadImage is the container image of the GPU workloads
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+	// DevicePluginConfigMapName is the name of the ConfigMap holding one device plugin config per GPU model
+	DevicePluginConfigMapName = "heterogeneous-device-plugin-config"
+
+	// The replicas of the first GPU model config, each next model gets one more replica so that the configs differ
+	firstModelReplicas = 2
+
+	clusterPolicyReadyTimeout = 15 * time.Minute
+	allocatablePollInterval   = 15 * time.Second
+	allocatableTimeout        = 10 * time.Minute
+	workloadTimeout           = 5 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Heterogeneous", Ordered, Label(tsparams.LabelSuite, tsparams.LabelMultiNode,
+	"heterogeneous"), cilabels.Spec(cilabels.Extended, cilabels.Medium, cilabels.DisruptsOperands,
+	cilabels.MultiNode), func() {
+	var (
+		nsBuilder      *namespace.Builder
+		configMap      *configmap.Builder
+		nodesByModel   map[string][]*nodes.Builder
+		models         []string
+		nodeGPUs       map[string]*gfd.NodeGPUs
+		previousConfig *nvidiagpuv1.DevicePluginConfig
+		configChanged  bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting heterogeneous GPU nodes test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		singleNode, err := sno.IsSingleNode(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error detecting Single-Node OpenShift: %v", err)
+
+		if singleNode {
+			Skip("Single-Node OpenShift runs a single GPU node, a heterogeneous cluster needs several")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		By("Group the GPU worker nodes by GPU model")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		nodesByModel = gfd.NodesByModel(gpuNodes)
+		for model := range nodesByModel {
+			models = append(models, model)
+		}
+
+		sort.Strings(models)
+
+		if len(models) < 2 {
+			Skip(fmt.Sprintf("The GPU worker nodes run %d GPU models %v, a heterogeneous cluster needs at least 2",
+				len(models), models))
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("The GPU worker nodes run the GPU models %v", models)
+
+		By("Query the GPUs of each node with nvidia-smi")
+		nodeGPUs = map[string]*gfd.NodeGPUs{}
+		for _, node := range gpuNodes {
+			nodeGPUs[node.Object.Name], err = gfd.QueryNodeGPUs(inittools.APIClient, node.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error querying the GPUs of node %s: %v", node.Object.Name, err)
+		}
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		for _, modelNodes := range nodesByModel {
+			for _, node := range modelNodes {
+				if err := timeslicing.LabelNodeDevicePluginConfig(inittools.APIClient, node.Object.Name,
+					""); err != nil {
+					glog.Errorf("Error removing %s label from node %s: %v", timeslicing.DevicePluginConfigLabel,
+						node.Object.Name, err)
+				}
+			}
+		}
+
+		if configChanged {
+			By("Restore the original ClusterPolicy devicePlugin.config")
+			if _, err := timeslicing.SetClusterPolicyDevicePluginConfig(inittools.APIClient,
+				nvidiagpu.ClusterPolicyName, previousConfig); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy devicePlugin.config: %v", err)
+			} else if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				clusterPolicyReadyTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+
+		if configMap != nil {
+			if err := configMap.Delete(); err != nil {
+				glog.Errorf("Error deleting ConfigMap %s: %v", configMap.Object.Name, err)
+			}
+		}
+
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should label the nodes of each GPU model with the properties of their GPUs",
+		Label("heterogeneous-labels"), func() {
+			for _, model := range models {
+				By(fmt.Sprintf("Verify the GFD labels of the %s nodes", model))
+				reference := nodesByModel[model][0].Object.Labels
+
+				for _, node := range nodesByModel[model] {
+					gpus := nodeGPUs[node.Object.Name].GPUs
+					Expect(gpus).ToNot(BeEmpty(), "nvidia-smi reported no GPU on node %s", node.Object.Name)
+
+					for _, gpu := range gpus {
+						Expect(gfd.ProductName(gpu.Name)).To(Equal(model),
+							"node %s labeled %s runs a %s GPU", node.Object.Name, model, gpu.Name)
+					}
+
+					for _, label := range []string{gfd.MemoryLabel, gfd.ComputeMajorLabel, gfd.ComputeMinorLabel} {
+						Expect(node.Object.Labels).To(HaveKeyWithValue(label, reference[label]),
+							"node %s has a different %s label than the other %s nodes", node.Object.Name, label, model)
+					}
+
+					Expect(node.Object.Labels).To(HaveKeyWithValue(gfd.MemoryLabel, gpus[0].MemoryMiB),
+						"node %s has an unexpected %s label", node.Object.Name, gfd.MemoryLabel)
+				}
+			}
+
+			By("Verify the GPU models are told apart by their labels")
+			for i := 1; i < len(models); i++ {
+				Expect(nodesByModel[models[i]][0].Object.Labels[gfd.ProductLabel]).ToNot(
+					Equal(nodesByModel[models[0]][0].Object.Labels[gfd.ProductLabel]),
+					"GPU models %s and %s share the same %s label", models[0], models[i], gfd.ProductLabel)
+			}
+		})
+
+	It("Should schedule a workload to a specific GPU model through its nodeSelector",
+		Label("heterogeneous-scheduling"), func() {
+			for i, model := range models {
+				modelNode := nodesByModel[model][0]
+				nodeSelector := map[string]string{gfd.ProductLabel: modelNode.Object.Labels[gfd.ProductLabel]}
+				podName := fmt.Sprintf("gpu-model-workload-%d", i)
+
+				By(fmt.Sprintf("Run pod %s on a %s GPU with nodeSelector %v", podName, model, nodeSelector))
+				podBuilder, err := autoscaling.NewWorkloadPod(inittools.APIClient, podName, TestNamespace,
+					disconnected.Image(WorkloadImage), nodeSelector, 0).Create()
+				Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", podName, err)
+
+				err = podBuilder.WaitUntilInStatus(corev1.PodSucceeded, workloadTimeout)
+				Expect(err).ToNot(HaveOccurred(), "pod %s did not complete: %v", podName, err)
+
+				Expect(podBuilder.Exists()).To(BeTrue(), "pod %s was deleted", podName)
+
+				scheduledNode, err := nodes.Pull(inittools.APIClient, podBuilder.Object.Spec.NodeName)
+				Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", podBuilder.Object.Spec.NodeName, err)
+				Expect(gfd.ModelName(scheduledNode.Object.Labels[gfd.ProductLabel])).To(Equal(model),
+					"pod %s requesting a %s GPU ran on node %s", podName, model, scheduledNode.Object.Name)
+
+				podLog, err := podBuilder.GetFullLog(autoscaling.WorkloadContainerName)
+				Expect(err).ToNot(HaveOccurred(), "error getting the logs of pod %s: %v", podName, err)
+				Expect(podLog).To(ContainSubstring(nodeGPUs[scheduledNode.Object.Name].GPUs[0].Name),
+					"pod %s did not list a %s GPU", podName, model)
+
+				_, err = podBuilder.DeleteAndWait(workloadTimeout)
+				Expect(err).ToNot(HaveOccurred(), "error deleting pod %s: %v", podName, err)
+			}
+		})
+
+	It("Should apply a different device plugin config to each GPU model", Label("heterogeneous-device-plugin"),
+		func() {
+			replicasByModel := map[string]int{}
+			for i, model := range models {
+				replicasByModel[model] = firstModelReplicas + i
+			}
+
+			By("Create a device plugin ConfigMap with one time-slicing config per GPU model")
+			var err error
+			configMap, err = timeslicing.CreateDevicePluginConfigMap(inittools.APIClient, DevicePluginConfigMapName,
+				nvidiagpu.NvidiaGPUNamespace, replicasByModel)
+			Expect(err).ToNot(HaveOccurred(), "error creating ConfigMap %s: %v", DevicePluginConfigMapName, err)
+
+			By("Point the ClusterPolicy devicePlugin.config at the per-model ConfigMap")
+			previousConfig, err = timeslicing.SetClusterPolicyDevicePluginConfig(inittools.APIClient,
+				nvidiagpu.ClusterPolicyName, &nvidiagpuv1.DevicePluginConfig{
+					Name:    DevicePluginConfigMapName,
+					Default: models[0],
+				})
+			Expect(err).ToNot(HaveOccurred(), "error updating ClusterPolicy: %v", err)
+			configChanged = true
+			DeferCleanup(cleanup.Register("the ClusterPolicy devicePlugin.config", func() error {
+				_, err := timeslicing.SetClusterPolicyDevicePluginConfig(inittools.APIClient,
+					nvidiagpu.ClusterPolicyName, previousConfig)
+
+				return err
+			}))
+
+			err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+			for _, model := range models {
+				By(fmt.Sprintf("Select the %s config on the %s nodes", model, model))
+				for _, node := range nodesByModel[model] {
+					err = timeslicing.LabelNodeDevicePluginConfig(inittools.APIClient, node.Object.Name, model)
+					Expect(err).ToNot(HaveOccurred(), "error labeling node %s: %v", node.Object.Name, err)
+				}
+			}
+
+			for _, model := range models {
+				replicas := replicasByModel[model]
+
+				for _, node := range nodesByModel[model] {
+					expected := int64(get.GPUCount(node) * replicas)
+
+					By(fmt.Sprintf("Wait for %s node %s to advertise %d %s", model, node.Object.Name, expected,
+						timeslicing.GPUResourceName))
+					err = wait.NodeAllocatable(inittools.APIClient, node.Object.Name, timeslicing.GPUResourceName,
+						expected, allocatablePollInterval, allocatableTimeout)
+					Expect(err).ToNot(HaveOccurred(), "node %s did not advertise %d %s: %v", node.Object.Name,
+						expected, timeslicing.GPUResourceName, err)
+
+					err := await.Match(context.TODO(),
+						fmt.Sprintf("GFD to report %d GPU replicas on node %s", replicas, node.Object.Name),
+						allocatablePollInterval, allocatableTimeout, func(context.Context) (interface{}, error) {
+							pulledNode, err := nodes.Pull(inittools.APIClient, node.Object.Name)
+							if err != nil {
+								return nil, err
+							}
+
+							return pulledNode.Object.Labels, nil
+						}, HaveKeyWithValue(timeslicing.GPUReplicasLabel, strconv.Itoa(replicas)))
+					Expect(err).ToNot(HaveOccurred(), "GFD did not report %d GPU replicas on node %s: %v", replicas,
+						node.Object.Name, err)
+				}
+			}
+		})
+})
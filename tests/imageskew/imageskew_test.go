@@ -0,0 +1,87 @@
+package imageskew
+
+import (
+	"fmt"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/operatorupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("ImageSkew", Ordered, Label(tsparams.LabelSuite, "imageskew"), cilabels.Spec(
+	cilabels.Smoke, cilabels.Short, cilabels.NonDisruptive), func() {
+	var (
+		csvBuilder        *olm.ClusterServiceVersionBuilder
+		clusterPolicySpec *nvidiagpuv1.ClusterPolicySpec
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting ImageSkew test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		clusterPolicySpec = &clusterPolicyBuilder.Definition.Spec
+
+		csvBuilder, err = operatorupgrade.GPUOperatorCSV(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error getting the GPU operator CSV: %v", err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Comparing the operand images with CSV '%s'", csvBuilder.Object.Name)
+	})
+
+	It("Should declare the expected images in the operand daemonsets", Label("imageskew-daemonsets"), func() {
+		for _, operand := range operatorupgrade.Operands(clusterPolicySpec) {
+			expectedImage, err := operand.ExpectedImage(csvBuilder)
+			Expect(err).ToNot(HaveOccurred(), "error getting the expected image of %s: %v", operand.DaemonSet, err)
+
+			images, found, err := operatorupgrade.DaemonSetImages(inittools.APIClient, operand.DaemonSet,
+				nvidiagpu.NvidiaGPUNamespace)
+			Expect(err).ToNot(HaveOccurred(), "error getting the images of %s: %v", operand.DaemonSet, err)
+
+			if !found {
+				glog.V(gpuparams.GpuLogLevel).Infof("Operand daemonset '%s' is not deployed, skipping",
+					operand.DaemonSet)
+
+				continue
+			}
+
+			Expect(operand.Runs(images, expectedImage)).To(BeTrue(), "daemonset %s images %v do not include %s",
+				operand.DaemonSet, images, expectedImage)
+		}
+	})
+
+	It("Should run the expected operand images on every node", Label("imageskew-pods"), func() {
+		operands := append(operatorupgrade.Operands(clusterPolicySpec),
+			operatorupgrade.DriverOperand(clusterPolicySpec))
+
+		staleImages, err := operatorupgrade.StaleImages(inittools.APIClient, operands, csvBuilder)
+		Expect(err).ToNot(HaveOccurred(), "error comparing the operand pod images: %v", err)
+
+		for _, staleImage := range staleImages {
+			glog.V(gpuparams.GpuLogLevel).Infof("Node %s pod %s runs %v instead of %s", staleImage.NodeName,
+				staleImage.PodName, staleImage.Images, staleImage.Expected)
+		}
+
+		Expect(staleImages).To(BeEmpty(), "operand pods run stale images")
+	})
+})
@@ -0,0 +1,273 @@
+package kata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/cc"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/kata"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	katacfg "github.com/rh-ecosystem-edge/nvidia-ci/pkg/kata"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the kata workload pods will run
+	TestNamespace = "test-kata"
+	// WorkloadImage is the container image of the kata workload pods
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+
+	operatorInstallTimeout    = 10 * time.Minute
+	kataConfigPollInterval    = time.Minute
+	kataConfigTimeout         = time.Hour
+	clusterPolicyReadyTimeout = 20 * time.Minute
+	allocatablePollInterval   = 30 * time.Second
+	allocatableTimeout        = 15 * time.Minute
+	workloadRunningTimeout    = 10 * time.Minute
+	workloadLogTimeout        = 2 * time.Minute
+	workloadLogPollInterval   = 10 * time.Second
+	ccModePollInterval        = 30 * time.Second
+	ccModeTimeout             = 15 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Kata", Ordered, Label(tsparams.LabelSuite, "kata"), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.DisruptsCluster, cilabels.BareMetal), func() {
+	var (
+		nsBuilder         *namespace.Builder
+		kataNode          *nodes.Builder
+		kataConfigBuilder *katacfg.Builder
+		previousSpec      *nvidiagpuv1.ClusterPolicySpec
+		passthroughKey    string
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Kata test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		By("Find a GPU worker node to run kata workloads")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		kataNode = gpuNodes[0]
+		glog.V(gpuparams.GpuLogLevel).Infof("Using node '%s' for kata tests", kataNode.Object.Name)
+
+		By("Install the OpenShift sandboxed containers operator")
+		err = kata.InstallSandboxedContainersOperator(inittools.APIClient, "",
+			nvidiaGPUConfig.OSCSubscriptionChannel, operatorInstallTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error installing the sandboxed containers operator: %v", err)
+
+		By("Install the kata runtime on the node")
+		if _, err := katacfg.Pull(inittools.APIClient, kata.KataConfigName); err != nil {
+			kataConfigBuilder, err = katacfg.NewBuilder(inittools.APIClient, kata.KataConfigName).
+				WithPoolSelector(map[string]string{"kubernetes.io/hostname": kataNode.Object.Name}).
+				Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating KataConfig %s: %v", kata.KataConfigName, err)
+		} else {
+			glog.V(gpuparams.GpuLogLevel).Infof("KataConfig '%s' already exists", kata.KataConfigName)
+		}
+
+		kataConfig, err := katacfg.Pull(inittools.APIClient, kata.KataConfigName)
+		Expect(err).ToNot(HaveOccurred(), "error pulling KataConfig %s: %v", kata.KataConfigName, err)
+
+		err = await.Match(context.TODO(), fmt.Sprintf("KataConfig %s to install the kata runtime", kata.KataConfigName),
+			kataConfigPollInterval, kataConfigTimeout, func(context.Context) (interface{}, error) {
+				return kataConfig.IsInstalled()
+			}, BeTrue())
+		Expect(err).ToNot(HaveOccurred(), "kata runtime is not installed by KataConfig %s: %v", kata.KataConfigName,
+			err)
+
+		By("Create the kata test namespace")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		By("Enable sandbox workloads, the kata manager and the CC manager in the ClusterPolicy")
+		previousSpec, err = kata.EnableKataInClusterPolicy(inittools.APIClient, nvidiagpu.ClusterPolicyName, "off")
+		Expect(err).ToNot(HaveOccurred(), "error enabling kata in ClusterPolicy: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		By(fmt.Sprintf("Label node %s with %s=%s", kataNode.Object.Name, kata.WorkloadConfigLabel,
+			kata.WorkloadConfigVMPassthrough))
+		err = nodes.SetLabel(inittools.APIClient, kataNode.Object.Name, kata.WorkloadConfigLabel,
+			kata.WorkloadConfigVMPassthrough)
+		Expect(err).ToNot(HaveOccurred(), "error labeling node %s: %v", kataNode.Object.Name, err)
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.DeleteAndWait(workloadRunningTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if kataNode != nil {
+			for _, label := range []string{kata.WorkloadConfigLabel, kata.CCModeLabel} {
+				if err := nodes.SetLabel(inittools.APIClient, kataNode.Object.Name, label, ""); err != nil {
+					glog.Errorf("Error removing label %s from node %s: %v", label, kataNode.Object.Name, err)
+				}
+			}
+		}
+
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			} else if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				clusterPolicyReadyTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+
+		if kataConfigBuilder != nil && nvidiaGPUConfig.CleanupAfterTest {
+			By("Uninstall the kata runtime from the node")
+			if err := kataConfigBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting KataConfig %s: %v", kata.KataConfigName, err)
+			}
+		}
+	})
+
+	It("Should advertise the passthrough GPU and kata runtime class", Label("kata-resource"), func() {
+		By(fmt.Sprintf("Wait for node %s to advertise a passthrough GPU", kataNode.Object.Name))
+		err := await.Match(context.TODO(), fmt.Sprintf("node %s to advertise a passthrough GPU", kataNode.Object.Name),
+			allocatablePollInterval, allocatableTimeout, func(context.Context) (interface{}, error) {
+				var err error
+				passthroughKey, err = kata.PassthroughResourceName(inittools.APIClient, kataNode.Object.Name)
+
+				return passthroughKey, err
+			}, Not(BeEmpty()))
+		Expect(err).ToNot(HaveOccurred(), "node %s does not advertise a passthrough GPU: %v", kataNode.Object.Name, err)
+
+		By(fmt.Sprintf("Check RuntimeClass %s is created by the kata manager", nvidiaGPUConfig.KataRuntimeClass))
+		err = await.Match(context.TODO(), fmt.Sprintf("RuntimeClass %s to exist", nvidiaGPUConfig.KataRuntimeClass),
+			allocatablePollInterval, allocatableTimeout, func(context.Context) (interface{}, error) {
+				return kata.RuntimeClassExists(inittools.APIClient, nvidiaGPUConfig.KataRuntimeClass)
+			}, BeTrue())
+		Expect(err).ToNot(HaveOccurred(), "RuntimeClass %s does not exist: %v", nvidiaGPUConfig.KataRuntimeClass, err)
+	})
+
+	It("Should run a CUDA pod with a passthrough GPU inside a kata VM", Label("kata-pod"), func() {
+		if passthroughKey == "" {
+			Skip("No passthrough GPU is advertised on the node")
+		}
+
+		runKataPod("kata-cuda-pod", nvidiaGPUConfig.KataRuntimeClass, passthroughKey, kataNode)
+	})
+
+	It("Should toggle the GPU confidential computing mode", Label("kata-cc"), func() {
+		ccCapable, err := kata.GetNodeLabel(inittools.APIClient, kataNode.Object.Name, kata.CCCapableLabel)
+		Expect(err).ToNot(HaveOccurred(), "error getting node %s labels: %v", kataNode.Object.Name, err)
+
+		if ccCapable != "true" {
+			Skip(fmt.Sprintf("The GPUs of node %s do not support confidential computing", kataNode.Object.Name))
+		}
+
+		By("Enable the confidential computing mode")
+		err = cc.SetMode(inittools.APIClient, kataNode.Object.Name, cc.ModeOn, ccModePollInterval, ccModeTimeout)
+		Expect(err).ToNot(HaveOccurred(), "CC manager did not apply mode %s: %v", cc.ModeOn, err)
+
+		if nvidiaGPUConfig.KataCCRuntimeClass != "" && passthroughKey != "" {
+			runKataPod("kata-cc-cuda-pod", nvidiaGPUConfig.KataCCRuntimeClass, passthroughKey, kataNode)
+		} else {
+			glog.V(gpuparams.GpuLogLevel).Info("NVIDIAGPU_KATA_CC_RUNTIME_CLASS is not set, not running a " +
+				"confidential workload")
+		}
+
+		By("Disable the confidential computing mode")
+		err = cc.SetMode(inittools.APIClient, kataNode.Object.Name, cc.ModeOff, ccModePollInterval, ccModeTimeout)
+		Expect(err).ToNot(HaveOccurred(), "CC manager did not apply mode %s: %v", cc.ModeOff, err)
+	})
+})
+
+// runKataPod runs a CUDA pod in a kata VM with the runtime class and checks that it lists the passthrough GPU from
+// a guest kernel that differs from the node kernel.
+func runKataPod(podName, runtimeClassName, resourceName string, kataNode *nodes.Builder) {
+	By(fmt.Sprintf("Create pod %s with RuntimeClass %s", podName, runtimeClassName))
+	kataPod := kata.CreateKataPod(podName, TestNamespace, runtimeClassName, resourceName,
+		disconnected.Image(WorkloadImage))
+	kataPod.Spec.NodeSelector = map[string]string{"kubernetes.io/hostname": kataNode.Object.Name}
+
+	proxy.Inject(inittools.APIClient, &kataPod.Spec)
+	_, err := inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), kataPod, metav1.CreateOptions{})
+	Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", podName, err)
+
+	podBuilder, err := pod.Pull(inittools.APIClient, podName, TestNamespace)
+	Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", podName, err)
+
+	defer func() {
+		if _, err := podBuilder.DeleteAndWait(workloadRunningTimeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", podName, err)
+		}
+	}()
+
+	err = podBuilder.WaitUntilRunning(workloadRunningTimeout)
+	Expect(err).ToNot(HaveOccurred(), "pod %s is not running: %v", podName, err)
+
+	var podLog string
+	err = await.Match(context.TODO(), fmt.Sprintf("pod %s to report its kernel", podName),
+		workloadLogPollInterval, workloadLogTimeout, func(context.Context) (interface{}, error) {
+			podLog, _ = podBuilder.GetFullLog(kata.WorkloadContainerName)
+
+			return podLog, nil
+		}, ContainSubstring(kata.GuestKernelLogPrefix))
+	Expect(err).ToNot(HaveOccurred(), "pod %s did not report its kernel: %v", podName, err)
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Pod %s log:\n%s", podName, podLog)
+
+	Expect(podLog).To(ContainSubstring("GPU 0:"), "pod %s does not see the passthrough GPU", podName)
+
+	_, guestKernel, _ := strings.Cut(podLog, kata.GuestKernelLogPrefix)
+	guestKernel, _, _ = strings.Cut(guestKernel, "\n")
+	Expect(guestKernel).ToNot(Equal(kataNode.Object.Status.NodeInfo.KernelVersion),
+		"pod %s runs on the node kernel instead of a kata VM", podName)
+}
@@ -0,0 +1,311 @@
+package kmm
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/brownfield"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpudirect"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/kmm"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace of the KMM Module and of the testcases pods
+	TestNamespace = "test-kmm"
+	// ModuleName is the name of the KMM Module loading the NVIDIA kernel modules
+	ModuleName = "nvidia-kmod"
+	// DockerfileConfigMapName is the name of the configmap holding NVIDIAGPU_KMM_DOCKERFILE
+	DockerfileConfigMapName = "nvidia-kmod-dockerfile"
+	// BuildImage is the in-cluster registry image KMM pushes the Module image to when it builds it
+	BuildImage = "image-registry.openshift-image-registry.svc:5000/" + TestNamespace + "/nvidia-kmod:" +
+		kmm.KernelVersionVariable
+	// DebugImage is the container image of the privileged debug pod inspecting the host driver
+	DebugImage = "registry.access.redhat.com/ubi9/ubi:latest"
+
+	operatorInstallTimeout    = 15 * time.Minute
+	moduleLoadedTimeout       = 30 * time.Minute
+	moduleUnloadedTimeout     = 10 * time.Minute
+	modulePollInterval        = 30 * time.Second
+	driverPodTimeout          = 20 * time.Minute
+	driverPodPollInterval     = 30 * time.Second
+	clusterPolicyReadyTimeout = 20 * time.Minute
+	hostDriverPodTimeout      = 5 * time.Minute
+	workloadSuccessTimeout    = 10 * time.Minute
+	kmmDriverJobName          = "cuda-kmm-driver"
+	operatorDriverJobName     = "cuda-operator-driver"
+	failoverDriverJobName     = "cuda-failover-driver"
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+// runVectorAdd runs the cuda vectorAdd sample on the node and checks that it passes.
+func runVectorAdd(jobName, nodeName string) {
+	By(fmt.Sprintf("Run cuda vectorAdd Job %s on node %s", jobName, nodeName))
+	sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, jobName, TestNamespace,
+		cudasamples.VectorAdd, disconnected.Image(cudasamples.VectorAddImage)).
+		WithNodeSelector(map[string]string{corev1.LabelHostname: nodeName}).
+		Create()
+	Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", jobName, err)
+
+	defer func() {
+		if err := sampleBuilder.Delete(); err != nil {
+			glog.Errorf("Error deleting Job %s: %v", jobName, err)
+		}
+	}()
+
+	err = sampleBuilder.WaitUntilComplete(workloadSuccessTimeout)
+	Expect(err).ToNot(HaveOccurred(), "Job %s did not succeed: %v", jobName, err)
+
+	result, err := sampleBuilder.GetResult()
+	Expect(err).ToNot(HaveOccurred(), "error getting Job %s result: %v", jobName, err)
+	Expect(result.Passed).To(BeTrue(), "cuda vectorAdd failed on node %s", nodeName)
+}
+
+var _ = Describe("KMM", Ordered, Label(tsparams.LabelSuite, "kmm"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.DisruptsOperands), func() {
+	var (
+		gpuNodes          []*nodes.Builder
+		kmmNode           string
+		nsBuilder         *namespace.Builder
+		moduleCreated     bool
+		driverDeployLabel *string
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting KMM test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.KMMModuleImage == "" && nvidiaGPUConfig.KMMDockerfile == "" {
+			Skip("Neither NVIDIAGPU_KMM_MODULE_IMAGE nor NVIDIAGPU_KMM_DOCKERFILE is set")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if !clusterPolicyBuilder.Definition.Spec.Driver.IsEnabled() {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' does not deploy the driver, there is no operator driver to "+
+				"hand over from", nvidiagpu.ClusterPolicyName))
+		}
+
+		By("Check the CUDA sample and debug images are reachable")
+		Expect(disconnected.CheckImages(cudasamples.VectorAddImage, DebugImage)).ToNot(HaveOccurred(),
+			"the CUDA sample and debug images are not reachable through the mirrors")
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		kmmNode = gpuNodes[0].Object.Name
+		glog.V(gpuparams.GpuLogLevel).Infof("Using node '%s' for the KMM driver", kmmNode)
+
+		if value, ok := gpuNodes[0].Object.Labels[kmm.DriverDeployLabel]; ok {
+			driverDeployLabel = &value
+		}
+
+		By(fmt.Sprintf("Create the privileged namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.PrivilegedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+
+		err = gpudirect.CreateRDMAServiceAccount(inittools.APIClient, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error creating the privileged service account: %v", err)
+	})
+
+	AfterAll(func() {
+		if moduleCreated {
+			if err := kmm.DeleteModule(inittools.APIClient, ModuleName, TestNamespace); err != nil {
+				glog.Errorf("Error deleting KMM Module %s: %v", ModuleName, err)
+			}
+
+			if err := kmm.WaitForModuleLoaded(inittools.APIClient, ModuleName, TestNamespace, kmmNode, false,
+				modulePollInterval, moduleUnloadedTimeout); err != nil {
+				glog.Errorf("Error waiting for KMM Module %s to unload: %v", ModuleName, err)
+			}
+		}
+
+		if kmmNode != "" {
+			// the label is removed when the node had none
+			value := ""
+			if driverDeployLabel != nil {
+				value = *driverDeployLabel
+			}
+
+			if err := nodes.SetLabel(inittools.APIClient, kmmNode, kmm.DriverDeployLabel, value); err != nil {
+				glog.Errorf("Error restoring node %s label %s: %v", kmmNode, kmm.DriverDeployLabel, err)
+			}
+
+			if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				clusterPolicyReadyTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy %s to be ready: %v", nvidiagpu.ClusterPolicyName, err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should install the Kernel Module Management operator", Label("kmm-operator-install"), func() {
+		err := kmm.InstallOperator(inittools.APIClient, "", nvidiaGPUConfig.KMMSubscriptionChannel,
+			operatorInstallTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error installing the Kernel Module Management operator: %v", err)
+	})
+
+	It("Should hand the driver of a node over from the operator to KMM", Label("kmm-handover"), func() {
+		By(fmt.Sprintf("Stop the operator driver on node %s", kmmNode))
+		err := nodes.SetLabel(inittools.APIClient, kmmNode, kmm.DriverDeployLabel, "false")
+		Expect(err).ToNot(HaveOccurred(), "error labeling node %s: %v", kmmNode, err)
+
+		err = kmm.WaitForNodeDriverPod(inittools.APIClient, kmmNode, false, driverPodPollInterval, driverPodTimeout)
+		Expect(err).ToNot(HaveOccurred(), "the operator driver pod of node %s was not removed: %v", kmmNode, err)
+
+		moduleConfig := kmm.ModuleConfig{
+			Name:               ModuleName,
+			Namespace:          TestNamespace,
+			Image:              nvidiaGPUConfig.KMMModuleImage,
+			FirmwarePath:       nvidiaGPUConfig.KMMFirmwarePath,
+			ServiceAccountName: gpudirect.RDMAServiceAccount,
+			NodeSelector:       map[string]string{corev1.LabelHostname: kmmNode},
+		}
+
+		if nvidiaGPUConfig.KMMDockerfile != "" {
+			dockerfile, err := os.ReadFile(nvidiaGPUConfig.KMMDockerfile)
+			Expect(err).ToNot(HaveOccurred(), "error reading Dockerfile %s: %v", nvidiaGPUConfig.KMMDockerfile, err)
+
+			_, err = kmm.CreateDockerfileConfigMap(inittools.APIClient, DockerfileConfigMapName, TestNamespace,
+				string(dockerfile))
+			Expect(err).ToNot(HaveOccurred(), "error creating configmap %s: %v", DockerfileConfigMapName, err)
+
+			moduleConfig.DockerfileConfigMap = DockerfileConfigMapName
+			if moduleConfig.Image == "" {
+				moduleConfig.Image = BuildImage
+			}
+		}
+
+		By(fmt.Sprintf("Load the NVIDIA kernel modules on node %s with KMM Module %s", kmmNode, ModuleName))
+		err = kmm.CreateModule(inittools.APIClient, moduleConfig)
+		Expect(err).ToNot(HaveOccurred(), "error creating KMM Module %s: %v", ModuleName, err)
+		moduleCreated = true
+
+		err = kmm.WaitForModuleLoaded(inittools.APIClient, ModuleName, TestNamespace, kmmNode, true,
+			modulePollInterval, moduleLoadedTimeout)
+		Expect(err).ToNot(HaveOccurred(), "KMM did not load the NVIDIA kernel modules: %v", err)
+
+		By("Check the GPU stack runs on top of the KMM driver")
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "ClusterPolicy is not ready with the KMM driver: %v", err)
+
+		hostDriver, err := brownfield.GetHostDriver(inittools.APIClient, kmmNode, TestNamespace,
+			disconnected.Image(DebugImage), hostDriverPodTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error inspecting node %s driver: %v", kmmNode, err)
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Node %s KMM driver %+v", kmmNode, hostDriver)
+		Expect(hostDriver.ModuleLoaded).To(BeTrue(), "node %s has not loaded the NVIDIA kernel module", kmmNode)
+
+		driverPods, err := kmm.NodeDriverPods(inittools.APIClient, kmmNode)
+		Expect(err).ToNot(HaveOccurred(), "error listing node %s driver pods: %v", kmmNode, err)
+		Expect(driverPods).To(BeEmpty(), "the operator runs a driver pod next to the KMM driver on node %s", kmmNode)
+
+		runVectorAdd(kmmDriverJobName, kmmNode)
+	})
+
+	It("Should keep the operator driver on the other GPU nodes", Label("kmm-coexistence"), func() {
+		if !moduleCreated {
+			Skip("The KMM Module was not created")
+		}
+
+		if len(gpuNodes) < 2 {
+			Skip("A second GPU worker node is needed to run the operator driver next to the KMM driver")
+		}
+
+		for _, gpuNode := range gpuNodes[1:] {
+			nodeName := gpuNode.Object.Name
+
+			driverPods, err := kmm.NodeDriverPods(inittools.APIClient, nodeName)
+			Expect(err).ToNot(HaveOccurred(), "error listing node %s driver pods: %v", nodeName, err)
+			Expect(driverPods).To(HaveLen(1), "node %s does not run one operator driver pod", nodeName)
+
+			for podName, phase := range driverPods {
+				Expect(phase).To(Equal(corev1.PodRunning), "driver pod %s of node %s is not running", podName,
+					nodeName)
+			}
+
+			nodeBuilder, err := nodes.Pull(inittools.APIClient, nodeName)
+			Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", nodeName, err)
+			Expect(nodeBuilder.Object.Labels).ToNot(HaveKey(kmm.ModuleReadyLabel(TestNamespace, ModuleName)),
+				"KMM loaded the NVIDIA kernel modules on node %s outside of the Module selector", nodeName)
+		}
+
+		runVectorAdd(operatorDriverJobName, gpuNodes[1].Object.Name)
+	})
+
+	It("Should fail the driver of the node back over to the operator", Label("kmm-failover"), func() {
+		if !moduleCreated {
+			Skip("The KMM Module was not created")
+		}
+
+		By(fmt.Sprintf("Unload the KMM driver from node %s", kmmNode))
+		err := kmm.DeleteModule(inittools.APIClient, ModuleName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error deleting KMM Module %s: %v", ModuleName, err)
+
+		err = kmm.WaitForModuleLoaded(inittools.APIClient, ModuleName, TestNamespace, kmmNode, false,
+			modulePollInterval, moduleUnloadedTimeout)
+		Expect(err).ToNot(HaveOccurred(), "KMM did not unload the NVIDIA kernel modules: %v", err)
+		moduleCreated = false
+
+		By(fmt.Sprintf("Restart the operator driver on node %s", kmmNode))
+		err = nodes.SetLabel(inittools.APIClient, kmmNode, kmm.DriverDeployLabel, "true")
+		Expect(err).ToNot(HaveOccurred(), "error labeling node %s: %v", kmmNode, err)
+
+		err = kmm.WaitForNodeDriverPod(inittools.APIClient, kmmNode, true, driverPodPollInterval, driverPodTimeout)
+		Expect(err).ToNot(HaveOccurred(), "the operator driver pod of node %s is not ready: %v", kmmNode, err)
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "ClusterPolicy is not ready after the failover: %v", err)
+
+		runVectorAdd(failoverDriverJobName, kmmNode)
+	})
+})
@@ -0,0 +1,360 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nvidiagpuv1alpha1 "github.com/NVIDIA/k8s-operator-libs/api/upgrade/v1alpha1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/chaos"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/clusterupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/maintenance"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// TestNamespace is the namespace where the GPU workloads moved by the node maintenance run
+	TestNamespace = "test-gpu-maintenance"
+	// WorkloadDeploymentName is the name of the GPU workload deployment rescheduled by the node maintenance
+	WorkloadDeploymentName = "maintenance-workload"
+	// WorkloadImage is the container image of the GPU workload
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+	// NodeMaintenanceName is the name of the NodeMaintenance putting the GPU node into maintenance
+	NodeMaintenanceName = "nvidia-ci-gpu-maintenance"
+	// ReturnJobName is the name of the vectorAdd Job run on the GPU node once out of maintenance
+	ReturnJobName = "maintenance-return"
+
+	pollInterval          = 10 * time.Second
+	maintenanceTimeout    = 15 * time.Minute
+	workloadReadyTimeout  = 10 * time.Minute
+	allocatableTimeout    = 5 * time.Minute
+	sampleCompleteTimeout = 5 * time.Minute
+	namespaceTimeout      = 5 * time.Minute
+	// nodeAffinityWeight makes the workload pods prefer the node put into maintenance.
+	nodeAffinityWeight = 100
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("GPU Node Maintenance", Ordered, Label(tsparams.LabelSuite, "maintenance"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.DisruptsCluster), func() {
+	var (
+		nodeSelector      labels.Set
+		nodeName          string
+		method            string
+		drainSpec         *nvidiagpuv1alpha1.DrainSpec
+		replicas          int32
+		gpuCapacity       int64
+		operandUIDs       map[types.UID]string
+		inMaintenance     bool
+		workloadScheduled bool
+		nsBuilder         *namespace.Builder
+		workloadBuilder   *deployment.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting GPU Node Maintenance test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) < 2 {
+			Skip(fmt.Sprintf("Found %d GPU worker node(s), the GPU workloads need another GPU node to move to",
+				len(gpuNodes)))
+		}
+
+		nodeName = gpuNodes[0].Object.Name
+
+		// The workload pods fill the GPUs of the node put into maintenance, as long as the other GPU nodes can take
+		// them over.
+		var otherGPUs int
+		for _, gpuNode := range gpuNodes[1:] {
+			otherGPUs += get.GPUCount(gpuNode)
+		}
+
+		replicas = int32(min(get.GPUCount(gpuNodes[0]), otherGPUs))
+		if replicas == 0 {
+			Skip("The GPU nodes do not report their GPU count")
+		}
+
+		method, err = maintenance.Method(inittools.APIClient, nvidiaGPUConfig.MaintenanceMethod)
+		Expect(err).ToNot(HaveOccurred(), "error selecting the maintenance method: %v", err)
+
+		// Like oc adm drain --ignore-daemonsets --delete-emptydir-data, every pod but the daemonset pods is evicted,
+		// within the drain timeout of the driver upgrade policy of the GPU operator.
+		upgradeDrainSpec := chaos.ClusterPolicyDrainSpec(clusterPolicyBuilder)
+		drainSpec = &nvidiagpuv1alpha1.DrainSpec{
+			Force:          upgradeDrainSpec.Force,
+			TimeoutSecond:  upgradeDrainSpec.TimeoutSecond,
+			DeleteEmptyDir: true,
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Putting node '%s' into maintenance with method '%s' and %d workload "+
+			"pod(s)", nodeName, method, replicas)
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if inMaintenance {
+			By(fmt.Sprintf("Take node %s out of maintenance", nodeName))
+			if err := maintenance.End(inittools.APIClient, method, NodeMaintenanceName, nodeName, pollInterval,
+				maintenanceTimeout); err != nil {
+				glog.Errorf("Error taking node %s out of maintenance: %v", nodeName, err)
+			}
+		}
+
+		if workloadBuilder != nil {
+			if err := workloadBuilder.DeleteAndWait(workloadReadyTimeout); err != nil {
+				glog.Errorf("Error deleting deployment %s: %v", WorkloadDeploymentName, err)
+			}
+		}
+
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.DeleteAndWait(namespaceTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should run GPU workloads on the GPU node", Label("maintenance-workload"), func() {
+		By(fmt.Sprintf("Start %d GPU workload pod(s) preferring node %s", replicas, nodeName))
+		workloadBuilder = clusterupgrade.NewWorkloadDeployment(inittools.APIClient, WorkloadDeploymentName,
+			TestNamespace, disconnected.Image(WorkloadImage)).
+			WithReplicas(replicas).
+			WithNodeSelector(nodeSelector)
+		workloadBuilder.Definition.Spec.Template.Spec.Affinity = &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{
+					Weight: nodeAffinityWeight,
+					Preference: corev1.NodeSelectorTerm{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      corev1.LabelHostname,
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{nodeName},
+						}},
+					},
+				}},
+			},
+		}
+
+		var err error
+		workloadBuilder, err = workloadBuilder.Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating deployment %s: %v", WorkloadDeploymentName, err)
+
+		podNodes := expectWorkloadRunning(int(replicas), "")
+		workloadScheduled = true
+
+		var onNode int
+
+		for _, podNode := range podNodes {
+			if podNode == nodeName {
+				onNode++
+			}
+		}
+
+		Expect(onNode).To(BeNumerically(">", 0), "no workload pod was scheduled on node %s: %v", nodeName, podNodes)
+	})
+
+	It("Should move the GPU workloads to the other GPU nodes and keep the GPU operands when the node enters "+
+		"maintenance", Label("maintenance-drain"), func() {
+		if !workloadScheduled {
+			Skip("The GPU workloads were not scheduled on the GPU node")
+		}
+
+		nodeBuilder, err := nodes.Pull(inittools.APIClient, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", nodeName, err)
+
+		allocatable := nodeBuilder.Object.Status.Allocatable[cudasamples.GPUResource]
+		gpuCapacity = allocatable.Value()
+
+		operandUIDs, err = chaos.NodeOperandPodUIDs(inittools.APIClient, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error listing the GPU operator operands of node %s: %v", nodeName, err)
+		Expect(operandUIDs).ToNot(BeEmpty(), "no GPU operator operand runs on node %s", nodeName)
+
+		By(fmt.Sprintf("Put node %s into maintenance with method %s", nodeName, method))
+		inMaintenance = true
+
+		err = maintenance.Start(inittools.APIClient, method, NodeMaintenanceName, nodeName, drainSpec, pollInterval,
+			maintenanceTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error putting node %s into maintenance: %v", nodeName, err)
+
+		nodeBuilder, err = nodes.Pull(inittools.APIClient, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", nodeName, err)
+		Expect(nodeBuilder.Object.Spec.Unschedulable).To(BeTrue(), "node %s is not cordoned", nodeName)
+
+		By(fmt.Sprintf("Check no GPU pod is left on node %s", nodeName))
+		gpuPods, err := maintenance.NodeGPUPods(inittools.APIClient, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error listing the GPU pods of node %s: %v", nodeName, err)
+		Expect(podNames(gpuPods)).To(BeEmpty(), "GPU pods are left on node %s in maintenance", nodeName)
+
+		By(fmt.Sprintf("Check the GPU operator operands of node %s were kept", nodeName))
+		expectOperandsKept(nodeName, operandUIDs)
+
+		By("Check the GPU workloads were rescheduled on the other GPU nodes")
+		expectWorkloadRunning(int(replicas), nodeName)
+	})
+
+	It("Should return the GPU node to service when it leaves maintenance", Label("maintenance-return"), func() {
+		if !inMaintenance {
+			Skip("The GPU node was not put into maintenance")
+		}
+
+		By(fmt.Sprintf("Take node %s out of maintenance", nodeName))
+		err := maintenance.End(inittools.APIClient, method, NodeMaintenanceName, nodeName, pollInterval,
+			maintenanceTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error taking node %s out of maintenance: %v", nodeName, err)
+
+		inMaintenance = false
+
+		By(fmt.Sprintf("Wait for node %s to advertise its %d GPU(s)", nodeName, gpuCapacity))
+		err = wait.NodeAllocatable(inittools.APIClient, nodeName, cudasamples.GPUResource, gpuCapacity,
+			pollInterval, allocatableTimeout)
+		Expect(err).ToNot(HaveOccurred(), "node %s does not advertise its GPUs: %v", nodeName, err)
+
+		expectOperandsKept(nodeName, operandUIDs)
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, maintenanceTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+		By(fmt.Sprintf("Run vectorAdd on node %s", nodeName))
+		sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, ReturnJobName, TestNamespace,
+			cudasamples.VectorAdd, disconnected.Image(cudasamples.VectorAddImage)).
+			WithNodeSelector(map[string]string{corev1.LabelHostname: nodeName}).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", ReturnJobName, err)
+
+		DeferCleanup(func() {
+			if err := sampleBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting Job %s: %v", ReturnJobName, err)
+			}
+		})
+
+		Expect(sampleBuilder.WaitUntilComplete(sampleCompleteTimeout)).To(Succeed(),
+			"the GPUs of node %s are not usable once out of maintenance", nodeName)
+	})
+})
+
+// expectWorkloadRunning waits for the replicas of the workload deployment to be ready and list their GPU, off the
+// excluded node when set, and returns the nodes of the workload pods by pod name.
+func expectWorkloadRunning(replicas int, excludedNode string) map[string]string {
+	var podNodes map[string]string
+
+	err := await.Match(context.TODO(), fmt.Sprintf("%d workload pod(s) to be ready off node '%s'", replicas,
+		excludedNode), pollInterval, workloadReadyTimeout, func(context.Context) (interface{}, error) {
+		workloadPods, err := pod.List(inittools.APIClient, TestNamespace,
+			metav1.ListOptions{LabelSelector: clusterupgrade.WorkloadPodLabel})
+		if err != nil {
+			return 0, err
+		}
+
+		podNodes = map[string]string{}
+
+		for _, workloadPod := range workloadPods {
+			if workloadPod.Object.Spec.NodeName != excludedNode && podReady(workloadPod.Object) {
+				podNodes[workloadPod.Object.Name] = workloadPod.Object.Spec.NodeName
+			}
+		}
+
+		return len(podNodes), nil
+	}, Equal(replicas))
+	Expect(err).ToNot(HaveOccurred(), "deployment %s is not ready: %v", WorkloadDeploymentName, err)
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Workload pods run on nodes %v", podNodes)
+
+	for podName := range podNodes {
+		workloadPod, err := pod.Pull(inittools.APIClient, podName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", podName, err)
+
+		err = await.Match(context.TODO(), fmt.Sprintf("pod %s to list the GPU", podName), pollInterval, time.Minute,
+			func(context.Context) (interface{}, error) {
+				return workloadPod.GetFullLog(clusterupgrade.WorkloadContainerName)
+			}, ContainSubstring("GPU 0:"))
+		Expect(err).ToNot(HaveOccurred(), "pod %s does not list the GPU: %v", podName, err)
+	}
+
+	return podNodes
+}
+
+// expectOperandsKept checks the GPU operator operand pods of the node were neither evicted nor replaced.
+func expectOperandsKept(nodeName string, operandUIDs map[types.UID]string) {
+	currentUIDs, err := chaos.NodeOperandPodUIDs(inittools.APIClient, nodeName)
+	Expect(err).ToNot(HaveOccurred(), "error listing the GPU operator operands of node %s: %v", nodeName, err)
+
+	for operandUID, operandName := range operandUIDs {
+		Expect(currentUIDs).To(HaveKey(operandUID), "GPU operator operand %s of node %s was evicted or replaced",
+			operandName, nodeName)
+	}
+}
+
+// podReady returns true when the pod is running, not being deleted, and all of its containers are ready.
+func podReady(workloadPod *corev1.Pod) bool {
+	if workloadPod.DeletionTimestamp != nil || workloadPod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+
+	for _, containerStatus := range workloadPod.Status.ContainerStatuses {
+		if !containerStatus.Ready {
+			return false
+		}
+	}
+
+	return len(workloadPod.Status.ContainerStatuses) > 0
+}
+
+// podNames returns the namespaced names of the pods.
+func podNames(pods []*pod.Builder) []string {
+	names := make([]string, 0, len(pods))
+	for _, namedPod := range pods {
+		names = append(names, namedPod.Object.Namespace+"/"+namedPod.Object.Name)
+	}
+
+	return names
+}
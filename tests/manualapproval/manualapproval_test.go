@@ -0,0 +1,259 @@
+package manualapproval
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/check"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/operatorupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+)
+
+const (
+	installPlanPollInterval = 30 * time.Second
+	installPlanTimeout      = 10 * time.Minute
+	// installPlanHoldDuration is how long the installation, or upgrade, is checked to be held by the pending
+	// installplan.
+	installPlanHoldDuration = 2 * time.Minute
+	namespaceDeleteTimeout  = 5 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Manual Approval", Ordered, Label(tsparams.LabelSuite, "manual-approval"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.DisruptsCluster), func() {
+	var (
+		installedCSV    string
+		operatorNs      *namespace.Builder
+		ogBuilder       *olm.OperatorGroupBuilder
+		subBuilder      *olm.SubscriptionBuilder
+		operatorCreated bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Manual Approval test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if _, err := olm.PullSubscription(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace); err == nil {
+			Skip(fmt.Sprintf("Subscription '%s' already exists, the manual approval tests deploy the GPU "+
+				"operator themselves", nvidiagpu.SubscriptionName))
+		}
+
+		if ready, err := check.NFDDeploymentsReady(inittools.APIClient); !ready {
+			Skip(fmt.Sprintf("NFD must be deployed before running the manual approval tests: %v", err))
+		}
+
+		catalogSource := nvidiaGPUConfig.CatalogSource
+		if catalogSource == "" {
+			catalogSource = nvidiagpu.CatalogSourceDefault
+		}
+
+		By("Resolve the subscription channel against the packagemanifest channels")
+		channel, err := olm.ResolveChannel(inittools.APIClient, nvidiagpu.Package, nvidiagpu.CatalogSourceNamespace,
+			catalogSource, nvidiaGPUConfig.SubscriptionChannel)
+		Expect(err).ToNot(HaveOccurred(), "error resolving NVIDIAGPU_SUBSCRIPTION_CHANNEL: %v", err)
+
+		By("Create and label the GPU operator namespace")
+		operatorNs = namespace.NewBuilder(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace)
+		if !operatorNs.Exists() {
+			createdNs, err := operatorNs.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", nvidiagpu.NvidiaGPUNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(map[string]string{
+				"openshift.io/cluster-monitoring":    "true",
+				"pod-security.kubernetes.io/enforce": "privileged",
+			}).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", nvidiagpu.NvidiaGPUNamespace, err)
+		}
+
+		ogBuilder = olm.NewOperatorGroupBuilder(inittools.APIClient, nvidiagpu.OperatorGroupName,
+			nvidiagpu.NvidiaGPUNamespace)
+		if !ogBuilder.Exists() {
+			_, err := ogBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating operatorgroup %s: %v", nvidiagpu.OperatorGroupName, err)
+		}
+
+		By(fmt.Sprintf("Subscribe to channel %s with manual installplan approval", channel))
+		subBuilder = olm.NewSubscriptionBuilder(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace, catalogSource, nvidiagpu.CatalogSourceNamespace, nvidiagpu.Package).
+			WithChannel(channel).
+			WithInstallPlanApproval(v1alpha1.ApprovalManual)
+
+		if nvidiaGPUConfig.SubscriptionStartingCSV != "" {
+			subBuilder.WithStartingCSV(nvidiaGPUConfig.SubscriptionStartingCSV)
+		}
+
+		_, err = subBuilder.Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating subscription %s: %v", nvidiagpu.SubscriptionName, err)
+		operatorCreated = true
+	})
+
+	AfterAll(func() {
+		if !operatorCreated || !nvidiaGPUConfig.CleanupAfterTest {
+			return
+		}
+
+		By("Remove the GPU operator deployed by the manual approval tests")
+		if err := subBuilder.Delete(); err != nil {
+			glog.Errorf("Error deleting subscription %s: %v", nvidiagpu.SubscriptionName, err)
+		}
+
+		csvBuilders, err := olm.ListClusterServiceVersion(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace)
+		if err == nil {
+			for _, csvBuilder := range csvBuilders {
+				if err := csvBuilder.Delete(); err != nil {
+					glog.Errorf("Error deleting CSV %s: %v", csvBuilder.Object.Name, err)
+				}
+			}
+		}
+
+		if err := ogBuilder.Delete(); err != nil {
+			glog.Errorf("Error deleting operatorgroup %s: %v", nvidiagpu.OperatorGroupName, err)
+		}
+
+		if err := operatorNs.DeleteAndWait(namespaceDeleteTimeout); err != nil {
+			glog.Errorf("Error deleting namespace %s: %v", nvidiagpu.NvidiaGPUNamespace, err)
+		}
+	})
+
+	It("Should hold the installation until the installplan is approved", Label("manual-approval-pending"), func() {
+		By("Wait for the installplan of the subscription to require approval")
+		err := wait.InstallPlansPending(inittools.APIClient, nvidiagpu.SubscriptionNamespace,
+			installPlanPollInterval, installPlanTimeout)
+		Expect(err).ToNot(HaveOccurred(), "no installplan requires approval: %v", err)
+
+		pendingInstallPlans, err := olm.ListPendingInstallPlans(inittools.APIClient, nvidiagpu.SubscriptionNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error listing the pending installplans: %v", err)
+
+		for _, installPlan := range pendingInstallPlans {
+			glog.V(gpuparams.GpuLogLevel).Infof("Installplan '%s' installing CSVs %v is pending approval",
+				installPlan.Object.Name, installPlan.Object.Spec.ClusterServiceVersionNames)
+			Expect(installPlan.Object.Spec.Approval).To(Equal(v1alpha1.ApprovalManual),
+				"installplan %s is not manually approved", installPlan.Object.Name)
+		}
+
+		By(fmt.Sprintf("Verify no CSV is installed for %s while the installplan is pending", installPlanHoldDuration))
+		Consistently(func() string {
+			pulledSubBuilder, err := olm.PullSubscription(inittools.APIClient, nvidiagpu.SubscriptionName,
+				nvidiagpu.SubscriptionNamespace)
+			if err != nil {
+				return err.Error()
+			}
+
+			return pulledSubBuilder.Object.Status.InstalledCSV
+		}, installPlanHoldDuration, installPlanPollInterval).Should(BeEmpty(),
+			"the subscription installed a CSV before its installplan was approved")
+	})
+
+	It("Should install the GPU operator once the installplan is approved", Label("manual-approval-approve"), func() {
+		By("Approve the pending installplans")
+		approvedCSVs, err := operatorupgrade.ApprovePendingInstallPlans(inittools.APIClient,
+			nvidiagpu.SubscriptionNamespace, installPlanPollInterval, installPlanTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error approving the pending installplans: %v", err)
+
+		pulledSubBuilder, err := olm.PullSubscription(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling subscription %s: %v", nvidiagpu.SubscriptionName, err)
+
+		currentCSV := pulledSubBuilder.Object.Status.CurrentCSV
+		Expect(approvedCSVs).To(ContainElement(currentCSV), "the approved installplans do not install CSV %s",
+			currentCSV)
+
+		By(fmt.Sprintf("Wait for the subscription to install CSV %s", currentCSV))
+		err = wait.SubscriptionInstalledCSV(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace, currentCSV, installPlanPollInterval, nvidiagpu.CsvSucceededTimeout)
+		Expect(err).ToNot(HaveOccurred(), "subscription did not install CSV %s: %v", currentCSV, err)
+
+		err = wait.CSVSucceeded(inittools.APIClient, currentCSV, nvidiagpu.NvidiaGPUNamespace,
+			nvidiagpu.CsvSucceededCheckInterval, nvidiagpu.CsvSucceededTimeout)
+		Expect(err).ToNot(HaveOccurred(), "CSV %s did not succeed: %v", currentCSV, err)
+
+		installedCSV = currentCSV
+		glog.V(gpuparams.GpuLogLevel).Infof("GPU operator CSV '%s' installed after manual approval", installedCSV)
+	})
+
+	It("Should hold the upgrade until the installplan is approved", Label("manual-approval-upgrade"), func() {
+		if installedCSV == "" {
+			Skip("The GPU operator was not installed")
+		}
+
+		if nvidiaGPUConfig.OperatorUpgradeToChannel == "" {
+			Skip("NVIDIAGPU_SUBSCRIPTION_UPGRADE_TO_CHANNEL is not set")
+		}
+
+		pulledSubBuilder, err := olm.PullSubscription(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling subscription %s: %v", nvidiagpu.SubscriptionName, err)
+
+		upgradeChannel, err := olm.ResolveChannel(inittools.APIClient, nvidiagpu.Package,
+			pulledSubBuilder.Object.Spec.CatalogSourceNamespace, pulledSubBuilder.Object.Spec.CatalogSource,
+			nvidiaGPUConfig.OperatorUpgradeToChannel)
+		Expect(err).ToNot(HaveOccurred(), "error resolving NVIDIAGPU_SUBSCRIPTION_UPGRADE_TO_CHANNEL: %v", err)
+
+		if upgradeChannel == pulledSubBuilder.Object.Spec.Channel {
+			Skip(fmt.Sprintf("The subscription is already on channel %s", upgradeChannel))
+		}
+
+		By(fmt.Sprintf("Switch the subscription channel to %s", upgradeChannel))
+		_, err = pulledSubBuilder.WithChannel(upgradeChannel).Update()
+		Expect(err).ToNot(HaveOccurred(), "error updating subscription %s: %v", nvidiagpu.SubscriptionName, err)
+
+		By("Wait for the upgrade installplan to require approval")
+		err = wait.InstallPlansPending(inittools.APIClient, nvidiagpu.SubscriptionNamespace,
+			installPlanPollInterval, installPlanTimeout)
+		Expect(err).ToNot(HaveOccurred(), "no upgrade installplan requires approval: %v", err)
+
+		By(fmt.Sprintf("Verify CSV %s stays installed for %s while the upgrade is pending", installedCSV,
+			installPlanHoldDuration))
+		Consistently(func() string {
+			pulledSubBuilder, err := olm.PullSubscription(inittools.APIClient, nvidiagpu.SubscriptionName,
+				nvidiagpu.SubscriptionNamespace)
+			if err != nil {
+				return err.Error()
+			}
+
+			return pulledSubBuilder.Object.Status.InstalledCSV
+		}, installPlanHoldDuration, installPlanPollInterval).Should(Equal(installedCSV),
+			"the subscription upgraded before its installplan was approved")
+
+		By("Approve the upgrade installplans")
+		_, err = operatorupgrade.ApprovePendingInstallPlans(inittools.APIClient, nvidiagpu.SubscriptionNamespace,
+			installPlanPollInterval, installPlanTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error approving the upgrade installplans: %v", err)
+
+		pulledSubBuilder, err = olm.PullSubscription(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling subscription %s: %v", nvidiagpu.SubscriptionName, err)
+
+		upgradedCSV := pulledSubBuilder.Object.Status.CurrentCSV
+		Expect(upgradedCSV).ToNot(Equal(installedCSV), "the upgrade installplan does not install a new CSV")
+
+		By(fmt.Sprintf("Wait for the subscription to upgrade to CSV %s", upgradedCSV))
+		err = wait.SubscriptionInstalledCSV(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace, upgradedCSV, installPlanPollInterval, nvidiagpu.CsvSucceededTimeout)
+		Expect(err).ToNot(HaveOccurred(), "subscription did not install CSV %s: %v", upgradedCSV, err)
+
+		err = wait.CSVSucceeded(inittools.APIClient, upgradedCSV, nvidiagpu.NvidiaGPUNamespace,
+			nvidiagpu.CsvSucceededCheckInterval, nvidiagpu.CsvSucceededTimeout)
+		Expect(err).ToNot(HaveOccurred(), "CSV %s did not succeed: %v", upgradedCSV, err)
+	})
+})
@@ -0,0 +1,460 @@
+package mig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/chaos"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/mig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/cleanup"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/gpuburn"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TestNamespace is the namespace where MIG workloads will run
+	TestNamespace = "test-mig"
+	// CUDAImage is the cuda sample image used to validate MIG slices
+	CUDAImage = cudasamples.VectorAddImage
+
+	migConfigTimeout         = 15 * time.Minute
+	allocatablePollInterval  = 15 * time.Second
+	allocatableTimeout       = 5 * time.Minute
+	clusterPolicyPollTimeout = 15 * time.Minute
+	workloadSuccessTimeout   = 5 * time.Minute
+
+	// LoadJobName is the gpu-burn Job loading the MIG slices while the MIG geometry changes.
+	LoadJobName = "mig-reconfigure-load"
+
+	// loadDuration outlasts the reconfiguration, the gpu-burn pod being evicted before it completes.
+	loadDuration       = time.Hour
+	pausedPollInterval = 5 * time.Second
+	evictionTimeout    = 5 * time.Minute
+)
+
+var (
+	// migGeometries contains the mig-parted profiles exercised per GPU product family.
+	// The first entries are uniform and used in the single strategy, "all-balanced" is used in the mixed strategy.
+	migGeometries = map[string][]mig.Geometry{
+		"A100-40GB": {
+			{Config: "all-1g.5gb", Profiles: map[string]int{"1g.5gb": 7}},
+			{Config: "all-2g.10gb", Profiles: map[string]int{"2g.10gb": 3}},
+			{Config: "all-3g.20gb", Profiles: map[string]int{"3g.20gb": 2}},
+			{Config: "all-balanced", Profiles: map[string]int{"1g.5gb": 2, "2g.10gb": 1, "3g.20gb": 1}},
+		},
+		"A100-80GB": {
+			{Config: "all-1g.10gb", Profiles: map[string]int{"1g.10gb": 7}},
+			{Config: "all-2g.20gb", Profiles: map[string]int{"2g.20gb": 3}},
+			{Config: "all-3g.40gb", Profiles: map[string]int{"3g.40gb": 2}},
+			{Config: "all-balanced", Profiles: map[string]int{"1g.10gb": 2, "2g.20gb": 1, "3g.40gb": 1}},
+		},
+		"H100-80GB": {
+			{Config: "all-1g.10gb", Profiles: map[string]int{"1g.10gb": 7}},
+			{Config: "all-2g.20gb", Profiles: map[string]int{"2g.20gb": 3}},
+			{Config: "all-3g.40gb", Profiles: map[string]int{"3g.40gb": 2}},
+			{Config: "all-balanced", Profiles: map[string]int{"1g.10gb": 2, "2g.20gb": 1, "3g.40gb": 1}},
+		},
+	}
+)
+
+var _ = Describe("MIG", Ordered, Label(tsparams.LabelSuite, "mig"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.DisruptsOperands, cilabels.MIG), func() {
+	var (
+		nsBuilder        *namespace.Builder
+		migNode          *nodes.Builder
+		gpuCount         int
+		singleGeometries []mig.Geometry
+		mixedGeometry    mig.Geometry
+		previousStrategy nvidiagpuv1.MIGStrategy
+		strategyChanged  bool
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting MIG test suite")
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(CUDAImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		By("Find a MIG capable GPU worker node")
+		migNodes, err := mig.ListMIGCapableNodes(inittools.APIClient, inittools.GeneralConfig.WorkerLabelMap)
+		Expect(err).ToNot(HaveOccurred(), "error listing MIG capable nodes: %v", err)
+
+		if len(migNodes) == 0 {
+			Skip("No worker node is labeled " + mig.MIGCapableLabel + "=true")
+		}
+
+		migNode = migNodes[0]
+		gpuCount = get.GPUCount(migNode)
+		product := migNode.Object.Labels[mig.GPUProductLabel]
+		glog.V(gpuparams.GpuLogLevel).Infof("Using node '%s' with %d '%s' GPUs for MIG tests",
+			migNode.Object.Name, gpuCount, product)
+		Expect(gpuCount).To(BeNumerically(">", 0), "node %s has no nvidia.com/gpu.count label",
+			migNode.Object.Name)
+
+		geometries := geometriesForProduct(product)
+		if geometries == nil {
+			Skip(fmt.Sprintf("No MIG geometries are defined for GPU product '%s'", product))
+		}
+
+		singleGeometries = geometries[:len(geometries)-1]
+		mixedGeometry = geometries[len(geometries)-1]
+
+		By("Create the MIG test namespace")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		DeferCleanup(cleanup.Register("the ClusterPolicy MIG strategy", func() error {
+			if !strategyChanged {
+				return nil
+			}
+
+			_, err := mig.SetClusterPolicyStrategy(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousStrategy)
+
+			return err
+		}))
+	})
+
+	AfterAll(func() {
+		if migNode != nil {
+			By("Disable MIG on the test node")
+			if err := mig.LabelNodeMIGConfig(inittools.APIClient, migNode.Object.Name,
+				mig.MIGConfigAllDisabled); err != nil {
+				glog.Errorf("Error resetting MIG config on node %s: %v", migNode.Object.Name, err)
+			} else if err := mig.WaitForMIGConfigState(inittools.APIClient, migNode.Object.Name,
+				mig.MIGConfigStateSuccess, migConfigTimeout); err != nil {
+				glog.Errorf("Error waiting for MIG to be disabled on node %s: %v", migNode.Object.Name, err)
+			}
+		}
+
+		if strategyChanged {
+			By("Restore the original ClusterPolicy MIG strategy")
+			if _, err := mig.SetClusterPolicyStrategy(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousStrategy); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy MIG strategy: %v", err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	Context("MIG single strategy", Label("mig-single"), func() {
+		BeforeAll(func() {
+			setStrategy(nvidiagpuv1.MIGStrategySingle, &previousStrategy, &strategyChanged)
+		})
+
+		It("Should expose uniform MIG slices as nvidia.com/gpu and run CUDA workloads", Label("mig"), func() {
+			for _, geometry := range singleGeometries {
+				applyGeometry(migNode.Object.Name, geometry)
+
+				for profile, count := range geometry.Profiles {
+					expected := int64(count * gpuCount)
+
+					By(fmt.Sprintf("Wait for %d %s resources with geometry %s", expected, mig.GPUResourceName,
+						geometry.Config))
+					err := wait.NodeAllocatable(inittools.APIClient, migNode.Object.Name, mig.GPUResourceName,
+						expected, allocatablePollInterval, allocatableTimeout)
+					Expect(err).ToNot(HaveOccurred(), "node %s did not advertise %d %s: %v",
+						migNode.Object.Name, expected, mig.GPUResourceName, err)
+
+					pulledNode, err := nodes.Pull(inittools.APIClient, migNode.Object.Name)
+					Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", migNode.Object.Name, err)
+					Expect(pulledNode.Object.Labels[mig.MIGStrategyLabel]).To(Equal(string(nvidiagpuv1.MIGStrategySingle)))
+					Expect(pulledNode.Object.Labels[mig.GPUProductLabel]).To(HaveSuffix("MIG-"+profile),
+						"GFD did not report the MIG product for profile %s", profile)
+
+					runCUDAWorkload(fmt.Sprintf("mig-single-%s", sanitize(profile)), migNode.Object.Name,
+						mig.GPUResourceName)
+				}
+			}
+		})
+
+		It("Should evict the workloads and schedule new ones when the MIG geometry changes under load",
+			Label("mig-reconfigure"), func() {
+				from := singleGeometries[0]
+				to := singleGeometries[len(singleGeometries)-1]
+				nodeName := migNode.Object.Name
+
+				applyGeometry(nodeName, from)
+
+				fromSlices := totalSlices(from, gpuCount)
+				By(fmt.Sprintf("Wait for %d %s resources with geometry %s", fromSlices, mig.GPUResourceName,
+					from.Config))
+				err := awaitAllocatable(nodeName, fromSlices)
+				Expect(err).ToNot(HaveOccurred(), "node %s did not advertise %d %s: %v", nodeName, fromSlices,
+					mig.GPUResourceName, err)
+
+				clusterArch, err := get.GetClusterArchitecture(inittools.APIClient,
+					map[string]string{corev1.LabelHostname: nodeName})
+				Expect(err).ToNot(HaveOccurred(), "error getting the node %s architecture: %v", nodeName, err)
+
+				burnImage, err := gpuburn.Images.Image(clusterArch)
+				Expect(err).ToNot(HaveOccurred(), "error selecting the gpu-burn image: %v", err)
+
+				By("Check the gpu-burn image is reachable")
+				Expect(disconnected.CheckImages(burnImage)).ToNot(HaveOccurred(),
+					"the gpu-burn image is not reachable through the mirrors")
+				reporter.RecordImageDigests(clusterArch, burnImage)
+
+				By(fmt.Sprintf("Load the %d MIG slices of geometry %s with gpu-burn", fromSlices, from.Config))
+				loadBuilder, err := gpuburn.NewBuilder(inittools.APIClient, LoadJobName, TestNamespace,
+					disconnected.Image(burnImage)).
+					WithDuration(loadDuration).
+					WithGPUsPerNode(int(fromSlices)).
+					WithNodeSelector(map[string]string{corev1.LabelHostname: nodeName}).
+					Create()
+				Expect(err).ToNot(HaveOccurred(), "error creating gpu-burn Job %s: %v", LoadJobName, err)
+
+				DeferCleanup(func() {
+					if err := loadBuilder.Delete(); err != nil {
+						glog.Errorf("Error deleting gpu-burn Job %s: %v", LoadJobName, err)
+					}
+				})
+
+				loadSelector := "job-name=" + LoadJobName
+				err = await.Match(context.TODO(), fmt.Sprintf("the gpu-burn pod to run on node %s", nodeName),
+					allocatablePollInterval, nvidiagpu.BurnPodCreationTimeout,
+					func(context.Context) (interface{}, error) {
+						return runningPods(loadSelector, nodeName)
+					}, Equal(1))
+				Expect(err).ToNot(HaveOccurred(), "gpu-burn Job %s did not start: %v", LoadJobName, err)
+
+				pulledNode, err := nodes.Pull(inittools.APIClient, nodeName)
+				Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", nodeName, err)
+				devicePluginDeploy := pulledNode.Object.Labels[mig.DevicePluginDeployLabel]
+
+				By(fmt.Sprintf("Change the MIG geometry of node %s to %s under load", nodeName, to.Config))
+				err = mig.LabelNodeMIGConfig(inittools.APIClient, nodeName, to.Config)
+				Expect(err).ToNot(HaveOccurred(), "error labeling node %s with MIG config %s: %v", nodeName,
+					to.Config, err)
+
+				By("Wait for the MIG manager to pause the GPU operands")
+				err = wait.NodeLabels(inittools.APIClient, nodeName, map[string]string{
+					mig.MIGConfigStateLabel:     mig.MIGConfigStatePending,
+					mig.DevicePluginDeployLabel: mig.GPUClientsPausedForMIGChange,
+				}, true, pausedPollInterval, migConfigTimeout)
+				Expect(err).ToNot(HaveOccurred(), "the MIG manager did not pause the GPU operands of node %s: %v",
+					nodeName, err)
+
+				By("Evict the workloads holding the MIG slices")
+				err = mig.EvictGPUWorkloads(inittools.APIClient, nodeName, loadSelector, evictionTimeout)
+				Expect(err).ToNot(HaveOccurred(), "error evicting the gpu-burn pods of node %s: %v", nodeName, err)
+
+				loadPods, err := chaos.NodePodUIDs(inittools.APIClient, TestNamespace, loadSelector, nodeName)
+				Expect(err).ToNot(HaveOccurred(), "error listing the gpu-burn pods of node %s: %v", nodeName, err)
+				Expect(loadPods).To(BeEmpty(), "gpu-burn pods are left on node %s", nodeName)
+
+				err = loadBuilder.WaitUntilComplete(evictionTimeout)
+				Expect(err).To(HaveOccurred(), "the evicted gpu-burn Job %s completed", LoadJobName)
+
+				By(fmt.Sprintf("Wait for the MIG manager to apply geometry %s", to.Config))
+				err = mig.WaitForMIGConfigState(inittools.APIClient, nodeName, mig.MIGConfigStateSuccess,
+					migConfigTimeout)
+				Expect(err).ToNot(HaveOccurred(), "MIG config %s was not applied on node %s: %v", to.Config,
+					nodeName, err)
+
+				By("Wait for the MIG manager to resume the GPU operands")
+				err = wait.NodeLabels(inittools.APIClient, nodeName,
+					map[string]string{mig.DevicePluginDeployLabel: devicePluginDeploy}, true, allocatablePollInterval,
+					allocatableTimeout)
+				Expect(err).ToNot(HaveOccurred(), "the MIG manager did not resume the GPU operands of node %s: %v",
+					nodeName, err)
+
+				toSlices := totalSlices(to, gpuCount)
+				By(fmt.Sprintf("Wait for %d %s resources with geometry %s", toSlices, mig.GPUResourceName,
+					to.Config))
+				err = awaitAllocatable(nodeName, toSlices)
+				Expect(err).ToNot(HaveOccurred(), "node %s did not advertise %d %s: %v", nodeName, toSlices,
+					mig.GPUResourceName, err)
+
+				pulledNode, err = nodes.Pull(inittools.APIClient, nodeName)
+				Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", nodeName, err)
+				Expect(pulledNode.Object.Labels[mig.MIGConfigLabel]).To(Equal(to.Config))
+
+				for profile := range to.Profiles {
+					Expect(pulledNode.Object.Labels[mig.GPUProductLabel]).To(HaveSuffix("MIG-"+profile),
+						"GFD did not report the MIG product for profile %s", profile)
+
+					runCUDAWorkload(fmt.Sprintf("mig-reconfigured-%s", sanitize(profile)), nodeName,
+						mig.GPUResourceName)
+				}
+			})
+	})
+
+	Context("MIG mixed strategy", Label("mig-mixed"), func() {
+		BeforeAll(func() {
+			setStrategy(nvidiagpuv1.MIGStrategyMixed, &previousStrategy, &strategyChanged)
+		})
+
+		It("Should expose every MIG profile as a dedicated resource and run CUDA workloads", Label("mig"), func() {
+			applyGeometry(migNode.Object.Name, mixedGeometry)
+
+			for profile, count := range mixedGeometry.Profiles {
+				resourceName := mig.ResourceName(profile)
+				expected := int64(count * gpuCount)
+
+				By(fmt.Sprintf("Wait for %d %s resources", expected, resourceName))
+				err := wait.NodeAllocatable(inittools.APIClient, migNode.Object.Name, resourceName, expected,
+					allocatablePollInterval, allocatableTimeout)
+				Expect(err).ToNot(HaveOccurred(), "node %s did not advertise %d %s: %v",
+					migNode.Object.Name, expected, resourceName, err)
+			}
+
+			pulledNode, err := nodes.Pull(inittools.APIClient, migNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", migNode.Object.Name, err)
+			Expect(pulledNode.Object.Labels[mig.MIGStrategyLabel]).To(Equal(string(nvidiagpuv1.MIGStrategyMixed)))
+
+			for profile := range mixedGeometry.Profiles {
+				runCUDAWorkload(fmt.Sprintf("mig-mixed-%s", sanitize(profile)), migNode.Object.Name,
+					mig.ResourceName(profile))
+			}
+		})
+	})
+})
+
+// setStrategy updates the ClusterPolicy MIG strategy and waits for the ClusterPolicy to be ready again.
+func setStrategy(strategy nvidiagpuv1.MIGStrategy, previousStrategy *nvidiagpuv1.MIGStrategy, changed *bool) {
+	By(fmt.Sprintf("Set ClusterPolicy MIG strategy to '%s'", strategy))
+	previous, err := mig.SetClusterPolicyStrategy(inittools.APIClient, nvidiagpu.ClusterPolicyName, strategy)
+	Expect(err).ToNot(HaveOccurred(), "error setting MIG strategy: %v", err)
+
+	if !*changed {
+		*previousStrategy = previous
+		*changed = true
+	}
+
+	err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyPollTimeout)
+	Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+}
+
+// applyGeometry labels the node with a mig-parted profile and waits for the MIG manager to apply it.
+func applyGeometry(nodeName string, geometry mig.Geometry) {
+	By(fmt.Sprintf("Apply MIG geometry '%s' on node %s", geometry.Config, nodeName))
+	err := mig.LabelNodeMIGConfig(inittools.APIClient, nodeName, geometry.Config)
+	Expect(err).ToNot(HaveOccurred(), "error labeling node %s with MIG config %s: %v", nodeName,
+		geometry.Config, err)
+
+	err = mig.WaitForMIGConfigState(inittools.APIClient, nodeName, mig.MIGConfigStateSuccess, migConfigTimeout)
+	Expect(err).ToNot(HaveOccurred(), "MIG config %s was not applied on node %s: %v", geometry.Config,
+		nodeName, err)
+}
+
+// runCUDAWorkload runs a cuda vectorAdd Job consuming one unit of the resource and checks that it passed.
+func runCUDAWorkload(jobName, nodeName string, resourceName corev1.ResourceName) {
+	By(fmt.Sprintf("Run cuda vectorAdd Job %s requesting %s", jobName, resourceName))
+	sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, jobName, TestNamespace, cudasamples.VectorAdd,
+		disconnected.Image(CUDAImage)).
+		WithGPUResource(resourceName).
+		WithNodeSelector(map[string]string{corev1.LabelHostname: nodeName}).
+		Create()
+	Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", jobName, err)
+
+	defer func() {
+		if err := sampleBuilder.Delete(); err != nil {
+			glog.Errorf("Error deleting Job %s: %v", jobName, err)
+		}
+	}()
+
+	err = sampleBuilder.WaitUntilComplete(workloadSuccessTimeout)
+	Expect(err).ToNot(HaveOccurred(), "Job %s did not succeed: %v", jobName, err)
+
+	result, err := sampleBuilder.GetResult()
+	Expect(err).ToNot(HaveOccurred(), "error getting Job %s result: %v", jobName, err)
+	Expect(result.Passed).To(BeTrue(), "cuda vectorAdd failed on %s", resourceName)
+}
+
+// geometriesForProduct returns the MIG geometries for a GPU product label, or nil if the product is unknown.
+func geometriesForProduct(product string) []mig.Geometry {
+	for family, geometries := range migGeometries {
+		model, memory, _ := strings.Cut(family, "-")
+		if strings.Contains(product, model) && strings.Contains(product, memory) {
+			return geometries
+		}
+	}
+
+	return nil
+}
+
+// totalSlices returns the number of MIG slices of the geometry on a node with gpuCount GPUs.
+func totalSlices(geometry mig.Geometry, gpuCount int) int64 {
+	perGPU := 0
+	for _, count := range geometry.Profiles {
+		perGPU += count
+	}
+
+	return int64(perGPU * gpuCount)
+}
+
+// awaitAllocatable waits until the node advertises exactly the expected count of nvidia.com/gpu, the count of the
+// previous geometry being still advertised right after a reconfiguration.
+func awaitAllocatable(nodeName string, expected int64) error {
+	return await.Match(context.TODO(), fmt.Sprintf("node %s to advertise %d %s", nodeName, expected,
+		mig.GPUResourceName), allocatablePollInterval, allocatableTimeout, func(context.Context) (interface{}, error) {
+		pulledNode, err := nodes.Pull(inittools.APIClient, nodeName)
+		if err != nil {
+			return nil, err
+		}
+
+		quantity := pulledNode.Object.Status.Allocatable[mig.GPUResourceName]
+
+		return quantity.Value(), nil
+	}, Equal(expected))
+}
+
+// runningPods returns the number of running pods of the test namespace matching the label selector on the node.
+func runningPods(labelSelector, nodeName string) (int, error) {
+	selectedPods, err := pod.List(inittools.APIClient, TestNamespace, metav1.ListOptions{
+		LabelSelector: labelSelector,
+		FieldSelector: "spec.nodeName=" + nodeName,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	running := 0
+
+	for _, selectedPod := range selectedPods {
+		if selectedPod.Object.Status.Phase == corev1.PodRunning {
+			running++
+		}
+	}
+
+	return running, nil
+}
+
+func sanitize(profile string) string {
+	return strings.ReplaceAll(profile, ".", "-")
+}
@@ -0,0 +1,197 @@
+package mpi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpudirect"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/mpioperator"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/nccl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the MPIJobs run
+	TestNamespace = "test-mpi"
+	// NCCLJobName is the name of the MPIJob running all_reduce_perf across 2 GPU nodes
+	NCCLJobName = "nccl-mpijob"
+	// WorkerCount is the number of nodes the MPIJob runs on
+	WorkerCount = 2
+
+	operatorTimeout    = 5 * time.Minute
+	jobCompleteTimeout = 30 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("MPI", Ordered, Label(tsparams.LabelSuite, tsparams.LabelMultiNode, "mpi"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.DisruptsCluster, cilabels.MultiNode), func() {
+	var (
+		nodeSelector      labels.Set
+		gpusPerWorker     int
+		nsBuilder         *namespace.Builder
+		operatorInstalled bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting MPI test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.NCCLTestsImage == "" {
+			Skip("NVIDIAGPU_NCCL_TESTS_IMAGE must be set to run the MPI tests")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the NCCL tests image is reachable")
+		Expect(disconnected.CheckImages(nvidiaGPUConfig.NCCLTestsImage)).ToNot(HaveOccurred(),
+			"the NCCL tests image is not reachable through the mirrors")
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) < WorkerCount {
+			Skip(fmt.Sprintf("At least %d GPU nodes are required for the MPI tests", WorkerCount))
+		}
+
+		// Every worker runs as many ranks as the GPUs of the smallest node, wherever it is scheduled.
+		gpusPerWorker = get.GPUCount(gpuNodes[0])
+		for _, node := range gpuNodes[1:] {
+			gpusPerWorker = min(gpusPerWorker, get.GPUCount(node))
+		}
+
+		if gpusPerWorker == 0 {
+			Skip("A GPU node does not report its GPU count")
+		}
+
+		By(fmt.Sprintf("Create the privileged namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.PrivilegedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+
+		// The workers run sshd as root and need to be allowed to run privileged pods.
+		err = gpudirect.CreateRDMAServiceAccount(inittools.APIClient, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error creating the privileged service account: %v", err)
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if operatorInstalled {
+			if err := mpioperator.UninstallOperator(nvidiaGPUConfig.MPIOperatorVersion, operatorTimeout); err != nil {
+				glog.Errorf("Error uninstalling the MPI operator: %v", err)
+			}
+		}
+	})
+
+	It("Should install the MPI operator", Label("mpi-operator-install"), func() {
+		if mpioperator.OperatorInstalled(inittools.APIClient) {
+			glog.V(gpuparams.GpuLogLevel).Infof("The MPI operator is already installed in namespace %s",
+				mpioperator.OperatorNamespace)
+
+			return
+		}
+
+		By(fmt.Sprintf("Install MPI operator version %s", nvidiaGPUConfig.MPIOperatorVersion))
+		err := mpioperator.InstallOperator(inittools.APIClient, nvidiaGPUConfig.MPIOperatorVersion, operatorTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error installing the MPI operator: %v", err)
+
+		operatorInstalled = true
+	})
+
+	It("Should all-reduce across 2 GPU nodes with an MPIJob", Label("mpi-nccl-multi-node"), func() {
+		if !mpioperator.OperatorInstalled(inittools.APIClient) {
+			Skip("The MPI operator is not installed")
+		}
+
+		ranks := WorkerCount * gpusPerWorker
+
+		By(fmt.Sprintf("Run MPIJob %s with %d ranks across %d GPU nodes", NCCLJobName, ranks, WorkerCount))
+		_, err := mpioperator.CreateNCCLJob(inittools.APIClient, mpioperator.NCCLJobConfig{
+			Name:               NCCLJobName,
+			Namespace:          TestNamespace,
+			Image:              disconnected.Image(nvidiaGPUConfig.NCCLTestsImage),
+			Workers:            WorkerCount,
+			GPUsPerWorker:      gpusPerWorker,
+			NodeSelector:       nodeSelector,
+			ServiceAccountName: gpudirect.RDMAServiceAccount,
+			TestArgs:           []string{"-b", "8", "-e", "1G", "-f", "2"},
+		})
+		Expect(err).ToNot(HaveOccurred(), "error creating MPIJob %s: %v", NCCLJobName, err)
+
+		DeferCleanup(func() {
+			if err := mpioperator.DeleteJob(inittools.APIClient, NCCLJobName, TestNamespace); err != nil {
+				glog.Errorf("Error deleting MPIJob %s: %v", NCCLJobName, err)
+			}
+		})
+
+		waitErr := mpioperator.WaitForJobSucceeded(inittools.APIClient, NCCLJobName, TestNamespace,
+			jobCompleteTimeout)
+
+		output, err := mpioperator.GetLauncherLog(inittools.APIClient, NCCLJobName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error getting MPIJob %s launcher log: %v", NCCLJobName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("MPIJob %s launcher log:\n%s", NCCLJobName, output)
+
+		Expect(waitErr).ToNot(HaveOccurred(), "MPIJob %s did not succeed: %v", NCCLJobName, waitErr)
+
+		By("Check the ranks ran on distinct nodes")
+		rankHosts := nccl.ParseRankHosts(output)
+		Expect(rankHosts).To(HaveLen(ranks), "all_reduce_perf did not report its %d ranks", ranks)
+
+		hosts := map[string]bool{}
+		for _, host := range rankHosts {
+			hosts[host] = true
+		}
+
+		Expect(hosts).To(HaveLen(WorkerCount), "the ranks did not run on %d workers: %v", WorkerCount, rankHosts)
+
+		busBandwidth, err := nccl.ParseBusBandwidth(output)
+		Expect(err).ToNot(HaveOccurred(), "error parsing the all_reduce_perf output: %v", err)
+
+		glog.V(gpuparams.GpuLogLevel).Infof("all_reduce_perf average bus bandwidth across %d nodes: %.2f GB/s",
+			WorkerCount, busBandwidth)
+		reporter.RecordMetric("mpi-nccl-bus-bandwidth-gbps", busBandwidth)
+		Expect(busBandwidth).To(BeNumerically(">=", nvidiaGPUConfig.NCCLMinBusBandwidth),
+			"average bus bandwidth across %d nodes is below %.2f GB/s", WorkerCount,
+			nvidiaGPUConfig.NCCLMinBusBandwidth)
+	})
+})
@@ -9,23 +9,19 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
 )
 
 var _, currentFile, _, _ = runtime.Caller(0)
 
 func TestMPS(t *testing.T) {
-	_, reporterConfig := GinkgoConfiguration()
-	reporterConfig.JUnitReport = inittools.GeneralConfig.GetJunitReportPath(currentFile)
+	suiteConfig, reporterConfig := GinkgoConfiguration()
+	reporter.ApplyFlakeAttempts(&suiteConfig)
 
 	RegisterFailHandler(Fail)
-	RunSpecs(t, "MPS", Label("nvidia-ci", "mps"), reporterConfig)
+	RunSpecs(t, "MPS", Label("nvidia-ci", "mps"), suiteConfig, reporterConfig)
 }
 
-var _ = JustAfterEach(func() {
-	specReport := CurrentSpecReport()
-	reporter.ReportIfFailed(
-		specReport, currentFile, tsparams.MpsReporterNamespacesToDump, tsparams.MpsReporterCRDsToDump, clients.SetScheme)
-
-})
+var _ = reporter.RegisterSuiteHooks(
+	currentFile, tsparams.MpsReporterNamespacesToDump, tsparams.MpsReporterCRDsToDump, clients.SetScheme,
+	reporter.GPUOperatorMustGather)
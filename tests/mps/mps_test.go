@@ -5,21 +5,32 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
 	"github.com/golang/glog"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gfd"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/mig"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/mps"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/timeslicing"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
 
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
 
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
@@ -46,18 +57,40 @@ const (
 	// Number of worker pods to create
 	NumWorkerPods = 20
 	// TestDuration is how long each pod should run
-	TestDuration = "25m"
+	TestDuration = 25 * time.Minute
 	// GPUOperatorNamespace is the namespace where the NVIDIA GPU operator is installed
 	GPUOperatorNamespace = "nvidia-gpu-operator"
 	LargeMPSReplicas     = 49
-	TimeStep             = "30s"
+	TimeStep             = 30 * time.Second
+	// MPSLimitReplicas is the number of GPU replicas for the MPS client limits tests, each client gets half of a GPU
+	MPSLimitReplicas = 2
+	// ClientMeasureDuration is how long each MPS client measures its matmul throughput
+	ClientMeasureDuration = time.Minute
+	// ClientStartDelay leaves the concurrent MPS clients time to get scheduled before they start measuring together
+	ClientStartDelay = 2 * time.Minute
+	// MinConcurrentThroughputRatio is the minimum throughput of a client running next to other clients, relative to
+	// its throughput when running alone, expected when the active thread percentage partitions the GPU
+	MinConcurrentThroughputRatio = 0.7
+	// DefaultDevicePluginConfigName is the device plugin config the ClusterPolicy created from the CSV applies by default
+	DefaultDevicePluginConfigName = "plugin-config.yaml"
+	// TimeSlicingConfigName is the device plugin config selected with the device-plugin.config node label
+	TimeSlicingConfigName = "time-slicing"
+	// SharingMPSReplicas is the number of MPS replicas per GPU of the sharing interaction tests
+	SharingMPSReplicas = 4
+	// SharingTimeSlicingReplicas is the number of time-slicing replicas per GPU of the sharing interaction tests
+	SharingTimeSlicingReplicas = 3
+	// SharingTimeout is how long the device plugin and GFD take to apply a new sharing config on a node
+	SharingTimeout = 15 * time.Minute
+	// SharingPollInterval is the polling interval of the node labels and allocatable GPUs
+	SharingPollInterval = 30 * time.Second
 )
 
 var (
 	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
 )
 
-var _ = Describe("MPS", Ordered, Label(tsparams.LabelSuite), func() {
+var _ = Describe("MPS", Ordered, Label(tsparams.LabelSuite), cilabels.Spec(
+	cilabels.Regression, cilabels.Long, cilabels.DisruptsOperands), func() {
 	var (
 		nsBuilder     *namespace.Builder
 		configMap     *configmap.Builder
@@ -69,6 +102,10 @@ var _ = Describe("MPS", Ordered, Label(tsparams.LabelSuite), func() {
 		// Set log level
 		glog.V(gpuparams.GpuLogLevel).Info("Starting MPS test suite")
 
+		By("Check the MPS image is reachable")
+		Expect(disconnected.CheckImages(MPSImage)).ToNot(HaveOccurred(),
+			"the MPS image is not reachable through the mirrors")
+
 		if tmpClusterPolicyBulider, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err == nil {
 			if _, err := tmpClusterPolicyBulider.Get(); err == nil {
 
@@ -133,36 +170,37 @@ var _ = Describe("MPS", Ordered, Label(tsparams.LabelSuite), func() {
 			Expect(err).ToNot(HaveOccurred(), "error creating device plugin ConfigMap: %v", err)
 			clusterPolicy, err = mps.CreateClusterPolicyFromCSV(inittools.APIClient, GPUOperatorNamespace, nvidiagpu.ClusterPolicyName)
 			Expect(err).ToNot(HaveOccurred(), "error updating cluster policy: %v", err)
-			Eventually(func() bool {
-
-				mpsDaemon, err := inittools.APIClient.Pods(GPUOperatorNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "app=nvidia-device-plugin-mps-control-daemon"})
-
-				if err != nil {
-					glog.Errorf("Error listing NVIDIA mps pods: %v", err)
-					return false
-				}
+			err = await.Match(context.TODO(), "the MPS control daemon to fail",
+				TimeStep, TestDuration, func(context.Context) (interface{}, error) {
+					mpsDaemon, err := inittools.APIClient.Pods(GPUOperatorNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "app=nvidia-device-plugin-mps-control-daemon"})
 
-				if len(mpsDaemon.Items) == 0 {
-					glog.Errorf("No NVIDIA driver pods found in namespace %s", GPUOperatorNamespace)
-					return false
-				}
+					if err != nil {
+						glog.Errorf("Error listing NVIDIA mps pods: %v", err)
+						return false, nil
+					}
 
-				for _, pod := range mpsDaemon.Items {
+					if len(mpsDaemon.Items) == 0 {
+						glog.Errorf("No NVIDIA driver pods found in namespace %s", GPUOperatorNamespace)
+						return false, nil
+					}
 
-					for _, containerStatus := range pod.Status.ContainerStatuses {
-						glog.V(gpuparams.GpuLogLevel).Infof("container %s waiting %v", containerStatus.Name, containerStatus.State.Waiting)
-						if strings.Contains(containerStatus.Name, "mps-control-daemon-ctr") {
+					for _, pod := range mpsDaemon.Items {
 
+						for _, containerStatus := range pod.Status.ContainerStatuses {
 							glog.V(gpuparams.GpuLogLevel).Infof("container %s waiting %v", containerStatus.Name, containerStatus.State.Waiting)
-							if containerStatus.State.Waiting != nil {
-								return true
+							if strings.Contains(containerStatus.Name, "mps-control-daemon-ctr") {
+
+								glog.V(gpuparams.GpuLogLevel).Infof("container %s waiting %v", containerStatus.Name, containerStatus.State.Waiting)
+								if containerStatus.State.Waiting != nil {
+									return true, nil
 
+								}
 							}
 						}
 					}
-				}
-				return false
-			}, TestDuration, TimeStep).Should(BeTrue(), "MPS daemon failed as expected")
+					return false, nil
+				}, BeTrue())
+			Expect(err).ToNot(HaveOccurred(), "MPS daemon failed as expected: %v", err)
 
 			mpsDaemons, err := pod.List(inittools.APIClient, GPUOperatorNamespace, metav1.ListOptions{LabelSelector: "app=nvidia-device-plugin-mps-control-daemon"})
 			Expect(err).ToNot(HaveOccurred(), "Failed locate MPS daemon %v", err)
@@ -249,12 +287,13 @@ var _ = Describe("MPS", Ordered, Label(tsparams.LabelSuite), func() {
 					inittools.APIClient,
 					workerPodName,
 					TestNamespace,
-					MPSImage,
+					disconnected.Image(MPSImage),
 				)
 				Expect(err).ToNot(HaveOccurred(), "error creating MPS worker pod %s: %v", workerPodName, err)
 				Expect(workerPod).ToNot(BeNil())
 
 				// Create pod in cluster
+				proxy.Inject(inittools.APIClient, &workerPod.Spec)
 				_, err = inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), workerPod, metav1.CreateOptions{})
 				Expect(err).ToNot(HaveOccurred(), "error creating worker pod %s in cluster: %v", workerPodName, err)
 
@@ -277,27 +316,29 @@ var _ = Describe("MPS", Ordered, Label(tsparams.LabelSuite), func() {
 			glog.V(gpuparams.GpuLogLevel).Infof("Waiting for worker pods to run for 2 minutes...")
 			time.Sleep(2 * time.Minute)
 			// Verify at least one worker pod is still running
-			Eventually(func() error {
-				pods, err := inittools.APIClient.Pods(TestNamespace).List(context.TODO(), metav1.ListOptions{
-					LabelSelector: "app=mps-test-app",
-				})
-				if err != nil {
-					return err
-				}
+			err = await.Match(context.TODO(), "a running MPS worker pod",
+				TimeStep, TestDuration, func(context.Context) (interface{}, error) {
+					pods, err := inittools.APIClient.Pods(TestNamespace).List(context.TODO(), metav1.ListOptions{
+						LabelSelector: "app=mps-test-app",
+					})
+					if err != nil {
+						return nil, err
+					}
 
-				runningPods := 0
-				for _, p := range pods.Items {
-					if p.Status.Phase == corev1.PodRunning {
-						runningPods++
+					runningPods := 0
+					for _, p := range pods.Items {
+						if p.Status.Phase == corev1.PodRunning {
+							runningPods++
+						}
 					}
-				}
 
-				if runningPods < 1 {
-					return fmt.Errorf("expected at least 1 running pod, got %d", runningPods)
-				}
+					if runningPods < 1 {
+						return nil, fmt.Errorf("expected at least 1 running pod, got %d", runningPods)
+					}
 
-				return nil
-			}, TestDuration, TimeStep).Should(Succeed(), "Not enough worker pods are running")
+					return nil, nil
+				}, Succeed())
+			Expect(err).ToNot(HaveOccurred(), "Not enough worker pods are running: %v", err)
 
 			// Get NVIDIA driver pods from the GPU operator namespace
 			driverPods, err := inittools.APIClient.Pods(GPUOperatorNamespace).List(context.TODO(), metav1.ListOptions{
@@ -362,6 +403,179 @@ var _ = Describe("MPS", Ordered, Label(tsparams.LabelSuite), func() {
 
 		})
 	})
+
+	Context("MPS per-client limits", Label("mps-client-limits"), func() {
+		var (
+			gpuNodeSelector map[string]string
+			gpuMemoryMiB    int64
+		)
+
+		BeforeEach(func() {
+			var err error
+			configMap, err = mps.CreateDevicePluginConfigMap(
+				inittools.APIClient,
+				MPSLimitReplicas,
+				DevicePluginConfigMapName,
+				GPUOperatorNamespace,
+				false)
+			Expect(err).ToNot(HaveOccurred(), "error creating device plugin ConfigMap: %v", err)
+			clusterPolicy, err = mps.CreateClusterPolicyFromCSV(inittools.APIClient, GPUOperatorNamespace, nvidiagpu.ClusterPolicyName)
+			Expect(err).ToNot(HaveOccurred(), "error creating cluster policy: %v", err)
+			EnsureAllGpuPodsAreRunning()
+
+			clientCM, err := mps.CreateClientPodConfigMap(inittools.APIClient, TestNamespace)
+			Expect(err).ToNot(HaveOccurred(), "error creating MPS client ConfigMap: %v", err)
+			DeferCleanup(func() {
+				if err := clientCM.Delete(); err != nil {
+					glog.Errorf("Error deleting MPS client ConfigMap: %v", err)
+				}
+			})
+
+			// Run all the clients of a test on the same node, so that they pull the image once and share its GPUs.
+			gpuNode := firstGPUNode()
+			gpuMemoryMiB, err = strconv.ParseInt(gpuNode.Object.Labels[gfd.MemoryLabel], 10, 64)
+			Expect(err).ToNot(HaveOccurred(), "node %s has no valid %s label: %v", gpuNode.Object.Name,
+				gfd.MemoryLabel, err)
+			gpuNodeSelector = map[string]string{corev1.LabelHostname: gpuNode.Object.Labels[corev1.LabelHostname]}
+		})
+
+		It("Should enforce the per-client pinned device memory limit", Label("mps"), func() {
+			memoryLimitMiB, _ := mps.ClientLimits(gpuMemoryMiB, MPSLimitReplicas)
+			glog.V(gpuparams.GpuLogLevel).Infof("Expecting a %d MiB pinned device memory limit per MPS client",
+				memoryLimitMiB)
+
+			By("Allocate device memory below and above the per-client limit")
+			results := runMPSClients(gpuNodeSelector, map[string][]corev1.EnvVar{
+				"mps-client-under-limit": {{Name: mps.ClientAllocEnv, Value: strconv.FormatInt(memoryLimitMiB/2, 10)}},
+				"mps-client-over-limit":  {{Name: mps.ClientAllocEnv, Value: strconv.FormatInt(memoryLimitMiB*11/10, 10)}},
+			})
+
+			Expect(results["mps-client-under-limit"].AllocationResult).To(Equal("ok"),
+				"MPS client failed to allocate %d MiB below the %d MiB limit", memoryLimitMiB/2, memoryLimitMiB)
+			Expect(results["mps-client-over-limit"].AllocationResult).To(Equal("oom"),
+				"MPS client allocated %d MiB above the %d MiB limit", memoryLimitMiB*11/10, memoryLimitMiB)
+		})
+
+		It("Should split the GPU compute between concurrent clients by active thread percentage", Label("mps"), func() {
+			_, threadPercentage := mps.ClientLimits(gpuMemoryMiB, MPSLimitReplicas)
+			glog.V(gpuparams.GpuLogLevel).Infof("Expecting a %d%% active thread percentage per MPS client",
+				threadPercentage)
+
+			measureEnv := func(startAt time.Time) []corev1.EnvVar {
+				return []corev1.EnvVar{
+					{Name: mps.ClientStartAtEnv, Value: strconv.FormatInt(startAt.Unix(), 10)},
+					{Name: mps.ClientDurationEnv, Value: strconv.Itoa(int(ClientMeasureDuration.Seconds()))},
+				}
+			}
+
+			By("Measure the throughput of a single MPS client")
+			alone := runMPSClients(gpuNodeSelector, map[string][]corev1.EnvVar{
+				"mps-client-alone": measureEnv(time.Time{}),
+			})["mps-client-alone"]
+
+			By(fmt.Sprintf("Measure the throughput of %d concurrent MPS clients", MPSLimitReplicas))
+			startAt := time.Now().Add(ClientStartDelay)
+			clientsEnv := map[string][]corev1.EnvVar{}
+			for i := 0; i < MPSLimitReplicas; i++ {
+				clientsEnv[fmt.Sprintf("mps-client-%d", i)] = measureEnv(startAt)
+			}
+
+			results := runMPSClients(gpuNodeSelector, clientsEnv)
+
+			for name, result := range results {
+				glog.V(gpuparams.GpuLogLevel).Infof("MPS client %s: %d SMs, %.2f TFLOPS, alone %d SMs, %.2f TFLOPS",
+					name, result.SMCount, result.ThroughputTFLOPS, alone.SMCount, alone.ThroughputTFLOPS)
+				Expect(result.SMCount).To(Equal(alone.SMCount), "MPS client %s sees a different SM count", name)
+				// Without the active thread percentage, the single client would use the whole GPU and the
+				// concurrent clients would each get a fraction of its throughput.
+				Expect(result.ThroughputTFLOPS).To(BeNumerically(">=", alone.ThroughputTFLOPS*MinConcurrentThroughputRatio),
+					"MPS client %s dropped to %.2f TFLOPS next to the other clients, %.2f TFLOPS alone",
+					name, result.ThroughputTFLOPS, alone.ThroughputTFLOPS)
+			}
+		})
+	})
+
+	Context("MPS interactions with time-slicing and MIG", Label("mps-interactions"), func() {
+		createSharingClusterPolicy := func(replicasByConfig map[string]mps.SharingReplicas) {
+			var err error
+			configMap, err = mps.CreateSharingDevicePluginConfigMap(
+				inittools.APIClient,
+				DevicePluginConfigMapName,
+				GPUOperatorNamespace,
+				replicasByConfig)
+			Expect(err).ToNot(HaveOccurred(), "error creating device plugin ConfigMap: %v", err)
+			clusterPolicy, err = mps.CreateClusterPolicyFromCSV(inittools.APIClient, GPUOperatorNamespace, nvidiagpu.ClusterPolicyName)
+			Expect(err).ToNot(HaveOccurred(), "error creating cluster policy: %v", err)
+			EnsureAllGpuPodsAreRunning()
+		}
+
+		It("Should share the GPUs with MPS when MPS and time-slicing replicas are both configured", Label("mps"), func() {
+			createSharingClusterPolicy(map[string]mps.SharingReplicas{
+				DefaultDevicePluginConfigName: {MPS: SharingMPSReplicas, TimeSlicing: SharingTimeSlicingReplicas},
+			})
+
+			// The device plugin applies a single sharing strategy per GPU, MPS taking precedence over time-slicing.
+			gpuNode := firstGPUNode()
+			waitForSharing(gpuNode, mps.SharingStrategyMPS, SharingMPSReplicas)
+		})
+
+		It("Should switch a node between MPS and time-slicing with the device-plugin.config label", Label("mps"), func() {
+			createSharingClusterPolicy(map[string]mps.SharingReplicas{
+				DefaultDevicePluginConfigName: {MPS: SharingMPSReplicas},
+				TimeSlicingConfigName:         {TimeSlicing: SharingTimeSlicingReplicas},
+			})
+
+			gpuNode := firstGPUNode()
+			waitForSharing(gpuNode, mps.SharingStrategyMPS, SharingMPSReplicas)
+
+			By(fmt.Sprintf("Label node %s with %s=%s", gpuNode.Object.Name, timeslicing.DevicePluginConfigLabel,
+				TimeSlicingConfigName))
+			err := timeslicing.LabelNodeDevicePluginConfig(inittools.APIClient, gpuNode.Object.Name, TimeSlicingConfigName)
+			Expect(err).ToNot(HaveOccurred(), "error labeling node %s: %v", gpuNode.Object.Name, err)
+			DeferCleanup(func() {
+				if err := timeslicing.LabelNodeDevicePluginConfig(inittools.APIClient, gpuNode.Object.Name, ""); err != nil {
+					glog.Errorf("Error removing %s label from node %s: %v", timeslicing.DevicePluginConfigLabel,
+						gpuNode.Object.Name, err)
+				}
+			})
+
+			waitForSharing(gpuNode, mps.SharingStrategyTimeSlicing, SharingTimeSlicingReplicas)
+
+			By("Remove the node label and verify MPS is applied again")
+			err = timeslicing.LabelNodeDevicePluginConfig(inittools.APIClient, gpuNode.Object.Name, "")
+			Expect(err).ToNot(HaveOccurred(), "error removing label from node %s: %v", gpuNode.Object.Name, err)
+
+			waitForSharing(gpuNode, mps.SharingStrategyMPS, SharingMPSReplicas)
+		})
+
+		It("Should reject MPS with the mixed MIG strategy", Label("mps", cilabels.MIG), func() {
+			createSharingClusterPolicy(map[string]mps.SharingReplicas{
+				DefaultDevicePluginConfigName: {MPS: SharingMPSReplicas},
+			})
+
+			migNodes, err := mig.ListMIGCapableNodes(inittools.APIClient, inittools.GeneralConfig.WorkerLabelMap)
+			Expect(err).ToNot(HaveOccurred(), "error listing MIG capable nodes: %v", err)
+
+			if len(migNodes) == 0 {
+				Skip("No MIG capable GPU node found")
+			}
+
+			By(fmt.Sprintf("Set the MIG strategy to %s", nvidiagpuv1.MIGStrategyMixed))
+			_, err = mig.SetClusterPolicyStrategy(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				nvidiagpuv1.MIGStrategyMixed)
+			Expect(err).ToNot(HaveOccurred(), "error setting the MIG strategy: %v", err)
+
+			By("Verify the device plugin rejects MPS sharing of MIG devices")
+			err = await.Match(context.TODO(),
+				"the device plugin to report that MPS is not supported with the mixed MIG strategy",
+				TimeStep, TestDuration, func(context.Context) (interface{}, error) {
+					return mps.FindContainerLogLine(inittools.APIClient, GPUOperatorNamespace, mps.DevicePluginPodLabel,
+						mps.DevicePluginContainerName, "mig", "mps", "not supported")
+				}, Not(BeEmpty()))
+			Expect(err).ToNot(HaveOccurred(),
+				"device plugin did not report that MPS is not supported with the mixed MIG strategy: %v", err)
+		})
+	})
 })
 
 // executeCommandInPod executes a command in a pod and returns the output
@@ -494,30 +708,32 @@ func parseNvidiaSmiOutput(output, podName string) {
 
 func EnsureAllGpuPodsAreRunning() {
 
-	Eventually(func() bool {
-		driverPods, err := inittools.APIClient.Pods(GPUOperatorNamespace).List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			glog.Errorf("Error listing NVIDIA driver pods: %v", err)
-			return false
-		}
+	err := await.Match(context.TODO(), "the NVIDIA driver pods to be ready",
+		TimeStep, TestDuration, func(context.Context) (interface{}, error) {
+			driverPods, err := inittools.APIClient.Pods(GPUOperatorNamespace).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				glog.Errorf("Error listing NVIDIA driver pods: %v", err)
+				return false, nil
+			}
 
-		if len(driverPods.Items) < 8 {
-			glog.Errorf("Not all NVIDIA driver pods found in namespace %s", GPUOperatorNamespace)
-			return false
-		}
+			if len(driverPods.Items) < 8 {
+				glog.Errorf("Not all NVIDIA driver pods found in namespace %s", GPUOperatorNamespace)
+				return false, nil
+			}
 
-		for _, pod := range driverPods.Items {
-			glog.V(gpuparams.GpuLogLevel).Infof("Pod %s is %s ", pod.Name, pod.Status.Phase)
-			// Check container ready status
-			for _, containerStatus := range pod.Status.ContainerStatuses {
-				if !containerStatus.Ready && containerStatus.State.Terminated == nil {
-					return false
-				}
+			for _, pod := range driverPods.Items {
+				glog.V(gpuparams.GpuLogLevel).Infof("Pod %s is %s ", pod.Name, pod.Status.Phase)
+				// Check container ready status
+				for _, containerStatus := range pod.Status.ContainerStatuses {
+					if !containerStatus.Ready && containerStatus.State.Terminated == nil {
+						return false, nil
+					}
 
+				}
 			}
-		}
-		return true
-	}, TestDuration, TimeStep).Should(BeTrue(), "NVIDIA driver pods did not become ready")
+			return true, nil
+		}, BeTrue())
+	Expect(err).ToNot(HaveOccurred(), "NVIDIA driver pods did not become ready: %v", err)
 
 	driverPods, err := inittools.APIClient.Pods(GPUOperatorNamespace).List(context.TODO(), metav1.ListOptions{})
 	Expect(err).ToNot(HaveOccurred(), "error listing NVIDIA driver pods: %v", err)
@@ -531,28 +747,113 @@ func EnsureAllGpuPodsAreRunning() {
 
 func EnsureOnlyOperatorIsRunning() {
 
-	Eventually(func() bool {
-		driverPods, err := inittools.APIClient.Pods(GPUOperatorNamespace).List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			glog.Errorf("Error listing NVIDIA driver pods: %v", err)
-			return false
-		}
+	err := await.Match(context.TODO(), "the NVIDIA driver pods to be cleaned up",
+		TimeStep, TestDuration, func(context.Context) (interface{}, error) {
+			driverPods, err := inittools.APIClient.Pods(GPUOperatorNamespace).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				glog.Errorf("Error listing NVIDIA driver pods: %v", err)
+				return false, nil
+			}
 
-		if len(driverPods.Items) > 1 || len(driverPods.Items) == 0 {
-			glog.Errorf("Not all NVIDIA driver pods deleted in namespace %s or namespace is empty", GPUOperatorNamespace)
-			return false
-		}
+			if len(driverPods.Items) > 1 || len(driverPods.Items) == 0 {
+				glog.Errorf("Not all NVIDIA driver pods deleted in namespace %s or namespace is empty", GPUOperatorNamespace)
+				return false, nil
+			}
 
-		for _, pod := range driverPods.Items {
-			glog.V(gpuparams.GpuLogLevel).Infof("Pod %s is %s ", pod.Name, pod.Status.Phase)
-			// Check container ready status
-			for _, containerStatus := range pod.Status.ContainerStatuses {
-				if !containerStatus.Ready && containerStatus.State.Terminated == nil {
-					return false
+			for _, pod := range driverPods.Items {
+				glog.V(gpuparams.GpuLogLevel).Infof("Pod %s is %s ", pod.Name, pod.Status.Phase)
+				// Check container ready status
+				for _, containerStatus := range pod.Status.ContainerStatuses {
+					if !containerStatus.Ready && containerStatus.State.Terminated == nil {
+						return false, nil
+					}
 				}
 			}
-		}
-		return true
-	}, TestDuration, TimeStep).Should(BeTrue(), "NVIDIA driver pods did not become ready")
+			return true, nil
+		}, BeTrue())
+	Expect(err).ToNot(HaveOccurred(), "NVIDIA driver pods did not become ready: %v", err)
+
+}
+
+// runMPSClients runs an MPS client pod per entry of clientsEnv, with its environment variables, on the nodes
+// matching nodeSelector, waits for all of them to succeed and returns their results by pod name.
+func runMPSClients(nodeSelector map[string]string, clientsEnv map[string][]corev1.EnvVar) map[string]*mps.ClientResult {
+	for podName, env := range clientsEnv {
+		clientPod := mps.CreateMPSClientPod(podName, TestNamespace, disconnected.Image(MPSImage), nodeSelector, env)
+		proxy.Inject(inittools.APIClient, &clientPod.Spec)
+		_, err := inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), clientPod, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred(), "error creating MPS client pod %s: %v", podName, err)
+
+		DeferCleanup(func() {
+			podBuilder, err := pod.Pull(inittools.APIClient, podName, TestNamespace)
+			if err != nil {
+				glog.Errorf("Error pulling pod %s: %v", podName, err)
+			} else if _, err = podBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting pod %s: %v", podName, err)
+			}
+		})
+	}
+
+	results := map[string]*mps.ClientResult{}
+
+	for podName := range clientsEnv {
+		var podBuilder *pod.Builder
+
+		err := await.Match(context.TODO(), fmt.Sprintf("MPS client pod %s to complete", podName),
+			TimeStep, TestDuration, func(context.Context) (interface{}, error) {
+				var err error
+				podBuilder, err = pod.Pull(inittools.APIClient, podName, TestNamespace)
+				if err != nil {
+					return "", err
+				}
+
+				return podBuilder.Object.Status.Phase, nil
+			}, BeElementOf(corev1.PodSucceeded, corev1.PodFailed))
+		Expect(err).ToNot(HaveOccurred(), "MPS client pod %s did not complete: %v", podName, err)
+
+		output, err := podBuilder.GetFullLog(mps.ClientContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error getting pod %s log: %v", podName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("MPS client pod %s log:\n%s", podName, output)
+		Expect(podBuilder.Object.Status.Phase).To(Equal(corev1.PodSucceeded), "MPS client pod %s failed", podName)
+
+		results[podName], err = mps.ParseClientOutput(output)
+		Expect(err).ToNot(HaveOccurred(), "error parsing pod %s log: %v", podName, err)
+	}
+
+	return results
+}
+
+// firstGPUNode returns the first GPU node of the cluster.
+func firstGPUNode() *nodes.Builder {
+	gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: "nvidia.com/gpu.present=true"})
+	Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+	Expect(gpuNodes).ToNot(BeEmpty(), "no GPU node found")
+
+	return gpuNodes[0]
+}
+
+// waitForSharing waits until GFD reports the sharing strategy and replicas on the node and the node advertises as
+// many GPUs as replicas of its GPUs.
+func waitForSharing(gpuNode *nodes.Builder, strategy string, replicas int) {
+	By(fmt.Sprintf("Wait for node %s to share its GPUs with %s in %d replicas", gpuNode.Object.Name, strategy,
+		replicas))
+	err := await.Match(context.TODO(),
+		fmt.Sprintf("GFD to report %s sharing with %d replicas on node %s", strategy, replicas, gpuNode.Object.Name),
+		SharingPollInterval, SharingTimeout, func(context.Context) (interface{}, error) {
+			nodeBuilder, err := nodes.Pull(inittools.APIClient, gpuNode.Object.Name)
+			if err != nil {
+				return nil, err
+			}
 
+			return nodeBuilder.Object.Labels, nil
+		}, And(HaveKeyWithValue(mps.SharingStrategyLabel, strategy),
+			HaveKeyWithValue(timeslicing.GPUReplicasLabel, strconv.Itoa(replicas))))
+	Expect(err).ToNot(HaveOccurred(), "GFD did not report %s sharing with %d replicas on node %s: %v", strategy,
+		replicas, gpuNode.Object.Name, err)
+
+	expected := int64(get.GPUCount(gpuNode) * replicas)
+	err = wait.NodeAllocatable(inittools.APIClient, gpuNode.Object.Name, timeslicing.GPUResourceName, expected,
+		SharingPollInterval, SharingTimeout)
+	Expect(err).ToNot(HaveOccurred(), "node %s did not advertise %d %s: %v", gpuNode.Object.Name, expected,
+		timeslicing.GPUResourceName, err)
 }
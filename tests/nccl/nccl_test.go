@@ -0,0 +1,170 @@
+package nccl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpudirect"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nccl"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	ncclworkload "github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/nccl"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the nccl-tests Jobs run
+	TestNamespace = "test-nccl"
+	// SingleNodeJobName is the name of the nccl-tests Job running across the GPUs of a single node
+	SingleNodeJobName = "nccl-single-node"
+	// MultiNodeJobName is the name of the nccl-tests Job running across the GPU nodes
+	MultiNodeJobName = "nccl-multi-node"
+
+	jobCompleteTimeout = 20 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("NCCL", Ordered, Label(tsparams.LabelSuite, "nccl"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.NonDisruptive), func() {
+	var (
+		gpuNodes     []*nodes.Builder
+		nodeSelector labels.Set
+		nsBuilder    *namespace.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting NCCL test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.NCCLTestsImage == "" {
+			Skip("NVIDIAGPU_NCCL_TESTS_IMAGE must be set to run the NCCL tests")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the NCCL tests image is reachable")
+		Expect(disconnected.CheckImages(nvidiaGPUConfig.NCCLTestsImage)).ToNot(HaveOccurred(),
+			"the NCCL tests image is not reachable through the mirrors")
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		var err error
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By(fmt.Sprintf("Create the privileged namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.PrivilegedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+
+		// The multi-node ranks run on the host network and need to be allowed to run privileged pods.
+		err = gpudirect.CreateRDMAServiceAccount(inittools.APIClient, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error creating the privileged service account: %v", err)
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should all-reduce across the GPUs of a single node", Label("nccl-single-node", cilabels.MultiGPU), func() {
+		var (
+			gpuNode  *nodes.Builder
+			gpuCount int
+		)
+
+		for _, node := range gpuNodes {
+			if count := get.GPUCount(node); count > gpuCount {
+				gpuNode, gpuCount = node, count
+			}
+		}
+
+		if gpuCount < 2 {
+			Skip("No GPU node with at least 2 GPUs found")
+		}
+
+		jobBuilder := ncclworkload.NewBuilder(inittools.APIClient, SingleNodeJobName, TestNamespace,
+			disconnected.Image(nvidiaGPUConfig.NCCLTestsImage)).
+			WithGPUsPerNode(gpuCount).
+			WithNodeSelector(map[string]string{corev1.LabelHostname: gpuNode.Object.Labels[corev1.LabelHostname]})
+
+		By(fmt.Sprintf("Run all_reduce_perf across %d GPUs of node %s", gpuCount, gpuNode.Object.Name))
+		err := nccl.RunAllReduce(jobBuilder, jobCompleteTimeout, nvidiaGPUConfig.NCCLMinBusBandwidth)
+		Expect(err).ToNot(HaveOccurred(), "error running all_reduce_perf: %v", err)
+	})
+
+	It("Should all-reduce across the GPU nodes", Label(tsparams.LabelMultiNode,
+		cilabels.MultiNode, "nccl-multi-node"), func() {
+		if len(gpuNodes) < 2 {
+			Skip("At least 2 GPU nodes are required for the multi-node all-reduce")
+		}
+
+		// Run the same number of ranks on every node, as many as the GPUs of the smallest node.
+		gpusPerNode := get.GPUCount(gpuNodes[0])
+		for _, node := range gpuNodes[1:] {
+			gpusPerNode = min(gpusPerNode, get.GPUCount(node))
+		}
+
+		if gpusPerNode == 0 {
+			Skip("A GPU node does not report its GPU count")
+		}
+
+		jobBuilder := ncclworkload.NewBuilder(inittools.APIClient, MultiNodeJobName, TestNamespace,
+			disconnected.Image(nvidiaGPUConfig.NCCLTestsImage)).
+			WithNodeCount(len(gpuNodes)).
+			WithGPUsPerNode(gpusPerNode).
+			WithNodeSelector(nodeSelector).
+			WithServiceAccount(gpudirect.RDMAServiceAccount)
+
+		if nvidiaGPUConfig.NCCLRDMAResource != "" {
+			jobBuilder.WithResource(nvidiaGPUConfig.NCCLRDMAResource, 1)
+		}
+
+		if nvidiaGPUConfig.NCCLIBHCA != "" {
+			jobBuilder.WithEnv("NCCL_IB_HCA", nvidiaGPUConfig.NCCLIBHCA)
+		}
+
+		By(fmt.Sprintf("Run all_reduce_perf across %d GPUs of %d nodes", gpusPerNode, len(gpuNodes)))
+		err := nccl.RunAllReduce(jobBuilder, jobCompleteTimeout, nvidiaGPUConfig.NCCLMinBusBandwidth)
+		Expect(err).ToNot(HaveOccurred(), "error running all_reduce_perf: %v", err)
+	})
+})
@@ -0,0 +1,268 @@
+package networkoperator
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	nvidianetworkv1alpha1 "github.com/Mellanox/network-operator/api/v1alpha1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/networkparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidianetworkconfig"
+	rdmatest "github.com/rh-ecosystem-edge/nvidia-ci/internal/rdma"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidianetwork"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	multus "gopkg.in/k8snetworkplumbingwg/multus-cni.v4/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace of the secondary networks and of the pods attached to them
+	TestNamespace = "test-network-operator"
+	// NetworkOperatorNamespace is the namespace where the NVIDIA Network Operator is installed
+	NetworkOperatorNamespace = "nvidia-network-operator"
+	// NicClusterPolicyName is the name of the NicClusterPolicy deployed with the NVIDIA Network Operator
+	NicClusterPolicyName = "nic-cluster-policy"
+	// MacvlanNetworkName is the name of the MacvlanNetwork created by the tests
+	MacvlanNetworkName = "test-macvlan-network"
+	// IPoIBNetworkName is the name of the IPoIBNetwork created by the tests
+	IPoIBNetworkName = "test-ipoib-network"
+	// AttachmentInterface is the interface of the secondary network in the attached pods
+	AttachmentInterface = "net1"
+	// WorkloadImage is the image of the pods attached to the secondary networks
+	WorkloadImage = "registry.access.redhat.com/ubi9/ubi-minimal:latest"
+
+	mellanoxNodeLabel                      = "feature.node.kubernetes.io/pci-15b3.present"
+	ofedDriverPodLabel                     = "nvidia.com/ofed-driver"
+	ofedAppliedStateName                   = "state-OFED"
+	mellanoxEthernetInterfaceNameDefault   = "ens1f0np0"
+	mellanoxInfinibandInterfaceNameDefault = "ibs1f1"
+
+	ofedRolloutTimeout  = 30 * time.Minute
+	networkReadyTimeout = 5 * time.Minute
+	podRunningTimeout   = 5 * time.Minute
+)
+
+var (
+	nvidiaNetworkConfig *nvidianetworkconfig.NvidiaNetworkConfig
+)
+
+var _ = Describe("Network Operator", Ordered, Label(tsparams.NetworkLabelSuite, "network-operator"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.DisruptsOperands, cilabels.RDMA), func() {
+	var (
+		mellanoxNodes []*nodes.Builder
+		nsBuilder     *namespace.Builder
+	)
+	nvidiaNetworkConfig = nvidianetworkconfig.NewNvidiaNetworkConfig()
+
+	BeforeAll(func() {
+		glog.V(networkparams.LogLevel).Info("Starting Network Operator test suite")
+
+		if nvidiaNetworkConfig == nil {
+			Skip("Failed to load the NVIDIANETWORK_ environment configuration")
+		}
+
+		if _, err := nvidianetwork.PullNicClusterPolicy(inittools.APIClient, NicClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("NicClusterPolicy '%s' not found, NVIDIA Network Operator must be deployed first: %v",
+				NicClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		nodeSelector := labels.Set{mellanoxNodeLabel: "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		var err error
+		mellanoxNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing Mellanox nodes: %v", err)
+
+		if len(mellanoxNodes) == 0 {
+			Skip("No Mellanox worker node found")
+		}
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should roll out the OFED driver on every Mellanox node", Label("network-operator-ofed"), func() {
+		nicClusterPolicy, err := nvidianetwork.PullNicClusterPolicy(inittools.APIClient, NicClusterPolicyName)
+		Expect(err).ToNot(HaveOccurred(), "error pulling NicClusterPolicy %s: %v", NicClusterPolicyName, err)
+
+		ofedDriver := nicClusterPolicy.Object.Spec.OFEDDriver
+		if ofedDriver == nil {
+			Skip(fmt.Sprintf("NicClusterPolicy %s does not deploy the OFED driver", NicClusterPolicyName))
+		}
+
+		By(fmt.Sprintf("Wait for NicClusterPolicy %s to be ready", NicClusterPolicyName))
+		err = wait.NicClusterPolicyReady(inittools.APIClient, NicClusterPolicyName, time.Minute, ofedRolloutTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for NicClusterPolicy %s to be ready: %v",
+			NicClusterPolicyName, err)
+
+		nicClusterPolicy, err = nvidianetwork.PullNicClusterPolicy(inittools.APIClient, NicClusterPolicyName)
+		Expect(err).ToNot(HaveOccurred(), "error pulling NicClusterPolicy %s: %v", NicClusterPolicyName, err)
+		Expect(nicClusterPolicy.Object.Status.AppliedStates).To(ContainElement(nvidianetworkv1alpha1.AppliedState{
+			Name:  ofedAppliedStateName,
+			State: nvidianetworkv1alpha1.StateReady,
+		}), "NicClusterPolicy %s did not apply %s", NicClusterPolicyName, ofedAppliedStateName)
+
+		By(fmt.Sprintf("Verify every Mellanox node runs the OFED driver %s", ofedDriver.Version))
+		driverPods, err := pod.List(inittools.APIClient, NetworkOperatorNamespace,
+			metav1.ListOptions{LabelSelector: ofedDriverPodLabel})
+		Expect(err).ToNot(HaveOccurred(), "error listing OFED driver pods: %v", err)
+
+		driverPodsByNode := map[string][]*pod.Builder{}
+		for _, driverPod := range driverPods {
+			driverPodsByNode[driverPod.Object.Spec.NodeName] = append(driverPodsByNode[driverPod.Object.Spec.NodeName],
+				driverPod)
+		}
+
+		for _, node := range mellanoxNodes {
+			nodePods := driverPodsByNode[node.Object.Name]
+			Expect(nodePods).To(HaveLen(1), "node %s does not run a single OFED driver pod", node.Object.Name)
+
+			driverPod := nodePods[0].Object
+			Expect(driverPod.Status.Phase).To(Equal(corev1.PodRunning), "OFED driver pod %s is not running",
+				driverPod.Name)
+
+			for _, containerStatus := range driverPod.Status.ContainerStatuses {
+				Expect(containerStatus.Ready).To(BeTrue(), "OFED driver pod %s container %s is not ready",
+					driverPod.Name, containerStatus.Name)
+			}
+
+			Expect(driverPod.Spec.Containers[0].Image).To(ContainSubstring(":"+ofedDriver.Version),
+				"OFED driver pod %s does not run version %s", driverPod.Name, ofedDriver.Version)
+			glog.V(networkparams.LogLevel).Infof("Node %s runs OFED driver pod %s with image %s", node.Object.Name,
+				driverPod.Name, driverPod.Spec.Containers[0].Image)
+		}
+	})
+
+	It("Should attach a pod to a MacvlanNetwork", Label("network-operator-macvlan"), func() {
+		ipRange := nvidiaNetworkConfig.MacvlanNetworkIPAMRange
+		if ipRange == "" || nvidiaNetworkConfig.MacvlanNetworkIPAMGateway == "" {
+			Skip("NVIDIANETWORK_MACVLANNETWORK_IPAM_RANGE and NVIDIANETWORK_MACVLANNETWORK_IPAM_GATEWAY must be set")
+		}
+
+		master := nvidiaNetworkConfig.MellanoxEthernetInterfaceName
+		if master == "" {
+			master = mellanoxEthernetInterfaceNameDefault
+		}
+
+		By(fmt.Sprintf("Create MacvlanNetwork %s on interface %s", MacvlanNetworkName, master))
+		macvlanNetworkBuilder, err := nvidianetwork.NewMacvlanNetworkBuilder(inittools.APIClient, MacvlanNetworkName,
+			TestNamespace, master, fmt.Sprintf(`{"type": "whereabouts", "range": "%s", "gateway": "%s"}`, ipRange,
+				nvidiaNetworkConfig.MacvlanNetworkIPAMGateway)).Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating MacvlanNetwork %s: %v", MacvlanNetworkName, err)
+
+		DeferCleanup(func() {
+			if _, err := macvlanNetworkBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting MacvlanNetwork %s: %v", MacvlanNetworkName, err)
+			}
+		})
+
+		err = wait.MacvlanNetworkReady(inittools.APIClient, MacvlanNetworkName, 10*time.Second, networkReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for MacvlanNetwork %s to be ready: %v",
+			MacvlanNetworkName, err)
+
+		attachPod("macvlan-attached-pod", MacvlanNetworkName, ipRange)
+	})
+
+	It("Should attach a pod to an IPoIBNetwork", Label("network-operator-ipoib"), func() {
+		ipRange := nvidiaNetworkConfig.IPoIBNetworkIPAMRange
+		if ipRange == "" {
+			Skip("NVIDIANETWORK_IPOIBNETWORK_IPAM_RANGE must be set")
+		}
+
+		master := nvidiaNetworkConfig.MellanoxInfinibandInterfaceName
+		if master == "" {
+			master = mellanoxInfinibandInterfaceNameDefault
+		}
+
+		var excludes []string
+		for _, exclude := range []string{nvidiaNetworkConfig.IPoIBNetworkIPAMExcludeIP1,
+			nvidiaNetworkConfig.IPoIBNetworkIPAMExcludeIP2} {
+			if exclude != "" {
+				excludes = append(excludes, fmt.Sprintf("%q", exclude))
+			}
+		}
+
+		By(fmt.Sprintf("Create IPoIBNetwork %s on interface %s", IPoIBNetworkName, master))
+		ipoibNetworkBuilder, err := nvidianetwork.NewIPoIBNetworkBuilder(inittools.APIClient, IPoIBNetworkName,
+			TestNamespace, master, fmt.Sprintf(`{"type": "whereabouts", "range": "%s", "exclude": [%s]}`, ipRange,
+				strings.Join(excludes, ", "))).Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating IPoIBNetwork %s: %v", IPoIBNetworkName, err)
+
+		DeferCleanup(func() {
+			if _, err := ipoibNetworkBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting IPoIBNetwork %s: %v", IPoIBNetworkName, err)
+			}
+		})
+
+		err = wait.IPoIBNetworkReady(inittools.APIClient, IPoIBNetworkName, 10*time.Second, networkReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for IPoIBNetwork %s to be ready: %v", IPoIBNetworkName, err)
+
+		attachPod("ipoib-attached-pod", IPoIBNetworkName, ipRange)
+	})
+})
+
+// attachPod runs a pod on a Mellanox node attached to the secondary network and checks that the network assigned
+// it an address in the IPAM range.
+func attachPod(podName, networkName, ipRange string) {
+	_, ipNet, err := net.ParseCIDR(ipRange)
+	Expect(err).ToNot(HaveOccurred(), "invalid IPAM range %s: %v", ipRange, err)
+
+	By(fmt.Sprintf("Attach pod %s to network %s", podName, networkName))
+	podBuilder, err := pod.NewBuilder(inittools.APIClient, podName, TestNamespace, disconnected.Image(WorkloadImage)).
+		WithNodeSelector(map[string]string{mellanoxNodeLabel: "true"}).
+		WithSecondaryNetwork([]*multus.NetworkSelectionElement{{Name: networkName}}).
+		CreateAndWaitUntilRunning(podRunningTimeout)
+	Expect(err).ToNot(HaveOccurred(), "error running pod %s attached to network %s: %v", podName, networkName, err)
+
+	DeferCleanup(func() {
+		if _, err := podBuilder.DeleteAndWait(podRunningTimeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", podName, err)
+		}
+	})
+
+	ip, err := rdmatest.GetMyServerIP(inittools.APIClient, podName, TestNamespace, AttachmentInterface)
+	Expect(err).ToNot(HaveOccurred(), "pod %s has no %s address on network %s: %v", podName, AttachmentInterface,
+		networkName, err)
+	glog.V(networkparams.LogLevel).Infof("Pod %s got address %s on network %s", podName, ip, networkName)
+
+	Expect(ipNet.Contains(net.ParseIP(ip))).To(BeTrue(), "pod %s address %s is not in the IPAM range %s",
+		podName, ip, ipRange)
+
+	// The secondary interface must be up for the pod to reach the other pods of the network.
+	output, err := podBuilder.ExecCommand([]string{"cat", fmt.Sprintf("/sys/class/net/%s/operstate",
+		AttachmentInterface)})
+	Expect(err).ToNot(HaveOccurred(), "error reading pod %s interface %s state: %v", podName,
+		AttachmentInterface, err)
+	Expect(strings.TrimSpace(output.String())).ToNot(Equal("down"), "pod %s interface %s is down", podName,
+		AttachmentInterface)
+}
@@ -0,0 +1,171 @@
+package nfdrules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd"
+	nfdv1alpha1 "github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd/api/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// RuleName is the name of the custom NodeFeatureRule
+	RuleName = "nvidia-ci-custom-rules"
+	// NVIDIAPCIVendor is the PCI vendor ID of NVIDIA
+	NVIDIAPCIVendor = "10de"
+	// UnknownPCIVendor is a PCI vendor ID no node has a device of
+	UnknownPCIVendor = "ffff"
+	// NVIDIAPCILabel is the label of the nodes with an NVIDIA PCI device
+	NVIDIAPCILabel = "feature.node.kubernetes.io/nvidia-ci-pci-nvidia"
+	// UnknownPCILabel is the label of the nodes with an UnknownPCIVendor PCI device
+	UnknownPCILabel = "feature.node.kubernetes.io/nvidia-ci-pci-unknown"
+	// KernelModulesLabel is the label of the nodes whose kernel supports the loadable modules
+	KernelModulesLabel = "feature.node.kubernetes.io/nvidia-ci-kernel-modules"
+	// KernelModuleSigLabel is the label of the nodes whose kernel supports the module signatures
+	KernelModuleSigLabel = "feature.node.kubernetes.io/nvidia-ci-kernel-module-sig"
+	// NFDPCIVendorLabel is the label of the default NFD worker config on the nodes with an NVIDIA PCI device
+	NFDPCIVendorLabel = "feature.node.kubernetes.io/pci-10de.present"
+
+	labelPollInterval = 10 * time.Second
+	labelTimeout      = 5 * time.Minute
+)
+
+var _ = Describe("NFD NodeFeatureRule", Ordered, Label(tsparams.LabelSuite, "nfd-rules"), cilabels.Spec(
+	cilabels.Regression, cilabels.Short, cilabels.DisruptsOperands), func() {
+	var (
+		ruleBuilder *nfd.RuleBuilder
+		gpuNodes    []*nodes.Builder
+		ruleLabels  = map[string]string{
+			NVIDIAPCILabel:       "true",
+			KernelModulesLabel:   "true",
+			KernelModuleSigLabel: "true",
+		}
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting NFD NodeFeatureRule test suite")
+
+		if _, err := nfd.Pull(inittools.APIClient, nfd.CRName, nfd.OperatorNamespace); err != nil {
+			Skip(fmt.Sprintf("NodeFeatureDiscovery '%s' not found, NFD operator must be deployed first: %v",
+				nfd.CRName, err))
+		}
+
+		By("Find the nodes NFD labels with an NVIDIA PCI device")
+		nodeSelector := labels.Set{NFDPCIVendorLabel: "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		var err error
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No worker node with an NVIDIA PCI device found")
+		}
+
+		By(fmt.Sprintf("Create NodeFeatureRule %s", RuleName))
+		ruleBuilder, err = nfd.NewRuleBuilder(inittools.APIClient, RuleName).
+			WithRule(pciVendorRule("nvidia-ci-pci-nvidia", NVIDIAPCIVendor, NVIDIAPCILabel)).
+			WithRule(pciVendorRule("nvidia-ci-pci-unknown", UnknownPCIVendor, UnknownPCILabel)).
+			WithRule(kernelConfigRule("nvidia-ci-kernel-modules", "MODULES", KernelModulesLabel)).
+			WithRule(kernelConfigRule("nvidia-ci-kernel-module-sig", "MODULE_SIG", KernelModuleSigLabel)).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating NodeFeatureRule %s: %v", RuleName, err)
+	})
+
+	AfterAll(func() {
+		if ruleBuilder != nil && ruleBuilder.Exists() {
+			if err := ruleBuilder.DeleteAndWait(labelTimeout); err != nil {
+				glog.Errorf("Error deleting NodeFeatureRule %s: %v", RuleName, err)
+			}
+		}
+	})
+
+	It("Should label the nodes matching the PCI vendor rule", Label("nfd-rules-pci"), func() {
+		for _, node := range gpuNodes {
+			By(fmt.Sprintf("Wait for node %s to get label %s", node.Object.Name, NVIDIAPCILabel))
+			err := wait.NodeLabels(inittools.APIClient, node.Object.Name, map[string]string{NVIDIAPCILabel: "true"},
+				true, labelPollInterval, labelTimeout)
+			Expect(err).ToNot(HaveOccurred(), "node %s did not get label %s: %v", node.Object.Name, NVIDIAPCILabel,
+				err)
+		}
+	})
+
+	It("Should not label the nodes not matching the PCI vendor rule", Label("nfd-rules-pci-mismatch"), func() {
+		workerNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{
+			LabelSelector: labels.Set(inittools.GeneralConfig.WorkerLabelMap).String(),
+		})
+		Expect(err).ToNot(HaveOccurred(), "error listing worker nodes: %v", err)
+
+		for _, node := range workerNodes {
+			Expect(node.Object.Labels).ToNot(HaveKey(UnknownPCILabel), "node %s has label %s of PCI vendor %s",
+				node.Object.Name, UnknownPCILabel, UnknownPCIVendor)
+		}
+	})
+
+	It("Should label the nodes matching the kernel config rules", Label("nfd-rules-kernel"), func() {
+		kernelLabels := map[string]string{KernelModulesLabel: "true", KernelModuleSigLabel: "true"}
+
+		for _, node := range gpuNodes {
+			By(fmt.Sprintf("Wait for node %s to get the kernel config labels", node.Object.Name))
+			err := wait.NodeLabels(inittools.APIClient, node.Object.Name, kernelLabels, true, labelPollInterval,
+				labelTimeout)
+			Expect(err).ToNot(HaveOccurred(), "node %s did not get the kernel config labels: %v", node.Object.Name,
+				err)
+		}
+	})
+
+	It("Should remove the labels when the NodeFeatureRule is deleted", Label("nfd-rules-cleanup"), func() {
+		By(fmt.Sprintf("Delete NodeFeatureRule %s", RuleName))
+		err := ruleBuilder.DeleteAndWait(labelTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error deleting NodeFeatureRule %s: %v", RuleName, err)
+
+		for _, node := range gpuNodes {
+			By(fmt.Sprintf("Wait for node %s to lose the custom labels", node.Object.Name))
+			err := wait.NodeLabels(inittools.APIClient, node.Object.Name, ruleLabels, false, labelPollInterval,
+				labelTimeout)
+			Expect(err).ToNot(HaveOccurred(), "node %s kept the custom labels: %v", node.Object.Name, err)
+		}
+	})
+})
+
+// pciVendorRule returns the rule labeling the nodes with a PCI device of the vendor.
+func pciVendorRule(name, vendor, label string) nfdv1alpha1.Rule {
+	return nfdv1alpha1.Rule{
+		Name:   name,
+		Labels: map[string]string{label: "true"},
+		MatchFeatures: []nfdv1alpha1.FeatureMatcherTerm{{
+			Feature: "pci.device",
+			MatchExpressions: map[string]*nfdv1alpha1.MatchExpression{
+				"vendor": {Op: nfdv1alpha1.MatchIn, Value: []string{vendor}},
+			},
+		}},
+	}
+}
+
+// kernelConfigRule returns the rule labeling the nodes whose kernel is built with the config option, without its
+// CONFIG_ prefix.
+func kernelConfigRule(name, option, label string) nfdv1alpha1.Rule {
+	return nfdv1alpha1.Rule{
+		Name:   name,
+		Labels: map[string]string{label: "true"},
+		MatchFeatures: []nfdv1alpha1.FeatureMatcherTerm{{
+			Feature: "kernel.config",
+			MatchExpressions: map[string]*nfdv1alpha1.MatchExpression{
+				option: {Op: nfdv1alpha1.MatchIn, Value: []string{"y"}},
+			},
+		}},
+	}
+}
@@ -0,0 +1,217 @@
+package nfdupgrade
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/check"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	nfdconfig "github.com/rh-ecosystem-edge/nvidia-ci/internal/nfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nfdupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// NFDPCIVendorLabel is the NFD label of the nodes with an NVIDIA PCI device, the GPU operator deploying its
+	// operands on them
+	NFDPCIVendorLabel = "feature.node.kubernetes.io/pci-10de.present"
+	// DevicePluginDeployLabel is the nodeSelector label of the device plugin daemonset set by the GPU operator
+	DevicePluginDeployLabel = "nvidia.com/gpu.deploy.device-plugin"
+	// WorkerTolerationKey is the key of the toleration added to the nfd-worker pods
+	WorkerTolerationKey = "nvidia-ci.test/nfd-worker"
+
+	labelPollInterval      = 5 * time.Second
+	labelSettleTime        = 2 * time.Minute
+	upgradePollInterval    = 30 * time.Second
+	upgradeTimeout         = 15 * time.Minute
+	workerRolloutTimeout   = 10 * time.Minute
+	workerRolloutInterval  = 15 * time.Second
+	csvSucceededTimeout    = 10 * time.Minute
+	csvSucceededPollPeriod = 30 * time.Second
+)
+
+// ExtraPCIDeviceClasses are the PCI device classes added to the NFD worker config, InfiniBand controllers and NVMe
+// controllers of the GPUDirect RDMA and Storage nodes.
+var ExtraPCIDeviceClasses = []string{"0207", "0108"}
+
+var _ = Describe("NFD Upgrade", Ordered, Label(tsparams.LabelSuite, "nfd-upgrade"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.DisruptsCluster), func() {
+	var (
+		nodeLabels         map[string]map[string]string
+		originalConfigData string
+		configChanged      bool
+		tolerationsSet     bool
+		workerTolerations  = []corev1.Toleration{{
+			Key:      WorkerTolerationKey,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		}}
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting NFD upgrade and reconfiguration test suite")
+
+		if ready, err := check.NFDDeploymentsReady(inittools.APIClient); !ready {
+			Skip(fmt.Sprintf("NFD must be deployed before running the NFD upgrade tests: %v", err))
+		}
+
+		nfdBuilder, err := nfd.Pull(inittools.APIClient, nfd.CRName, nfd.OperatorNamespace)
+		if err != nil {
+			Skip(fmt.Sprintf("NodeFeatureDiscovery '%s' not found: %v", nfd.CRName, err))
+		}
+
+		originalConfigData = nfdBuilder.Definition.Spec.WorkerConfig.ConfigData
+
+		By("Find the GPU worker nodes and the labels they must keep")
+		nodeSelector := labels.Set{NFDPCIVendorLabel: "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No worker node with an NVIDIA PCI device found")
+		}
+
+		nodeLabels = map[string]map[string]string{}
+		for _, node := range gpuNodes {
+			nodeLabels[node.Object.Name] = nfdupgrade.Labels(node, NFDPCIVendorLabel, DevicePluginDeployLabel)
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' must keep labels %v", node.Object.Name,
+				nodeLabels[node.Object.Name])
+		}
+	})
+
+	AfterAll(func() {
+		if tolerationsSet {
+			if _, err := nfdupgrade.SetWorkerTolerations(inittools.APIClient, nfd.CRName, nfd.OperatorNamespace,
+				nil); err != nil {
+				glog.Errorf("Error removing the NodeFeatureDiscovery %s worker tolerations: %v", nfd.CRName, err)
+			}
+		}
+
+		if !configChanged {
+			return
+		}
+
+		nfdBuilder, err := nfd.Pull(inittools.APIClient, nfd.CRName, nfd.OperatorNamespace)
+		if err != nil {
+			glog.Errorf("Error pulling NodeFeatureDiscovery %s: %v", nfd.CRName, err)
+
+			return
+		}
+
+		nfdBuilder.Definition.Spec.WorkerConfig.ConfigData = originalConfigData
+		if _, err := nfdBuilder.Update(false); err != nil {
+			glog.Errorf("Error restoring the NodeFeatureDiscovery %s worker config: %v", nfd.CRName, err)
+		}
+	})
+
+	It("Should keep the GPU node labels across the NFD operator upgrade", Label("nfd-upgrade-channel"), func() {
+		nfdConfig, err := nfdconfig.NewNFDConfig()
+		Expect(err).ToNot(HaveOccurred(), "error loading the NFD_ environment configuration: %v", err)
+
+		if nfdConfig.UpgradeToChannel == "" {
+			Skip("NFD_SUBSCRIPTION_UPGRADE_TO_CHANNEL must be set to run the NFD operator upgrade test")
+		}
+
+		subBuilder, err := olm.PullSubscription(inittools.APIClient, nfd.SubscriptionName, nfd.OperatorNamespace)
+		if err != nil {
+			Skip(fmt.Sprintf("Subscription '%s' not found, NFD must be deployed by the test framework: %v",
+				nfd.SubscriptionName, err))
+		}
+
+		upgradeChannel, err := olm.ResolveChannel(inittools.APIClient, subBuilder.Object.Spec.Package,
+			subBuilder.Object.Spec.CatalogSourceNamespace, subBuilder.Object.Spec.CatalogSource,
+			nfdConfig.UpgradeToChannel)
+		Expect(err).ToNot(HaveOccurred(), "error resolving NFD_SUBSCRIPTION_UPGRADE_TO_CHANNEL: %v", err)
+
+		if subBuilder.Object.Spec.Channel == upgradeChannel {
+			Skip(fmt.Sprintf("Subscription '%s' already follows channel %s", nfd.SubscriptionName, upgradeChannel))
+		}
+
+		initialCSV := subBuilder.Object.Status.InstalledCSV
+		watcher := nfdupgrade.WatchNodeLabels(inittools.APIClient, nodeLabels, labelPollInterval)
+		defer watcher.Stop()
+
+		By(fmt.Sprintf("Switch the NFD subscription channel from %s to %s", subBuilder.Object.Spec.Channel,
+			upgradeChannel))
+		_, err = subBuilder.WithChannel(upgradeChannel).Update()
+		Expect(err).ToNot(HaveOccurred(), "error updating subscription %s: %v", nfd.SubscriptionName, err)
+
+		upgradedCSV, err := nfdupgrade.InstalledCSVChanged(inittools.APIClient, nfd.SubscriptionName,
+			nfd.OperatorNamespace, initialCSV, upgradePollInterval, upgradeTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error upgrading the NFD operator: %v", err)
+		glog.V(gpuparams.GpuLogLevel).Infof("NFD operator upgraded from '%s' to '%s'", initialCSV, upgradedCSV)
+
+		err = wait.CSVSucceeded(inittools.APIClient, upgradedCSV, nfd.OperatorNamespace, csvSucceededPollPeriod,
+			csvSucceededTimeout)
+		Expect(err).ToNot(HaveOccurred(), "CSV %s did not succeed: %v", upgradedCSV, err)
+
+		ready, err := check.NFDDeploymentsReady(inittools.APIClient)
+		Expect(ready).To(BeTrue(), "the NFD deployments are not ready after the upgrade: %v", err)
+
+		time.Sleep(labelSettleTime)
+		Expect(watcher.Stop()).To(BeEmpty(), "GPU node labels were lost during the NFD operator upgrade")
+	})
+
+	It("Should keep the GPU node labels when adding label sources", Label("nfd-reconfigure-sources"), func() {
+		nfdBuilder, err := nfd.Pull(inittools.APIClient, nfd.CRName, nfd.OperatorNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling NodeFeatureDiscovery %s: %v", nfd.CRName, err)
+
+		watcher := nfdupgrade.WatchNodeLabels(inittools.APIClient, nodeLabels, labelPollInterval)
+		defer watcher.Stop()
+
+		By(fmt.Sprintf("Add PCI device classes %v to the NFD worker config", ExtraPCIDeviceClasses))
+		err = nfdupgrade.AddPCIDeviceClasses(nfdBuilder, ExtraPCIDeviceClasses...)
+		Expect(err).ToNot(HaveOccurred(), "error adding PCI device classes: %v", err)
+
+		_, err = nfdBuilder.Update(false)
+		Expect(err).ToNot(HaveOccurred(), "error updating NodeFeatureDiscovery %s: %v", nfd.CRName, err)
+		configChanged = true
+
+		By("Wait for the nfd-worker pods to roll out the new worker config")
+		err = nfdupgrade.WorkerRolledOut(inittools.APIClient, nil, workerRolloutInterval,
+			workerRolloutTimeout)
+		Expect(err).ToNot(HaveOccurred(), "the nfd-worker daemonset did not roll out: %v", err)
+
+		time.Sleep(labelSettleTime)
+		Expect(watcher.Stop()).To(BeEmpty(), "GPU node labels were lost while reconfiguring the label sources")
+	})
+
+	It("Should keep the GPU node labels when changing the worker tolerations", Label("nfd-reconfigure-tolerations"),
+		func() {
+			watcher := nfdupgrade.WatchNodeLabels(inittools.APIClient, nodeLabels, labelPollInterval)
+			defer watcher.Stop()
+
+			By(fmt.Sprintf("Add toleration %s to the NFD workers", WorkerTolerationKey))
+			supported, err := nfdupgrade.SetWorkerTolerations(inittools.APIClient, nfd.CRName, nfd.OperatorNamespace,
+				workerTolerations)
+			Expect(err).ToNot(HaveOccurred(), "error setting the NFD worker tolerations: %v", err)
+			tolerationsSet = true
+
+			if !supported {
+				Skip("The NodeFeatureDiscovery CRD of the NFD operator does not support worker tolerations")
+			}
+
+			err = nfdupgrade.WorkerRolledOut(inittools.APIClient, workerTolerations, workerRolloutInterval,
+				workerRolloutTimeout)
+			Expect(err).ToNot(HaveOccurred(), "the nfd-worker daemonset did not roll out the tolerations: %v", err)
+
+			time.Sleep(labelSettleTime)
+			Expect(watcher.Stop()).To(BeEmpty(), "GPU node labels were lost while changing the worker tolerations")
+		})
+})
@@ -0,0 +1,241 @@
+package nim
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/dcgmexporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nim"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/prereq"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the NIM runs
+	TestNamespace = "test-nim"
+	// ServerName is the name of the NIM deployment and service
+	ServerName = "nim-llm"
+
+	// The NIM image is several GB large and the NIM downloads its model from NGC before loading it on the GPU
+	serverReadyTimeout        = 40 * time.Minute
+	clusterPolicyReadyTimeout = 10 * time.Minute
+	metricsTimeout            = 5 * time.Minute
+	metricsPollInterval       = 30 * time.Second
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("NIM", Ordered, Label(tsparams.LabelSuite, "nim", prereq.Label), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.NonDisruptive), func() {
+	var (
+		nsBuilder        *namespace.Builder
+		serverBuilder    *deployment.Builder
+		serverPod        *pod.Builder
+		previousSpec     *nvidiagpuv1.ClusterPolicySpec
+		prometheusClient *prometheus.Client
+		baselineUsed     map[string]map[string]float64
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting NIM test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if prereq.Only() {
+			By("Check the NIM prerequisites")
+			checkPrerequisites()
+		}
+
+		if nvidiaGPUConfig.NIMNGCAPIKey == "" {
+			Skip("NVIDIAGPU_NIM_NGC_API_KEY must be set to pull the NIM image and download its model")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if !clusterPolicyBuilder.Definition.Spec.DCGMExporter.IsEnabled() {
+			Skip(fmt.Sprintf("The DCGM exporter is disabled in ClusterPolicy '%s'", nvidiagpu.ClusterPolicyName))
+		}
+
+		By("Check the NIM image is reachable")
+		Expect(disconnected.CheckImages(nvidiaGPUConfig.NIMImage)).ToNot(HaveOccurred(),
+			"the NIM image is not reachable through the mirrors")
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By("Enable the DCGM exporter ServiceMonitor in the ClusterPolicy")
+		previousSpec, err = dcgmexporter.EnableServiceMonitor(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		Expect(err).ToNot(HaveOccurred(), "error enabling the DCGM exporter ServiceMonitor: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		if previousSpec != nil {
+			err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+		}
+
+		prometheusClient, err = prometheus.NewClient(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error creating the Prometheus client: %v", err)
+
+		By("Record the GPU framebuffer used before deploying the NIM")
+		baselineUsed = map[string]map[string]float64{}
+		for _, gpuNode := range gpuNodes {
+			baselineUsed[gpuNode.Object.Name], err = nim.FramebufferUsed(prometheusClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error querying node %s GPU framebuffer: %v", gpuNode.Object.Name, err)
+		}
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		By("Create the NGC pull and API key secrets")
+		err = nim.CreateNGCSecrets(inittools.APIClient, TestNamespace, nvidiaGPUConfig.NIMNGCAPIKey)
+		Expect(err).ToNot(HaveOccurred(), "error creating the NGC secrets: %v", err)
+
+		By(fmt.Sprintf("Deploy the NIM with image %s", nvidiaGPUConfig.NIMImage))
+		serverBuilder, err = nim.CreateServerDeployment(inittools.APIClient, ServerName, TestNamespace,
+			disconnected.Image(nvidiaGPUConfig.NIMImage), nodeSelector)
+		Expect(err).ToNot(HaveOccurred(), "error creating deployment %s: %v", ServerName, err)
+	})
+
+	AfterAll(func() {
+		if serverBuilder != nil {
+			if err := serverBuilder.DeleteAndWait(serverReadyTimeout); err != nil {
+				glog.Errorf("Error deleting deployment %s: %v", ServerName, err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			}
+		}
+	})
+
+	It("Should load the NIM model on a GPU", Label("nim-deploy"), func() {
+		Expect(serverBuilder.IsReady(serverReadyTimeout)).To(BeTrue(), "deployment %s is not ready", ServerName)
+
+		serverPods, err := pod.List(inittools.APIClient, TestNamespace, metav1.ListOptions{
+			LabelSelector: labels.Set(serverBuilder.Definition.Spec.Selector.MatchLabels).String(),
+		})
+		Expect(err).ToNot(HaveOccurred(), "error listing deployment %s pods: %v", ServerName, err)
+		Expect(serverPods).To(HaveLen(1), "deployment %s does not run a single pod", ServerName)
+
+		serverPod = serverPods[0]
+		glog.V(gpuparams.GpuLogLevel).Infof("NIM pod %s is ready on node %s", serverPod.Object.Name,
+			serverPod.Object.Spec.NodeName)
+	})
+
+	It("Should answer an OpenAI compatible completion request", Label("nim-completion"), func() {
+		if serverPod == nil {
+			Skip("The NIM is not ready")
+		}
+
+		By(fmt.Sprintf("Send a completion request from pod %s", serverPod.Object.Name))
+		output, err := serverPod.ExecCommand(nim.ClientCommand(), nim.ServerContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error sending the completion request: %v: %s", err, output.String())
+
+		result, err := nim.ParseCompletion(output.String())
+		Expect(err).ToNot(HaveOccurred(), "error parsing the completion response: %v", err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Model %s completed %d tokens: %q", result.Model,
+			result.CompletionTokens, result.Text)
+
+		Expect(result.CompletionTokens).To(BeNumerically(">", 0), "model %s completed no token", result.Model)
+		Expect(strings.TrimSpace(result.Text)).ToNot(BeEmpty(), "model %s completed an empty text", result.Model)
+	})
+
+	It("Should consume GPU memory reported by DCGM", Label("nim-gpu-memory"), func() {
+		if serverPod == nil {
+			Skip("The NIM is not ready")
+		}
+
+		nodeName := serverPod.Object.Spec.NodeName
+
+		By(fmt.Sprintf("Wait for DCGM to report at least %.0f MiB more framebuffer used on a GPU of node %s",
+			nvidiaGPUConfig.NIMMinFramebufferUsed, nodeName))
+		err := await.Match(context.TODO(),
+			fmt.Sprintf("DCGM to report the GPU memory of the NIM model on node %s", nodeName),
+			metricsPollInterval, metricsTimeout, func(context.Context) (interface{}, error) {
+				used, err := nim.FramebufferUsed(prometheusClient, nodeName)
+				if err != nil {
+					return 0, err
+				}
+
+				growth := 0.0
+				for gpu, value := range used {
+					growth = max(growth, value-baselineUsed[nodeName][gpu])
+				}
+
+				glog.V(gpuparams.GpuLogLevel).Infof("Node %s GPU framebuffer used %v MiB, largest growth %.0f MiB",
+					nodeName, used, growth)
+
+				return growth, nil
+			}, BeNumerically(">=", nvidiaGPUConfig.NIMMinFramebufferUsed))
+		Expect(err).ToNot(HaveOccurred(), "DCGM does not report the GPU memory of the NIM model on node %s: %v",
+			nodeName, err)
+	})
+})
+
+// checkPrerequisites checks, without changing the cluster, the NGC API key, the ClusterPolicy and its DCGM exporter,
+// the GPU nodes and the NIM image the suite needs, and skips the suite when they are all met.
+func checkPrerequisites() {
+	checker := prereq.NewChecker("NIM").Set("NVIDIAGPU_NIM_NGC_API_KEY", nvidiaGPUConfig.NIMNGCAPIKey)
+
+	clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+	if err == nil && !clusterPolicyBuilder.Definition.Spec.DCGMExporter.IsEnabled() {
+		err = fmt.Errorf("the DCGM exporter is disabled")
+	}
+
+	checker.Add("ClusterPolicy "+nvidiagpu.ClusterPolicyName, "DCGM exporter enabled", err).
+		GPUNodes(inittools.APIClient, "nvidia.com/gpu.present").
+		Images(nvidiaGPUConfig.NIMImage).
+		Finish()
+}
@@ -3,24 +3,30 @@ package nvidiagpu
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
 	nvidiagpuv1alpha1 "github.com/NVIDIA/k8s-operator-libs/api/upgrade/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/networkparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
 
 	internalNFD "github.com/rh-ecosystem-edge/nvidia-ci/internal/nfd"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
 	_ "github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 	. "github.com/rh-ecosystem-edge/nvidia-ci/pkg/global"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/machine"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodepool"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
 
 	nfd "github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfd"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfdcheck"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu/clusterpolicybuilder"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/operatorconfig"
 
 	"github.com/golang/glog"
@@ -34,10 +40,14 @@ import (
 
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/check"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/config"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/deploy"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
 	gpuburn "github.com/rh-ecosystem-edge/nvidia-ci/internal/gpu-burn"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/precompiled"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/prereq"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/sno"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
 	corev1 "k8s.io/api/core/v1"
@@ -55,11 +65,6 @@ var (
 		nvidiagpu.NvidiaGPULabel:            "true",
 	}
 
-	BurnImageName = map[string]string{
-		"amd64": "quay.io/wabouham/gpu_burn_amd64:ubi9",
-		"arm64": "quay.io/wabouham/gpu_burn_arm64:ubi9",
-	}
-
 	machineSetNamespace         = "openshift-machine-api"
 	replicas              int32 = 1
 	workerMachineSetLabel       = "machine.openshift.io/cluster-api-machine-role"
@@ -82,13 +87,17 @@ var (
 	OperatorUpgradeToChannel   = UndefinedValue
 	cleanupAfterTest           = true
 	deployFromBundle           = false
+	bundleCatalogSource        = false
+	bundleRegistryImage        = ""
+	usePrecompiled             = false
 	operatorBundleImage        = ""
 	CurrentCSV                 = ""
 	CurrentCSVVersion          = ""
 	clusterArchitecture        = UndefinedValue
 )
 
-var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
+var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), cilabels.Spec(
+	cilabels.Smoke, cilabels.Medium, cilabels.DisruptsCluster), func() {
 
 	var (
 		deployBundle       deploy.Deploy
@@ -99,7 +108,7 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 
 	nfdConfig, _ = internalNFD.NewNFDConfig()
 
-	Context("DeployGpu", Label("deploy-gpu-with-dtk"), func() {
+	Context("DeployGpu", Label("deploy-gpu-with-dtk", prereq.Label), func() {
 
 		BeforeAll(func() {
 			if nvidiaGPUConfig.InstanceType == "" {
@@ -163,12 +172,36 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 					glog.V(gpuparams.GpuLogLevel).Infof("env variable NVIDIAGPU_BUNDLE_IMAGE"+
 						" is set, will use the specified bundle image '%s'", operatorBundleImage)
 				}
+
+				bundleCatalogSource = nvidiaGPUConfig.BundleCatalogSource
+				if bundleCatalogSource {
+					glog.V(gpuparams.GpuLogLevel).Info("env variable NVIDIAGPU_BUNDLE_CATALOGSOURCE is set to " +
+						"true, will deploy the bundle image from a catalogsource serving it")
+
+					bundleRegistryImage = nvidiaGPUConfig.BundleRegistryImage
+					if bundleRegistryImage == "" {
+						bundleRegistryImage = deploy.DefaultRegistryImage
+					}
+				} else {
+					glog.V(gpuparams.GpuLogLevel).Info("env variable NVIDIAGPU_BUNDLE_CATALOGSOURCE is set to " +
+						"false or is not set, will deploy the bundle image with operator-sdk")
+				}
 			} else {
 				glog.V(gpuparams.GpuLogLevel).Infof("env variable NVIDIAGPU_DEPLOY_FROM_BUNDLE" +
 					" is set to false or is not set, will deploy GPU Operator from catalogsource")
 				deployFromBundle = false
 			}
 
+			usePrecompiled = nvidiaGPUConfig.UsePrecompiled
+
+			if usePrecompiled {
+				glog.V(gpuparams.GpuLogLevel).Info("env variable NVIDIAGPU_USE_PRECOMPILED is set to true, will " +
+					"deploy the GPU driver from precompiled driver containers")
+			} else {
+				glog.V(gpuparams.GpuLogLevel).Info("env variable NVIDIAGPU_USE_PRECOMPILED is set to false or is " +
+					"not set, will deploy the GPU driver compiled with DTK")
+			}
+
 			if nvidiaGPUConfig.OperatorUpgradeToChannel == "" {
 				glog.V(gpuparams.GpuLogLevel).Infof("env variable NVIDIAGPU_SUBSCRIPTION_UPGRADE_TO_CHANNEL" +
 					" is not set, will not run the Upgrade Testcase")
@@ -184,7 +217,7 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 					"NVIDIAGPU_GPU_FALLBACK_CATALOGSOURCE_INDEX_IMAGE is set, and has value: '%s'",
 					nvidiaGPUConfig.GPUFallbackCatalogsourceIndexImage)
 
-				CustomCatalogsourceIndexImage = nvidiaGPUConfig.GPUFallbackCatalogsourceIndexImage
+				CustomCatalogsourceIndexImage = disconnected.Image(nvidiaGPUConfig.GPUFallbackCatalogsourceIndexImage)
 
 				glog.V(gpuparams.GpuLogLevel).Infof("Setting flag to create custom GPU operator catalogsource" +
 					" from fall back index image to True")
@@ -206,7 +239,8 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 					"NFD_FALLBACK_CATALOGSOURCE_INDEX_IMAGE is set, and has value: '%s'",
 					nfdConfig.FallbackCatalogSourceIndexImage)
 
-				nfdInstance.CustomCatalogSourceIndexImage = nfdConfig.FallbackCatalogSourceIndexImage
+				nfdInstance.CustomCatalogSourceIndexImage = disconnected.Image(
+					nfdConfig.FallbackCatalogSourceIndexImage)
 
 				glog.V(gpuparams.GpuLogLevel).Infof("Setting flag to create custom NFD operator catalogsource" +
 					" from fall back index image to True")
@@ -223,6 +257,17 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 				nfdInstance.CreateCustomCatalogsource = false
 			}
 
+			if prereq.Only() {
+				By("Check the GPU operator deployment prerequisites")
+				checkDeployPrerequisites()
+			}
+
+			By("Check the operator images are reachable")
+			Expect(disconnected.CheckImages(operatorBundleImage, bundleRegistryImage,
+				nvidiaGPUConfig.GPUFallbackCatalogsourceIndexImage,
+				nfdConfig.FallbackCatalogSourceIndexImage)).ToNot(HaveOccurred(),
+				"the operator images are not reachable through the mirrors")
+
 			By("Report OpenShift version")
 			ocpVersion, err := inittools.GetOpenShiftVersion()
 			glog.V(gpuparams.GpuLogLevel).Infof("Current OpenShift cluster version is: '%s'", ocpVersion)
@@ -267,7 +312,26 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 					"to scale cluster and add a GPU machineset is set to false")
 				Skip("No GPU labeled worker nodes were found and not scaling current cluster")
 
+			} else if !gpuNodeFound && ScaleCluster && hostedCluster() {
+				By("Expand the hosted cluster using a NodePool of instanceType from the env variable " +
+					"NVIDIAGPU_GPU_MACHINESET_INSTANCE_TYPE")
+				deleteNodePool := scaleHostedCluster(nvidiaGPUConfig.InstanceType)
+
+				defer func() {
+					if cleanupAfterTest {
+						deleteNodePool()
+					}
+				}()
+
 			} else if !gpuNodeFound && ScaleCluster {
+				singleNode, err := sno.IsSingleNode(inittools.APIClient)
+				Expect(err).ToNot(HaveOccurred(), "error detecting Single-Node OpenShift: %v", err)
+
+				if singleNode {
+					Skip("No GPU labeled node was found and Single-Node OpenShift cannot be scaled with a GPU " +
+						"MachineSet")
+				}
+
 				By("Expand the OCP cluster using machineset instanceType from the env variable " +
 					"NVIDIAGPU_GPU_MACHINESET_INSTANCE_TYPE")
 
@@ -318,17 +382,15 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 
 				defer func() {
 					if cleanupAfterTest {
-						err := pulledMachineSetBuilder.Delete()
+						err := pulledMachineSetBuilder.DeleteAndWait(nvidiagpu.MachineReadyWaitDuration)
 						Expect(err).ToNot(HaveOccurred())
 					}
-					// later add wait for machineset to be deleted
 				}()
-			}
 
-			// Here we don't need this step is we already have a GPU worker node on cluster
-			if ScaleCluster {
-				glog.V(gpuparams.GpuLogLevel).Infof("Sleeping for %s to allow the newly created GPU worker node to be labeled by NFD", nvidiagpu.NodeLabelingDelay.String())
-				time.Sleep(nvidiagpu.NodeLabelingDelay)
+				By("Wait on the new GPU worker node to be labeled by NFD")
+				_, err = machine.WaitForMachineSetNodes(inittools.APIClient, createdMsBuilder.Definition.ObjectMeta.Name,
+					machineSetNamespace, nvidiagpu.NvidiaGPULabel, nvidiagpu.NodeLabelingDelay)
+				Expect(err).ToNot(HaveOccurred(), "GPU worker node was not labeled by NFD: %v", err)
 			}
 
 			By("Get Cluster Architecture from first GPU enabled worker node")
@@ -342,10 +404,45 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 				clusterArchitecture)
 
 			By("Check if GPU Operator Deployment is from Bundle")
-			if deployFromBundle {
+			if deployFromBundle && bundleCatalogSource {
+				By("Create a catalogsource serving the GPU operator bundle image")
+				deployBundle = deploy.NewDeploy(inittools.APIClient)
+				deployBundleConfig.BundleImage = disconnected.Image(operatorBundleImage)
+				deployBundleConfig.RegistryImage = disconnected.Image(bundleRegistryImage)
+
+				glog.V(gpuparams.GpuLogLevel).Infof("Deploying GPU operator bundle image '%s' from catalogsource "+
+					"'%s'", deployBundleConfig.BundleImage, nvidiagpu.BundleCatalogSourceName)
+
+				bundleCatalogSourceBuilder, err := deployBundle.DeployBundleCatalogSource(gpuparams.GpuLogLevel,
+					&deployBundleConfig, nvidiagpu.BundleCatalogSourceName, nvidiagpu.CatalogSourceNamespace,
+					nvidiagpu.CustomCatalogSourceDisplayName, nvidiagpu.CustomCatalogSourcePublisherName,
+					nvidiagpu.GpuBundleDeploymentTimeout)
+				Expect(err).ToNot(HaveOccurred(), "error from deploy.DeployBundleCatalogSource():  '%v' ", err)
+
+				defer func() {
+					if cleanupAfterTest {
+						err := deployBundle.DeleteBundleCatalogSource(gpuparams.GpuLogLevel,
+							nvidiagpu.BundleCatalogSourceName, nvidiagpu.CatalogSourceNamespace)
+						Expect(err).ToNot(HaveOccurred())
+					}
+				}()
+
+				CatalogSource = bundleCatalogSourceBuilder.Definition.Name
+
+				gpuPkgManifestBuilderByBundleCatalog, err := olm.PullPackageManifestByCatalogWithTimeout(
+					inittools.APIClient, nvidiagpu.Package, nvidiagpu.CatalogSourceNamespace, CatalogSource,
+					nvidiagpu.PackageManifestCheckInterval, nvidiagpu.PackageManifestTimeout)
+				Expect(err).ToNot(HaveOccurred(), "error getting GPU packagemanifest '%s' from bundle catalog "+
+					"'%s':  %v", nvidiagpu.Package, CatalogSource, err)
+
+				By("Get the GPU Default Channel from Packagemanifest")
+				DefaultSubscriptionChannel = gpuPkgManifestBuilderByBundleCatalog.Object.Status.DefaultChannel
+				glog.V(gpuparams.GpuLogLevel).Infof("GPU channel '%s' retrieved from packagemanifest of bundle "+
+					"catalogsource '%s'", DefaultSubscriptionChannel, CatalogSource)
+			} else if deployFromBundle {
 				// This returns the Deploy interface object initialized with the API client
 				deployBundle = deploy.NewDeploy(inittools.APIClient)
-				deployBundleConfig.BundleImage = operatorBundleImage
+				deployBundleConfig.BundleImage = disconnected.Image(operatorBundleImage)
 				glog.V(gpuparams.GpuLogLevel).Infof("Deploying GPU operator from bundle image '%s'",
 					deployBundleConfig.BundleImage)
 			} else {
@@ -468,8 +565,8 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 			}()
 
 			// Namespace needed to be created by this point or checked if created
-			if deployFromBundle {
-				deployBundleConfig.BundleImage = operatorBundleImage
+			if deployFromBundle && !bundleCatalogSource {
+				deployBundleConfig.BundleImage = disconnected.Image(operatorBundleImage)
 
 				glog.V(gpuparams.GpuLogLevel).Infof("Deploy the GPU Operator bundle image '%s'",
 					deployBundleConfig.BundleImage)
@@ -507,6 +604,11 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 					CatalogSource, nvidiagpu.CatalogSourceNamespace, nvidiagpu.Package)
 
 				if SubscriptionChannel != UndefinedValue {
+					By("Resolve the subscription channel against the packagemanifest channels")
+					SubscriptionChannel, err = olm.ResolveChannel(inittools.APIClient, nvidiagpu.Package,
+						nvidiagpu.CatalogSourceNamespace, CatalogSource, SubscriptionChannel)
+					Expect(err).ToNot(HaveOccurred(), "error resolving NVIDIAGPU_SUBSCRIPTION_CHANNEL: %v", err)
+
 					glog.V(gpuparams.GpuLogLevel).Infof("Setting the subscription channel to: '%s'",
 						SubscriptionChannel)
 					subBuilder.WithChannel(SubscriptionChannel)
@@ -635,13 +737,12 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 
 			By("Deploy ClusterPolicy")
 
-			var clusterPolicyBuilder *nvidiagpu.Builder
-			if nvidiaGPUConfig.ClusterPolicyPatch == "" {
-				glog.V(gpuparams.GpuLogLevel).Infof("Creating default ClusterPolicy from CSV almExamples without modifications")
-				clusterPolicyBuilder = nvidiagpu.NewBuilderFromObjectString(inittools.APIClient, almExamples)
-			} else {
-				glog.V(gpuparams.GpuLogLevel).Infof("Creating default ClusterPolicy from CSV almExamples and aplying a patch")
-				clusterPolicyBuilder = nvidiagpu.NewBuilderFromObjectStringAndPatch(inittools.APIClient, almExamples, nvidiaGPUConfig.ClusterPolicyPatch)
+			glog.V(gpuparams.GpuLogLevel).Infof("Creating default ClusterPolicy from CSV almExamples, patched: %t, "+
+				"precompiled driver: %t", nvidiaGPUConfig.ClusterPolicyPatch != "", usePrecompiled)
+			clusterPolicyBuilder := clusterpolicybuilder.NewBuilder(inittools.APIClient, almExamples).
+				WithPatch(nvidiaGPUConfig.ClusterPolicyPatch)
+			if usePrecompiled {
+				clusterPolicyBuilder.WithPrecompiledDriver(true)
 			}
 
 			createdClusterPolicyBuilder, err := clusterPolicyBuilder.Create()
@@ -674,10 +775,30 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 					err)
 			}
 
+			if usePrecompiled {
+				By(fmt.Sprintf("Wait up to %s for the precompiled driver image to be resolved for the node kernel",
+					precompiled.ImageResolvedTimeout))
+				driverImages, err := precompiled.DriverImagesResolved(inittools.APIClient, WorkerNodeSelector,
+					precompiled.ImageResolvedCheckInterval, precompiled.ImageResolvedTimeout)
+
+				if errors.Is(err, precompiled.ErrImageNotFound) {
+					Skip(fmt.Sprintf("Skipping test: no precompiled driver container is published for the "+
+						"running kernel: %v", err))
+				}
+
+				Expect(err).ToNot(HaveOccurred(), "error waiting for the precompiled driver image to be "+
+					"resolved:  %v", err)
+
+				for nodeName, driverImage := range driverImages {
+					glog.V(gpuparams.GpuLogLevel).Infof("Precompiled driver image resolved for node '%s' is "+
+						"'%s'", nodeName, driverImage)
+				}
+			}
+
 			By(fmt.Sprintf("Wait up to %s for ClusterPolicy to be ready", nvidiagpu.ClusterPolicyReadyTimeout))
 			glog.V(gpuparams.GpuLogLevel).Infof("Waiting up to %s for ClusterPolicy to be ready", nvidiagpu.ClusterPolicyReadyTimeout)
 			err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
-				nvidiagpu.ClusterPolicyReadyCheckInterval, nvidiagpu.ClusterPolicyReadyTimeout)
+				nvidiagpu.ClusterPolicyReadyTimeout)
 
 			glog.V(gpuparams.GpuLogLevel).Infof("error waiting for ClusterPolicy to be Ready:  %v ", err)
 			Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be Ready:  %v ",
@@ -761,16 +882,24 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 			}()
 
 			By("Deploy gpu-burn pod in test-gpu-burn namespace")
+			burnImage, err := gpuburn.Images.Image(clusterArchitecture)
+			Expect(err).ToNot(HaveOccurred(), "Error selecting the gpu-burn image: %v", err)
+
+			Expect(disconnected.CheckImages(burnImage)).ToNot(HaveOccurred(),
+				"the gpu-burn image is not reachable through the mirrors")
+			burnImage = disconnected.Image(burnImage)
+
 			glog.V(gpuparams.GpuLogLevel).Infof("gpu-burn pod image name is: '%s', in namespace '%s'",
-				BurnImageName[clusterArchitecture], burn.Namespace)
+				burnImage, burn.Namespace)
 
 			gpuBurnPod, err := gpuburn.CreateGPUBurnPod(inittools.APIClient, burn.Namespace, burn.Namespace,
-				BurnImageName[(clusterArchitecture)], nvidiagpu.BurnPodCreationTimeout)
+				burnImage, nvidiagpu.BurnPodCreationTimeout)
 			Expect(err).ToNot(HaveOccurred(), "Error creating gpu burn pod: %v", err)
 
 			glog.V(gpuparams.GpuLogLevel).Infof("Creating gpu-burn pod '%s' in namespace '%s'",
 				burn.Namespace, burn.Namespace)
 
+			proxy.Inject(inittools.APIClient, &gpuBurnPod.Spec)
 			_, err = inittools.APIClient.Pods(gpuBurnPod.Namespace).Create(context.TODO(), gpuBurnPod,
 				metav1.CreateOptions{})
 			Expect(err).ToNot(HaveOccurred(), "Error creating gpu-burn '%s' in "+
@@ -908,6 +1037,12 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 
 			glog.V(100).Infof("Current Subscription Channel : %s", pulledSubBuilder.Definition.Spec.Channel)
 
+			By("Resolve the channel to upgrade to against the packagemanifest channels")
+			OperatorUpgradeToChannel, err = olm.ResolveChannel(inittools.APIClient, nvidiagpu.Package,
+				pulledSubBuilder.Definition.Spec.CatalogSourceNamespace, pulledSubBuilder.Definition.Spec.CatalogSource,
+				OperatorUpgradeToChannel)
+			Expect(err).ToNot(HaveOccurred(), "error resolving NVIDIAGPU_SUBSCRIPTION_UPGRADE_TO_CHANNEL: %v", err)
+
 			pulledSubBuilder.Definition.Spec.Channel = OperatorUpgradeToChannel
 			glog.V(100).Infof("Updating Subscription Channel to upgrade to : %s",
 				pulledSubBuilder.Definition.Spec.Channel)
@@ -934,7 +1069,7 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 			By("Wait for daemonsets to be redeployed up to 15 minutes and for ClusterPolicy to be ready again")
 			glog.V(gpuparams.GpuLogLevel).Infof("Waiting up to 15 mins for ClusterPolicy to be ready again " +
 				"after upgrade")
-			err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, 60*time.Second, 15*time.Minute)
+			err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, 15*time.Minute)
 
 			glog.V(gpuparams.GpuLogLevel).Infof("error waiting for ClusterPolicy to be Ready:  %v ", err)
 			Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be Ready:  %v ",
@@ -985,10 +1120,6 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 			_, err = currentGpuBurnPodPulled.Delete()
 			Expect(err).ToNot(HaveOccurred(), "Error deleting gpu-burn pod")
 
-			By("Re-deploy gpu-burn pod in test-gpu-burn namespace")
-			glog.V(gpuparams.GpuLogLevel).Infof("Re-deployed gpu-burn pod image name is: '%s', in "+
-				"namespace '%s'", BurnImageName[clusterArchitecture], burn.Namespace)
-
 			By("Get Cluster Architecture from first GPU enabled worker node")
 			glog.V(gpuparams.GpuLogLevel).Infof("Getting cluster architecture from nodes with "+
 				"WorkerNodeSelector: %v", WorkerNodeSelector)
@@ -998,14 +1129,22 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 			glog.V(gpuparams.GpuLogLevel).Infof("cluster architecture for GPU enabled worker node is: %s",
 				clusterArch)
 
+			burnImage, err := gpuburn.Images.Image(clusterArch)
+			Expect(err).ToNot(HaveOccurred(), "Error selecting the gpu-burn image: %v", err)
+
+			By("Re-deploy gpu-burn pod in test-gpu-burn namespace")
+			glog.V(gpuparams.GpuLogLevel).Infof("Re-deployed gpu-burn pod image name is: '%s', in "+
+				"namespace '%s'", burnImage, burn.Namespace)
+
 			gpuBurnPod2, err := gpuburn.CreateGPUBurnPod(inittools.APIClient, burn.Namespace, burn.Namespace,
-				BurnImageName[(clusterArch)], nvidiagpu.BurnPodPostUpgradeCreationTimeout)
+				burnImage, nvidiagpu.BurnPodPostUpgradeCreationTimeout)
 			Expect(err).ToNot(HaveOccurred(), "Error re-building gpu burn pod object after "+
 				"upgrade: %v", err)
 
 			glog.V(gpuparams.GpuLogLevel).Infof("Re-deploying gpu-burn pod '%s' in namespace '%s'",
 				burn.Namespace, burn.Namespace)
 
+			proxy.Inject(inittools.APIClient, &gpuBurnPod2.Spec)
 			_, err = inittools.APIClient.Pods(burn.Namespace).Create(context.TODO(), gpuBurnPod2,
 				metav1.CreateOptions{})
 			Expect(err).ToNot(HaveOccurred(), "Error re-deploying gpu-burn '%s' after operator"+
@@ -1065,3 +1204,96 @@ var _ = Describe("GPU", Ordered, Label(tsparams.LabelSuite), func() {
 
 	})
 })
+
+// hostedCluster returns true when the suite runs against a HyperShift hosted cluster, whose GPU nodes are added with a
+// NodePool of the management cluster rather than a MachineSet.
+func hostedCluster() bool {
+	hosted, err := inittools.APIClient.IsHostedCluster()
+	Expect(err).ToNot(HaveOccurred(), "error detecting a HyperShift hosted cluster: %v", err)
+
+	return hosted
+}
+
+// scaleHostedCluster creates a GPU NodePool of the instance type for the hosted cluster and waits for its nodes to be
+// labeled by NFD. It returns the function deleting the NodePool.
+func scaleHostedCluster(instanceType string) func() {
+	generalConfig := inittools.GeneralConfig
+	if generalConfig.HostedClusterName == "" {
+		Skip("No GPU labeled node was found and HOSTED_CLUSTER_NAME is not set to scale the hosted cluster")
+	}
+
+	mngClient, err := inittools.GetClusterClient(config.ManagementCluster)
+	Expect(err).ToNot(HaveOccurred(), "error getting the management cluster client: %v", err)
+
+	By("Create the new GPU enabled NodePool")
+	nodePool, err := nodepool.CreateGPUNodePool(mngClient, generalConfig.HostedClusterName,
+		generalConfig.HostedClusterNamespace, instanceType, "gpu", replicas)
+	Expect(err).ToNot(HaveOccurred(), "error creating a GPU enabled NodePool: %v", err)
+
+	glog.V(gpuparams.GpuLogLevel).Infof("Successfully created GPU enabled NodePool %s", nodePool.GetName())
+
+	deleteNodePool := func() {
+		err := nodepool.DeleteAndWait(mngClient, nodePool.GetName(), generalConfig.HostedClusterNamespace,
+			nvidiagpu.MachineReadyWaitDuration)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	By("Wait on the new GPU worker nodes to be labeled by NFD")
+	_, err = nodepool.WaitForNodePoolNodes(inittools.APIClient, nodePool.GetName(), int(replicas),
+		nvidiagpu.NvidiaGPULabel, nvidiagpu.MachineReadyWaitDuration+nvidiagpu.NodeLabelingDelay)
+	if err != nil {
+		if cleanupAfterTest {
+			deleteNodePool()
+		}
+
+		Fail(fmt.Sprintf("The nodes of NodePool %s were not labeled by NFD: %v", nodePool.GetName(), err))
+	}
+
+	return deleteNodePool
+}
+
+// checkDeployPrerequisites checks, without changing the cluster, the GPU nodes, catalogsources, packages, pull secret
+// and images the deployment of NFD and of the GPU operator needs, and skips the suite when they are all met.
+func checkDeployPrerequisites() {
+	checker := prereq.NewChecker("GPU").
+		Secret(inittools.APIClient, "pull-secret", "openshift-config")
+
+	nfdInstalled, err := check.NFDDeploymentsReady(inittools.APIClient)
+
+	switch {
+	case nfdInstalled:
+		checker.Add("NFD operator", "installed", nil)
+
+		if ScaleCluster {
+			checker.Add("GPU worker nodes", "added by scaling the cluster with instance type "+
+				nvidiaGPUConfig.InstanceType, nil)
+		} else {
+			checker.GPUNodes(inittools.APIClient, nvidiagpu.NvidiaGPULabel)
+		}
+	case nfdInstance.CreateCustomCatalogsource:
+		glog.V(gpuparams.GpuLogLevel).Infof("NFD is not installed: %v", err)
+		checker.Images(nfdConfig.FallbackCatalogSourceIndexImage)
+	default:
+		glog.V(gpuparams.GpuLogLevel).Infof("NFD is not installed: %v", err)
+		checker.CatalogSource(inittools.APIClient, nfd.CatalogSourceDefault, nfd.CatalogSourceNamespace).
+			PackageChannel(inittools.APIClient, nfd.Package, nfd.CatalogSourceNamespace, nfd.CatalogSourceDefault, "")
+	}
+
+	switch {
+	case deployFromBundle:
+		checker.Images(operatorBundleImage, bundleRegistryImage)
+	case createGPUCustomCatalogsource:
+		checker.Images(nvidiaGPUConfig.GPUFallbackCatalogsourceIndexImage)
+	default:
+		checker.CatalogSource(inittools.APIClient, CatalogSource, nvidiagpu.CatalogSourceNamespace).
+			PackageChannel(inittools.APIClient, nvidiagpu.Package, nvidiagpu.CatalogSourceNamespace, CatalogSource,
+				nvidiaGPUConfig.SubscriptionChannel)
+
+		if OperatorUpgradeToChannel != UndefinedValue {
+			checker.PackageChannel(inittools.APIClient, nvidiagpu.Package, nvidiagpu.CatalogSourceNamespace,
+				CatalogSource, OperatorUpgradeToChannel)
+		}
+	}
+
+	checker.Finish()
+}
@@ -4,16 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
 	"strings"
 	"time"
 
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidianetworkconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/ofed"
 	rdmatest "github.com/rh-ecosystem-edge/nvidia-ci/internal/rdma"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nfdcheck"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/operatorconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -85,17 +89,12 @@ var (
 
 	ofedDriverVersion    = UndefinedValue
 	ofedDriverRepository = UndefinedValue
+	ofedDriverVersions   []string
 
 	sriovNetworkName = UndefinedValue
 
 	rdmaTestImage = UndefinedValue
 
-	// Default image based on cluster architecture
-	rdmaTestImageDefault = map[string]string{
-		"amd64": "quay.io/wabouham/ecosys-nvidia/rdma-tools:0.0.3",
-		"arm64": "quay.io/wabouham/ecosys-nvidia/rdma-tools-aarch64:0.0.3",
-	}
-
 	withCuda = "no"
 )
 
@@ -117,11 +116,16 @@ const (
 	nnoCustomCatalogSourcePublisherName = "Red Hat"
 	nnoCustomCatalogSourceDisplayName   = "Certified Operators Custom"
 
+	// ofedDriverRolloutTimeout is the time for the network operator to upgrade the driver of every node, one node
+	// at a time.
+	ofedDriverRolloutTimeout = 60 * time.Minute
+
 	mellanoxEthernetInterfaceNameDefault   = "ens1f0np0"
 	mellanoxInfinibandInterfaceNameDefault = "ibs1f1"
 )
 
-var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
+var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), cilabels.Spec(
+	cilabels.Smoke, cilabels.Medium, cilabels.DisruptsCluster, cilabels.RDMA), func() {
 
 	var (
 		deployBundle       deploy.Deploy
@@ -183,6 +187,15 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 					"NVIDIANETWORK_OFED_DRIVER_VERSION value '%s'", ofedDriverVersion)
 			}
 
+			if nvidiaNetworkConfig.OfedDriverVersions == "" {
+				glog.V(networkparams.LogLevel).Infof("env variable NVIDIANETWORK_OFED_DRIVER_VERSIONS" +
+					" is not set, the OFED driver version matrix testcase will be skipped")
+			} else {
+				ofedDriverVersions = strings.Split(nvidiaNetworkConfig.OfedDriverVersions, ",")
+				glog.V(networkparams.LogLevel).Infof("ofedDriverVersions is set to env variable "+
+					"NVIDIANETWORK_OFED_DRIVER_VERSIONS value '%v'", ofedDriverVersions)
+			}
+
 			if nvidiaNetworkConfig.OfedDriverRepository == "" {
 				glog.V(networkparams.LogLevel).Infof("env variable NVIDIANETWORK_OFED_REPOSITORY" +
 					" is not set, will use default ofed driver repository from CSV alm-examples section")
@@ -423,7 +436,8 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 					"NVIDIANETWORK_NNO_FALLBACK_CATALOGSOURCE_INDEX_IMAGE is set, and has value: '%s'",
 					nvidiaNetworkConfig.NNOFallbackCatalogsourceIndexImage)
 
-				CustomCatalogsourceIndexImage = nvidiaNetworkConfig.NNOFallbackCatalogsourceIndexImage
+				CustomCatalogsourceIndexImage = disconnected.Image(
+					nvidiaNetworkConfig.NNOFallbackCatalogsourceIndexImage)
 
 				glog.V(networkparams.LogLevel).Infof("Setting flag to create custom Network Operator " +
 					"catalogsource from fall back index image to True")
@@ -445,7 +459,8 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 					"NFD_FALLBACK_CATALOGSOURCE_INDEX_IMAGE is set, and has value: '%s'",
 					nfdConfig.FallbackCatalogSourceIndexImage)
 
-				nfdInstance.CustomCatalogSourceIndexImage = nfdConfig.FallbackCatalogSourceIndexImage
+				nfdInstance.CustomCatalogSourceIndexImage = disconnected.Image(
+					nfdConfig.FallbackCatalogSourceIndexImage)
 
 				glog.V(networkparams.LogLevel).Infof("Setting flag to create custom NFD operator " +
 					"catalogsource from fall back index image to True")
@@ -472,6 +487,12 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 			glog.V(networkparams.LogLevel).Infof("Setting the withCuda switch for RDMA workload to '%s'",
 				withCuda)
 
+			By("Check the operator images are reachable")
+			Expect(disconnected.CheckImages(networkOperatorBundleImage,
+				nvidiaNetworkConfig.NNOFallbackCatalogsourceIndexImage,
+				nfdConfig.FallbackCatalogSourceIndexImage)).ToNot(HaveOccurred(),
+				"the operator images are not reachable through the mirrors")
+
 			By("Report OpenShift version")
 			ocpVersion, err := inittools.GetOpenShiftVersion()
 			glog.V(networkparams.LogLevel).Infof("Current OpenShift cluster version is: '%s'", ocpVersion)
@@ -499,15 +520,20 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 				"is: %s", clusterArchitecture)
 
 			if nvidiaNetworkConfig.RdmaTestImage == "" {
+				rdmaTestImage, err = rdmatest.TestImages.Image(clusterArchitecture)
+				Expect(err).ToNot(HaveOccurred(), "error selecting the default rdma test image: %v", err)
 				glog.V(networkparams.LogLevel).Infof("env variable NVIDIANETWORK_RDMA_TEST_IMAGE"+
-					" is not set, will use default container image '%s'", rdmaTestImageDefault[clusterArchitecture])
-				rdmaTestImage = rdmaTestImageDefault[clusterArchitecture]
+					" is not set, will use default container image '%s'", rdmaTestImage)
 			} else {
 				rdmaTestImage = nvidiaNetworkConfig.RdmaTestImage
 				glog.V(networkparams.LogLevel).Infof("rdmaTestImage is set to env variable "+
 					"NVIDIANETWORK_RDMA_TEST_IMAGE value '%v'", rdmaTestImage)
 			}
 
+			Expect(disconnected.CheckImages(rdmaTestImage)).ToNot(HaveOccurred(),
+				"the rdma test image is not reachable through the mirrors")
+			rdmaTestImage = disconnected.Image(rdmaTestImage)
+
 		})
 
 		BeforeEach(func() {
@@ -568,7 +594,7 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 				glog.V(networkparams.LogLevel).Infof("Deploying Network operator from bundle")
 				// This returns the Deploy interface object initialized with the API client
 				deployBundle = deploy.NewDeploy(inittools.APIClient)
-				deployBundleConfig.BundleImage = networkOperatorBundleImage
+				deployBundleConfig.BundleImage = disconnected.Image(networkOperatorBundleImage)
 				glog.V(networkparams.LogLevel).Infof("Deploying Network operator from bundle image '%s'",
 					deployBundleConfig.BundleImage)
 
@@ -695,7 +721,7 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 				glog.V(networkparams.LogLevel).Infof("Initializing the kube API Client before deploying bundle")
 				deployBundle = deploy.NewDeploy(inittools.APIClient)
 
-				deployBundleConfig.BundleImage = networkOperatorBundleImage
+				deployBundleConfig.BundleImage = disconnected.Image(networkOperatorBundleImage)
 
 				glog.V(networkparams.LogLevel).Infof("Deploy the Network Operator bundle image '%s'",
 					deployBundleConfig.BundleImage)
@@ -1302,6 +1328,7 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 				rdmaWorkloadNamespace, withCuda, "server", rdmaServerHostname, rdmaMlxDevice,
 				workloadPodNetworkName, rdmaTestImage, rdmaLinkType, "none", rdmaNetworkType)
 
+			proxy.Inject(inittools.APIClient, &rdmaServerPod.Spec)
 			createdRdmaServerPod, err := inittools.APIClient.Pods(rdmaServerPod.Namespace).Create(context.TODO(),
 				rdmaServerPod, metav1.CreateOptions{})
 
@@ -1341,6 +1368,7 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 				"client", rdmaClientHostname, rdmaMlxDevice, workloadPodNetworkName, rdmaTestImage,
 				rdmaLinkType, net1IntIpAddrServer, rdmaNetworkType)
 
+			proxy.Inject(inittools.APIClient, &rdmaClientPod.Spec)
 			createdRdmaClientPod, err := inittools.APIClient.Pods(rdmaClientPod.Namespace).Create(context.TODO(),
 				rdmaClientPod, metav1.CreateOptions{})
 
@@ -1454,6 +1482,7 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 				"server", rdmaServerHostname, "sriov", sriovNetworkName, rdmaTestImage, rdmaLinkType,
 				"none", rdmaNetworkType)
 
+			proxy.Inject(inittools.APIClient, &rdmaServerPod.Spec)
 			createdRdmaServerPod, err := inittools.APIClient.Pods(rdmaServerPod.Namespace).Create(context.TODO(),
 				rdmaServerPod, metav1.CreateOptions{})
 
@@ -1497,6 +1526,7 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 				"client", rdmaClientHostname, "sriov", sriovNetworkName, rdmaTestImage,
 				rdmaLinkType, net1IntIpAddrServer, rdmaNetworkType)
 
+			proxy.Inject(inittools.APIClient, &rdmaClientPod.Spec)
 			createdRdmaClientPod, err := inittools.APIClient.Pods(rdmaClientPod.Namespace).Create(context.TODO(),
 				rdmaClientPod, metav1.CreateOptions{})
 
@@ -1550,5 +1580,79 @@ var _ = Describe("NNO", Ordered, Label(tsparams.LabelSuite), func() {
 
 		})
 
+		It("Roll out each OFED driver version of the version matrix", Label("ofed-version-matrix"), func() {
+			if len(ofedDriverVersions) == 0 {
+				Skip("env variable NVIDIANETWORK_OFED_DRIVER_VERSIONS is not set")
+			}
+
+			if _, err := nvidianetwork.PullNicClusterPolicy(inittools.APIClient, nnoNicClusterPolicyName); err != nil {
+				Skip(fmt.Sprintf("NicClusterPolicy '%s' not found: %v", nnoNicClusterPolicyName, err))
+			}
+
+			By("Count the active RDMA ports of the nodes before the OFED driver version matrix")
+			initialActivePorts, err := ofed.ActiveRDMAPorts(inittools.APIClient)
+			Expect(err).ToNot(HaveOccurred(), "error counting the active RDMA ports: %v", err)
+			Expect(initialActivePorts).ToNot(BeEmpty(), "no OFED driver pod found")
+			glog.V(networkparams.LogLevel).Infof("Active RDMA ports before the OFED driver version matrix: %v",
+				initialActivePorts)
+
+			var (
+				initialVersion string
+				versionChanged bool
+			)
+
+			DeferCleanup(func() {
+				if !versionChanged {
+					return
+				}
+
+				By(fmt.Sprintf("Restore the NicClusterPolicy ofedDriver version to '%s'", initialVersion))
+				if _, err := ofed.SetNicClusterPolicyOFEDVersion(inittools.APIClient, nnoNicClusterPolicyName,
+					initialVersion); err != nil {
+					glog.Errorf("Error restoring the NicClusterPolicy ofedDriver version: %v", err)
+				} else if err := wait.OFEDDriverRolledOut(inittools.APIClient, initialVersion, 60*time.Second,
+					ofedDriverRolloutTimeout); err != nil {
+					glog.Errorf("Error waiting for the OFED driver version '%s' to roll out: %v", initialVersion,
+						err)
+				}
+			})
+
+			for _, version := range ofedDriverVersions {
+				By(fmt.Sprintf("Update the NicClusterPolicy ofedDriver version to '%s'", version))
+				previousVersion, err := ofed.SetNicClusterPolicyOFEDVersion(inittools.APIClient,
+					nnoNicClusterPolicyName, version)
+				Expect(err).ToNot(HaveOccurred(), "error updating the NicClusterPolicy ofedDriver version to "+
+					"'%s': %v", version, err)
+
+				if !versionChanged {
+					initialVersion = previousVersion
+					versionChanged = true
+				}
+
+				By(fmt.Sprintf("Wait up to %v for the OFED driver version '%s' to roll out", ofedDriverRolloutTimeout,
+					version))
+				err = wait.OFEDDriverRolledOut(inittools.APIClient, version, 60*time.Second, ofedDriverRolloutTimeout)
+				Expect(err).ToNot(HaveOccurred(), "error waiting for the OFED driver version '%s' to roll out: %v",
+					version, err)
+
+				err = wait.NicClusterPolicyReady(inittools.APIClient, nnoNicClusterPolicyName, 60*time.Second,
+					24*time.Minute)
+				Expect(err).ToNot(HaveOccurred(), "error waiting for NicClusterPolicy to be Ready with OFED "+
+					"driver version '%s': %v", version, err)
+
+				By(fmt.Sprintf("Check the RDMA devices are functional with OFED driver version '%s'", version))
+				activePorts, err := ofed.ActiveRDMAPorts(inittools.APIClient)
+				Expect(err).ToNot(HaveOccurred(), "error counting the active RDMA ports: %v", err)
+
+				for nodeName, initialCount := range initialActivePorts {
+					Expect(activePorts).To(HaveKeyWithValue(nodeName, BeNumerically(">=", initialCount)),
+						"node '%s' lost active RDMA ports with OFED driver version '%s'", nodeName, version)
+				}
+
+				glog.V(networkparams.LogLevel).Infof("OFED driver version '%s' rolled out, active RDMA ports: %v",
+					version, activePorts)
+			}
+		})
+
 	})
 })
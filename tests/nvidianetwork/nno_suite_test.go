@@ -7,7 +7,6 @@ import (
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
 	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clients"
 
-	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
 	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -17,15 +16,12 @@ import (
 var _, currentFile, _, _ = runtime.Caller(0)
 
 func TestNNODeploy(t *testing.T) {
-	_, reporterConfig := GinkgoConfiguration()
-	reporterConfig.JUnitReport = inittools.GeneralConfig.GetJunitReportPath(currentFile)
+	suiteConfig, reporterConfig := GinkgoConfiguration()
+	reporter.ApplyFlakeAttempts(&suiteConfig)
 
 	RegisterFailHandler(Fail)
-	RunSpecs(t, "NNO", Label(tsparams.NetworkLabels...), reporterConfig)
+	RunSpecs(t, "NNO", Label(tsparams.NetworkLabels...), suiteConfig, reporterConfig)
 }
 
-var _ = JustAfterEach(func() {
-	reporter.ReportIfFailed(
-		CurrentSpecReport(), currentFile, tsparams.NetworkReporterNamespacesToDump, tsparams.NetworkReporterCRDsToDump,
-		clients.SetScheme)
-})
+var _ = reporter.RegisterSuiteHooks(
+	currentFile, tsparams.NetworkReporterNamespacesToDump, tsparams.NetworkReporterCRDsToDump, clients.SetScheme)
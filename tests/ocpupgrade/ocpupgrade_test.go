@@ -0,0 +1,233 @@
+package ocpupgrade
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/clusterupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/sno"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/clusterversion"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the workload running across the cluster upgrade is deployed
+	TestNamespace = "test-ocp-upgrade"
+	// WorkloadDeploymentName is the name of the GPU workload deployment running across the cluster upgrade
+	WorkloadDeploymentName = "ocp-upgrade-workload"
+	// WorkloadImage is the container image of the GPU workload
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+
+	clusterUpgradePollInterval = time.Minute
+	clusterUpgradeTimeout      = 3 * time.Hour
+	driverRolloutPollInterval  = 30 * time.Second
+	driverRolloutTimeout       = 30 * time.Minute
+	clusterPolicyReadyTimeout  = 30 * time.Minute
+	workloadReadyTimeout       = 10 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("OCP Upgrade", Ordered, Label(tsparams.LabelSuite, "ocp-upgrade"), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.DisruptsCluster), func() {
+	var (
+		gpuNodeNames    []string
+		statesBefore    map[string]*clusterupgrade.NodeState
+		initialVersion  string
+		upgradeDone     bool
+		singleNode      bool
+		nsBuilder       *namespace.Builder
+		workloadBuilder *deployment.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting OCP Upgrade test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.OCPUpgradeVersion == "" && nvidiaGPUConfig.OCPUpgradeImage == "" {
+			Skip("NVIDIAGPU_OCP_UPGRADE_VERSION or NVIDIAGPU_OCP_UPGRADE_IMAGE must be set to run the OCP " +
+				"upgrade tests")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		hostedCluster, err := inittools.APIClient.IsHostedCluster()
+		Expect(err).ToNot(HaveOccurred(), "error detecting a HyperShift hosted cluster: %v", err)
+
+		if hostedCluster {
+			Skip("HyperShift hosted clusters are upgraded through their HostedCluster on the management cluster")
+		}
+
+		clusterVersionBuilder, err := clusterversion.Pull(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error pulling ClusterVersion: %v", err)
+
+		initialVersion, err = clusterVersionBuilder.GetCompletedVersion()
+		Expect(err).ToNot(HaveOccurred(), "error getting the cluster version: %v", err)
+
+		if initialVersion == nvidiaGPUConfig.OCPUpgradeVersion {
+			Skip(fmt.Sprintf("The cluster already runs OpenShift %s", initialVersion))
+		}
+
+		By("Record the RHCOS version and driver pod of the GPU nodes")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		singleNode, err = sno.IsSingleNode(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error detecting Single-Node OpenShift: %v", err)
+
+		statesBefore = map[string]*clusterupgrade.NodeState{}
+
+		for _, gpuNode := range gpuNodes {
+			state, err := clusterupgrade.GetNodeState(inittools.APIClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error getting the state of node %s: %v", gpuNode.Object.Name, err)
+
+			gpuNodeNames = append(gpuNodeNames, gpuNode.Object.Name)
+			statesBefore[gpuNode.Object.Name] = state
+		}
+
+		By("Start a GPU workload running across the cluster upgrade")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		workloadBuilder, err = clusterupgrade.CreateWorkloadDeployment(inittools.APIClient, WorkloadDeploymentName,
+			TestNamespace, disconnected.Image(WorkloadImage))
+		Expect(err).ToNot(HaveOccurred(), "error creating deployment %s: %v", WorkloadDeploymentName, err)
+		Expect(workloadBuilder.IsReady(workloadReadyTimeout)).To(BeTrue(), "deployment %s is not ready",
+			WorkloadDeploymentName)
+	})
+
+	AfterAll(func() {
+		if workloadBuilder != nil {
+			if err := workloadBuilder.DeleteAndWait(workloadReadyTimeout); err != nil {
+				glog.Errorf("Error deleting deployment %s: %v", WorkloadDeploymentName, err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should upgrade the cluster", Label("ocp-upgrade-cluster"), func() {
+		By(fmt.Sprintf("Upgrade the cluster from %s to version '%s' image '%s'", initialVersion,
+			nvidiaGPUConfig.OCPUpgradeVersion, nvidiaGPUConfig.OCPUpgradeImage))
+		clusterVersionBuilder, err := clusterversion.Pull(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error pulling ClusterVersion: %v", err)
+
+		// A release image outside of the cluster channel can only be applied by forcing the update.
+		_, err = clusterVersionBuilder.WithDesiredUpdate(nvidiaGPUConfig.OCPUpgradeVersion,
+			nvidiaGPUConfig.OCPUpgradeImage, nvidiaGPUConfig.OCPUpgradeImage != "").Update()
+		Expect(err).ToNot(HaveOccurred(), "error updating ClusterVersion: %v", err)
+
+		err = wait.ClusterVersionUpdated(inittools.APIClient, nvidiaGPUConfig.OCPUpgradeVersion,
+			nvidiaGPUConfig.OCPUpgradeImage, clusterUpgradePollInterval, clusterUpgradeTimeout)
+		Expect(err).ToNot(HaveOccurred(), "cluster upgrade did not complete: %v", err)
+		upgradeDone = true
+	})
+
+	It("Should roll the driver daemonset onto the new RHCOS kernel", Label("ocp-upgrade-driver"), func() {
+		if !upgradeDone {
+			Skip("The cluster was not upgraded")
+		}
+
+		for _, nodeName := range gpuNodeNames {
+			before := statesBefore[nodeName]
+
+			By(fmt.Sprintf("Wait for a ready driver pod matching the new RHCOS of node %s", nodeName))
+			var after *clusterupgrade.NodeState
+			err := await.Match(context.TODO(),
+				fmt.Sprintf("the driver to roll onto the new RHCOS of node %s", nodeName),
+				driverRolloutPollInterval, driverRolloutTimeout, func(context.Context) (interface{}, error) {
+					var err error
+					after, err = clusterupgrade.GetNodeState(inittools.APIClient, nodeName)
+					if err != nil {
+						return nil, err
+					}
+
+					return after.OSTreeVersion != before.OSTreeVersion &&
+						clusterupgrade.DriverPodMatchesOSTree(after.DriverPod, after.OSTreeVersion) &&
+						clusterupgrade.DriverPodReady(after.DriverPod), nil
+				}, BeTrue())
+			Expect(err).ToNot(HaveOccurred(), "driver was not rolled onto the new RHCOS of node %s: %v", nodeName, err)
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' moved from kernel '%s' to '%s', driver pod '%s'",
+				nodeName, before.KernelVersion, after.KernelVersion, after.DriverPod.Object.Name)
+
+			Expect(after.KernelVersion).ToNot(Equal(before.KernelVersion), "kernel of node %s did not change",
+				nodeName)
+			Expect(after.DriverPod.Object.UID).ToNot(Equal(before.DriverPod.Object.UID),
+				"driver pod of node %s was not replaced", nodeName)
+		}
+
+		err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+	})
+
+	It("Should resume the GPU workload", Label("ocp-upgrade-workload"), func() {
+		if !upgradeDone {
+			Skip("The cluster was not upgraded")
+		}
+
+		By(fmt.Sprintf("Wait for deployment %s to be ready", WorkloadDeploymentName))
+		Expect(workloadBuilder.IsReady(sno.DisruptionTimeout(singleNode, workloadReadyTimeout))).To(BeTrue(),
+			"deployment %s is not ready", WorkloadDeploymentName)
+
+		workloadPods, err := pod.List(inittools.APIClient, TestNamespace,
+			metav1.ListOptions{LabelSelector: clusterupgrade.WorkloadPodLabel})
+		Expect(err).ToNot(HaveOccurred(), "error listing pods in namespace %s: %v", TestNamespace, err)
+		Expect(workloadPods).ToNot(BeEmpty(), "no workload pod found in namespace %s", TestNamespace)
+
+		for _, workloadPod := range workloadPods {
+			err := await.Match(context.TODO(), fmt.Sprintf("pod %s to list the GPU", workloadPod.Object.Name),
+				10*time.Second, time.Minute, func(context.Context) (interface{}, error) {
+					return workloadPod.GetFullLog(clusterupgrade.WorkloadContainerName)
+				}, ContainSubstring("GPU 0:"))
+			Expect(err).ToNot(HaveOccurred(), "pod %s does not list the GPU: %v", workloadPod.Object.Name, err)
+		}
+	})
+})
@@ -0,0 +1,340 @@
+package operatorupgrade
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/check"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/operatorupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu/clusterpolicybuilder"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/olm"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// TestNamespace is the namespace where the workload running across the upgrade is deployed
+	TestNamespace = "test-operator-upgrade"
+	// WorkloadPodName is the name of the GPU workload pod running across the upgrade
+	WorkloadPodName = "operator-upgrade-workload"
+	// WorkloadImage is the container image of the GPU workload pod
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+
+	installPlanPollInterval   = 30 * time.Second
+	installPlanSettleTimeout  = 3 * time.Minute
+	maxUpgradeSteps           = 5
+	clusterPolicyReadyTimeout = 20 * time.Minute
+	workloadRunningTimeout    = 5 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Operator Upgrade", Ordered, Label(tsparams.LabelSuite, "operator-upgrade"), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.DisruptsCluster), func() {
+	var (
+		catalogSource   string
+		startChannel    string
+		upgradeChannel  string
+		initialCSV      string
+		upgradedCSVs    []string
+		workloadPodUID  types.UID
+		operatorNs      *namespace.Builder
+		testNs          *namespace.Builder
+		ogBuilder       *olm.OperatorGroupBuilder
+		subBuilder      *olm.SubscriptionBuilder
+		operatorCreated bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Operator Upgrade test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.SubscriptionChannel == "" || nvidiaGPUConfig.OperatorUpgradeToChannel == "" {
+			Skip("NVIDIAGPU_SUBSCRIPTION_CHANNEL and NVIDIAGPU_SUBSCRIPTION_UPGRADE_TO_CHANNEL must be set to " +
+				"run the operator upgrade tests")
+		}
+
+		if _, err := olm.PullSubscription(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace); err == nil {
+			Skip(fmt.Sprintf("Subscription '%s' already exists, the operator upgrade tests deploy the GPU "+
+				"operator themselves", nvidiagpu.SubscriptionName))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		if ready, err := check.NFDDeploymentsReady(inittools.APIClient); !ready {
+			Skip(fmt.Sprintf("NFD must be deployed before running the operator upgrade tests: %v", err))
+		}
+
+		catalogSource = nvidiaGPUConfig.CatalogSource
+		if catalogSource == "" {
+			catalogSource = nvidiagpu.CatalogSourceDefault
+		}
+
+		By("Resolve the subscription channels against the packagemanifest channels")
+		var err error
+		startChannel, err = olm.ResolveChannel(inittools.APIClient, nvidiagpu.Package,
+			nvidiagpu.CatalogSourceNamespace, catalogSource, nvidiaGPUConfig.SubscriptionChannel)
+		Expect(err).ToNot(HaveOccurred(), "error resolving NVIDIAGPU_SUBSCRIPTION_CHANNEL: %v", err)
+
+		upgradeChannel, err = olm.ResolveChannel(inittools.APIClient, nvidiagpu.Package,
+			nvidiagpu.CatalogSourceNamespace, catalogSource, nvidiaGPUConfig.OperatorUpgradeToChannel)
+		Expect(err).ToNot(HaveOccurred(), "error resolving NVIDIAGPU_SUBSCRIPTION_UPGRADE_TO_CHANNEL: %v", err)
+
+		if startChannel == upgradeChannel {
+			Skip(fmt.Sprintf("NVIDIAGPU_SUBSCRIPTION_CHANNEL and NVIDIAGPU_SUBSCRIPTION_UPGRADE_TO_CHANNEL both "+
+				"resolve to channel %s", startChannel))
+		}
+
+		By("Create and label the GPU operator namespace")
+		operatorNs = namespace.NewBuilder(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace)
+		if !operatorNs.Exists() {
+			createdNs, err := operatorNs.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", nvidiagpu.NvidiaGPUNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(map[string]string{
+				"openshift.io/cluster-monitoring":    "true",
+				"pod-security.kubernetes.io/enforce": "privileged",
+			}).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", nvidiagpu.NvidiaGPUNamespace, err)
+		}
+
+		ogBuilder = olm.NewOperatorGroupBuilder(inittools.APIClient, nvidiagpu.OperatorGroupName,
+			nvidiagpu.NvidiaGPUNamespace)
+		if !ogBuilder.Exists() {
+			_, err := ogBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating operatorgroup %s: %v", nvidiagpu.OperatorGroupName, err)
+		}
+
+		By(fmt.Sprintf("Subscribe to channel %s with manual installplan approval", startChannel))
+		subBuilder = olm.NewSubscriptionBuilder(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace, catalogSource, nvidiagpu.CatalogSourceNamespace, nvidiagpu.Package).
+			WithChannel(startChannel).
+			WithInstallPlanApproval(v1alpha1.ApprovalManual)
+
+		if nvidiaGPUConfig.SubscriptionStartingCSV != "" {
+			subBuilder.WithStartingCSV(nvidiaGPUConfig.SubscriptionStartingCSV)
+		}
+
+		_, err = subBuilder.Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating subscription %s: %v", nvidiagpu.SubscriptionName, err)
+		operatorCreated = true
+
+		By("Approve the initial installplan")
+		initialCSV, err = operatorupgrade.ApproveInstallPlan(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace, installPlanPollInterval, nvidiagpu.CsvSucceededTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error approving the initial installplan: %v", err)
+
+		err = wait.SubscriptionInstalledCSV(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace, initialCSV, installPlanPollInterval, nvidiagpu.CsvSucceededTimeout)
+		Expect(err).ToNot(HaveOccurred(), "subscription did not install CSV %s: %v", initialCSV, err)
+
+		err = wait.CSVSucceeded(inittools.APIClient, initialCSV, nvidiagpu.NvidiaGPUNamespace,
+			nvidiagpu.CsvSucceededCheckInterval, nvidiagpu.CsvSucceededTimeout)
+		Expect(err).ToNot(HaveOccurred(), "CSV %s did not succeed: %v", initialCSV, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Initial GPU operator CSV '%s' installed", initialCSV)
+
+		By("Create the ClusterPolicy from the initial CSV almExamples")
+		csvBuilder, err := olm.PullClusterServiceVersion(inittools.APIClient, initialCSV,
+			nvidiagpu.NvidiaGPUNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling CSV %s: %v", initialCSV, err)
+
+		// The driver daemonset is not rolled by the upgrade, so that GPU workloads keep running.
+		_, err = clusterpolicybuilder.NewBuilderFromCSV(inittools.APIClient, csvBuilder).
+			WithPatch(nvidiaGPUConfig.ClusterPolicyPatch).
+			WithDriverAutoUpgrade(false).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating ClusterPolicy: %v", err)
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+		By("Start a GPU workload running across the upgrade")
+		testNs = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !testNs.Exists() {
+			_, err := testNs.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		workloadPod := operatorupgrade.CreateWorkloadPod(WorkloadPodName, TestNamespace,
+			disconnected.Image(WorkloadImage))
+		proxy.Inject(inittools.APIClient, &workloadPod.Spec)
+		_, err = inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), workloadPod, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", WorkloadPodName, err)
+
+		podBuilder, err := pod.Pull(inittools.APIClient, WorkloadPodName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", WorkloadPodName, err)
+
+		err = podBuilder.WaitUntilRunning(workloadRunningTimeout)
+		Expect(err).ToNot(HaveOccurred(), "pod %s is not running: %v", WorkloadPodName, err)
+		workloadPodUID = podBuilder.Object.UID
+	})
+
+	AfterAll(func() {
+		if testNs != nil {
+			if err := testNs.DeleteAndWait(workloadRunningTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if !operatorCreated || !nvidiaGPUConfig.CleanupAfterTest {
+			return
+		}
+
+		By("Remove the GPU operator deployed by the upgrade tests")
+		if clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err == nil {
+			if _, err := clusterPolicyBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting ClusterPolicy %s: %v", nvidiagpu.ClusterPolicyName, err)
+			}
+		}
+
+		if err := subBuilder.Delete(); err != nil {
+			glog.Errorf("Error deleting subscription %s: %v", nvidiagpu.SubscriptionName, err)
+		}
+
+		csvBuilders, err := olm.ListClusterServiceVersion(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace)
+		if err == nil {
+			for _, csvBuilder := range csvBuilders {
+				if err := csvBuilder.Delete(); err != nil {
+					glog.Errorf("Error deleting CSV %s: %v", csvBuilder.Object.Name, err)
+				}
+			}
+		}
+
+		if err := ogBuilder.Delete(); err != nil {
+			glog.Errorf("Error deleting operatorgroup %s: %v", nvidiagpu.OperatorGroupName, err)
+		}
+
+		if err := operatorNs.Delete(); err != nil {
+			glog.Errorf("Error deleting namespace %s: %v", nvidiagpu.NvidiaGPUNamespace, err)
+		}
+	})
+
+	It("Should upgrade the GPU operator to the new channel", Label("operator-upgrade-channel"), func() {
+		By(fmt.Sprintf("Switch the subscription channel from %s to %s", startChannel, upgradeChannel))
+		pulledSubBuilder, err := olm.PullSubscription(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling subscription %s: %v", nvidiagpu.SubscriptionName, err)
+
+		_, err = pulledSubBuilder.WithChannel(upgradeChannel).Update()
+		Expect(err).ToNot(HaveOccurred(), "error updating subscription %s: %v", nvidiagpu.SubscriptionName, err)
+
+		By("Approve the upgrade installplans")
+		upgradedCSVs, err = operatorupgrade.ApproveUpgrades(inittools.APIClient, nvidiagpu.SubscriptionName,
+			nvidiagpu.SubscriptionNamespace, maxUpgradeSteps, installPlanPollInterval, installPlanSettleTimeout,
+			nvidiagpu.CsvSucceededTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error upgrading the GPU operator: %v", err)
+		Expect(upgradedCSVs).ToNot(BeEmpty(), "no upgrade was installed")
+		glog.V(gpuparams.GpuLogLevel).Infof("GPU operator upgraded from '%s' through %v", initialCSV, upgradedCSVs)
+
+		Expect(upgradedCSVs[len(upgradedCSVs)-1]).ToNot(Equal(initialCSV), "the GPU operator CSV did not change")
+
+		By("Wait for the ClusterPolicy to be ready with the upgraded operator")
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+	})
+
+	It("Should keep the GPU workload running during the upgrade", Label("operator-upgrade-workload"), func() {
+		if len(upgradedCSVs) == 0 {
+			Skip("The GPU operator was not upgraded")
+		}
+
+		podBuilder, err := pod.Pull(inittools.APIClient, WorkloadPodName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", WorkloadPodName, err)
+
+		Expect(podBuilder.Object.UID).To(Equal(workloadPodUID), "pod %s was recreated", WorkloadPodName)
+		Expect(podBuilder.Object.Status.Phase).To(Equal(corev1.PodRunning), "pod %s is not running",
+			WorkloadPodName)
+
+		for _, containerStatus := range podBuilder.Object.Status.ContainerStatuses {
+			Expect(containerStatus.RestartCount).To(BeZero(), "container %s of pod %s restarted",
+				containerStatus.Name, WorkloadPodName)
+		}
+
+		podLog, err := podBuilder.GetFullLog(operatorupgrade.WorkloadContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error getting pod %s log: %v", WorkloadPodName, err)
+		Expect(podLog).To(ContainSubstring("GPU 0:"), "pod %s does not list the GPU", WorkloadPodName)
+	})
+
+	It("Should deploy the operand images of the upgraded operator", Label("operator-upgrade-operands"), func() {
+		if len(upgradedCSVs) == 0 {
+			Skip("The GPU operator was not upgraded")
+		}
+
+		upgradedCSV := upgradedCSVs[len(upgradedCSVs)-1]
+		csvBuilder, err := olm.PullClusterServiceVersion(inittools.APIClient, upgradedCSV,
+			nvidiagpu.NvidiaGPUNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling CSV %s: %v", upgradedCSV, err)
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		Expect(err).ToNot(HaveOccurred(), "error pulling ClusterPolicy %s: %v", nvidiagpu.ClusterPolicyName, err)
+
+		for _, operand := range operatorupgrade.Operands(&clusterPolicyBuilder.Object.Spec) {
+			expectedImage, err := operand.ExpectedImage(csvBuilder)
+			Expect(err).ToNot(HaveOccurred(), "error getting the expected image of %s: %v", operand.DaemonSet, err)
+
+			images, found, err := operatorupgrade.DaemonSetImages(inittools.APIClient, operand.DaemonSet,
+				nvidiagpu.NvidiaGPUNamespace)
+			Expect(err).ToNot(HaveOccurred(), "error getting the images of %s: %v", operand.DaemonSet, err)
+
+			if !found {
+				glog.V(gpuparams.GpuLogLevel).Infof("Operand daemonset '%s' is not deployed, skipping",
+					operand.DaemonSet)
+
+				continue
+			}
+
+			By(fmt.Sprintf("Verify daemonset %s runs %s", operand.DaemonSet, expectedImage))
+			Expect(images).To(ContainElement(expectedImage), "daemonset %s does not run the image of CSV %s",
+				operand.DaemonSet, upgradedCSV)
+		}
+	})
+
+	It("Should replace the operand pods of every node after the upgrade", Label("operator-upgrade-stale-pods"),
+		func() {
+			if len(upgradedCSVs) == 0 {
+				Skip("The GPU operator was not upgraded")
+			}
+
+			upgradedCSV := upgradedCSVs[len(upgradedCSVs)-1]
+			csvBuilder, err := olm.PullClusterServiceVersion(inittools.APIClient, upgradedCSV,
+				nvidiagpu.NvidiaGPUNamespace)
+			Expect(err).ToNot(HaveOccurred(), "error pulling CSV %s: %v", upgradedCSV, err)
+
+			clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+			Expect(err).ToNot(HaveOccurred(), "error pulling ClusterPolicy %s: %v", nvidiagpu.ClusterPolicyName,
+				err)
+
+			operands := append(operatorupgrade.Operands(&clusterPolicyBuilder.Object.Spec),
+				operatorupgrade.DriverOperand(&clusterPolicyBuilder.Object.Spec))
+
+			staleImages, err := operatorupgrade.StaleImages(inittools.APIClient, operands, csvBuilder)
+			Expect(err).ToNot(HaveOccurred(), "error comparing the operand pod images: %v", err)
+			Expect(staleImages).To(BeEmpty(), "operand pods still run images older than CSV %s", upgradedCSV)
+		})
+})
@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TestNamespace is the namespace where the proxied workload runs
+	TestNamespace = "test-gpu-proxy"
+	// WorkloadImage is the container image of the proxied workload, shipping curl
+	WorkloadImage = "registry.access.redhat.com/ubi9/ubi-minimal:latest"
+	// WorkloadPodName is the name of the pod fetching the external URL
+	WorkloadPodName = "proxy-fetch"
+	// ExternalURL is the external URL fetched through the cluster wide proxy, any HTTP answer proving it is reachable
+	ExternalURL = "https://nvcr.io/v2/"
+	// DriverPodLabel is the label of the GPU driver pods
+	DriverPodLabel = "app=nvidia-driver-daemonset"
+
+	workloadTimeout = 5 * time.Minute
+)
+
+var _ = Describe("Proxy", Ordered, Label(tsparams.LabelSuite, "proxy"), cilabels.Spec(
+	cilabels.Regression, cilabels.Short, cilabels.NonDisruptive, cilabels.Proxy), func() {
+	var (
+		nsBuilder *namespace.Builder
+		proxyEnv  []corev1.EnvVar
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting cluster wide proxy test suite")
+
+		var err error
+		proxyEnv, err = proxy.EnvVars(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error getting the cluster wide proxy: %v", err)
+
+		if len(proxyEnv) == 0 {
+			Skip(fmt.Sprintf("Proxy '%s' sets no HTTP nor HTTPS proxy", proxy.ClusterProxyName))
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.DeleteAndWait(workloadTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should run the GPU operator with the cluster wide proxy", Label("proxy-operator"), func() {
+		operatorBuilder, err := deployment.Pull(inittools.APIClient, nvidiagpu.OperatorDeployment,
+			nvidiagpu.NvidiaGPUNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling deployment %s: %v", nvidiagpu.OperatorDeployment, err)
+
+		By(fmt.Sprintf("Verify the containers of deployment %s have the proxy environment",
+			nvidiagpu.OperatorDeployment))
+		missing := proxy.MissingEnv(operatorBuilder.Object.Spec.Template.Spec.Containers, proxyEnv)
+		Expect(missing).To(BeEmpty(), "containers %v of deployment %s miss the cluster proxy environment",
+			missing, nvidiagpu.OperatorDeployment)
+	})
+
+	It("Should run the GPU driver with the cluster wide proxy", Label("proxy-driver"), func() {
+		driverPods, err := inittools.APIClient.Pods(nvidiagpu.NvidiaGPUNamespace).List(context.TODO(),
+			metav1.ListOptions{LabelSelector: DriverPodLabel})
+		Expect(err).ToNot(HaveOccurred(), "error listing the driver pods: %v", err)
+
+		if len(driverPods.Items) == 0 {
+			Skip("No GPU driver pod found, the driver may be preinstalled on the nodes")
+		}
+
+		for _, driverPod := range driverPods.Items {
+			By(fmt.Sprintf("Verify the driver container of pod %s has the proxy environment", driverPod.Name))
+			missing := proxy.MissingEnv(driverPod.Spec.Containers[:1], proxyEnv)
+			Expect(missing).To(BeEmpty(), "driver pod %s misses the cluster proxy environment", driverPod.Name)
+		}
+	})
+
+	It("Should fetch external content from a test workload", Label("proxy-workload"), func() {
+		By(fmt.Sprintf("Fetch %s from pod %s", ExternalURL, WorkloadPodName))
+		podBuilder, err := pod.NewBuilder(inittools.APIClient, WorkloadPodName, TestNamespace,
+			disconnected.Image(WorkloadImage)).
+			RedefineDefaultCMD([]string{"curl", "-sS", "-o", "/dev/null", "--max-time", "60", ExternalURL}).
+			WithRestartPolicy(corev1.RestartPolicyNever).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", WorkloadPodName, err)
+
+		missing := proxy.MissingEnv(podBuilder.Definition.Spec.Containers, proxyEnv)
+		Expect(missing).To(BeEmpty(), "the proxy environment was not injected in pod %s", WorkloadPodName)
+
+		err = podBuilder.WaitUntilInStatus(corev1.PodSucceeded, workloadTimeout)
+		Expect(err).ToNot(HaveOccurred(), "pod %s could not fetch %s through the proxy: %v", WorkloadPodName,
+			ExternalURL, err)
+	})
+})
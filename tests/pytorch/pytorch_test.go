@@ -0,0 +1,155 @@
+package pytorch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nsisolation"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/pytorch"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespacePrefix is the prefix of the isolated namespace where the PyTorch training Jobs run
+	TestNamespacePrefix = "test-pytorch"
+	// SingleGPUJobName is the name of the Job training on one GPU
+	SingleGPUJobName = "pytorch-train-1gpu"
+	// MultiGPUJobName is the name of the Job training on all the GPUs of the node
+	MultiGPUJobName = "pytorch-train-multi-gpu"
+
+	// The PyTorch image is several GB large
+	trainCompleteTimeout = 30 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("PyTorch", Ordered, Label(tsparams.LabelSuite, "pytorch"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.NonDisruptive), func() {
+	var (
+		trainNode           *nodes.Builder
+		nodeGPUs            int
+		nsBuilder           *namespace.Builder
+		singleGPUThroughput float64
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting PyTorch test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the PyTorch image is reachable")
+		Expect(disconnected.CheckImages(nvidiaGPUConfig.PyTorchImage)).ToNot(HaveOccurred(),
+			"the PyTorch image is not reachable through the mirrors")
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		// Both trainings run on the node with the most GPUs, so that the multi GPU scaling is measured on one node.
+		for _, node := range gpuNodes {
+			if count := get.GPUCount(node); count > nodeGPUs {
+				trainNode, nodeGPUs = node, count
+			}
+		}
+
+		if trainNode == nil {
+			Skip("No GPU node reports its GPU count")
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Training on node %s with %d GPU(s)", trainNode.Object.Name, nodeGPUs)
+
+		nsBuilder, err = nsisolation.Create(inittools.APIClient, TestNamespacePrefix, nil)
+		Expect(err).ToNot(HaveOccurred(), "error creating the isolated namespace: %v", err)
+	})
+
+	It("Should train ResNet on one GPU", Label("pytorch-single-gpu"), func() {
+		result := runTraining(SingleGPUJobName, nsBuilder.Object.Name, trainNode.Object.Name, 1)
+
+		singleGPUThroughput = result.ImagesPerSecond
+		reporter.RecordMetric("pytorch-images-per-sec-1gpu", result.ImagesPerSecond)
+	})
+
+	It("Should train ResNet on all the GPUs of a node", Label("pytorch-multi-gpu"), func() {
+		if nodeGPUs < 2 {
+			Skip(fmt.Sprintf("Node %s has a single GPU", trainNode.Object.Name))
+		}
+
+		result := runTraining(MultiGPUJobName, nsBuilder.Object.Name, trainNode.Object.Name, nodeGPUs)
+		Expect(result.GPUs).To(Equal(nodeGPUs), "the training did not run one rank per GPU")
+
+		reporter.RecordMetric(fmt.Sprintf("pytorch-images-per-sec-%dgpu", nodeGPUs), result.ImagesPerSecond)
+
+		if singleGPUThroughput == 0 {
+			glog.V(gpuparams.GpuLogLevel).Info("No single GPU throughput, the scaling efficiency is not checked")
+
+			return
+		}
+
+		efficiency := result.ImagesPerSecond / (float64(nodeGPUs) * singleGPUThroughput)
+		reporter.RecordMetric(fmt.Sprintf("pytorch-scaling-efficiency-%dgpu", nodeGPUs),
+			fmt.Sprintf("%.2f", efficiency))
+		Expect(efficiency).To(BeNumerically(">=", nvidiaGPUConfig.PyTorchMinScalingEfficiency),
+			"the training on %d GPUs is %.2f times as fast as on one GPU", nodeGPUs, efficiency*float64(nodeGPUs))
+	})
+})
+
+// runTraining runs a PyTorch ResNet training Job in the namespace on the GPUs of the node and returns its result.
+func runTraining(jobName, nsName, nodeName string, gpus int) *pytorch.Result {
+	By(fmt.Sprintf("Run PyTorch training Job %s on %d GPU(s) of node %s", jobName, gpus, nodeName))
+	trainBuilder, err := pytorch.NewBuilder(inittools.APIClient, jobName, nsName,
+		disconnected.Image(nvidiaGPUConfig.PyTorchImage)).
+		WithGPUs(gpus).
+		WithNodeSelector(map[string]string{corev1.LabelHostname: nodeName}).
+		Create()
+	Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", jobName, err)
+
+	defer func() {
+		if err := trainBuilder.Delete(); err != nil {
+			glog.Errorf("Error deleting Job %s: %v", jobName, err)
+		}
+	}()
+
+	err = trainBuilder.WaitUntilComplete(trainCompleteTimeout)
+	Expect(err).ToNot(HaveOccurred(), "Job %s did not succeed: %v", jobName, err)
+
+	result, err := trainBuilder.GetResult()
+	Expect(err).ToNot(HaveOccurred(), "error getting Job %s result: %v", jobName, err)
+	glog.V(gpuparams.GpuLogLevel).Infof("Job %s trained %.2f images/s on %d GPU(s), last loss %.4f", jobName,
+		result.ImagesPerSecond, result.GPUs, result.Loss)
+
+	return result
+}
@@ -0,0 +1,236 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/quota"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the quota testcases workloads run
+	TestNamespace = "test-quota"
+	// QuotaName is the name of the ResourceQuota on the GPU requests of the test namespace
+	QuotaName = "gpu-quota"
+	// LimitRangeName is the name of the LimitRange defaulting the cpu and memory of the test namespace
+	LimitRangeName = "cpu-memory-defaults"
+	// AdmittedPodName is the name of the GPU pod fitting in the quota
+	AdmittedPodName = "gpu-quota-admitted"
+	// ExceedingPodName is the name of the GPU pod exceeding the quota while the admitted pod runs
+	ExceedingPodName = "gpu-quota-exceeding"
+	// DefaultedPodName is the name of the GPU pod getting the LimitRange defaults
+	DefaultedPodName = "gpu-limitrange-defaulted"
+
+	quotaGPUs          = 1
+	quotaSyncTimeout   = 2 * time.Minute
+	podRunningTimeout  = 10 * time.Minute
+	podDeletedTimeout  = 2 * time.Minute
+	vectorAddTimeout   = 5 * time.Minute
+	vectorAddPollDelay = 5 * time.Second
+)
+
+var (
+	limitRangeRequests = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+		corev1.ResourceMemory: resource.MustParse("128Mi"),
+	}
+	limitRangeLimits = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("500m"),
+		corev1.ResourceMemory: resource.MustParse("512Mi"),
+	}
+)
+
+var _ = Describe("Quota", Ordered, Label(tsparams.LabelSuite, "quota"), cilabels.Spec(
+	cilabels.Regression, cilabels.Short, cilabels.NonDisruptive), func() {
+	var (
+		nsBuilder      *namespace.Builder
+		admittedPod    *pod.Builder
+		quotaAvailable bool
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Quota test suite")
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the CUDA sample image is reachable")
+		Expect(disconnected.CheckImages(cudasamples.VectorAddImage)).ToNot(HaveOccurred(),
+			"the CUDA sample image is not reachable through the mirrors")
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should admit a GPU pod within the GPU requests quota", Label("quota-gpu-admitted"), func() {
+		By(fmt.Sprintf("Create ResourceQuota %s of %d GPU(s) in namespace %s", QuotaName, quotaGPUs, TestNamespace))
+		_, err := quota.CreateGPUQuota(inittools.APIClient, QuotaName, TestNamespace, quotaGPUs)
+		Expect(err).ToNot(HaveOccurred(), "error creating ResourceQuota %s: %v", QuotaName, err)
+
+		err = quota.WaitForQuotaUsed(inittools.APIClient, QuotaName, TestNamespace, 0, quotaSyncTimeout)
+		Expect(err).ToNot(HaveOccurred(), "ResourceQuota %s status was not computed: %v", QuotaName, err)
+
+		By(fmt.Sprintf("Create pod %s requesting %d GPU(s)", AdmittedPodName, quotaGPUs))
+		admittedPod, err = quota.CreateGPUPod(inittools.APIClient, AdmittedPodName, TestNamespace,
+			disconnected.Image(cudasamples.VectorAddImage), quotaGPUs)
+		Expect(err).ToNot(HaveOccurred(), "pod %s within the quota was not admitted: %v", AdmittedPodName, err)
+
+		err = admittedPod.WaitUntilRunning(podRunningTimeout)
+		Expect(err).ToNot(HaveOccurred(), "pod %s is not running: %v", AdmittedPodName, err)
+
+		checkVectorAdd(admittedPod)
+
+		By(fmt.Sprintf("Check ResourceQuota %s reports the GPU(s) of pod %s as used", QuotaName, AdmittedPodName))
+		err = quota.WaitForQuotaUsed(inittools.APIClient, QuotaName, TestNamespace, quotaGPUs, quotaSyncTimeout)
+		Expect(err).ToNot(HaveOccurred(), "ResourceQuota %s does not count pod %s: %v", QuotaName,
+			AdmittedPodName, err)
+
+		quotaAvailable = true
+	})
+
+	It("Should reject a GPU pod exceeding the GPU requests quota", Label("quota-gpu-exceeded"), func() {
+		if !quotaAvailable {
+			Skip(fmt.Sprintf("ResourceQuota %s is not used by a running GPU pod", QuotaName))
+		}
+
+		By(fmt.Sprintf("Create pod %s requesting %d more GPU(s)", ExceedingPodName, quotaGPUs))
+		_, err := quota.CreateGPUPod(inittools.APIClient, ExceedingPodName, TestNamespace,
+			disconnected.Image(cudasamples.VectorAddImage), quotaGPUs)
+		Expect(err).To(HaveOccurred(), "pod %s exceeding the quota was admitted", ExceedingPodName)
+		Expect(quota.IsQuotaExceeded(err)).To(BeTrue(), "pod %s was not rejected for exceeding the quota: %v",
+			ExceedingPodName, err)
+
+		By(fmt.Sprintf("Delete pod %s and check pod %s is admitted once the GPU(s) are released", AdmittedPodName,
+			ExceedingPodName))
+		_, err = admittedPod.DeleteAndWait(podDeletedTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error deleting pod %s: %v", AdmittedPodName, err)
+
+		err = quota.WaitForQuotaUsed(inittools.APIClient, QuotaName, TestNamespace, 0, quotaSyncTimeout)
+		Expect(err).ToNot(HaveOccurred(), "ResourceQuota %s did not release the GPU(s): %v", QuotaName, err)
+
+		exceedingPod, err := quota.CreateGPUPod(inittools.APIClient, ExceedingPodName, TestNamespace,
+			disconnected.Image(cudasamples.VectorAddImage), quotaGPUs)
+		Expect(err).ToNot(HaveOccurred(), "pod %s was not admitted once the GPU(s) were released: %v",
+			ExceedingPodName, err)
+
+		_, err = exceedingPod.DeleteAndWait(podDeletedTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error deleting pod %s: %v", ExceedingPodName, err)
+
+		err = quota.WaitForQuotaUsed(inittools.APIClient, QuotaName, TestNamespace, 0, quotaSyncTimeout)
+		Expect(err).ToNot(HaveOccurred(), "ResourceQuota %s did not release the GPU(s): %v", QuotaName, err)
+	})
+
+	It("Should apply the LimitRange defaults without altering the GPU resources", Label("quota-limitrange"), func() {
+		By(fmt.Sprintf("Create LimitRange %s in namespace %s", LimitRangeName, TestNamespace))
+		_, err := quota.CreateLimitRange(inittools.APIClient, LimitRangeName, TestNamespace, limitRangeRequests,
+			limitRangeLimits)
+		Expect(err).ToNot(HaveOccurred(), "error creating LimitRange %s: %v", LimitRangeName, err)
+
+		By(fmt.Sprintf("Create pod %s requesting only %d GPU(s)", DefaultedPodName, quotaGPUs))
+		defaultedPod, err := quota.CreateGPUPod(inittools.APIClient, DefaultedPodName, TestNamespace,
+			disconnected.Image(cudasamples.VectorAddImage), quotaGPUs)
+		Expect(err).ToNot(HaveOccurred(), "pod %s was not admitted: %v", DefaultedPodName, err)
+
+		DeferCleanup(func() {
+			if _, err := defaultedPod.DeleteAndWait(podDeletedTimeout); err != nil {
+				glog.Errorf("Error deleting pod %s: %v", DefaultedPodName, err)
+			}
+		})
+
+		By("Check the container got the cpu and memory defaults and kept its GPU resources")
+		resources := defaultedPod.Object.Spec.Containers[0].Resources
+		glog.V(gpuparams.GpuLogLevel).Infof("Pod %s admitted with requests %v and limits %v", DefaultedPodName,
+			resources.Requests, resources.Limits)
+
+		gpus := *resource.NewQuantity(quotaGPUs, resource.DecimalSI)
+		checkResources(resources.Requests, limitRangeRequests, gpus)
+		checkResources(resources.Limits, limitRangeLimits, gpus)
+
+		err = defaultedPod.WaitUntilRunning(podRunningTimeout)
+		Expect(err).ToNot(HaveOccurred(), "pod %s is not running: %v", DefaultedPodName, err)
+
+		checkVectorAdd(defaultedPod)
+	})
+})
+
+// checkVectorAdd waits for the vectorAdd run of the GPU pod and checks it passed.
+func checkVectorAdd(gpuPod *pod.Builder) {
+	By(fmt.Sprintf("Check vectorAdd passed in pod %s", gpuPod.Definition.Name))
+
+	err := await.Match(context.TODO(), fmt.Sprintf("vectorAdd to pass in pod %s", gpuPod.Definition.Name),
+		vectorAddPollDelay, vectorAddTimeout, func(context.Context) (interface{}, error) {
+			output, err := gpuPod.GetFullLog(quota.WorkloadContainerName)
+			if err != nil {
+				return false, err
+			}
+
+			result, err := cudasamples.ParseVectorAdd(output)
+			if err != nil {
+				return false, err
+			}
+
+			return result.Passed, nil
+		}, BeTrue())
+	Expect(err).ToNot(HaveOccurred(), "vectorAdd did not pass in pod %s: %v", gpuPod.Definition.Name, err)
+}
+
+// checkResources checks the container resources are the LimitRange defaults plus the GPUs, no GPU amount being
+// defaulted nor altered by the LimitRange.
+func checkResources(resources, defaults corev1.ResourceList, gpus resource.Quantity) {
+	expected := defaults.DeepCopy()
+	expected[cudasamples.GPUResource] = gpus
+
+	Expect(resources).To(HaveLen(len(expected)), "unexpected container resources %v, expected %v", resources,
+		expected)
+
+	for name, quantity := range expected {
+		actual, found := resources[name]
+		Expect(found).To(BeTrue(), "container resource %s was not set", name)
+		Expect(actual.Cmp(quantity)).To(BeZero(), "container resource %s is %s, expected %s", name,
+			actual.String(), quantity.String())
+	}
+}
@@ -0,0 +1,193 @@
+package reinstall
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/autoscaling"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reinstall"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace of the debug pods and of the workload of the reinstalled operator
+	TestNamespace = "test-gpu-reinstall"
+	// WorkloadName is the name of the CUDA workload run on the reinstalled operator
+	WorkloadName = "reinstall-workload"
+	// WorkloadImage is the CUDA container image of the workload
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+	// DebugImage is the container image of the privileged debug pods checking the hosts
+	DebugImage = "registry.access.redhat.com/ubi9/ubi:latest"
+
+	uninstallPollInterval     = 15 * time.Second
+	uninstallTimeout          = 20 * time.Minute
+	nodeCleanupTimeout        = 10 * time.Minute
+	debugPodTimeout           = 5 * time.Minute
+	clusterPolicyReadyTimeout = 30 * time.Minute
+	workloadTimeout           = 5 * time.Minute
+)
+
+var _ = Describe("Reinstall", Ordered, Label(tsparams.LabelSuite, "reinstall"), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.DisruptsCluster), func() {
+	var (
+		nsBuilder    *namespace.Builder
+		state        *reinstall.OperatorState
+		gpuNodeNames []string
+		gpuSelector  labels.Set
+		uninstalled  bool
+		reinstalled  bool
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting GPU operator uninstall and reinstall test suite")
+
+		var err error
+		state, err = reinstall.RecordOperator(inittools.APIClient)
+		if err != nil {
+			Skip(fmt.Sprintf("The GPU operator must be deployed with a Subscription and a ClusterPolicy first: %v",
+				err))
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Recorded GPU operator CSV '%s' of channel '%s' from catalog '%s'",
+			state.InstalledCSV, state.Channel, state.CatalogSource)
+
+		By("Check the workload and debug images are reachable")
+		Expect(disconnected.CheckImages(WorkloadImage, DebugImage)).ToNot(HaveOccurred(),
+			"the workload images are not reachable through the mirrors")
+
+		By("Find the GPU worker nodes")
+		gpuSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			gpuSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: gpuSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		for _, gpuNode := range gpuNodes {
+			gpuNodeNames = append(gpuNodeNames, gpuNode.Object.Name)
+		}
+
+		// The namespace must allow the privileged debug pods checking the hosts.
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace).
+			WithMultipleLabels(params.PrivilegedNSLabels)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if uninstalled && !reinstalled {
+			By("Deploy the uninstalled GPU operator again")
+			if _, err := reinstall.Install(inittools.APIClient, state, uninstallPollInterval,
+				nvidiagpu.CsvSucceededTimeout); err != nil {
+				glog.Errorf("Error deploying the GPU operator again: %v", err)
+			}
+		}
+
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.DeleteAndWait(workloadTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should uninstall the GPU operator", Label("reinstall-uninstall"), func() {
+		By("Delete the ClusterPolicy, Subscription, CSV and namespace of the GPU operator")
+		uninstalled = true
+		err := reinstall.Uninstall(inittools.APIClient, uninstallPollInterval, uninstallTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error uninstalling the GPU operator: %v", err)
+	})
+
+	It("Should not leave operand daemonsets behind", Label("reinstall-leftover-daemonsets"), func() {
+		if !uninstalled {
+			Skip("The GPU operator was not uninstalled")
+		}
+
+		daemonSets, err := reinstall.LeftoverDaemonSets(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error listing the daemonsets: %v", err)
+		Expect(daemonSets).To(BeEmpty(), "daemonsets of the ClusterPolicy are left after the uninstall")
+	})
+
+	It("Should not leave GPU operator labels or resources on the nodes", Label("reinstall-leftover-labels"),
+		func() {
+			if !uninstalled {
+				Skip("The GPU operator was not uninstalled")
+			}
+
+			leftovers, err := reinstall.NodesCleaned(inittools.APIClient, gpuNodeNames, uninstallPollInterval,
+				nodeCleanupTimeout)
+			Expect(err).ToNot(HaveOccurred(), "GPU operator node state is left after the uninstall: %v", leftovers)
+		})
+
+	It("Should not leave runtime config on the nodes", Label("reinstall-leftover-runtime"), func() {
+		if !uninstalled {
+			Skip("The GPU operator was not uninstalled")
+		}
+
+		runtimeClasses, err := reinstall.LeftoverRuntimeClasses(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error listing the runtime classes: %v", err)
+		Expect(runtimeClasses).To(BeEmpty(), "NVIDIA runtime classes are left after the uninstall")
+
+		for index, nodeName := range gpuNodeNames {
+			By(fmt.Sprintf("Check the host runtime config of node %s", nodeName))
+			leftovers, err := reinstall.HostLeftovers(inittools.APIClient, fmt.Sprintf("reinstall-host-check-%d",
+				index), nodeName, TestNamespace, disconnected.Image(DebugImage), debugPodTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error checking the host of node %s: %v", nodeName, err)
+			Expect(leftovers).To(BeEmpty(), "runtime config is left on node %s after the uninstall", nodeName)
+		}
+	})
+
+	It("Should deploy the GPU operator cleanly again", Label("reinstall-deploy"), func() {
+		if !uninstalled {
+			Skip("The GPU operator was not uninstalled")
+		}
+
+		By(fmt.Sprintf("Deploy CSV %s and the recorded ClusterPolicy again", state.InstalledCSV))
+		reinstalled = true
+		installedCSV, err := reinstall.Install(inittools.APIClient, state, uninstallPollInterval,
+			nvidiagpu.CsvSucceededTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error deploying the GPU operator again: %v", err)
+		glog.V(gpuparams.GpuLogLevel).Infof("GPU operator CSV '%s' deployed again", installedCSV)
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "ClusterPolicy %s is not ready after the reinstall: %v",
+			nvidiagpu.ClusterPolicyName, err)
+
+		restarted, err := reinstall.RestartedContainers(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error listing the GPU operator pods: %v", err)
+		Expect(restarted).To(BeEmpty(), "GPU operator containers restarted during the second deployment")
+
+		By(fmt.Sprintf("Run CUDA workload %s", WorkloadName))
+		podBuilder, err := autoscaling.NewWorkloadPod(inittools.APIClient, WorkloadName, TestNamespace,
+			disconnected.Image(WorkloadImage), gpuSelector, 0).Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", WorkloadName, err)
+
+		err = podBuilder.WaitUntilInStatus(corev1.PodSucceeded, workloadTimeout)
+		Expect(err).ToNot(HaveOccurred(), "pod %s did not complete: %v", WorkloadName, err)
+
+		logs, err := podBuilder.GetFullLog(autoscaling.WorkloadContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error getting pod %s logs: %v", WorkloadName, err)
+		Expect(logs).To(ContainSubstring("GPU 0"), "pod %s did not get a GPU", WorkloadName)
+	})
+})
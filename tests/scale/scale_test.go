@@ -0,0 +1,130 @@
+package scale
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/scale"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace of the GPU pods of the scale test
+	TestNamespace = "test-gpu-scale"
+	// PodPrefix prefixes the names of the GPU pods of the scale test
+	PodPrefix = "scale-workload"
+	// WorkloadImage is the CUDA container image of the GPU pods
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+
+	podPollInterval  = 10 * time.Second
+	podStartTimeout  = 5 * time.Minute
+	namespaceTimeout = 5 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("GPU Scale", Ordered, Label(tsparams.LabelSuite, "scale"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.NonDisruptive), func() {
+	var (
+		nodeSelector labels.Set
+		capacity     int64
+		nsBuilder    *namespace.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting GPU scheduling scale test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.ScaleFactor < 1 {
+			Skip(fmt.Sprintf("NVIDIAGPU_SCALE_FACTOR %d is not positive", nvidiaGPUConfig.ScaleFactor))
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		var err error
+		capacity, err = scale.GPUCapacity(inittools.APIClient, nodeSelector)
+		Expect(err).ToNot(HaveOccurred(), "error getting the GPU capacity: %v", err)
+
+		if capacity == 0 {
+			Skip("No GPU is allocatable on the GPU worker nodes")
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.DeleteAndWait(namespaceTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should schedule and run more GPU pods than the GPU capacity", Label("scale-scheduling"), func() {
+		podCount := int(capacity) * nvidiaGPUConfig.ScaleFactor
+		duration := nvidiaGPUConfig.ScalePodDuration
+
+		By(fmt.Sprintf("Create %d GPU pods for %d allocatable GPUs", podCount, capacity))
+		err := scale.CreatePods(inittools.APIClient, PodPrefix, TestNamespace, disconnected.Image(WorkloadImage),
+			nodeSelector, podCount, duration)
+		Expect(err).ToNot(HaveOccurred(), "error creating the GPU pods: %v", err)
+
+		By("Wait for the pod queue to drain")
+		// Every GPU runs the pods of the queue one after the other, each taking up to its start timeout to start.
+		drainTimeout := time.Duration(nvidiaGPUConfig.ScaleFactor) * (duration + podStartTimeout)
+		result, err := scale.WaitPodsCompleted(inittools.APIClient, PodPrefix, TestNamespace, podPollInterval,
+			drainTimeout)
+		Expect(err).ToNot(HaveOccurred(), "the GPU pod queue did not drain: %v", err)
+
+		reporter.RecordMetric("gpu-capacity", capacity)
+		reporter.RecordMetric("pods", result.Pods)
+		reporter.RecordMetric("scheduling-latency-p50", scale.Percentile(result.SchedulingLatencies, 50))
+		reporter.RecordMetric("scheduling-latency-p95", scale.Percentile(result.SchedulingLatencies, 95))
+		reporter.RecordMetric("scheduling-latency-max", scale.Percentile(result.SchedulingLatencies, 100))
+		reporter.RecordMetric("queue-drain-time", result.DrainTime)
+		reporter.RecordMetric("allocation-errors", len(result.AllocationErrors))
+		reporter.RecordMetric("failed-pods", len(result.Failures))
+
+		glog.V(gpuparams.GpuLogLevel).Infof("%d pods on %d GPUs drained in %s, scheduling latency p95 %s",
+			result.Pods, capacity, result.DrainTime, scale.Percentile(result.SchedulingLatencies, 95))
+
+		Expect(result.Pods).To(Equal(podCount), "pods of the scale test are missing")
+		Expect(result.AllocationErrors).To(BeEmpty(), "the device plugin failed to allocate GPUs")
+		Expect(result.Failures).To(BeEmpty(), "GPU pods failed")
+		Expect(result.SchedulingLatencies).To(HaveLen(podCount), "GPU pods were not scheduled")
+	})
+})
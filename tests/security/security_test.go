@@ -0,0 +1,181 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/security"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace enforcing the restricted Pod Security Admission level
+	TestNamespace = "test-security"
+	// RestrictedJobName is the name of the CUDA Job run in the restricted namespace
+	RestrictedJobName = "cuda-vectoradd-restricted"
+	// PrivilegedPodName is the name of the privileged pod the restricted namespace must reject
+	PrivilegedPodName = "privileged-rejected"
+
+	workloadSuccessTimeout = 10 * time.Minute
+)
+
+var _ = Describe("Security", Ordered, Label(tsparams.LabelSuite, "security"), cilabels.Spec(
+	cilabels.Regression, cilabels.Short, cilabels.NonDisruptive), func() {
+	var (
+		nodeSelector labels.Set
+		nsBuilder    *namespace.Builder
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Security test suite")
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should run the GPU operator namespace at the privileged PSA level", Label("security-namespace-psa"), func() {
+		operatorNs, err := namespace.Pull(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling namespace %s: %v", nvidiagpu.NvidiaGPUNamespace, err)
+
+		level := operatorNs.Object.Labels[security.PSAEnforceLabel]
+		glog.V(gpuparams.GpuLogLevel).Infof("Namespace %s enforces PSA level %q", nvidiagpu.NvidiaGPUNamespace,
+			level)
+		Expect(level).To(Equal(security.PSAPrivileged),
+			"namespace %s must enforce the privileged PSA level for the operand DaemonSets",
+			nvidiagpu.NvidiaGPUNamespace)
+	})
+
+	It("Should limit the privileged operand pods to the documented DaemonSets", Label("security-operand-scc"), func() {
+		operandPods, err := pod.List(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error listing pods in namespace %s: %v",
+			nvidiagpu.NvidiaGPUNamespace, err)
+		Expect(operandPods).ToNot(BeEmpty(), "no pod found in namespace %s", nvidiagpu.NvidiaGPUNamespace)
+
+		var (
+			evidence   []string
+			violations []string
+		)
+
+		for _, operandPod := range operandPods {
+			podSecurity := security.Describe(operandPod.Object)
+			evidence = append(evidence, podSecurity.String())
+			violations = append(violations, podSecurity.Violations()...)
+
+			if podSecurity.OwnerKind == "Deployment" && podSecurity.OwnerName == nvidiagpu.OperatorDeployment {
+				Expect(podSecurity.Privileged).To(BeFalse(), "the GPU operator pod %s runs privileged",
+					podSecurity.Name)
+				Expect(podSecurity.SCC).ToNot(Equal(security.PrivilegedSCC),
+					"the GPU operator pod %s runs under the privileged SCC", podSecurity.Name)
+			}
+		}
+
+		glog.V(gpuparams.GpuLogLevel).Infof("GPU operator pod security profiles:\n%s",
+			strings.Join(evidence, "\n"))
+		Expect(violations).To(BeEmpty(), "GPU operator pods break the security expectations:\n%s",
+			strings.Join(violations, "\n"))
+	})
+
+	It("Should run a GPU workload in a restricted namespace", Label("security-restricted-workload"), func() {
+		By(fmt.Sprintf("Create namespace %s enforcing the restricted PSA level", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.RestrictedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+
+		By(fmt.Sprintf("Run cuda vectorAdd Job %s", RestrictedJobName))
+		sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, RestrictedJobName, TestNamespace,
+			cudasamples.VectorAdd, disconnected.Image(cudasamples.VectorAddImage)).
+			WithNodeSelector(nodeSelector).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", RestrictedJobName, err)
+
+		DeferCleanup(func() {
+			if err := sampleBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting Job %s: %v", RestrictedJobName, err)
+			}
+		})
+
+		err = sampleBuilder.WaitUntilComplete(workloadSuccessTimeout)
+		Expect(err).ToNot(HaveOccurred(), "Job %s did not succeed: %v", RestrictedJobName, err)
+
+		result, err := sampleBuilder.GetResult()
+		Expect(err).ToNot(HaveOccurred(), "error getting Job %s result: %v", RestrictedJobName, err)
+		Expect(result.Passed).To(BeTrue(), "cuda vectorAdd failed in the restricted namespace")
+
+		samplePod, err := pod.Pull(inittools.APIClient, result.PodName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", result.PodName, err)
+
+		podSecurity := security.Describe(samplePod.Object)
+		glog.V(gpuparams.GpuLogLevel).Infof("Restricted GPU workload %s", podSecurity)
+		Expect(podSecurity.SCC).To(Equal(security.RestrictedSCC), "pod %s was not admitted under the %s SCC",
+			result.PodName, security.RestrictedSCC)
+	})
+
+	It("Should reject privileged pods in the restricted namespace", Label("security-restricted-reject"), func() {
+		if nsBuilder == nil || !nsBuilder.Exists() {
+			Skip(fmt.Sprintf("Namespace %s was not created", TestNamespace))
+		}
+
+		isTrue := true
+		privilegedPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: PrivilegedPodName, Namespace: TestNamespace},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:            "privileged-ctr",
+					Image:           disconnected.Image(cudasamples.VectorAddImage),
+					SecurityContext: &corev1.SecurityContext{Privileged: &isTrue},
+				}},
+			},
+		}
+
+		By(fmt.Sprintf("Create privileged pod %s with a server dry run", PrivilegedPodName))
+		_, err := inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), privilegedPod,
+			metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+		Expect(err).To(HaveOccurred(), "namespace %s admitted privileged pod %s", TestNamespace, PrivilegedPodName)
+		glog.V(gpuparams.GpuLogLevel).Infof("Privileged pod %s rejected: %v", PrivilegedPodName, err)
+	})
+})
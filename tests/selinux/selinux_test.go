@@ -0,0 +1,169 @@
+package selinux
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpudirect"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/selinux"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the SELinux testcases run
+	TestNamespace = "test-selinux"
+	// DebugImage is the container image of the privileged debug pod listing the device contexts on the host
+	DebugImage = "registry.access.redhat.com/ubi9/ubi:latest"
+	// CustomLevel is the MCS level of the workload run with custom categories
+	CustomLevel = "s0:c27,c842"
+
+	devicePodTimeout       = 5 * time.Minute
+	workloadSuccessTimeout = 10 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("SELinux", Ordered, Label(tsparams.LabelSuite, "selinux"), cilabels.Spec(
+	cilabels.Regression, cilabels.Short, cilabels.NonDisruptive), func() {
+	var (
+		gpuNodes     []*nodes.Builder
+		nodeSelector labels.Set
+		nsBuilder    *namespace.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting SELinux test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the CUDA sample and debug images are reachable")
+		Expect(disconnected.CheckImages(cudasamples.VectorAddImage, DebugImage)).ToNot(HaveOccurred(),
+			"the CUDA sample and debug images are not reachable through the mirrors")
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		var err error
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By(fmt.Sprintf("Create the privileged namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			createdNs, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+
+			_, err = createdNs.WithMultipleLabels(params.PrivilegedNSLabels).Update()
+			Expect(err).ToNot(HaveOccurred(), "error labeling namespace %s: %v", TestNamespace, err)
+		}
+
+		// The restricted SCCs assign the namespace SELinux context, custom contexts need the privileged SCC.
+		err = gpudirect.CreateRDMAServiceAccount(inittools.APIClient, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error creating the privileged service account: %v", err)
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should label the GPU device nodes for container access", Label("selinux-device-labels"), func() {
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+
+			By(fmt.Sprintf("List the SELinux contexts of the GPU devices of node %s", nodeName))
+			nodeDevices, err := selinux.GetNodeDevices(inittools.APIClient, nodeName, TestNamespace,
+				disconnected.Image(DebugImage), devicePodTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error listing node %s GPU device contexts: %v", nodeName, err)
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Node %s SELinux mode %s, GPU device contexts %v", nodeName,
+				nodeDevices.Mode, nodeDevices.Devices)
+			Expect(nodeDevices.Mode).To(Equal(selinux.EnforcingMode), "node %s does not enforce SELinux", nodeName)
+			Expect(nodeDevices.Devices).To(HaveKey("/dev/nvidiactl"), "node %s has no /dev/nvidiactl", nodeName)
+
+			for path, context := range nodeDevices.Devices {
+				Expect(context.Type).To(Equal(nvidiaGPUConfig.SELinuxDeviceType),
+					"node %s device %s is labelled %s", nodeName, path, context)
+			}
+		}
+	})
+
+	It("Should run a GPU workload as container_t", Label("selinux-container-t"), func() {
+		runCUDAWorkload("cuda-selinux-container-t", &corev1.SELinuxOptions{Type: selinux.ContainerType},
+			nodeSelector)
+	})
+
+	It("Should run a GPU workload with custom MCS categories", Label("selinux-custom-level"), func() {
+		runCUDAWorkload("cuda-selinux-custom-level",
+			&corev1.SELinuxOptions{Type: selinux.ContainerType, Level: CustomLevel}, nodeSelector)
+	})
+
+	It("Should run a GPU workload with a custom SELinux type", Label("selinux-custom-type"), func() {
+		if nvidiaGPUConfig.SELinuxCustomType == "" {
+			Skip("No custom SELinux type set in NVIDIAGPU_SELINUX_CUSTOM_TYPE")
+		}
+
+		runCUDAWorkload("cuda-selinux-custom-type", &corev1.SELinuxOptions{Type: nvidiaGPUConfig.SELinuxCustomType},
+			nodeSelector)
+	})
+})
+
+// runCUDAWorkload runs a CUDA vectorAdd Job with the SELinux context and checks that it accessed the GPU.
+func runCUDAWorkload(jobName string, seLinuxOptions *corev1.SELinuxOptions, nodeSelector map[string]string) {
+	By(fmt.Sprintf("Run cuda vectorAdd Job %s with SELinux options %v", jobName, seLinuxOptions))
+	sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, jobName, TestNamespace, cudasamples.VectorAdd,
+		disconnected.Image(cudasamples.VectorAddImage)).
+		WithNodeSelector(nodeSelector).
+		WithServiceAccount(gpudirect.RDMAServiceAccount).
+		WithSELinuxOptions(seLinuxOptions).
+		Create()
+	Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", jobName, err)
+
+	defer func() {
+		if err := sampleBuilder.Delete(); err != nil {
+			glog.Errorf("Error deleting Job %s: %v", jobName, err)
+		}
+	}()
+
+	err = sampleBuilder.WaitUntilComplete(workloadSuccessTimeout)
+	Expect(err).ToNot(HaveOccurred(), "Job %s did not succeed: %v", jobName, err)
+
+	result, err := sampleBuilder.GetResult()
+	Expect(err).ToNot(HaveOccurred(), "error getting Job %s result: %v", jobName, err)
+	Expect(result.Passed).To(BeTrue(), "cuda vectorAdd failed with SELinux options %v", seLinuxOptions)
+}
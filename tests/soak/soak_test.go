@@ -0,0 +1,261 @@
+package soak
+
+import (
+	"fmt"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/dcgmexporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/soak"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/prometheus"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/gpuburn"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8swait "k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// TestNamespace is the namespace where the gpu-burn Jobs of the soak cycles run
+	TestNamespace = "test-gpu-soak"
+	// BurnJobName prefixes the names of the gpu-burn Jobs of the soak cycles
+	BurnJobName = "gpu-soak"
+	// SnapshotDir is the directory of the metrics snapshots in the reports directory
+	SnapshotDir = "soak-metrics"
+
+	checkInterval             = 5 * time.Minute
+	clusterPolicyReadyTimeout = 15 * time.Minute
+	namespaceTimeout          = 5 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("GPU Soak", Ordered, Label(tsparams.LabelSuite, "soak"), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.NonDisruptive), func() {
+	var (
+		gpuNodes         []*nodes.Builder
+		nodeSelector     labels.Set
+		nsBuilder        *namespace.Builder
+		jobBuilder       *gpuburn.Builder
+		previousSpec     *nvidiagpuv1.ClusterPolicySpec
+		prometheusClient *prometheus.Client
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting GPU Soak test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if !clusterPolicyBuilder.Definition.Spec.DCGMExporter.IsEnabled() {
+			Skip(fmt.Sprintf("The DCGM exporter is disabled in ClusterPolicy '%s'", nvidiagpu.ClusterPolicyName))
+		}
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By("Enable the DCGM exporter ServiceMonitor in the ClusterPolicy")
+		previousSpec, err = dcgmexporter.EnableServiceMonitor(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		Expect(err).ToNot(HaveOccurred(), "error enabling the DCGM exporter ServiceMonitor: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		if previousSpec != nil {
+			err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+		}
+
+		prometheusClient, err = prometheus.NewClient(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error creating the Prometheus client: %v", err)
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if jobBuilder != nil {
+			if err := jobBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting gpu-burn Job %s: %v", jobBuilder.Definition.Name, err)
+			}
+		}
+
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.DeleteAndWait(namespaceTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			}
+		}
+	})
+
+	It("Should cycle GPU workloads for the soak duration without GPU health issue or XID error",
+		Label("soak-cycles"), func() {
+			clusterArch, err := get.GetClusterArchitecture(inittools.APIClient, nodeSelector)
+			Expect(err).ToNot(HaveOccurred(), "error getting the GPU nodes architecture: %v", err)
+
+			burnImage, err := gpuburn.Images.Image(clusterArch)
+			Expect(err).ToNot(HaveOccurred(), "error selecting the gpu-burn image: %v", err)
+
+			By("Check the gpu-burn image is reachable")
+			Expect(disconnected.CheckImages(burnImage)).ToNot(HaveOccurred(),
+				"the gpu-burn image is not reachable through the mirrors")
+			reporter.RecordImageDigests(clusterArch, burnImage)
+			burnImage = disconnected.Image(burnImage)
+
+			gpusPerNode := gpuburn.GPUsPerNode(gpuNodes)
+
+			if gpusPerNode == 0 {
+				Skip("A GPU node does not report its GPU count")
+			}
+
+			snapshotDir := inittools.GeneralConfig.GetReportPath(SnapshotDir)
+			DeferCleanup(func() {
+				reporter.IndexArtifact(reporter.SnapshotArtifact, snapshotDir, CurrentSpecReport().FullText(), "")
+				reporter.RecordArtifact(snapshotDir)
+			})
+
+			var dcgmPods []*pod.Builder
+
+			// The DCGM health checks run once the health watches were enabled on the DCGM host engines.
+			checkGPUs := func() {
+				xidErrors, err := soak.XIDErrors(prometheusClient)
+				Expect(err).ToNot(HaveOccurred(), "error querying the XID errors: %v", err)
+				Expect(xidErrors).To(BeEmpty(), "GPUs hit XID errors")
+
+				if len(dcgmPods) == 0 {
+					return
+				}
+
+				unhealthy, err := soak.UnhealthyGPUs(dcgmPods)
+				Expect(err).ToNot(HaveOccurred(), "error checking the DCGM health: %v", err)
+				Expect(unhealthy).To(BeEmpty(), "DCGM reports unhealthy GPUs")
+			}
+
+			dcgmPods, err = soak.DCGMPods(inittools.APIClient)
+			Expect(err).ToNot(HaveOccurred(), "error listing the DCGM pods: %v", err)
+
+			if len(dcgmPods) == 0 {
+				glog.V(gpuparams.GpuLogLevel).Info("The standalone DCGM host engine is not deployed, " +
+					"only checking the XID errors")
+			} else {
+				By("Enable the DCGM health watches")
+				Expect(soak.EnableHealthWatches(dcgmPods)).To(Succeed(), "error enabling the DCGM health watches")
+			}
+
+			By("Snapshot the metrics of the idle GPUs")
+			firstSnapshot, err := soak.TakeSnapshot(prometheusClient, snapshotDir)
+			Expect(err).ToNot(HaveOccurred(), "error taking the metrics snapshot: %v", err)
+			lastSnapshot := firstSnapshot.Time
+
+			deadline := time.Now().Add(nvidiaGPUConfig.SoakDuration)
+			cycle := 0
+
+			for ; time.Now().Before(deadline); cycle++ {
+				burnDuration := min(nvidiaGPUConfig.SoakCycleDuration, time.Until(deadline))
+				jobName := fmt.Sprintf("%s-%d", BurnJobName, cycle)
+
+				By(fmt.Sprintf("Burn %d GPU(s) of %d node(s) for %s in cycle %d", gpusPerNode, len(gpuNodes),
+					burnDuration.Round(time.Second), cycle))
+				jobBuilder, err = gpuburn.NewAcrossNodes(inittools.APIClient, jobName, TestNamespace,
+					burnImage, gpuNodes).
+					WithDuration(burnDuration).
+					WithNodeSelector(nodeSelector).
+					Create()
+				Expect(err).ToNot(HaveOccurred(), "error creating gpu-burn Job %s: %v", jobName, err)
+
+				// Leave the pods time to pull the image and get scheduled on top of the burn itself.
+				cycleDeadline := time.Now().Add(burnDuration + nvidiagpu.BurnPodCreationTimeout)
+
+				for {
+					waitErr := jobBuilder.WaitUntilComplete(checkInterval)
+
+					checkGPUs()
+
+					if time.Since(lastSnapshot) >= nvidiaGPUConfig.SoakSnapshotInterval {
+						snapshot, err := soak.TakeSnapshot(prometheusClient, snapshotDir)
+						Expect(err).ToNot(HaveOccurred(), "error taking the metrics snapshot: %v", err)
+						lastSnapshot = snapshot.Time
+					}
+
+					if waitErr == nil {
+						break
+					}
+
+					Expect(k8swait.Interrupted(waitErr)).To(BeTrue(), "gpu-burn Job %s failed: %v", jobName, waitErr)
+					Expect(time.Now().Before(cycleDeadline)).To(BeTrue(), "gpu-burn Job %s did not complete",
+						jobName)
+				}
+
+				reports, err := jobBuilder.GetReports()
+				Expect(err).ToNot(HaveOccurred(), "error getting the gpu-burn reports: %v", err)
+
+				for _, report := range reports {
+					for _, gpu := range report.GPUs {
+						Expect(gpu.Result).To(Equal("OK"), "gpu-burn found node %s GPU %s faulty in cycle %d",
+							report.NodeName, gpu.Index, cycle)
+					}
+				}
+
+				Expect(jobBuilder.Delete()).To(Succeed(), "error deleting gpu-burn Job %s", jobName)
+				jobBuilder = nil
+			}
+
+			reporter.RecordMetric("cycles", cycle)
+
+			By("Snapshot the metrics of the idle GPUs after the soak")
+			finalSnapshot, err := soak.TakeSnapshot(prometheusClient, snapshotDir)
+			Expect(err).ToNot(HaveOccurred(), "error taking the metrics snapshot: %v", err)
+
+			checkGPUs()
+
+			// A framebuffer growth of the idle GPUs across the soak hints at a driver memory leak.
+			initialUsed := soak.FramebufferUsed(firstSnapshot)
+			for gpu, used := range soak.FramebufferUsed(finalSnapshot) {
+				if initial, found := initialUsed[gpu]; found {
+					reporter.RecordMetric("fb-used-growth-mib "+gpu, used-initial)
+				}
+			}
+		})
+})
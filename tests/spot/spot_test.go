@@ -0,0 +1,239 @@
+package spot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/clusterupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/sno"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/machine"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	watchwait "github.com/rh-ecosystem-edge/nvidia-ci/pkg/wait"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TestNamespace is the namespace where the GPU workload runs
+	TestNamespace = "test-gpu-spot"
+	// WorkloadDeploymentName is the name of the GPU workload deployment
+	WorkloadDeploymentName = "gpu-spot-workload"
+	// WorkloadImage is the container image of the GPU workload
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+	// MachineSetNamespace is the namespace of the spot GPU MachineSet
+	MachineSetNamespace = "openshift-machine-api"
+	// MachineSetNameSuffix is appended to the copied worker MachineSet name to name the spot GPU MachineSet
+	MachineSetNameSuffix = "spot"
+
+	workerMachineSetLabel = "machine.openshift.io/cluster-api-machine-role"
+
+	// The spot instance provisioning includes the NFD labeling of the new node
+	provisionTimeout     = 30 * time.Minute
+	replacementTimeout   = 30 * time.Minute
+	gpuStackTimeout      = 30 * time.Minute
+	workloadReadyTimeout = 15 * time.Minute
+	machineSetTimeout    = 15 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Spot", Ordered, Label(tsparams.LabelSuite, tsparams.LabelMultiNode, "spot"), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.DisruptsCluster, cilabels.MultiNode, cilabels.MachineSet), func() {
+	var (
+		nsBuilder         *namespace.Builder
+		machineSetBuilder *machine.SetBuilder
+		workloadBuilder   *deployment.Builder
+		spotNodeName      string
+		workloadRunning   bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting spot instance interruption test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.InstanceType == "" {
+			Skip("NVIDIAGPU_GPU_MACHINESET_INSTANCE_TYPE is not set, no spot GPU MachineSet to create")
+		}
+
+		singleNode, err := sno.IsSingleNode(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error detecting Single-Node OpenShift: %v", err)
+
+		if singleNode {
+			Skip("Single-Node OpenShift has no worker MachineSet to provision spot GPU nodes from")
+		}
+
+		hostedCluster, err := inittools.APIClient.IsHostedCluster()
+		Expect(err).ToNot(HaveOccurred(), "error detecting a HyperShift hosted cluster: %v", err)
+
+		if hostedCluster {
+			Skip("HyperShift hosted clusters have no MachineSet to provision spot GPU nodes from")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if workloadBuilder != nil {
+			if err := workloadBuilder.DeleteAndWait(workloadReadyTimeout); err != nil {
+				glog.Errorf("Error deleting deployment %s: %v", WorkloadDeploymentName, err)
+			}
+		}
+
+		if machineSetBuilder != nil && machineSetBuilder.Exists() {
+			if err := machineSetBuilder.DeleteAndWait(machineSetTimeout); err != nil {
+				glog.Errorf("Error deleting MachineSet %s: %v", machineSetBuilder.Definition.Name, err)
+			}
+		}
+
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should run a GPU workload on a spot GPU node", Label("spot-workload"), func() {
+		By(fmt.Sprintf("Create a spot GPU MachineSet of instance type %s", nvidiaGPUConfig.InstanceType))
+		var err error
+		machineSetBuilder, err = machine.NewSetBuilderFromCopy(inittools.APIClient, MachineSetNamespace,
+			nvidiaGPUConfig.InstanceType, workerMachineSetLabel, 1).
+			WithNameSuffix(MachineSetNameSuffix).
+			WithSpotInstances().
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating the spot GPU MachineSet: %v", err)
+
+		machineSetName := machineSetBuilder.Definition.Name
+
+		By("Wait for the spot GPU node to join the cluster and be labeled by NFD")
+		spotNodes, err := machine.WaitForMachineSetNodes(inittools.APIClient, machineSetName, MachineSetNamespace,
+			nvidiagpu.NvidiaGPULabel, provisionTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for the spot GPU node: %v", err)
+		Expect(spotNodes).To(HaveLen(1), "MachineSet %s must have a single node", machineSetName)
+
+		spotNodeName = spotNodes[0].Object.Name
+		Expect(spotNodes[0].Object.Labels).To(HaveKey(machine.InterruptibleInstanceLabel),
+			"node %s is not an interruptible instance", spotNodeName)
+
+		expectGPUStackReady(spotNodeName)
+
+		By("Start a GPU workload on the spot GPU node")
+		workloadBuilder, err = clusterupgrade.NewWorkloadDeployment(inittools.APIClient, WorkloadDeploymentName,
+			TestNamespace, disconnected.Image(WorkloadImage)).
+			WithNodeSelector(map[string]string{machine.InterruptibleInstanceLabel: ""}).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating deployment %s: %v", WorkloadDeploymentName, err)
+
+		expectWorkloadRunningOn(workloadBuilder, spotNodeName)
+		workloadRunning = true
+	})
+
+	It("Should recover the GPU stack and the workload on the replacement of an interrupted spot node",
+		Label("spot-interruption"), func() {
+			if !workloadRunning {
+				Skip("The GPU workload is not running on a spot GPU node")
+			}
+
+			machineSetName := machineSetBuilder.Definition.Name
+
+			machines, err := machine.ListMachines(inittools.APIClient, machineSetName, MachineSetNamespace)
+			Expect(err).ToNot(HaveOccurred(), "error listing the Machines of MachineSet %s: %v", machineSetName, err)
+			Expect(machines).To(HaveLen(1), "MachineSet %s must have a single Machine", machineSetName)
+
+			interrupted := machines[0]
+
+			By(fmt.Sprintf("Simulate the interruption of spot node %s by deleting Machine %s", spotNodeName,
+				interrupted.Name))
+			err = machine.DeleteMachine(inittools.APIClient, interrupted.Name, MachineSetNamespace)
+			Expect(err).ToNot(HaveOccurred(), "error deleting Machine %s: %v", interrupted.Name, err)
+
+			By("Wait for the MachineSet to replace the interrupted spot node")
+			replacementNodes, err := machine.WaitForMachineReplaced(inittools.APIClient, interrupted,
+				nvidiagpu.NvidiaGPULabel, replacementTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error waiting for the replacement node: %v", err)
+			Expect(replacementNodes).To(HaveLen(1), "MachineSet %s must have a single node", machineSetName)
+
+			replacementNodeName := replacementNodes[0].Object.Name
+			Expect(replacementNodeName).ToNot(Equal(spotNodeName), "the interrupted node was not replaced")
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Spot node %s was replaced by node %s", spotNodeName,
+				replacementNodeName)
+
+			expectGPUStackReady(replacementNodeName)
+			expectWorkloadRunningOn(workloadBuilder, replacementNodeName)
+		})
+})
+
+// expectGPUStackReady waits for the GPU operator to reconcile the GPU stack on the node and the ClusterPolicy to be
+// ready.
+func expectGPUStackReady(nodeName string) {
+	By(fmt.Sprintf("Wait for the GPU operator to deploy the GPU stack on node %s", nodeName))
+	ctx, cancel := context.WithTimeout(context.TODO(), gpuStackTimeout)
+	defer cancel()
+
+	err := watchwait.NodeLabel(ctx, inittools.APIClient, nodeName, "nvidia.com/gpu.present", "true")
+	Expect(err).ToNot(HaveOccurred(), "node %s was not labeled by GPU feature discovery: %v", nodeName, err)
+
+	err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, gpuStackTimeout)
+	Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+}
+
+// expectWorkloadRunningOn checks that the workload deployment is ready and its pods run on the node and list their
+// GPU.
+func expectWorkloadRunningOn(workloadBuilder *deployment.Builder, nodeName string) {
+	By(fmt.Sprintf("Wait for deployment %s to be ready on node %s", WorkloadDeploymentName, nodeName))
+	Expect(workloadBuilder.IsReady(workloadReadyTimeout)).To(BeTrue(), "deployment %s is not ready",
+		WorkloadDeploymentName)
+
+	var workloadPods []*pod.Builder
+
+	err := await.Match(context.TODO(), fmt.Sprintf("the workload pods to be rescheduled on node %s", nodeName),
+		10*time.Second, workloadReadyTimeout, func(context.Context) (interface{}, error) {
+			var err error
+			workloadPods, err = pod.List(inittools.APIClient, TestNamespace,
+				metav1.ListOptions{LabelSelector: clusterupgrade.WorkloadPodLabel})
+
+			return workloadPods, err
+		}, And(Not(BeEmpty()), HaveEach(HaveField("Object.Spec.NodeName", nodeName))))
+	Expect(err).ToNot(HaveOccurred(), "the workload pods were not rescheduled on node %s: %v", nodeName, err)
+
+	for _, workloadPod := range workloadPods {
+		err := await.Match(context.TODO(), fmt.Sprintf("pod %s to list its GPU", workloadPod.Object.Name),
+			10*time.Second, time.Minute, func(context.Context) (interface{}, error) {
+				return workloadPod.GetFullLog(clusterupgrade.WorkloadContainerName)
+			}, ContainSubstring("GPU 0:"))
+		Expect(err).ToNot(HaveOccurred(), "pod %s did not list its GPU: %v", workloadPod.Object.Name, err)
+	}
+}
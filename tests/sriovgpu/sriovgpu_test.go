@@ -0,0 +1,298 @@
+package sriovgpu
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/sriovgpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/arch"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/sriov"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// TestNamespace is the namespace where the SR-IOV GPU workload pod runs
+	TestNamespace = "test-sriov-gpu"
+	// NodePolicyName is the name of the SriovNetworkNodePolicy of the suite
+	NodePolicyName = "nvidia-ci-sriov-gpu"
+	// NetworkName is the name of the SriovNetwork of the suite and of its NetworkAttachmentDefinition
+	NetworkName = "nvidia-ci-sriov-gpu-net"
+	// WorkloadPodName is the name of the pod consuming a GPU and a VF
+	WorkloadPodName = "sriov-gpu-workload"
+	// NetworkIPAM is the IPAM CNI configuration of the SriovNetwork
+	NetworkIPAM = `{"type": "host-local", "subnet": "192.168.151.0/24"}`
+
+	// sriovCapableLabel is set by NFD on the nodes with an SR-IOV capable NIC, which run the SR-IOV config daemon.
+	sriovCapableLabel = "feature.node.kubernetes.io/network-sriov.capable"
+
+	// nodePolicySyncTimeout includes the drain and reboot of the nodes the SR-IOV config daemon may need.
+	nodePolicySyncTimeout     = 45 * time.Minute
+	clusterPolicyReadyTimeout = 20 * time.Minute
+	networkCreateTimeout      = 2 * time.Minute
+	vfAllocatableTimeout      = 5 * time.Minute
+	workloadCompleteTimeout   = 10 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("SR-IOV GPU", Ordered, Label(tsparams.LabelSuite, "sriov-gpu"), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.DisruptsOperands, cilabels.SRIOV), func() {
+	var (
+		gpuNodes         []*nodes.Builder
+		nodeSelector     labels.Set
+		alignedNodeNames []string
+		nsBuilder        *namespace.Builder
+		nodePolicy       *sriov.NodePolicyBuilder
+		network          *sriov.NetworkBuilder
+		workloadNodeName string
+		workloadImage    string
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting SR-IOV GPU test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if len(nvidiaGPUConfig.SRIOVPFNames) == 0 {
+			Skip("NVIDIAGPU_SRIOV_PF_NAMES must be set to run the SR-IOV GPU tests")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if !sriov.OperatorDeployed(inittools.APIClient) {
+			Skip(fmt.Sprintf("The SR-IOV network operator is not deployed in namespace %s", sriov.OperatorNamespace))
+		}
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true", sriovCapableLabel: "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		var err error
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node with an SR-IOV capable NIC found")
+		}
+
+		By("Check the Topology Manager policy of the GPU nodes")
+		for _, gpuNode := range gpuNodes {
+			policy, err := sriovgpu.TopologyManagerPolicy(inittools.APIClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "error getting the Topology Manager policy of node %s: %v",
+				gpuNode.Object.Name, err)
+
+			if policy == sriovgpu.TopologyManagerPolicySingleNUMANode ||
+				policy == sriovgpu.TopologyManagerPolicyRestricted {
+				alignedNodeNames = append(alignedNodeNames, gpuNode.Object.Name)
+			}
+		}
+
+		if len(alignedNodeNames) == 0 {
+			Skip(fmt.Sprintf("No GPU node has the '%s' or '%s' Topology Manager policy",
+				sriovgpu.TopologyManagerPolicySingleNUMANode, sriovgpu.TopologyManagerPolicyRestricted))
+		}
+
+		clusterArch, err := arch.ClusterArchitecture(inittools.APIClient, nodeSelector)
+		Expect(err).ToNot(HaveOccurred(), "error getting the GPU nodes architecture: %v", err)
+
+		workloadImage, err = sriovgpu.WorkloadImages.Image(clusterArch)
+		Expect(err).ToNot(HaveOccurred(), "error getting the workload image: %v", err)
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(workloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+		reporter.RecordImageDigests(clusterArch, workloadImage)
+
+		By(fmt.Sprintf("Create the namespace %s", TestNamespace))
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if network != nil {
+			if err := network.Delete(); err != nil {
+				glog.Errorf("Error deleting SriovNetwork %s: %v", NetworkName, err)
+			}
+		}
+
+		if nodePolicy != nil {
+			By(fmt.Sprintf("Delete the SriovNetworkNodePolicy %s and wait for its VFs to be removed", NodePolicyName))
+			if err := nodePolicy.Delete(); err != nil {
+				glog.Errorf("Error deleting SriovNetworkNodePolicy %s: %v", NodePolicyName, err)
+			} else if err := waitNodeStatesSynced(gpuNodes); err != nil {
+				glog.Errorf("Error waiting for the SR-IOV node states to be synced: %v", err)
+			} else if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				clusterPolicyReadyTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should create the VFs of the SriovNetworkNodePolicy on the GPU nodes", Label("sriov-gpu-policy"), func() {
+		By(fmt.Sprintf("Create the SriovNetworkNodePolicy %s with %d VFs on %v", NodePolicyName,
+			nvidiaGPUConfig.SRIOVNumVFs, nvidiaGPUConfig.SRIOVPFNames))
+		var err error
+		nodePolicy, err = sriov.NewNodePolicyBuilder(inittools.APIClient, NodePolicyName, sriovgpu.ResourceName,
+			int64(nvidiaGPUConfig.SRIOVNumVFs), nvidiaGPUConfig.SRIOVPFNames, nodeSelector).Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating SriovNetworkNodePolicy %s: %v", NodePolicyName, err)
+
+		By("Wait for the SR-IOV config daemon to configure the VFs")
+		Expect(waitNodeStatesSynced(gpuNodes)).To(Succeed(), "the SR-IOV node states were not synced")
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+		By("Find a GPU node with NUMA alignment advertising both GPUs and VFs")
+		vfResourceName := corev1.ResourceName(sriov.ResourcePrefix + sriovgpu.ResourceName)
+
+		// The SR-IOV device plugin advertises the VFs shortly after the node state is synced.
+		err = await.Match(context.TODO(),
+			fmt.Sprintf("a NUMA aligned GPU node advertising both %s and nvidia.com/gpu", vfResourceName),
+			10*time.Second, vfAllocatableTimeout, func(context.Context) (interface{}, error) {
+				for _, nodeName := range alignedNodeNames {
+					nodeBuilder, err := nodes.Pull(inittools.APIClient, nodeName)
+					if err != nil {
+						return "", err
+					}
+
+					allocatable := nodeBuilder.Object.Status.Allocatable
+					vfs, gpus := allocatable[vfResourceName], allocatable["nvidia.com/gpu"]
+
+					glog.V(gpuparams.GpuLogLevel).Infof("Node '%s' allocatable VFs: %s, GPUs: %s", nodeName,
+						vfs.String(), gpus.String())
+
+					if !vfs.IsZero() && !gpus.IsZero() {
+						workloadNodeName = nodeName
+
+						return workloadNodeName, nil
+					}
+				}
+
+				return "", nil
+			}, Not(BeEmpty()))
+		Expect(err).ToNot(HaveOccurred(), "no NUMA aligned GPU node advertises both %s and nvidia.com/gpu: %v",
+			vfResourceName, err)
+	})
+
+	It("Should render the SriovNetwork as a NetworkAttachmentDefinition", Label("sriov-gpu-network"), func() {
+		By(fmt.Sprintf("Create the SriovNetwork %s in namespace %s", NetworkName, TestNamespace))
+		var err error
+		network, err = sriov.NewNetworkBuilder(inittools.APIClient, NetworkName, TestNamespace, sriovgpu.ResourceName,
+			NetworkIPAM).Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating SriovNetwork %s: %v", NetworkName, err)
+
+		err = await.Match(context.TODO(),
+			fmt.Sprintf("NetworkAttachmentDefinition %s/%s to be created", TestNamespace, NetworkName),
+			5*time.Second, networkCreateTimeout, func(context.Context) (interface{}, error) {
+				return nil, inittools.APIClient.Client.Get(context.TODO(), types.NamespacedName{Name: NetworkName,
+					Namespace: TestNamespace}, &nadv1.NetworkAttachmentDefinition{})
+			}, Succeed())
+		Expect(err).ToNot(HaveOccurred(), "NetworkAttachmentDefinition %s was not created in namespace %s: %v",
+			NetworkName, TestNamespace, err)
+	})
+
+	It("Should allocate a NUMA aligned GPU and VF to a pod", Label("sriov-gpu-numa"), func() {
+		if workloadNodeName == "" || network == nil {
+			Skip("The SR-IOV VFs or network are not ready")
+		}
+
+		By(fmt.Sprintf("Run a pod consuming a GPU and a VF on node %s", workloadNodeName))
+		workloadPod := sriovgpu.CreateWorkloadPod(WorkloadPodName, TestNamespace, disconnected.Image(workloadImage),
+			workloadNodeName, NetworkName)
+		proxy.Inject(inittools.APIClient, &workloadPod.Spec)
+		_, err := inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), workloadPod, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", WorkloadPodName, err)
+
+		var podBuilder *pod.Builder
+
+		DeferCleanup(func() {
+			if podBuilder == nil {
+				return
+			}
+
+			if _, err := podBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting pod %s: %v", WorkloadPodName, err)
+			}
+		})
+
+		err = await.Match(context.TODO(), fmt.Sprintf("pod %s to complete", WorkloadPodName),
+			10*time.Second, workloadCompleteTimeout, func(context.Context) (interface{}, error) {
+				podBuilder, err = pod.Pull(inittools.APIClient, WorkloadPodName, TestNamespace)
+				if err != nil {
+					return "", err
+				}
+
+				return podBuilder.Object.Status.Phase, nil
+			}, BeElementOf(corev1.PodSucceeded, corev1.PodFailed))
+		Expect(err).ToNot(HaveOccurred(), "pod %s did not complete: %v", WorkloadPodName, err)
+
+		// The kubelet rejects the pod with the TopologyAffinityError reason when it cannot align its devices.
+		Expect(podBuilder.Object.Status.Phase).To(Equal(corev1.PodSucceeded), "pod %s failed: %s %s",
+			WorkloadPodName, podBuilder.Object.Status.Reason, podBuilder.Object.Status.Message)
+
+		output, err := podBuilder.GetFullLog(sriovgpu.WorkloadContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error getting pod %s log: %v", WorkloadPodName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Pod %s log:\n%s", WorkloadPodName, output)
+
+		allocation, err := sriovgpu.ParseAllocation(output)
+		Expect(err).ToNot(HaveOccurred(), "error parsing pod %s log: %v", WorkloadPodName, err)
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Pod %s was allocated GPU %s on NUMA node %d and VF %s on NUMA node %d",
+			WorkloadPodName, allocation.GPUPCIAddress, allocation.GPUNUMANode, allocation.VFPCIAddress,
+			allocation.VFNUMANode)
+
+		Expect(allocation.NUMAAligned()).To(BeTrue(), "GPU %s on NUMA node %d and VF %s on NUMA node %d are not "+
+			"NUMA aligned", allocation.GPUPCIAddress, allocation.GPUNUMANode, allocation.VFPCIAddress,
+			allocation.VFNUMANode)
+	})
+})
+
+// waitNodeStatesSynced waits until the SR-IOV config daemon of every node applied the SriovNetworkNodePolicies.
+func waitNodeStatesSynced(gpuNodes []*nodes.Builder) error {
+	var nodeNames []string
+	for _, gpuNode := range gpuNodes {
+		nodeNames = append(nodeNames, gpuNode.Object.Name)
+	}
+
+	return wait.SriovNodeStatesSynced(inittools.APIClient, nodeNames, 30*time.Second, nodePolicySyncTimeout)
+}
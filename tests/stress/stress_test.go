@@ -0,0 +1,155 @@
+package stress
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/gpuburn"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the gpu-burn Job runs
+	TestNamespace = "test-gpu-stress"
+	// BurnJobName is the name of the gpu-burn Job running on all the GPU nodes
+	BurnJobName = "gpu-stress"
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("GPU Stress", Ordered, Label(tsparams.LabelSuite, "stress"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.NonDisruptive), func() {
+	var (
+		gpuNodes     []*nodes.Builder
+		nodeSelector labels.Set
+		nsBuilder    *namespace.Builder
+		jobBuilder   *gpuburn.Builder
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting GPU Stress test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		var err error
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if jobBuilder != nil {
+			if err := jobBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting gpu-burn Job %s: %v", BurnJobName, err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should burn all the GPU nodes without GPU fault or overheating", Label("stress-gpu-burn"), func() {
+		clusterArch, err := get.GetClusterArchitecture(inittools.APIClient, nodeSelector)
+		Expect(err).ToNot(HaveOccurred(), "error getting the GPU nodes architecture: %v", err)
+
+		burnImage, err := gpuburn.Images.Image(clusterArch)
+		Expect(err).ToNot(HaveOccurred(), "error selecting the gpu-burn image: %v", err)
+
+		By("Check the gpu-burn image is reachable")
+		Expect(disconnected.CheckImages(burnImage)).ToNot(HaveOccurred(),
+			"the gpu-burn image is not reachable through the mirrors")
+		reporter.RecordImageDigests(clusterArch, burnImage)
+		burnImage = disconnected.Image(burnImage)
+
+		gpusPerNode := gpuburn.GPUsPerNode(gpuNodes)
+
+		if gpusPerNode == 0 {
+			Skip("A GPU node does not report its GPU count")
+		}
+
+		By(fmt.Sprintf("Burn %d GPU(s) of %d node(s) for %s", gpusPerNode, len(gpuNodes),
+			nvidiaGPUConfig.StressDuration))
+		jobBuilder = gpuburn.NewAcrossNodes(inittools.APIClient, BurnJobName, TestNamespace, burnImage, gpuNodes).
+			WithDuration(nvidiaGPUConfig.StressDuration).
+			WithNodeSelector(nodeSelector)
+
+		_, err = jobBuilder.Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating gpu-burn Job %s: %v", BurnJobName, err)
+
+		// Leave the pods time to pull the image and get scheduled on top of the burn itself.
+		waitErr := jobBuilder.WaitUntilComplete(nvidiaGPUConfig.StressDuration + nvidiagpu.BurnPodCreationTimeout)
+
+		reports, err := jobBuilder.GetReports()
+		Expect(err).ToNot(HaveOccurred(), "error getting the gpu-burn reports: %v", err)
+
+		for _, report := range reports {
+			for _, gpu := range report.GPUs {
+				throttling := "none"
+				if len(gpu.ActiveThrottling) > 0 {
+					throttling = strings.Join(gpu.ActiveThrottling, ", ")
+				}
+
+				summary := fmt.Sprintf("node %s GPU %s: result %s, max temperature %d C, throttling %s",
+					report.NodeName, gpu.Index, gpu.Result, gpu.MaxTemperature, throttling)
+				glog.V(gpuparams.GpuLogLevel).Info(summary)
+				AddReportEntry("gpu-burn "+report.NodeName+" GPU "+gpu.Index, summary)
+			}
+		}
+
+		Expect(waitErr).ToNot(HaveOccurred(), "gpu-burn Job %s did not complete: %v", BurnJobName, waitErr)
+		Expect(reports).To(HaveLen(len(gpuNodes)), "not every GPU node reported a gpu-burn result")
+
+		for _, report := range reports {
+			Expect(report.GPUs).To(HaveLen(gpusPerNode), "node %s did not report every burnt GPU", report.NodeName)
+
+			for _, gpu := range report.GPUs {
+				Expect(gpu.Result).To(Equal("OK"), "gpu-burn found node %s GPU %s faulty", report.NodeName,
+					gpu.Index)
+				Expect(gpu.MaxTemperature).To(BeNumerically("<=", nvidiaGPUConfig.StressMaxTemperature),
+					"node %s GPU %s reached %d C", report.NodeName, gpu.Index, gpu.MaxTemperature)
+			}
+		}
+	})
+})
@@ -0,0 +1,247 @@
+package taints
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/chaos"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/mps"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/taints"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the taint testcases workloads run
+	TestNamespace = "test-taints"
+	// UntoleratedJobName is the name of the CUDA Job not tolerating the custom taint
+	UntoleratedJobName = "cuda-untolerated"
+	// ToleratedJobName is the name of the CUDA Job tolerating the custom taint
+	ToleratedJobName = "cuda-tolerated"
+
+	clusterPolicyReadyTimeout = 20 * time.Minute
+	operandPollInterval       = 30 * time.Second
+	operandTimeout            = 20 * time.Minute
+	podReplacedTimeout        = 5 * time.Minute
+	unschedulableTimeout      = 2 * time.Minute
+	workloadSuccessTimeout    = 10 * time.Minute
+)
+
+// CustomTaint is the taint the GPU operator operands only tolerate once it is added to the ClusterPolicy
+// daemonsets.tolerations.
+var CustomTaint = corev1.Taint{Key: "nvidia-ci/gpu-taint", Value: "custom", Effect: corev1.TaintEffectNoSchedule}
+
+var _ = Describe("Taints", Ordered, Label(tsparams.LabelSuite, "taints"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.DisruptsOperands), func() {
+	var (
+		nodeName          string
+		baseline          map[string]int32
+		nsBuilder         *namespace.Builder
+		previousSpec      *nvidiagpuv1.ClusterPolicySpec
+		gpuPresentTainted bool
+		customTainted     bool
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Taints test suite")
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the CUDA sample image is reachable")
+		Expect(disconnected.CheckImages(cudasamples.VectorAddImage)).ToNot(HaveOccurred(),
+			"the CUDA sample image is not reachable through the mirrors")
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		nodeName = gpuNodes[0].Object.Name
+		glog.V(gpuparams.GpuLogLevel).Infof("Tainting GPU node %s", nodeName)
+
+		By("Record the nodes every operand DaemonSet runs on")
+		baseline, err = taints.OperandScheduling(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error getting the operand DaemonSets scheduling: %v", err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Operand DaemonSets desired pods: %v", baseline)
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+
+		if customTainted {
+			if err := taints.UntaintNode(inittools.APIClient, nodeName, CustomTaint); err != nil {
+				glog.Errorf("Error removing taint %s from node %s: %v", CustomTaint.ToString(), nodeName, err)
+			}
+		}
+
+		if gpuPresentTainted {
+			if err := taints.UntaintNode(inittools.APIClient, nodeName, taints.GPUPresentTaint); err != nil {
+				glog.Errorf("Error removing taint %s from node %s: %v", taints.GPUPresentTaint.ToString(), nodeName,
+					err)
+			}
+		}
+
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			}
+
+			if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				clusterPolicyReadyTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+	})
+
+	It("Should keep the operands on a node tainted nvidia.com/gpu=present", Label("taints-gpu-present"), func() {
+		if hasTaint(nodeName, taints.GPUPresentTaint) {
+			glog.V(gpuparams.GpuLogLevel).Infof("Node %s is already tainted %s", nodeName,
+				taints.GPUPresentTaint.ToString())
+		} else {
+			By(fmt.Sprintf("Taint node %s with %s", nodeName, taints.GPUPresentTaint.ToString()))
+			err := taints.TaintNode(inittools.APIClient, nodeName, taints.GPUPresentTaint)
+			Expect(err).ToNot(HaveOccurred(), "error tainting node %s: %v", nodeName, err)
+
+			gpuPresentTainted = true
+		}
+
+		By(fmt.Sprintf("Kill the device plugin pod of node %s and wait for it to be rescheduled", nodeName))
+		previousUID, err := chaos.KillPod(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace,
+			mps.DevicePluginPodLabel, nodeName)
+		Expect(err).ToNot(HaveOccurred(), "error killing the device plugin pod of node %s: %v", nodeName, err)
+
+		_, err = chaos.NodePodReplaced(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace, mps.DevicePluginPodLabel,
+			nodeName, previousUID, operandPollInterval, podReplacedTimeout)
+		Expect(err).ToNot(HaveOccurred(), "the device plugin pod was not rescheduled on node %s: %v", nodeName, err)
+
+		By("Check every operand DaemonSet still runs on the tainted node")
+		err = taints.WaitForOperandScheduling(inittools.APIClient, baseline, operandPollInterval, operandTimeout)
+		Expect(err).ToNot(HaveOccurred(), "the operands do not tolerate %s: %v", taints.GPUPresentTaint.ToString(),
+			err)
+	})
+
+	It("Should reject a GPU workload not tolerating a custom taint", Label("taints-untolerated-workload"), func() {
+		By(fmt.Sprintf("Taint node %s with %s", nodeName, CustomTaint.ToString()))
+		err := taints.TaintNode(inittools.APIClient, nodeName, CustomTaint)
+		Expect(err).ToNot(HaveOccurred(), "error tainting node %s: %v", nodeName, err)
+
+		customTainted = true
+
+		By(fmt.Sprintf("Run cuda vectorAdd Job %s on node %s without tolerating the taint", UntoleratedJobName,
+			nodeName))
+		sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, UntoleratedJobName, TestNamespace,
+			cudasamples.VectorAdd, disconnected.Image(cudasamples.VectorAddImage)).
+			WithNodeSelector(map[string]string{corev1.LabelHostname: nodeName}).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", UntoleratedJobName, err)
+
+		DeferCleanup(func() {
+			if err := sampleBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting Job %s: %v", UntoleratedJobName, err)
+			}
+		})
+
+		err = await.Match(context.TODO(),
+			fmt.Sprintf("Job %s pod to be rejected for the untolerated taint %s", UntoleratedJobName,
+				CustomTaint.ToString()),
+			5*time.Second, unschedulableTimeout, func(context.Context) (interface{}, error) {
+				return taints.UnschedulableMessage(inittools.APIClient, TestNamespace, UntoleratedJobName)
+			}, ContainSubstring(CustomTaint.Key))
+		Expect(err).ToNot(HaveOccurred(), "Job %s pod was not rejected for the untolerated taint %s: %v",
+			UntoleratedJobName, CustomTaint.ToString(), err)
+	})
+
+	It("Should run operands and workloads tolerating the custom taint", Label("taints-custom-tolerated"), func() {
+		if !customTainted {
+			Skip(fmt.Sprintf("Node %s is not tainted with %s", nodeName, CustomTaint.ToString()))
+		}
+
+		By("Add the custom taint toleration to the ClusterPolicy daemonsets.tolerations")
+		var err error
+		previousSpec, err = taints.AddOperandTolerations(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+			taints.Toleration(CustomTaint))
+		Expect(err).ToNot(HaveOccurred(), "error adding the operand tolerations: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+		By("Check every operand DaemonSet rolled out and runs on the tainted node")
+		err = taints.WaitForOperandScheduling(inittools.APIClient, baseline, operandPollInterval, operandTimeout)
+		Expect(err).ToNot(HaveOccurred(), "the operands do not tolerate %s: %v", CustomTaint.ToString(), err)
+
+		By(fmt.Sprintf("Run cuda vectorAdd Job %s on node %s tolerating the taint", ToleratedJobName, nodeName))
+		sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, ToleratedJobName, TestNamespace,
+			cudasamples.VectorAdd, disconnected.Image(cudasamples.VectorAddImage)).
+			WithNodeSelector(map[string]string{corev1.LabelHostname: nodeName}).
+			WithToleration(taints.Toleration(CustomTaint)).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", ToleratedJobName, err)
+
+		defer func() {
+			if err := sampleBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting Job %s: %v", ToleratedJobName, err)
+			}
+		}()
+
+		err = sampleBuilder.WaitUntilComplete(workloadSuccessTimeout)
+		Expect(err).ToNot(HaveOccurred(), "Job %s did not succeed: %v", ToleratedJobName, err)
+
+		result, err := sampleBuilder.GetResult()
+		Expect(err).ToNot(HaveOccurred(), "error getting Job %s result: %v", ToleratedJobName, err)
+		Expect(result.Passed).To(BeTrue(), "cuda vectorAdd failed on the tainted node %s", nodeName)
+	})
+})
+
+// hasTaint checks whether the node has the taint, with the same key, value and effect.
+func hasTaint(nodeName string, taint corev1.Taint) bool {
+	nodeBuilder, err := nodes.Pull(inittools.APIClient, nodeName)
+	Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", nodeName, err)
+
+	for _, nodeTaint := range nodeBuilder.Object.Spec.Taints {
+		if nodeTaint.MatchTaint(&taint) && nodeTaint.Value == taint.Value {
+			return true
+		}
+	}
+
+	return false
+}
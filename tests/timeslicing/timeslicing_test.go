@@ -0,0 +1,294 @@
+package timeslicing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/timeslicing"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/cleanup"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where time-slicing workloads will run
+	TestNamespace = "test-time-slicing"
+	// DevicePluginConfigMapName is the name of the ConfigMap containing the time-slicing configs
+	DevicePluginConfigMapName = "time-slicing-config"
+	// DefaultConfigName is the cluster wide time-slicing config set in the ClusterPolicy
+	DefaultConfigName = "time-sliced-4"
+	// DefaultReplicas is the number of replicas per GPU of DefaultConfigName
+	DefaultReplicas = 4
+	// NodeConfigName is the time-slicing config selected through the node label
+	NodeConfigName = "time-sliced-8"
+	// NodeReplicas is the number of replicas per GPU of NodeConfigName
+	NodeReplicas = 8
+	// WorkloadImage is the container image of the time-slicing workload pods
+	WorkloadImage = "nvcr.io/nvidia/cuda:12.4.1-base-ubi8"
+
+	clusterPolicyReadyTimeout = 15 * time.Minute
+	allocatablePollInterval   = 15 * time.Second
+	allocatableTimeout        = 10 * time.Minute
+	workloadRunningTimeout    = 5 * time.Minute
+	pendingCheckDuration      = time.Minute
+)
+
+var _ = Describe("Time-Slicing", Ordered, Label(tsparams.LabelSuite, "time-slicing"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.DisruptsOperands), func() {
+	var (
+		nsBuilder      *namespace.Builder
+		configMap      *configmap.Builder
+		gpuNode        *nodes.Builder
+		gpuCount       int
+		previousConfig *nvidiagpuv1.DevicePluginConfig
+		configChanged  bool
+	)
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Time-Slicing test suite")
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the workload image is reachable")
+		Expect(disconnected.CheckImages(WorkloadImage)).ToNot(HaveOccurred(),
+			"the workload image is not reachable through the mirrors")
+
+		By("Find a GPU worker node")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		gpuNode = gpuNodes[0]
+		gpuCount = get.GPUCount(gpuNode)
+		Expect(gpuCount).To(BeNumerically(">", 0), "node %s has no nvidia.com/gpu.count label",
+			gpuNode.Object.Name)
+		glog.V(gpuparams.GpuLogLevel).Infof("Using node '%s' with %d GPUs for time-slicing tests",
+			gpuNode.Object.Name, gpuCount)
+
+		By("Create the time-slicing test namespace")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		By("Create the time-slicing device plugin ConfigMap")
+		configMap, err = timeslicing.CreateDevicePluginConfigMap(inittools.APIClient, DevicePluginConfigMapName,
+			nvidiagpu.NvidiaGPUNamespace, map[string]int{
+				DefaultConfigName: DefaultReplicas,
+				NodeConfigName:    NodeReplicas,
+			})
+		Expect(err).ToNot(HaveOccurred(), "error creating time-slicing ConfigMap: %v", err)
+
+		By("Point the ClusterPolicy devicePlugin.config at the time-slicing ConfigMap")
+		previousConfig, err = timeslicing.SetClusterPolicyDevicePluginConfig(inittools.APIClient,
+			nvidiagpu.ClusterPolicyName, &nvidiagpuv1.DevicePluginConfig{
+				Name:    DevicePluginConfigMapName,
+				Default: DefaultConfigName,
+			})
+		Expect(err).ToNot(HaveOccurred(), "error updating ClusterPolicy: %v", err)
+		configChanged = true
+		DeferCleanup(cleanup.Register("the ClusterPolicy devicePlugin.config", func() error {
+			_, err := timeslicing.SetClusterPolicyDevicePluginConfig(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousConfig)
+
+			return err
+		}))
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+	})
+
+	AfterEach(func() {
+		deleteWorkloadPods()
+	})
+
+	AfterAll(func() {
+		if gpuNode != nil {
+			if err := timeslicing.LabelNodeDevicePluginConfig(inittools.APIClient, gpuNode.Object.Name,
+				""); err != nil {
+				glog.Errorf("Error removing %s label from node %s: %v", timeslicing.DevicePluginConfigLabel,
+					gpuNode.Object.Name, err)
+			}
+		}
+
+		if configChanged {
+			By("Restore the original ClusterPolicy devicePlugin.config")
+			if _, err := timeslicing.SetClusterPolicyDevicePluginConfig(inittools.APIClient,
+				nvidiagpu.ClusterPolicyName, previousConfig); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy devicePlugin.config: %v", err)
+			} else if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				clusterPolicyReadyTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+
+		if configMap != nil {
+			if err := configMap.Delete(); err != nil {
+				glog.Errorf("Error deleting ConfigMap %s: %v", configMap.Object.Name, err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should advertise the configured number of replicas", Label("time-slicing-replicas"), func() {
+		expected := int64(gpuCount * DefaultReplicas)
+
+		By(fmt.Sprintf("Wait for node %s to advertise %d %s", gpuNode.Object.Name, expected,
+			timeslicing.GPUResourceName))
+		err := wait.NodeAllocatable(inittools.APIClient, gpuNode.Object.Name, timeslicing.GPUResourceName,
+			expected, allocatablePollInterval, allocatableTimeout)
+		Expect(err).ToNot(HaveOccurred(), "node %s did not advertise %d %s: %v", gpuNode.Object.Name,
+			expected, timeslicing.GPUResourceName, err)
+
+		err = await.Match(context.TODO(), fmt.Sprintf("GFD to report %d GPU replicas", DefaultReplicas),
+			allocatablePollInterval, allocatableTimeout, func(context.Context) (interface{}, error) {
+				return gpuReplicasLabel(gpuNode.Object.Name), nil
+			}, Equal(strconv.Itoa(DefaultReplicas)))
+		Expect(err).ToNot(HaveOccurred(), "GFD did not report %d GPU replicas: %v", DefaultReplicas, err)
+	})
+
+	It("Should run more GPU pods than physical GPUs", Label("time-slicing-oversubscription"), func() {
+		replicas := gpuCount * DefaultReplicas
+		Expect(replicas).To(BeNumerically(">", gpuCount))
+
+		By(fmt.Sprintf("Create %d pods requesting one %s each on %d physical GPUs", replicas,
+			timeslicing.GPUResourceName, gpuCount))
+		for i := 0; i < replicas; i++ {
+			podBuilder := createWorkloadPod(fmt.Sprintf("time-slicing-pod-%d", i), gpuNode.Object.Name)
+
+			err := podBuilder.WaitUntilRunning(workloadRunningTimeout)
+			Expect(err).ToNot(HaveOccurred(), "pod %s is not running: %v", podBuilder.Definition.Name, err)
+		}
+
+		By("Verify a pod beyond the advertised replicas stays Pending")
+		extraPod := createWorkloadPod("time-slicing-pod-extra", gpuNode.Object.Name)
+		Consistently(func() corev1.PodPhase {
+			pulledPod, err := pod.Pull(inittools.APIClient, extraPod.Definition.Name, TestNamespace)
+			if err != nil {
+				return ""
+			}
+
+			return pulledPod.Object.Status.Phase
+		}, pendingCheckDuration, 10*time.Second).Should(Equal(corev1.PodPending),
+			"pod %s should not be scheduled once all replicas are in use", extraPod.Definition.Name)
+	})
+
+	It("Should apply the per-node config selected by the device-plugin.config label",
+		Label("time-slicing-node-config"), func() {
+			By(fmt.Sprintf("Label node %s with %s=%s", gpuNode.Object.Name, timeslicing.DevicePluginConfigLabel,
+				NodeConfigName))
+			err := timeslicing.LabelNodeDevicePluginConfig(inittools.APIClient, gpuNode.Object.Name, NodeConfigName)
+			Expect(err).ToNot(HaveOccurred(), "error labeling node %s: %v", gpuNode.Object.Name, err)
+
+			expected := int64(gpuCount * NodeReplicas)
+			err = wait.NodeAllocatable(inittools.APIClient, gpuNode.Object.Name, timeslicing.GPUResourceName,
+				expected, allocatablePollInterval, allocatableTimeout)
+			Expect(err).ToNot(HaveOccurred(), "node %s did not advertise %d %s: %v", gpuNode.Object.Name,
+				expected, timeslicing.GPUResourceName, err)
+
+			err = await.Match(context.TODO(), fmt.Sprintf("GFD to report %d GPU replicas", NodeReplicas),
+				allocatablePollInterval, allocatableTimeout, func(context.Context) (interface{}, error) {
+					return gpuReplicasLabel(gpuNode.Object.Name), nil
+				}, Equal(strconv.Itoa(NodeReplicas)))
+			Expect(err).ToNot(HaveOccurred(), "GFD did not report %d GPU replicas: %v", NodeReplicas, err)
+
+			By("Remove the node label and verify the default config is applied again")
+			err = timeslicing.LabelNodeDevicePluginConfig(inittools.APIClient, gpuNode.Object.Name, "")
+			Expect(err).ToNot(HaveOccurred(), "error removing label from node %s: %v", gpuNode.Object.Name, err)
+
+			err = await.Match(context.TODO(), "the node to fall back to the default config",
+				allocatablePollInterval, allocatableTimeout, func(context.Context) (interface{}, error) {
+					return allocatableGPUs(gpuNode.Object.Name), nil
+				}, Equal(int64(gpuCount*DefaultReplicas)))
+			Expect(err).ToNot(HaveOccurred(), "node did not fall back to the default config: %v", err)
+		})
+})
+
+// createWorkloadPod creates a time-slicing workload pod pinned to the node.
+func createWorkloadPod(podName, nodeName string) *pod.Builder {
+	workloadPod := timeslicing.CreateTimeSlicingPod(podName, TestNamespace, nodeName, disconnected.Image(WorkloadImage))
+
+	proxy.Inject(inittools.APIClient, &workloadPod.Spec)
+	_, err := inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), workloadPod, metav1.CreateOptions{})
+	Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", podName, err)
+
+	podBuilder, err := pod.Pull(inittools.APIClient, podName, TestNamespace)
+	Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", podName, err)
+
+	return podBuilder
+}
+
+// deleteWorkloadPods deletes all the time-slicing workload pods from the test namespace.
+func deleteWorkloadPods() {
+	workloadPods, err := pod.List(inittools.APIClient, TestNamespace,
+		metav1.ListOptions{LabelSelector: "app=time-slicing-test-app"})
+	if err != nil {
+		glog.Errorf("Error listing pods in namespace %s: %v", TestNamespace, err)
+
+		return
+	}
+
+	for _, workloadPod := range workloadPods {
+		if _, err := workloadPod.DeleteAndWait(workloadRunningTimeout); err != nil {
+			glog.Errorf("Error deleting pod %s: %v", workloadPod.Object.Name, err)
+		}
+	}
+}
+
+func gpuReplicasLabel(nodeName string) string {
+	nodeBuilder, err := nodes.Pull(inittools.APIClient, nodeName)
+	if err != nil {
+		return ""
+	}
+
+	return nodeBuilder.Object.Labels[timeslicing.GPUReplicasLabel]
+}
+
+func allocatableGPUs(nodeName string) int64 {
+	nodeBuilder, err := nodes.Pull(inittools.APIClient, nodeName)
+	if err != nil {
+		return -1
+	}
+
+	quantity := nodeBuilder.Object.Status.Allocatable[timeslicing.GPUResourceName]
+
+	return quantity.Value()
+}
@@ -0,0 +1,130 @@
+package toolkit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nsisolation"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/params"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/toolkit"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespacePrefix is the prefix of the isolated namespace where the toolkit debug pods run
+	TestNamespacePrefix = "test-toolkit"
+	// DebugImage is the container image of the privileged debug pod reading the node toolkit configuration
+	DebugImage = "registry.access.redhat.com/ubi9/ubi:latest"
+
+	nodePodTimeout = 5 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Toolkit", Ordered, Label(tsparams.LabelSuite, "toolkit"), cilabels.Spec(
+	cilabels.Smoke, cilabels.Short, cilabels.NonDisruptive), func() {
+	var (
+		expected    *toolkit.Expected
+		nodeConfigs map[string]*toolkit.NodeConfig
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Toolkit test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if !clusterPolicyBuilder.Definition.Spec.Toolkit.IsEnabled() {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' does not deploy the container toolkit", nvidiagpu.ClusterPolicyName))
+		}
+
+		expected, err = toolkit.ExpectedFrom(&clusterPolicyBuilder.Definition.Spec)
+		Expect(err).ToNot(HaveOccurred(), "error reading the ClusterPolicy toolkit settings: %v", err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Expected toolkit configuration %+v", expected)
+
+		By("Check the debug image is reachable")
+		Expect(disconnected.CheckImages(DebugImage)).ToNot(HaveOccurred(),
+			"the debug image is not reachable through the mirrors")
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By("Create the privileged isolated namespace")
+		nsBuilder, err := nsisolation.Create(inittools.APIClient, TestNamespacePrefix, params.PrivilegedNSLabels)
+		Expect(err).ToNot(HaveOccurred(), "error creating the isolated namespace: %v", err)
+
+		By("Read the container toolkit configuration of every GPU node")
+		nodeConfigs = map[string]*toolkit.NodeConfig{}
+
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+
+			nodeConfig, err := toolkit.GetNodeConfig(inittools.APIClient, nodeName, nsBuilder.Object.Name,
+				disconnected.Image(DebugImage), expected.ToolkitDir, nodePodTimeout)
+			Expect(err).ToNot(HaveOccurred(), "error reading node %s toolkit configuration: %v", nodeName, err)
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Node %s toolkit files %v, OCI hooks %v, CRI-O runtimes %v, "+
+				"config.toml %v", nodeName, nodeConfig.Files, nodeConfig.Hooks, nodeConfig.CRIORuntimes,
+				nodeConfig.Toolkit)
+
+			nodeConfigs[nodeName] = nodeConfig
+		}
+	})
+
+	It("Should register the GPU runtime class with CRI-O", Label("toolkit-runtime-class"), func() {
+		handler, err := toolkit.RuntimeClassHandler(inittools.APIClient, expected.RuntimeClass)
+		Expect(err).ToNot(HaveOccurred(), "error getting the GPU runtime class: %v", err)
+		Expect(handler).To(Equal(expected.RuntimeClass), "RuntimeClass %s handler", expected.RuntimeClass)
+
+		for nodeName, nodeConfig := range nodeConfigs {
+			Expect(nodeConfig.RuntimeDrift(expected)).To(BeEmpty(),
+				"CRI-O of node %s does not run the GPU containers through the toolkit", nodeName)
+		}
+	})
+
+	It("Should point the container runtime to the toolkit binaries and driver root", Label("toolkit-hook-paths"),
+		func() {
+			for nodeName, nodeConfig := range nodeConfigs {
+				Expect(nodeConfig.HookDrift(expected)).To(BeEmpty(),
+					"node %s nvidia-container-runtime configuration drifted from the ClusterPolicy", nodeName)
+			}
+		})
+
+	It("Should accept the visible devices as set in the ClusterPolicy", Label("toolkit-visible-devices"), func() {
+		for nodeName, nodeConfig := range nodeConfigs {
+			Expect(nodeConfig.VisibleDevicesDrift(expected)).To(BeEmpty(),
+				"node %s accept-nvidia-visible-devices settings drifted from the ClusterPolicy", nodeName)
+		}
+	})
+})
@@ -0,0 +1,171 @@
+package triton
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/triton"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/deployment"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the Triton server and client run
+	TestNamespace = "test-triton"
+	// ServerName is the name of the Triton server deployment and service
+	ServerName = "triton-server"
+	// ModelRepositoryName is the name of the configmap holding the Triton model repository
+	ModelRepositoryName = "triton-model-repository"
+	// ClientPodName is the name of the pod sending the inference requests
+	ClientPodName = "triton-client"
+
+	// The Triton server and SDK images are several GB large
+	serverReadyTimeout    = 20 * time.Minute
+	clientCompleteTimeout = 15 * time.Minute
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Triton", Ordered, Label(tsparams.LabelSuite, "triton"), cilabels.Spec(
+	cilabels.Regression, cilabels.Medium, cilabels.NonDisruptive), func() {
+	var (
+		nodeSelector  labels.Set
+		nsBuilder     *namespace.Builder
+		serverBuilder *deployment.Builder
+		clientBuilder *pod.Builder
+		serverReady   bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Triton test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Check the Triton images are reachable")
+		Expect(disconnected.CheckImages(nvidiaGPUConfig.TritonImage,
+			nvidiaGPUConfig.TritonSDKImage)).ToNot(HaveOccurred(),
+			"the Triton images are not reachable through the mirrors")
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if clientBuilder != nil {
+			if _, err := clientBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting pod %s: %v", ClientPodName, err)
+			}
+		}
+
+		if serverBuilder != nil {
+			if err := serverBuilder.DeleteAndWait(serverReadyTimeout); err != nil {
+				glog.Errorf("Error deleting deployment %s: %v", ServerName, err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should serve a model on a GPU with Triton", Label("triton-server"), func() {
+		By(fmt.Sprintf("Create the model repository with model %s", triton.ModelName))
+		_, err := triton.CreateModelRepository(inittools.APIClient, ModelRepositoryName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error creating configmap %s: %v", ModelRepositoryName, err)
+
+		By(fmt.Sprintf("Deploy Triton with image %s", nvidiaGPUConfig.TritonImage))
+		serverBuilder, err = triton.CreateServerDeployment(inittools.APIClient, ServerName, TestNamespace,
+			disconnected.Image(nvidiaGPUConfig.TritonImage), ModelRepositoryName, nodeSelector)
+		Expect(err).ToNot(HaveOccurred(), "error creating deployment %s: %v", ServerName, err)
+
+		Expect(serverBuilder.IsReady(serverReadyTimeout)).To(BeTrue(), "deployment %s is not ready", ServerName)
+		serverReady = true
+	})
+
+	It("Should answer HTTP and gRPC inference requests", Label("triton-inference"), func() {
+		if !serverReady {
+			Skip("The Triton server is not ready")
+		}
+
+		By(fmt.Sprintf("Send the inference requests from pod %s", ClientPodName))
+		var err error
+		clientBuilder, err = triton.NewClientPod(inittools.APIClient, ClientPodName, TestNamespace,
+			disconnected.Image(nvidiaGPUConfig.TritonSDKImage), ServerName).Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", ClientPodName, err)
+
+		err = await.Match(context.TODO(), fmt.Sprintf("pod %s to complete", ClientPodName),
+			10*time.Second, clientCompleteTimeout, func(context.Context) (interface{}, error) {
+				clientPod, err := pod.Pull(inittools.APIClient, ClientPodName, TestNamespace)
+				if err != nil {
+					return "", err
+				}
+
+				return clientPod.Object.Status.Phase, nil
+			}, BeElementOf(corev1.PodSucceeded, corev1.PodFailed))
+		Expect(err).ToNot(HaveOccurred(), "pod %s did not complete: %v", ClientPodName, err)
+
+		output, err := clientBuilder.GetFullLog(triton.ClientContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error getting pod %s log: %v", ClientPodName, err)
+		glog.V(gpuparams.GpuLogLevel).Infof("Pod %s log:\n%s", ClientPodName, output)
+
+		clientBuilder, err = pod.Pull(inittools.APIClient, ClientPodName, TestNamespace)
+		Expect(err).ToNot(HaveOccurred(), "error pulling pod %s: %v", ClientPodName, err)
+		Expect(clientBuilder.Object.Status.Phase).To(Equal(corev1.PodSucceeded), "pod %s failed", ClientPodName)
+
+		result, err := triton.ParseClientOutput(output)
+		Expect(err).ToNot(HaveOccurred(), "error parsing pod %s log: %v", ClientPodName, err)
+
+		By("Validate the inference responses")
+		expected := triton.ExpectedOutputs()
+		for _, protocol := range []string{"http", "grpc"} {
+			Expect(result.Outputs).To(HaveKeyWithValue(protocol, Equal(expected)),
+				"unexpected %s inference response", protocol)
+		}
+
+		Expect(result.MetricsGPUs).To(BeNumerically(">=", 1), "Triton does not export metrics for any GPU")
+	})
+})
@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/mig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/validator"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	validationsTimeout      = 10 * time.Minute
+	validationsPollInterval = 15 * time.Second
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("Validator", Ordered, Label(tsparams.LabelSuite, "validator"), cilabels.Spec(
+	cilabels.Smoke, cilabels.Short, cilabels.NonDisruptive), func() {
+	var (
+		gpuNodes          []*nodes.Builder
+		clusterPolicySpec *nvidiagpuv1.ClusterPolicySpec
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting Validator test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		clusterPolicySpec = &clusterPolicyBuilder.Definition.Spec
+
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+	})
+
+	It("Should apply the MIG configuration requested on the GPU nodes", Label("validator-mig", cilabels.MIG), func() {
+		migNodes := 0
+
+		for _, gpuNode := range gpuNodes {
+			if _, found := gpuNode.Object.Labels[mig.MIGConfigLabel]; !found {
+				continue
+			}
+
+			migNodes++
+
+			err := await.Match(context.TODO(), fmt.Sprintf("the MIG manager to configure node %s", gpuNode.Object.Name),
+				validationsPollInterval, validationsTimeout, func(context.Context) (interface{}, error) {
+					nodeBuilder, err := nodes.Pull(inittools.APIClient, gpuNode.Object.Name)
+					if err != nil {
+						return "", err
+					}
+
+					return validator.MIGPending(nodeBuilder.Object.Labels), nil
+				}, BeEmpty())
+			Expect(err).ToNot(HaveOccurred(), "the MIG manager did not configure node %s: %v", gpuNode.Object.Name, err)
+		}
+
+		if migNodes == 0 {
+			Skip("No GPU worker node requests a MIG configuration")
+		}
+	})
+
+	It("Should run and pass every validation on the GPU nodes", Label("validator-validations"), func() {
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+
+			By(fmt.Sprintf("Check the validations of node %s", nodeName))
+			nodeValidations, err := validator.WaitForNodeValidations(inittools.APIClient, clusterPolicySpec, nodeName,
+				gpuNode.Object.Labels, validationsPollInterval, validationsTimeout)
+
+			if err != nil && nodeValidations != nil {
+				for container, log := range nodeValidations.Logs() {
+					AddReportEntry(fmt.Sprintf("validator %s %s log", nodeName, container), log,
+						ReportEntryVisibilityFailureOrVerbose)
+				}
+			}
+
+			Expect(err).ToNot(HaveOccurred(), "node %s validations are incomplete: %v", nodeName, err)
+
+			glog.V(gpuparams.GpuLogLevel).Infof("Node %s validator pod %s ran %v", nodeName,
+				nodeValidations.Pod.Object.Name, nodeValidations.Validations)
+		}
+	})
+
+	It("Should run every validation once on the GPU nodes", Label("validator-restarts"), func() {
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+
+			nodeValidations, err := validator.GetNodeValidations(inittools.APIClient, clusterPolicySpec, nodeName,
+				gpuNode.Object.Labels)
+			Expect(err).ToNot(HaveOccurred(), "error reading node %s validations: %v", nodeName, err)
+
+			for name, validation := range nodeValidations.Validations {
+				if validation.Restarts > 0 {
+					log, err := nodeValidations.Pod.GetFullLog(name)
+					if err != nil {
+						log = fmt.Sprintf("failed to get container %s log: %v", name, err)
+					}
+
+					AddReportEntry(fmt.Sprintf("validator %s %s log", nodeName, name), log,
+						ReportEntryVisibilityFailureOrVerbose)
+				}
+
+				Expect(validation.Restarts).To(BeZero(), "validation %s of node %s restarted", name, nodeName)
+			}
+		}
+	})
+})
@@ -0,0 +1,163 @@
+package vgpulicensing
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/driverupgrade"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/vgpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/configmap"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	licensePollInterval = 30 * time.Second
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("vGPU Licensing", Ordered, Label(tsparams.LabelSuite, "vgpu-licensing"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.DisruptsOperands, cilabels.VGPU), func() {
+	var (
+		gpuNodes           []*nodes.Builder
+		licensingConfigMap *configmap.Builder
+		previousSpec       *nvidiagpuv1.ClusterPolicySpec
+		guestDriverReady   bool
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting vGPU Licensing test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.VGPUGuestDriverRepository == "" || nvidiaGPUConfig.VGPUGuestDriverVersion == "" ||
+			nvidiaGPUConfig.VGPULicenseTokenFile == "" {
+			Skip("NVIDIAGPU_VGPU_GUEST_DRIVER_REPOSITORY, NVIDIAGPU_VGPU_GUEST_DRIVER_VERSION and " +
+				"NVIDIAGPU_VGPU_LICENSE_TOKEN_FILE must be set to run the vGPU licensing tests")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		By("Find the GPU worker nodes")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		var err error
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		clientToken, err := os.ReadFile(nvidiaGPUConfig.VGPULicenseTokenFile)
+		Expect(err).ToNot(HaveOccurred(), "error reading the client configuration token %s: %v",
+			nvidiaGPUConfig.VGPULicenseTokenFile, err)
+
+		By(fmt.Sprintf("Create the licensing ConfigMap %s", vgpu.LicensingConfigMapName))
+		licensingConfigMap, err = vgpu.CreateLicensingConfigMap(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace,
+			string(clientToken), nvidiaGPUConfig.VGPULicenseFeatureType)
+		Expect(err).ToNot(HaveOccurred(), "error creating the licensing ConfigMap: %v", err)
+	})
+
+	AfterAll(func() {
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			} else if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				driverupgrade.DriverRolloutTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+
+		if licensingConfigMap != nil {
+			if err := licensingConfigMap.Delete(); err != nil {
+				glog.Errorf("Error deleting ConfigMap %s: %v", vgpu.LicensingConfigMapName, err)
+			}
+		}
+	})
+
+	It("Should deploy the vGPU guest driver with the licensing config", Label("vgpu-licensing-driver"), func() {
+		By(fmt.Sprintf("Set the ClusterPolicy driver to %s/%s:%s", nvidiaGPUConfig.VGPUGuestDriverRepository,
+			nvidiaGPUConfig.VGPUGuestDriverImage, nvidiaGPUConfig.VGPUGuestDriverVersion))
+		var err error
+		previousSpec, err = vgpu.EnableGuestDriverLicensing(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+			nvidiaGPUConfig.VGPUGuestDriverRepository, nvidiaGPUConfig.VGPUGuestDriverImage,
+			nvidiaGPUConfig.VGPUGuestDriverVersion, nvidiaGPUConfig.VGPUGuestDriverPullSecret)
+		Expect(err).ToNot(HaveOccurred(), "error enabling vGPU licensing in ClusterPolicy: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+			driverupgrade.DriverRolloutTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+		for _, gpuNode := range gpuNodes {
+			By(fmt.Sprintf("Check node %s runs the vGPU guest driver", gpuNode.Object.Name))
+			_, _, err := vgpu.NodeLicenseStatus(inittools.APIClient, gpuNode.Object.Name)
+			Expect(err).ToNot(HaveOccurred(), "node %s does not run the vGPU guest driver: %v",
+				gpuNode.Object.Name, err)
+		}
+
+		guestDriverReady = true
+	})
+
+	It("Should license the vGPU of every GPU node within the grace period", Label("vgpu-licensing-state"), func() {
+		if !guestDriverReady {
+			Skip("The vGPU guest driver is not deployed")
+		}
+
+		var unlicensedNodes []string
+
+		for _, gpuNode := range gpuNodes {
+			nodeName := gpuNode.Object.Name
+
+			By(fmt.Sprintf("Wait up to %s for the vGPU of node %s to be licensed", nvidiaGPUConfig.VGPULicenseTimeout,
+				nodeName))
+			err := vgpu.WaitForLicensed(inittools.APIClient, nodeName, licensePollInterval,
+				nvidiaGPUConfig.VGPULicenseTimeout)
+			if err == nil {
+				continue
+			}
+
+			glog.Errorf("vGPU of node %s is not licensed: %v", nodeName, err)
+			unlicensedNodes = append(unlicensedNodes, nodeName)
+
+			diagnostics := vgpu.LicensingDiagnostics(inittools.APIClient, nodeName)
+			glog.V(gpuparams.GpuLogLevel).Info(diagnostics)
+
+			if err := reporter.AppendPodExecLog(diagnostics); err != nil {
+				glog.Errorf("Error saving node %s licensing diagnostics: %v", nodeName, err)
+			}
+		}
+
+		Expect(unlicensedNodes).To(BeEmpty(), "vGPU of nodes %v not licensed within %s", unlicensedNodes,
+			nvidiaGPUConfig.VGPULicenseTimeout)
+	})
+})
@@ -0,0 +1,229 @@
+package vgpu
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/vgpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/kubevirt"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// TestNamespace is the namespace where the vGPU VirtualMachine will run
+	TestNamespace = "test-vgpu"
+	// VMName is the name of the VirtualMachine with a vGPU attached
+	VMName = "vgpu-test-vm"
+	// VMMemory is the memory requested by the VirtualMachine
+	VMMemory = "4Gi"
+
+	clusterPolicyReadyTimeout = 20 * time.Minute
+	allocatablePollInterval   = 30 * time.Second
+	allocatableTimeout        = 15 * time.Minute
+	vmReadyTimeout            = 10 * time.Minute
+	guestCheckTimeout         = 10 * time.Minute
+	guestCheckPollInterval    = 20 * time.Second
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("vGPU", Ordered, Label(tsparams.LabelSuite, "vgpu"), cilabels.Spec(
+	cilabels.Extended, cilabels.Long, cilabels.DisruptsOperands, cilabels.VGPU, cilabels.BareMetal), func() {
+	var (
+		nsBuilder       *namespace.Builder
+		vmBuilder       *kubevirt.Builder
+		vgpuNode        *nodes.Builder
+		previousSpec    *nvidiagpuv1.ClusterPolicySpec
+		hcoUpdated      bool
+		vgpuResourceKey string
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting vGPU test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		if nvidiaGPUConfig.VGPUManagerRepository == "" || nvidiaGPUConfig.VGPUManagerVersion == "" ||
+			nvidiaGPUConfig.VGPUMdevType == "" || nvidiaGPUConfig.VGPUVMImage == "" {
+			Skip("NVIDIAGPU_VGPU_MANAGER_REPOSITORY, NVIDIAGPU_VGPU_MANAGER_VERSION, NVIDIAGPU_VGPU_MDEV_TYPE " +
+				"and NVIDIAGPU_VGPU_VM_IMAGE must be set to run the vGPU tests")
+		}
+
+		if _, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName); err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		hcoBuilder, err := kubevirt.PullHyperConverged(inittools.APIClient, kubevirt.HyperConvergedName,
+			kubevirt.HyperConvergedNamespace)
+		if err != nil {
+			Skip(fmt.Sprintf("OpenShift Virtualization is not installed: %v", err))
+		}
+
+		By("Find a GPU worker node to run vGPU workloads")
+		nodeSelector := labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err := nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		vgpuNode = gpuNodes[0]
+		vgpuResourceKey = vgpu.ResourceName(nvidiaGPUConfig.VGPUMdevType)
+		glog.V(gpuparams.GpuLogLevel).Infof("Using node '%s' for vGPU tests with mediated device type '%s'",
+			vgpuNode.Object.Name, nvidiaGPUConfig.VGPUMdevType)
+
+		By("Create the vGPU test namespace")
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+
+		By("Enable sandbox workloads and the vGPU manager in the ClusterPolicy")
+		previousSpec, err = vgpu.EnableVGPUInClusterPolicy(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+			nvidiaGPUConfig.VGPUManagerRepository, nvidiaGPUConfig.VGPUManagerVersion)
+		Expect(err).ToNot(HaveOccurred(), "error enabling vGPU in ClusterPolicy: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		By(fmt.Sprintf("Label node %s with %s=%s", vgpuNode.Object.Name, vgpu.WorkloadConfigLabel,
+			vgpu.WorkloadConfigVMVGPU))
+		err = nodes.SetLabel(inittools.APIClient, vgpuNode.Object.Name, vgpu.WorkloadConfigLabel,
+			vgpu.WorkloadConfigVMVGPU)
+		Expect(err).ToNot(HaveOccurred(), "error labeling node %s: %v", vgpuNode.Object.Name, err)
+
+		if nvidiaGPUConfig.VGPUConfig != "" {
+			err = nodes.SetLabel(inittools.APIClient, vgpuNode.Object.Name, vgpu.VGPUConfigLabel,
+				nvidiaGPUConfig.VGPUConfig)
+			Expect(err).ToNot(HaveOccurred(), "error labeling node %s: %v", vgpuNode.Object.Name, err)
+		}
+
+		err = wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName, clusterPolicyReadyTimeout)
+		Expect(err).ToNot(HaveOccurred(), "error waiting for ClusterPolicy to be ready: %v", err)
+
+		By(fmt.Sprintf("Permit mediated device '%s' in the HyperConverged CR", nvidiaGPUConfig.VGPUMdevType))
+		_, err = hcoBuilder.WithPermittedMediatedDevice(nvidiaGPUConfig.VGPUMdevType, vgpuResourceKey).Update()
+		Expect(err).ToNot(HaveOccurred(), "error updating HyperConverged: %v", err)
+		hcoUpdated = true
+	})
+
+	AfterAll(func() {
+		if vmBuilder != nil {
+			if err := vmBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting VirtualMachine %s: %v", VMName, err)
+			}
+		}
+
+		if hcoUpdated {
+			hcoBuilder, err := kubevirt.PullHyperConverged(inittools.APIClient, kubevirt.HyperConvergedName,
+				kubevirt.HyperConvergedNamespace)
+			if err == nil {
+				_, err = hcoBuilder.WithoutPermittedMediatedDevice(vgpuResourceKey).Update()
+			}
+
+			if err != nil {
+				glog.Errorf("Error removing permitted mediated device from HyperConverged: %v", err)
+			}
+		}
+
+		if vgpuNode != nil {
+			for _, label := range []string{vgpu.WorkloadConfigLabel, vgpu.VGPUConfigLabel} {
+				if err := nodes.SetLabel(inittools.APIClient, vgpuNode.Object.Name, label, ""); err != nil {
+					glog.Errorf("Error removing label %s from node %s: %v", label, vgpuNode.Object.Name, err)
+				}
+			}
+		}
+
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			} else if err := wait.ClusterPolicyReady(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				clusterPolicyReadyTimeout); err != nil {
+				glog.Errorf("Error waiting for ClusterPolicy to be ready: %v", err)
+			}
+		}
+
+		if nsBuilder != nil {
+			if err := nsBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should advertise the vGPU mediated device on the node", Label("vgpu-resource"), func() {
+		By(fmt.Sprintf("Wait for node %s to advertise %s", vgpuNode.Object.Name, vgpuResourceKey))
+		err := wait.NodeAllocatable(inittools.APIClient, vgpuNode.Object.Name,
+			corev1.ResourceName(vgpuResourceKey), 1, allocatablePollInterval, allocatableTimeout)
+		Expect(err).ToNot(HaveOccurred(), "node %s did not advertise %s: %v", vgpuNode.Object.Name,
+			vgpuResourceKey, err)
+	})
+
+	It("Should run a VirtualMachine whose guest sees the vGPU", Label("vgpu-vm"), func() {
+		By(fmt.Sprintf("Create VirtualMachine %s with a %s vGPU", VMName, nvidiaGPUConfig.VGPUMdevType))
+		var err error
+		vmBuilder, err = kubevirt.NewBuilder(inittools.APIClient, VMName, TestNamespace,
+			nvidiaGPUConfig.VGPUVMImage, VMMemory).
+			WithGPU("vgpu1", vgpuResourceKey).
+			WithCloudInitUserData(vgpu.GuestCheckUserData()).
+			WithNodeSelector(map[string]string{"kubernetes.io/hostname": vgpuNode.Object.Name}).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating VirtualMachine %s: %v", VMName, err)
+
+		By("Wait for the VirtualMachine to be ready")
+		err = await.Match(context.TODO(), fmt.Sprintf("VirtualMachine %s to be ready", VMName),
+			guestCheckPollInterval, vmReadyTimeout, func(context.Context) (interface{}, error) {
+				return vmBuilder.IsReady()
+			}, BeTrue())
+		Expect(err).ToNot(HaveOccurred(), "VirtualMachine %s is not ready: %v", VMName, err)
+
+		By("Check nvidia-smi output from the guest serial console")
+		var consoleLog string
+		err = await.Match(context.TODO(), "the guest nvidia-smi check to complete",
+			guestCheckPollInterval, guestCheckTimeout, func(context.Context) (interface{}, error) {
+				consoleLog, err = vgpu.GetGuestConsoleLog(inittools.APIClient, VMName, TestNamespace)
+				if err != nil {
+					return nil, err
+				}
+
+				return strings.Contains(consoleLog, vgpu.GuestCheckEndMarker), nil
+			}, BeTrue())
+		Expect(err).ToNot(HaveOccurred(), "guest nvidia-smi check did not complete: %v", err)
+
+		_, guestOutput, _ := strings.Cut(consoleLog, vgpu.GuestCheckBeginMarker)
+		glog.V(gpuparams.GpuLogLevel).Infof("Guest nvidia-smi output:\n%s", guestOutput)
+
+		Expect(guestOutput).To(ContainSubstring(vgpu.GuestCheckEndMarker+" rc=0"),
+			"nvidia-smi failed in the guest")
+		Expect(guestOutput).To(ContainSubstring("GPU 0:"), "guest did not list the vGPU")
+	})
+})
@@ -0,0 +1,453 @@
+package xid
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	nvidiagpuv1 "github.com/NVIDIA/gpu-operator/api/nvidia/v1"
+	"github.com/golang/glog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/chaos"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/disconnected"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/get"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpuparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/gpusettings"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/inittools"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/nvidiagpuconfig"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/reporter"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/soak"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/taints"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/tsparams"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/wait"
+	"github.com/rh-ecosystem-edge/nvidia-ci/internal/xid"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/await"
+	cilabels "github.com/rh-ecosystem-edge/nvidia-ci/pkg/labels"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/namespace"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nodes"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/nvidiagpu"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/pod"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/proxy"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/cudasamples"
+	"github.com/rh-ecosystem-edge/nvidia-ci/pkg/workloads/gpuburn"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// TestNamespace is the namespace where the XID workloads run
+	TestNamespace = "test-gpu-xid"
+	// BurnJobName is the name of the gpu-burn Job monitored for XID errors
+	BurnJobName = "xid-burn"
+	// FaultPodName is the name of the pod provoking an XID error
+	FaultPodName = "xid-fault"
+	// NodeJobName is the name of the vectorAdd Job requesting all the GPUs of the faulted node
+	NodeJobName = "xid-node-gpus"
+	// HealthyJobName is the name of the vectorAdd Job requesting the healthy GPUs of the faulted node
+	HealthyJobName = "xid-healthy-gpus"
+
+	pollInterval          = 10 * time.Second
+	faultPodTimeout       = 10 * time.Minute
+	xidLoggedTimeout      = 2 * time.Minute
+	allocatableTimeout    = 5 * time.Minute
+	devicePluginTimeout   = 10 * time.Minute
+	unschedulableTimeout  = 2 * time.Minute
+	sampleCompleteTimeout = 5 * time.Minute
+	namespaceTimeout      = 5 * time.Minute
+	insufficientGPUs      = "Insufficient nvidia.com/gpu"
+)
+
+var (
+	nvidiaGPUConfig *nvidiagpuconfig.NvidiaGPUConfig
+)
+
+var _ = Describe("GPU XID errors", Ordered, Label(tsparams.LabelSuite, "xid"), cilabels.Spec(
+	cilabels.Extended, cilabels.Medium, cilabels.DisruptsOperands), func() {
+	var (
+		gpuNodes     []*nodes.Builder
+		nodeSelector labels.Set
+		nsBuilder    *namespace.Builder
+		jobBuilder   *gpuburn.Builder
+		previousSpec *nvidiagpuv1.ClusterPolicySpec
+		faultImage   string
+	)
+	nvidiaGPUConfig = nvidiagpuconfig.NewNvidiaGPUConfig()
+
+	// provokeXID runs the fault pod on the node and returns the recoverable XID error the driver logged for it.
+	provokeXID := func(nodeName string) xid.Event {
+		since := time.Now().Add(-time.Second)
+
+		By(fmt.Sprintf("Provoke an XID error with pod %s on node %s", FaultPodName, nodeName))
+		faultPod := xid.CreateFaultPod(FaultPodName, TestNamespace, faultImage, nodeName)
+		proxy.Inject(inittools.APIClient, &faultPod.Spec)
+		_, err := inittools.APIClient.Pods(TestNamespace).Create(context.TODO(), faultPod, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred(), "error creating pod %s: %v", FaultPodName, err)
+
+		var podBuilder *pod.Builder
+
+		defer func() {
+			if podBuilder == nil {
+				return
+			}
+
+			if _, err := podBuilder.DeleteAndWait(namespaceTimeout); err != nil {
+				glog.Errorf("Error deleting pod %s: %v", FaultPodName, err)
+			}
+		}()
+
+		err = await.Match(context.TODO(), fmt.Sprintf("pod %s to complete", FaultPodName), pollInterval,
+			faultPodTimeout, func(context.Context) (interface{}, error) {
+				podBuilder, err = pod.Pull(inittools.APIClient, FaultPodName, TestNamespace)
+				if err != nil {
+					return "", err
+				}
+
+				return podBuilder.Object.Status.Phase, nil
+			}, BeElementOf(corev1.PodSucceeded, corev1.PodFailed))
+		Expect(err).ToNot(HaveOccurred(), "pod %s did not complete: %v", FaultPodName, err)
+
+		output, err := podBuilder.GetFullLog(xid.FaultContainerName)
+		Expect(err).ToNot(HaveOccurred(), "error getting pod %s log: %v", FaultPodName, err)
+		AddReportEntry("XID fault pod "+nodeName, output)
+		Expect(podBuilder.Object.Status.Phase).To(Equal(corev1.PodSucceeded),
+			"the fault pod kernel did not fault with an illegal address: %s", output)
+
+		By(fmt.Sprintf("Wait for the driver to log the XID error in the kernel log of node %s", nodeName))
+
+		var provoked xid.Event
+
+		err = await.For(context.TODO(), fmt.Sprintf("node %s to log an XID error of %v", nodeName, xid.FaultXIDs),
+			pollInterval, xidLoggedTimeout, func(ctx context.Context) (interface{}, bool, error) {
+				events, err := xid.NodeEvents(inittools.APIClient, nodeName, since)
+				if err != nil {
+					return nil, false, err
+				}
+
+				for _, event := range events {
+					for _, faultXID := range xid.FaultXIDs {
+						if event.XID == faultXID {
+							provoked = event
+
+							return event.String(), true, nil
+						}
+					}
+				}
+
+				return fmt.Sprintf("%d XID errors", len(events)), false, nil
+			})
+		Expect(err).ToNot(HaveOccurred(), "the XID error of pod %s was not logged: %v", FaultPodName, err)
+
+		glog.V(gpuparams.GpuLogLevel).Infof("Provoked %s", provoked)
+		AddReportEntry("XID "+nodeName, provoked.String())
+
+		return provoked
+	}
+
+	// runVectorAdd runs a vectorAdd Job on the GPUs of the node and returns its builder once created.
+	runVectorAdd := func(jobName, nodeName string, gpus int) *cudasamples.Builder {
+		sampleBuilder, err := cudasamples.NewBuilder(inittools.APIClient, jobName, TestNamespace,
+			cudasamples.VectorAdd, disconnected.Image(cudasamples.VectorAddImage)).
+			WithGPUs(gpus).
+			WithNodeSelector(map[string]string{corev1.LabelHostname: nodeName}).
+			Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating Job %s: %v", jobName, err)
+
+		DeferCleanup(func() {
+			if err := sampleBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting Job %s: %v", jobName, err)
+			}
+		})
+
+		return sampleBuilder
+	}
+
+	BeforeAll(func() {
+		glog.V(gpuparams.GpuLogLevel).Info("Starting GPU XID errors test suite")
+
+		if nvidiaGPUConfig == nil {
+			Skip("Failed to load the NVIDIAGPU_ environment configuration")
+		}
+
+		clusterPolicyBuilder, err := nvidiagpu.Pull(inittools.APIClient, nvidiagpu.ClusterPolicyName)
+		if err != nil {
+			Skip(fmt.Sprintf("ClusterPolicy '%s' not found, GPU operator must be deployed first: %v",
+				nvidiagpu.ClusterPolicyName, err))
+		}
+
+		if !clusterPolicyBuilder.Definition.Spec.Driver.IsEnabled() {
+			Skip(fmt.Sprintf("The driver is disabled in ClusterPolicy '%s', the XID errors being read from the "+
+				"driver container", nvidiagpu.ClusterPolicyName))
+		}
+
+		nodeSelector = labels.Set{"nvidia.com/gpu.present": "true"}
+		for key, value := range inittools.GeneralConfig.WorkerLabelMap {
+			nodeSelector[key] = value
+		}
+
+		gpuNodes, err = nodes.List(inittools.APIClient, metav1.ListOptions{LabelSelector: nodeSelector.String()})
+		Expect(err).ToNot(HaveOccurred(), "error listing GPU nodes: %v", err)
+
+		if len(gpuNodes) == 0 {
+			Skip("No GPU worker node found")
+		}
+
+		By("Check the CUDA image of the fault pod is reachable")
+		Expect(disconnected.CheckImages(nvidiaGPUConfig.XIDCUDAImage)).ToNot(HaveOccurred(),
+			"the CUDA image is not reachable through the mirrors")
+		faultImage = disconnected.Image(nvidiaGPUConfig.XIDCUDAImage)
+
+		nsBuilder = namespace.NewBuilder(inittools.APIClient, TestNamespace)
+		if !nsBuilder.Exists() {
+			_, err := nsBuilder.Create()
+			Expect(err).ToNot(HaveOccurred(), "error creating namespace %s: %v", TestNamespace, err)
+		}
+	})
+
+	AfterAll(func() {
+		if jobBuilder != nil {
+			if err := jobBuilder.Delete(); err != nil {
+				glog.Errorf("Error deleting gpu-burn Job %s: %v", BurnJobName, err)
+			}
+		}
+
+		if previousSpec != nil {
+			By("Restore the original ClusterPolicy spec")
+			if err := nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName,
+				previousSpec); err != nil {
+				glog.Errorf("Error restoring ClusterPolicy: %v", err)
+			}
+		}
+
+		if nsBuilder != nil && nsBuilder.Exists() {
+			if err := nsBuilder.DeleteAndWait(namespaceTimeout); err != nil {
+				glog.Errorf("Error deleting namespace %s: %v", TestNamespace, err)
+			}
+		}
+	})
+
+	It("Should burn the GPUs of every GPU node without XID error", Label("xid-stress"), func() {
+		clusterArch, err := get.GetClusterArchitecture(inittools.APIClient, nodeSelector)
+		Expect(err).ToNot(HaveOccurred(), "error getting the GPU nodes architecture: %v", err)
+
+		burnImage, err := gpuburn.Images.Image(clusterArch)
+		Expect(err).ToNot(HaveOccurred(), "error selecting the gpu-burn image: %v", err)
+
+		Expect(disconnected.CheckImages(burnImage)).ToNot(HaveOccurred(),
+			"the gpu-burn image is not reachable through the mirrors")
+		reporter.RecordImageDigests(clusterArch, burnImage)
+		burnImage = disconnected.Image(burnImage)
+
+		gpusPerNode := gpuburn.GPUsPerNode(gpuNodes)
+
+		if gpusPerNode == 0 {
+			Skip("A GPU node does not report its GPU count")
+		}
+
+		dcgmPods, err := soak.DCGMPods(inittools.APIClient)
+		Expect(err).ToNot(HaveOccurred(), "error listing the DCGM pods: %v", err)
+
+		lastXIDs := map[string]map[int]int{}
+		for _, dcgmPod := range dcgmPods {
+			lastXIDs[dcgmPod.Object.Spec.NodeName], err = xid.DCGMLastXIDs(dcgmPod)
+			Expect(err).ToNot(HaveOccurred(), "error querying the DCGM XID errors: %v", err)
+		}
+
+		since := time.Now()
+
+		By(fmt.Sprintf("Burn %d GPU(s) of %d node(s) for %s", gpusPerNode, len(gpuNodes),
+			nvidiaGPUConfig.XIDBurnDuration))
+		jobBuilder = gpuburn.NewAcrossNodes(inittools.APIClient, BurnJobName, TestNamespace, burnImage, gpuNodes).
+			WithDuration(nvidiaGPUConfig.XIDBurnDuration).
+			WithNodeSelector(nodeSelector)
+
+		_, err = jobBuilder.Create()
+		Expect(err).ToNot(HaveOccurred(), "error creating gpu-burn Job %s: %v", BurnJobName, err)
+
+		waitErr := jobBuilder.WaitUntilComplete(nvidiaGPUConfig.XIDBurnDuration + nvidiagpu.BurnPodCreationTimeout)
+
+		By("Check the kernel logs and DCGM report no XID error")
+		var xidErrors []string
+
+		for _, gpuNode := range gpuNodes {
+			events, err := xid.NodeEvents(inittools.APIClient, gpuNode.Object.Name, since)
+			Expect(err).ToNot(HaveOccurred(), "error reading the XID errors of node %s: %v", gpuNode.Object.Name,
+				err)
+
+			for _, event := range events {
+				xidErrors = append(xidErrors, event.String())
+			}
+		}
+
+		for _, dcgmPod := range dcgmPods {
+			nodeName := dcgmPod.Object.Spec.NodeName
+
+			after, err := xid.DCGMLastXIDs(dcgmPod)
+			Expect(err).ToNot(HaveOccurred(), "error querying the DCGM XID errors of node %s: %v", nodeName, err)
+
+			for gpu, lastXID := range after {
+				if lastXID != 0 && lastXID != lastXIDs[nodeName][gpu] {
+					xidErrors = append(xidErrors, fmt.Sprintf("node %s DCGM GPU %d XID %d", nodeName, gpu, lastXID))
+				}
+			}
+		}
+
+		if len(xidErrors) > 0 {
+			AddReportEntry("XID errors", strings.Join(xidErrors, "\n"))
+		}
+
+		Expect(waitErr).ToNot(HaveOccurred(), "gpu-burn Job %s did not complete: %v", BurnJobName, waitErr)
+		Expect(xidErrors).To(BeEmpty(), "GPUs hit XID errors during gpu-burn")
+	})
+
+	It("Should detect a recoverable XID error and keep the GPU schedulable", Label("xid-recoverable"), func() {
+		nodeName := gpuNodes[0].Object.Name
+
+		capacity := nodeGPUCapacity(nodeName)
+		if capacity == 0 {
+			Skip(fmt.Sprintf("Node %s has no allocatable nvidia.com/gpu", nodeName))
+		}
+
+		dcgmPod, dcgmErr := gpusettings.NodeDCGMPod(inittools.APIClient, nodeName)
+
+		provoked := provokeXID(nodeName)
+		Expect(xid.Recoverable(provoked.XID)).To(BeTrue(), "XID %d is not an application XID", provoked.XID)
+
+		if dcgmErr != nil {
+			glog.V(gpuparams.GpuLogLevel).Infof("Not checking the DCGM XID errors: %v", dcgmErr)
+		} else {
+			By(fmt.Sprintf("Check DCGM reports XID %d on node %s", provoked.XID, nodeName))
+			err := await.For(context.TODO(), fmt.Sprintf("DCGM to report XID %d on node %s", provoked.XID, nodeName),
+				pollInterval, xidLoggedTimeout, func(ctx context.Context) (interface{}, bool, error) {
+					lastXIDs, err := xid.DCGMLastXIDs(dcgmPod)
+					if err != nil {
+						return nil, false, err
+					}
+
+					for _, lastXID := range lastXIDs {
+						if lastXID == provoked.XID {
+							return lastXIDs, true, nil
+						}
+					}
+
+					return lastXIDs, false, nil
+				})
+			Expect(err).ToNot(HaveOccurred(), "DCGM did not report the XID error: %v", err)
+		}
+
+		By(fmt.Sprintf("Run vectorAdd on the %d GPU(s) of node %s", capacity, nodeName))
+		sampleBuilder := runVectorAdd(NodeJobName, nodeName, capacity)
+		Expect(sampleBuilder.WaitUntilComplete(sampleCompleteTimeout)).To(Succeed(),
+			"the GPUs of node %s are not usable after the recoverable XID error", nodeName)
+	})
+
+	It("Should mark the GPU unhealthy on an XID error with its health check enabled and schedule the pending pods "+
+		"once the GPU is restored", Label("xid-health-check"), func() {
+		nodeName := gpuNodes[0].Object.Name
+
+		capacity := nodeGPUCapacity(nodeName)
+		if capacity == 0 {
+			Skip(fmt.Sprintf("Node %s has no allocatable nvidia.com/gpu", nodeName))
+		}
+
+		By(fmt.Sprintf("Enable the device plugin health checks of XID errors %v", xid.FaultXIDs))
+		previousUID := devicePluginUID(nodeName)
+
+		var err error
+		previousSpec, err = xid.EnableHealthChecks(inittools.APIClient, nvidiagpu.ClusterPolicyName, xid.FaultXIDs)
+		Expect(err).ToNot(HaveOccurred(), "error enabling the device plugin health checks: %v", err)
+		DeferCleanup(nvidiagpu.RegisterRestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec))
+
+		awaitDevicePluginRestarted(nodeName, previousUID, capacity)
+
+		provoked := provokeXID(nodeName)
+
+		By(fmt.Sprintf("Wait for the device plugin to withdraw the GPU %s of node %s", provoked.PCIBusID, nodeName))
+		err = await.Match(context.TODO(), fmt.Sprintf("node %s to advertise %d nvidia.com/gpu", nodeName,
+			capacity-1), pollInterval, allocatableTimeout, func(context.Context) (interface{}, error) {
+			return nodeAllocatableGPUs(nodeName)
+		}, Equal(capacity-1))
+		Expect(err).ToNot(HaveOccurred(), "the device plugin did not mark the GPU unhealthy: %v", err)
+
+		By(fmt.Sprintf("Check a pod requesting the %d GPU(s) of node %s is not scheduled", capacity, nodeName))
+		nodeJob := runVectorAdd(NodeJobName, nodeName, capacity)
+
+		err = await.Match(context.TODO(), fmt.Sprintf("Job %s pod to be unschedulable", NodeJobName), pollInterval,
+			unschedulableTimeout, func(context.Context) (interface{}, error) {
+				return taints.UnschedulableMessage(inittools.APIClient, TestNamespace, NodeJobName)
+			}, ContainSubstring(insufficientGPUs))
+		Expect(err).ToNot(HaveOccurred(), "Job %s pod was scheduled on the unhealthy GPU: %v", NodeJobName, err)
+
+		if capacity > 1 {
+			By(fmt.Sprintf("Run vectorAdd on the %d healthy GPU(s) of node %s", capacity-1, nodeName))
+			healthyJob := runVectorAdd(HealthyJobName, nodeName, capacity-1)
+			Expect(healthyJob.WaitUntilComplete(sampleCompleteTimeout)).To(Succeed(),
+				"the healthy GPUs of node %s are not usable", nodeName)
+		}
+
+		By("Restore the device plugin health checks")
+		previousUID = devicePluginUID(nodeName)
+
+		err = nvidiagpu.RestoreSpec(inittools.APIClient, nvidiagpu.ClusterPolicyName, previousSpec)
+		Expect(err).ToNot(HaveOccurred(), "error restoring ClusterPolicy: %v", err)
+
+		previousSpec = nil
+
+		awaitDevicePluginRestarted(nodeName, previousUID, capacity)
+
+		By(fmt.Sprintf("Check the pending Job %s runs once the GPU is restored", NodeJobName))
+		Expect(nodeJob.WaitUntilComplete(sampleCompleteTimeout)).To(Succeed(),
+			"Job %s did not run once the GPU of node %s was restored", NodeJobName, nodeName)
+	})
+})
+
+// awaitDevicePluginRestarted waits for the device plugin pod of the node to be replaced and to advertise all the
+// GPUs of the node again.
+func awaitDevicePluginRestarted(nodeName string, previousUID types.UID, capacity int) {
+	_, err := chaos.NodePodReplaced(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace, xid.DevicePluginPodLabel,
+		nodeName, previousUID, pollInterval, devicePluginTimeout)
+	Expect(err).ToNot(HaveOccurred(), "the device plugin pod of node %s was not restarted: %v", nodeName, err)
+
+	err = wait.NodeAllocatable(inittools.APIClient, nodeName, cudasamples.GPUResource, int64(capacity), pollInterval,
+		allocatableTimeout)
+	Expect(err).ToNot(HaveOccurred(), "node %s does not advertise its GPUs: %v", nodeName, err)
+}
+
+// devicePluginUID returns the UID of the device plugin pod of the node.
+func devicePluginUID(nodeName string) types.UID {
+	podUIDs, err := chaos.NodePodUIDs(inittools.APIClient, nvidiagpu.NvidiaGPUNamespace, xid.DevicePluginPodLabel,
+		nodeName)
+	Expect(err).ToNot(HaveOccurred(), "error listing the device plugin pods of node %s: %v", nodeName, err)
+	Expect(podUIDs).To(HaveLen(1), "node %s does not run a single device plugin pod", nodeName)
+
+	for podUID := range podUIDs {
+		return podUID
+	}
+
+	return ""
+}
+
+// nodeGPUCapacity returns the nvidia.com/gpu capacity of the node.
+func nodeGPUCapacity(nodeName string) int {
+	nodeBuilder, err := nodes.Pull(inittools.APIClient, nodeName)
+	Expect(err).ToNot(HaveOccurred(), "error pulling node %s: %v", nodeName, err)
+
+	capacity := nodeBuilder.Object.Status.Capacity[cudasamples.GPUResource]
+
+	return int(capacity.Value())
+}
+
+// nodeAllocatableGPUs returns the allocatable nvidia.com/gpu of the node.
+func nodeAllocatableGPUs(nodeName string) (int, error) {
+	nodeBuilder, err := nodes.Pull(inittools.APIClient, nodeName)
+	if err != nil {
+		return 0, err
+	}
+
+	allocatable := nodeBuilder.Object.Status.Allocatable[cudasamples.GPUResource]
+
+	return int(allocatable.Value()), nil
+}